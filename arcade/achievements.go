@@ -0,0 +1,205 @@
+package arcade
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+const ACHIEVEMENTS_FILENAME = "achievements.json"
+
+// Achievement is something to aim for in online games, unlocked once and kept
+// for good.
+type Achievement struct {
+	ID          string
+	Name        string
+	Description string
+
+	// The game it's for, or empty for any
+	GameType string
+
+	// Drawn in the achievements grid once it's unlocked
+	Art []string
+
+	// Whether the result, with our progress so far counted, unlocks it
+	unlocks func(p *AchievementProgress, result MatchResult, me string) bool
+}
+
+var achievements = []*Achievement{
+	{
+		ID:          "first_win",
+		Name:        "First Win",
+		Description: "Win an online game",
+		Art:         []string{"  ___  ", " |   | ", "  \\_/  ", "  _|_  "},
+		unlocks: func(p *AchievementProgress, result MatchResult, me string) bool {
+			return result.Winner == me
+		},
+	},
+	{
+		ID:          "streak_10",
+		Name:        "On a Roll",
+		Description: "Win 10 online games in a row",
+		Art:         []string{" )  )  ", "(  (   ", " )  )  ", "=10==> "},
+		unlocks: func(p *AchievementProgress, result MatchResult, me string) bool {
+			return p.Streak >= 10
+		},
+	},
+	{
+		ID:          "regular",
+		Name:        "Regular",
+		Description: "Play 50 online games",
+		Art:         []string{" _____ ", "| 50  |", "|games|", "|_____|"},
+		unlocks: func(p *AchievementProgress, result MatchResult, me string) bool {
+			return p.Played >= 50
+		},
+	},
+	{
+		ID:          "pong_shutout",
+		Name:        "Perfect Shutout",
+		Description: "Win a Pong game without losing a life",
+		GameType:    Pong,
+		Art:         []string{"|     |", "|  o  |", "|     |", " 3 - 0 "},
+		unlocks: func(p *AchievementProgress, result MatchResult, me string) bool {
+			return result.Winner == me && result.Scores[me] == PongStartingLives
+		},
+	},
+	{
+		ID:          "tron_win",
+		Name:        "Last Cycle Standing",
+		Description: "Win a game of Tron",
+		GameType:    Tron,
+		Art:         []string{" ____  ", "|    | ", "|  __|>", "|_|    "},
+		unlocks: func(p *AchievementProgress, result MatchResult, me string) bool {
+			return result.Winner == me
+		},
+	},
+}
+
+// AchievementProgress is what's been unlocked, and what's counted towards the
+// rest. It's kept in the config directory, so it's only ours.
+type AchievementProgress struct {
+	// When each achievement was unlocked, by ID
+	Unlocked map[string]time.Time `json:"unlocked"`
+
+	Played int `json:"played"`
+	Streak int `json:"streak"`
+}
+
+// record counts the result towards our progress, returning the achievements
+// it unlocked.
+func (p *AchievementProgress) record(result MatchResult, me string, now time.Time) []*Achievement {
+	if p.Unlocked == nil {
+		p.Unlocked = make(map[string]time.Time)
+	}
+
+	p.Played++
+
+	if result.Winner == me {
+		p.Streak++
+	} else {
+		p.Streak = 0
+	}
+
+	var unlocked []*Achievement
+
+	for _, a := range achievements {
+		if _, ok := p.Unlocked[a.ID]; ok || (a.GameType != "" && a.GameType != result.GameType) {
+			continue
+		}
+
+		if a.unlocks(p, result, me) {
+			p.Unlocked[a.ID] = now
+			unlocked = append(unlocked, a)
+		}
+	}
+
+	return unlocked
+}
+
+// Achievements keeps our progress, saving it as games end.
+type Achievements struct {
+	mu       sync.Mutex
+	progress AchievementProgress
+}
+
+func achievementsPath() (string, error) {
+	dir, err := configDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, ACHIEVEMENTS_FILENAME), nil
+}
+
+// LoadAchievements reads our progress, starting from nothing if there's none
+// yet.
+func LoadAchievements() (*Achievements, error) {
+	a := &Achievements{}
+	file, err := achievementsPath()
+
+	if err != nil {
+		return a, err
+	}
+
+	data, err := os.ReadFile(file)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return a, nil
+	} else if err != nil {
+		return a, err
+	}
+
+	return a, json.Unmarshal(data, &a.progress)
+}
+
+func (a *Achievements) save() error {
+	file, err := achievementsPath()
+
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(a.progress, "", " ")
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, data, 0644)
+}
+
+// Record counts a game we played, saving our progress, and returns the
+// achievements it unlocked.
+func (a *Achievements) Record(result MatchResult, me string) ([]*Achievement, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	unlocked := a.progress.record(result, me, time.Now())
+	return unlocked, a.save()
+}
+
+// Unlocked returns when the achievement was unlocked, if it has been.
+func (a *Achievements) Unlocked(id string) (time.Time, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	at, ok := a.progress.Unlocked[id]
+	return at, ok
+}
+
+// Count returns how many achievements are unlocked.
+func (a *Achievements) Count() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return len(a.progress.Unlocked)
+}
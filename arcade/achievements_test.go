@@ -0,0 +1,66 @@
+package arcade
+
+import (
+	"testing"
+	"time"
+)
+
+func unlockedIDs(unlocked []*Achievement) map[string]bool {
+	ids := make(map[string]bool)
+
+	for _, a := range unlocked {
+		ids[a.ID] = true
+	}
+
+	return ids
+}
+
+func TestAchievementsUnlockOnce(t *testing.T) {
+	var p AchievementProgress
+	win := MatchResult{GameType: Tron, Winner: "me"}
+
+	if got := unlockedIDs(p.record(win, "me", time.Now())); !got["first_win"] || !got["tron_win"] {
+		t.Fatalf("first Tron win unlocked %v", got)
+	}
+
+	if got := p.record(win, "me", time.Now()); len(got) != 0 {
+		t.Errorf("unlocked %v again", unlockedIDs(got))
+	}
+}
+
+func TestAchievementStreakResetsOnLoss(t *testing.T) {
+	var p AchievementProgress
+	win := MatchResult{GameType: Tron, Winner: "me"}
+
+	for i := 0; i < 9; i++ {
+		p.record(win, "me", time.Now())
+	}
+
+	p.record(MatchResult{GameType: Tron, Winner: "them"}, "me", time.Now())
+
+	for i := 0; i < 9; i++ {
+		if got := unlockedIDs(p.record(win, "me", time.Now())); got["streak_10"] {
+			t.Fatalf("streak unlocked after %d wins since a loss", i+1)
+		}
+	}
+
+	if got := unlockedIDs(p.record(win, "me", time.Now())); !got["streak_10"] {
+		t.Error("10 wins in a row didn't unlock the streak")
+	}
+}
+
+func TestPongShutoutOnlyWithoutLosingALife(t *testing.T) {
+	var p AchievementProgress
+
+	narrow := MatchResult{GameType: Pong, Winner: "me", Scores: map[string]int{"me": 1, "them": 0}}
+
+	if got := unlockedIDs(p.record(narrow, "me", time.Now())); got["pong_shutout"] {
+		t.Error("a close win was a shutout")
+	}
+
+	perfect := MatchResult{GameType: Pong, Winner: "me", Scores: map[string]int{"me": PongStartingLives, "them": 0}}
+
+	if got := unlockedIDs(p.record(perfect, "me", time.Now())); !got["pong_shutout"] {
+		t.Error("a perfect win wasn't a shutout")
+	}
+}
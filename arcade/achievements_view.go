@@ -0,0 +1,136 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"encoding"
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	achievementCols    = 3
+	achievementWidth   = 24
+	achievementHeight  = 7
+	achievementsTopY   = 5
+	achievementDetailY = achievementsTopY + 2*achievementHeight
+)
+
+// What locked achievements show instead of their art
+var lockedAchievementArt = []string{"  ___  ", " |   | ", " | ? | ", " |___| "}
+
+var achievementsFooter = "Arrows Move    [B]ack"
+
+// AchievementsView shows every achievement in a grid, with the art of the
+// ones we've unlocked.
+type AchievementsView struct {
+	View
+	mgr *ViewManager
+
+	selected int
+}
+
+func NewAchievementsView(mgr *ViewManager) *AchievementsView {
+	return &AchievementsView{mgr: mgr}
+}
+
+func (v *AchievementsView) Init() {
+}
+
+func (v *AchievementsView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		selected := v.selected
+
+		switch evt.Key() {
+		case tcell.KeyLeft:
+			selected--
+		case tcell.KeyRight:
+			selected++
+		case tcell.KeyUp:
+			selected -= achievementCols
+		case tcell.KeyDown:
+			selected += achievementCols
+		case tcell.KeyRune:
+			if evt.Rune() == 'b' {
+				v.mgr.PopView()
+			}
+		}
+
+		if selected >= 0 && selected < len(achievements) {
+			v.selected = selected
+		}
+	}
+}
+
+func (v *AchievementsView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *AchievementsView) Render(s *Screen) {
+	width, height := s.displaySize()
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	selectedSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorLightGreen)
+	lockedSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGray)
+
+	s.DrawBlockText(CenterX, 1, sty, "ACHIEVEMENTS", false)
+
+	count := fmt.Sprintf("%d of %d unlocked", v.mgr.Achievements.Count(), len(achievements))
+	s.DrawText(layout.Center(width, count), achievementsTopY-1, sty, count)
+
+	x0 := (width - achievementCols*achievementWidth) / 2
+
+	for i, a := range achievements {
+		x := x0 + (i%achievementCols)*achievementWidth
+		y := achievementsTopY + (i/achievementCols)*achievementHeight
+
+		_, unlocked := v.mgr.Achievements.Unlocked(a.ID)
+		cellSty, art := lockedSty, lockedAchievementArt
+
+		if unlocked {
+			cellSty, art = sty, a.Art
+		}
+
+		boxSty := cellSty
+
+		if i == v.selected {
+			boxSty = selectedSty
+		}
+
+		s.DrawBox(x+1, y, x+achievementWidth-2, y+achievementHeight-1, boxSty, i == v.selected)
+
+		sprite := NewSprite(art...)
+		spriteWidth, _ := sprite.Size()
+		s.DrawSprite(x+(achievementWidth-spriteWidth)/2, y+1, cellSty, sprite)
+
+		name := layout.Truncate(a.Name, achievementWidth-4)
+		s.DrawText(x+layout.Center(achievementWidth, name), y+achievementHeight-2, cellSty, name)
+	}
+
+	a := achievements[v.selected]
+	detail := a.Description
+
+	if a.GameType != "" {
+		detail = a.GameType + ": " + detail
+	}
+
+	s.DrawText(layout.Center(width, detail), achievementDetailY, sty, detail)
+
+	status := "Locked"
+
+	if at, ok := v.mgr.Achievements.Unlocked(a.ID); ok {
+		status = "Unlocked " + at.Format("January 2, 2006")
+	}
+
+	s.DrawText(layout.Center(width, status), achievementDetailY+1, lockedSty, status)
+	s.DrawText(layout.Center(width, achievementsFooter), height-2, sty, achievementsFooter)
+}
+
+func (v *AchievementsView) Unload() {
+}
+
+func (v *AchievementsView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
@@ -0,0 +1,171 @@
+package arcade
+
+import (
+	"arcade/arcade/net"
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const adminHelp = `Commands:
+  ban <player ID | IP | CIDR> [duration] [reason]   e.g. ban 10.0.0.0/8 24h spam
+  unban <player ID | IP | CIDR>
+  bans
+  lobby-cap <n>                 lobbies each host can advertise, 0 for no limit
+  limit <message type> <n>      messages per second from each peer, 0 for no limit
+  standings
+  spectating                    games being streamed to spectators
+  diagnostics                   runtime stats and message handling times
+  help`
+
+// runAdminConsole reads the operator's commands on a distributor, until in is
+// closed.
+func (s *Server) runAdminConsole(in io.Reader, out io.Writer) {
+	scanner := bufio.NewScanner(in)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		if err := s.adminCommand(fields[0], fields[1:], out); err != nil {
+			fmt.Fprintln(out, "Error:", err)
+		}
+	}
+}
+
+func (s *Server) adminCommand(command string, args []string, out io.Writer) error {
+	switch command {
+	case "ban":
+		if len(args) == 0 {
+			return fmt.Errorf("usage: ban <player ID | IP | CIDR> [duration] [reason]")
+		}
+
+		var duration time.Duration
+		reason := args[1:]
+
+		if len(args) > 1 {
+			if d, err := time.ParseDuration(args[1]); err == nil {
+				duration = d
+				reason = args[2:]
+			}
+		}
+
+		ban, err := s.bans.Add(args[0], strings.Join(reason, " "), duration)
+
+		if err != nil && ban.Target == "" {
+			return err
+		}
+
+		s.disconnectBanned()
+		fmt.Fprintln(out, "Banned", describeBan(ban))
+
+		// Still banned for now, just not saved
+		return err
+	case "unban":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: unban <player ID | IP | CIDR>")
+		}
+
+		ok, err := s.bans.Remove(args[0])
+
+		if !ok {
+			return fmt.Errorf("%s isn't banned", args[0])
+		}
+
+		fmt.Fprintln(out, "Unbanned", args[0])
+		return err
+	case "bans":
+		bans := s.bans.Bans()
+
+		if len(bans) == 0 {
+			fmt.Fprintln(out, "No bans")
+		}
+
+		for _, ban := range bans {
+			fmt.Fprintln(out, describeBan(ban))
+		}
+	case "lobby-cap":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: lobby-cap <n>")
+		}
+
+		max, err := strconv.Atoi(args[0])
+
+		if err != nil || max < 0 {
+			return fmt.Errorf("not a number of lobbies: %s", args[0])
+		}
+
+		s.directory.SetMaxLobbiesPerHost(max)
+		fmt.Fprintln(out, "Hosts can advertise", max, "lobbies")
+	case "limit":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: limit <message type> <n>")
+		}
+
+		rate, err := strconv.ParseFloat(args[1], 64)
+
+		if err != nil {
+			return fmt.Errorf("not a rate: %s", args[1])
+		}
+
+		s.RateLimiter.Configure(map[string]float64{args[0]: rate})
+		fmt.Fprintf(out, "Limited '%s' to %g per second\n", args[0], rate)
+	case "standings":
+		for _, entry := range s.leaderboard.Standings() {
+			fmt.Fprintf(out, "%s  %d wins, %d played\n", entry.PlayerID, entry.Wins, entry.Played)
+		}
+	case "spectating":
+		for _, game := range s.spectators.list() {
+			fmt.Fprintf(out, "%s  %s (%s), %d watching\n", game.GameID, game.LobbyName, game.GameType, game.Spectators)
+		}
+	case "diagnostics":
+		stats := readRuntimeStats()
+		fmt.Fprintf(out, "%d goroutines, %s heap in use of %s, %d GCs pausing %s in all\n",
+			stats.Goroutines, formatBytes(stats.HeapAlloc), formatBytes(stats.HeapSys), stats.NumGC, stats.PauseTotal.Round(time.Microsecond))
+
+		for _, h := range arcade.Diagnostics.Timings() {
+			fmt.Fprintf(out, "%s  %d handled, %s average, %s max\n", h.Name, h.N, formatMillis(h.Total/time.Duration(h.N)), formatMillis(h.Max))
+		}
+	case "help":
+		fmt.Fprintln(out, adminHelp)
+	default:
+		return fmt.Errorf("unknown command %s, try help", command)
+	}
+
+	return nil
+}
+
+func describeBan(ban Ban) string {
+	text := ban.Target
+
+	if !ban.Until.IsZero() {
+		text += " until " + ban.Until.Format(time.RFC1123)
+	}
+
+	if ban.Reason != "" {
+		text += " (" + ban.Reason + ")"
+	}
+
+	return text
+}
+
+// disconnectBanned drops anyone connected to us who's now banned.
+func (s *Server) disconnectBanned() {
+	s.Network.ClientsRange(func(c *net.Client) bool {
+		c.RLock()
+		id, addr, neighbor := c.ID, c.Addr, c.Neighbor
+		c.RUnlock()
+
+		if _, banned := s.bans.Banned(id, addr); banned && neighbor {
+			s.Network.Disconnect(id)
+		}
+
+		return true
+	})
+}
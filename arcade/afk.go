@@ -0,0 +1,48 @@
+package arcade
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Players who haven't pressed a key in this many seconds are marked idle.
+const defaultIdleTimeout = 60
+
+// Choices for how long a lobby waits before marking players idle
+var lobbyIdleTimeouts = []string{"off", "30 sec", "60 sec", "2 min", "5 min"}
+
+// LobbyPlayerStatus is sent to the host in each player's heartbeats.
+type LobbyPlayerStatus struct {
+	LobbyID string
+	Ready   bool
+	Idle    bool
+	Name    string
+	Avatar  string
+
+	// Season badge, or empty if they haven't played this season
+	Rank string
+
+	// Region the player's in, or empty if they can't tell
+	Region string `json:",omitempty"`
+}
+
+func (s LobbyPlayerStatus) MarshalBinary() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// parseIdleTimeout returns the seconds chosen in lobbyIdleTimeouts, or 0 for
+// off.
+func parseIdleTimeout(value string) int {
+	var n int
+	var unit string
+
+	if _, err := fmt.Sscanf(value, "%d %s", &n, &unit); err != nil {
+		return 0
+	}
+
+	if unit == "min" {
+		return n * 60
+	}
+
+	return n
+}
@@ -0,0 +1,93 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"fmt"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Announcer keeps the latest announcement for accessibility mode: a short
+// sentence about what just changed, on a line of its own with the cursor
+// parked after it so that screen readers pick it up.
+type Announcer struct {
+	mu sync.RWMutex
+
+	enabled bool
+	text    string
+}
+
+func NewAnnouncer() *Announcer {
+	return &Announcer{}
+}
+
+func (a *Announcer) SetEnabled(enabled bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.enabled = enabled
+
+	if !enabled {
+		a.text = ""
+	}
+}
+
+func (a *Announcer) Enabled() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.enabled
+}
+
+// Announce replaces the announcement. Returns false if accessibility mode is
+// off.
+func (a *Announcer) Announce(text string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.enabled {
+		return false
+	}
+
+	a.text = text
+	return true
+}
+
+// Render draws the announcement in the top of the border, and moves the
+// cursor to the end of it.
+func (a *Announcer) Render(s *Screen) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if !a.enabled {
+		s.HideCursor()
+		return
+	}
+
+	width, _ := s.displaySize()
+	text := layout.Truncate(" "+a.text+" ", width-4)
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	textSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorWhite)
+
+	s.DrawLine(1, 0, width-2, 0, sty, true)
+	s.DrawText(2, 0, textSty, text)
+
+	x, y := s.offset()
+	s.ShowCursor(x+2+layout.Width(text)-1, y)
+}
+
+// announce says something in accessibility mode. Anything worth a toast is
+// announced already, so this is for what isn't.
+func announce(format string, args ...interface{}) {
+	if arcade.Distributor || arcade.Server == nil || arcade.Server.mgr == nil {
+		return
+	}
+
+	mgr := arcade.Server.mgr
+
+	if mgr.Announcer.Announce(fmt.Sprintf(format, args...)) {
+		mgr.RequestRender()
+	}
+}
@@ -0,0 +1,73 @@
+package arcade
+
+import (
+	"log"
+	"sync"
+)
+
+const (
+	// Inputs a player can send per game tick. Key repeat can land a couple in
+	// one tick, but nobody presses faster than this
+	maxInputsPerTick = 3
+
+	// Violations before a player is removed, if the host has that turned on
+	inputViolationsToKick = 5
+)
+
+// InputValidator is kept by the host to count players' inputs each tick, and
+// the inputs it's rejected from them.
+type InputValidator struct {
+	mu sync.Mutex
+
+	game     string
+	autoKick bool
+
+	tick       map[string]int
+	inputs     map[string]int
+	violations map[string]int
+	kicked     map[string]bool
+}
+
+func NewInputValidator(game string, autoKick bool) *InputValidator {
+	return &InputValidator{
+		game:       game,
+		autoKick:   autoKick,
+		tick:       make(map[string]int),
+		inputs:     make(map[string]int),
+		violations: make(map[string]int),
+		kicked:     make(map[string]bool),
+	}
+}
+
+// Input records an input from the player during the tick, and returns false
+// if they've sent more this tick than a person could.
+func (iv *InputValidator) Input(playerID string, tick int) bool {
+	iv.mu.Lock()
+	defer iv.mu.Unlock()
+
+	if iv.tick[playerID] != tick {
+		iv.tick[playerID] = tick
+		iv.inputs[playerID] = 0
+	}
+
+	iv.inputs[playerID]++
+
+	return iv.inputs[playerID] <= maxInputsPerTick
+}
+
+// Violation logs a rejected input, and returns true the first time the player
+// should be kicked for it.
+func (iv *InputValidator) Violation(playerID, reason string) bool {
+	iv.mu.Lock()
+	defer iv.mu.Unlock()
+
+	iv.violations[playerID]++
+	log.Printf("%s: rejected input from %s (%s), %d so far\n", iv.game, playerID, reason, iv.violations[playerID])
+
+	if !iv.autoKick || iv.kicked[playerID] || iv.violations[playerID] < inputViolationsToKick {
+		return false
+	}
+
+	iv.kicked[playerID] = true
+	return true
+}
@@ -0,0 +1,98 @@
+package arcade
+
+import "time"
+
+const (
+	// When a lobby's arena starts closing in, and how often it closes in
+	// again after that
+	arenaShrinkAfter = 30 * time.Second
+	arenaShrinkEvery = 3 * time.Second
+
+	// How long before the wall moves players are warned of it
+	arenaShrinkWarning = time.Second
+
+	// Cells left free in the middle of an arena that's closed in all it can
+	arenaShrinkRoom = 2
+)
+
+// ShrinkSchedule is when a battle's arena closes in, a cell from each edge at
+// a time, until there's hardly any room left. It's counted in the game's ticks
+// and kept in the game's state, which the host sends to everyone, so every
+// player and spectator has the wall in the same place.
+type ShrinkSchedule struct {
+	// Tick the arena first closes in at
+	Start int
+
+	// Ticks between each time it closes in, or 0 for just the once
+	Every int `json:",omitempty"`
+
+	// Cells it closes in from each edge at most
+	Most int
+
+	// Ticks before the wall moves that players are warned
+	Warn int `json:",omitempty"`
+}
+
+// NewShrinkSchedule returns a schedule for an arena with inside of the size,
+// in a game with ticks of the period.
+func NewShrinkSchedule(after, every, period time.Duration, width, height int) *ShrinkSchedule {
+	most := (minInt(width, height) - arenaShrinkRoom) / 2
+
+	if most < 0 {
+		most = 0
+	}
+
+	return &ShrinkSchedule{
+		Start: int(after / period),
+		Every: int(every / period),
+		Most:  most,
+		Warn:  int(arenaShrinkWarning / period),
+	}
+}
+
+// Inset returns how many cells the arena's closed in from each edge by the
+// tick.
+func (s *ShrinkSchedule) Inset(tick int) int {
+	if s == nil || tick < s.Start {
+		return 0
+	}
+
+	inset := 1
+
+	if s.Every > 0 {
+		inset += (tick - s.Start) / s.Every
+	}
+
+	return minInt(inset, s.Most)
+}
+
+// Warning returns true in the ticks just before the wall next moves.
+func (s *ShrinkSchedule) Warning(tick int) bool {
+	if s == nil || s.Inset(tick) >= s.Most {
+		return false
+	}
+
+	next := s.Start
+
+	if tick >= s.Start {
+		if s.Every <= 0 {
+			return false
+		}
+
+		next += ((tick-s.Start)/s.Every + 1) * s.Every
+	}
+
+	return next-tick <= s.Warn
+}
+
+// Closed returns true if the cell's been walled off by the tick, in an arena
+// with inside from (x1, y1) to (x2, y2).
+func (s *ShrinkSchedule) Closed(tick, x, y, x1, y1, x2, y2 int) bool {
+	inset := s.Inset(tick)
+
+	if inset == 0 {
+		return false
+	}
+
+	return x < x1+inset || x > x2-inset || y < y1+inset || y > y2-inset
+}
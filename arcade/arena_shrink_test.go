@@ -0,0 +1,67 @@
+package arcade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShrinkScheduleInset(t *testing.T) {
+	schedule := NewShrinkSchedule(10*time.Second, 2*time.Second, time.Second, 20, 10)
+
+	tests := map[int]int{0: 0, 9: 0, 10: 1, 11: 1, 12: 2, 15: 3, 1000: 4}
+
+	for tick, inset := range tests {
+		if got := schedule.Inset(tick); got != inset {
+			t.Errorf("tick %d: closed in %d, want %d", tick, got, inset)
+		}
+	}
+
+	if (*ShrinkSchedule)(nil).Inset(1000) != 0 {
+		t.Errorf("arena without a schedule closed in")
+	}
+}
+
+func TestShrinkScheduleWarning(t *testing.T) {
+	schedule := NewShrinkSchedule(10*time.Second, 3*time.Second, time.Second, 20, 10)
+
+	for tick, warn := range map[int]bool{8: false, 9: true, 10: false, 12: true, 13: false} {
+		if got := schedule.Warning(tick); got != warn {
+			t.Errorf("tick %d: warning %v, want %v", tick, got, warn)
+		}
+	}
+
+	// Nothing left to close in
+	if schedule.Warning(1000) {
+		t.Errorf("warned once the arena stopped closing in")
+	}
+}
+
+func TestShrinkScheduleClosed(t *testing.T) {
+	schedule := NewShrinkSchedule(0, time.Second, time.Second, 10, 10)
+
+	if !schedule.Closed(0, 2, 5, 2, 2, 11, 11) || schedule.Closed(0, 3, 5, 2, 2, 11, 11) {
+		t.Errorf("wrong cells closed after closing in once")
+	}
+
+	if !schedule.Closed(1, 10, 10, 2, 2, 11, 11) || schedule.Closed(1, 9, 9, 2, 2, 11, 11) {
+		t.Errorf("wrong cells closed after closing in twice")
+	}
+}
+
+func TestTronShrinkSchedule(t *testing.T) {
+	tg := &TronGameView{shrinking: true}
+
+	if tg.shrinkSchedule(80, 24).Start != int(arenaShrinkAfter/TronTimestepPeriod) {
+		t.Errorf("shrinking arena doesn't start closing in on time")
+	}
+
+	tg.timer = NewMatchTimer(10*time.Second, tronSuddenDeathEvery, TronTimestepPeriod)
+
+	if schedule := tg.shrinkSchedule(80, 24); schedule.Start != tg.timer.Limit {
+		t.Errorf("arena doesn't close in when time's up first")
+	}
+
+	if (&TronGameView{}).shrinkSchedule(80, 24) != nil {
+		t.Errorf("arena closes in without a time limit or the lobby asking")
+	}
+}
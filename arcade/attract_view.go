@@ -0,0 +1,203 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"encoding"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	// How long the lobby list sits untouched before the demo starts
+	attractModeIdle = 60 * time.Second
+
+	// Ticks a bot keeps aiming at the same spot on its paddle, and how far
+	// off the middle that spot can be. Aiming past the end of the paddle is
+	// what makes the bots miss.
+	attractAimTicks  = 20
+	attractAimSpread = 3
+
+	// Ticks the result of a demo game stays up before the next one
+	attractGameOverTicks = 60
+)
+
+var attractFooter = "DEMO  -  Press any key to play"
+
+// AttractView plays Pong between bots while nobody is around, like an arcade
+// cabinet does. Any key goes back.
+type AttractView struct {
+	View
+	mgr *ViewManager
+
+	mu           sync.RWMutex
+	playerIDs    []string
+	state        PongGameState
+	aim          map[string]int
+	endedTicks   int
+	games        int
+	rng          *MatchRNG
+	stopTickerCh chan bool
+}
+
+func NewAttractView(mgr *ViewManager) *AttractView {
+	v := &AttractView{
+		mgr:          mgr,
+		stopTickerCh: make(chan bool),
+	}
+
+	v.newGame()
+	return v
+}
+
+// newGame starts the next demo, switching between two and four bots.
+func (v *AttractView) newGame() {
+	players := 2
+
+	if v.games%2 == 1 {
+		players = PongMaxPlayers
+	}
+
+	v.games++
+	v.playerIDs = make([]string, players)
+
+	for i := range v.playerIDs {
+		v.playerIDs[i] = fmt.Sprintf("bot-%d", i+1)
+	}
+
+	v.rng = NewMatchRNG()
+	v.state = newPongGameState(v.playerIDs, v.games%3 == 0, v.rng)
+	v.aim = make(map[string]int)
+	v.endedTicks = 0
+}
+
+func (v *AttractView) Init() {
+	ticker := time.NewTicker(PongTickPeriod)
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				v.tick()
+				v.mgr.RequestRender()
+			case <-v.stopTickerCh:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (v *AttractView) tick() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.state.Ended {
+		v.endedTicks++
+
+		if v.endedTicks >= attractGameOverTicks {
+			v.newGame()
+		}
+
+		return
+	}
+
+	for id, cs := range v.state.ClientStates {
+		if cs.Eliminated() {
+			continue
+		}
+
+		if v.state.Tick%attractAimTicks == 0 {
+			v.aim[id] = rand.Intn(2*attractAimSpread+1) - attractAimSpread
+		}
+
+		v.state = v.state.withClientState(id, movePongBot(v.state, cs, v.aim[id]))
+	}
+
+	v.state = stepPong(v.state, v.rng)
+}
+
+// movePongBot moves a paddle one step toward the ball, a bit off depending on
+// where the bot is aiming.
+func movePongBot(state PongGameState, cs PongClientState, aim int) PongClientState {
+	switch target := pongBotTarget(state, cs, aim); {
+	case target > cs.Pos:
+		cs.Pos = clampPaddle(cs.Side, cs.Pos+1)
+	case target < cs.Pos:
+		cs.Pos = clampPaddle(cs.Side, cs.Pos-1)
+	}
+
+	return cs
+}
+
+// pongBotTarget returns where a bot wants its paddle, a bit off the ball
+// depending on where it's aiming.
+func pongBotTarget(state PongGameState, cs PongClientState, aim int) int {
+	if cs.Side == PongTop || cs.Side == PongBottom {
+		return int(state.Ball.X) + aim*2
+	}
+
+	return int(state.Ball.Y) + aim
+}
+
+func (v *AttractView) ProcessEvent(evt interface{}) {
+	switch evt.(type) {
+	case *tcell.EventKey:
+		if !v.mgr.PopView() {
+			v.mgr.SetView(NewGamesListView(v.mgr))
+		}
+	}
+}
+
+func (v *AttractView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *AttractView) Render(s *Screen) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	renderPongCourt(s, v.state, v.playerIDs, "")
+
+	width, height := s.displaySize()
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorTeal)
+
+	if v.state.Ended {
+		s.DrawBlockText(CenterX, CenterY, sty, "GAME OVER", true)
+	}
+
+	// Blink about once a second
+	if (v.state.Tick+v.endedTicks)/10%2 == 0 {
+		s.DrawText(layout.Center(width, attractFooter), height-2, sty, attractFooter)
+	}
+}
+
+func (v *AttractView) PixelArea() (int, int, int, int) {
+	return pongCourtX1, pongCourtY1, pongCourtX2, pongCourtY2
+}
+
+func (v *AttractView) RenderPixels(c *PixelCanvas) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.state.Ended {
+		return false
+	}
+
+	renderPongPixels(c, v.state)
+	return true
+}
+
+func (v *AttractView) Unload() {
+	// Closed rather than sent on, since the ticker may be waiting to render
+	// while the view manager unloads this view
+	close(v.stopTickerCh)
+}
+
+func (v *AttractView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
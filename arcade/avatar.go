@@ -0,0 +1,92 @@
+package arcade
+
+import (
+	"strings"
+	"time"
+)
+
+// AvatarPose is what a lobby member's stickman is doing.
+type AvatarPose int
+
+const (
+	AvatarIdle AvatarPose = iota
+	AvatarWave
+	AvatarReady
+)
+
+const (
+	// Stickmen are drawn in three rows of three cells
+	avatarWidth  = 3
+	avatarHeight = 3
+
+	avatarFrameTime = 400 * time.Millisecond
+
+	// How long new arrivals wave for
+	avatarWaveTime = 3 * time.Second
+)
+
+// Avatar is a stickman, told apart from the others by its head.
+type Avatar struct {
+	Name string
+	Head string
+}
+
+var avatars = []Avatar{
+	{"Classic", "o"},
+	{"Big head", "O"},
+	{"Curly", "@"},
+	{"Robot", "#"},
+	{"Shades", "8"},
+}
+
+// avatarPoses are the frames of each pose, with H where the head goes.
+var avatarPoses = map[AvatarPose][][avatarHeight]string{
+	AvatarIdle: {
+		{" H ", "/|\\", "/ \\"},
+		{" H ", "/|\\", "/ \\"},
+		{" H ", "/|\\", "/ \\"},
+		{" H ", "/|\\", "/ >"},
+	},
+	AvatarWave: {
+		{" H/", "/| ", "/ \\"},
+		{" H_", "/| ", "/ \\"},
+	},
+	AvatarReady: {
+		{"\\H/", " | ", "/ \\"},
+		{"_H_", " | ", "/ \\"},
+	},
+}
+
+// avatarNames lists the avatars by name, for picking one.
+func avatarNames() []string {
+	names := make([]string, len(avatars))
+
+	for i, a := range avatars {
+		names[i] = a.Name
+	}
+
+	return names
+}
+
+// findAvatar returns the avatar with the name, or the first one.
+func findAvatar(name string) Avatar {
+	for _, a := range avatars {
+		if a.Name == name {
+			return a
+		}
+	}
+
+	return avatars[0]
+}
+
+// Frame returns the rows of the pose after it's been held for elapsed.
+func (a Avatar) Frame(pose AvatarPose, elapsed time.Duration) [avatarHeight]string {
+	frames := avatarPoses[pose]
+	frame := frames[int(elapsed/avatarFrameTime)%len(frames)]
+
+	for i := range frame {
+		frame[i] = strings.Replace(frame[i], "H", a.Head, 1)
+	}
+
+	return frame
+}
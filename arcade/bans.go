@@ -0,0 +1,208 @@
+package arcade
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const BANS_FILENAME = "bans.yaml"
+
+// Ban keeps a player ID, an IP address or an IP range off the distributor.
+type Ban struct {
+	Target string    `yaml:"target"`
+	Reason string    `yaml:"reason,omitempty"`
+	Until  time.Time `yaml:"until,omitempty"`
+}
+
+func (b Ban) expired() bool {
+	return !b.Until.IsZero() && time.Now().After(b.Until)
+}
+
+// BanList is the distributor's bans, saved so they last across restarts.
+type BanList struct {
+	mu sync.RWMutex
+
+	file string
+	bans map[string]Ban
+}
+
+func bansPath() (string, error) {
+	dir, err := configDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, BANS_FILENAME), nil
+}
+
+// LoadBanList reads the bans saved in the config directory. The list is still
+// usable if it couldn't be read, it just starts empty.
+func LoadBanList() (*BanList, error) {
+	l := &BanList{bans: make(map[string]Ban)}
+	file, err := bansPath()
+
+	if err != nil {
+		return l, err
+	}
+
+	l.file = file
+	data, err := os.ReadFile(file)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	} else if err != nil {
+		return l, err
+	}
+
+	var bans []Ban
+
+	if err := yaml.Unmarshal(data, &bans); err != nil {
+		return l, err
+	}
+
+	for _, ban := range bans {
+		l.bans[ban.Target] = ban
+	}
+
+	return l, nil
+}
+
+// parseBanTarget checks that the target is a player ID, IP or CIDR range, and
+// returns it in a normal form.
+func parseBanTarget(target string) (string, error) {
+	if _, ipNet, err := net.ParseCIDR(target); err == nil {
+		return ipNet.String(), nil
+	}
+
+	if ip := net.ParseIP(target); ip != nil {
+		return ip.String(), nil
+	}
+
+	if len(target) < 8 || strings.ContainsAny(target, " /") {
+		return "", errors.New("not a player ID, IP or IP range")
+	}
+
+	return target, nil
+}
+
+// Add bans the target, for the duration or forever if it's 0.
+func (l *BanList) Add(target, reason string, duration time.Duration) (Ban, error) {
+	target, err := parseBanTarget(target)
+
+	if err != nil {
+		return Ban{}, err
+	}
+
+	ban := Ban{Target: target, Reason: reason}
+
+	if duration > 0 {
+		ban.Until = time.Now().Add(duration)
+	}
+
+	l.mu.Lock()
+	l.bans[target] = ban
+	l.mu.Unlock()
+
+	return ban, l.save()
+}
+
+// Remove lifts the ban on the target. Returns false if it wasn't banned.
+func (l *BanList) Remove(target string) (bool, error) {
+	if normal, err := parseBanTarget(target); err == nil {
+		target = normal
+	}
+
+	l.mu.Lock()
+	_, ok := l.bans[target]
+	delete(l.bans, target)
+	l.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	return true, l.save()
+}
+
+// Bans returns the bans still in effect.
+func (l *BanList) Bans() []Ban {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	bans := make([]Ban, 0, len(l.bans))
+
+	for _, ban := range l.bans {
+		if !ban.expired() {
+			bans = append(bans, ban)
+		}
+	}
+
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Target < bans[j].Target
+	})
+
+	return bans
+}
+
+// Banned returns the ban on the player, or on the address they're connecting
+// from, if there is one. The address can have a port.
+func (l *BanList) Banned(playerID, addr string) (Ban, bool) {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	ip := net.ParseIP(addr)
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, ban := range l.bans {
+		if ban.expired() {
+			continue
+		}
+
+		if ban.Target == playerID && playerID != "" {
+			return ban, true
+		}
+
+		if ip == nil {
+			continue
+		}
+
+		if _, ipNet, err := net.ParseCIDR(ban.Target); err == nil && ipNet.Contains(ip) {
+			return ban, true
+		} else if banned := net.ParseIP(ban.Target); banned != nil && banned.Equal(ip) {
+			return ban, true
+		}
+	}
+
+	return Ban{}, false
+}
+
+func (l *BanList) save() error {
+	if l.file == "" {
+		return nil
+	}
+
+	data, err := yaml.Marshal(l.Bans())
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path.Dir(l.file), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(l.file, data, 0644)
+}
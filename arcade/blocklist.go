@@ -0,0 +1,110 @@
+package arcade
+
+import (
+	"sort"
+	"sync"
+)
+
+// blockList caches the profile's muted and blocked players so chat and the
+// lobby list don't have to read the profile for every message.
+type blockList struct {
+	mu sync.RWMutex
+
+	loaded  bool
+	muted   map[string]bool
+	blocked map[string]bool
+}
+
+var blocks = &blockList{}
+
+func (b *blockList) load() {
+	if b.loaded {
+		return
+	}
+
+	b.muted = make(map[string]bool)
+	b.blocked = make(map[string]bool)
+	b.loaded = true
+
+	profile, err := LoadProfile()
+
+	if err != nil {
+		return
+	}
+
+	for _, id := range profile.Muted {
+		b.muted[id] = true
+	}
+
+	for _, id := range profile.Blocked {
+		b.blocked[id] = true
+	}
+}
+
+func (b *blockList) save() error {
+	return UpdateProfile(func(profile *Profile) {
+		profile.Muted = sortedKeys(b.muted)
+		profile.Blocked = sortedKeys(b.blocked)
+	})
+}
+
+// IsMuted returns true if chat from the player should be hidden. Blocked
+// players are always muted.
+func IsMuted(playerID string) bool {
+	blocks.mu.Lock()
+	defer blocks.mu.Unlock()
+
+	blocks.load()
+	return blocks.muted[playerID] || blocks.blocked[playerID]
+}
+
+// IsBlocked returns true if invites and lobbies from the player should be
+// ignored.
+func IsBlocked(playerID string) bool {
+	blocks.mu.Lock()
+	defer blocks.mu.Unlock()
+
+	blocks.load()
+	return blocks.blocked[playerID]
+}
+
+func SetMuted(playerID string, muted bool) error {
+	blocks.mu.Lock()
+	defer blocks.mu.Unlock()
+
+	blocks.load()
+
+	if muted {
+		blocks.muted[playerID] = true
+	} else {
+		delete(blocks.muted, playerID)
+	}
+
+	return blocks.save()
+}
+
+func SetBlocked(playerID string, blocked bool) error {
+	blocks.mu.Lock()
+	defer blocks.mu.Unlock()
+
+	blocks.load()
+
+	if blocked {
+		blocks.blocked[playerID] = true
+	} else {
+		delete(blocks.blocked, playerID)
+	}
+
+	return blocks.save()
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
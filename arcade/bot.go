@@ -0,0 +1,117 @@
+package arcade
+
+import "math"
+
+// BotSkill is how well a bot plays in practice. Each game uses the parts that
+// make sense for it.
+type BotSkill struct {
+	Name string
+
+	// Ticks between moves, so slower bots fall behind fast balls
+	MoveEvery int
+
+	// How far off a Pong bot aims from the ball
+	AimSpread int
+
+	// Cells a Tron bot looks ahead for walls and trails
+	Lookahead int
+
+	// Chance of a Tron bot not reacting to a wall it sees coming
+	Mistakes float64
+
+	// How the bot changes with the score, or nil to always play the same
+	Adapt *BotAdaptation
+}
+
+// BotAdaptation is how a practice bot eases off while the player's behind and
+// presses harder while they're ahead, to keep the game close. Each preset has
+// its own, and they can be changed in the config by the preset's name.
+type BotAdaptation struct {
+	// How far ahead or behind the player has to be for the bot to change all
+	// it can
+	Range int `yaml:"range"`
+
+	// Shape of the change up to then: 1 changes evenly with the score, while
+	// higher hardly changes for a close game and a lot for a runaway one
+	Curve float64 `yaml:"curve"`
+
+	// Most the bot's ticks between moves and cells of lookahead change by,
+	// for how quickly it reacts
+	MoveEvery int `yaml:"move_every"`
+	Lookahead int `yaml:"lookahead"`
+
+	// Most the bot's aim and chance of mistakes change by, for how often it
+	// gets things wrong
+	AimSpread int     `yaml:"aim_spread"`
+	Mistakes  float64 `yaml:"mistakes"`
+}
+
+var botSkills = []BotSkill{
+	{
+		Name: "Easy", MoveEvery: 2, AimSpread: 4, Lookahead: 2, Mistakes: 0.3,
+		Adapt: &BotAdaptation{Range: 2, Curve: 1, MoveEvery: 1, Lookahead: 2, AimSpread: 2, Mistakes: 0.2},
+	},
+	{
+		Name: "Normal", MoveEvery: 1, AimSpread: 3, Lookahead: 4, Mistakes: 0.1,
+		Adapt: &BotAdaptation{Range: 3, Curve: 1.5, MoveEvery: 1, Lookahead: 3, AimSpread: 2, Mistakes: 0.1},
+	},
+	{
+		Name: "Hard", MoveEvery: 1, AimSpread: 1, Lookahead: 8,
+		Adapt: &BotAdaptation{Range: 3, Curve: 2, MoveEvery: 1, Lookahead: 4, AimSpread: 2, Mistakes: 0.1},
+	},
+}
+
+// scoredGame is a local game that can tell how far ahead a player is, for
+// bots that adapt to it.
+type scoredGame interface {
+	// ScoreDifferential returns how far the player's ahead of the bots, or
+	// behind if negative
+	ScoreDifferential(player int) int
+}
+
+// adapted returns the bot's skill with the player the differential ahead,
+// along its adaptation curve.
+func (skill BotSkill) adapted(differential int) BotSkill {
+	adapt := skill.Adapt
+
+	if adapt == nil || adapt.Range <= 0 || differential == 0 {
+		return skill
+	}
+
+	// How far along the curve, from -1 with the player furthest behind to 1
+	// with them furthest ahead
+	t := math.Min(math.Abs(float64(differential))/float64(adapt.Range), 1)
+
+	if adapt.Curve > 0 {
+		t = math.Pow(t, adapt.Curve)
+	}
+
+	if differential < 0 {
+		t = -t
+	}
+
+	skill.MoveEvery = maxInt(skill.MoveEvery-int(math.Round(t*float64(adapt.MoveEvery))), 1)
+	skill.Lookahead = maxInt(skill.Lookahead+int(math.Round(t*float64(adapt.Lookahead))), 1)
+	skill.AimSpread = maxInt(skill.AimSpread-int(math.Round(t*float64(adapt.AimSpread))), 0)
+	skill.Mistakes = math.Max(0, math.Min(skill.Mistakes-t*adapt.Mistakes, 1))
+
+	return skill
+}
+
+// botAdaptation returns the adaptation curve for the preset, changed by the
+// config if it has one for it.
+func botAdaptation(config *Config, skill BotSkill) *BotAdaptation {
+	if adapt, ok := config.BotCurves[skill.Name]; ok {
+		return &adapt
+	}
+
+	return skill.Adapt
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
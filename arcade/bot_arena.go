@@ -0,0 +1,236 @@
+package arcade
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Most game time an arena match can go on for before it's called a draw
+const arenaMaxGameTime = 10 * time.Minute
+
+var errArenaPlayers = errors.New("wrong number of bots for the game")
+
+// ArenaResult is how one bot did over an arena's matches.
+type ArenaResult struct {
+	Bot    string
+	Played int
+	Wins   int
+	Losses int
+	Draws  int
+
+	// Bots that couldn't be loaded, or stopped badly, in a match
+	Errors int `json:",omitempty"`
+}
+
+// WinRate returns the share of matches the bot won.
+func (r ArenaResult) WinRate() float64 {
+	if r.Played == 0 {
+		return 0
+	}
+
+	return float64(r.Wins) / float64(r.Played)
+}
+
+// ArenaReport is what came of an arena: every bot's results, and the seed to
+// play the same matches again.
+type ArenaReport struct {
+	Game     string
+	Seed     string
+	Matches  int
+	Ticks    int
+	Duration time.Duration
+	Results  []ArenaResult
+}
+
+// runBotArena plays the bots against each other in the game with no screen,
+// as fast as they answer, changing seats every match so no one keeps the
+// best one. Every match is drawn from the seed, or a random one if it's "".
+func runBotArena(gameType string, specs []botSpec, matches int, seed string) (ArenaReport, error) {
+	gt, ok := localGameTypes[gameType]
+
+	if !ok {
+		return ArenaReport{}, fmt.Errorf("can't play %q locally", gameType)
+	}
+
+	if capacities := lobbyCapacities[gameType]; len(specs) < 2 || len(specs) > len(capacities)+1 {
+		return ArenaReport{}, errArenaPlayers
+	}
+
+	if seed == "" {
+		seed = NewMatchRNG().Seed()
+	}
+
+	if _, err := NewSeededMatchRNG(seed); err != nil {
+		return ArenaReport{}, err
+	}
+
+	report := ArenaReport{Game: gameType, Seed: seed, Matches: matches, Results: make([]ArenaResult, len(specs))}
+	started := time.Now()
+
+	for i, spec := range specs {
+		report.Results[i].Bot = spec.Name()
+	}
+
+	for match := 0; match < matches; match++ {
+		rng, _ := NewSeededMatchRNG(arenaMatchSeed(seed, match))
+		game := gt.New(&ViewManager{}, len(specs), rng)
+
+		// Seat i is played by bot seats[i]
+		seats := make([]int, len(specs))
+
+		for i := range seats {
+			seats[i] = (i + match) % len(specs)
+		}
+
+		winner, ticks := playArenaMatch(gameType, game, specs, seats, &report)
+		report.Ticks += ticks
+
+		for seat, bot := range seats {
+			result := &report.Results[bot]
+			result.Played++
+
+			switch {
+			case winner < 0:
+				result.Draws++
+			case winner == seat:
+				result.Wins++
+			default:
+				result.Losses++
+			}
+		}
+	}
+
+	report.Duration = time.Since(started)
+	return report, nil
+}
+
+// playArenaMatch plays one match out, returning the winning seat, or -1 for
+// a draw, and how many ticks it took.
+func playArenaMatch(gameType string, game localGame, specs []botSpec, seats []int, report *ArenaReport) (int, int) {
+	drivers := make([]BotDriver, len(seats))
+
+	for seat, bot := range seats {
+		driver, err := specs[bot].Load(gameType, game, seat)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Couldn't load %s: %v\n", specs[bot].Name(), err)
+			report.Results[bot].Errors++
+			continue
+		}
+
+		drivers[seat] = driver
+	}
+
+	defer func() {
+		for seat, driver := range drivers {
+			if driver != nil && driver.Close() != nil {
+				report.Results[seats[seat]].Errors++
+			}
+		}
+	}()
+
+	maxTicks := int(arenaMaxGameTime / game.TickPeriod())
+
+	for tick := 0; tick < maxTicks; tick++ {
+		for seat, driver := range drivers {
+			if driver == nil {
+				continue
+			}
+
+			if action := driver.Choose(observe(gameType, game, seat)); action != "" {
+				game.Input(seat, action)
+			}
+		}
+
+		game.Step()
+
+		if ended, winner := game.Ended(); ended {
+			return winner, tick + 1
+		}
+	}
+
+	return -1, maxTicks
+}
+
+// arenaMatchSeed returns the seed for one of an arena's matches.
+func arenaMatchSeed(seed string, match int) string {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, uint64(match))
+
+	sum := sha256.Sum256(append([]byte(seed), data...))
+	return hex.EncodeToString(sum[:])
+}
+
+// Write prints the report as a table, best bot first.
+func (report ArenaReport) Write(w io.Writer) {
+	results := append([]ArenaResult(nil), report.Results...)
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].WinRate() > results[j].WinRate()
+	})
+
+	fmt.Fprintf(w, "%s arena, %d matches in %s\n", report.Game, report.Matches, report.Duration.Round(time.Millisecond))
+	fmt.Fprintf(w, "Seed %s\n\n", report.Seed)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Bot\tPlayed\tWins\tLosses\tDraws\tWin rate\tErrors")
+
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.0f%%\t%d\n", r.Bot, r.Played, r.Wins, r.Losses, r.Draws, 100*r.WinRate(), r.Errors)
+	}
+
+	tw.Flush()
+}
+
+// runArenaCommand runs an arena from the command line, printing the report
+// and saving it as JSON if there's a path for it. Returns the exit code.
+func runArenaCommand(gameType string, bots string, matches int, seed string, reportPath string) int {
+	var specs []botSpec
+
+	for _, spec := range strings.Split(bots, ",") {
+		specs = append(specs, botSpec(strings.TrimSpace(spec)))
+	}
+
+	// Game names are taken in any case
+	for _, name := range localGameNames() {
+		if strings.EqualFold(name, gameType) {
+			gameType = name
+		}
+	}
+
+	report, err := runBotArena(gameType, specs, matches, seed)
+
+	if err != nil {
+		fmt.Println("Couldn't run the arena:", err)
+		return 1
+	}
+
+	report.Write(os.Stdout)
+
+	if reportPath == "" {
+		return 0
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+
+	if err == nil {
+		err = os.WriteFile(reportPath, data, 0644)
+	}
+
+	if err != nil {
+		fmt.Println("Couldn't save the report:", err)
+		return 1
+	}
+
+	return 0
+}
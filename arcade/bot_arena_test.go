@@ -0,0 +1,90 @@
+package arcade
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBotArenaPlaysEveryMatch(t *testing.T) {
+	for _, game := range localGameNames() {
+		report, err := runBotArena(game, []botSpec{"easy", "Hard"}, 4, "")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, r := range report.Results {
+			if r.Played != 4 || r.Wins+r.Losses+r.Draws != 4 || r.Errors != 0 {
+				t.Errorf("%s: %+v", game, r)
+			}
+		}
+	}
+}
+
+func TestBotArenaIsRepeatable(t *testing.T) {
+	first, err := runBotArena(Tron, []botSpec{"normal", "normal", "hard"}, 3, "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, _ := runBotArena(Tron, []botSpec{"normal", "normal", "hard"}, 3, first.Seed)
+
+	if first.Ticks != again.Ticks {
+		t.Errorf("same seed played %d ticks, then %d", first.Ticks, again.Ticks)
+	}
+}
+
+func TestBotArenaPlayers(t *testing.T) {
+	if _, err := runBotArena(Pong, []botSpec{"easy"}, 1, ""); err != errArenaPlayers {
+		t.Errorf("arena ran with one bot")
+	}
+
+	if _, err := runBotArena(Pong, []botSpec{"easy", "easy", "easy", "easy", "easy"}, 1, ""); err != errArenaPlayers {
+		t.Errorf("arena ran with more bots than Pong has sides")
+	}
+}
+
+func TestScriptDriver(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell to run a script with")
+	}
+
+	// Answers up for whatever tick it's asked about
+	script := filepath.Join(t.TempDir(), "bot.sh")
+	source := `while read line; do
+	tick=$(echo "$line" | sed 's/.*"Tick":\([0-9]*\).*/\1/')
+	echo "{\"Tick\": $tick, \"Action\": \"up\"}"
+done
+`
+
+	if err := os.WriteFile(script, []byte(source), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	game := newLocalPong(2, NewMatchRNG())
+	driver, err := botSpec("sh "+script).Load(Pong, game, 1)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// However slowly the shell starts
+	driver.(*scriptDriver).timeout = 10 * time.Second
+	obs := observe(Pong, game, 1)
+
+	if obs.PlayerID != "local-2" || obs.State == nil {
+		t.Errorf("bad observation: %+v", obs)
+	}
+
+	if action := driver.Choose(obs); action != ActionUp {
+		t.Errorf("script chose %q", action)
+	}
+
+	if err := driver.Close(); err != nil {
+		t.Errorf("script didn't stop: %v", err)
+	}
+}
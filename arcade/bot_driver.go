@@ -0,0 +1,110 @@
+package arcade
+
+import (
+	"errors"
+	"strings"
+)
+
+// BotObservation is what a bot sees of a local game each tick.
+type BotObservation struct {
+	Game string
+
+	// The bot's player number and ID in the game's state
+	Player   int
+	PlayerID string
+
+	Tick int
+
+	// The game's state, as it's sent between players online
+	State interface{}
+}
+
+// BotDriver plays a player in a local game, whether one of our own bots or
+// one from the community, loaded from a plugin or run as a script.
+type BotDriver interface {
+	// Choose returns what the bot does this tick, or "" for nothing
+	Choose(obs BotObservation) Action
+
+	// Close stops the bot once its game is over
+	Close() error
+}
+
+// observableGame is a local game that can show its state to bots.
+type observableGame interface {
+	// Observe returns what the player sees this tick
+	Observe(player int) BotObservation
+}
+
+var errBotNotObservable = errors.New("game can't be played by loaded bots")
+
+// skillDriver is one of our own bots, at one of the skill presets.
+type skillDriver struct {
+	game  localGame
+	skill BotSkill
+}
+
+func (d *skillDriver) Choose(obs BotObservation) Action {
+	return d.game.BotMove(obs.Player, d.skill)
+}
+
+func (d *skillDriver) Close() error {
+	return nil
+}
+
+// botSpec says where a bot comes from: one of the skill presets by name, a Go
+// plugin ending in .so, or else a command to run as a script.
+type botSpec string
+
+// skill returns the preset the spec names, whatever its case.
+func (spec botSpec) skill() (BotSkill, bool) {
+	for _, skill := range botSkills {
+		if strings.EqualFold(skill.Name, string(spec)) {
+			return skill, true
+		}
+	}
+
+	return BotSkill{}, false
+}
+
+// Name returns what the bot's called in reports.
+func (spec botSpec) Name() string {
+	if skill, ok := spec.skill(); ok {
+		return skill.Name
+	}
+
+	return string(spec)
+}
+
+// Load starts the bot to play as the player in the game.
+func (spec botSpec) Load(gameType string, game localGame, player int) (BotDriver, error) {
+	if skill, ok := spec.skill(); ok {
+		return &skillDriver{game, skill}, nil
+	}
+
+	if _, ok := game.(observableGame); !ok {
+		return nil, errBotNotObservable
+	}
+
+	if strings.HasSuffix(string(spec), ".so") {
+		return loadPluginDriver(string(spec), gameType, player)
+	}
+
+	if strings.TrimSpace(string(spec)) == "" {
+		return nil, errors.New("no bot given")
+	}
+
+	return startScriptDriver(string(spec))
+}
+
+// observe returns what the player sees of the game, or nothing much if the
+// game can't show it.
+func observe(gameType string, game localGame, player int) BotObservation {
+	if g, ok := game.(observableGame); ok {
+		obs := g.Observe(player)
+		obs.Game = gameType
+
+		return obs
+	}
+
+	return BotObservation{Game: gameType, Player: player}
+}
@@ -0,0 +1,42 @@
+package arcade
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// NewBotFunc is what a bot plugin exports as NewBot, to start a bot playing
+// as the player in a game of the type. Plugins are built against this
+// package with go build -buildmode=plugin, and only load where Go supports
+// them.
+type NewBotFunc = func(gameType string, player int) BotDriver
+
+// loadPluginDriver starts a bot from a Go plugin.
+func loadPluginDriver(path string, gameType string, player int) (BotDriver, error) {
+	p, err := plugin.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sym, err := p.Lookup("NewBot")
+
+	if err != nil {
+		return nil, err
+	}
+
+	newBot, ok := sym.(NewBotFunc)
+
+	if !ok {
+		// Exported as a variable rather than a function
+		if ptr, isPtr := sym.(*NewBotFunc); isPtr {
+			newBot, ok = *ptr, true
+		}
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%s's NewBot is a %T, not a %T", path, sym, newBot)
+	}
+
+	return newBot(gameType, player), nil
+}
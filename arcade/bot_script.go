@@ -0,0 +1,152 @@
+package arcade
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// How long a script has to answer each tick before it does nothing that
+	// tick, and to stop once its game is over
+	botScriptTimeout = 50 * time.Millisecond
+	botScriptStop    = time.Second
+
+	// Answers kept that haven't been read yet, the rest being dropped
+	botScriptBacklog = 16
+)
+
+// botScriptReply is a script's answer for a tick.
+type botScriptReply struct {
+	Tick   int
+	Action Action
+}
+
+// scriptDriver runs a bot as a program of its own, in any language. Each tick
+// it's sent the observation as a line of JSON on its standard input, and
+// answers with a line like {"Tick": 12, "Action": "up"} on its standard
+// output. Answers that come too late are skipped.
+type scriptDriver struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	enc     *json.Encoder
+	replies chan botScriptReply
+	timeout time.Duration
+}
+
+// startScriptDriver runs the command, split on spaces, as a bot.
+func startScriptDriver(command string) (*scriptDriver, error) {
+	args := strings.Fields(command)
+
+	if len(args) == 0 {
+		return nil, errors.New("no bot command given")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = log.Writer()
+
+	stdin, err := cmd.StdinPipe()
+
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	d := &scriptDriver{
+		cmd:     cmd,
+		stdin:   stdin,
+		enc:     json.NewEncoder(stdin),
+		replies: make(chan botScriptReply, botScriptBacklog),
+		timeout: botScriptTimeout,
+	}
+
+	go d.read(stdout)
+
+	return d, nil
+}
+
+// read passes on the script's answers until it stops.
+func (d *scriptDriver) read(stdout io.Reader) {
+	defer close(d.replies)
+
+	scanner := bufio.NewScanner(stdout)
+
+	for scanner.Scan() {
+		var reply botScriptReply
+
+		if err := json.Unmarshal(scanner.Bytes(), &reply); err != nil {
+			log.Println("Bot script sent something that isn't an answer:", err)
+			continue
+		}
+
+		select {
+		case d.replies <- reply:
+		default:
+		}
+	}
+}
+
+func (d *scriptDriver) Choose(obs BotObservation) Action {
+	if err := d.enc.Encode(obs); err != nil {
+		return ""
+	}
+
+	timeout := time.NewTimer(d.timeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case reply, ok := <-d.replies:
+			if !ok {
+				return ""
+			}
+
+			// Late for an earlier tick
+			if reply.Tick < obs.Tick {
+				continue
+			}
+
+			if !isMoveAction(reply.Action) {
+				return ""
+			}
+
+			return reply.Action
+		case <-timeout.C:
+			return ""
+		}
+	}
+}
+
+// Close ends the script's input, and stops it if it doesn't finish soon
+// after.
+func (d *scriptDriver) Close() error {
+	d.stdin.Close()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- d.cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(botScriptStop):
+		d.cmd.Process.Kill()
+		return <-done
+	}
+}
@@ -0,0 +1,75 @@
+package arcade
+
+import "testing"
+
+func TestBotAdapts(t *testing.T) {
+	normal := botSkills[1]
+
+	if got := normal.adapted(0); got.MoveEvery != normal.MoveEvery || got.Mistakes != normal.Mistakes {
+		t.Errorf("bot changed in an even game: %+v", got)
+	}
+
+	behind := normal.adapted(-normal.Adapt.Range)
+
+	if behind.MoveEvery <= normal.MoveEvery || behind.AimSpread <= normal.AimSpread || behind.Mistakes <= normal.Mistakes {
+		t.Errorf("bot didn't ease off with the player behind: %+v", behind)
+	}
+
+	ahead := normal.adapted(100)
+
+	if ahead.Lookahead <= normal.Lookahead || ahead.AimSpread >= normal.AimSpread || ahead.Mistakes != 0 {
+		t.Errorf("bot didn't press harder with the player ahead: %+v", ahead)
+	}
+
+	if ahead.MoveEvery < 1 {
+		t.Errorf("bot moves every %d ticks", ahead.MoveEvery)
+	}
+}
+
+func TestBotAdaptationCurve(t *testing.T) {
+	skill := BotSkill{Mistakes: 0.5, Adapt: &BotAdaptation{Range: 4, Curve: 1, Mistakes: 0.4}}
+
+	if got := skill.adapted(-2).Mistakes; got != 0.7 {
+		t.Errorf("linear curve halfway gave %v mistakes, want 0.7", got)
+	}
+
+	skill.Adapt.Curve = 2
+
+	if got := skill.adapted(-2).Mistakes; got != 0.6 {
+		t.Errorf("squared curve halfway gave %v mistakes, want 0.6", got)
+	}
+
+	skill.Adapt = nil
+
+	if got := skill.adapted(-2).Mistakes; got != 0.5 {
+		t.Errorf("bot without a curve adapted")
+	}
+}
+
+func TestBotCurvesFromConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.BotCurves = map[string]BotAdaptation{"Hard": {Range: 10}}
+
+	if adapt := botAdaptation(config, botSkills[2]); adapt.Range != 10 {
+		t.Errorf("config's curve not used: %+v", adapt)
+	}
+
+	if adapt := botAdaptation(config, botSkills[0]); adapt != botSkills[0].Adapt {
+		t.Errorf("preset's curve not used without one in the config")
+	}
+}
+
+func TestPongScoreDifferential(t *testing.T) {
+	g := newLocalPong(2, NewMatchRNG())
+	cs := g.state.ClientStates[g.playerIDs[1]]
+	cs.Lives--
+	g.state.ClientStates[g.playerIDs[1]] = cs
+
+	if got := g.ScoreDifferential(0); got != 1 {
+		t.Errorf("one life ahead gave %d", got)
+	}
+
+	if got := g.ScoreDifferential(1); got != -1 {
+		t.Errorf("one life behind gave %d", got)
+	}
+}
@@ -1,6 +1,8 @@
 package arcade
 
 import (
+	"arcade/arcade/layout"
+
 	"github.com/gdamore/tcell/v2"
 )
 
@@ -43,7 +45,7 @@ func (b *Button) ProcessEvent(evt interface{}) {
 			if b.delegate.NavigateForward() {
 				b.active = false
 			}
-		case tcell.KeyUp:
+		case tcell.KeyUp, tcell.KeyBacktab:
 			if b.delegate.NavigateBackward() {
 				b.active = false
 			}
@@ -79,5 +81,6 @@ func (b *Button) Render(s *Screen) {
 	}
 
 	s.DrawEmpty(x, y, x+b.width-1, y+BUTTON_HEIGHT-1, tcell.StyleDefault.Background(color))
-	s.DrawText(x+(b.width-len(b.title))/2, y+1, tcell.StyleDefault.Background(color).Foreground(tcell.ColorBlack), b.title)
+	title := layout.Truncate(b.title, b.width)
+	s.DrawText(x+layout.Center(b.width, title), y+1, tcell.StyleDefault.Background(color).Foreground(tcell.ColorBlack), title)
 }
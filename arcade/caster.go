@@ -0,0 +1,246 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	// Rounds kept in the caster's history
+	casterHistoryLength = 6
+
+	casterBoardWidth   = 40
+	casterHistoryWidth = 34
+)
+
+// casterPlayer is how one player is doing, for the caster's overlays.
+type casterPlayer struct {
+	id     string
+	name   string
+	color  string
+	status string
+	out    bool
+
+	// Where their name plate goes, next to their paddle or bike
+	plateX, plateY int
+}
+
+// cameraID returns the player the camera follows, or "" for the whole game.
+// Expects the lock to be held.
+func (v *SpectateView) cameraID() string {
+	if v.camera <= 0 || v.camera > len(v.info.PlayerIDs) {
+		return ""
+	}
+
+	return v.info.PlayerIDs[v.camera-1]
+}
+
+// moveCamera switches to the next or previous player's perspective, with the
+// whole game between the last player and the first.
+func (v *SpectateView) moveCamera(delta int) {
+	v.mu.Lock()
+	cameras := len(v.info.PlayerIDs) + 1
+	v.camera = ((v.camera+delta)%cameras + cameras) % cameras
+	v.mu.Unlock()
+
+	v.mgr.RequestRender()
+}
+
+// recordRounds notes what happened between the last frame and this one.
+// Expects the lock to be held.
+func (v *SpectateView) recordRounds(prevPong PongGameState, prevTron TronGameState) {
+	events := make([]string, 0)
+
+	switch v.info.GameType {
+	case Pong:
+		for _, playerID := range v.info.PlayerIDs {
+			prev, ok := prevPong.ClientStates[playerID]
+			cs, ok2 := v.pong.ClientStates[playerID]
+
+			if !ok || !ok2 || cs.Lives >= prev.Lives {
+				continue
+			}
+
+			if cs.Eliminated() {
+				events = append(events, fmt.Sprintf("%s is out", v.playerName(playerID)))
+			} else {
+				events = append(events, fmt.Sprintf("%s missed, %d left", v.playerName(playerID), cs.Lives))
+			}
+		}
+	case Tron:
+		for _, playerID := range v.info.PlayerIDs {
+			prev, ok := prevTron.ClientStates[playerID]
+			cs, ok2 := v.tron.ClientStates[playerID]
+
+			if ok && ok2 && prev.Alive && !cs.Alive {
+				events = append(events, fmt.Sprintf("%s crashed", v.playerName(playerID)))
+			}
+		}
+	}
+
+	if v.ended {
+		if winner := v.winner(); winner != "" {
+			events = append(events, fmt.Sprintf("%s won", v.playerName(winner)))
+		}
+	}
+
+	v.history = append(v.history, events...)
+
+	if len(v.history) > casterHistoryLength {
+		v.history = v.history[len(v.history)-casterHistoryLength:]
+	}
+}
+
+// winner returns who won, once the game is over. Expects the lock to be held.
+func (v *SpectateView) winner() string {
+	switch v.info.GameType {
+	case Pong:
+		return v.pong.Winner
+	case Tron:
+		return v.tron.Winner
+	}
+
+	return ""
+}
+
+// casterPlayers returns how each player is doing. Expects the lock to be held.
+func (v *SpectateView) casterPlayers() []casterPlayer {
+	players := make([]casterPlayer, 0, len(v.info.PlayerIDs))
+
+	for _, playerID := range v.info.PlayerIDs {
+		p := casterPlayer{id: playerID, name: v.playerName(playerID)}
+		plateWidth := layout.Width(p.name) + 2
+
+		switch v.info.GameType {
+		case Pong:
+			cs, ok := v.pong.ClientStates[playerID]
+
+			if !ok {
+				continue
+			}
+
+			p.color = cs.Color
+			p.out = cs.Eliminated()
+			p.status = strings.Repeat("♥", cs.Lives)
+
+			if p.out {
+				p.status = "OUT"
+			}
+
+			line := pongPaddleLine(cs.Side)
+
+			switch cs.Side {
+			case PongLeft:
+				p.plateX, p.plateY = line+2, cs.Pos
+			case PongRight:
+				p.plateX, p.plateY = line-1-plateWidth, cs.Pos
+			case PongTop:
+				p.plateX, p.plateY = cs.Pos-plateWidth/2, line+1
+			case PongBottom:
+				p.plateX, p.plateY = cs.Pos-plateWidth/2, line-1
+			}
+		case Tron:
+			cs, ok := v.tron.ClientStates[playerID]
+
+			if !ok {
+				continue
+			}
+
+			p.color = cs.Color
+			p.out = !cs.Alive
+			p.status = "riding"
+
+			if p.out {
+				p.status = "crashed"
+			}
+
+			p.plateX, p.plateY = cs.X-plateWidth/2, cs.Y-1
+
+			if p.plateY < 2 {
+				p.plateY = cs.Y + 1
+			}
+		}
+
+		players = append(players, p)
+	}
+
+	return players
+}
+
+// renderCaster draws the scoreboard, name plates and round history over the
+// game. Expects the lock to be held.
+func (v *SpectateView) renderCaster(s *Screen) {
+	width, height := s.displaySize()
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorTeal)
+	camera := v.cameraID()
+	players := v.casterPlayers()
+
+	for _, p := range players {
+		if p.out {
+			continue
+		}
+
+		plate := " " + p.name + " "
+		plateSty := tcell.StyleDefault.Background(tcell.ColorNames[p.color]).Foreground(tcell.ColorBlack)
+
+		if camera != "" && p.id != camera {
+			plateSty = plateSty.Background(tcell.ColorDarkGray)
+		}
+
+		x := p.plateX
+
+		if x < 2 {
+			x = 2
+		} else if x+layout.Width(plate) > width-2 {
+			x = width - 2 - layout.Width(plate)
+		}
+
+		s.DrawText(x, p.plateY, plateSty.Bold(p.id == camera), plate)
+	}
+
+	// Scoreboard
+	boardX1 := (width - casterBoardWidth) / 2
+	boardX2 := boardX1 + casterBoardWidth
+	boardY1 := 3
+	boardY2 := boardY1 + len(players) + 1
+
+	s.DrawEmpty(boardX1, boardY1, boardX2, boardY2, sty)
+	s.DrawBox(boardX1, boardY1, boardX2, boardY2, sty, true)
+	s.DrawText(boardX1+2, boardY1, sty, " "+strings.ToUpper(v.info.GameType)+" ")
+
+	for i, p := range players {
+		y := boardY1 + 1 + i
+		rowSty := sty
+
+		if p.out {
+			rowSty = rowSty.Foreground(tcell.ColorGray)
+		}
+
+		if p.id == camera {
+			rowSty = rowSty.Bold(true)
+			s.DrawText(boardX1+1, y, rowSty, "▶")
+		}
+
+		s.DrawText(boardX1+3, y, tcell.StyleDefault.Foreground(tcell.ColorNames[p.color]), "██")
+		s.DrawText(boardX1+6, y, rowSty, layout.Truncate(p.name, casterBoardWidth-18))
+		s.DrawText(boardX2-2-layout.Width(p.status), y, rowSty, p.status)
+	}
+
+	// Round history, newest at the bottom
+	if len(v.history) > 0 {
+		historyX1 := 3
+		historyY2 := height - 4
+		historyY1 := historyY2 - len(v.history) - 1
+
+		s.DrawEmpty(historyX1, historyY1, historyX1+casterHistoryWidth, historyY2, sty)
+		s.DrawBox(historyX1, historyY1, historyX1+casterHistoryWidth, historyY2, sty, false)
+		s.DrawText(historyX1+2, historyY1, sty, " ROUNDS ")
+
+		for i, event := range v.history {
+			s.DrawText(historyX1+2, historyY1+1+i, sty, layout.Truncate(event, casterHistoryWidth-3))
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// ChallengeInfoMessage is the distributor's reply to a ChallengeQueryMessage
+// or a ChallengeScoreMessage: the day's challenge and how it's going.
+type ChallengeInfoMessage struct {
+	message.Message
+	Challenge DailyChallenge
+
+	// The best scores, and how many players have played
+	Standings []ChallengeScore
+	Players   int
+
+	// Set once we've played, with where we ranked
+	Mine *ChallengeScore
+	Rank int
+}
+
+func NewChallengeInfoMessage(challenge DailyChallenge, standings []ChallengeScore, players int) *ChallengeInfoMessage {
+	return &ChallengeInfoMessage{
+		Message:   message.Message{Type: "challenge_info"},
+		Challenge: challenge,
+		Standings: standings,
+		Players:   players,
+	}
+}
+
+func (m ChallengeInfoMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,21 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// ChallengeQueryMessage asks the distributor for the day's challenge.
+type ChallengeQueryMessage struct {
+	message.Message
+}
+
+func NewChallengeQueryMessage() *ChallengeQueryMessage {
+	return &ChallengeQueryMessage{
+		Message: message.Message{Type: "challenge_query"},
+	}
+}
+
+func (m ChallengeQueryMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
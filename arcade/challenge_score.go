@@ -0,0 +1,28 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// ChallengeScoreMessage sends the distributor our score at the day's
+// challenge.
+type ChallengeScoreMessage struct {
+	message.Message
+	Day   string
+	Name  string
+	Score int
+}
+
+func NewChallengeScoreMessage(day, name string, score int) *ChallengeScoreMessage {
+	return &ChallengeScoreMessage{
+		Message: message.Message{Type: "challenge_score"},
+		Day:     day,
+		Name:    name,
+		Score:   score,
+	}
+}
+
+func (m ChallengeScoreMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,34 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"arcade/arcade/net"
+	"encoding/json"
+)
+
+type ChatMessage struct {
+	message.Message
+
+	PlayerID string
+	Name     string
+	GameID   string
+	Text     string
+}
+
+func NewChatMessage(playerID, name, gameID, text string) *ChatMessage {
+	return &ChatMessage{
+		Message:  message.Message{Type: "chat"},
+		PlayerID: playerID,
+		Name:     name,
+		GameID:   gameID,
+		Text:     text,
+	}
+}
+
+func (m ChatMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func (m ChatMessage) SendPriority() net.Priority {
+	return net.PriorityChat
+}
@@ -0,0 +1,257 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	chatMaxLength    = 40
+	chatMaxLines     = 4
+	chatDisplayTime  = 5 * time.Second
+	chatOverlayLeftX = 3
+)
+
+// Quick emotes are sent with the number keys while the chat input is closed.
+var quickEmotes = []string{"gg", "nice", "glhf", "oops", "(^_^)", "(>_<)", "(o_O)", "\\(^o^)/", "(T_T)"}
+
+type chatEntry struct {
+	playerID string
+	name     string
+	text     string
+	at       time.Time
+
+	// Set for our messages that didn't go to everyone, like when the upload
+	// cap's been reached
+	unsent bool
+}
+
+// ChatOverlay is a one-line chat that game views draw on top of themselves.
+// Enter opens the input and sends the message, and messages fade out after a
+// few seconds so they don't get in the way of the game.
+type ChatOverlay struct {
+	mu sync.RWMutex
+
+	gameID    string
+	playerIDs []string
+	name      string
+
+	open    bool
+	input   string
+	entries []chatEntry
+
+	// Messages that came in while the input was closed
+	unread int
+}
+
+func NewChatOverlay(gameID string, playerIDs []string) *ChatOverlay {
+	name := arcade.Server.ID[:4]
+
+	if profile, err := LoadProfile(); err == nil && profile.Name != "" {
+		name = profile.Name
+	}
+
+	return &ChatOverlay{
+		gameID:    gameID,
+		playerIDs: playerIDs,
+		name:      name,
+	}
+}
+
+// Typing returns true while the chat box is open.
+func (co *ChatOverlay) Typing() bool {
+	co.mu.RLock()
+	defer co.mu.RUnlock()
+
+	return co.open
+}
+
+// Unread returns how many messages came in since the input was last opened.
+func (co *ChatOverlay) Unread() int {
+	co.mu.RLock()
+	defer co.mu.RUnlock()
+
+	return co.unread
+}
+
+// Showing returns true while the chat box is open or recent messages are up.
+func (co *ChatOverlay) Showing() bool {
+	co.mu.RLock()
+	defer co.mu.RUnlock()
+
+	n := len(co.entries)
+	return co.open || (n > 0 && time.Since(co.entries[n-1].at) <= chatDisplayTime)
+}
+
+// ProcessEvent handles chat keys and returns true if the event was consumed.
+// Arrow keys are never consumed so players can keep moving while typing.
+func (co *ChatOverlay) ProcessEvent(evt *tcell.EventKey) bool {
+	co.mu.Lock()
+
+	switch evt.Key() {
+	case tcell.KeyEnter:
+		if !co.open {
+			co.open = true
+			co.unread = 0
+			co.mu.Unlock()
+			return true
+		}
+
+		text := strings.TrimSpace(co.input)
+		co.open = false
+		co.input = ""
+		co.mu.Unlock()
+
+		if text != "" {
+			co.send(text)
+		}
+
+		return true
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		defer co.mu.Unlock()
+
+		if !co.open {
+			return false
+		}
+
+		if len(co.input) > 0 {
+			_, size := utf8.DecodeLastRuneInString(co.input)
+			co.input = co.input[:len(co.input)-size]
+		}
+
+		return true
+	case tcell.KeyRune:
+		if co.open {
+			defer co.mu.Unlock()
+
+			if utf8.RuneCountInString(co.input) < chatMaxLength {
+				co.input += string(evt.Rune())
+			}
+
+			return true
+		}
+
+		co.mu.Unlock()
+
+		if r := evt.Rune(); r >= '1' && r <= '9' && int(r-'1') < len(quickEmotes) {
+			co.send(quickEmotes[r-'1'])
+			return true
+		}
+
+		return false
+	}
+
+	co.mu.Unlock()
+	return false
+}
+
+// ProcessMessage records incoming chat for this game and returns true if the
+// message was a chat message.
+func (co *ChatOverlay) ProcessMessage(p interface{}) bool {
+	msg, ok := p.(*ChatMessage)
+
+	if !ok {
+		return false
+	}
+
+	if msg.GameID == co.gameID && msg.PlayerID == msg.SenderID && !IsMuted(msg.PlayerID) {
+		co.addEntry(msg.PlayerID, filterText(msg.Name), filterText(msg.Text), false)
+		playSound(SoundChat)
+		announce("%s says %s", filterText(msg.Name), filterText(msg.Text))
+
+		co.mu.Lock()
+		if !co.open {
+			co.unread++
+		}
+		co.mu.Unlock()
+	}
+
+	return true
+}
+
+func (co *ChatOverlay) send(text string) {
+	sent := true
+
+	for _, playerID := range co.playerIDs {
+		if playerID == arcade.Server.ID {
+			continue
+		}
+
+		client, ok := arcade.Server.Network.GetClient(playerID)
+
+		if !ok || !arcade.Server.Network.Send(client, NewChatMessage(arcade.Server.ID, co.name, co.gameID, text)) {
+			sent = false
+		}
+	}
+
+	co.addEntry(arcade.Server.ID, co.name, text, !sent)
+}
+
+func (co *ChatOverlay) addEntry(playerID, name, text string, unsent bool) {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+
+	co.entries = append(co.entries, chatEntry{
+		playerID: playerID,
+		name:     name,
+		text:     text,
+		at:       time.Now(),
+		unsent:   unsent,
+	})
+
+	if len(co.entries) > chatMaxLines {
+		co.entries = co.entries[len(co.entries)-chatMaxLines:]
+	}
+}
+
+func (co *ChatOverlay) playerColor(playerID string) tcell.Color {
+	for i, id := range co.playerIDs {
+		if id == playerID && i < len(TRON_COLORS) {
+			return tcell.ColorNames[TRON_COLORS[i]]
+		}
+	}
+
+	return tcell.ColorWhite
+}
+
+func (co *ChatOverlay) Render(s *Screen) {
+	co.mu.RLock()
+	defer co.mu.RUnlock()
+
+	_, displayHeight := s.displaySize()
+	y := displayHeight - 3
+
+	if co.open {
+		sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorWhite)
+		prompt := "> " + co.input
+		s.DrawText(chatOverlayLeftX, y, sty, prompt)
+		s.DrawText(chatOverlayLeftX+layout.Width(prompt), y, sty.Background(tcell.ColorGray), " ")
+		y--
+	}
+
+	for i := len(co.entries) - 1; i >= 0; i-- {
+		entry := co.entries[i]
+
+		if time.Since(entry.at) > chatDisplayTime {
+			break
+		}
+
+		nameSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(co.playerColor(entry.playerID))
+		textSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorWhite)
+
+		label := entry.name + ": "
+		s.DrawText(chatOverlayLeftX, y, nameSty, label)
+		s.DrawText(chatOverlayLeftX+layout.Width(label), y, textSty, entry.text)
+
+		if entry.unsent {
+			s.DrawText(chatOverlayLeftX+layout.Width(label+entry.text), y, textSty.Foreground(tcell.ColorGray), " (not sent)")
+		}
+
+		y--
+	}
+}
@@ -9,3 +9,7 @@ func NewClientConnectedEvent(clientID string) *ClientConnectedEvent {
 		ClientID: clientID,
 	}
 }
+
+func (e *ClientConnectedEvent) Topic() EventTopic {
+	return NetworkEvents
+}
@@ -9,3 +9,7 @@ func NewClientDisconnectedEvent(clientID string) *ClientDisconnectedEvent {
 		ClientID: clientID,
 	}
 }
+
+func (e *ClientDisconnectedEvent) Topic() EventTopic {
+	return NetworkEvents
+}
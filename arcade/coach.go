@@ -0,0 +1,125 @@
+package arcade
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// How often a player sends their coach what they see
+const coachFrameInterval = 100 * time.Millisecond
+
+// CoachRun is a stretch of a row on screen drawn in one style.
+type CoachRun struct {
+	Text string
+	Fg   tcell.Color `json:",omitempty"`
+	Bg   tcell.Color `json:",omitempty"`
+	Bold bool        `json:",omitempty"`
+}
+
+func (r CoachRun) sameStyle(other CoachRun) bool {
+	return r.Fg == other.Fg && r.Bg == other.Bg && r.Bold == other.Bold
+}
+
+// coachRelay sends our screen to our coach while we're in a game, so they see
+// exactly what we do.
+type coachRelay struct {
+	mu sync.Mutex
+
+	coachID   string
+	streaming bool
+	lastFrame time.Time
+}
+
+// setCoach sets who to send frames to, or "" for nobody.
+func (r *coachRelay) setCoach(coachID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.coachID = coachID
+}
+
+// relay sends the screen the view was just drawn on, if it's a game and a
+// frame is due.
+func (r *coachRelay) relay(s *Screen, v View) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.coachID == "" {
+		return
+	}
+
+	coach, ok := arcade.Server.Network.GetClient(r.coachID)
+
+	if !isGameView(v) {
+		if r.streaming && ok {
+			go arcade.Server.Network.Send(coach, NewCoachEndMessage())
+		}
+
+		r.streaming = false
+
+		// Coaching lasts as long as the lobby does
+		if _, inLobby := v.(*LobbyView); !inLobby {
+			r.coachID = ""
+		}
+
+		return
+	}
+
+	if !ok || time.Since(r.lastFrame) < coachFrameInterval {
+		return
+	}
+
+	r.streaming = true
+	r.lastFrame = time.Now()
+
+	go arcade.Server.Network.Send(coach, NewCoachFrameMessage(captureScreen(s)))
+}
+
+// isGameView returns whether the view is an online game.
+func isGameView(v View) bool {
+	switch v.(type) {
+	case *PongGameView, *TronGameView:
+		return true
+	}
+
+	return false
+}
+
+// captureScreen reads back what's been drawn, row by row.
+func captureScreen(s *Screen) [][]CoachRun {
+	startX, startY := s.offset()
+	width, height := s.displaySize()
+	rows := make([][]CoachRun, height)
+
+	for y := 0; y < height; y++ {
+		var runs []CoachRun
+
+		for x := 0; x < width; x++ {
+			r, _, style, w := s.Screen.GetContent(startX+x, startY+y)
+			fg, bg, attrs := style.Decompose()
+
+			if r == 0 {
+				r = ' '
+			}
+
+			run := CoachRun{Text: string(r), Fg: fg, Bg: bg, Bold: attrs&tcell.AttrBold != 0}
+
+			if n := len(runs); n > 0 && runs[n-1].sameStyle(run) {
+				runs[n-1].Text += run.Text
+			} else {
+				runs = append(runs, run)
+			}
+
+			// Wide runes cover the next cell too
+			if w > 1 {
+				x += w - 1
+			}
+		}
+
+		rows[y] = runs
+	}
+
+	return rows
+}
@@ -0,0 +1,48 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"arcade/arcade/net"
+	"encoding/json"
+)
+
+// CoachFrameMessage is what a player sees, sent to their coach while they're
+// in a game.
+type CoachFrameMessage struct {
+	message.Message
+	Rows [][]CoachRun `json:",omitempty"`
+
+	// Set once the player leaves the game
+	Ended bool `json:",omitempty"`
+}
+
+func NewCoachFrameMessage(rows [][]CoachRun) *CoachFrameMessage {
+	return &CoachFrameMessage{
+		Message: message.Message{Type: "coach_frame"},
+		Rows:    rows,
+	}
+}
+
+func NewCoachEndMessage() *CoachFrameMessage {
+	return &CoachFrameMessage{
+		Message: message.Message{Type: "coach_frame"},
+		Ended:   true,
+	}
+}
+
+func (m CoachFrameMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// SendPriority lets frames give way to the game, though not the end of it.
+func (m CoachFrameMessage) SendPriority() net.Priority {
+	if m.Ended {
+		return net.PriorityControl
+	}
+
+	return net.PriorityState
+}
+
+func (m CoachFrameMessage) CoalesceKey() string {
+	return "coach_frame"
+}
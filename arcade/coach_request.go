@@ -0,0 +1,29 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// CoachRequestMessage asks the host to invite a peer to coach the sender.
+type CoachRequestMessage struct {
+	message.Message
+	LobbyID string
+	CoachID string
+
+	// The sender's name, for the invite
+	PlayerName string
+}
+
+func NewCoachRequestMessage(lobbyID, coachID, playerName string) *CoachRequestMessage {
+	return &CoachRequestMessage{
+		Message:    message.Message{Type: "coach_request"},
+		LobbyID:    lobbyID,
+		CoachID:    coachID,
+		PlayerName: playerName,
+	}
+}
+
+func (m CoachRequestMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,140 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"encoding"
+	"fmt"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// CoachView shows a coach the screen of the player they're coaching, for as
+// long as the player is in a game.
+type CoachView struct {
+	View
+	mgr *ViewManager
+
+	mu sync.RWMutex
+
+	lobbyID    string
+	hostID     string
+	playerID   string
+	playerName string
+
+	// The player's last frame
+	rows [][]CoachRun
+}
+
+func NewCoachView(mgr *ViewManager, lobby *Lobby, playerID string) *CoachView {
+	lobby.mu.RLock()
+	defer lobby.mu.RUnlock()
+
+	return &CoachView{
+		mgr:        mgr,
+		lobbyID:    lobby.ID,
+		hostID:     lobby.HostID,
+		playerID:   playerID,
+		playerName: lobby.playerName(playerID),
+	}
+}
+
+func (v *CoachView) Init() {
+}
+
+func (v *CoachView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *ClientDisconnectedEvent:
+		if evt.ClientID == v.playerID {
+			notify("Lost connection to %s", v.playerName)
+			v.leave()
+		}
+	}
+}
+
+func (v *CoachView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	switch p := p.(type) {
+	case *CoachFrameMessage:
+		if p.SenderID != v.playerID {
+			return nil
+		}
+
+		if p.Ended {
+			notify("%s's game is over", v.playerName)
+			v.leave()
+			return nil
+		}
+
+		v.mu.Lock()
+		v.rows = p.Rows
+		v.mu.Unlock()
+	}
+
+	return nil
+}
+
+// leave stops coaching and goes back to the lobby list. The lobby ends with
+// the game, so there's nothing to go back to.
+func (v *CoachView) leave() {
+	if host, ok := arcade.Server.Network.GetClient(v.hostID); ok {
+		go arcade.Server.Network.Send(host, NewLeaveMessage(arcade.Server.ID, v.lobbyID))
+	}
+
+	arcade.Server.EndAllHeartbeats()
+	v.mgr.SetView(NewGamesListView(v.mgr))
+}
+
+// Back stops coaching.
+func (v *CoachView) Back() bool {
+	v.leave()
+	return true
+}
+
+func (v *CoachView) Render(s *Screen) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	s.ClearContent()
+
+	startX, startY := s.offset()
+	width, height := s.displaySize()
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+
+	if v.rows == nil {
+		msg := fmt.Sprintf("Waiting for %s's game...", v.playerName)
+		s.DrawText(layout.Center(width, msg), height/2, sty, msg)
+	}
+
+	for y, runs := range v.rows {
+		if y >= height {
+			break
+		}
+
+		x := 0
+
+		for _, run := range runs {
+			runSty := tcell.StyleDefault.Foreground(run.Fg).Background(run.Bg).Bold(run.Bold)
+
+			for _, r := range run.Text {
+				if x >= width {
+					break
+				}
+
+				// Colors were themed on the player's screen already
+				s.Screen.SetContent(startX+x, startY+y, r, nil, runSty)
+				x += layout.Width(string(r))
+			}
+		}
+	}
+
+	label := fmt.Sprintf(" Coaching %s ", v.playerName)
+	s.DrawText(width-layout.Width(label)-1, 0, sty.Reverse(true), label)
+}
+
+func (v *CoachView) Unload() {
+}
+
+func (v *CoachView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
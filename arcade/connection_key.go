@@ -0,0 +1,58 @@
+package arcade
+
+import (
+	"arcade/arcade/net"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	gonet "net"
+)
+
+// Salts the origins the distributor hands out, so hosts can't work out a
+// player's address by hashing every address there is. Made fresh every run.
+var originSalt = newOriginSalt()
+
+func newOriginSalt() []byte {
+	salt := make([]byte, 16)
+
+	if _, err := rand.Read(salt); err != nil {
+		panic(err)
+	}
+
+	return salt
+}
+
+// addrHost returns the host part of the address, so each port a machine
+// connects from doesn't count as another machine.
+func addrHost(addr string) string {
+	if host, _, err := gonet.SplitHostPort(addr); err == nil {
+		return host
+	}
+
+	return addr
+}
+
+// messageOrigin is what the distributor stamps on messages it relays from
+// the address: enough for the recipient to tell one connection from another,
+// without learning where it is.
+func messageOrigin(addr string) string {
+	sum := sha256.Sum256(append(append([]byte(nil), originSalt...), addrHost(addr)...))
+	return hex.EncodeToString(sum[:8])
+}
+
+// connectionKey names where a message really came from, for limits that a
+// sender mustn't dodge by changing the ID it puts on its messages. That's
+// the address of the connection it arrived on, or for messages relayed by
+// the distributor, the origin the distributor stamped on them. The
+// distributor's own messages have no origin.
+func connectionKey(c *net.Client, origin string) string {
+	c.RLock()
+	distributor, addr := c.Distributor, c.Addr
+	c.RUnlock()
+
+	if distributor && origin != "" {
+		return "relay:" + origin
+	}
+
+	return "addr:" + addrHost(addr)
+}
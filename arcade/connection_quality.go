@@ -0,0 +1,141 @@
+package arcade
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// Heartbeats that loss and jitter are worked out over
+	qualityWindow = 20
+
+	// Loss and jitter past which a connection's shown as poor
+	poorLoss   = 0.1
+	poorJitter = 40 * time.Millisecond
+
+	// Time without hearing from a peer before their connection counts as
+	// interrupted. They're dropped altogether at timeoutInterval
+	interruptedAfter = 3 * heartbeatInterval
+)
+
+// ConnectionQuality is how well heartbeats to a peer have been getting
+// through lately.
+type ConnectionQuality struct {
+	RTT    time.Duration
+	Jitter time.Duration
+
+	// Fraction of recent heartbeats that went unanswered
+	Loss float64
+
+	// Set if we haven't heard from them in a while
+	Interrupted bool
+}
+
+// Poor returns true if the connection's bad enough that the player should
+// expect to feel it.
+func (q ConnectionQuality) Poor() bool {
+	return q.Interrupted || q.Loss >= poorLoss || q.Jitter >= poorJitter
+}
+
+// String describes what's wrong with the connection, for warnings.
+func (q ConnectionQuality) String() string {
+	switch {
+	case q.Interrupted:
+		return "connection interrupted"
+	case q.Loss >= poorLoss:
+		return fmt.Sprintf("%d%% loss", int(q.Loss*100))
+	case q.Jitter >= poorJitter:
+		return fmt.Sprintf("%dms jitter", q.Jitter.Milliseconds())
+	}
+
+	return fmt.Sprintf("%dms", q.RTT.Milliseconds())
+}
+
+// recordReply adds whether a heartbeat was answered, and how quickly,
+// keeping only the last qualityWindow of each.
+func (c ConnectedClientInfo) recordReply(answered bool, rtt time.Duration) ConnectedClientInfo {
+	c.Replies = append(c.Replies, answered)
+
+	if len(c.Replies) > qualityWindow {
+		c.Replies = c.Replies[len(c.Replies)-qualityWindow:]
+	}
+
+	if answered {
+		c.RTTs = append(c.RTTs, rtt)
+
+		if len(c.RTTs) > qualityWindow {
+			c.RTTs = c.RTTs[len(c.RTTs)-qualityWindow:]
+		}
+	}
+
+	return c
+}
+
+// Quality works out the connection's loss and jitter from its heartbeats.
+// Jitter is the mean change in round trip time from one heartbeat to the
+// next.
+func (c ConnectedClientInfo) Quality(now time.Time) ConnectionQuality {
+	q := ConnectionQuality{
+		RTT:         c.GetMeanRTT(),
+		Interrupted: now.Sub(c.LastHeartbeat) >= interruptedAfter,
+	}
+
+	lost := 0
+
+	for _, answered := range c.Replies {
+		if !answered {
+			lost++
+		}
+	}
+
+	if len(c.Replies) > 0 {
+		q.Loss = float64(lost) / float64(len(c.Replies))
+	}
+
+	if len(c.RTTs) > 1 {
+		var total time.Duration
+
+		for i := 1; i < len(c.RTTs); i++ {
+			diff := c.RTTs[i] - c.RTTs[i-1]
+
+			if diff < 0 {
+				diff = -diff
+			}
+
+			total += diff
+		}
+
+		q.Jitter = total / time.Duration(len(c.RTTs)-1)
+	}
+
+	return q
+}
+
+// ConnectionQuality returns how the connection to the peer is doing, or
+// false if we aren't exchanging heartbeats with them.
+func (s *Server) ConnectionQuality(clientID string) (ConnectionQuality, bool) {
+	info, ok := s.connectedClients.Load(clientID)
+
+	if !ok {
+		return ConnectionQuality{}, false
+	}
+
+	return info.(ConnectedClientInfo).Quality(time.Now()), true
+}
+
+// connectionWarning returns a HUD item warning of a poor connection to the
+// host, for players in a game. Hosts don't get one, since everyone else's
+// connection is to them.
+func connectionWarning(hostID string) (HUDItem, bool) {
+	if arcade.Server == nil || hostID == arcade.Server.ID {
+		return HUDItem{}, false
+	}
+
+	q, ok := arcade.Server.ConnectionQuality(hostID)
+
+	if !ok || !q.Poor() {
+		return HUDItem{}, false
+	}
+
+	return HUDItem{Label: "⚠ " + q.String(), Warn: true}, true
+}
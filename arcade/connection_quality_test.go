@@ -0,0 +1,45 @@
+package arcade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConnectionQuality(t *testing.T) {
+	now := time.Now()
+	info := ConnectedClientInfo{LastHeartbeat: now}
+
+	for i := 0; i < 10; i++ {
+		info = info.recordReply(true, 50*time.Millisecond)
+	}
+
+	if q := info.Quality(now); q.Poor() || q.Loss != 0 || q.Jitter != 0 {
+		t.Errorf("steady connection: got %+v", q)
+	}
+
+	// Two of twenty lost
+	for i := 0; i < 10; i++ {
+		info = info.recordReply(i%5 != 0, 50*time.Millisecond)
+	}
+
+	if q := info.Quality(now); q.Loss != 0.1 || !q.Poor() {
+		t.Errorf("lossy connection: got %+v", q)
+	}
+
+	// Only the last qualityWindow heartbeats count
+	for i := 0; i < qualityWindow; i++ {
+		info = info.recordReply(true, time.Duration(20+i%2*60)*time.Millisecond)
+	}
+
+	if q := info.Quality(now); q.Loss != 0 || q.Jitter != 60*time.Millisecond || !q.Poor() {
+		t.Errorf("jittery connection: got %+v", q)
+	}
+
+	if len(info.RTTs) != qualityWindow || len(info.Replies) != qualityWindow {
+		t.Errorf("kept %d round trips and %d replies", len(info.RTTs), len(info.Replies))
+	}
+
+	if q := info.Quality(now.Add(interruptedAfter)); !q.Interrupted {
+		t.Errorf("silent connection: got %+v", q)
+	}
+}
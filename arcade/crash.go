@@ -0,0 +1,200 @@
+package arcade
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	CRASHES_DIRNAME = "crashes"
+	REJOIN_FILENAME = "rejoin.json"
+
+	// How long after a crash the next run still offers to rejoin
+	rejoinMaxAge = 10 * time.Minute
+
+	// Most of the other goroutines' stacks kept in a crash report
+	crashStackSize = 1 << 20
+)
+
+// rejoinInfo is the lobby we were in when we crashed, saved so the next run
+// can offer to go back to it.
+type rejoinInfo struct {
+	LobbyID   string
+	LobbyName string
+	HostID    string
+	HostAddr  string
+	Code      string
+	CrashedAt time.Time
+}
+
+var (
+	// The lobby we're playing in, kept apart from the views so a crash
+	// doesn't need their locks to find it
+	lastLobbyMu sync.Mutex
+	lastLobby   *rejoinInfo
+)
+
+// rememberLobby notes the lobby someone else is hosting that we've joined.
+func rememberLobby(lobby *Lobby) {
+	lobby.mu.RLock()
+	info := &rejoinInfo{
+		LobbyID:   lobby.ID,
+		LobbyName: lobby.Name,
+		HostID:    lobby.HostID,
+		Code:      lobby.Code,
+	}
+	lobby.mu.RUnlock()
+
+	if info.HostID == arcade.Server.ID {
+		// Our lobby goes down with us
+		forgetLobby()
+		return
+	}
+
+	if host, ok := arcade.Server.Network.GetClient(info.HostID); ok {
+		info.HostAddr = host.Addr
+	}
+
+	lastLobbyMu.Lock()
+	defer lastLobbyMu.Unlock()
+
+	lastLobby = info
+}
+
+// forgetLobby notes that we're not in a lobby.
+func forgetLobby() {
+	lastLobbyMu.Lock()
+	defer lastLobbyMu.Unlock()
+
+	lastLobby = nil
+}
+
+// recoverCrash is deferred at the top of the main loop and game goroutines.
+// On a panic it puts the terminal back the way it was, writes a crash report
+// and saves the lobby we were in before exiting.
+func (mgr *ViewManager) recoverCrash() {
+	r := recover()
+
+	if r == nil {
+		return
+	}
+
+	if mgr != nil && mgr.screen != nil && mgr.screen.Screen != nil {
+		mgr.screen.Fini()
+	}
+
+	log.Printf("Crashed: %v\n%s", r, debug.Stack())
+	fmt.Fprintln(os.Stderr, "ASCII Arcade crashed:", r)
+
+	if file, err := writeCrashReport(r); err == nil {
+		fmt.Fprintln(os.Stderr, "A crash report was saved to", file)
+	} else {
+		fmt.Fprintln(os.Stderr, "Couldn't save a crash report:", err)
+	}
+
+	if err := saveRejoin(); err != nil {
+		log.Println("Couldn't save the lobby to rejoin:", err)
+	}
+
+	os.Exit(1)
+}
+
+// writeCrashReport saves the panic with the stacks of every goroutine, and
+// returns where.
+func writeCrashReport(r interface{}) (string, error) {
+	dir, err := configDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	dir = path.Join(dir, CRASHES_DIRNAME)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	stacks := make([]byte, crashStackSize)
+	stacks = stacks[:runtime.Stack(stacks, true)]
+
+	report := fmt.Sprintf("ASCII Arcade crashed at %s\n%s %s/%s\n\npanic: %v\n\n%s\nAll goroutines:\n\n%s",
+		now.Format(time.RFC1123), runtime.Version(), runtime.GOOS, runtime.GOARCH, r, debug.Stack(), stacks)
+
+	file := path.Join(dir, fmt.Sprintf("crash-%s.txt", now.Format("20060102-150405")))
+	return file, os.WriteFile(file, []byte(report), 0644)
+}
+
+func rejoinPath() (string, error) {
+	dir, err := configDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, REJOIN_FILENAME), nil
+}
+
+// saveRejoin saves the lobby we were in, if any, for the next run.
+func saveRejoin() error {
+	lastLobbyMu.Lock()
+	info := lastLobby
+	lastLobbyMu.Unlock()
+
+	if info == nil {
+		return nil
+	}
+
+	file, err := rejoinPath()
+
+	if err != nil {
+		return err
+	}
+
+	info.CrashedAt = time.Now()
+	data, err := json.Marshal(info)
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, data, 0644)
+}
+
+// loadRejoin returns the lobby we were in when we last crashed, if it was
+// recently. It's only offered once.
+func loadRejoin() *rejoinInfo {
+	file, err := rejoinPath()
+
+	if err != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(file)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		log.Println("Couldn't read the lobby to rejoin:", err)
+		return nil
+	}
+
+	os.Remove(file)
+
+	var info rejoinInfo
+
+	if err := json.Unmarshal(data, &info); err != nil || time.Since(info.CrashedAt) > rejoinMaxAge {
+		return nil
+	}
+
+	return &info
+}
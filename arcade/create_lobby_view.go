@@ -0,0 +1,336 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"encoding"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	maxLobbyNameLength = 24
+	minPasswordLength  = 4
+)
+
+var lobbyCapacities = map[string][]string{
+	Tron: {"2", "3", "4", "5", "6", "7", "8"},
+	Pong: {"2", "3", "4"},
+}
+
+var createLobbyFooter = "Tab/↑↓ to move     ←/→ to change     Enter to select"
+
+// What the arena selector's called for each game
+var lobbyArenaLabels = map[string]string{
+	Tron: "Shrinking arena",
+	Pong: "Obstacles",
+}
+
+const noModName = "none"
+
+// CreateLobbyView is the form for setting up a new lobby, with a preview of
+// how it will look in the lobby list.
+type CreateLobbyView struct {
+	BaseView
+	View
+
+	nameField          *TextField
+	gameSelector       *Selector
+	capacitySelector   *Selector
+	visibilitySelector *Selector
+	spectateSelector   *Selector
+	arenaSelector      *Selector
+	mapSelector        *Selector
+	modSelector        *Selector
+	timeSelector       *Selector
+	idleSelector       *Selector
+	unreadySelector    *Selector
+	passwordField      *TextField
+
+	// Our custom Tron maps and mods, by name
+	maps map[string]*TronMap
+	mods map[string]*TronMod
+}
+
+type lobbyFormError struct {
+	y   int
+	msg string
+}
+
+const (
+	clvFormX     = 4
+	clvFormWidth = 36
+
+	clvPreviewX1 = 44
+	clvPreviewX2 = 75
+	clvPreviewY1 = 4
+	clvPreviewY2 = 20
+
+	clvButtonWidth = 16
+)
+
+func NewCreateLobbyView(mgr *ViewManager) *CreateLobbyView {
+	v := &CreateLobbyView{
+		BaseView: NewBaseView(mgr),
+	}
+
+	v.nameField = NewTextField(clvFormX, 5, clvFormWidth, "Lobby name")
+	v.nameField.SetMaxLength(maxLobbyNameLength)
+
+	if profile, err := LoadProfile(); err == nil && profile.Name != "" {
+		v.nameField.value = profile.Name + "'s lobby"
+		v.nameField.cursorPos = len(v.nameField.value)
+	}
+
+	v.gameSelector = NewSelector(clvFormX, 8, clvFormWidth, "Game", []string{Tron, Pong})
+	v.capacitySelector = NewSelector(clvFormX, 9, clvFormWidth, "Players", lobbyCapacities[Tron])
+	v.visibilitySelector = NewSelector(clvFormX, 10, clvFormWidth, "Visibility", []string{"public", "private"})
+	v.spectateSelector = NewSelector(clvFormX, 11, clvFormWidth, "Spectators (public)", []string{"off", "on"})
+	v.arenaSelector = NewSelector(clvFormX, 12, clvFormWidth, lobbyArenaLabels[Tron], []string{"off", "on"})
+
+	v.maps = make(map[string]*TronMap)
+	mapNames := []string{classicMapName}
+
+	for _, m := range loadTronMaps() {
+		v.maps[m.Name] = m
+		mapNames = append(mapNames, m.Name)
+	}
+
+	v.mapSelector = NewSelector(clvFormX, 13, clvFormWidth, "Map (Tron)", mapNames)
+
+	v.mods = make(map[string]*TronMod)
+	modNames := []string{noModName}
+
+	for _, m := range loadTronMods() {
+		v.mods[m.Name] = m
+		modNames = append(modNames, m.Name)
+	}
+
+	v.modSelector = NewSelector(clvFormX, 14, clvFormWidth, "Mod (Tron)", modNames)
+
+	v.timeSelector = NewSelector(clvFormX, 15, clvFormWidth, "Time limit", matchTimeLimits)
+	v.idleSelector = NewSelector(clvFormX, 16, clvFormWidth, "Mark idle after", lobbyIdleTimeouts)
+	v.idleSelector.SetValue(fmt.Sprintf("%d sec", defaultIdleTimeout))
+	v.unreadySelector = NewSelector(clvFormX, 17, clvFormWidth, "Unready idle players", []string{"on", "off"})
+
+	v.passwordField = NewTextField(clvFormX, 19, clvFormWidth, "Password (optional)")
+	v.passwordField.SetMasked(true)
+	v.passwordField.SetMaxLength(maxPasswordLength)
+
+	// Capacity choices, and what the arena selector does, depend on the game
+	v.gameSelector.OnChange(func(game string) {
+		v.capacitySelector.SetOptions(lobbyCapacities[game])
+		v.arenaSelector.SetLabel(lobbyArenaLabels[game])
+	})
+
+	v.SetComponents(v, []Component{
+		v.nameField,
+		v.gameSelector,
+		v.capacitySelector,
+		v.visibilitySelector,
+		v.spectateSelector,
+		v.arenaSelector,
+		v.mapSelector,
+		v.modSelector,
+		v.timeSelector,
+		v.idleSelector,
+		v.unreadySelector,
+		v.passwordField,
+		NewButton(clvPreviewX1, 21, clvButtonWidth, "CREATE", v.create),
+		NewButton(clvPreviewX2-clvButtonWidth+1, 21, clvButtonWidth, "CANCEL", func() {
+			mgr.PopView()
+		}),
+	})
+
+	return v
+}
+
+// validate returns a problem for each field that isn't filled in correctly,
+// along with the row of the field.
+func (v *CreateLobbyView) validate() []lobbyFormError {
+	errs := make([]lobbyFormError, 0)
+	name := strings.TrimSpace(v.nameField.Value())
+	password := v.passwordField.Value()
+
+	if name == "" {
+		errs = append(errs, lobbyFormError{6, "Name is required"})
+	}
+
+	if m := v.selectedMap(); m != nil {
+		capacity, _ := strconv.Atoi(v.capacitySelector.Value())
+
+		if spawns := len(m.Spawns()); spawns < capacity {
+			errs = append(errs, lobbyFormError{13, fmt.Sprintf("Map only has %d spawns", spawns)})
+		}
+	}
+
+	if password != "" && len(password) < minPasswordLength {
+		errs = append(errs, lobbyFormError{20, fmt.Sprintf("Password needs %d+ characters", minPasswordLength)})
+	}
+
+	return errs
+}
+
+// selectedMap returns the custom map chosen for a Tron lobby, or nil for the
+// classic arena.
+func (v *CreateLobbyView) selectedMap() *TronMap {
+	if v.gameSelector.Value() != Tron {
+		return nil
+	}
+
+	return v.maps[v.mapSelector.Value()]
+}
+
+// selectedMod returns the mod chosen for a Tron lobby, or nil for none.
+func (v *CreateLobbyView) selectedMod() *TronMod {
+	if v.gameSelector.Value() != Tron {
+		return nil
+	}
+
+	return v.mods[v.modSelector.Value()]
+}
+
+func (v *CreateLobbyView) create() {
+	if len(v.validate()) > 0 {
+		return
+	}
+
+	game := v.gameSelector.Value()
+	capacity, _ := strconv.Atoi(v.capacitySelector.Value())
+	private := v.visibilitySelector.Value() == "private"
+
+	lobby := NewLobby(strings.TrimSpace(v.nameField.Value()), private, game, capacity, arcade.Server.ID)
+	lobby.Obstacles = game == Pong && v.arenaSelector.Value() == "on"
+	lobby.Spectatable = !private && v.spectateSelector.Value() == "on"
+	lobby.TimeLimit = parseTimeLimit(v.timeSelector.Value())
+	lobby.ShrinkArena = game == Tron && v.arenaSelector.Value() == "on"
+	lobby.IdleTimeout = parseIdleTimeout(v.idleSelector.Value())
+	lobby.AutoUnready = lobby.IdleTimeout > 0 && v.unreadySelector.Value() == "on"
+	lobby.Region = v.mgr.Region()
+	lobby.SetPassword(v.passwordField.Value())
+
+	if m := v.selectedMap(); m != nil {
+		lobby.MapName, lobby.MapHash = m.Name, m.Hash()
+	}
+
+	// Try the mod out first, so it's not only found broken once the game
+	// starts
+	if m := v.selectedMod(); m != nil {
+		if _, err := m.Rules(modSeed(lobby.ID, m), capacity, v.selectedMap()); err != nil {
+			notify("The mod %s doesn't work: %v", m.Name, err)
+			return
+		}
+
+		lobby.ModName, lobby.ModHash = m.Name, m.Hash()
+	}
+
+	v.mgr.offerTutorial(game, func() {
+		v.mgr.ReplaceView(NewLobbyView(v.mgr, lobby))
+	})
+}
+
+func (v *CreateLobbyView) Init() {
+}
+
+func (v *CreateLobbyView) ProcessEvent(evt interface{}) {
+	v.RLock()
+	c := v.components[v.componentIndex]
+	v.RUnlock()
+
+	c.ProcessEvent(evt)
+}
+
+func (v *CreateLobbyView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *CreateLobbyView) Render(s *Screen) {
+	s.Clear()
+
+	width, height := s.displaySize()
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	boldSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorLightGreen)
+	errSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorRed)
+
+	s.DrawBlockText(CenterX, 1, sty, "CREATE LOBBY", false)
+
+	v.RLock()
+	for _, c := range v.components {
+		c.Render(s)
+	}
+	v.RUnlock()
+
+	// Mark fields with problems
+	errs := v.validate()
+
+	for _, err := range errs {
+		s.DrawText(clvFormX-2, err.y, errSty, "!")
+	}
+
+	// Draw preview of the lobby
+	s.DrawBox(clvPreviewX1, clvPreviewY1, clvPreviewX2, clvPreviewY2, sty, false)
+	s.DrawText(clvPreviewX1+2, clvPreviewY1, sty, " PREVIEW ")
+
+	game := v.gameSelector.Value()
+	name := strings.TrimSpace(v.nameField.Value())
+
+	if name == "" {
+		name = "(no name)"
+	}
+
+	visibility := "Public"
+
+	if v.visibilitySelector.Value() == "private" {
+		visibility = "Private, join code"
+	}
+
+	password := "none"
+
+	if v.passwordField.Value() != "" {
+		password = "required"
+	}
+
+	lines := []string{
+		fmt.Sprintf("%s - 1/%s players", game, v.capacitySelector.Value()),
+		visibility,
+		"Password: " + password,
+	}
+
+	if game == Pong {
+		lines = append(lines, "Obstacles: "+v.arenaSelector.Value())
+	} else {
+		lines = append(lines, "Map: "+v.mapSelector.Value())
+		lines = append(lines, "Mod: "+v.modSelector.Value())
+		lines = append(lines, "Shrinking arena: "+v.arenaSelector.Value())
+	}
+
+	if v.visibilitySelector.Value() == "public" {
+		lines = append(lines, "Spectators: "+v.spectateSelector.Value())
+	}
+
+	lines = append(lines, "Time limit: "+v.timeSelector.Value())
+	lines = append(lines, "Idle after: "+v.idleSelector.Value())
+
+	s.DrawText(clvPreviewX1+2, clvPreviewY1+2, boldSty, name)
+
+	for i, line := range lines {
+		s.DrawText(clvPreviewX1+2, clvPreviewY1+4+i, sty, line)
+	}
+
+	for i, err := range errs {
+		s.DrawText(clvPreviewX1+2, clvPreviewY2-len(errs)+i, errSty, err.msg)
+	}
+
+	s.DrawText(layout.Center(width, createLobbyFooter), height-2, sty, createLobbyFooter)
+}
+
+func (v *CreateLobbyView) Unload() {
+}
+
+func (v *CreateLobbyView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
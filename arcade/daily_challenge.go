@@ -0,0 +1,284 @@
+package arcade
+
+import (
+	"arcade/arcade/net"
+	"arcade/arcade/widgets"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	challengeDayFormat = "2006-01-02"
+
+	// Top scores sent with the day's challenge
+	challengeStandings = 5
+
+	// Longest name kept with a score
+	maxChallengeNameLength = 24
+)
+
+var (
+	errChallengeOver   = errors.New("that challenge is over")
+	errChallengePlayed = errors.New("already played today's challenge")
+)
+
+// DailyChallenge is the game everyone plays on a day: the same game, against
+// the same bots, with the same seed.
+type DailyChallenge struct {
+	Day      string
+	GameType string
+	Skill    string
+	Seed     string
+}
+
+// ChallengeScore is one player's score at a day's challenge.
+type ChallengeScore struct {
+	PlayerID string
+	Name     string
+	Score    int
+}
+
+// challengeDay returns the day it is at t. Days are in UTC, so they change at
+// the same moment for everyone.
+func challengeDay(t time.Time) string {
+	return t.UTC().Format(challengeDayFormat)
+}
+
+// dailyChallengeFor picks the day's challenge. It depends on nothing but the
+// day, so it doesn't change when the distributor restarts.
+func dailyChallengeFor(day string) DailyChallenge {
+	sum := sha256.Sum256([]byte("asciiarcade daily challenge " + day))
+	n := binary.BigEndian.Uint64(sum[:8])
+
+	games := localGameNames()
+
+	// Easy bots wouldn't be much of a challenge
+	skills := botSkills[1:]
+
+	return DailyChallenge{
+		Day:      day,
+		GameType: games[n%uint64(len(games))],
+		Skill:    skills[(n/uint64(len(games)))%uint64(len(skills))].Name,
+		Seed:     hex.EncodeToString(sum[:]),
+	}
+}
+
+// ChallengeBoard is the distributor's leaderboard for the day's challenge.
+// Each player gets one score a day, and the board starts over when the day
+// changes. Scores are taken at the player's word, like practice games.
+type ChallengeBoard struct {
+	mu sync.Mutex
+
+	day string
+
+	// In the order they were played, so earlier scores win ties
+	scores []ChallengeScore
+	played map[string]bool
+}
+
+func NewChallengeBoard() *ChallengeBoard {
+	return &ChallengeBoard{played: make(map[string]bool)}
+}
+
+func (b *ChallengeBoard) handleMessage(c *net.Client, msg interface{}) (interface{}, bool) {
+	switch msg := msg.(type) {
+	case *ChallengeQueryMessage:
+		return b.info(msg.SenderID, time.Now()), true
+	case *ChallengeScoreMessage:
+		score := ChallengeScore{PlayerID: msg.SenderID, Name: msg.Name, Score: msg.Score}
+
+		if err := b.Submit(score, msg.Day, time.Now()); err != nil {
+			return NewErrorMessage(err.Error()), true
+		}
+
+		return b.info(msg.SenderID, time.Now()), true
+	}
+
+	return nil, false
+}
+
+// today starts the board over if the day's changed, and returns it. Expects
+// the lock to be held.
+func (b *ChallengeBoard) today(now time.Time) string {
+	if day := challengeDay(now); day != b.day {
+		b.day = day
+		b.scores = nil
+		b.played = make(map[string]bool)
+	}
+
+	return b.day
+}
+
+// Submit records the player's score at the day's challenge, if it's still the
+// day and they haven't played it.
+func (b *ChallengeBoard) Submit(score ChallengeScore, day string, now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if day != b.today(now) {
+		return errChallengeOver
+	}
+
+	if b.played[score.PlayerID] {
+		return errChallengePlayed
+	}
+
+	if len(score.Name) > maxChallengeNameLength {
+		score.Name = score.Name[:maxChallengeNameLength]
+	}
+
+	b.played[score.PlayerID] = true
+	b.scores = append(b.scores, score)
+
+	sort.SliceStable(b.scores, func(i, j int) bool {
+		return b.scores[i].Score > b.scores[j].Score
+	})
+
+	return nil
+}
+
+// info returns the day's challenge and standings, with the player's own score
+// if they've played it.
+func (b *ChallengeBoard) info(playerID string, now time.Time) *ChallengeInfoMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	day := b.today(now)
+	top := b.scores
+
+	if len(top) > challengeStandings {
+		top = top[:challengeStandings]
+	}
+
+	msg := NewChallengeInfoMessage(dailyChallengeFor(day), append([]ChallengeScore(nil), top...), len(b.scores))
+
+	for i, score := range b.scores {
+		if score.PlayerID == playerID {
+			score := score
+			msg.Mine = &score
+			msg.Rank = i + 1
+			break
+		}
+	}
+
+	return msg
+}
+
+// botSkillNamed returns the bot skill with the name.
+func botSkillNamed(name string) (BotSkill, bool) {
+	for _, skill := range botSkills {
+		if skill.Name == name {
+			return skill, true
+		}
+	}
+
+	return BotSkill{}, false
+}
+
+// showDailyChallenge asks the distributor for the day's challenge, and offers
+// to play it if we haven't.
+func showDailyChallenge(mgr *ViewManager) {
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		notify("Daily challenges need a connection to the distributor")
+		return
+	}
+
+	res, err := arcade.Server.Network.SendAndReceive(distributor, NewChallengeQueryMessage())
+	info, ok := res.(*ChallengeInfoMessage)
+
+	if !ok || err != nil {
+		notify("Couldn't get today's challenge")
+		return
+	}
+
+	challenge := info.Challenge
+	skill, ok := botSkillNamed(challenge.Skill)
+
+	if _, known := localGameTypes[challenge.GameType]; !ok || !known {
+		notify("Today's challenge needs a newer arcade")
+		return
+	}
+
+	lines := []string{
+		fmt.Sprintf("%s against %s bots, the same game for everyone.", challenge.GameType, skill.Name),
+		"",
+	}
+
+	lines = append(lines, challengeStandingsLines(info)...)
+
+	if info.Mine != nil {
+		mgr.ShowModal(widgets.NewModal("Daily challenge "+challenge.Day, lines, []string{"OK"}, func(int) {}))
+		return
+	}
+
+	lines = append(lines, "", "You only get one try.")
+
+	mgr.ShowModal(widgets.NewModal("Daily challenge "+challenge.Day, lines, []string{"Play", "Cancel"}, func(choice int) {
+		if choice == 0 {
+			mgr.PushView(NewChallengeView(mgr, challenge, skill))
+		}
+	}))
+}
+
+// challengeStandingsLines describes the day's top scores, and ours.
+func challengeStandingsLines(info *ChallengeInfoMessage) []string {
+	if len(info.Standings) == 0 {
+		return []string{"Nobody's played it yet."}
+	}
+
+	lines := []string{fmt.Sprintf("Top scores of %d:", info.Players)}
+
+	for i, score := range info.Standings {
+		name := filterText(score.Name)
+
+		if name == "" {
+			name = shortID(score.PlayerID, 8)
+		}
+
+		lines = append(lines, fmt.Sprintf("%d. %-24s %6d", i+1, name, score.Score))
+	}
+
+	if info.Mine != nil {
+		lines = append(lines, fmt.Sprintf("You scored %d, ranked %d.", info.Mine.Score, info.Rank))
+	}
+
+	return lines
+}
+
+// submitChallengeScore sends our score at the challenge to the distributor,
+// and tells us how we ranked.
+func submitChallengeScore(challenge DailyChallenge, score int) {
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		notify("Lost the distributor, your challenge score wasn't sent")
+		return
+	}
+
+	name := ""
+
+	if profile, err := LoadProfile(); err == nil {
+		name = profile.Name
+	}
+
+	res, err := arcade.Server.Network.SendAndReceive(distributor, NewChallengeScoreMessage(challenge.Day, name, score))
+
+	switch res := res.(type) {
+	case *ChallengeInfoMessage:
+		notify("Challenge score %d, ranked %d of %d", score, res.Rank, res.Players)
+	case *ErrorMessage:
+		notify("Challenge score not counted: %s", res.Text)
+	default:
+		log.Println("Couldn't send challenge score:", err)
+		notify("Couldn't send your challenge score")
+	}
+}
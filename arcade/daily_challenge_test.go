@@ -0,0 +1,59 @@
+package arcade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDailyChallengeIsTheSameAllDay(t *testing.T) {
+	morning := time.Date(2024, 3, 1, 0, 5, 0, 0, time.UTC)
+	evening := time.Date(2024, 3, 1, 23, 55, 0, 0, time.UTC)
+
+	if a, b := dailyChallengeFor(challengeDay(morning)), dailyChallengeFor(challengeDay(evening)); a != b {
+		t.Fatalf("got %+v in the morning and %+v in the evening", a, b)
+	}
+
+	challenge := dailyChallengeFor(challengeDay(morning))
+
+	if _, ok := localGameTypes[challenge.GameType]; !ok {
+		t.Errorf("challenge is %s, which can't be played locally", challenge.GameType)
+	}
+
+	if _, err := NewSeededMatchRNG(challenge.Seed); err != nil {
+		t.Errorf("challenge seed is unusable: %v", err)
+	}
+}
+
+func TestChallengeBoardTakesOneScoreADay(t *testing.T) {
+	b := NewChallengeBoard()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	day := challengeDay(now)
+
+	if err := b.Submit(ChallengeScore{PlayerID: "a", Score: 10}, day, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.Submit(ChallengeScore{PlayerID: "a", Score: 99}, day, now); err != errChallengePlayed {
+		t.Errorf("second try got %v", err)
+	}
+
+	if err := b.Submit(ChallengeScore{PlayerID: "b", Score: 20}, day, now); err != nil {
+		t.Fatal(err)
+	}
+
+	info := b.info("a", now)
+
+	if info.Players != 2 || info.Standings[0].PlayerID != "b" || info.Rank != 2 || info.Mine.Score != 10 {
+		t.Errorf("got standings %+v, rank %d", info.Standings, info.Rank)
+	}
+
+	tomorrow := now.Add(24 * time.Hour)
+
+	if err := b.Submit(ChallengeScore{PlayerID: "c", Score: 5}, day, tomorrow); err != errChallengeOver {
+		t.Errorf("yesterday's score got %v", err)
+	}
+
+	if info := b.info("a", tomorrow); info.Players != 0 || info.Mine != nil {
+		t.Errorf("board didn't start over, got %+v", info)
+	}
+}
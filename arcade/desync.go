@@ -0,0 +1,153 @@
+package arcade
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// Ticks between players sending the host a checksum of their state
+	stateChecksumInterval = 20
+
+	// Checksums the host keeps of its own state, to compare late ones with
+	stateChecksumHistory = 64
+)
+
+// StateChecksum is a hash of a game state at a tick, along with a hash of
+// each field so a mismatch can say what differs.
+type StateChecksum struct {
+	Tick   int
+	Hash   uint64
+	Fields map[string]uint64
+}
+
+// newStateChecksum hashes the state's JSON. Objects' fields are hashed two
+// levels down, so "ClientStates.<player ID>" can be told apart from the ball.
+func newStateChecksum(tick int, state interface{}) StateChecksum {
+	data, err := json.Marshal(state)
+
+	if err != nil {
+		return StateChecksum{Tick: tick}
+	}
+
+	checksum := StateChecksum{
+		Tick:   tick,
+		Hash:   hashBytes(data),
+		Fields: make(map[string]uint64),
+	}
+
+	var fields map[string]json.RawMessage
+
+	if json.Unmarshal(data, &fields) != nil {
+		return checksum
+	}
+
+	for name, value := range fields {
+		checksum.Fields[name] = hashBytes(value)
+
+		var members map[string]json.RawMessage
+
+		if json.Unmarshal(value, &members) != nil {
+			continue
+		}
+
+		for key, member := range members {
+			checksum.Fields[name+"."+key] = hashBytes(member)
+		}
+	}
+
+	return checksum
+}
+
+func hashBytes(data []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(data)
+
+	return h.Sum64()
+}
+
+// diff returns the fields that differ between the checksums, most specific
+// first.
+func (c StateChecksum) diff(other StateChecksum) []string {
+	fields := make([]string, 0)
+
+	for name, hash := range c.Fields {
+		if other.Fields[name] != hash {
+			fields = append(fields, name)
+		}
+	}
+
+	for name := range other.Fields {
+		if _, ok := c.Fields[name]; !ok {
+			fields = append(fields, name)
+		}
+	}
+
+	// A field whose members differ is left out, since its members say more
+	sort.Strings(fields)
+	specific := make([]string, 0, len(fields))
+
+	for i, name := range fields {
+		if i+1 < len(fields) && strings.HasPrefix(fields[i+1], name+".") {
+			continue
+		}
+
+		specific = append(specific, name)
+	}
+
+	return specific
+}
+
+// DesyncDetector is kept by the host, to compare players' checksums with
+// what its own state was at the same tick.
+type DesyncDetector struct {
+	mu sync.Mutex
+
+	gameID    string
+	checksums map[int]StateChecksum
+	ticks     []int
+}
+
+func NewDesyncDetector(gameID string) *DesyncDetector {
+	return &DesyncDetector{
+		gameID:    gameID,
+		checksums: make(map[int]StateChecksum),
+	}
+}
+
+// Record keeps the host's checksum for the tick.
+func (d *DesyncDetector) Record(checksum StateChecksum) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.checksums[checksum.Tick]; !ok {
+		d.ticks = append(d.ticks, checksum.Tick)
+	}
+
+	d.checksums[checksum.Tick] = checksum
+
+	if len(d.ticks) > stateChecksumHistory {
+		delete(d.checksums, d.ticks[0])
+		d.ticks = d.ticks[1:]
+	}
+}
+
+// Check compares a player's checksum with the host's, and logs a report if
+// they differ. Returns true if the player has drifted and needs resyncing.
+// Checksums for ticks the host no longer remembers are ignored.
+func (d *DesyncDetector) Check(playerID string, checksum StateChecksum) bool {
+	d.mu.Lock()
+	ours, ok := d.checksums[checksum.Tick]
+	d.mu.Unlock()
+
+	if !ok || ours.Hash == checksum.Hash {
+		return false
+	}
+
+	log.Printf("Desync in %s: %s at tick %d differs in %s\n", d.gameID, playerID, checksum.Tick, strings.Join(ours.diff(checksum), ", "))
+	return true
+}
@@ -0,0 +1,292 @@
+package arcade
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Upper bounds of the timing buckets. Anything slower lands in one more after
+// them.
+var diagnosticsBuckets = []time.Duration{
+	100 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	16 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+}
+
+// Anything slower than a frame at 60 fps is a hitch someone could notice
+const hitchThreshold = 16 * time.Millisecond
+
+// How many of the most recent GC pauses are shown
+const diagnosticsGCPauses = 8
+
+// What frame timings are kept under, next to the message types
+const diagnosticsFrames = "(frame)"
+
+// Diagnostics times message handlers and frames, to chase down hitches.
+// Servers made outside of Start, like in tests, have none, and a nil
+// Diagnostics records nothing.
+type Diagnostics struct {
+	mu sync.Mutex
+
+	// Where pprof is served, if it is
+	PprofAddr string
+
+	timings map[string]*latencyHistogram
+
+	// The last handler that took longer than a frame
+	slowest     string
+	slowestTook time.Duration
+}
+
+type latencyHistogram struct {
+	Name   string
+	Counts []int
+	N      int
+	Total  time.Duration
+	Max    time.Duration
+}
+
+func NewDiagnostics(pprofAddr string) *Diagnostics {
+	return &Diagnostics{
+		PprofAddr: pprofAddr,
+		timings:   make(map[string]*latencyHistogram),
+	}
+}
+
+// observe notes that something under the name took from start until now.
+func (d *Diagnostics) observe(name string, start time.Time) {
+	if d == nil {
+		return
+	}
+
+	took := time.Since(start)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, ok := d.timings[name]
+
+	if !ok {
+		h = &latencyHistogram{Name: name, Counts: make([]int, len(diagnosticsBuckets)+1)}
+		d.timings[name] = h
+	}
+
+	bucket := sort.Search(len(diagnosticsBuckets), func(i int) bool {
+		return took < diagnosticsBuckets[i]
+	})
+
+	h.Counts[bucket]++
+	h.N++
+	h.Total += took
+
+	if took > h.Max {
+		h.Max = took
+	}
+}
+
+// handlerTrace times one message through handleMessage, under the branch it
+// ended up in.
+type handlerTrace struct {
+	d           *Diagnostics
+	messageType string
+	branch      string
+	start       time.Time
+}
+
+// trace starts timing a message of the type. It returns nil, which traces
+// nothing, if d is.
+func (d *Diagnostics) trace(messageType string) *handlerTrace {
+	if d == nil {
+		return nil
+	}
+
+	return &handlerTrace{d: d, messageType: messageType, start: time.Now()}
+}
+
+// in notes which branch is handling the message.
+func (t *handlerTrace) in(branch string) {
+	if t != nil {
+		t.branch = branch
+	}
+}
+
+// done records the time taken, and logs it if it was long enough to hold up
+// everything received after it.
+func (t *handlerTrace) done() {
+	if t == nil {
+		return
+	}
+
+	name := t.messageType
+
+	if t.branch != "" {
+		name += "/" + t.branch
+	}
+
+	t.d.observe(name, t.start)
+
+	if took := time.Since(t.start); took >= hitchThreshold {
+		log.Printf("Handling '%s' took %v\n", name, took)
+
+		t.d.mu.Lock()
+		t.d.slowest, t.d.slowestTook = name, took
+		t.d.mu.Unlock()
+	}
+}
+
+// Slowest returns the last handler that took longer than a frame, and how
+// long it took.
+func (d *Diagnostics) Slowest() (string, time.Duration, bool) {
+	if d == nil {
+		return "", 0, false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.slowest, d.slowestTook, d.slowest != ""
+}
+
+// observeFrame notes how long a frame took to draw, from start until now.
+func (d *Diagnostics) observeFrame(start time.Time) {
+	d.observe(diagnosticsFrames, start)
+}
+
+// Timings returns a copy of every histogram, slowest first.
+func (d *Diagnostics) Timings() []latencyHistogram {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	timings := make([]latencyHistogram, 0, len(d.timings))
+
+	for _, h := range d.timings {
+		c := *h
+		c.Counts = append([]int(nil), h.Counts...)
+		timings = append(timings, c)
+	}
+
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].Max != timings[j].Max {
+			return timings[i].Max > timings[j].Max
+		}
+
+		return timings[i].Name < timings[j].Name
+	})
+
+	return timings
+}
+
+// Reset forgets every timing so far.
+func (d *Diagnostics) Reset() {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.timings = make(map[string]*latencyHistogram)
+	d.slowest, d.slowestTook = "", 0
+}
+
+type runtimeStats struct {
+	Goroutines int
+	HeapAlloc  uint64
+	HeapSys    uint64
+	NumGC      uint32
+	PauseTotal time.Duration
+
+	// Most recent first
+	Pauses []time.Duration
+}
+
+// readRuntimeStats reads the numbers shown next to the timings. It stops the
+// world briefly, so it shouldn't be called more than every second or so.
+func readRuntimeStats() runtimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stats := runtimeStats{
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  m.HeapAlloc,
+		HeapSys:    m.HeapSys,
+		NumGC:      m.NumGC,
+		PauseTotal: time.Duration(m.PauseTotalNs),
+	}
+
+	for i := uint32(0); i < m.NumGC && i < diagnosticsGCPauses; i++ {
+		// PauseNs is a circular buffer, the latest at (NumGC+255)%256
+		stats.Pauses = append(stats.Pauses, time.Duration(m.PauseNs[(m.NumGC-1-i)%uint32(len(m.PauseNs))]))
+	}
+
+	return stats
+}
+
+// startPprof serves net/http/pprof on the address. The directory API has its
+// own mux, so the default one only ever has pprof on it.
+func startPprof(addr string) {
+	go func() {
+		if err := http.ListenAndServe(addr, nil); err != nil {
+			log.Println("Couldn't serve pprof:", err)
+		}
+	}()
+}
+
+// printUsage is flag.PrintDefaults without the flags that have no usage,
+// which are kept out of sight.
+func printUsage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
+
+	flag.VisitAll(func(f *flag.Flag) {
+		if f.Usage == "" {
+			return
+		}
+
+		name, usage := flag.UnquoteUsage(f)
+		line := "  -" + f.Name
+
+		if name != "" {
+			line += " " + name
+		}
+
+		line += "\n    \t" + usage
+
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			line += fmt.Sprintf(" (default %v)", f.DefValue)
+		}
+
+		fmt.Fprintln(out, line)
+	})
+
+	fmt.Fprintf(out, "\nCommands:\n  serve [-lobbies file]\n    \tHost the lobbies listed in the file with no screen, until interrupted (default file %s)\n", defaultServeConfigFile)
+}
+
+// formatBytes shows a byte count in the biggest unit that keeps it above one.
+func formatBytes(b uint64) string {
+	switch {
+	case b >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(b)/(1<<30))
+	case b >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(b)/(1<<20))
+	case b >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(b)/(1<<10))
+	}
+
+	return fmt.Sprintf("%d B", b)
+}
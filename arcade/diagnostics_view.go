@@ -0,0 +1,202 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"encoding"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const diagnosticsRefreshInterval = time.Second
+
+// DiagnosticsView shows the runtime's numbers and how long message handlers
+// and frames have taken, refreshed every second.
+type DiagnosticsView struct {
+	View
+	mgr *ViewManager
+
+	mu sync.RWMutex
+
+	stats        runtimeStats
+	timings      []latencyHistogram
+	stopTickerCh chan bool
+}
+
+var diagnosticsFooter = "[C]lear timings   [Esc] Back"
+
+func NewDiagnosticsView(mgr *ViewManager) *DiagnosticsView {
+	return &DiagnosticsView{
+		mgr:          mgr,
+		stopTickerCh: make(chan bool),
+	}
+}
+
+func (v *DiagnosticsView) Init() {
+	ticker := time.NewTicker(diagnosticsRefreshInterval)
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				v.refresh()
+			case <-v.stopTickerCh:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	go v.refresh()
+}
+
+func (v *DiagnosticsView) refresh() {
+	stats := readRuntimeStats()
+	timings := arcade.Diagnostics.Timings()
+
+	v.mu.Lock()
+	v.stats = stats
+	v.timings = timings
+	v.mu.Unlock()
+
+	v.mgr.RequestRender()
+}
+
+func (v *DiagnosticsView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		if evt.Key() == tcell.KeyRune && (evt.Rune() == 'c' || evt.Rune() == 'C') {
+			arcade.Diagnostics.Reset()
+			go v.refresh()
+		}
+	}
+}
+
+func (v *DiagnosticsView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *DiagnosticsView) Render(s *Screen) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	width, height := s.displaySize()
+
+	const (
+		tableWidth = 72
+		maxRows    = 9
+	)
+
+	var (
+		tableX1 = (width-tableWidth)/2 - 1
+		tableX2 = width - (width-tableWidth)/2
+		tableY1 = 11
+
+		nameColX    = tableX1 + 1
+		countColX   = tableX1 + 16
+		maxColX     = tableX1 + 23
+		bucketsColX = tableX1 + 30
+		bucketWidth = 6
+	)
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	slowSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorRed)
+
+	s.DrawBlockText(CenterX, 1, sty, "DIAGNOSTICS", false)
+
+	stats := v.stats
+	s.DrawEmpty(tableX1, 4, tableX2, 7, sty)
+	s.DrawText(tableX1, 4, sty, fmt.Sprintf("Goroutines: %d", stats.Goroutines))
+	s.DrawText(tableX1, 5, sty, fmt.Sprintf("Heap: %s in use of %s", formatBytes(stats.HeapAlloc), formatBytes(stats.HeapSys)))
+	s.DrawText(tableX1, 6, sty, fmt.Sprintf("GC: %d runs, %s paused in all", stats.NumGC, stats.PauseTotal.Round(time.Microsecond)))
+
+	pauses := make([]string, len(stats.Pauses))
+
+	for i, pause := range stats.Pauses {
+		pauses[i] = formatMillis(pause)
+	}
+
+	s.DrawText(tableX1, 7, sty, layout.Truncate("Last pauses: "+strings.Join(pauses, " "), tableWidth))
+
+	if addr := arcade.Diagnostics.PprofAddr; addr != "" {
+		s.DrawText(tableX1+40, 4, sty, layout.Truncate("pprof on "+addr, tableWidth-40))
+	}
+
+	s.DrawText(tableX1, tableY1-3, sty, "Times in ms. Red rows took longer than a frame at least once.")
+	s.DrawText(nameColX, tableY1-1, sty, "HANDLER")
+	s.DrawText(countColX, tableY1-1, sty, "COUNT")
+	s.DrawText(maxColX, tableY1-1, sty, "MAX")
+
+	for i, bound := range diagnosticsBuckets {
+		s.DrawText(bucketsColX+i*bucketWidth, tableY1-1, sty, fmt.Sprintf("<%g", float64(bound)/float64(time.Millisecond)))
+	}
+
+	s.DrawText(bucketsColX+len(diagnosticsBuckets)*bucketWidth, tableY1-1, sty, "more")
+
+	for y := tableY1; y < tableY1+maxRows; y++ {
+		s.DrawEmpty(tableX1, y, tableX2, y, sty)
+	}
+
+	if len(v.timings) == 0 {
+		msg := "Nothing timed yet."
+		s.DrawText(layout.Center(width, msg), tableY1+1, sty, msg)
+	}
+
+	for i, h := range v.timings {
+		if i >= maxRows {
+			break
+		}
+
+		y := tableY1 + i
+		rowSty := sty
+
+		if h.Max >= hitchThreshold {
+			rowSty = slowSty
+		}
+
+		s.DrawText(nameColX, y, rowSty, layout.Truncate(h.Name, countColX-nameColX-1))
+		s.DrawText(countColX, y, rowSty, formatCount(h.N))
+		s.DrawText(maxColX, y, rowSty, formatMillis(h.Max))
+
+		for j, count := range h.Counts {
+			s.DrawText(bucketsColX+j*bucketWidth, y, rowSty, formatCount(count))
+		}
+	}
+
+	s.DrawText(layout.Center(width, diagnosticsFooter), height-2, sty, diagnosticsFooter)
+}
+
+func (v *DiagnosticsView) Unload() {
+	v.stopTickerCh <- true
+}
+
+func (v *DiagnosticsView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
+
+// formatMillis shows a duration in milliseconds, short enough for a column.
+func formatMillis(d time.Duration) string {
+	ms := float64(d) / float64(time.Millisecond)
+
+	if ms < 10 {
+		return fmt.Sprintf("%.1fms", ms)
+	}
+
+	return fmt.Sprintf("%.0fms", ms)
+}
+
+// formatCount keeps counts to five characters, for the histogram columns.
+func formatCount(n int) string {
+	switch {
+	case n >= 10_000_000:
+		return fmt.Sprintf("%dM", n/1_000_000)
+	case n >= 100_000:
+		return fmt.Sprintf("%dk", n/1000)
+	}
+
+	return fmt.Sprint(n)
+}
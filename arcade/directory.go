@@ -0,0 +1,314 @@
+package arcade
+
+import (
+	"arcade/arcade/net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Players that haven't reported their presence in this long are considered
+// offline.
+const presenceTimeout = 3 * presenceInterval
+
+// Lobbies one host can advertise at a time, unless the operator changes it
+const defaultmaxLobbiesPerHost = 2
+
+type presenceEntry struct {
+	Presence
+	lastSeen time.Time
+}
+
+// Directory is the distributor's view of who is online. Clients report their
+// presence to it periodically, and query it for the status of their friends.
+type Directory struct {
+	mu sync.RWMutex
+
+	// Whether player names are run through the profanity filter
+	FilterNames bool
+
+	// Lobbies one host can advertise at a time, or 0 for no limit
+	maxLobbiesPerHost int
+
+	presences map[string]presenceEntry
+
+	// When each host's lobbies were last advertised, by host and lobby ID
+	lobbies map[string]map[string]time.Time
+
+	// Lobbies their hosts have closed, and when, so presences still showing
+	// them don't bring them back
+	closed map[string]time.Time
+}
+
+func NewDirectory() *Directory {
+	return &Directory{
+		maxLobbiesPerHost: defaultmaxLobbiesPerHost,
+		presences:         make(map[string]presenceEntry),
+		lobbies:           make(map[string]map[string]time.Time),
+		closed:            make(map[string]time.Time),
+	}
+}
+
+// handleMessage processes messages addressed to the distributor itself. The
+// second return value is false if the message isn't one the directory handles.
+func (d *Directory) handleMessage(c *net.Client, msg interface{}) (interface{}, bool) {
+	switch msg := msg.(type) {
+	case *PresenceMessage:
+		if msg.Presence.PlayerID != msg.SenderID {
+			return NewErrorMessage("invalid presence"), true
+		}
+
+		d.updatePresence(msg.Presence)
+		return nil, true
+	case *FriendsQueryMessage:
+		return NewFriendsReplyMessage(d.lookupFriends(msg.Friends)), true
+	case *LobbyStateMessage:
+		d.updateLobbyState(msg.SenderID, msg.LobbyID, msg.State)
+		return nil, true
+	}
+
+	return nil, false
+}
+
+func (d *Directory) updatePresence(presence Presence) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.FilterNames {
+		presence.Name = FilterProfanity(presence.Name)
+	}
+
+	for id, closedAt := range d.closed {
+		if time.Since(closedAt) > presenceTimeout {
+			delete(d.closed, id)
+		}
+	}
+
+	// Lobbies past the host's cap, or closed, aren't shown to anyone
+	if presence.LobbyID != "" && (d.isClosed(presence.LobbyID) || !d.allowLobby(presence.HostID, presence.LobbyID)) {
+		presence.LobbyID = ""
+		presence.HostID = ""
+	}
+
+	if presence.Lobby != nil && !d.listable(presence.PlayerID, presence.Lobby) {
+		presence.Lobby = nil
+	}
+
+	lobbies := presence.Lobbies[:0:0]
+
+	for _, lobby := range presence.Lobbies {
+		if lobby != nil && d.listable(presence.PlayerID, lobby) {
+			lobbies = append(lobbies, lobby)
+		}
+	}
+
+	presence.Lobbies = lobbies
+
+	d.presences[presence.PlayerID] = presenceEntry{
+		Presence: presence,
+		lastSeen: time.Now(),
+	}
+}
+
+func (d *Directory) lookupFriends(friends []Friend) []Presence {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	online := make([]Presence, 0)
+
+	for id, entry := range d.presences {
+		if time.Since(entry.lastSeen) > presenceTimeout {
+			delete(d.presences, id)
+			continue
+		}
+
+		for _, friend := range friends {
+			if friend.Matches(entry.Presence) {
+				online = append(online, entry.Presence)
+				break
+			}
+		}
+	}
+
+	return online
+}
+
+// SetMaxLobbiesPerHost changes how many lobbies a host can advertise at a
+// time, or takes the limit away if it's 0.
+func (d *Directory) SetMaxLobbiesPerHost(max int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.maxLobbiesPerHost = max
+}
+
+// AllowLobby records that the host is advertising the lobby, and returns false
+// if that's more lobbies than a host may have.
+func (d *Directory) AllowLobby(hostID, lobbyID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.allowLobby(hostID, lobbyID)
+}
+
+// allowLobby is AllowLobby for when the lock is held.
+func (d *Directory) allowLobby(hostID, lobbyID string) bool {
+	lobbies, ok := d.lobbies[hostID]
+
+	if !ok {
+		lobbies = make(map[string]time.Time)
+		d.lobbies[hostID] = lobbies
+	}
+
+	for id, lastSeen := range lobbies {
+		if time.Since(lastSeen) > presenceTimeout {
+			delete(lobbies, id)
+		}
+	}
+
+	if _, ok := lobbies[lobbyID]; !ok && d.maxLobbiesPerHost > 0 && len(lobbies) >= d.maxLobbiesPerHost {
+		return false
+	}
+
+	lobbies[lobbyID] = time.Now()
+	return true
+}
+
+// updateLobbyState records the state of a lobby the host is advertising. Once
+// it's closed it isn't listed, and no longer counts towards the host's cap.
+func (d *Directory) updateLobbyState(hostID, lobbyID string, state LobbyState) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, ok := d.presences[hostID]
+
+	if !ok {
+		return
+	}
+
+	// Summaries are copied rather than changed, since they may be being
+	// encoded for the API
+	update := func(lobby *LobbySummary) *LobbySummary {
+		if lobby == nil || lobby.ID != lobbyID {
+			return lobby
+		} else if state == LobbyClosed {
+			return nil
+		}
+
+		updated := *lobby
+		updated.State = state
+		return &updated
+	}
+
+	entry.Lobby = update(entry.Lobby)
+	lobbies := make([]*LobbySummary, 0, len(entry.Lobbies))
+
+	for _, lobby := range entry.Lobbies {
+		if lobby = update(lobby); lobby != nil {
+			lobbies = append(lobbies, lobby)
+		}
+	}
+
+	entry.Lobbies = lobbies
+
+	if state == LobbyClosed {
+		delete(d.lobbies[hostID], lobbyID)
+		d.closed[lobbyID] = time.Now()
+	}
+
+	d.presences[hostID] = entry
+}
+
+// listable returns whether a lobby the player says they host can be listed:
+// it's theirs, it's open, and it's within their cap. Expects the lock to be
+// held.
+func (d *Directory) listable(playerID string, lobby *LobbySummary) bool {
+	return lobby.HostID == playerID && !d.isClosed(lobby.ID) && d.allowLobby(playerID, lobby.ID)
+}
+
+// isClosed returns whether the lobby's host has closed it. Expects the lock to
+// be held.
+func (d *Directory) isClosed(lobbyID string) bool {
+	_, ok := d.closed[lobbyID]
+	return ok
+}
+
+// Lobbies returns the public lobbies hosted by players who are online, other
+// than those that have closed or have no one left in them.
+func (d *Directory) Lobbies() []LobbySummary {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	lobbies := make([]LobbySummary, 0)
+
+	for _, entry := range d.presences {
+		if time.Since(entry.lastSeen) > presenceTimeout {
+			continue
+		}
+
+		for _, lobby := range append([]*LobbySummary{entry.Lobby}, entry.Lobbies...) {
+			if lobby == nil || lobby.Private || d.isClosed(lobby.ID) {
+				continue
+			}
+
+			if lobby.State == LobbyClosed || lobby.Players == 0 && !lobby.Dedicated {
+				continue
+			}
+
+			lobbies = append(lobbies, *lobby)
+		}
+	}
+
+	return lobbies
+}
+
+// Online returns how many players are online, and how many of those are in a
+// game.
+func (d *Directory) Online() (online, playing int) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, entry := range d.presences {
+		if time.Since(entry.lastSeen) > presenceTimeout {
+			continue
+		}
+
+		online++
+
+		if strings.HasPrefix(entry.Activity, "playing ") {
+			playing++
+		}
+	}
+
+	return online, playing
+}
+
+// snapshot returns the presences of players who are online, to save.
+func (d *Directory) snapshot() []Presence {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	presences := make([]Presence, 0, len(d.presences))
+
+	for _, entry := range d.presences {
+		if time.Since(entry.lastSeen) <= presenceTimeout {
+			presences = append(presences, entry.Presence)
+		}
+	}
+
+	return presences
+}
+
+// restore brings back saved presences. They count as just seen, so players
+// stay listed while they reconnect after a restart.
+func (d *Directory) restore(presences []Presence) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, presence := range presences {
+		d.presences[presence.PlayerID] = presenceEntry{
+			Presence: presence,
+			lastSeen: time.Now(),
+		}
+	}
+}
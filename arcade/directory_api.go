@@ -0,0 +1,158 @@
+package arcade
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// Leaderboard entries returned when the request doesn't say how many
+	defaultAPIStandings = 50
+
+	// How long a client has to send its request and read the reply, and to
+	// keep a connection open between requests. The API's public, so clients
+	// that trickle bytes in or never read can't hold connections forever
+	apiReadHeaderTimeout = 5 * time.Second
+	apiReadTimeout       = 10 * time.Second
+	apiWriteTimeout      = 10 * time.Second
+	apiIdleTimeout       = time.Minute
+
+	apiMaxHeaderBytes = 8 << 10
+)
+
+type apiLobby struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Game     string `json:"game"`
+	Players  int    `json:"players"`
+	Capacity int    `json:"capacity"`
+}
+
+type apiOnline struct {
+	Players int `json:"players"`
+	Playing int `json:"playing"`
+	Lobbies int `json:"lobbies"`
+}
+
+type apiStanding struct {
+	PlayerID string `json:"player_id"`
+	Played   int    `json:"played"`
+	Wins     int    `json:"wins"`
+}
+
+// serveAPI serves a read-only JSON API of what's on the distributor, for
+// websites and bots that don't speak the game protocol:
+//
+//	GET /api/lobbies               public lobbies, fullest first
+//	GET /api/online                players online and in games
+//	GET /api/leaderboard?limit=n   standings, most wins first
+func (s *Server) serveAPI(addr string) error {
+	return s.apiServer(addr).ListenAndServe()
+}
+
+// apiServer returns the HTTP server for the API on the address.
+func (s *Server) apiServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/lobbies", s.apiHandler(func(r *http.Request) (interface{}, error) {
+		return s.apiLobbies(), nil
+	}))
+
+	mux.HandleFunc("/api/online", s.apiHandler(func(r *http.Request) (interface{}, error) {
+		online, playing := s.directory.Online()
+		return apiOnline{online, playing, len(s.directory.Lobbies())}, nil
+	}))
+
+	mux.HandleFunc("/api/leaderboard", s.apiHandler(func(r *http.Request) (interface{}, error) {
+		limit := defaultAPIStandings
+
+		if value := r.URL.Query().Get("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+
+			if err != nil || n <= 0 {
+				return nil, errBadLimit
+			}
+
+			limit = n
+		}
+
+		standings := make([]apiStanding, 0)
+
+		for _, entry := range s.leaderboard.Standings() {
+			if len(standings) == limit {
+				break
+			}
+
+			standings = append(standings, apiStanding{entry.PlayerID, entry.Played, entry.Wins})
+		}
+
+		return standings, nil
+	}))
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: apiReadHeaderTimeout,
+		ReadTimeout:       apiReadTimeout,
+		WriteTimeout:      apiWriteTimeout,
+		IdleTimeout:       apiIdleTimeout,
+		MaxHeaderBytes:    apiMaxHeaderBytes,
+	}
+}
+
+var errBadLimit = errors.New("limit must be a positive number")
+
+// apiHandler writes what the function returns as JSON, to GET requests from
+// anywhere.
+func (s *Server) apiHandler(f func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"error": "only GET is allowed"})
+			return
+		}
+
+		body, err := f(r)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+func (s *Server) apiLobbies() []apiLobby {
+	summaries := s.directory.Lobbies()
+	lobbies := make([]apiLobby, 0, len(summaries))
+
+	for _, l := range summaries {
+		name := l.Name
+
+		if s.directory.FilterNames {
+			name = FilterProfanity(name)
+		}
+
+		lobbies = append(lobbies, apiLobby{l.ID, name, l.GameType, l.Players, l.Capacity})
+	}
+
+	sort.Slice(lobbies, func(i, j int) bool {
+		if lobbies[i].Players != lobbies[j].Players {
+			return lobbies[i].Players > lobbies[j].Players
+		}
+
+		return lobbies[i].ID < lobbies[j].ID
+	})
+
+	return lobbies
+}
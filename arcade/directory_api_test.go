@@ -0,0 +1,46 @@
+package arcade
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAPIDropsSlowClients(t *testing.T) {
+	server := (&Server{}).apiServer("127.0.0.1:0")
+
+	if server.ReadHeaderTimeout == 0 || server.ReadTimeout == 0 || server.WriteTimeout == 0 || server.IdleTimeout == 0 {
+		t.Fatalf("server has no timeout for some of %+v", server)
+	}
+
+	// Shorter, so the test doesn't wait
+	server.ReadHeaderTimeout = 50 * time.Millisecond
+
+	listener, err := net.Listen("tcp", server.Addr)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	// A request that never finishes its headers
+	conn.Write([]byte("GET /api/online HTTP/1.1\r\nHost: x\r\n"))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	_, err = bufio.NewReader(conn).ReadString('\n')
+
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		t.Error("connection held open past the header timeout")
+	}
+}
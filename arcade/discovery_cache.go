@@ -0,0 +1,62 @@
+package arcade
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// How often we announce ourselves on the LAN, so peers know we're still
+	// around
+	lanAnnounceInterval = 20 * time.Second
+
+	// Time without an announcement before a LAN peer counts as gone
+	lanPeerTTL = 3 * lanAnnounceInterval
+)
+
+// DiscoveryCache remembers the peers found on the LAN and when each last
+// announced itself, so announcements from peers we already know don't make
+// us connect again, and ones that go quiet can be noticed.
+type DiscoveryCache struct {
+	mu    sync.Mutex
+	peers map[string]discoveredPeer
+}
+
+type discoveredPeer struct {
+	Addr string
+	Seen time.Time
+}
+
+func NewDiscoveryCache() *DiscoveryCache {
+	return &DiscoveryCache{peers: make(map[string]discoveredPeer)}
+}
+
+// Seen records an announcement from the peer, and returns true if it's news:
+// a peer we didn't know of, that's moved, or that had gone quiet.
+func (c *DiscoveryCache) Seen(id, addr string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	peer, ok := c.peers[id]
+	c.peers[id] = discoveredPeer{Addr: addr, Seen: now}
+
+	return !ok || peer.Addr != addr || now.Sub(peer.Seen) >= lanPeerTTL
+}
+
+// Expire forgets peers that haven't announced themselves within the TTL, and
+// returns their IDs.
+func (c *DiscoveryCache) Expire(now time.Time) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expired []string
+
+	for id, peer := range c.peers {
+		if now.Sub(peer.Seen) >= lanPeerTTL {
+			expired = append(expired, id)
+			delete(c.peers, id)
+		}
+	}
+
+	return expired
+}
@@ -0,0 +1,35 @@
+package arcade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiscoveryCache(t *testing.T) {
+	c := NewDiscoveryCache()
+	now := time.Now()
+
+	if !c.Seen("a", "10.0.0.2:6824", now) {
+		t.Error("a new peer wasn't news")
+	}
+
+	if c.Seen("a", "10.0.0.2:6824", now.Add(lanAnnounceInterval)) {
+		t.Error("a peer announcing again was news")
+	}
+
+	if !c.Seen("a", "10.0.0.3:6824", now.Add(lanAnnounceInterval)) {
+		t.Error("a peer at a new address wasn't news")
+	}
+
+	c.Seen("b", "10.0.0.4:6824", now.Add(lanPeerTTL))
+
+	expired := c.Expire(now.Add(lanAnnounceInterval + lanPeerTTL))
+
+	if len(expired) != 1 || expired[0] != "a" {
+		t.Errorf("expired %v, want [a]", expired)
+	}
+
+	if !c.Seen("a", "10.0.0.3:6824", now.Add(2*lanPeerTTL)) {
+		t.Error("a peer back after expiring wasn't news")
+	}
+}
@@ -0,0 +1,214 @@
+package arcade
+
+import (
+	"arcade/arcade/net"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	// Sent to players turned away because the distributor is full
+	errServerFull = "server full, try again later"
+
+	// How often the distributor tells players how busy it is
+	loadReportInterval = presenceInterval
+
+	// How long the distributor reports shedding after it last dropped
+	// something
+	sheddingReportTime = 5 * time.Second
+)
+
+// DistributorLoad is how busy a distributor is, sent to its players in
+// heartbeat metadata.
+type DistributorLoad struct {
+	Connections    int
+	MaxConnections int `json:",omitempty"`
+
+	// Bytes relayed in the last second, and the most allowed
+	RelayBytes    int
+	MaxRelayBytes int `json:",omitempty"`
+
+	// True if relayed messages have been dropped recently
+	Shedding bool
+}
+
+// Busy returns true if players should expect trouble connecting or lag.
+func (l DistributorLoad) Busy() bool {
+	return l.Shedding || (l.MaxConnections > 0 && l.Connections*10 >= l.MaxConnections*9)
+}
+
+// loadShedder keeps the distributor within its connection and relay
+// bandwidth caps. Messages for the distributor itself, like presences and
+// friend queries, are never counted, so the directory keeps working however
+// busy relaying gets.
+type loadShedder struct {
+	mu sync.Mutex
+
+	maxConnections int
+	maxRelayBytes  int
+
+	// Token bucket of relay bytes, refilled every second
+	tokens float64
+	last   time.Time
+
+	// Bytes relayed in the current and last second
+	relayed     int
+	lastRelayed int
+	second      time.Time
+
+	lastShed time.Time
+}
+
+func newLoadShedder() *loadShedder {
+	return &loadShedder{}
+}
+
+// setCaps changes the caps, with 0 meaning no limit.
+func (l *loadShedder) setCaps(maxConnections, maxRelayBytes int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.maxConnections = maxConnections
+	l.maxRelayBytes = maxRelayBytes
+	l.tokens = float64(maxRelayBytes)
+	l.last = time.Now()
+}
+
+// full returns true if another player can't connect.
+func (l *loadShedder) full(connections int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.maxConnections > 0 && connections >= l.maxConnections
+}
+
+// admitRelay returns whether to relay a message of the size. Once over the
+// cap, game state and chat are dropped first; control messages can go over
+// by up to half a second's worth, since dropping them breaks lobbies.
+func (l *loadShedder) admitRelay(size int, priority net.Priority) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(l.second) >= time.Second {
+		l.lastRelayed = l.relayed
+		l.relayed = 0
+		l.second = now
+	}
+
+	if l.maxRelayBytes > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * float64(l.maxRelayBytes)
+		l.last = now
+
+		if l.tokens > float64(l.maxRelayBytes) {
+			l.tokens = float64(l.maxRelayBytes)
+		}
+
+		floor := 0.0
+
+		if priority == net.PriorityControl {
+			floor = -float64(l.maxRelayBytes) / 2
+		}
+
+		if l.tokens-float64(size) < floor {
+			l.lastShed = now
+			return false
+		}
+
+		l.tokens -= float64(size)
+	}
+
+	l.relayed += size
+	return true
+}
+
+func (l *loadShedder) load(connections int) DistributorLoad {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return DistributorLoad{
+		Connections:    connections,
+		MaxConnections: l.maxConnections,
+		RelayBytes:     l.lastRelayed,
+		MaxRelayBytes:  l.maxRelayBytes,
+		Shedding:       time.Since(l.lastShed) < sheddingReportTime,
+	}
+}
+
+// SetCapacity caps how many players can connect to the distributor, and how
+// many bytes a second it relays between them. 0 means no limit.
+func (s *Server) SetCapacity(maxConnections, maxRelayBytes int) {
+	s.shedder.setCaps(maxConnections, maxRelayBytes)
+}
+
+// neighbors returns how many players are connected to us directly, besides
+// the one given.
+func (s *Server) neighbors(except *net.Client) int {
+	n := 0
+
+	s.Network.ClientsRange(func(c *net.Client) bool {
+		c.RLock()
+		defer c.RUnlock()
+
+		if c != except && c.Neighbor && c.State == net.Connected {
+			n++
+		}
+
+		return true
+	})
+
+	return n
+}
+
+// startLoadReports periodically tells every connected player how busy the
+// distributor is, in heartbeat metadata.
+func (s *Server) startLoadReports() {
+	ticker := time.NewTicker(loadReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			return
+		}
+
+		load := s.shedder.load(s.neighbors(nil))
+		data, err := json.Marshal(HeartbeatMetadata{
+			Version: heartbeatMetadataVersion,
+			View:    "Distributor",
+			Load:    &load,
+			Region:  s.Region,
+		})
+
+		if err != nil {
+			continue
+		}
+
+		s.Network.ClientsRange(func(c *net.Client) bool {
+			c.RLock()
+			neighbor := c.Neighbor && !c.Distributor
+			c.RUnlock()
+
+			if neighbor {
+				s.Network.Send(c, NewHeartbeatMessage(0, data))
+			}
+
+			return true
+		})
+	}
+}
+
+// DistributorLoad returns how busy the distributor said it was, if it has.
+func (s *Server) DistributorLoad() (DistributorLoad, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	if s.distributorLoad == nil {
+		return DistributorLoad{}, false
+	}
+
+	return *s.distributorLoad, true
+}
@@ -0,0 +1,45 @@
+package arcade
+
+import (
+	"arcade/arcade/net"
+)
+
+// Lobbies the distributor keeps open when it hosts ranked matches. It runs
+// their games itself, so no player gets the host's head start of playing
+// with no lag.
+var rankedLobbies = []ServedLobbyConfig{
+	{Name: "Ranked Pong", Game: Pong, Capacity: 2},
+	{Name: "Ranked Pong for 4", Game: Pong, Capacity: 4},
+}
+
+// HostMatches starts hosting ranked matches, running their games and
+// streaming them to the players. Quick match picks them over lobbies players
+// host.
+func (s *Server) HostMatches() {
+	newLobbyServer("", rankedLobbies)
+}
+
+// handleMatchMessage processes messages from players finding, joining and
+// playing the matches we host. Players ask every distributor for its
+// lobbies, so ones hosting none answer that they have none. The second
+// return value is false if the message isn't about one.
+func (s *Server) handleMatchMessage(c *net.Client, senderID string, msg interface{}) (interface{}, bool) {
+	if reply, ok := s.answerForHostedLobbies(c, msg); ok {
+		return reply, true
+	}
+
+	switch msg := msg.(type) {
+	case *HeartbeatMessage:
+		// Only our players heartbeat us
+		if _, ok := s.connectedClients.Load(senderID); !ok {
+			return nil, false
+		}
+
+		return s.receiveHeartbeat(c, msg), true
+	case *HeartbeatReplyMessage:
+		// Already handed to the heartbeat waiting for it
+		return nil, true
+	}
+
+	return s.routeToLobby(c, senderID, msg)
+}
@@ -0,0 +1,145 @@
+package arcade
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path"
+	"syscall"
+	"time"
+)
+
+const (
+	DISTRIBUTOR_STATE_FILENAME = "distributor.json"
+
+	// How often the distributor saves what it knows, besides when it's stopped
+	distributorSaveInterval = 30 * time.Second
+)
+
+// distributorState is what the distributor saves to pick up where it left
+// off after a restart. Bans are saved separately, as soon as they change.
+type distributorState struct {
+	SavedAt     time.Time
+	Presences   []Presence
+	Leaderboard []LeaderboardEntry
+	Recorded    []string
+	Season      *seasonLadder
+	Workshop    []workshopEntry
+}
+
+func distributorStatePath() (string, error) {
+	dir, err := configDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, DISTRIBUTOR_STATE_FILENAME), nil
+}
+
+// loadState restores the directory and leaderboard from the last save, if
+// there is one.
+func (s *Server) loadState() error {
+	file, err := distributorStatePath()
+
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(file)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var state distributorState
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	s.directory.restore(state.Presences)
+	s.leaderboard.restore(state.Leaderboard, state.Recorded)
+
+	if state.Season != nil {
+		s.leaderboard.restoreSeason(state.Season)
+	}
+
+	s.workshop.restore(state.Workshop)
+
+	fmt.Printf("Restored %d presences and %d players' records from %s\n", len(state.Presences), len(state.Leaderboard), state.SavedAt.Format(time.RFC1123))
+	return nil
+}
+
+// saveState writes the directory and leaderboard to disk, replacing the last
+// save only once the new one is complete.
+func (s *Server) saveState() error {
+	file, err := distributorStatePath()
+
+	if err != nil {
+		return err
+	}
+
+	recorded, entries := s.leaderboard.snapshot()
+	state := distributorState{
+		SavedAt:     time.Now(),
+		Presences:   s.directory.snapshot(),
+		Leaderboard: entries,
+		Recorded:    recorded,
+		Season:      s.leaderboard.snapshotSeason(),
+		Workshop:    s.workshop.snapshot(),
+	}
+
+	data, err := json.Marshal(state)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(file+".tmp", data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(file+".tmp", file)
+}
+
+// startSaving saves the distributor's state periodically, and once more
+// before exiting when it's stopped.
+func (s *Server) startSaving() {
+	ticker := time.NewTicker(distributorSaveInterval)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.saveState(); err != nil {
+				fmt.Println("Couldn't save state:", err)
+			}
+		case <-stop:
+			if err := s.saveState(); err != nil {
+				fmt.Println("Couldn't save state:", err)
+			}
+
+			os.Exit(0)
+		case <-s.ctx.Done():
+			ticker.Stop()
+			signal.Stop(stop)
+
+			if err := s.saveState(); err != nil {
+				fmt.Println("Couldn't save state:", err)
+			}
+
+			return
+		}
+	}
+}
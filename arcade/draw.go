@@ -0,0 +1,184 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Cells are about twice as tall as they are wide, so circles are drawn twice
+// as wide as their radius to look round
+const cellAspect = 2
+
+// PlotLine draws a line of the rune between any two cells, inclusive. Unlike
+// DrawLine, it doesn't need to be straight.
+func (s *Screen) PlotLine(x1, y1, x2, y2 int, style tcell.Style, r rune) {
+	startX, startY := s.offset()
+
+	bresenham(x1, y1, x2, y2, func(x, y int) {
+		s.SetContent(startX+x, startY+y, r, nil, style)
+	})
+}
+
+// FillRect fills the cells from (x1, y1) to (x2, y2), inclusive, with the
+// rune.
+func (s *Screen) FillRect(x1, y1, x2, y2 int, style tcell.Style, r rune) {
+	startX, startY := s.offset()
+
+	for row := y1; row <= y2; row++ {
+		for col := x1; col <= x2; col++ {
+			s.SetContent(startX+col, startY+row, r, nil, style)
+		}
+	}
+}
+
+// DrawCircle draws the outline of a circle of the rune, centered on a cell,
+// with a radius in rows.
+func (s *Screen) DrawCircle(cx, cy, radius int, style tcell.Style, r rune) {
+	s.drawEllipse(cx, cy, radius*cellAspect, radius, false, style, r)
+}
+
+// FillCircle fills a circle with the rune, centered on a cell, with a radius
+// in rows.
+func (s *Screen) FillCircle(cx, cy, radius int, style tcell.Style, r rune) {
+	s.drawEllipse(cx, cy, radius*cellAspect, radius, true, style, r)
+}
+
+func (s *Screen) drawEllipse(cx, cy, rx, ry int, filled bool, style tcell.Style, r rune) {
+	startX, startY := s.offset()
+
+	ellipseCells(rx, ry, filled, func(dx, dy int) {
+		s.SetContent(startX+cx+dx, startY+cy+dy, r, nil, style)
+	})
+}
+
+// Sprite is multi-line ASCII art. Its transparent cells leave whatever's
+// underneath showing, so it can be drawn over a background.
+type Sprite struct {
+	Rows []string
+
+	// Cells of this rune aren't drawn
+	Transparent rune
+}
+
+// NewSprite returns a sprite whose spaces are transparent.
+func NewSprite(rows ...string) Sprite {
+	return Sprite{Rows: rows, Transparent: ' '}
+}
+
+// ParseSprite returns a sprite from art with a row on each line, leaving out
+// an empty first or last line, so it can be written as a raw string.
+func ParseSprite(art string) Sprite {
+	art = strings.TrimPrefix(art, "\n")
+	art = strings.TrimSuffix(art, "\n")
+
+	return NewSprite(strings.Split(art, "\n")...)
+}
+
+// Size returns how many cells across and down the sprite is.
+func (sp Sprite) Size() (int, int) {
+	width := 0
+
+	for _, row := range sp.Rows {
+		if w := layout.Width(row); w > width {
+			width = w
+		}
+	}
+
+	return width, len(sp.Rows)
+}
+
+// DrawSprite draws the sprite with its top left at (x, y), leaving its
+// transparent cells alone.
+func (s *Screen) DrawSprite(x, y int, style tcell.Style, sp Sprite) {
+	startX, startY := s.offset()
+
+	for i, row := range sp.Rows {
+		col := x
+
+		for _, r := range row {
+			if r != sp.Transparent {
+				s.SetContent(startX+col, startY+y+i, r, nil, style)
+			}
+
+			col += layout.RuneWidth(r)
+		}
+	}
+}
+
+// bresenham calls plot for each cell on the line between two cells,
+// inclusive, in order from the first.
+func bresenham(x1, y1, x2, y2 int, plot func(x, y int)) {
+	dx, dy := abs(x2-x1), -abs(y2-y1)
+	sx, sy := 1, 1
+
+	if x1 > x2 {
+		sx = -1
+	}
+
+	if y1 > y2 {
+		sy = -1
+	}
+
+	err := dx + dy
+
+	for {
+		plot(x1, y1)
+
+		if x1 == x2 && y1 == y2 {
+			return
+		}
+
+		e2 := 2 * err
+
+		if e2 >= dy {
+			err += dy
+			x1 += sx
+		}
+
+		if e2 <= dx {
+			err += dx
+			y1 += sy
+		}
+	}
+}
+
+// ellipseCells calls plot with the offset from the center of each cell in
+// an ellipse with the radii, or only those on its edge unless it's filled.
+// Edge cells are the ones next to a cell outside, so the outline has no gaps.
+func ellipseCells(rx, ry int, filled bool, plot func(dx, dy int)) {
+	if rx <= 0 || ry <= 0 {
+		plot(0, 0)
+		return
+	}
+
+	inside := func(dx, dy int) bool {
+		x, y := float64(dx)/float64(rx), float64(dy)/float64(ry)
+
+		// A little over 1, so the cells at the ends of each axis are in
+		return x*x+y*y <= 1.05
+	}
+
+	for dy := -ry; dy <= ry; dy++ {
+		for dx := -rx; dx <= rx; dx++ {
+			if !inside(dx, dy) {
+				continue
+			}
+
+			edge := !inside(dx-1, dy) || !inside(dx+1, dy) || !inside(dx, dy-1) || !inside(dx, dy+1)
+
+			if filled || edge {
+				plot(dx, dy)
+			}
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
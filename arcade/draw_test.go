@@ -0,0 +1,91 @@
+package arcade
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestPlotLine(t *testing.T) {
+	canvas, f := (&Screen{}).Canvas()
+	canvas.PlotLine(2, 1, 8, 4, tcell.StyleDefault, '*')
+
+	// Every column from one end to the other has a cell of the line
+	for x := 2; x <= 8; x++ {
+		found := false
+
+		for y := 1; y <= 4; y++ {
+			found = found || f[y][x].primary == '*'
+		}
+
+		if !found {
+			t.Errorf("gap in the line at x = %d", x)
+		}
+	}
+
+	if f[1][2].primary != '*' || f[4][8].primary != '*' || f[4][2].primary == '*' {
+		t.Error("line doesn't run between its ends")
+	}
+}
+
+func TestBresenhamBackwards(t *testing.T) {
+	var forward, backward [][2]int
+
+	bresenham(0, 0, 5, 2, func(x, y int) { forward = append(forward, [2]int{x, y}) })
+	bresenham(5, 2, 0, 0, func(x, y int) { backward = append(backward, [2]int{x, y}) })
+
+	if len(forward) != 6 || len(backward) != 6 || backward[0] != [2]int{5, 2} || backward[5] != [2]int{0, 0} {
+		t.Errorf("forward %v, backward %v", forward, backward)
+	}
+}
+
+func TestCircles(t *testing.T) {
+	canvas, f := (&Screen{}).Canvas()
+	canvas.DrawCircle(20, 10, 4, tcell.StyleDefault, 'o')
+	canvas.FillCircle(60, 10, 4, tcell.StyleDefault, '#')
+
+	// Twice as wide as it is tall, reaching the ends of each axis
+	for _, cell := range [][2]int{{20, 6}, {20, 14}, {12, 10}, {28, 10}} {
+		if f[cell[1]][cell[0]].primary != 'o' {
+			t.Errorf("outline missing (%d, %d)", cell[0], cell[1])
+		}
+	}
+
+	if f[10][20].primary == 'o' || f[10][60].primary != '#' {
+		t.Error("outline filled in, or fill left empty")
+	}
+
+	if f[10][11].primary == 'o' || f[5][20].primary == 'o' {
+		t.Error("circle bigger than its radius")
+	}
+}
+
+func TestDrawSprite(t *testing.T) {
+	canvas, f := (&Screen{}).Canvas()
+	canvas.FillRect(0, 0, 9, 3, tcell.StyleDefault, '.')
+
+	sprite := ParseSprite(`
+ /\
+/__\
+`)
+
+	if w, h := sprite.Size(); w != 4 || h != 2 {
+		t.Fatalf("sprite is %dx%d, want 4x2", w, h)
+	}
+
+	canvas.DrawSprite(2, 1, tcell.StyleDefault, sprite)
+
+	if f[1][2].primary != '.' || f[1][3].primary != '/' || f[2][2].primary != '/' || f[2][5].primary != '\\' {
+		t.Errorf("got %q and %q", frameRow(f[1][:7]), frameRow(f[2][:7]))
+	}
+}
+
+func frameRow(cells []frameCell) string {
+	row := make([]rune, len(cells))
+
+	for i, c := range cells {
+		row[i] = c.primary
+	}
+
+	return string(row)
+}
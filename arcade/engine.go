@@ -0,0 +1,208 @@
+package arcade
+
+import (
+	"arcade/arcade/multicast"
+	"arcade/arcade/net"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// EngineOptions are what an Engine is started with.
+type EngineOptions struct {
+	// Port to listen on, or the next free one after it
+	Port int
+
+	// The distributor to connect to, or empty to only play over the LAN
+	DistributorAddr string
+
+	// Who we are, or a new identity if nil
+	Identity *Identity
+
+	// Whether to find and be found by players on the LAN, and the network
+	// interface to, or empty for every one
+	LAN          bool
+	LANInterface string
+
+	// How connections are made, KCP if nil
+	Transport net.Transport
+}
+
+// Engine is the arcade without a user interface: networking, finding and
+// joining lobbies, and the events they make. Frontends call its methods and
+// subscribe to its Events. The terminal UI is one, and adds the views that
+// host lobbies and run games on top.
+//
+// The game and its views still find the server through package state, so
+// only one Engine can run in a process.
+type Engine struct {
+	Server *Server
+
+	opts EngineOptions
+}
+
+var errNoHost = errors.New("not connected to the lobby's host")
+
+// NewEngine makes the engine's server and makes it the process's. Views are
+// handed messages through mgr, which can be nil for frontends without them.
+func NewEngine(opts EngineOptions, mgr *ViewManager) *Engine {
+	if opts.Identity == nil {
+		opts.Identity = NewIdentity()
+	}
+
+	registerMessages()
+
+	s := NewServer(fmt.Sprintf("0.0.0.0:%d", opts.Port), opts.Port, false, opts.Identity, mgr)
+
+	if opts.Transport != nil {
+		s.Network.Transport = opts.Transport
+	}
+
+	s.LANInterface = opts.LANInterface
+
+	arcade.Port = opts.Port
+	arcade.LAN = opts.LAN
+	arcade.Server = s
+
+	return &Engine{Server: s, opts: opts}
+}
+
+// Start listens for other players and connects to the distributor, returning
+// once it's tried.
+func (e *Engine) Start() {
+	e.Listen()
+
+	// Give the listener a moment, so the distributor can reach us back
+	time.Sleep(10 * time.Millisecond)
+
+	if e.opts.DistributorAddr != "" {
+		e.Server.Network.Connect(e.opts.DistributorAddr, "", nil)
+	}
+}
+
+// Listen listens for other players in the background. If that stops, a
+// ServerErrorEvent is published, and Listen can be called again, say after
+// changing port.
+func (e *Engine) Listen() {
+	go func() {
+		if err := e.Server.Start(!e.opts.LAN); err != nil {
+			log.Println("Server stopped:", err)
+			e.Server.Events.Publish(NewServerErrorEvent(err))
+		}
+	}()
+}
+
+// Stop stops the engine's server.
+func (e *Engine) Stop() {
+	e.Server.Stop()
+}
+
+// ID returns our player ID.
+func (e *Engine) ID() string {
+	return e.Server.ID
+}
+
+// Events returns where the engine publishes what happens.
+func (e *Engine) Events() *EventBus {
+	return e.Server.Events
+}
+
+// FindLobbies asks everyone we're connected to, our distributor included in
+// case it hosts ranked matches, and anyone on the LAN, for their lobbies. Each one found is handed to found, with how long its host
+// took to answer, as it arrives. It returns once everyone has answered or
+// timed out.
+func (e *Engine) FindLobbies(found func(lobby *Lobby)) {
+	if e.opts.LAN {
+		go multicast.Discover(e.Server.Addr, e.Server.ID, e.Server.Port())
+	}
+
+	var wg sync.WaitGroup
+
+	e.Server.Network.ClientsRange(func(client *net.Client) bool {
+		client.RLock()
+		if client.State != net.Connected && client.State != net.Connecting {
+			client.RUnlock()
+			return true
+		}
+		client.RUnlock()
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if lobby, ok := e.QueryLobby(client); ok {
+				found(lobby)
+			}
+		}()
+
+		return true
+	})
+
+	wg.Wait()
+}
+
+// QueryLobby asks the client what lobby it's hosting, if any, and sets the
+// lobby's ping to how long it took to answer. Lobbies of players we've
+// blocked aren't returned.
+func (e *Engine) QueryLobby(client *net.Client) (*Lobby, bool) {
+	start := time.Now()
+	res, err := e.Server.Network.SendAndReceive(client, NewHelloMessage())
+	end := time.Now()
+
+	p, ok := res.(*LobbyInfoMessage)
+
+	if !ok || err != nil || p.Lobby == nil || IsBlocked(p.Lobby.HostID) {
+		return nil, false
+	}
+
+	p.Lobby.Ping = int(end.Sub(start).Milliseconds())
+	return p.Lobby, true
+}
+
+// Join asks the lobby's host to let us in, with the lobby's code and password
+// if it has them. The host's answer is published as a JoinReplyEvent.
+func (e *Engine) Join(hostID, lobbyID, code, password string) error {
+	host, ok := e.Server.Network.GetClient(hostID)
+
+	if !ok {
+		return errNoHost
+	}
+
+	msg := NewPasswordJoinMessage(code, password, e.Server.ID, lobbyID)
+	msg.Status = e.joinStatus(lobbyID)
+
+	e.Server.Network.Send(host, msg)
+	return nil
+}
+
+// joinStatus is how we'll appear on the lobby's roster when we join.
+func (e *Engine) joinStatus(lobbyID string) LobbyPlayerStatus {
+	status := LobbyPlayerStatus{LobbyID: lobbyID, Name: e.Server.ID[:8]}
+
+	if profile, err := LoadProfile(); err == nil {
+		if profile.Name != "" {
+			status.Name = profile.Name
+		}
+
+		status.Avatar = profile.Avatar
+	}
+
+	if mgr := e.Server.mgr; mgr != nil {
+		status.Rank = mgr.seasonBadge()
+		status.Region = mgr.Region()
+	}
+
+	return status
+}
+
+// Leave tells the lobby's host we're leaving.
+func (e *Engine) Leave(hostID, lobbyID string) {
+	if host, ok := e.Server.Network.GetClient(hostID); ok {
+		e.Server.Network.Send(host, NewLeaveMessage(e.Server.ID, lobbyID))
+	}
+
+	e.Server.EndAllHeartbeats()
+}
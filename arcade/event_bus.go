@@ -0,0 +1,86 @@
+package arcade
+
+import "sync"
+
+// EventTopic is a kind of event, to subscribe to.
+type EventTopic int
+
+const (
+	// Clients connecting and disconnecting, heartbeats, and the server
+	// stopping
+	NetworkEvents EventTopic = iota
+
+	// Lobbies being advertised, changing and ending
+	LobbyEvents
+
+	// Games starting and ending
+	GameEvents
+)
+
+// Event is anything published on an EventBus.
+type Event interface {
+	Topic() EventTopic
+}
+
+// EventBus hands events to whoever subscribed to their topic, so the server
+// doesn't need to know whether it's the view manager, a bot or a test that's
+// listening.
+type EventBus struct {
+	mu sync.RWMutex
+
+	subscriptions []*subscription
+}
+
+type subscription struct {
+	topics []EventTopic
+	handle func(ev Event)
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe has handle called with every event published on the topics, on
+// the publisher's goroutine, until the returned function is called.
+func (b *EventBus) Subscribe(handle func(ev Event), topics ...EventTopic) (unsubscribe func()) {
+	sub := &subscription{topics: topics, handle: handle}
+
+	b.mu.Lock()
+	b.subscriptions = append(b.subscriptions, sub)
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		for i, s := range b.subscriptions {
+			if s == sub {
+				b.subscriptions = append(b.subscriptions[:i:i], b.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish hands the event to everyone subscribed to its topic, in the order
+// they subscribed. Events are shared, so subscribers shouldn't change them.
+func (b *EventBus) Publish(ev Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	subscriptions := b.subscriptions
+	b.mu.RUnlock()
+
+	topic := ev.Topic()
+
+	for _, sub := range subscriptions {
+		for _, t := range sub.topics {
+			if t == topic {
+				sub.handle(ev)
+				break
+			}
+		}
+	}
+}
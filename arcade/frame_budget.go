@@ -0,0 +1,107 @@
+package arcade
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// Frames looked back over to tell whether a view's too slow to draw
+	frameBudgetWindow = 30
+
+	// Frames in the window over budget before a view counts as slow, and at
+	// or under which it's fast enough again
+	frameBudgetStrikes  = 10
+	frameBudgetRecovery = 3
+)
+
+// DegradableView is implemented by views with decorative animation they can
+// leave out while drawing them takes longer than a frame has, so the rest
+// keeps up.
+type DegradableView interface {
+	SetDegraded(degraded bool)
+}
+
+// FrameBudget keeps track of how long the view on screen takes to draw, next
+// to how long a frame has at the FPS cap. One that keeps going over counts as
+// slow until it's been under for a while.
+type FrameBudget struct {
+	mu sync.Mutex
+
+	view string
+
+	// Whether each of the last frames went over, going round, and how many
+	// did
+	over    [frameBudgetWindow]bool
+	next    int
+	strikes int
+
+	slow bool
+
+	// The slowest recent frame, and the budget it had
+	worst  time.Duration
+	budget time.Duration
+}
+
+// Observe notes that drawing the view took as long as it did, with the
+// budget it had. Returns whether that changed whether it's slow.
+func (f *FrameBudget) Observe(view string, took, budget time.Duration) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Views start over with a clean slate
+	if view != f.view {
+		f.view = view
+		f.over = [frameBudgetWindow]bool{}
+		f.strikes, f.slow, f.worst = 0, false, 0
+	}
+
+	over := took > budget
+
+	if f.over[f.next] {
+		f.strikes--
+	}
+
+	if over {
+		f.strikes++
+	}
+
+	f.over[f.next] = over
+	f.next = (f.next + 1) % frameBudgetWindow
+
+	if over && took > f.worst {
+		f.worst = took
+	}
+
+	f.budget = budget
+
+	switch {
+	case !f.slow && f.strikes >= frameBudgetStrikes:
+		f.slow = true
+		return true
+	case f.slow && f.strikes <= frameBudgetRecovery:
+		f.slow = false
+		f.worst = 0
+		return true
+	}
+
+	return false
+}
+
+// Slow returns the view on screen if it's slow, with its slowest recent
+// frame and its budget.
+func (f *FrameBudget) Slow() (string, time.Duration, time.Duration, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.view, f.worst, f.budget, f.slow
+}
+
+// Degraded returns whether decorative animation should be left out, since
+// the view on screen is slow.
+func (f *FrameBudget) Degraded() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.slow
+}
@@ -0,0 +1,44 @@
+package arcade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFrameBudget(t *testing.T) {
+	var f FrameBudget
+	budget := 33 * time.Millisecond
+
+	for i := 0; i < frameBudgetStrikes-1; i++ {
+		if f.Observe("PongGameView", 50*time.Millisecond, budget) {
+			t.Fatalf("slow after %d frames over", i+1)
+		}
+	}
+
+	if !f.Observe("PongGameView", 60*time.Millisecond, budget) || !f.Degraded() {
+		t.Fatal("not slow after enough frames over budget")
+	}
+
+	if view, worst, _, ok := f.Slow(); !ok || view != "PongGameView" || worst != 60*time.Millisecond {
+		t.Errorf("slow view = %s taking %v, want PongGameView taking 60ms", view, worst)
+	}
+
+	// Fast frames push the slow ones out of the window
+	recovered := false
+
+	for i := 0; i < frameBudgetWindow && !recovered; i++ {
+		recovered = f.Observe("PongGameView", time.Millisecond, budget)
+	}
+
+	if !recovered || f.Degraded() {
+		t.Error("still slow after a window of fast frames")
+	}
+
+	for i := 0; i < frameBudgetStrikes; i++ {
+		f.Observe("PongGameView", 50*time.Millisecond, budget)
+	}
+
+	if f.Observe("GamesListView", time.Millisecond, budget) || f.Degraded() {
+		t.Error("a new view started out slow")
+	}
+}
@@ -0,0 +1,106 @@
+package arcade
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const presenceInterval = 5 * time.Second
+
+// Friend is an entry in the local friends list. Friends can be added either by
+// player ID or by name; whichever is set is used to find them online.
+type Friend struct {
+	Name string `json:"name,omitempty"`
+	ID   string `json:"id,omitempty"`
+}
+
+// NewFriend creates a friend from user input, which is treated as a player ID
+// if it looks like one and as a name otherwise.
+func NewFriend(input string) Friend {
+	input = strings.TrimSpace(input)
+
+	if _, err := uuid.Parse(input); err == nil {
+		return Friend{ID: input}
+	}
+
+	return Friend{Name: input}
+}
+
+func (f Friend) Matches(p Presence) bool {
+	if f.ID != "" {
+		return f.ID == p.PlayerID
+	}
+
+	return f.Name != "" && strings.EqualFold(f.Name, p.Name)
+}
+
+func (f Friend) String() string {
+	if f.Name != "" {
+		return f.Name
+	}
+
+	return f.ID
+}
+
+// startPresenceUpdates periodically tells the distributor what we're up to.
+func startPresenceUpdates(mgr *ViewManager) {
+	for {
+		if distributor, ok := arcade.Server.Network.GetDistributor(); ok {
+			arcade.Server.Network.Send(distributor, NewPresenceMessage(mgr.GetPresence()))
+		}
+
+		time.Sleep(presenceInterval)
+	}
+}
+
+// GetPresence describes the current view as a presence for the distributor.
+func (mgr *ViewManager) GetPresence() Presence {
+	presence := Presence{
+		PlayerID: arcade.Server.ID,
+		Activity: "online",
+		Away:     mgr.Away(),
+	}
+
+	if profile, err := LoadProfile(); err == nil {
+		presence.Name = profile.Name
+	}
+
+	mgr.RLock()
+	v := mgr.view
+	mgr.RUnlock()
+
+	switch v := v.(type) {
+	case *GamesListView:
+		presence.Activity = "browsing lobbies"
+	case *CreateLobbyView:
+		presence.Activity = "creating a lobby"
+	case *LobbyView:
+		v.RLock()
+		lobby := v.Lobby
+		v.RUnlock()
+
+		lobby.mu.RLock()
+		presence.Activity = fmt.Sprintf("in lobby: %s %d/%d", lobby.GameType, len(lobby.PlayerIDs), lobby.Capacity)
+
+		if len(lobby.PlayerIDs) < lobby.Capacity {
+			presence.LobbyID = lobby.ID
+			presence.HostID = lobby.HostID
+		}
+
+		if lobby.HostID == arcade.Server.ID {
+			presence.Lobby = lobby.summary()
+		}
+		lobby.mu.RUnlock()
+	case *TronGameView:
+		presence.Activity = "playing " + Tron
+	case *PongGameView:
+		presence.Activity = "playing " + Pong
+	case *SpectateView:
+		presence.Activity = "watching " + v.info.GameType
+	}
+
+	return presence
+}
@@ -0,0 +1,22 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+type FriendsQueryMessage struct {
+	message.Message
+	Friends []Friend
+}
+
+func NewFriendsQueryMessage(friends []Friend) *FriendsQueryMessage {
+	return &FriendsQueryMessage{
+		Message: message.Message{Type: "friends_query"},
+		Friends: friends,
+	}
+}
+
+func (m FriendsQueryMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
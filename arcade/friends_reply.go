@@ -0,0 +1,22 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+type FriendsReplyMessage struct {
+	message.Message
+	Online []Presence
+}
+
+func NewFriendsReplyMessage(online []Presence) *FriendsReplyMessage {
+	return &FriendsReplyMessage{
+		Message: message.Message{Type: "friends_reply"},
+		Online:  online,
+	}
+}
+
+func (m FriendsReplyMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
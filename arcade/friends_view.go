@@ -0,0 +1,390 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"encoding"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const friendsRefreshInterval = 2 * time.Second
+
+type FriendsView struct {
+	View
+	mgr *ViewManager
+
+	mu sync.RWMutex
+
+	friends      []Friend
+	online       map[Friend]Presence
+	selectedRow  int
+	adding       bool
+	input        string
+	errMsg       string
+	stopTickerCh chan bool
+}
+
+var friendsFooter = "[A]dd friend   [D]elete   [J]oin friend   [B]ack"
+
+func NewFriendsView(mgr *ViewManager) *FriendsView {
+	v := &FriendsView{
+		mgr:          mgr,
+		online:       make(map[Friend]Presence),
+		stopTickerCh: make(chan bool),
+	}
+
+	if profile, err := LoadProfile(); err == nil {
+		v.friends = profile.Friends
+	}
+
+	return v
+}
+
+func (v *FriendsView) Init() {
+	ticker := time.NewTicker(friendsRefreshInterval)
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				v.refresh()
+			case <-v.stopTickerCh:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	go v.refresh()
+}
+
+// refresh asks the distributor which of our friends are online.
+func (v *FriendsView) refresh() {
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		return
+	}
+
+	v.mu.RLock()
+	friends := make([]Friend, len(v.friends))
+	copy(friends, v.friends)
+	v.mu.RUnlock()
+
+	res, err := arcade.Server.Network.SendAndReceive(distributor, NewFriendsQueryMessage(friends))
+	reply, ok := res.(*FriendsReplyMessage)
+
+	if !ok || err != nil {
+		return
+	}
+
+	online := make(map[Friend]Presence)
+	filter := profanityFilterEnabled()
+
+	for _, friend := range friends {
+		for _, presence := range reply.Online {
+			if friend.Matches(presence) {
+				if filter {
+					presence.Name = FilterProfanity(presence.Name)
+				}
+
+				online[friend] = presence
+				break
+			}
+		}
+	}
+
+	v.mu.Lock()
+	v.online = online
+	v.mu.Unlock()
+
+	v.mgr.RequestRender()
+}
+
+func (v *FriendsView) saveFriends() {
+	v.mu.RLock()
+	friends := v.friends
+	v.mu.RUnlock()
+
+	if err := UpdateProfile(func(profile *Profile) { profile.Friends = friends }); err != nil {
+		log.Println("Couldn't save friends:", err)
+	}
+}
+
+// Keymap returns the friends list keys, or nil while adding a friend.
+func (v *FriendsView) Keymap() *Keymap {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.adding || v.errMsg != "" {
+		return nil
+	}
+
+	return friendsKeymap
+}
+
+func (v *FriendsView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		v.mu.Lock()
+
+		if v.errMsg != "" {
+			v.errMsg = ""
+			v.mu.Unlock()
+			return
+		}
+
+		if v.adding {
+			v.processAddEvent(evt)
+			return
+		}
+
+		switch friendsKeymap.Action(evt) {
+		case ActionMove:
+			if evt.Key() == tcell.KeyDown && v.selectedRow < len(v.friends)-1 {
+				v.selectedRow++
+			} else if evt.Key() == tcell.KeyUp && v.selectedRow > 0 {
+				v.selectedRow--
+			}
+		case ActionAddFriend:
+			v.adding = true
+			v.input = ""
+		case ActionDeleteFriend:
+			if len(v.friends) == 0 {
+				break
+			}
+
+			v.friends = append(v.friends[:v.selectedRow:v.selectedRow], v.friends[v.selectedRow+1:]...)
+
+			if v.selectedRow > 0 && v.selectedRow >= len(v.friends) {
+				v.selectedRow--
+			}
+
+			v.mu.Unlock()
+			v.saveFriends()
+			return
+		case ActionJoin:
+			v.joinSelected()
+		case ActionBack:
+			v.mu.Unlock()
+			v.mgr.PopView()
+			return
+		}
+
+		v.mu.Unlock()
+	}
+}
+
+// processAddEvent handles typing in the add friend box. Expects the lock to be
+// held and releases it.
+func (v *FriendsView) processAddEvent(evt *tcell.EventKey) {
+	switch evt.Key() {
+	case tcell.KeyEnter:
+		friend := NewFriend(v.input)
+		v.adding = false
+		v.input = ""
+
+		if friend.String() == "" {
+			break
+		}
+
+		for _, f := range v.friends {
+			if f == friend {
+				v.errMsg = "Already on your friends list."
+				v.mu.Unlock()
+				return
+			}
+		}
+
+		v.friends = append(v.friends, friend)
+		v.mu.Unlock()
+
+		v.saveFriends()
+		go v.refresh()
+		return
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(v.input) > 0 {
+			v.input = v.input[:len(v.input)-1]
+		}
+	case tcell.KeyRune:
+		if len(v.input) < 36 {
+			v.input += string(evt.Rune())
+		}
+	}
+
+	v.mu.Unlock()
+}
+
+// joinSelected asks to join the lobby the selected friend is in. Expects the
+// lock to be held.
+func (v *FriendsView) joinSelected() {
+	if len(v.friends) == 0 {
+		return
+	}
+
+	presence, ok := v.online[v.friends[v.selectedRow]]
+
+	if !ok {
+		v.errMsg = "That friend is offline."
+		return
+	}
+
+	if presence.LobbyID == "" {
+		v.errMsg = "That friend isn't in an open lobby."
+		return
+	}
+
+	host, ok := arcade.Server.Network.GetClient(presence.HostID)
+
+	if !ok {
+		v.errMsg = "Couldn't reach the lobby host."
+		return
+	}
+
+	go arcade.Server.Network.Send(host, NewJoinMessage("", arcade.Server.ID, presence.LobbyID))
+}
+
+func (v *FriendsView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	switch p := p.(type) {
+	case *JoinReplyMessage:
+		switch p.Error {
+		case OK:
+			v.mgr.ReplaceView(NewLobbyView(v.mgr, p.Lobby))
+			arcade.Server.BeginHeartbeats(p.Lobby.HostID)
+		case ErrWrongCode:
+			v.mu.Lock()
+			v.errMsg = "That lobby is private."
+			v.mu.Unlock()
+		case ErrCapacity:
+			v.mu.Lock()
+			v.errMsg = "That lobby is full."
+			v.mu.Unlock()
+		case ErrWrongPassword:
+			v.mu.Lock()
+			v.errMsg = "That lobby needs a password."
+			v.mu.Unlock()
+		case ErrRateLimited:
+			v.mu.Lock()
+			v.errMsg = "Too many attempts, try again later."
+			v.mu.Unlock()
+		case ErrIdentity:
+			v.mu.Lock()
+			v.errMsg = "The host couldn't verify who you are."
+			v.mu.Unlock()
+		}
+	}
+
+	return nil
+}
+
+func (v *FriendsView) Render(s *Screen) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	width, height := s.displaySize()
+
+	const (
+		tableWidth  = 72
+		tableHeight = 14
+	)
+
+	var (
+		tableX1 = (width-tableWidth)/2 - 1
+		tableY1 = 7
+		tableX2 = width - (width-tableWidth)/2
+		tableY2 = tableY1 + tableHeight
+
+		nameColX   = tableX1 + 1
+		statusColX = tableX1 + 27
+
+		boxX1 = tableX1 + 4
+		boxY1 = tableY1 + 2
+		boxX2 = tableX2 - 4
+		boxY2 = tableY2 - 3
+	)
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	selectedSty := tcell.StyleDefault.Background(tcell.ColorDarkGreen).Foreground(tcell.ColorWhite)
+	offlineSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGray)
+	boldSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorLightGreen)
+
+	s.DrawBlockText(CenterX, 1, sty, "FRIENDS", false)
+
+	s.DrawBox(tableX1-1, 4, tableX2+1, tableY2+1, sty, true)
+	s.DrawText(layout.Center(width, friendsFooter), height-2, sty, friendsFooter)
+
+	s.DrawText(nameColX, 5, sty, "NAME")
+	s.DrawText(statusColX, 5, sty, "STATUS")
+
+	s.DrawLine(tableX1, 6, tableX2, 6, sty, true)
+	s.DrawText(tableX1-1, 6, sty, "╠")
+	s.DrawText(tableX2+1, 6, sty, "╣")
+
+	for y := tableY1; y <= tableY2; y++ {
+		s.DrawEmpty(tableX1, y, tableX2, y, sty)
+	}
+
+	if len(v.friends) == 0 {
+		msg := "No friends yet. Press [A] to add one by name or ID."
+		s.DrawText(layout.Center(width, msg), tableY1+1, sty, msg)
+	}
+
+	for i, friend := range v.friends {
+		y := tableY1 + i
+
+		if y > tableY2 {
+			break
+		}
+
+		name := friend.String()
+		status := "offline"
+		rowSty := offlineSty
+
+		if presence, ok := v.online[friend]; ok {
+			status = presence.Activity
+			rowSty = sty
+
+			if presence.Away {
+				status += " (away)"
+			}
+
+			if presence.Name != "" {
+				name = presence.Name
+			}
+		}
+
+		if i == v.selectedRow {
+			rowSty = selectedSty
+			s.DrawEmpty(tableX1, y, tableX2, y, rowSty)
+		}
+
+		s.DrawText(nameColX, y, rowSty, layout.Truncate(name, statusColX-nameColX-1))
+		s.DrawText(statusColX, y, rowSty, status)
+	}
+
+	if v.adding || v.errMsg != "" {
+		s.DrawEmpty(boxX1, boxY1, boxX2, boxY2, sty)
+		s.DrawBox(boxX1, boxY1, boxX2, boxY2, sty, true)
+	}
+
+	if v.adding {
+		header := "Add a friend by name or player ID"
+		s.DrawText(layout.Center(width, header), boxY1+2, sty, header)
+		s.DrawText(layout.Center(width, v.input), boxY1+4, boldSty, v.input)
+	} else if v.errMsg != "" {
+		msg := v.errMsg + " Press any key to continue."
+		s.DrawText(layout.Center(width, msg), boxY1+3, boldSty, msg)
+	}
+}
+
+func (v *FriendsView) Unload() {
+	v.stopTickerCh <- true
+}
+
+func (v *FriendsView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
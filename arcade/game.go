@@ -2,8 +2,10 @@ package arcade
 
 import (
 	"arcade/arcade/message"
+	"arcade/arcade/net"
 	"arcade/raft"
 	"encoding/json"
+	"fmt"
 )
 
 const (
@@ -61,14 +63,30 @@ type Game[GS any, CS any] struct {
 	TimestepPeriod int
 	Timestep       int
 	RaftServer     *raft.Raft
+
+	// Random numbers for the match, which only the host can draw from until
+	// the seed is revealed
+	RNG *MatchRNG
+
+	// Set for games the host holds still while a player's connection is
+	// interrupted, rather than playing on without them
+	PauseOnInterrupt bool
+
+	// Our network use when the match started, to report on at the end
+	NetworkStart net.UsageReport
 }
 
 var letters = []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
 
-func NewGame(mgr *ViewManager, lobby *Lobby) {
+func NewGame(mgr *ViewManager, lobby *Lobby, rng *MatchRNG) {
+	arcade.Server.Events.Publish(NewGameStartedEvent(lobby))
+	go reportMatchEntry(lobby)
+
 	switch lobby.GameType {
 	case Tron:
-		mgr.SetView(NewTronGameView(mgr, lobby))
+		mgr.ReplaceView(NewTronGameView(mgr, lobby, rng))
+	case Pong:
+		mgr.ReplaceView(NewPongGameView(mgr, lobby, rng))
 	}
 }
 
@@ -94,6 +112,13 @@ type AckGameUpdateMessage struct {
 type StartGameMessage struct {
 	message.Message
 	GameID string
+
+	// Hash of the match's random seed
+	SeedCommitment string
+
+	// Tron mod the host's playing the match with, which may not be the
+	// lobby's if it didn't work. Empty for none
+	ModHash string `json:",omitempty"`
 }
 
 type EndGameMessage struct {
@@ -105,8 +130,8 @@ func NewEndGameMessage(winner string) *EndGameMessage {
 	return &EndGameMessage{message.Message{Type: "end_game"}, winner}
 }
 
-func NewStartGameMessage(GameID string) *StartGameMessage {
-	return &StartGameMessage{message.Message{Type: "start_game"}, GameID}
+func NewStartGameMessage(GameID string, seedCommitment string, modHash string) *StartGameMessage {
+	return &StartGameMessage{message.Message{Type: "start_game"}, GameID, seedCommitment, modHash}
 }
 
 func NewAckGameUpdateMessage() *AckGameUpdateMessage {
@@ -117,10 +142,28 @@ func (m ClientUpdateMessage[any]) MarshalBinary() ([]byte, error) {
 	return json.Marshal(m)
 }
 
+func (m ClientUpdateMessage[any]) SendPriority() net.Priority {
+	return net.PriorityState
+}
+
+// CoalesceKey lets a player's newer update replace one still waiting to be
+// sent, since updates carry the whole client state.
+func (m ClientUpdateMessage[any]) CoalesceKey() string {
+	return "client_update:" + m.Id
+}
+
 func (m GameUpdateMessage[GS, CS]) MarshalBinary() ([]byte, error) {
 	return json.Marshal(m)
 }
 
+func (m GameUpdateMessage[GS, CS]) SendPriority() net.Priority {
+	return net.PriorityState
+}
+
+func (m GameUpdateMessage[GS, CS]) CoalesceKey() string {
+	return fmt.Sprintf("game_update:%s:%d", m.ID, m.FragmentNum)
+}
+
 func (m StartGameMessage) MarshalBinary() ([]byte, error) {
 	return json.Marshal(m)
 }
@@ -0,0 +1,17 @@
+package arcade
+
+// GameEndedEvent is published when a game we played in ends, with the result
+// as we saw it.
+type GameEndedEvent struct {
+	Result MatchResult
+}
+
+func NewGameEndedEvent(result MatchResult) *GameEndedEvent {
+	return &GameEndedEvent{
+		Result: result,
+	}
+}
+
+func (e *GameEndedEvent) Topic() EventTopic {
+	return GameEvents
+}
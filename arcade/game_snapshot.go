@@ -0,0 +1,34 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"arcade/arcade/net"
+	"encoding/json"
+)
+
+// GameSnapshotMessage carries the host's game state to one player.
+type GameSnapshotMessage struct {
+	message.Message
+	GameID   string
+	Snapshot *Snapshot
+}
+
+func NewGameSnapshotMessage(gameID string, snapshot *Snapshot) *GameSnapshotMessage {
+	return &GameSnapshotMessage{
+		Message:  message.Message{Type: "game_snapshot"},
+		GameID:   gameID,
+		Snapshot: snapshot,
+	}
+}
+
+func (m GameSnapshotMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func (m GameSnapshotMessage) SendPriority() net.Priority {
+	return net.PriorityState
+}
+
+func (m GameSnapshotMessage) CoalesceKey() string {
+	return "game_snapshot:" + m.GameID
+}
@@ -0,0 +1,35 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"arcade/arcade/net"
+	"encoding/json"
+)
+
+// GameSnapshotAckMessage tells the host the newest snapshot a player has, so
+// it can send deltas from it.
+type GameSnapshotAckMessage struct {
+	message.Message
+	GameID string
+	Seq    int
+}
+
+func NewGameSnapshotAckMessage(gameID string, seq int) *GameSnapshotAckMessage {
+	return &GameSnapshotAckMessage{
+		Message: message.Message{Type: "game_snapshot_ack"},
+		GameID:  gameID,
+		Seq:     seq,
+	}
+}
+
+func (m GameSnapshotAckMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func (m GameSnapshotAckMessage) SendPriority() net.Priority {
+	return net.PriorityState
+}
+
+func (m GameSnapshotAckMessage) CoalesceKey() string {
+	return "game_snapshot_ack:" + m.GameID
+}
@@ -0,0 +1,21 @@
+package arcade
+
+// GameStartedEvent is published when a game we're playing in starts, whether
+// we're hosting it or not.
+type GameStartedEvent struct {
+	GameID    string
+	GameType  string
+	PlayerIDs []string
+}
+
+func NewGameStartedEvent(lobby *Lobby) *GameStartedEvent {
+	return &GameStartedEvent{
+		GameID:    lobby.ID,
+		GameType:  lobby.GameType,
+		PlayerIDs: append([]string(nil), lobby.PlayerIDs...),
+	}
+}
+
+func (e *GameStartedEvent) Topic() EventTopic {
+	return GameEvents
+}
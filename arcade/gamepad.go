@@ -0,0 +1,262 @@
+package arcade
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Gamepad settings. Anything else is the path of a joystick device.
+const (
+	GamepadAuto = "auto"
+	GamepadOff  = "off"
+)
+
+const (
+	// Bytes in each event read from a joystick device
+	gamepadEventSize = 8
+
+	// Event types. Init is set on the events sent when the device is opened,
+	// giving where everything starts
+	gamepadButton = 0x01
+	gamepadAxis   = 0x02
+	gamepadInit   = 0x80
+
+	// How far a stick has to be pushed, out of 32767, to count
+	gamepadDeadzone = 16384
+
+	// A held direction repeats like a held key, so games that move while a
+	// key's held see it the same way
+	gamepadRepeatDelay = 300 * time.Millisecond
+	gamepadRepeatEvery = 40 * time.Millisecond
+
+	// How often to look for a gamepad when there isn't one
+	gamepadRetryPeriod = 5 * time.Second
+)
+
+var errGamepadUnsupported = errors.New("gamepads aren't supported on this platform")
+
+// gamepadAxes are the axes used for moving, and whether each is horizontal:
+// the left stick, then the d-pad, on most controllers. The triggers are axes
+// too, so the rest are left alone.
+var gamepadAxes = map[uint8]bool{
+	0: true,
+	1: false,
+	6: true,
+	7: false,
+}
+
+// gamepadButtons are what the buttons do: A and Start pick, B goes back.
+var gamepadButtons = map[uint8]Action{
+	0: ActionSelect,
+	1: ActionQuit,
+	7: ActionSelect,
+}
+
+// gamepadEvent is a button or axis changing on a joystick device.
+type gamepadEvent struct {
+	Value  int16
+	Type   uint8
+	Number uint8
+}
+
+// decodeGamepadEvent reads an event in the Linux joystick format: a
+// timestamp, the value, the type, then the button or axis number.
+func decodeGamepadEvent(b []byte) gamepadEvent {
+	return gamepadEvent{
+		Value:  int16(binary.LittleEndian.Uint16(b[4:6])),
+		Type:   b[6],
+		Number: b[7],
+	}
+}
+
+// gamepadInput turns a gamepad's events into actions, and keeps track of the
+// direction being held.
+type gamepadInput struct {
+	// The direction each axis is pushed, or "" if it's in the middle
+	axes map[uint8]Action
+
+	held      Action
+	heldSince time.Time
+}
+
+func newGamepadInput() *gamepadInput {
+	return &gamepadInput{axes: make(map[uint8]Action)}
+}
+
+// Handle returns the action the event presses, or "" if it doesn't press
+// anything.
+func (p *gamepadInput) Handle(evt gamepadEvent, now time.Time) Action {
+	init := evt.Type&gamepadInit != 0
+
+	switch evt.Type &^ gamepadInit {
+	case gamepadButton:
+		if init || evt.Value == 0 {
+			return ""
+		}
+
+		return gamepadButtons[evt.Number]
+	case gamepadAxis:
+		horizontal, ok := gamepadAxes[evt.Number]
+
+		if !ok {
+			return ""
+		}
+
+		action := gamepadAxisAction(horizontal, evt.Value)
+		before := p.axes[evt.Number]
+		p.axes[evt.Number] = action
+
+		// A stick already pushed when the device opens doesn't count
+		if init || action == before {
+			return ""
+		}
+
+		if before != "" && before == p.held {
+			p.held = ""
+		}
+
+		if action != "" {
+			p.held, p.heldSince = action, now
+		}
+
+		return action
+	}
+
+	return ""
+}
+
+// Repeat returns the direction being held, if it's been held long enough to
+// repeat.
+func (p *gamepadInput) Repeat(now time.Time) Action {
+	if p.held == "" || now.Sub(p.heldSince) < gamepadRepeatDelay {
+		return ""
+	}
+
+	return p.held
+}
+
+// gamepadAxisAction returns the direction an axis at the value is pushed.
+// Up is negative, like on the screen.
+func gamepadAxisAction(horizontal bool, value int16) Action {
+	switch {
+	case value <= -gamepadDeadzone && horizontal:
+		return ActionLeft
+	case value <= -gamepadDeadzone:
+		return ActionUp
+	case value >= gamepadDeadzone && horizontal:
+		return ActionRight
+	case value >= gamepadDeadzone:
+		return ActionDown
+	}
+
+	return ""
+}
+
+// gamepadKey returns the key a gamepad action presses. Directions use the
+// game keys, so they follow the player's bindings.
+func gamepadKey(action Action) (Key, bool) {
+	switch action {
+	case ActionSelect:
+		return SpecialKey(tcell.KeyEnter), true
+	case ActionQuit:
+		return globalKeymap.Key(ActionQuit)
+	}
+
+	return gameKeymap.Key(action)
+}
+
+// startGamepad reads the gamepad in the config, if there is one, pressing
+// keys for it until the arcade quits. It keeps looking when there isn't one,
+// so one can be plugged in later.
+func startGamepad(mgr *ViewManager, screen *Screen) {
+	defer mgr.recoverCrash()
+
+	for {
+		setting := mgr.Config().Gamepad
+
+		if setting == GamepadOff {
+			time.Sleep(gamepadRetryPeriod)
+			continue
+		}
+
+		path := setting
+
+		if path == GamepadAuto || path == "" {
+			paths := gamepadPaths()
+
+			if len(paths) == 0 {
+				time.Sleep(gamepadRetryPeriod)
+				continue
+			}
+
+			path = paths[0]
+		}
+
+		device, err := openGamepad(path)
+
+		if errors.Is(err, errGamepadUnsupported) {
+			return
+		}
+
+		if err != nil {
+			time.Sleep(gamepadRetryPeriod)
+			continue
+		}
+
+		notify("Gamepad connected")
+		err = readGamepad(screen, device)
+		device.Close()
+
+		log.Println("Gamepad disconnected:", err)
+		notify("Gamepad disconnected")
+	}
+}
+
+// readGamepad presses keys for the device's events until it can't be read.
+func readGamepad(screen *Screen, device io.Reader) error {
+	events := make(chan gamepadEvent)
+	done := make(chan error, 1)
+
+	go func() {
+		buf := make([]byte, gamepadEventSize)
+
+		for {
+			if _, err := io.ReadFull(device, buf); err != nil {
+				done <- err
+				return
+			}
+
+			events <- decodeGamepadEvent(buf)
+		}
+	}()
+
+	input := newGamepadInput()
+	ticker := time.NewTicker(gamepadRepeatEvery)
+	defer ticker.Stop()
+
+	press := func(action Action) {
+		if key, ok := gamepadKey(action); ok {
+			screen.PostEvent(tcell.NewEventKey(key.Key, key.Rune, tcell.ModNone))
+		}
+	}
+
+	for {
+		select {
+		case evt := <-events:
+			if action := input.Handle(evt, time.Now()); action != "" {
+				press(action)
+			}
+		case now := <-ticker.C:
+			if action := input.Repeat(now); action != "" {
+				press(action)
+			}
+		case err := <-done:
+			return err
+		}
+	}
+}
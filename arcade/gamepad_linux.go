@@ -0,0 +1,20 @@
+//go:build linux
+
+package arcade
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// gamepadPaths returns the joystick devices plugged in.
+func gamepadPaths() []string {
+	paths, _ := filepath.Glob("/dev/input/js*")
+	return paths
+}
+
+// openGamepad opens a joystick device to read its events.
+func openGamepad(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
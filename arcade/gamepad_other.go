@@ -0,0 +1,15 @@
+//go:build !linux
+
+package arcade
+
+import "io"
+
+// gamepadPaths can't find joystick devices here.
+func gamepadPaths() []string {
+	return nil
+}
+
+// openGamepad can't read joystick devices here.
+func openGamepad(path string) (io.ReadCloser, error) {
+	return nil, errGamepadUnsupported
+}
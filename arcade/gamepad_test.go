@@ -0,0 +1,66 @@
+package arcade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecodeGamepadEvent(t *testing.T) {
+	// Axis 1 pushed all the way up, 1000ms after the device started
+	evt := decodeGamepadEvent([]byte{0xe8, 0x03, 0, 0, 0x01, 0x80, gamepadAxis, 1})
+
+	if evt.Value != -32767 || evt.Type != gamepadAxis || evt.Number != 1 {
+		t.Errorf("got %+v", evt)
+	}
+}
+
+func TestGamepadInput(t *testing.T) {
+	input := newGamepadInput()
+	now := time.Now()
+
+	// A stick resting off center when the device opens doesn't press
+	if action := input.Handle(gamepadEvent{Value: 30000, Type: gamepadAxis | gamepadInit, Number: 0}, now); action != "" {
+		t.Errorf("init pressed %s", action)
+	}
+
+	if action := input.Handle(gamepadEvent{Value: 0, Type: gamepadAxis, Number: 0}, now); action != "" {
+		t.Errorf("centering pressed %s", action)
+	}
+
+	if action := input.Handle(gamepadEvent{Value: -20000, Type: gamepadAxis, Number: 1}, now); action != ActionUp {
+		t.Errorf("pushing up pressed %q", action)
+	}
+
+	// Moving within the same direction isn't another press
+	if action := input.Handle(gamepadEvent{Value: -32767, Type: gamepadAxis, Number: 1}, now); action != "" {
+		t.Errorf("pushing further pressed %s", action)
+	}
+
+	if input.Repeat(now.Add(gamepadRepeatDelay/2)) != "" || input.Repeat(now.Add(gamepadRepeatDelay)) != ActionUp {
+		t.Error("holding up didn't repeat after the delay")
+	}
+
+	// Straight across to the other side
+	if action := input.Handle(gamepadEvent{Value: 32767, Type: gamepadAxis, Number: 1}, now); action != ActionDown {
+		t.Errorf("pushing down pressed %q", action)
+	}
+
+	input.Handle(gamepadEvent{Value: 100, Type: gamepadAxis, Number: 1}, now)
+
+	if action := input.Repeat(now.Add(time.Second)); action != "" {
+		t.Errorf("let go of the stick but %s repeated", action)
+	}
+
+	// The triggers aren't for moving
+	if action := input.Handle(gamepadEvent{Value: 32767, Type: gamepadAxis, Number: 2}, now); action != "" {
+		t.Errorf("trigger pressed %s", action)
+	}
+
+	if action := input.Handle(gamepadEvent{Value: 1, Type: gamepadButton, Number: 1}, now); action != ActionQuit {
+		t.Errorf("B pressed %q", action)
+	}
+
+	if action := input.Handle(gamepadEvent{Value: 0, Type: gamepadButton, Number: 1}, now); action != "" {
+		t.Errorf("letting go of B pressed %s", action)
+	}
+}
@@ -1,11 +1,12 @@
 package arcade
 
 import (
-	"arcade/arcade/multicast"
+	"arcade/arcade/layout"
 	"arcade/arcade/net"
+	"arcade/arcade/widgets"
 	"encoding"
 	"fmt"
-	"sort"
+	"log"
 	"sync"
 	"time"
 
@@ -19,64 +20,275 @@ type GamesListView struct {
 	mu sync.RWMutex
 
 	lobbies      map[string]*Lobby
-	selectedRow  int
+	listings     []lobbyListing
 	stopTickerCh chan bool
+	ticking      bool
 
-	lastTimeRefreshed int
+	// When we last heard from each lobby's host, and the lobbies we've asked
+	// again after hearing nothing for a while
+	seen    map[string]time.Time
+	probing map[string]bool
 
-	glv_join_box          string
-	selectedLobbyKey      string
-	err_msg               string
-	glv_code_input_string string
-	glv_code              string
+	lastRefresh time.Time
+	lastUpdate  time.Time
+
+	list        *widgets.ScrollList
+	searchInput *widgets.TextInput
+	codeInput   *widgets.TextInput
+	focus       *widgets.FocusGroup
+
+	glv_join_box     string
+	selectedLobbyKey string
+	err_msg          string
+	glv_code         string
+
+	// Lobby we crashed in, waiting to connect to its host to rejoin
+	rejoining *rejoinInfo
+
+	filters LobbyFilters
+
+	// The filters as last saved to the profile
+	savedFilters LobbyFilters
 }
 
 var footer = []string{
-	"[C]reate new lobby      [J]oin selected lobby",
+	"[C]reate lobby  [Q]uick match  [L]ocal play  [F]riends  [R]efresh  [,] Settings",
+	"[/] Search  [G]ame  [V]is  [O]pen  [P]ing  Regio[n]  Pr[a]ctice  [?] More keys",
 }
 
-// const (
-// 	nameColX    = 4
-// 	gameColX    = 30
-// 	playersColX = 40
-// 	pingColX    = 70
+const (
+	// How often every peer is asked for its lobby. Changes in between arrive
+	// as LobbyUpdateMessages.
+	lobbyRefreshInterval = 30 * time.Second
+
+	// Number of lobbies that fit in the table, leaving a line for the status
+	lobbyPageSize = 12
+
+	// Time without hearing from a lobby's host before the lobby's hidden and
+	// the host's asked whether it's still there
+	lobbyStaleAfter = lobbyRefreshInterval + 15*time.Second
+)
+
+const (
+	glvTableWidth  = 72
+	glvTableHeight = 12
 
-// 	tableX1 = 3
-// 	tableY1 = 7
-// 	tableX2 = 76
-// 	tableY2 = 18
+	glvTableX1 = (displayWidth-glvTableWidth)/2 - 1
+	glvTableY1 = 7
+	glvTableX2 = displayWidth - (displayWidth-glvTableWidth)/2
+	glvTableY2 = glvTableY1 + glvTableHeight
 
-// 	joinbox_X1 = 7
-// 	joinbox_Y1 = 9
-// 	joinbox_X2 = 72
-// 	joinbox_Y2 = 15
-// )
+	glvJoinboxX1 = glvTableX1 + 4
+	glvJoinboxY1 = glvTableY1 + 2
+	glvJoinboxX2 = glvTableX2 - 4
+	glvJoinboxY2 = glvTableY2 - 3
+
+	glvSearchLabel = "Search: "
+	glvSearchWidth = 21
+	glvCodeLabel   = "Enter code: "
+)
 
 func NewGamesListView(mgr *ViewManager) *GamesListView {
-	return &GamesListView{
-		mgr:               mgr,
-		stopTickerCh:      make(chan bool),
-		lobbies:           make(map[string]*Lobby),
-		lastTimeRefreshed: 3,
+	v := &GamesListView{
+		mgr:          mgr,
+		stopTickerCh: make(chan bool),
+		lobbies:      make(map[string]*Lobby),
+		seen:         make(map[string]time.Time),
+		probing:      make(map[string]bool),
+		filters:      loadLobbyFilters(),
+	}
+
+	v.savedFilters = v.filters
+
+	v.list = widgets.NewScrollList(glvTableX1, glvTableY1, glvTableX2-glvTableX1+1, lobbyPageSize)
+	v.list.OnSelect = func(index int) { v.joinSelected() }
+
+	searchX := (displayWidth-len(glvSearchLabel)-glvSearchWidth)/2 + len(glvSearchLabel)
+	v.searchInput = widgets.NewTextInput(searchX, 3, glvSearchWidth)
+	v.searchInput.MaxLength = 20
+	v.searchInput.SetValue(v.filters.Search)
+	v.searchInput.OnChange = func(value string) {
+		v.applyFilters(func(f *LobbyFilters) { f.Search = value })
+	}
+	v.searchInput.OnSubmit = func(string) {
+		v.setFocus(v.list)
+		v.saveFilters()
+	}
+
+	v.codeInput = widgets.NewTextInput(0, glvJoinboxY1+2, maxPasswordLength)
+	v.codeInput.OnSubmit = v.submitCode
+
+	v.focus = widgets.NewFocusGroup(v.list)
+	v.refreshList()
+
+	return v
+}
+
+// Widgets returns the widget that should get key events: the lobby list, the
+// search box or the join code box.
+func (v *GamesListView) Widgets() *widgets.FocusGroup {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	// Any key dismisses an error, so the view needs to see it
+	if v.err_msg != "" {
+		return nil
+	}
+
+	return v.focus
+}
+
+// Keymap returns the lobby list's keys, or nil while typing in a box.
+func (v *GamesListView) Keymap() *Keymap {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.err_msg != "" || v.glv_join_box != "" || v.focus.Focused() != v.list {
+		return nil
+	}
+
+	return gamesListKeymap
+}
+
+func (v *GamesListView) setFocus(w widgets.Widget) {
+	v.mu.Lock()
+	v.focus.Focused().SetFocused(false)
+	v.focus = widgets.NewFocusGroup(w)
+	v.mu.Unlock()
+}
+
+// refreshList rebuilds the list rows from the lobbies that pass the filters,
+// keeping the same lobby selected.
+func (v *GamesListView) refreshList() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	selectedID := ""
+
+	if i := v.list.Selected(); i >= 0 && i < len(v.listings) {
+		selectedID = v.listings[i].ID
+	}
+
+	all := make([]lobbyListing, 0, len(v.lobbies))
+
+	for id, lobby := range v.lobbies {
+		// Hosts that have gone quiet may have left, so their lobbies wait
+		// until they answer again
+		if time.Since(v.seen[id]) >= lobbyStaleAfter {
+			continue
+		}
+
+		all = append(all, newLobbyListing(lobby))
+	}
+
+	v.listings = v.filters.Apply(all)
+	rows := make([]string, len(v.listings))
+	selected := 0
+
+	for i, l := range v.listings {
+		rows[i] = fmt.Sprintf(" %s %s %-12s %-16s %dms", layout.Pad(l.Name, 25), layout.Pad(l.GameType, 9), fmt.Sprintf("%d/%d", l.Players, l.Capacity), l.Region, l.Ping)
+
+		if l.ID == selectedID {
+			selected = i
+		}
+	}
+
+	v.list.Placeholder = "No lobbies found yet."
+
+	if len(v.lobbies) > 0 {
+		v.list.Placeholder = "No lobbies match your filters."
+	}
+
+	v.list.SetItems(rows)
+	v.list.Select(selected)
+}
+
+// updateFilters applies a change to the filters and saves them to the profile.
+func (v *GamesListView) updateFilters(update func(f *LobbyFilters)) {
+	v.applyFilters(update)
+	v.saveFilters()
+}
+
+// applyFilters changes the filters without saving them, for changes still
+// being typed.
+func (v *GamesListView) applyFilters(update func(f *LobbyFilters)) {
+	v.mu.Lock()
+	update(&v.filters)
+	v.mu.Unlock()
+
+	v.list.Select(0)
+	v.refreshList()
+}
+
+// saveFilters saves the filters to the profile, if they've changed since
+// they were last saved.
+func (v *GamesListView) saveFilters() {
+	v.mu.Lock()
+	filters := v.filters
+	changed := filters != v.savedFilters
+	v.savedFilters = filters
+	v.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if err := saveLobbyFilters(filters); err != nil {
+		log.Println("Couldn't save lobby filters:", err)
 	}
 }
 
 func (v *GamesListView) Init() {
+	forgetLobby()
+	v.startTicker()
+	go v.SendHelloMessages()
+
+	if info := loadRejoin(); info != nil {
+		go v.offerRejoin(info)
+	}
+}
+
+// OnPause stops refreshing lobbies while another view is on top.
+func (v *GamesListView) OnPause() {
+	v.stopTicker()
+}
+
+// OnResume catches up on lobbies that changed while paused.
+func (v *GamesListView) OnResume() {
+	forgetLobby()
+	v.startTicker()
+	go v.SendHelloMessages()
+}
+
+func (v *GamesListView) startTicker() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.ticking {
+		return
+	}
+
+	v.ticking = true
 	ticker := time.NewTicker(time.Second)
 
 	go func() {
 		for {
 			select {
 			case <-ticker.C:
-				v.mu.Lock()
-				v.lastTimeRefreshed = (v.lastTimeRefreshed + 1) % 6
+				v.mu.RLock()
+				refresh := time.Since(v.lastRefresh) >= lobbyRefreshInterval
+				v.mu.RUnlock()
 
-				if v.lastTimeRefreshed == 0 {
-					// Send out hello messages when the timer hits zero
+				if refresh {
 					go v.SendHelloMessages()
 				}
 
-				v.mu.Unlock()
+				v.probeStale()
+
+				if v.mgr.IdleFor() >= attractModeIdle && !v.mgr.modalOpen() && !v.mgr.Announcer.Enabled() {
+					go v.mgr.PushView(NewAttractView(v.mgr))
+				}
+
 				v.mgr.RequestRender()
 			case <-v.stopTickerCh:
 				ticker.Stop()
@@ -84,143 +296,389 @@ func (v *GamesListView) Init() {
 			}
 		}
 	}()
+}
 
-	go v.SendHelloMessages()
+func (v *GamesListView) stopTicker() {
+	v.mu.Lock()
+	ticking := v.ticking
+	v.ticking = false
+	v.mu.Unlock()
+
+	if ticking {
+		v.stopTickerCh <- true
+	}
 }
 
 func (v *GamesListView) SendHelloMessages() {
-	// Scan LAN for lobbies
-	go multicast.Discover(arcade.Server.Addr, arcade.Server.ID, arcade.Port)
-
-	// Send hello messages to everyone we find
-	arcade.Server.Network.ClientsRange(func(client *net.Client) bool {
-		client.RLock()
-		if (client.State != net.Connected && client.State != net.Connecting) || client.Distributor {
-			client.RUnlock()
-			return true
-		}
-		client.RUnlock()
+	v.mu.Lock()
+	v.lastRefresh = time.Now()
+	v.mu.Unlock()
 
-		go v.QueryClient(client)
-		return true
-	})
+	arcade.Engine.FindLobbies(v.addLobby)
 }
 
-// QueryClient sends a HelloMessage to the client and waits for a reply. If a
-// LobbyInfoMessage is received, the client immediately re-renders the view
-// with the new lobby included.
+// QueryClient asks the client for its lobby, and adds it to the list if it
+// has one.
 func (v *GamesListView) QueryClient(client *net.Client) {
-	start := time.Now()
-	res, err := arcade.Server.Network.SendAndReceive(client, NewHelloMessage())
-	end := time.Now()
+	if lobby, ok := arcade.Engine.QueryLobby(client); ok {
+		v.addLobby(lobby)
+	}
+}
+
+// addLobby adds the lobby to the list, or updates it if it's already there.
+func (v *GamesListView) addLobby(lobby *Lobby) {
+	v.mu.Lock()
+	v.lobbies[lobby.ID] = lobby
+	v.lastUpdate = time.Now()
+	v.seen[lobby.ID] = v.lastUpdate
+	v.mu.Unlock()
+
+	v.refreshList()
+	v.mgr.RequestRender()
+}
 
-	p, ok := res.(*LobbyInfoMessage)
+// removeLobby takes a lobby that's ended off the list.
+func (v *GamesListView) removeLobby(lobbyID string) {
+	v.mu.Lock()
+	delete(v.lobbies, lobbyID)
+	delete(v.seen, lobbyID)
+	v.lastUpdate = time.Now()
+	v.mu.Unlock()
+
+	v.refreshList()
+}
+
+// probeStale asks the hosts of lobbies we haven't heard from in a while
+// whether they're still there, and removes the lobbies of those that don't
+// answer, so the list doesn't show games that can't be joined.
+func (v *GamesListView) probeStale() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for id, lobby := range v.lobbies {
+		if v.probing[id] || time.Since(v.seen[id]) < lobbyStaleAfter {
+			continue
+		}
+
+		v.probing[id] = true
+		go v.probe(id, newLobbyListing(lobby).HostID)
+	}
+}
+
+func (v *GamesListView) probe(lobbyID, hostID string) {
+	var (
+		lobby *Lobby
+		ok    bool
+	)
+
+	if client, connected := arcade.Server.Network.GetClient(hostID); connected {
+		lobby, ok = arcade.Engine.QueryLobby(client)
+	}
+
+	v.mu.Lock()
+	delete(v.probing, lobbyID)
 
-	if !ok || err != nil {
+	if !ok || lobby.ID != lobbyID {
+		delete(v.lobbies, lobbyID)
+		delete(v.seen, lobbyID)
+	}
+	v.mu.Unlock()
+
+	if ok {
+		v.addLobby(lobby)
 		return
 	}
 
+	v.refreshList()
+	v.mgr.RequestRender()
+}
+
+// forgetHost marks the lobbies of a host that's gone quiet as stale, so
+// they're hidden and checked on.
+func (v *GamesListView) forgetHost(hostID string) {
 	v.mu.Lock()
-	p.Lobby.Ping = int(end.Sub(start).Milliseconds())
-	v.lobbies[p.Lobby.ID] = p.Lobby
+	for id, lobby := range v.lobbies {
+		if newLobbyListing(lobby).HostID == hostID {
+			v.seen[id] = time.Time{}
+		}
+	}
 	v.mu.Unlock()
 
+	v.refreshList()
 	v.mgr.RequestRender()
 }
 
+// joinSelected joins the selected lobby, asking for its code or password
+// first if it needs one.
+func (v *GamesListView) joinSelected() {
+	v.mu.Lock()
+	i := v.list.Selected()
+
+	if i < 0 || i >= len(v.listings) {
+		v.mu.Unlock()
+		return
+	}
+
+	v.selectedLobbyKey = v.listings[i].ID
+	selectedLobby := v.lobbies[v.selectedLobbyKey]
+
+	if v.mgr.tutorialUnseen(selectedLobby.GameType) {
+		v.mu.Unlock()
+
+		v.mgr.offerTutorial(selectedLobby.GameType, v.joinSelected)
+		return
+	}
+
+	v.glv_code = ""
+
+	if selectedLobby.Private {
+		v.glv_join_box = "join_code"
+	} else if selectedLobby.HasPassword {
+		v.glv_join_box = "password"
+	} else {
+		v.mu.Unlock()
+
+		go arcade.Engine.Join(selectedLobby.HostID, selectedLobby.ID, "", "")
+		return
+	}
+
+	joinBox := v.glv_join_box
+	v.mu.Unlock()
+
+	v.openCodeInput(joinBox)
+}
+
+// openCodeInput shows the box for typing a join code or password.
+func (v *GamesListView) openCodeInput(joinBox string) {
+	label := glvCodeLabel
+	v.codeInput.MaxLength = 4
+	v.codeInput.Masked = false
+
+	if joinBox == "password" {
+		label = "Enter password: "
+		v.codeInput.MaxLength = maxPasswordLength
+		v.codeInput.Masked = true
+	}
+
+	v.codeInput.X = (displayWidth-len(label)-maxPasswordLength)/2 + len(label)
+	v.codeInput.SetValue("")
+	v.setFocus(v.codeInput)
+}
+
+func (v *GamesListView) submitCode(value string) {
+	v.mu.Lock()
+	selectedLobby := v.lobbies[v.selectedLobbyKey]
+
+	if selectedLobby == nil {
+		v.mu.Unlock()
+		return
+	}
+
+	if v.glv_join_box == "join_code" {
+		if len(value) != 4 {
+			v.err_msg = "Code must be four characters long."
+			v.mu.Unlock()
+			return
+		}
+
+		v.glv_code = value
+
+		if selectedLobby.HasPassword {
+			// Ask for the password next
+			v.glv_join_box = "password"
+			v.mu.Unlock()
+
+			v.openCodeInput("password")
+			return
+		}
+
+		v.mu.Unlock()
+
+		go arcade.Engine.Join(selectedLobby.HostID, selectedLobby.ID, value, "")
+		return
+	}
+
+	code := v.glv_code
+	v.mu.Unlock()
+
+	go arcade.Engine.Join(selectedLobby.HostID, selectedLobby.ID, code, value)
+}
+
 func (v *GamesListView) ProcessEvent(evt interface{}) {
 	switch evt := evt.(type) {
 	case *ClientConnectedEvent:
 		if client, ok := arcade.Server.Network.GetClient(evt.ClientID); ok {
 			go v.QueryClient(client)
 		}
+
+		v.mu.Lock()
+		info := v.rejoining
+
+		if info != nil && info.HostID == evt.ClientID {
+			v.rejoining = nil
+		}
+		v.mu.Unlock()
+
+		if info != nil && info.HostID == evt.ClientID {
+			v.rejoin(info)
+		}
 	case *ClientDisconnectedEvent:
 		v.mu.Lock()
-		delete(v.lobbies, evt.ClientID)
+		for id, lobby := range v.lobbies {
+			if newLobbyListing(lobby).HostID == evt.ClientID {
+				delete(v.lobbies, id)
+				delete(v.seen, id)
+			}
+		}
 		v.mu.Unlock()
 
+		v.refreshList()
 		v.mgr.RequestRender()
+	case *LANPeerLostEvent:
+		v.forgetHost(evt.ClientID)
 	case *tcell.EventKey:
+		v.mu.Lock()
 		if len(v.err_msg) > 0 {
 			v.err_msg = ""
 			v.glv_join_box = ""
+			v.mu.Unlock()
+
+			v.setFocus(v.list)
 			return
 		}
-		switch evt.Key() {
-		case tcell.KeyDown:
-			v.selectedRow++
 
-			v.mu.RLock()
-			if v.selectedRow > len(v.lobbies)-1 {
-				v.selectedRow = len(v.lobbies) - 1
-			}
-			v.mu.RUnlock()
-		case tcell.KeyUp:
-			v.selectedRow--
+		joinBox := v.glv_join_box
+		v.mu.Unlock()
 
-			if v.selectedRow < 0 {
-				v.selectedRow = 0
-			}
-		case tcell.KeyBackspace, tcell.KeyBackspace2:
-			if v.glv_join_box != "" {
-				if len(v.glv_code_input_string) > 0 {
-					v.glv_code_input_string = v.glv_code_input_string[:len(v.glv_code_input_string)-1]
-				}
-			}
-		case tcell.KeyEnter:
-			if v.glv_join_box == "join_code" {
-				if len(v.glv_code_input_string) == 4 {
-					v.glv_code = v.glv_code_input_string
-					selectedLobby := v.lobbies[v.selectedLobbyKey]
-					host, _ := arcade.Server.Network.GetClient(selectedLobby.HostID)
-
-					go arcade.Server.Network.Send(host, NewJoinMessage(v.glv_code, arcade.Server.ID, selectedLobby.ID))
-				} else {
-					v.glv_join_box = "join_code"
-					v.err_msg = "Code must be four characters long."
-				}
+		if joinBox != "" {
+			return
+		}
 
-			}
-		case tcell.KeyRune:
-			if v.glv_join_box == "" {
-				switch evt.Rune() {
-				case 'c':
-					v.glv_join_box = ""
-					v.mgr.SetView(NewLobbyCreateView(v.mgr))
-				case 'j':
-					if len(v.lobbies) != 0 {
-						v.mu.RLock()
-
-						keys := make([]string, 0, len(v.lobbies))
-
-						for k := range v.lobbies {
-							keys = append(keys, k)
-						}
-						sort.Strings(keys)
-
-						v.selectedLobbyKey = keys[v.selectedRow]
-						selectedLobby := v.lobbies[keys[v.selectedRow]]
-						if selectedLobby.Private {
-							v.glv_join_box = "join_code"
-						} else {
-							host, _ := arcade.Server.Network.GetClient(selectedLobby.HostID)
-
-							go arcade.Server.Network.Send(host, NewJoinMessage("", arcade.Server.ID, selectedLobby.ID))
-						}
-						v.mu.RUnlock()
-
-					}
+		switch gamesListKeymap.Action(evt) {
+		case ActionSortPrev:
+			v.updateFilters(func(f *LobbyFilters) { f.MoveSortColumn(-1) })
+		case ActionSortNext:
+			v.updateFilters(func(f *LobbyFilters) { f.MoveSortColumn(1) })
+		case ActionCreateLobby:
+			v.mgr.PushView(NewCreateLobbyView(v.mgr))
+		case ActionLocalPlay:
+			v.chooseLocalGame()
+		case ActionPractice:
+			v.choosePractice()
+		case ActionFriends:
+			v.mgr.PushView(NewFriendsView(v.mgr))
+		case ActionWatch:
+			v.mgr.PushView(NewLiveGamesView(v.mgr))
+		case ActionReplays:
+			v.mgr.PushView(NewReplaysView(v.mgr))
+		case ActionAchievements:
+			v.mgr.PushView(NewAchievementsView(v.mgr))
+		case ActionChallenge:
+			go showDailyChallenge(v.mgr)
+		case ActionSeason:
+			v.mgr.PushView(NewSeasonView(v.mgr))
+		case ActionMaps:
+			v.mgr.PushView(NewMapsView(v.mgr))
+		case ActionSettings:
+			v.mgr.PushView(NewSettingsView(v.mgr))
+		case ActionRefresh:
+			go v.SendHelloMessages()
+		case ActionSearch:
+			v.setFocus(v.searchInput)
+		case ActionFilterGame:
+			v.updateFilters(func(f *LobbyFilters) { f.CycleGameType() })
+		case ActionFilterVis:
+			v.updateFilters(func(f *LobbyFilters) { f.CycleVisibility() })
+		case ActionFilterOpen:
+			v.updateFilters(func(f *LobbyFilters) { f.OpenSlots = !f.OpenSlots })
+		case ActionFilterPing:
+			v.updateFilters(func(f *LobbyFilters) { f.CycleMaxPing() })
+		case ActionFilterRegion:
+			v.updateFilters(func(f *LobbyFilters) { f.CycleRegion() })
+		case ActionSortOrder:
+			v.updateFilters(func(f *LobbyFilters) { f.SortDesc = !f.SortDesc })
+		case ActionJoin:
+			v.joinSelected()
+		case ActionQuickMatch:
+			go v.quickMatch()
+		}
+	}
+}
 
-				}
-			} else {
-				if len(v.glv_code_input_string) < 4 {
-					v.glv_code_input_string += string(evt.Rune())
-				}
-			}
+// chooseLocalGame asks which game to play on this keyboard.
+func (v *GamesListView) chooseLocalGame() {
+	games := localGameNames()
+
+	v.mgr.ShowModal(widgets.NewModal("Local play", []string{
+		"Two players, one keyboard.",
+		fmt.Sprintf("P1 moves with %s, P2 with %s.", movementLabel(localKeymaps[0]), movementLabel(localKeymaps[1])),
+	}, append(games, "Cancel"), func(choice int) {
+		if choice < len(games) {
+			v.mgr.PushView(NewLocalGameView(v.mgr, games[choice]))
 		}
+	}))
+}
+
+// offerRejoin asks whether to go back to the lobby we crashed in.
+func (v *GamesListView) offerRejoin(info *rejoinInfo) {
+	v.mgr.ShowModal(widgets.NewModal("Rejoin", []string{
+		"ASCII Arcade closed unexpectedly last time.",
+		fmt.Sprintf("Go back to lobby '%s'?", info.LobbyName),
+	}, []string{"Rejoin", "Not now"}, func(choice int) {
+		if choice == 0 {
+			v.rejoin(info)
+		}
+	}))
+}
+
+// rejoin asks the host of the lobby to let us back in, connecting to them
+// first if we haven't yet. The reply is handled like any other join.
+func (v *GamesListView) rejoin(info *rejoinInfo) {
+	if host, ok := arcade.Server.Network.GetClient(info.HostID); ok && host.State == net.Connected {
+		go arcade.Engine.Join(info.HostID, info.LobbyID, info.Code, "")
+		return
+	}
+
+	v.mu.Lock()
+	v.rejoining = info
+	v.mu.Unlock()
+
+	if info.HostAddr != "" {
+		go arcade.Server.Network.Connect(info.HostAddr, info.HostID, nil)
 	}
 }
 
+// choosePractice asks which game to practice, and how good the bots should be.
+func (v *GamesListView) choosePractice() {
+	games := localGameNames()
+
+	v.mgr.ShowModal(widgets.NewModal("Practice", []string{
+		"Play against bots, no network needed.",
+	}, append(games, "Cancel"), func(choice int) {
+		if choice >= len(games) {
+			return
+		}
+
+		game := games[choice]
+		skills := make([]string, len(botSkills))
+
+		for i, skill := range botSkills {
+			skills[i] = skill.Name
+		}
+
+		v.mgr.ShowModal(widgets.NewModal("Practice "+game, []string{
+			"How good should the bots be?",
+		}, append(skills, "Tutorial"), func(choice int) {
+			if choice == len(botSkills) {
+				v.mgr.PushView(NewTutorialView(v.mgr, game))
+				return
+			}
+
+			v.mgr.PushView(NewPracticeView(v.mgr, game, botSkills[choice]))
+		}))
+	}))
+}
+
 func (v *GamesListView) ProcessMessage(from *net.Client, p interface{}) interface{} {
 	switch p := p.(type) {
 	case *JoinReplyMessage:
@@ -228,10 +686,9 @@ func (v *GamesListView) ProcessMessage(from *net.Client, p interface{}) interfac
 			v.mu.Lock()
 			v.err_msg = ""
 			v.glv_join_box = ""
-			v.glv_code_input_string = ""
 			v.mu.Unlock()
 
-			v.mgr.SetView(NewLobbyView(v.mgr, p.Lobby))
+			v.mgr.PushView(NewLobbyView(v.mgr, p.Lobby))
 
 			arcade.Server.BeginHeartbeats(p.Lobby.HostID)
 		} else if p.Error == ErrWrongCode {
@@ -242,69 +699,60 @@ func (v *GamesListView) ProcessMessage(from *net.Client, p interface{}) interfac
 			v.mu.Lock()
 			v.err_msg = "Game is now full."
 			v.mu.Unlock()
+		} else if p.Error == ErrWrongPassword {
+			v.mu.Lock()
+			v.err_msg = "Wrong password."
+			v.mu.Unlock()
+		} else if p.Error == ErrRateLimited {
+			v.mu.Lock()
+			v.err_msg = "Too many attempts, try later."
+			v.mu.Unlock()
+		} else if p.Error == ErrIdentity {
+			v.mu.Lock()
+			v.err_msg = "Couldn't verify your identity."
+			v.mu.Unlock()
 		}
-	case *LobbyEndMessage:
+	case *LobbyUpdateMessage:
+		if p.Lobby == nil || p.Lobby.HostID != p.SenderID || IsBlocked(p.Lobby.HostID) {
+			break
+		}
+
+		// Lobbies no one's left in are as good as closed, unless they're a
+		// server's waiting for players
+		if p.Lobby.State == LobbyClosed || len(p.Lobby.PlayerIDs) == 0 && !p.Lobby.Dedicated {
+			v.removeLobby(p.Lobby.ID)
+			break
+		}
+
 		v.mu.Lock()
-		delete(v.lobbies, p.LobbyID)
-		v.selectedRow--
-		if v.selectedRow < 0 {
-			v.selectedRow = 0
+		if existing, ok := v.lobbies[p.Lobby.ID]; ok {
+			p.Lobby.Ping = newLobbyListing(existing).Ping
 		}
 
+		v.lobbies[p.Lobby.ID] = p.Lobby
+		v.lastUpdate = time.Now()
+		v.seen[p.Lobby.ID] = v.lastUpdate
 		v.mu.Unlock()
 
+		v.refreshList()
+	case *LobbyEndMessage:
+		v.removeLobby(p.LobbyID)
 	}
 
 	return nil
 }
 
 func (v *GamesListView) Render(s *Screen) {
-	if v.glv_join_box == "" && len(v.glv_code_input_string) > 0 {
-		s.Clear()
-		v.glv_code_input_string = ""
-	}
-
 	width, height := s.displaySize()
 
-	const (
-		tableWidth  = 72
-		tableHeight = 14
-	)
-
 	var (
-		tableX1 = (width-tableWidth)/2 - 1
-		tableY1 = 7
-		tableX2 = width - (width-tableWidth)/2
-		tableY2 = tableY1 + tableHeight
-
-		nameColX    = tableX1 + 1
-		gameColX    = tableX1 + 27
-		playersColX = tableX1 + 37
-		pingColX    = tableX1 + 67
-
-		joinbox_X1 = tableX1 + 4
-		joinbox_Y1 = tableY1 + 2
-		joinbox_X2 = tableX2 - 4
-		joinbox_Y2 = tableY2 - 3
+		nameColX    = glvTableX1 + 1
+		gameColX    = glvTableX1 + 27
+		playersColX = glvTableX1 + 37
+		regionColX  = glvTableX1 + 50
+		pingColX    = glvTableX1 + 67
 	)
 
-	// const (
-	// 	nameColX    = 4
-	// 	gameColX    = 30
-	// 	playersColX = 40
-	// 	pingColX    = 70
-
-	// 	tableX1 = 3
-	// 	tableY1 = 7
-	// 	tableX2 = 76
-	// 	tableY2 = 18
-
-	// 	joinbox_X1 = 7
-	// 	joinbox_Y1 = 9
-	// 	joinbox_X2 = 72
-	// 	joinbox_Y2 = 15
-	// )
-
 	// Green text on default background
 	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
 
@@ -312,107 +760,125 @@ func (v *GamesListView) Render(s *Screen) {
 	s.DrawBlockText(CenterX, 1, sty, "ASCII ARCADE", false)
 
 	// Draw box surrounding games list
-	s.DrawBox(tableX1-1, 4, tableX2+1, tableY2+1, sty, true)
+	s.DrawBox(glvTableX1-1, 4, glvTableX2+1, glvTableY2+1, sty, true)
 
 	// Draw footer with navigation keystrokes
-	s.DrawText((width-len(footer[0]))/2, height-2, sty, footer[0])
+	s.DrawText(layout.Center(width, footer[0]), height-3, sty, footer[0])
+	s.DrawText(layout.Center(width, footer[1]), height-2, sty, footer[1])
 
-	v.mu.Lock()
-	countdownMsg := fmt.Sprintf("Refreshing in %d", 6-v.lastTimeRefreshed)
+	v.mu.RLock()
+	filters := v.filters
+	searching := v.focus.Focused() == v.searchInput
+	v.mu.RUnlock()
 
-	if v.lastTimeRefreshed < 3 {
-		countdownMsg = "     Refreshing...     "
-	}
-	v.mu.Unlock()
+	// Draw active filters on the top border
+	filtersString := filters.String()
+	s.DrawText(layout.Center(width, filtersString), 4, sty, filtersString)
 
-	s.DrawText((width-len(countdownMsg))/2, height-3, sty, countdownMsg)
+	// Draw column headers, marking the one we're sorted by
+	headerSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorLightGreen)
+	sortArrow := "▲"
 
-	// Draw column headers
-	s.DrawText(nameColX, 5, sty, "NAME")
-	s.DrawText(gameColX, 5, sty, "GAME")
-	s.DrawText(playersColX, 5, sty, "PLAYERS")
-	s.DrawText(pingColX, 5, sty, "PING")
+	if filters.SortDesc {
+		sortArrow = "▼"
+	}
 
-	// Draw border below column headers
-	s.DrawLine(tableX1, 6, tableX2, 6, sty, true)
-	s.DrawText(tableX1-1, 6, sty, "╠")
-	s.DrawText(tableX2+1, 6, sty, "╣")
+	for _, header := range []struct {
+		x      int
+		title  string
+		column string
+	}{
+		{nameColX, "NAME", SortByName},
+		{gameColX, "GAME", ""},
+		{playersColX, "PLAYERS", SortByPlayers},
+		{regionColX, "REGION", ""},
+		{pingColX, "PING", SortByPing},
+	} {
+		if header.column != "" && (header.column == filters.SortColumn || (filters.SortColumn == "" && header.column == SortByName)) {
+			s.DrawText(header.x, 5, headerSty, header.title+sortArrow)
+		} else {
+			s.DrawText(header.x, 5, sty, header.title+" ")
+		}
+	}
 
-	// Clear screen of potentially old games
-	for m := tableY1; m <= tableY2; m++ {
-		s.DrawEmpty(tableX1, m, tableX2, m, sty)
+	s.DrawEmpty(glvTableX1, 3, glvTableX2, 3, sty)
+
+	if searching || filters.Search != "" {
+		s.DrawText(v.searchInput.X-len(glvSearchLabel), 3, headerSty, glvSearchLabel)
+		v.searchInput.Render(s)
 	}
 
-	// Draw selected row
-	selectedSty := tcell.StyleDefault.Background(tcell.ColorDarkGreen).Foreground(tcell.ColorWhite)
-	sty_bold := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorLightGreen)
+	// Draw border below column headers
+	s.DrawLine(glvTableX1, 6, glvTableX2, 6, sty, true)
+	s.DrawText(glvTableX1-1, 6, sty, "╠")
+	s.DrawText(glvTableX2+1, 6, sty, "╣")
+
+	// Draw lobbies
+	v.list.Render(s)
 
-	i := 0
 	v.mu.RLock()
+	defer v.mu.RUnlock()
 
-	keys := make([]string, 0, len(v.lobbies))
+	// Draw which lobbies are showing and when the list last changed
+	s.DrawEmpty(glvTableX1, glvTableY2, glvTableX2, glvTableY2, sty)
+	statusMsg := "No updates yet"
 
-	for k := range v.lobbies {
-		keys = append(keys, k)
+	if !v.lastUpdate.IsZero() {
+		statusMsg = fmt.Sprintf("Updated %ds ago", int(time.Since(v.lastUpdate).Seconds()))
 	}
-	sort.Strings(keys)
 
-	for _, lobbyID := range keys {
-		lobby := v.lobbies[lobbyID]
-		lobby.mu.RLock()
-		y := tableY1 + i
-		if y == tableY2+1 {
-			lobby.mu.RUnlock()
-			break
-		}
-		rowSty := sty
+	if total := len(v.listings); total > lobbyPageSize {
+		first := v.list.Offset() + 1
+		last := first + lobbyPageSize - 1
 
-		if i == v.selectedRow {
-			rowSty = selectedSty
+		if last > total {
+			last = total
 		}
 
-		name := lobby.Name
-		game := lobby.GameType
-		players := fmt.Sprintf("%d/%d", len(lobby.PlayerIDs), lobby.Capacity)
-		ping := fmt.Sprintf("%dms", lobby.Ping)
-		lobby.mu.RUnlock()
-
-		s.DrawEmpty(tableX1, y, nameColX-1, y, rowSty)
-		s.DrawText(nameColX, y, rowSty, name)
-		s.DrawEmpty(nameColX+len(name), y, gameColX-1, y, rowSty)
-		s.DrawText(gameColX, y, rowSty, game)
-		s.DrawEmpty(gameColX+len(game), y, playersColX-1, y, rowSty)
-		s.DrawText(playersColX, y, rowSty, players)
-		s.DrawEmpty(playersColX+len(players), y, pingColX-1, y, rowSty)
-		s.DrawText(pingColX, y, rowSty, ping)
-		s.DrawEmpty(pingColX+len(ping), y, tableX2, y, rowSty)
-		i++
+		statusMsg = fmt.Sprintf("%d-%d of %d  (PgUp/PgDn)    %s", first, last, total, statusMsg)
 	}
 
-	if v.glv_join_box != "" {
+	s.DrawText(layout.Center(width, statusMsg), glvTableY2, sty, statusMsg)
 
+	if v.glv_join_box != "" {
+		sty_bold := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorLightGreen)
 		selectedLobby := v.lobbies[v.selectedLobbyKey]
-		// Draw box surrounding games list
-		s.DrawBox(joinbox_X1, joinbox_Y1, joinbox_X2, joinbox_Y2, sty, true)
+		lobbyName := ""
 
-		joinheader := "Joining private game " + selectedLobby.Name
-		s.DrawText((width-len(joinheader))/2, joinbox_Y1+1, sty, "Joining private game ")
-		s.DrawText((width-len(joinheader))/2+len(joinheader)-len(selectedLobby.Name), joinbox_Y1+1, sty_bold, selectedLobby.Name)
-		codeHeader := "Enter code: "
-		s.DrawText((width-len(codeHeader)-5)/2, joinbox_Y1+2, sty, codeHeader)
-		s.DrawText((width-len(codeHeader)-5)/2+len(codeHeader), joinbox_Y1+2, sty_bold, v.glv_code_input_string)
-		s.DrawText((width-len(codeHeader)-5)/2+len(codeHeader)+len(v.glv_code_input_string), joinbox_Y1+2, sty_bold, "    ")
+		if selectedLobby != nil {
+			lobbyName = newLobbyListing(selectedLobby).Name
+			lobbyName = layout.Truncate(lobbyName, glvJoinboxX2-glvJoinboxX1-3-layout.Width("Joining private game "))
+		}
+
+		// Draw box for the code or password
+		s.DrawEmpty(glvJoinboxX1, glvJoinboxY1, glvJoinboxX2, glvJoinboxY2, sty)
+		s.DrawBox(glvJoinboxX1, glvJoinboxY1, glvJoinboxX2, glvJoinboxY2, sty, true)
+
+		joinheader := "Joining private game " + lobbyName
+		s.DrawText(layout.Center(width, joinheader), glvJoinboxY1+1, sty, "Joining private game ")
+		s.DrawText(layout.Center(width, joinheader)+layout.Width(joinheader)-layout.Width(lobbyName), glvJoinboxY1+1, sty_bold, lobbyName)
+
+		label := glvCodeLabel
+
+		if v.glv_join_box == "password" {
+			label = "Enter password: "
+		}
+
+		s.DrawText(v.codeInput.X-len(label), glvJoinboxY1+2, sty, label)
+		v.codeInput.Render(s)
 
 		if len(v.err_msg) > 0 {
 			shortString := v.err_msg + " Press any key to continue."
-			s.DrawText((width-len(shortString))/2, joinbox_Y1+4, sty_bold, shortString)
+			s.DrawText(layout.Center(width, shortString), glvJoinboxY1+4, sty_bold, shortString)
 		}
 	}
-	v.mu.RUnlock()
 }
 
 func (v *GamesListView) Unload() {
-	v.stopTickerCh <- true
+	v.stopTicker()
+
+	// Keep a search that was left without pressing enter
+	v.saveFilters()
 }
 
 func (v *GamesListView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
@@ -0,0 +1,353 @@
+package arcade
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Graphics settings. Auto uses whatever the terminal looks like it supports.
+const (
+	GraphicsAuto  = "auto"
+	GraphicsOff   = "off"
+	GraphicsKitty = "kitty"
+	GraphicsSixel = "sixel"
+)
+
+var graphicsModes = []string{GraphicsAuto, GraphicsOff, GraphicsKitty, GraphicsSixel}
+
+const (
+	// Cell size used when the terminal doesn't say. Kitty scales images to
+	// fit the cells they're placed on, so this only matters for sixel.
+	defaultCellWidth  = 10
+	defaultCellHeight = 20
+
+	// Kitty reads image data in chunks of at most this many bytes
+	kittyChunkSize = 4096
+
+	// Every frame replaces the same kitty image
+	kittyImageID = 1
+)
+
+// PixelView is implemented by views that can draw part of themselves as real
+// pixels on terminals with a graphics protocol. The view still renders as
+// text underneath, which is all that shows everywhere else.
+type PixelView interface {
+	// PixelArea returns the cells the pixels cover, inclusive.
+	PixelArea() (x1, y1, x2, y2 int)
+
+	// RenderPixels draws the area, or returns false if the text should show
+	// through instead, like while a countdown is up.
+	RenderPixels(c *PixelCanvas) bool
+}
+
+// PixelCanvas is an image covering some cells of the screen, in the current
+// theme.
+type PixelCanvas struct {
+	*image.RGBA
+
+	CellWidth  int
+	CellHeight int
+
+	theme *Theme
+}
+
+func newPixelCanvas(cols, rows, cellWidth, cellHeight int, theme *Theme) *PixelCanvas {
+	return &PixelCanvas{
+		RGBA:       image.NewRGBA(image.Rect(0, 0, cols*cellWidth, rows*cellHeight)),
+		CellWidth:  cellWidth,
+		CellHeight: cellHeight,
+		theme:      theme,
+	}
+}
+
+// Color converts a terminal color to a pixel color, with the theme applied.
+func (c *PixelCanvas) Color(tc tcell.Color) color.RGBA {
+	if c.theme != nil {
+		fg, _, _ := c.theme.apply(tcell.StyleDefault.Foreground(tc)).Decompose()
+		tc = fg
+	}
+
+	r, g, b := tc.RGB()
+	return color.RGBA{uint8(r), uint8(g), uint8(b), 255}
+}
+
+// FillCells fills the cells from (x1, y1) to (x2, y2), inclusive, relative to
+// the top left of the canvas.
+func (c *PixelCanvas) FillCells(x1, y1, x2, y2 int, tc tcell.Color) {
+	c.FillRect(float64(x1), float64(y1), float64(x2+1), float64(y2+1), tc)
+}
+
+// FillRect fills a rectangle measured in cells, which doesn't need to line up
+// with them.
+func (c *PixelCanvas) FillRect(x1, y1, x2, y2 float64, tc tcell.Color) {
+	col := c.Color(tc)
+	px1, py1 := int(x1*float64(c.CellWidth)), int(y1*float64(c.CellHeight))
+	px2, py2 := int(x2*float64(c.CellWidth)), int(y2*float64(c.CellHeight))
+
+	for y := py1; y < py2; y++ {
+		for x := px1; x < px2; x++ {
+			c.SetRGBA(x, y, col)
+		}
+	}
+}
+
+// FillCircle draws a round dot centered on a point measured in cells, with a
+// radius in cell widths.
+func (c *PixelCanvas) FillCircle(cx, cy, radius float64, tc tcell.Color) {
+	col := c.Color(tc)
+	r := radius * float64(c.CellWidth)
+	px, py := cx*float64(c.CellWidth), cy*float64(c.CellHeight)
+
+	for y := int(py - r); y <= int(py+r); y++ {
+		for x := int(px - r); x <= int(px+r); x++ {
+			if math.Hypot(float64(x)-px, float64(y)-py) <= r {
+				c.SetRGBA(x, y, col)
+			}
+		}
+	}
+}
+
+// DrawLine draws a line a pixel wide between two points measured in cells.
+func (c *PixelCanvas) DrawLine(x1, y1, x2, y2 float64, tc tcell.Color) {
+	col := c.Color(tc)
+	w, h := float64(c.CellWidth), float64(c.CellHeight)
+
+	bresenham(int(x1*w), int(y1*h), int(x2*w), int(y2*h), func(x, y int) {
+		c.SetRGBA(x, y, col)
+	})
+}
+
+// detectGraphics guesses which graphics protocol the terminal speaks from its
+// environment. Multiplexers get in the way of both, so they get none.
+func detectGraphics() string {
+	term := os.Getenv("TERM")
+	program := os.Getenv("TERM_PROGRAM")
+
+	switch {
+	case os.Getenv("TMUX") != "" || strings.HasPrefix(term, "screen"):
+		return GraphicsOff
+	case os.Getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty", term == "xterm-ghostty", program == "ghostty", program == "WezTerm":
+		return GraphicsKitty
+	case strings.Contains(term, "sixel"), term == "foot", strings.HasPrefix(term, "foot-"), term == "mlterm", term == "contour", program == "iTerm.app":
+		return GraphicsSixel
+	}
+
+	return GraphicsOff
+}
+
+// graphicsRenderer writes images to the terminal with one of the graphics
+// protocols.
+type graphicsRenderer interface {
+	// draw puts the image over the cells from (x, y), in screen coordinates.
+	draw(w io.Writer, c *PixelCanvas, x, y, cols, rows int) error
+
+	// clear takes the image off the screen.
+	clear(w io.Writer, s *Screen) error
+}
+
+// newGraphicsRenderer returns the renderer for the setting, or nil to stick
+// to text.
+func newGraphicsRenderer(mode string, detected string) graphicsRenderer {
+	if mode == GraphicsAuto || mode == "" {
+		mode = detected
+	}
+
+	switch mode {
+	case GraphicsKitty:
+		return kittyRenderer{}
+	case GraphicsSixel:
+		return sixelRenderer{}
+	}
+
+	return nil
+}
+
+type kittyRenderer struct{}
+
+func (kittyRenderer) draw(w io.Writer, c *PixelCanvas, x, y, cols, rows int) error {
+	var buf bytes.Buffer
+
+	if err := png.Encode(&buf, c); err != nil {
+		return err
+	}
+
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	var out strings.Builder
+
+	// Save the cursor, since tcell expects it where it left it
+	fmt.Fprintf(&out, "\x1b7\x1b[%d;%dH", y+1, x+1)
+
+	for first := true; len(data) > 0 || first; first = false {
+		chunk := data
+
+		if len(chunk) > kittyChunkSize {
+			chunk = chunk[:kittyChunkSize]
+		}
+
+		data = data[len(chunk):]
+
+		more := 0
+		if len(data) > 0 {
+			more = 1
+		}
+
+		if first {
+			// q=2 keeps the terminal from answering, which tcell would read
+			// as keys. C=1 leaves the cursor alone.
+			fmt.Fprintf(&out, "\x1b_Ga=T,f=100,i=%d,p=1,q=2,C=1,c=%d,r=%d,m=%d;%s\x1b\\", kittyImageID, cols, rows, more, chunk)
+		} else {
+			fmt.Fprintf(&out, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+
+	out.WriteString("\x1b8")
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func (kittyRenderer) clear(w io.Writer, s *Screen) error {
+	_, err := fmt.Fprintf(w, "\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", kittyImageID)
+	return err
+}
+
+type sixelRenderer struct{}
+
+func (sixelRenderer) draw(w io.Writer, c *PixelCanvas, x, y, cols, rows int) error {
+	var out strings.Builder
+
+	fmt.Fprintf(&out, "\x1b7\x1b[%d;%dH", y+1, x+1)
+	encodeSixel(&out, c.RGBA)
+	out.WriteString("\x1b8")
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// clear redraws the text, which paints over sixel pixels.
+func (sixelRenderer) clear(w io.Writer, s *Screen) error {
+	s.Sync()
+	return nil
+}
+
+// encodeSixel writes the image as sixel data. Game canvases only use a
+// handful of colors, so each one gets a register; past 256 they're rounded
+// to a smaller palette.
+func encodeSixel(out *strings.Builder, img *image.RGBA) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	palette := make(map[color.RGBA]int)
+	quantize := false
+
+	for y := 0; y < height && !quantize; y++ {
+		for x := 0; x < width; x++ {
+			col := img.RGBAAt(x, y)
+
+			if _, ok := palette[col]; !ok {
+				if len(palette) == 256 {
+					quantize = true
+					break
+				}
+
+				palette[col] = len(palette)
+			}
+		}
+	}
+
+	pixel := func(x, y int) color.RGBA {
+		col := img.RGBAAt(x, y)
+
+		if quantize {
+			// Six levels per channel
+			col.R, col.G, col.B = col.R/51*51, col.G/51*51, col.B/51*51
+		}
+
+		return col
+	}
+
+	if quantize {
+		palette = make(map[color.RGBA]int)
+
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if _, ok := palette[pixel(x, y)]; !ok {
+					palette[pixel(x, y)] = len(palette)
+				}
+			}
+		}
+	}
+
+	fmt.Fprintf(out, "\x1bPq\"1;1;%d;%d", width, height)
+
+	for col, i := range palette {
+		fmt.Fprintf(out, "#%d;2;%d;%d;%d", i, int(col.R)*100/255, int(col.G)*100/255, int(col.B)*100/255)
+	}
+
+	// Each sixel is a column of six pixels, drawn one color at a time
+	for top := 0; top < height; top += 6 {
+		bands := make(map[int][]byte)
+
+		for x := 0; x < width; x++ {
+			for dy := 0; dy < 6 && top+dy < height; dy++ {
+				i := palette[pixel(x, top+dy)]
+
+				if bands[i] == nil {
+					bands[i] = make([]byte, width)
+				}
+
+				bands[i][x] |= 1 << dy
+			}
+		}
+
+		first := true
+
+		for i, bits := range bands {
+			if !first {
+				out.WriteByte('$')
+			}
+
+			first = false
+			fmt.Fprintf(out, "#%d", i)
+			writeSixelRuns(out, bits)
+		}
+
+		out.WriteByte('-')
+	}
+
+	out.WriteString("\x1b\\")
+}
+
+// writeSixelRuns writes a row of sixels, squashing repeats.
+func writeSixelRuns(out *strings.Builder, bits []byte) {
+	for x := 0; x < len(bits); {
+		run := 1
+
+		for x+run < len(bits) && bits[x+run] == bits[x] {
+			run++
+		}
+
+		ch := byte(63 + bits[x])
+
+		if run > 3 {
+			fmt.Fprintf(out, "!%d%c", run, ch)
+		} else {
+			for i := 0; i < run; i++ {
+				out.WriteByte(ch)
+			}
+		}
+
+		x += run
+	}
+}
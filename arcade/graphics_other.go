@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package arcade
+
+// cellPixelSize can't ask the terminal here, so it guesses.
+func cellPixelSize() (int, int) {
+	return defaultCellWidth, defaultCellHeight
+}
@@ -0,0 +1,20 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package arcade
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cellPixelSize asks the terminal how many pixels make up a cell.
+func cellPixelSize() (int, int) {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+
+	if err != nil || ws.Col == 0 || ws.Row == 0 || ws.Xpixel == 0 || ws.Ypixel == 0 {
+		return defaultCellWidth, defaultCellHeight
+	}
+
+	return int(ws.Xpixel / ws.Col), int(ws.Ypixel / ws.Row)
+}
@@ -1,11 +1,23 @@
 package arcade
 
 type HeartbeatEvent struct {
+	ClientID string
+
+	// The sender's view's payload, and everything else it said
 	Metadata []byte
+	Info     *HeartbeatMetadata
 }
 
-func NewHeartbeatEvent(metadata []byte) *HeartbeatEvent {
+func NewHeartbeatEvent(clientID string, metadata []byte) *HeartbeatEvent {
+	info := parseHeartbeatMetadata(metadata)
+
 	return &HeartbeatEvent{
-		Metadata: metadata,
+		ClientID: clientID,
+		Metadata: info.Payload,
+		Info:     info,
 	}
 }
+
+func (e *HeartbeatEvent) Topic() EventTopic {
+	return NetworkEvents
+}
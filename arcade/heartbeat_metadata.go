@@ -0,0 +1,199 @@
+package arcade
+
+import (
+	"encoding"
+	"encoding/json"
+	"hash/fnv"
+	"reflect"
+	"sync"
+)
+
+// heartbeatMetadataVersion goes up when the meaning of a field changes.
+// Adding fields or extensions doesn't need a new version, since anything a
+// client doesn't know about is ignored.
+const heartbeatMetadataVersion = 1
+
+// HeartbeatMetadata rides along with every heartbeat, saying what the sender
+// is up to.
+type HeartbeatMetadata struct {
+	Version int
+
+	// Type of the sender's current view, like "LobbyView"
+	View string
+
+	// Set while the sender is in a lobby or game
+	Lobby   *LobbySummary `json:",omitempty"`
+	Players int           `json:",omitempty"`
+
+	// Set by distributors, saying how busy they are
+	Load *DistributorLoad `json:",omitempty"`
+
+	// Set by distributors that know which region they serve
+	Region string `json:",omitempty"`
+
+	// Set while the sender hasn't touched the keyboard in a while
+	Away bool `json:",omitempty"`
+
+	// Hash of the sender's game state, to spot players drifting apart
+	StateHash uint64 `json:",omitempty"`
+
+	// Whatever the view returned from GetHeartbeatMetadata
+	Payload json.RawMessage `json:",omitempty"`
+
+	// Extra data from registered extensions, by name
+	Extensions map[string]json.RawMessage `json:",omitempty"`
+}
+
+// LobbySummary is the gist of a lobby, small enough to send every heartbeat.
+type LobbySummary struct {
+	ID       string
+	Name     string
+	HostID   string
+	GameType string
+	Capacity int
+	Players  int
+	Private  bool
+	Region   string     `json:",omitempty"`
+	State    LobbyState `json:",omitempty"`
+
+	// Set for lobbies hosted by a server that doesn't play in them
+	Dedicated bool `json:",omitempty"`
+}
+
+// HeartbeatView is implemented by views with more to say in heartbeats than
+// their payload.
+type HeartbeatView interface {
+	AnnotateHeartbeat(md *HeartbeatMetadata)
+}
+
+var (
+	heartbeatExtensionsMu sync.RWMutex
+	heartbeatExtensions   = make(map[string]func() interface{})
+)
+
+// RegisterHeartbeatExtension adds data to every heartbeat under the name.
+// The function is called for each heartbeat, and can return nil to leave it
+// out.
+func RegisterHeartbeatExtension(name string, f func() interface{}) {
+	heartbeatExtensionsMu.Lock()
+	defer heartbeatExtensionsMu.Unlock()
+
+	heartbeatExtensions[name] = f
+}
+
+// heartbeatSource is a view, or a lobby we host, whose heartbeats say what
+// it's up to.
+type heartbeatSource interface {
+	GetHeartbeatMetadata() encoding.BinaryMarshaler
+}
+
+// newHeartbeatMetadata collects the metadata for the view.
+func newHeartbeatMetadata(v heartbeatSource) (*HeartbeatMetadata, error) {
+	md := &HeartbeatMetadata{
+		Version: heartbeatMetadataVersion,
+		View:    reflect.Indirect(reflect.ValueOf(v)).Type().Name(),
+	}
+
+	if payload := v.GetHeartbeatMetadata(); payload != nil {
+		data, err := payload.MarshalBinary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		md.Payload = data
+	}
+
+	if hv, ok := v.(HeartbeatView); ok {
+		hv.AnnotateHeartbeat(md)
+	}
+
+	heartbeatExtensionsMu.RLock()
+	defer heartbeatExtensionsMu.RUnlock()
+
+	for name, f := range heartbeatExtensions {
+		ext := f()
+
+		if ext == nil {
+			continue
+		}
+
+		data, err := json.Marshal(ext)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if md.Extensions == nil {
+			md.Extensions = make(map[string]json.RawMessage)
+		}
+
+		md.Extensions[name] = data
+	}
+
+	return md, nil
+}
+
+// parseHeartbeatMetadata reads heartbeat metadata. Peers from before it had
+// a version send their view's payload alone, which is returned as it was.
+func parseHeartbeatMetadata(data []byte) *HeartbeatMetadata {
+	md := &HeartbeatMetadata{}
+
+	if len(data) == 0 {
+		return md
+	}
+
+	if err := json.Unmarshal(data, md); err != nil || md.Version == 0 {
+		return &HeartbeatMetadata{Payload: data}
+	}
+
+	return md
+}
+
+// Extension decodes the named extension into v. Returns false if the sender
+// didn't include it, or it couldn't be read.
+func (md *HeartbeatMetadata) Extension(name string, v interface{}) bool {
+	data, ok := md.Extensions[name]
+
+	if !ok {
+		return false
+	}
+
+	return json.Unmarshal(data, v) == nil
+}
+
+func (md *HeartbeatMetadata) MarshalBinary() ([]byte, error) {
+	return json.Marshal(md)
+}
+
+// summary returns the gist of the lobby for heartbeats. Expects the lock to
+// be held.
+func (l *Lobby) summary() *LobbySummary {
+	return &LobbySummary{
+		ID:       l.ID,
+		Name:     l.Name,
+		HostID:   l.HostID,
+		GameType: l.GameType,
+		Capacity: l.Capacity,
+		Players:  len(l.PlayerIDs),
+		Private:  l.Private,
+		Region:   l.Region,
+		State:    l.State,
+
+		Dedicated: l.Dedicated,
+	}
+}
+
+// hashState hashes anything that marshals to JSON, for StateHash.
+func hashState(state interface{}) uint64 {
+	data, err := json.Marshal(state)
+
+	if err != nil {
+		return 0
+	}
+
+	h := fnv.New64a()
+	h.Write(data)
+
+	return h.Sum64()
+}
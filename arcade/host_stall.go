@@ -0,0 +1,45 @@
+package arcade
+
+import (
+	"time"
+)
+
+const (
+	// Gap between a host's ticks past which it's taken to have stalled, like
+	// when its machine slept or its process was suspended, rather than just
+	// run a little late
+	hostStallGap = time.Second
+
+	// How long the game's held still once the host's back, so everyone sees
+	// where things are before they move again
+	hostResumeDelay = time.Second
+)
+
+// HostStall notices when the host stopped ticking for a while. Tickers drop
+// the ticks they couldn't deliver, so a host that comes back simply carries
+// on from where it was, and the players would see the game jump ahead.
+// Holding it still until everyone's caught up keeps anyone from missing the
+// ball while they were frozen.
+type HostStall struct {
+	last     time.Time
+	resumeAt time.Time
+}
+
+// Tick records a tick at now. Returns whether the game should be held still
+// while it catches up after a stall, and whether the stall only just ended.
+func (h *HostStall) Tick(now time.Time) (held, returned bool) {
+	returned = !h.last.IsZero() && now.Sub(h.last) > hostStallGap
+	h.last = now
+
+	if returned {
+		h.resumeAt = now.Add(hostResumeDelay)
+	}
+
+	return now.Before(h.resumeAt), returned
+}
+
+// hostQuiet returns whether we've gone long enough without hearing from the
+// host since we last did that it's likely stalled.
+func hostQuiet(lastHeard, now time.Time) bool {
+	return !lastHeard.IsZero() && now.Sub(lastHeard) > hostStallGap
+}
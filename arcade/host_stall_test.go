@@ -0,0 +1,43 @@
+package arcade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHostStall(t *testing.T) {
+	var h HostStall
+	start := time.Now()
+
+	for i := 0; i < 5; i++ {
+		if held, returned := h.Tick(start.Add(time.Duration(i) * PongTickPeriod)); held || returned {
+			t.Fatalf("tick %d held the game with no stall", i)
+		}
+	}
+
+	back := start.Add(10 * time.Second)
+
+	if held, returned := h.Tick(back); !held || !returned {
+		t.Errorf("coming back from a stall = %v, %v, want held and returned", held, returned)
+	}
+
+	if held, returned := h.Tick(back.Add(PongTickPeriod)); !held || returned {
+		t.Errorf("tick after a stall = %v, %v, want still held", held, returned)
+	}
+
+	if held, _ := h.Tick(back.Add(hostResumeDelay + PongTickPeriod)); held {
+		t.Error("still held once the resume delay passed")
+	}
+}
+
+func TestHostQuiet(t *testing.T) {
+	now := time.Now()
+
+	if hostQuiet(time.Time{}, now) {
+		t.Error("quiet before hearing from the host at all")
+	}
+
+	if hostQuiet(now.Add(-PongTickPeriod), now) || !hostQuiet(now.Add(-2*hostStallGap), now) {
+		t.Error("wrong about how long the host's been quiet")
+	}
+}
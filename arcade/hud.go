@@ -0,0 +1,196 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Cells between HUD items, and from the corners of the court
+const (
+	hudGap    = 1
+	hudMargin = 3
+)
+
+// HUDItem is one thing a game shows along the top of the court, like a
+// player's lives or the time left.
+type HUDItem struct {
+	Label string
+
+	// Shown after the label, or nothing for just the label
+	Value string
+
+	Color tcell.Color
+
+	// Set for the player's own, so they can find it
+	Bold bool
+
+	// Set for something that needs noticing, like time running out
+	Warn bool
+}
+
+// text returns how the item's drawn, or just its value if space is short.
+func (item HUDItem) text(compact bool) string {
+	switch {
+	case item.Value == "":
+		return " " + item.Label + " "
+	case compact:
+		return " " + item.Value + " "
+	}
+
+	return fmt.Sprintf(" %s:%s ", item.Label, item.Value)
+}
+
+// HUD is what a game shows over the top edge of the court, worked out from
+// its state each frame so it's always what the host last sent. Players go on
+// the left, and things about the whole game, like timers, on the right.
+type HUD struct {
+	Left  []HUDItem
+	Right []HUDItem
+}
+
+// hudLayout places each item in a HUD on the top row of a display of the
+// width, returning their texts and where they start. Labels are dropped when
+// everything won't fit, then whatever's left over is left off.
+func hudLayout(hud HUD, width int) (texts []string, xs []int) {
+	items := append(append([]HUDItem(nil), hud.Left...), hud.Right...)
+
+	fits := func(compact bool) bool {
+		total := 2*hudMargin - hudGap
+
+		for _, item := range items {
+			total += layout.Width(item.text(compact)) + hudGap
+		}
+
+		return total <= width
+	}
+
+	compact := !fits(false)
+	texts = make([]string, len(items))
+	xs = make([]int, len(items))
+
+	for i, item := range items {
+		texts[i] = item.text(compact)
+	}
+
+	left := hudMargin
+
+	for i := range hud.Left {
+		xs[i] = left
+		left += layout.Width(texts[i]) + hudGap
+	}
+
+	right := width - hudMargin
+
+	for i := len(items) - 1; i >= len(hud.Left); i-- {
+		right -= layout.Width(texts[i])
+		xs[i] = right
+		right -= hudGap
+	}
+
+	// Anything that would run into something else is left off
+	for i := range items {
+		end := xs[i] + layout.Width(texts[i])
+		clash := end > width-hudMargin
+
+		if i < len(hud.Left) && len(hud.Right) > 0 {
+			clash = clash || end > xs[len(hud.Left)]-hudGap
+		}
+
+		if clash {
+			texts[i] = ""
+		}
+	}
+
+	return texts, xs
+}
+
+// DrawHUD draws the HUD over the top edge of the court.
+func (s *Screen) DrawHUD(hud HUD) {
+	width, _ := s.displaySize()
+	texts, xs := hudLayout(hud, width)
+	items := append(append([]HUDItem(nil), hud.Left...), hud.Right...)
+
+	for i, item := range items {
+		if texts[i] == "" {
+			continue
+		}
+
+		sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(item.Color).Bold(item.Bold)
+
+		if item.Warn {
+			sty = tcell.StyleDefault.Background(tcell.ColorRed).Foreground(tcell.ColorWhite).Bold(true)
+		}
+
+		s.DrawText(xs[i], 1, sty, texts[i])
+	}
+}
+
+// pongHUD shows each player's lives, in their color.
+func pongHUD(state PongGameState, playerIDs []string, me string) HUD {
+	var hud HUD
+
+	for i, playerID := range playerIDs {
+		cs, ok := state.ClientStates[playerID]
+
+		if !ok {
+			continue
+		}
+
+		lives := fmt.Sprint(cs.Lives)
+
+		if cs.Eliminated() {
+			lives = "X"
+		}
+
+		hud.Left = append(hud.Left, HUDItem{
+			Label: fmt.Sprintf("P%d", i+1),
+			Value: lives,
+			Color: tcell.ColorNames[cs.Color],
+			Bold:  playerID == me,
+		})
+	}
+
+	if clock, ok := state.Timer.HUDItem(state.Tick, PongTickPeriod); ok {
+		hud.Right = append(hud.Right, clock)
+	}
+
+	return hud
+}
+
+// tronHUD shows who's still riding, in their color, and warns of the arena
+// closing in.
+func tronHUD(state TronGameState, playerIDs []string, me string) HUD {
+	var hud HUD
+
+	for i, playerID := range playerIDs {
+		cs, ok := state.ClientStates[playerID]
+
+		if !ok {
+			continue
+		}
+
+		item := HUDItem{
+			Label: fmt.Sprintf("P%d", i+1),
+			Color: tcell.ColorNames[cs.Color],
+			Bold:  playerID == me,
+		}
+
+		if !cs.Alive {
+			item.Value = "X"
+		}
+
+		hud.Left = append(hud.Left, item)
+	}
+
+	if state.Shrink.Warning(state.Tick) {
+		hud.Right = append(hud.Right, HUDItem{Label: "WALL CLOSING", Warn: true})
+	}
+
+	if clock, ok := state.Timer.HUDItem(state.Tick, TronTimestepPeriod); ok {
+		hud.Right = append(hud.Right, clock)
+	}
+
+	return hud
+}
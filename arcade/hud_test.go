@@ -0,0 +1,63 @@
+package arcade
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHUDLayout(t *testing.T) {
+	hud := HUD{
+		Left:  []HUDItem{{Label: "P1", Value: "3"}, {Label: "P2", Value: "X"}},
+		Right: []HUDItem{{Label: "Time", Value: "1:30"}},
+	}
+
+	texts, xs := hudLayout(hud, displayWidth)
+
+	if texts[0] != " P1:3 " || xs[0] != 3 || xs[1] != 10 {
+		t.Errorf("players at %v: %q", xs, texts)
+	}
+
+	if texts[2] != " Time:1:30 " || xs[2]+len(texts[2]) != displayWidth-hudMargin {
+		t.Errorf("timer %q at %d", texts[2], xs[2])
+	}
+}
+
+func TestHUDLayoutWhenCrowded(t *testing.T) {
+	var hud HUD
+
+	for i := 0; i < 10; i++ {
+		hud.Left = append(hud.Left, HUDItem{Label: fmt.Sprintf("Player %d", i+1), Value: "3"})
+	}
+
+	texts, _ := hudLayout(hud, displayWidth)
+
+	if texts[0] != " 3 " {
+		t.Errorf("labels kept when crowded: %q", texts[0])
+	}
+
+	// Far too many to fit, even without labels
+	for i := 0; i < 30; i++ {
+		hud.Left = append(hud.Left, HUDItem{Label: "P", Value: "10"})
+	}
+
+	texts, xs := hudLayout(hud, displayWidth)
+
+	for i, text := range texts {
+		if text != "" && xs[i]+len(text) > displayWidth-hudMargin {
+			t.Errorf("%q runs off the edge", text)
+		}
+	}
+}
+
+func TestPongHUDFollowsState(t *testing.T) {
+	state := PongGameState{ClientStates: map[string]PongClientState{
+		"a": {Lives: 2},
+		"b": {Lives: 0},
+	}}
+
+	hud := pongHUD(state, []string{"a", "b"}, "b")
+
+	if hud.Left[0].Value != "2" || hud.Left[1].Value != "X" || !hud.Left[1].Bold || hud.Left[0].Bold {
+		t.Errorf("got %+v", hud.Left)
+	}
+}
@@ -0,0 +1,199 @@
+package arcade
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+
+	"github.com/google/uuid"
+)
+
+const IDENTITY_FILENAME = "identity.key"
+
+// Player IDs are UUIDs named by the identity's public key in this namespace,
+// so they look like the random ones used before identities were kept.
+var identityNamespace = uuid.MustParse("5b0f6a4e-2c1d-4f7e-9a3b-8d6e1c2f4a70")
+
+// Identity is the key pair a player is known by. It's made on first run and
+// kept in the config directory, so friends, blocks and results refer to the
+// same person from one session to the next.
+type Identity struct {
+	Key ed25519.PrivateKey
+}
+
+// NewIdentity makes an identity that isn't saved anywhere.
+func NewIdentity() *Identity {
+	_, key, err := ed25519.GenerateKey(nil)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return &Identity{Key: key}
+}
+
+func identityPath() (string, error) {
+	dir, err := configDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, IDENTITY_FILENAME), nil
+}
+
+// LoadIdentity reads the identity from the given file, or the default one if
+// it's "", and makes and saves one if there isn't one yet.
+func LoadIdentity(file string) (*Identity, error) {
+	if file == "" {
+		var err error
+
+		if file, err = identityPath(); err != nil {
+			return NewIdentity(), err
+		}
+	}
+
+	data, err := os.ReadFile(file)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		identity := NewIdentity()
+
+		if err := os.MkdirAll(path.Dir(file), 0755); err != nil {
+			return identity, err
+		}
+
+		// Only we should be able to read the key
+		return identity, os.WriteFile(file, identity.Key.Seed(), 0600)
+	} else if err != nil {
+		return NewIdentity(), err
+	}
+
+	if len(data) != ed25519.SeedSize {
+		return NewIdentity(), errors.New("identity file is corrupt")
+	}
+
+	return &Identity{Key: ed25519.NewKeyFromSeed(data)}, nil
+}
+
+func (i *Identity) PublicKey() ed25519.PublicKey {
+	return i.Key.Public().(ed25519.PublicKey)
+}
+
+// PlayerID returns the ID the identity is known by.
+func (i *Identity) PlayerID() string {
+	return identityPlayerID(i.PublicKey())
+}
+
+func identityPlayerID(key ed25519.PublicKey) string {
+	return uuid.NewSHA1(identityNamespace, key).String()
+}
+
+// validPlayerID returns true if the ID is written the way identities' are.
+func validPlayerID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil && len(id) == len(identityNamespace.String())
+}
+
+// shortID returns the start of a player ID for showing people, n characters
+// at most. IDs from other players aren't trusted to be as long as ours.
+func shortID(id string, n int) string {
+	if len(id) <= n {
+		return id
+	}
+
+	return id[:n]
+}
+
+// NewSessionToken vouches for a session key, so others can tell it belongs to
+// this identity.
+func (i *Identity) NewSessionToken(sessionKey ed25519.PublicKey) SessionToken {
+	return SessionToken{
+		IdentityKey: i.PublicKey(),
+		SessionKey:  sessionKey,
+		Signature:   ed25519.Sign(i.Key, sessionKey),
+	}
+}
+
+// SessionToken ties a session's key to the identity of the player using it.
+type SessionToken struct {
+	IdentityKey ed25519.PublicKey
+	SessionKey  ed25519.PublicKey
+	Signature   []byte
+}
+
+// Verify returns true if the token is from the player with the ID.
+func (t SessionToken) Verify(playerID string) bool {
+	if len(t.IdentityKey) != ed25519.PublicKeySize || len(t.SessionKey) != ed25519.PublicKeySize {
+		return false
+	}
+
+	return identityPlayerID(t.IdentityKey) == playerID && ed25519.Verify(t.IdentityKey, t.SessionKey, t.Signature)
+}
+
+// JoinProof shows a join is from whoever holds the session key in the
+// joiner's token, meant for the one lobby, once. Every host a player joins
+// is handed their token, so the token alone doesn't show who's sending it.
+type JoinProof struct {
+	Nonce     string
+	Signature []byte
+}
+
+// joinProofBytes returns what joiners sign.
+func joinProofBytes(lobbyID, nonce string) []byte {
+	return []byte("join:" + lobbyID + ":" + nonce)
+}
+
+// newJoinProof signs our join to the lobby with our session key.
+func (s *Server) newJoinProof(lobbyID string) JoinProof {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+
+	proof := JoinProof{Nonce: hex.EncodeToString(nonce)}
+	proof.Signature = ed25519.Sign(s.SessionKey, joinProofBytes(lobbyID, proof.Nonce))
+
+	return proof
+}
+
+// Verify returns true if the proof is signed with the token's session key,
+// for the lobby.
+func (p JoinProof) Verify(token SessionToken, lobbyID string) bool {
+	if p.Nonce == "" || len(token.SessionKey) != ed25519.PublicKeySize {
+		return false
+	}
+
+	return ed25519.Verify(token.SessionKey, joinProofBytes(lobbyID, p.Nonce), p.Signature)
+}
+
+// sessionKeyProof shows an end-to-end session key is a player's: it's signed
+// with the session key their identity vouches for.
+type sessionKeyProof struct {
+	Token     SessionToken
+	Signature []byte
+}
+
+// proveSessionKey returns proof the end-to-end session key is ours.
+func (s *Server) proveSessionKey(publicKey []byte) []byte {
+	proof, _ := json.Marshal(sessionKeyProof{
+		Token:     s.SessionToken,
+		Signature: ed25519.Sign(s.SessionKey, publicKey),
+	})
+
+	return proof
+}
+
+// checkSessionKey returns true if the proof shows the end-to-end session key
+// is the player's.
+func checkSessionKey(playerID string, publicKey, proof []byte) bool {
+	var p sessionKeyProof
+
+	if err := json.Unmarshal(proof, &p); err != nil || !p.Token.Verify(playerID) {
+		return false
+	}
+
+	return ed25519.Verify(p.Token.SessionKey, publicKey, p.Signature)
+}
@@ -0,0 +1,159 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShortID(t *testing.T) {
+	for _, c := range []struct {
+		id   string
+		n    int
+		want string
+	}{
+		{"5b0f6a4e-2c1d", 8, "5b0f6a4e"},
+		{"5b0f", 8, "5b0f"},
+		{"", 4, ""},
+	} {
+		if got := shortID(c.id, c.n); got != c.want {
+			t.Errorf("shortID(%q, %d) = %q, want %q", c.id, c.n, got, c.want)
+		}
+	}
+}
+
+func TestSessionTokenVerify(t *testing.T) {
+	identity := NewIdentity()
+	session := NewIdentity()
+	token := identity.NewSessionToken(session.PublicKey())
+
+	if !token.Verify(identity.PlayerID()) {
+		t.Fatal("rejected a good token")
+	}
+
+	if token.Verify(session.PlayerID()) {
+		t.Error("accepted a token for another player")
+	}
+
+	bad := token
+	bad.Signature = append([]byte(nil), token.Signature...)
+	bad.Signature[0] ^= 0xff
+
+	if bad.Verify(identity.PlayerID()) {
+		t.Error("accepted a bad signature")
+	}
+
+	// Someone else's session key, with our identity's signature
+	bad = token
+	bad.SessionKey = NewIdentity().PublicKey()
+
+	if bad.Verify(identity.PlayerID()) {
+		t.Error("accepted a session key the identity didn't sign")
+	}
+
+	bad = token
+	bad.IdentityKey = token.IdentityKey[:16]
+
+	if bad.Verify(identity.PlayerID()) {
+		t.Error("accepted a short identity key")
+	}
+
+	bad = token
+	bad.SessionKey = append(append([]byte(nil), token.SessionKey...), 0)
+
+	if bad.Verify(identity.PlayerID()) {
+		t.Error("accepted a long session key")
+	}
+
+	if (SessionToken{}).Verify(identity.PlayerID()) {
+		t.Error("accepted an empty token")
+	}
+}
+
+func TestVerifyJoin(t *testing.T) {
+	newPlayer := func() *Server {
+		identity := NewIdentity()
+		public, session, _ := ed25519.GenerateKey(nil)
+
+		return &Server{ID: identity.PlayerID(), SessionKey: session, SessionToken: identity.NewSessionToken(public)}
+	}
+
+	joinFrom := func(s *Server, lobbyID string) *JoinMessage {
+		return &JoinMessage{
+			Message:  message.Message{Type: "join", SenderID: s.ID},
+			PlayerID: s.ID,
+			LobbyID:  lobbyID,
+			Token:    s.SessionToken,
+			Proof:    s.newJoinProof(lobbyID),
+		}
+	}
+
+	player, host := newPlayer(), newPlayer()
+	lobby, other := &Lobby{ID: "a"}, &Lobby{ID: "b"}
+	join := joinFrom(player, "a")
+
+	if !lobby.verifyJoin(join) {
+		t.Fatal("rejected a good join")
+	}
+
+	if lobby.verifyJoin(join) {
+		t.Error("took the same join twice")
+	}
+
+	// The host passing the player's join on to another lobby
+	replayed := *join
+	replayed.LobbyID = "b"
+
+	if other.verifyJoin(&replayed) {
+		t.Error("took a join meant for another lobby")
+	}
+
+	// The player's token, with the host's own signature
+	forged := joinFrom(host, "b")
+	forged.SenderID, forged.PlayerID, forged.Token = player.ID, player.ID, player.SessionToken
+
+	if other.verifyJoin(forged) {
+		t.Error("took a join signed by someone else")
+	}
+}
+
+func TestLoadIdentity(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keys", IDENTITY_FILENAME)
+
+	first, err := LoadIdentity(file)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := LoadIdentity(file)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.PlayerID() != second.PlayerID() {
+		t.Error("identity changed between loads")
+	}
+
+	if err := os.WriteFile(file, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := LoadIdentity(file)
+
+	if err == nil {
+		t.Error("loaded a corrupt identity file")
+	}
+
+	// There's still an identity to play with this session
+	if identity == nil || identity.PlayerID() == first.PlayerID() {
+		t.Error("didn't make a new identity for a corrupt file")
+	}
+
+	if data, _ := os.ReadFile(file); string(data) != "not a key" {
+		t.Error("overwrote a corrupt identity file")
+	}
+}
@@ -0,0 +1,208 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	// Size of a corner window, border included. About a third of the display
+	// each way, so a game's still readable in it
+	insetWidth  = 28
+	insetHeight = 10
+
+	// Corner windows open at most, the oldest closing for a new one
+	maxInsets = 2
+)
+
+// Inset is a view shown in a window over the current one, like a friend's
+// game kept in the corner while browsing lobbies. It's drawn shrunk to fit,
+// and gets messages but not keys.
+type Inset struct {
+	View  View
+	Title string
+
+	// Where the window is, border included, and which is drawn over which:
+	// higher is on top
+	X1, Y1, X2, Y2 int
+	Z              int
+}
+
+// PopToInset moves the current view into a window in the corner, still
+// running, and goes back to the view underneath. Returns false if there's
+// nothing to go back to.
+func (mgr *ViewManager) PopToInset(title string) bool {
+	mgr.Lock()
+
+	if len(mgr.stack) == 0 {
+		mgr.Unlock()
+		return false
+	}
+
+	if len(mgr.insets) == maxInsets {
+		mgr.insets[0].View.Unload()
+		mgr.insets = mgr.insets[1:]
+	}
+
+	// Up the right hand side from the bottom, above the status bar, in the
+	// first place that's free
+	width, height := mgr.screen.displaySize()
+	x2 := width - 2
+	y2 := height - 3
+
+	for taken := true; taken; {
+		taken = false
+
+		for _, in := range mgr.insets {
+			if in.Y2 == y2 {
+				taken = true
+				y2 -= insetHeight
+			}
+		}
+	}
+
+	inset := &Inset{
+		View:  mgr.view,
+		Title: title,
+		X1:    x2 - insetWidth + 1,
+		Y1:    y2 - insetHeight + 1,
+		X2:    x2,
+		Y2:    y2,
+		Z:     mgr.nextInsetZ,
+	}
+
+	mgr.nextInsetZ++
+	mgr.insets = append(mgr.insets, inset)
+	sort.SliceStable(mgr.insets, func(i, j int) bool {
+		return mgr.insets[i].Z < mgr.insets[j].Z
+	})
+
+	v := mgr.stack[len(mgr.stack)-1]
+	mgr.stack = mgr.stack[:len(mgr.stack)-1]
+	mgr.show(v, TransitionNone)
+	mgr.Unlock()
+
+	if pv, ok := v.(PausableView); ok {
+		pv.OnResume()
+	}
+
+	mgr.Status.Update(v)
+	mgr.RequestRender()
+	return true
+}
+
+// CloseInsets unloads the views in corner windows.
+func (mgr *ViewManager) CloseInsets() {
+	mgr.Lock()
+	insets := mgr.insets
+	mgr.insets = nil
+	mgr.Unlock()
+
+	for _, in := range insets {
+		in.View.Unload()
+	}
+
+	mgr.screen.Reset()
+	mgr.RequestRender()
+}
+
+// insetViews returns the views in corner windows, bottom first.
+func (mgr *ViewManager) insetViews() []*Inset {
+	mgr.RLock()
+	defer mgr.RUnlock()
+
+	return append([]*Inset(nil), mgr.insets...)
+}
+
+// renderInsets draws the corner windows over the view. They're left out of
+// games, so they never hide the player's own.
+func (mgr *ViewManager) renderInsets() {
+	mgr.RLock()
+	v := mgr.view
+	mgr.RUnlock()
+
+	if inGame(v) {
+		return
+	}
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorTeal)
+
+	for _, in := range mgr.insetViews() {
+		canvas, f := mgr.screen.Canvas()
+
+		mgr.RLock()
+		in.View.Render(canvas)
+		mgr.RUnlock()
+
+		mgr.screen.DrawFrame(f.scaled(in.X2-in.X1-1, in.Y2-in.Y1-1), in.X1+1, in.Y1+1)
+		mgr.screen.DrawBox(in.X1, in.Y1, in.X2, in.Y2, sty, false)
+
+		title := layout.Truncate(" "+in.Title+" ", in.X2-in.X1-3)
+		mgr.screen.DrawText(in.X1+2, in.Y1, sty, title)
+	}
+}
+
+// DrawFrame copies a frame onto the screen with its top left at the cell,
+// cut off at the edge of the display.
+func (s *Screen) DrawFrame(f frame, x, y int) {
+	startX, startY := s.offset()
+	width, height := s.displaySize()
+
+	for row := range f {
+		for col, c := range f[row] {
+			if x+col < 0 || x+col >= width || y+row < 0 || y+row >= height {
+				continue
+			}
+
+			s.SetContent(startX+x+col, startY+y+row, c.primary, c.combining, c.style)
+		}
+	}
+}
+
+// scaled shrinks the frame to the size. Each cell stands for a block of the
+// original, and shows the first thing drawn in it, so a ball or a paddle a
+// cell wide isn't lost between the cells that get kept.
+func (f frame) scaled(width, height int) frame {
+	out := make(frame, height)
+
+	if len(f) == 0 {
+		return out
+	}
+
+	srcWidth, srcHeight := len(f[0]), len(f)
+
+	for y := range out {
+		out[y] = make([]frameCell, width)
+		y1, y2 := y*srcHeight/height, (y+1)*srcHeight/height
+
+		for x := range out[y] {
+			x1, x2 := x*srcWidth/width, (x+1)*srcWidth/width
+			out[y][x] = f[y1][x1]
+
+		block:
+			for sy := y1; sy < y2; sy++ {
+				for sx := x1; sx < x2; sx++ {
+					if f[sy][sx].drawn() {
+						out[y][x] = f[sy][sx]
+						break block
+					}
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+// drawn returns true if the cell has something in it, rather than being
+// empty background.
+func (c frameCell) drawn() bool {
+	if c.primary != ' ' && c.primary != 0 {
+		return true
+	}
+
+	_, bg, _ := c.style.Decompose()
+	return bg != tcell.ColorDefault && bg != tcell.ColorBlack
+}
@@ -0,0 +1,54 @@
+package arcade
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestCanvasDrawsIntoFrame(t *testing.T) {
+	canvas, f := (&Screen{}).Canvas()
+	sty := tcell.StyleDefault.Foreground(tcell.ColorGreen)
+
+	canvas.DrawText(displayWidth-2, 3, sty, "hello")
+	canvas.DrawBox(-5, -5, 2, 2, sty, false)
+
+	if f[3][displayWidth-2].primary != 'h' || f[3][displayWidth-1].primary != 'e' {
+		t.Errorf("text not drawn, got %q", string(f[3][displayWidth-2].primary))
+	}
+
+	// Cut off at the edge rather than wrapping or panicking
+	if f[4][0].primary != ' ' || f[2][2].primary != '┛' {
+		t.Error("box not cut off at the edge")
+	}
+}
+
+func TestScaledFrameKeepsSmallThings(t *testing.T) {
+	canvas, f := (&Screen{}).Canvas()
+	canvas.SetContent(41, 13, '●', nil, tcell.StyleDefault)
+	canvas.DrawEmpty(3, 8, 3, 12, tcell.StyleDefault.Background(tcell.ColorWhite))
+
+	small := f.scaled(26, 8)
+
+	if len(small) != 8 || len(small[0]) != 26 {
+		t.Fatalf("got %dx%d", len(small[0]), len(small))
+	}
+
+	ball, paddle := 0, 0
+
+	for _, row := range small {
+		for _, c := range row {
+			if c.primary == '●' {
+				ball++
+			}
+
+			if _, bg, _ := c.style.Decompose(); bg == tcell.ColorWhite {
+				paddle++
+			}
+		}
+	}
+
+	if ball != 1 || paddle == 0 {
+		t.Errorf("got %d balls and %d paddle cells", ball, paddle)
+	}
+}
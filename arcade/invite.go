@@ -0,0 +1,37 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+type InviteMessage struct {
+	message.Message
+	LobbyID   string
+	LobbyName string
+	GameType  string
+	HostID    string
+	HostName  string
+
+	// Set when inviting a coach, with the player they'd be coaching
+	CoachFor   string
+	PlayerName string
+}
+
+func NewInviteMessage(lobby *Lobby, hostName string) *InviteMessage {
+	lobby.mu.RLock()
+	defer lobby.mu.RUnlock()
+
+	return &InviteMessage{
+		Message:   message.Message{Type: "invite"},
+		LobbyID:   lobby.ID,
+		LobbyName: lobby.Name,
+		GameType:  lobby.GameType,
+		HostID:    lobby.HostID,
+		HostName:  hostName,
+	}
+}
+
+func (m InviteMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -9,7 +9,20 @@ type JoinMessage struct {
 	message.Message
 	PlayerID string
 	Code     string
+	Password string
 	LobbyID  string
+
+	// Set when joining as this player's coach rather than to play
+	CoachFor string
+
+	// The joining player's session key, for signing match results, vouched
+	// for by their identity, and their signature with it on this join
+	Token SessionToken
+	Proof JoinProof
+
+	// Who's joining, so they're on the roster in the lobby the host sends
+	// back rather than nameless until their first heartbeat
+	Status LobbyPlayerStatus
 }
 
 func NewJoinMessage(code string, playerID string, lobbyID string) *JoinMessage {
@@ -18,9 +31,40 @@ func NewJoinMessage(code string, playerID string, lobbyID string) *JoinMessage {
 		PlayerID: playerID,
 		Code:     code,
 		LobbyID:  lobbyID,
+		Token:    arcade.Server.SessionToken,
+		Proof:    arcade.Server.newJoinProof(lobbyID),
 	}
 }
 
+func NewPasswordJoinMessage(code string, password string, playerID string, lobbyID string) *JoinMessage {
+	msg := NewJoinMessage(code, playerID, lobbyID)
+	msg.Password = password
+	return msg
+}
+
 func (m JoinMessage) MarshalBinary() ([]byte, error) {
 	return json.Marshal(m)
 }
+
+// verifyJoin returns true if the join is from the player it's for, signed
+// for this lobby with the session key their identity vouches for, and isn't
+// one the host's already taken.
+func (l *Lobby) verifyJoin(p *JoinMessage) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if p.PlayerID != p.SenderID || !p.Token.Verify(p.PlayerID) || !p.Proof.Verify(p.Token, l.ID) {
+		return false
+	}
+
+	if l.joinNonces[p.Proof.Nonce] {
+		return false
+	}
+
+	if l.joinNonces == nil {
+		l.joinNonces = make(map[string]bool)
+	}
+
+	l.joinNonces[p.Proof.Nonce] = true
+	return true
+}
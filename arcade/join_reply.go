@@ -9,6 +9,10 @@ const (
 	OK           = "OK"
 	ErrCapacity  = "ErrCapacity"
 	ErrWrongCode = "ErrWrongCode"
+
+	ErrWrongPassword = "ErrWrongPassword"
+	ErrRateLimited   = "ErrRateLimited"
+	ErrIdentity      = "ErrIdentity"
 )
 
 type JoinErr string
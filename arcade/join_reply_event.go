@@ -0,0 +1,19 @@
+package arcade
+
+// JoinReplyEvent is published when a host answers our asking to join their
+// lobby. Error is OK if we're in.
+type JoinReplyEvent struct {
+	Lobby *Lobby
+	Error JoinErr
+}
+
+func NewJoinReplyEvent(lobby *Lobby, err JoinErr) *JoinReplyEvent {
+	return &JoinReplyEvent{
+		Lobby: lobby,
+		Error: err,
+	}
+}
+
+func (e *JoinReplyEvent) Topic() EventTopic {
+	return LobbyEvents
+}
@@ -0,0 +1,116 @@
+package arcade
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// How long after a press its first repeat can come and still mean the
+	// key's held. Terminals wait a while before repeating
+	defaultRepeatDelay = 600
+
+	// How long without a repeat before a held key counts as let go. Longer
+	// than the time between repeats on slow terminals
+	defaultRepeatRelease = 150
+)
+
+// KeyHold works out when a movement key is held down from the terminal's key
+// repeats, since terminals only send presses. While it's held the move
+// happens every tick, so paddles move at the same speed whatever the
+// terminal's repeat rate.
+type KeyHold struct {
+	mu sync.Mutex
+
+	delay   time.Duration
+	release time.Duration
+
+	held      Action
+	pressedAt time.Time
+	lastSeen  time.Time
+
+	// Whether a repeat has come, so a tap isn't mistaken for a hold
+	repeating bool
+}
+
+// NewKeyHold returns a KeyHold with the thresholds in the config. A release
+// time of 0 turns it off, so every press is one move.
+func NewKeyHold(config *Config) *KeyHold {
+	return &KeyHold{
+		delay:   time.Duration(config.RepeatDelay) * time.Millisecond,
+		release: time.Duration(config.RepeatRelease) * time.Millisecond,
+	}
+}
+
+// Press notes the key for the action was pressed, and returns true if it
+// should move now. Repeats of a held key don't, since Tick moves it instead.
+func (h *KeyHold) Press(action Action, now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.release <= 0 {
+		return true
+	}
+
+	if action == h.held && h.stillHeld(now) {
+		h.repeating = true
+		h.lastSeen = now
+
+		return false
+	}
+
+	h.held = action
+	h.pressedAt, h.lastSeen = now, now
+	h.repeating = false
+
+	return true
+}
+
+// Tick returns the action of the key that's held, if there is one, to move
+// it once for the tick.
+func (h *KeyHold) Tick(now time.Time) Action {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.held == "" {
+		return ""
+	}
+
+	if !h.stillHeld(now) {
+		h.held = ""
+		return ""
+	}
+
+	if !h.repeating {
+		return ""
+	}
+
+	return h.held
+}
+
+// Release forgets the held key, like when the game's over.
+func (h *KeyHold) Release() {
+	h.mu.Lock()
+	h.held = ""
+	h.mu.Unlock()
+}
+
+// stillHeld returns true if the key's repeats say it hasn't been let go.
+// Expects the lock to be held.
+func (h *KeyHold) stillHeld(now time.Time) bool {
+	if h.repeating {
+		return now.Sub(h.lastSeen) <= h.release
+	}
+
+	return now.Sub(h.pressedAt) <= h.delay
+}
+
+// isMoveAction returns true for the actions a key can be held down for.
+func isMoveAction(action Action) bool {
+	switch action {
+	case ActionUp, ActionDown, ActionLeft, ActionRight:
+		return true
+	}
+
+	return false
+}
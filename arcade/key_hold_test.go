@@ -0,0 +1,60 @@
+package arcade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyHoldTellsTapsFromHolds(t *testing.T) {
+	h := NewKeyHold(&Config{RepeatDelay: 500, RepeatRelease: 100})
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	// A tap moves once, and not again while waiting to see if it repeats
+	if !h.Press(ActionUp, at(0)) {
+		t.Fatal("first press didn't move")
+	}
+
+	if action := h.Tick(at(50)); action != "" {
+		t.Errorf("tap moved again on a tick, %s", action)
+	}
+
+	// The terminal starts repeating, so it's held and moves every tick
+	if h.Press(ActionUp, at(400)) {
+		t.Error("repeat moved as well as the tick")
+	}
+
+	for ms := 420; ms <= 520; ms += 50 {
+		h.Press(ActionUp, at(ms-10))
+
+		if action := h.Tick(at(ms)); action != ActionUp {
+			t.Errorf("held key didn't move at %dms", ms)
+		}
+	}
+
+	// No more repeats, so it's been let go
+	if action := h.Tick(at(700)); action != "" {
+		t.Errorf("let go key still moved, %s", action)
+	}
+
+	// Another key takes over straight away
+	h.Press(ActionUp, at(800))
+
+	if !h.Press(ActionDown, at(810)) {
+		t.Error("pressing the other way didn't move")
+	}
+}
+
+func TestKeyHoldOff(t *testing.T) {
+	h := NewKeyHold(&Config{})
+
+	for i := 0; i < 3; i++ {
+		if !h.Press(ActionUp, time.Now()) {
+			t.Fatal("every press should move when holding's off")
+		}
+	}
+
+	if action := h.Tick(time.Now()); action != "" {
+		t.Errorf("tick moved with holding off, %s", action)
+	}
+}
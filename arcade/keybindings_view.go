@@ -0,0 +1,239 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"arcade/arcade/widgets"
+	"encoding"
+	"fmt"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+type KeybindingsView struct {
+	View
+	mgr *ViewManager
+
+	mu sync.RWMutex
+
+	list  *widgets.ScrollList
+	focus *widgets.FocusGroup
+
+	// Keymap and binding for each row of the list
+	rows []keybindingRow
+
+	// Row waiting for a new key, or -1
+	capturing int
+}
+
+type keybindingRow struct {
+	keymap *Keymap
+	action Action
+}
+
+var keybindingsFooter = "[Enter] Change    [Backspace] Reset    [B]ack"
+
+func NewKeybindingsView(mgr *ViewManager) *KeybindingsView {
+	v := &KeybindingsView{
+		mgr:       mgr,
+		list:      widgets.NewScrollList(8, 5, 64, 14),
+		capturing: -1,
+	}
+
+	v.list.OnSelect = func(index int) {
+		v.mu.Lock()
+		v.capturing = index
+		v.mu.Unlock()
+	}
+
+	v.focus = widgets.NewFocusGroup(v.list)
+	v.refresh()
+
+	return v
+}
+
+// refresh rebuilds the list rows from the keymaps.
+func (v *KeybindingsView) refresh() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.rows = v.rows[:0]
+	items := make([]string, 0)
+
+	for _, km := range keymaps {
+		for _, binding := range km.List() {
+			// Moving through lists is up to the list itself
+			if binding.Action == ActionMove || binding.Action == ActionPage || binding.Action == ActionSelect {
+				continue
+			}
+
+			v.rows = append(v.rows, keybindingRow{km, binding.Action})
+			items = append(items, fmt.Sprintf(" %-11s %-30s %s", km.Name, binding.Help, binding.Label()))
+		}
+	}
+
+	v.list.SetItems(items)
+}
+
+// Widgets gives the list the keys, except while waiting for a new key.
+func (v *KeybindingsView) Widgets() *widgets.FocusGroup {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.capturing >= 0 {
+		return nil
+	}
+
+	return v.focus
+}
+
+// bind gives the row's action the key, asking first if another action in the
+// same keymap already uses it.
+func (v *KeybindingsView) bind(row keybindingRow, key Key) {
+	conflict := row.keymap.Conflict(row.action, key)
+
+	if conflict == "" {
+		row.keymap.Bind(row.action, key)
+		v.save()
+		return
+	}
+
+	conflictHelp := string(conflict)
+
+	for _, binding := range row.keymap.List() {
+		if binding.Action == conflict {
+			conflictHelp = binding.Help
+		}
+	}
+
+	v.mgr.ShowModal(widgets.NewModal("Overwrite key", []string{
+		fmt.Sprintf("%s is already used for '%s'.", key, conflictHelp),
+		"Use it here instead?",
+	}, []string{"Cancel", "Overwrite"}, func(choice int) {
+		if choice == 1 {
+			row.keymap.Bind(row.action, key)
+			v.save()
+		}
+	}))
+}
+
+// reset puts the row's keys back to the default.
+func (v *KeybindingsView) reset(row keybindingRow) {
+	for _, binding := range defaultKeys[row.keymap.ID] {
+		if binding.Action == row.action {
+			row.keymap.Bind(row.action, binding.Keys...)
+		}
+	}
+
+	v.save()
+}
+
+func (v *KeybindingsView) save() {
+	config := *v.mgr.Config()
+	config.Keybindings = keybindingsConfig()
+
+	if err := config.Save(); err != nil {
+		notify("Couldn't save keybindings")
+	}
+
+	v.mgr.ApplyConfig(&config)
+	v.refresh()
+}
+
+// Back cancels waiting for a key before leaving the view.
+func (v *KeybindingsView) Back() bool {
+	v.mu.Lock()
+	capturing := v.capturing
+	v.capturing = -1
+	v.mu.Unlock()
+
+	if capturing < 0 {
+		return false
+	}
+
+	v.mgr.RequestRender()
+	return true
+}
+
+func (v *KeybindingsView) Init() {
+}
+
+func (v *KeybindingsView) ProcessEvent(evt interface{}) {
+	evtKey, ok := evt.(*tcell.EventKey)
+
+	if !ok {
+		return
+	}
+
+	v.mu.Lock()
+	capturing := v.capturing
+	v.capturing = -1
+
+	var row keybindingRow
+	selected := v.list.Selected()
+
+	if capturing >= 0 && capturing < len(v.rows) {
+		row = v.rows[capturing]
+	} else if selected >= 0 && selected < len(v.rows) {
+		row = v.rows[selected]
+	}
+	v.mu.Unlock()
+
+	if row.keymap == nil {
+		return
+	}
+
+	if capturing >= 0 {
+		if evtKey.Key() != tcell.KeyBackspace && evtKey.Key() != tcell.KeyBackspace2 {
+			v.bind(row, KeyFromEvent(evtKey))
+		}
+
+		return
+	}
+
+	switch evtKey.Key() {
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		v.reset(row)
+	case tcell.KeyRune:
+		if evtKey.Rune() == 'b' {
+			v.mgr.PopView()
+		}
+	}
+}
+
+func (v *KeybindingsView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *KeybindingsView) Render(s *Screen) {
+	width, height := s.displaySize()
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	headerSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorLightGreen)
+
+	s.DrawBlockText(CenterX, 1, sty, "KEYS", false)
+	s.DrawText(8, 4, headerSty, fmt.Sprintf(" %-11s %-30s %s", "SCREEN", "ACTION", "KEYS"))
+
+	v.list.Render(s)
+
+	v.mu.RLock()
+	capturing := v.capturing
+	v.mu.RUnlock()
+
+	s.DrawEmpty(1, 20, width-2, 20, sty)
+
+	if capturing >= 0 {
+		prompt := "Press the new key, or Backspace to cancel"
+		s.DrawText(layout.Center(width, prompt), 20, headerSty, prompt)
+	}
+
+	s.DrawText(layout.Center(width, keybindingsFooter), height-2, sty, keybindingsFooter)
+}
+
+func (v *KeybindingsView) Unload() {
+}
+
+func (v *KeybindingsView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
@@ -0,0 +1,525 @@
+package arcade
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Action is something the player can do with a key. Views react to actions
+// rather than to keys, so keys can be looked up for help and changed later.
+type Action string
+
+const (
+	// Anywhere
+	ActionHelp          Action = "help"
+	ActionQuit          Action = "quit"
+	ActionForceQuit     Action = "force_quit"
+	ActionNotifications Action = "notifications"
+	ActionDismiss       Action = "dismiss"
+	ActionDebug         Action = "debug"
+	ActionCloseInsets   Action = "close_insets"
+
+	// Menus
+	ActionMove   Action = "move"
+	ActionPage   Action = "page"
+	ActionSelect Action = "select"
+	ActionBack   Action = "back"
+
+	// Lobby list
+	ActionSettings     Action = "settings"
+	ActionCreateLobby  Action = "create_lobby"
+	ActionJoin         Action = "join"
+	ActionFriends      Action = "friends"
+	ActionRefresh      Action = "refresh"
+	ActionSearch       Action = "search"
+	ActionFilterGame   Action = "filter_game"
+	ActionFilterVis    Action = "filter_visibility"
+	ActionFilterOpen   Action = "filter_open"
+	ActionFilterPing   Action = "filter_ping"
+	ActionFilterRegion Action = "filter_region"
+	ActionQuickMatch   Action = "quick_match"
+	ActionSortPrev     Action = "sort_prev"
+	ActionSortNext     Action = "sort_next"
+	ActionSortOrder    Action = "sort_order"
+	ActionLocalPlay    Action = "local_play"
+	ActionPractice     Action = "practice"
+	ActionWatch        Action = "watch"
+	ActionReplays      Action = "replays"
+	ActionAchievements Action = "achievements"
+	ActionChallenge    Action = "challenge"
+	ActionSeason       Action = "season"
+	ActionMaps         Action = "maps"
+
+	// Lobby
+	ActionStart   Action = "start"
+	ActionReady   Action = "ready"
+	ActionInvite  Action = "invite"
+	ActionCoach   Action = "coach"
+	ActionPlayers Action = "players"
+	ActionLeave   Action = "leave"
+	ActionMute    Action = "mute"
+	ActionBlock   Action = "block"
+	ActionKick    Action = "kick"
+
+	// Friends
+	ActionAddFriend    Action = "add_friend"
+	ActionDeleteFriend Action = "delete_friend"
+
+	// Replays
+	ActionShare     Action = "share"
+	ActionSwitchTab Action = "switch_tab"
+
+	// Spectating
+	ActionCast       Action = "cast"
+	ActionCameraPrev Action = "camera_prev"
+	ActionCameraNext Action = "camera_next"
+	ActionInset      Action = "inset"
+	ActionSplit      Action = "split"
+
+	// Games
+	ActionUp    Action = "up"
+	ActionDown  Action = "down"
+	ActionLeft  Action = "left"
+	ActionRight Action = "right"
+	ActionChat  Action = "chat"
+)
+
+// Key is a special key, or a rune if Key is tcell.KeyRune.
+type Key struct {
+	Key  tcell.Key
+	Rune rune
+}
+
+func RuneKey(r rune) Key {
+	return Key{Key: tcell.KeyRune, Rune: r}
+}
+
+func SpecialKey(k tcell.Key) Key {
+	return Key{Key: k}
+}
+
+func (k Key) Matches(evt *tcell.EventKey) bool {
+	if k.Key != tcell.KeyRune {
+		return evt.Key() == k.Key
+	}
+
+	return evt.Key() == tcell.KeyRune && unicode.ToLower(evt.Rune()) == unicode.ToLower(k.Rune)
+}
+
+var keyLabels = map[tcell.Key]string{
+	tcell.KeyUp:     "↑",
+	tcell.KeyDown:   "↓",
+	tcell.KeyLeft:   "←",
+	tcell.KeyRight:  "→",
+	tcell.KeyEscape: "Esc",
+	tcell.KeyEnter:  "Enter",
+	tcell.KeyPgUp:   "PgUp",
+	tcell.KeyPgDn:   "PgDn",
+}
+
+func (k Key) String() string {
+	if k.Key == tcell.KeyRune {
+		return strings.ToUpper(string(k.Rune))
+	}
+
+	if label, ok := keyLabels[k.Key]; ok {
+		return label
+	}
+
+	return tcell.KeyNames[k.Key]
+}
+
+// ParseKey reads a key written the way Key.String writes it.
+func ParseKey(label string) (Key, error) {
+	if utf8.RuneCountInString(label) == 1 {
+		r, _ := utf8.DecodeRuneInString(label)
+		return RuneKey(unicode.ToLower(r)), nil
+	}
+
+	for k, l := range keyLabels {
+		if strings.EqualFold(l, label) {
+			return SpecialKey(k), nil
+		}
+	}
+
+	for k, l := range tcell.KeyNames {
+		if strings.EqualFold(l, label) {
+			return SpecialKey(k), nil
+		}
+	}
+
+	return Key{}, fmt.Errorf("unknown key %q", label)
+}
+
+// KeyFromEvent returns the key that was pressed.
+func KeyFromEvent(evt *tcell.EventKey) Key {
+	if evt.Key() == tcell.KeyRune {
+		return RuneKey(unicode.ToLower(evt.Rune()))
+	}
+
+	return SpecialKey(evt.Key())
+}
+
+// KeyBinding ties an action to the keys that trigger it.
+type KeyBinding struct {
+	Action Action
+	Keys   []Key
+	Help   string
+}
+
+// Label lists the binding's keys, like "↑/↓".
+func (b KeyBinding) Label() string {
+	labels := make([]string, len(b.Keys))
+
+	for i, key := range b.Keys {
+		labels[i] = key.String()
+	}
+
+	return strings.Join(labels, "/")
+}
+
+// Keymap is the set of bindings for one part of the arcade.
+type Keymap struct {
+	mu sync.RWMutex
+
+	// ID names the keymap in the config file
+	ID       string
+	Name     string
+	Bindings []KeyBinding
+}
+
+// List returns a copy of the bindings.
+func (km *Keymap) List() []KeyBinding {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	return append([]KeyBinding(nil), km.Bindings...)
+}
+
+// Action returns the action bound to the key, or "" if there isn't one.
+func (km *Keymap) Action(evt *tcell.EventKey) Action {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	for _, binding := range km.Bindings {
+		for _, key := range binding.Keys {
+			if key.Matches(evt) {
+				return binding.Action
+			}
+		}
+	}
+
+	return ""
+}
+
+// Key returns the first key bound to the action, if it has one.
+func (km *Keymap) Key(action Action) (Key, bool) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	for _, binding := range km.Bindings {
+		if binding.Action == action && len(binding.Keys) > 0 {
+			return binding.Keys[0], true
+		}
+	}
+
+	return Key{}, false
+}
+
+// Only returns a keymap with just the bindings for the given actions, for
+// views that only have some of their keys active at a time.
+func (km *Keymap) Only(actions ...Action) *Keymap {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	bindings := make([]KeyBinding, 0, len(actions))
+
+	for _, binding := range km.Bindings {
+		for _, action := range actions {
+			if binding.Action == action {
+				bindings = append(bindings, binding)
+				break
+			}
+		}
+	}
+
+	return &Keymap{ID: km.ID, Name: km.Name, Bindings: bindings}
+}
+
+// Conflict returns the action other than the given one that already uses the
+// key, or "" if it's free.
+func (km *Keymap) Conflict(action Action, key Key) Action {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	for _, binding := range km.Bindings {
+		if binding.Action == action {
+			continue
+		}
+
+		for _, k := range binding.Keys {
+			if k == key {
+				return binding.Action
+			}
+		}
+	}
+
+	return ""
+}
+
+// Bind sets the keys for an action, taking them away from any other action
+// that used them.
+func (km *Keymap) Bind(action Action, keys ...Key) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	for i, binding := range km.Bindings {
+		if binding.Action == action {
+			km.Bindings[i].Keys = keys
+			continue
+		}
+
+		remaining := make([]Key, 0, len(binding.Keys))
+
+		for _, k := range binding.Keys {
+			taken := false
+
+			for _, key := range keys {
+				if k == key {
+					taken = true
+				}
+			}
+
+			if !taken {
+				remaining = append(remaining, k)
+			}
+		}
+
+		km.Bindings[i].Keys = remaining
+	}
+}
+
+// keymaps lists every keymap that can be changed in the settings.
+var keymaps = append([]*Keymap{gamesListKeymap, lobbyKeymap, friendsKeymap, liveGamesKeymap, replaysKeymap, spectateKeymap, gameKeymap}, localKeymaps...)
+
+// defaultKeys holds the original bindings so they can be restored.
+var defaultKeys = func() map[string][]KeyBinding {
+	defaults := make(map[string][]KeyBinding)
+
+	for _, km := range append(keymaps, globalKeymap) {
+		defaults[km.ID] = km.List()
+	}
+
+	return defaults
+}()
+
+// applyKeybindings sets the keymaps from the config, which maps
+// "keymap.action" to keys like "J/Enter". Bindings not in the config go back
+// to their defaults.
+func applyKeybindings(bindings map[string]string) {
+	for _, km := range keymaps {
+		km.mu.Lock()
+		km.Bindings = make([]KeyBinding, len(defaultKeys[km.ID]))
+
+		for i, binding := range defaultKeys[km.ID] {
+			binding.Keys = append([]Key(nil), binding.Keys...)
+			km.Bindings[i] = binding
+		}
+		km.mu.Unlock()
+
+		for _, binding := range km.List() {
+			value, ok := bindings[km.ID+"."+string(binding.Action)]
+
+			if !ok {
+				continue
+			}
+
+			keys := make([]Key, 0)
+
+			for _, label := range strings.Split(value, "/") {
+				if key, err := ParseKey(label); err == nil {
+					keys = append(keys, key)
+				}
+			}
+
+			km.Bind(binding.Action, keys...)
+		}
+	}
+}
+
+// keybindingsConfig returns the bindings that differ from the defaults, in the
+// form applyKeybindings reads.
+func keybindingsConfig() map[string]string {
+	bindings := make(map[string]string)
+
+	for _, km := range keymaps {
+		for i, binding := range km.List() {
+			if binding.Label() != defaultKeys[km.ID][i].Label() {
+				bindings[km.ID+"."+string(binding.Action)] = binding.Label()
+			}
+		}
+	}
+
+	return bindings
+}
+
+// KeymapView is implemented by views that list their keys in the help overlay.
+// Keymap returns the bindings active right now, or nil while the player is
+// typing so '?' can still be typed.
+type KeymapView interface {
+	Keymap() *Keymap
+}
+
+var globalKeymap = &Keymap{
+	ID:   "global",
+	Name: "Anywhere",
+	Bindings: []KeyBinding{
+		{ActionHelp, []Key{RuneKey('?')}, "Show or hide this help"},
+		{ActionQuit, []Key{SpecialKey(tcell.KeyEscape)}, "Back, or quit"},
+		{ActionForceQuit, []Key{SpecialKey(tcell.KeyCtrlC)}, "Quit without asking"},
+		{ActionNotifications, []Key{SpecialKey(tcell.KeyCtrlN)}, "Notification history"},
+		{ActionDismiss, []Key{SpecialKey(tcell.KeyCtrlX)}, "Dismiss notifications"},
+		{ActionDebug, []Key{SpecialKey(tcell.KeyCtrlD)}, "Debug panel"},
+		{ActionCloseInsets, []Key{SpecialKey(tcell.KeyCtrlP)}, "Close games watched in the corner"},
+	},
+}
+
+var gamesListKeymap = &Keymap{
+	ID:   "lobby_list",
+	Name: "Lobby list",
+	Bindings: []KeyBinding{
+		{ActionMove, []Key{SpecialKey(tcell.KeyUp), SpecialKey(tcell.KeyDown)}, "Move through lobbies"},
+		{ActionPage, []Key{SpecialKey(tcell.KeyPgUp), SpecialKey(tcell.KeyPgDn)}, "Page through lobbies"},
+		{ActionJoin, []Key{RuneKey('j'), SpecialKey(tcell.KeyEnter)}, "Join selected lobby"},
+		{ActionCreateLobby, []Key{RuneKey('c')}, "Create new lobby"},
+		{ActionQuickMatch, []Key{RuneKey('q')}, "Join the nearest open lobby"},
+		{ActionLocalPlay, []Key{RuneKey('l')}, "Play on this keyboard"},
+		{ActionPractice, []Key{RuneKey('a')}, "Practice against bots"},
+		{ActionFriends, []Key{RuneKey('f')}, "Friends"},
+		{ActionWatch, []Key{RuneKey('w')}, "Watch live games"},
+		{ActionReplays, []Key{RuneKey('e')}, "Replays"},
+		{ActionAchievements, []Key{RuneKey('h')}, "Achievements"},
+		{ActionChallenge, []Key{RuneKey('y')}, "Daily challenge"},
+		{ActionSeason, []Key{RuneKey('k')}, "Ranked season"},
+		{ActionMaps, []Key{RuneKey('m')}, "Tron maps"},
+		{ActionSettings, []Key{RuneKey(',')}, "Settings"},
+		{ActionRefresh, []Key{RuneKey('r')}, "Refresh"},
+		{ActionSearch, []Key{RuneKey('/')}, "Search"},
+		{ActionFilterGame, []Key{RuneKey('g')}, "Filter by game"},
+		{ActionFilterVis, []Key{RuneKey('v')}, "Filter by visibility"},
+		{ActionFilterOpen, []Key{RuneKey('o')}, "Only open lobbies"},
+		{ActionFilterPing, []Key{RuneKey('p')}, "Filter by ping"},
+		{ActionFilterRegion, []Key{RuneKey('n')}, "Filter by region"},
+		{ActionSortPrev, []Key{SpecialKey(tcell.KeyLeft)}, "Sort by previous column"},
+		{ActionSortNext, []Key{SpecialKey(tcell.KeyRight)}, "Sort by next column"},
+		{ActionSortOrder, []Key{RuneKey('s')}, "Reverse sort order"},
+	},
+}
+
+var lobbyKeymap = &Keymap{
+	ID:   "lobby",
+	Name: "Lobby",
+	Bindings: []KeyBinding{
+		{ActionStart, []Key{RuneKey('s')}, "Start game"},
+		{ActionReady, []Key{RuneKey('r')}, "Toggle ready"},
+		{ActionInvite, []Key{RuneKey('i')}, "Invite players"},
+		{ActionCoach, []Key{RuneKey('h')}, "Invite a coach"},
+		{ActionPlayers, []Key{RuneKey('p')}, "Show players"},
+		{ActionLeave, []Key{RuneKey('c')}, "Leave lobby"},
+		{ActionMove, []Key{SpecialKey(tcell.KeyUp), SpecialKey(tcell.KeyDown)}, "Move through list"},
+		{ActionSelect, []Key{SpecialKey(tcell.KeyEnter)}, "Send invite"},
+		{ActionMute, []Key{RuneKey('m')}, "Mute player"},
+		{ActionBlock, []Key{RuneKey('b')}, "Block player"},
+		{ActionKick, []Key{RuneKey('k')}, "Kick player"},
+	},
+}
+
+var friendsKeymap = &Keymap{
+	ID:   "friends",
+	Name: "Friends",
+	Bindings: []KeyBinding{
+		{ActionMove, []Key{SpecialKey(tcell.KeyUp), SpecialKey(tcell.KeyDown)}, "Move through friends"},
+		{ActionAddFriend, []Key{RuneKey('a')}, "Add friend"},
+		{ActionDeleteFriend, []Key{RuneKey('d')}, "Delete friend"},
+		{ActionJoin, []Key{RuneKey('j')}, "Join friend's lobby"},
+		{ActionBack, []Key{RuneKey('b')}, "Back"},
+	},
+}
+
+var liveGamesKeymap = &Keymap{
+	ID:   "live_games",
+	Name: "Live games",
+	Bindings: []KeyBinding{
+		{ActionMove, []Key{SpecialKey(tcell.KeyUp), SpecialKey(tcell.KeyDown)}, "Move through games"},
+		{ActionWatch, []Key{RuneKey('w'), SpecialKey(tcell.KeyEnter)}, "Watch selected game"},
+		{ActionRefresh, []Key{RuneKey('r')}, "Refresh"},
+		{ActionBack, []Key{RuneKey('b')}, "Back"},
+	},
+}
+
+var replaysKeymap = &Keymap{
+	ID:   "replays",
+	Name: "Replays",
+	Bindings: []KeyBinding{
+		{ActionMove, []Key{SpecialKey(tcell.KeyUp), SpecialKey(tcell.KeyDown)}, "Move through replays"},
+		{ActionWatch, []Key{SpecialKey(tcell.KeyEnter)}, "Play selected replay"},
+		{ActionShare, []Key{RuneKey('u')}, "Share selected replay"},
+		{ActionSwitchTab, []Key{SpecialKey(tcell.KeyTab)}, "Saved or notable replays"},
+		{ActionRefresh, []Key{RuneKey('r')}, "Refresh"},
+		{ActionBack, []Key{RuneKey('b')}, "Back"},
+	},
+}
+
+var spectateKeymap = &Keymap{
+	ID:   "spectate",
+	Name: "Watching a game",
+	Bindings: []KeyBinding{
+		{ActionCast, []Key{RuneKey('c')}, "Scoreboard, name plates and rounds"},
+		{ActionCameraPrev, []Key{SpecialKey(tcell.KeyLeft)}, "Previous player's camera"},
+		{ActionCameraNext, []Key{SpecialKey(tcell.KeyRight)}, "Next player's camera"},
+		{ActionInset, []Key{RuneKey('p')}, "Keep watching in the corner"},
+		{ActionSplit, []Key{RuneKey('v')}, "Split screen between two players"},
+	},
+}
+
+var gameKeymap = &Keymap{
+	ID:   "game",
+	Name: "Game",
+	Bindings: []KeyBinding{
+		{ActionUp, []Key{SpecialKey(tcell.KeyUp)}, "Up"},
+		{ActionDown, []Key{SpecialKey(tcell.KeyDown)}, "Down"},
+		{ActionLeft, []Key{SpecialKey(tcell.KeyLeft)}, "Left"},
+		{ActionRight, []Key{SpecialKey(tcell.KeyRight)}, "Right"},
+		{ActionChat, []Key{SpecialKey(tcell.KeyEnter)}, "Chat, 1-9 for quick emotes"},
+	},
+}
+
+// localKeymaps are the keys of each player sharing the keyboard in local
+// play, by player.
+var localKeymaps = []*Keymap{
+	{
+		ID:   "local_p1",
+		Name: "Local player 1",
+		Bindings: []KeyBinding{
+			{ActionUp, []Key{RuneKey('w')}, "Up"},
+			{ActionDown, []Key{RuneKey('s')}, "Down"},
+			{ActionLeft, []Key{RuneKey('a')}, "Left"},
+			{ActionRight, []Key{RuneKey('d')}, "Right"},
+		},
+	},
+	{
+		ID:   "local_p2",
+		Name: "Local player 2",
+		Bindings: []KeyBinding{
+			{ActionUp, []Key{SpecialKey(tcell.KeyUp)}, "Up"},
+			{ActionDown, []Key{SpecialKey(tcell.KeyDown)}, "Down"},
+			{ActionLeft, []Key{SpecialKey(tcell.KeyLeft)}, "Left"},
+			{ActionRight, []Key{SpecialKey(tcell.KeyRight)}, "Right"},
+		},
+	},
+}
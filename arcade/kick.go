@@ -0,0 +1,22 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+type KickMessage struct {
+	message.Message
+	LobbyID string
+}
+
+func NewKickMessage(lobbyID string) *KickMessage {
+	return &KickMessage{
+		Message: message.Message{Type: "kick"},
+		LobbyID: lobbyID,
+	}
+}
+
+func (m KickMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,17 @@
+package arcade
+
+// LANPeerLostEvent is published when a peer found on the LAN stops
+// announcing itself, so whatever it was advertising can be checked on.
+type LANPeerLostEvent struct {
+	ClientID string
+}
+
+func NewLANPeerLostEvent(clientID string) *LANPeerLostEvent {
+	return &LANPeerLostEvent{
+		ClientID: clientID,
+	}
+}
+
+func (e *LANPeerLostEvent) Topic() EventTopic {
+	return NetworkEvents
+}
@@ -0,0 +1,50 @@
+// Package layout measures and fits text by the number of terminal cells it
+// takes up, rather than bytes or runes. CJK and most emoji are two cells
+// wide, and combining marks take none.
+package layout
+
+import "github.com/mattn/go-runewidth"
+
+// Ellipsis marks text that was cut short.
+const Ellipsis = "…"
+
+// Width returns how many cells the text takes up.
+func Width(text string) int {
+	return runewidth.StringWidth(text)
+}
+
+// RuneWidth returns how many cells the rune takes up.
+func RuneWidth(r rune) int {
+	return runewidth.RuneWidth(r)
+}
+
+// Center returns the column to start the text at to center it in width
+// cells.
+func Center(width int, text string) int {
+	return (width - Width(text)) / 2
+}
+
+// Truncate cuts text down to at most width cells, ending it with an ellipsis
+// if anything was cut.
+func Truncate(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	return runewidth.Truncate(text, width, Ellipsis)
+}
+
+// Clip cuts text down to at most width cells, without an ellipsis.
+func Clip(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	return runewidth.Truncate(text, width, "")
+}
+
+// Pad truncates text to width cells and fills it out with spaces to exactly
+// width, for table columns.
+func Pad(text string, width int) string {
+	return runewidth.FillRight(Truncate(text, width), width)
+}
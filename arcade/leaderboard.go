@@ -0,0 +1,414 @@
+package arcade
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// Once reports for a match have been coming in for this long, it's
+	// settled with what's been reported
+	resultReportTimeout = 2 * time.Minute
+
+	// How long players' entries to a match are kept, waiting for its result
+	matchEntryTimeout = 2 * time.Hour
+)
+
+type pendingResult struct {
+	started time.Time
+
+	// Digest each player reported, the address they reported from, and the
+	// result for each digest
+	reports map[string]string
+	addrs   map[string]string
+	results map[string]MatchResult
+}
+
+// matchEntries are the players who signed that they were starting a match,
+// with the digest of the entry each signed.
+type matchEntries struct {
+	started time.Time
+	digests map[string]string
+}
+
+// LeaderboardEntry is one player's record.
+type LeaderboardEntry struct {
+	PlayerID string
+	Played   int
+	Wins     int
+}
+
+// Leaderboard is kept by the distributor. Players sign that they're entering
+// a match as it starts, and sign its result as it ends. A match is recorded:
+//
+//   - as soon as most of its players, counted by identity, report the same
+//     result. No one address counts for a majority on its own, so one machine
+//     can't make up players to outvote the rest with, unless every player in
+//     the match reported from it, like two people behind one router.
+//   - or, once reports stop coming in, if the players who reported all agree
+//     and every one who didn't had entered the match. Entering is agreeing to
+//     the result of those who do report, so losing and saying nothing doesn't
+//     keep a match off the board.
+type Leaderboard struct {
+	mu sync.Mutex
+
+	pending  map[string]*pendingResult
+	entered  map[string]*matchEntries
+	recorded map[string]bool
+	entries  map[string]*LeaderboardEntry
+
+	season *seasonLadder
+}
+
+func NewLeaderboard() *Leaderboard {
+	return &Leaderboard{
+		pending:  make(map[string]*pendingResult),
+		entered:  make(map[string]*matchEntries),
+		recorded: make(map[string]bool),
+		entries:  make(map[string]*LeaderboardEntry),
+		season:   newSeasonLadder(time.Now()),
+	}
+}
+
+// Enter takes a player's signed entry to a match as it starts, with the token
+// vouching for their session key.
+func (l *Leaderboard) Enter(playerID string, token SessionToken, entry MatchResult, signature []byte) error {
+	if len(entry.Players) < 2 {
+		return errors.New("not enough players")
+	}
+
+	if !containsString(entry.Players, playerID) {
+		return errors.New("not a player in the match")
+	}
+
+	if !entry.VerifyEntry(playerID, token, signature) {
+		return errors.New("bad signature")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(time.Now())
+
+	if l.recorded[entry.GameID] {
+		return nil
+	}
+
+	e, ok := l.entered[entry.GameID]
+
+	if !ok {
+		e = &matchEntries{started: time.Now(), digests: make(map[string]string)}
+		l.entered[entry.GameID] = e
+	}
+
+	if _, ok := e.digests[playerID]; !ok {
+		e.digests[playerID] = entry.entryDigest()
+	}
+
+	return nil
+}
+
+// Report takes a player's signed result, reported from the address, with the
+// token vouching for their session key. Returns true if it completed a
+// quorum and the match was recorded.
+func (l *Leaderboard) Report(playerID, addr string, token SessionToken, result MatchResult, signature []byte) (bool, error) {
+	if len(result.Players) < 2 {
+		return false, errors.New("not enough players")
+	}
+
+	if !containsString(result.Players, playerID) {
+		return false, errors.New("not a player in the match")
+	}
+
+	if !result.Verify(playerID, token, signature) {
+		return false, errors.New("bad signature")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(time.Now())
+
+	if l.recorded[result.GameID] {
+		return false, nil
+	}
+
+	p, ok := l.pending[result.GameID]
+
+	if !ok {
+		p = &pendingResult{
+			started: time.Now(),
+			reports: make(map[string]string),
+			addrs:   make(map[string]string),
+			results: make(map[string]MatchResult),
+		}
+
+		l.pending[result.GameID] = p
+	}
+
+	// A player's first report is the one that counts
+	if _, ok := p.reports[playerID]; ok {
+		return false, nil
+	}
+
+	digest := result.digest()
+	p.reports[playerID] = digest
+	p.addrs[playerID] = addrHost(addr)
+	p.results[digest] = result
+
+	if !p.quorum(result) {
+		return false, nil
+	}
+
+	l.settle(result)
+	return true, nil
+}
+
+// quorum returns whether most of the match's players reported the result,
+// counting no more of them from one address than can't make a majority on
+// their own, unless the whole match reported from there.
+func (p *pendingResult) quorum(result MatchResult) bool {
+	digest := result.digest()
+	majority := len(result.Players)/2 + 1
+
+	agree := make(map[string]int)
+	everyAddr := make(map[string]bool)
+
+	for id, d := range p.reports {
+		everyAddr[p.addrs[id]] = true
+
+		if d == digest {
+			agree[p.addrs[id]]++
+		}
+	}
+
+	perAddr := majority - 1
+
+	if len(p.reports) == len(result.Players) && len(everyAddr) == 1 {
+		perAddr = len(result.Players)
+	}
+
+	counted := 0
+
+	for _, n := range agree {
+		if n > perAddr {
+			n = perAddr
+		}
+
+		counted += n
+	}
+
+	return counted >= majority
+}
+
+// unopposed returns the result if everyone who reported agreed on it, and
+// every player who didn't had entered the match.
+func (p *pendingResult) unopposed(entries *matchEntries) (MatchResult, bool) {
+	if len(p.results) != 1 || entries == nil {
+		return MatchResult{}, false
+	}
+
+	var result MatchResult
+
+	for _, r := range p.results {
+		result = r
+	}
+
+	entry := result.entryDigest()
+
+	for _, playerID := range result.Players {
+		if _, reported := p.reports[playerID]; !reported && entries.digests[playerID] != entry {
+			return MatchResult{}, false
+		}
+	}
+
+	return result, true
+}
+
+// Sweep settles the matches whose reports have stopped coming in, and forgets
+// entries to matches that never reported.
+func (l *Leaderboard) Sweep() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(time.Now())
+}
+
+// sweep settles what's timed out by now. Expects the lock to be held.
+func (l *Leaderboard) sweep(now time.Time) {
+	for gameID, p := range l.pending {
+		if now.Sub(p.started) <= resultReportTimeout {
+			continue
+		}
+
+		if result, ok := p.unopposed(l.entered[gameID]); ok {
+			l.settle(result)
+		} else {
+			delete(l.pending, gameID)
+			delete(l.entered, gameID)
+		}
+	}
+
+	for gameID, e := range l.entered {
+		if now.Sub(e.started) > matchEntryTimeout {
+			delete(l.entered, gameID)
+		}
+	}
+}
+
+// settle records the result and forgets what was kept to decide on it.
+// Expects the lock to be held.
+func (l *Leaderboard) settle(result MatchResult) {
+	l.record(result)
+	delete(l.pending, result.GameID)
+	delete(l.entered, result.GameID)
+}
+
+// RecordHosted records a match the distributor hosted. It saw the game end
+// itself, so there's no waiting for the players to agree on how.
+func (l *Leaderboard) RecordHosted(result MatchResult) {
+	if len(result.Players) < 2 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.recorded[result.GameID] {
+		return
+	}
+
+	l.settle(result)
+}
+
+// record adds a match to players' records. Expects the lock to be held.
+func (l *Leaderboard) record(result MatchResult) {
+	l.recorded[result.GameID] = true
+
+	for _, playerID := range result.Players {
+		entry, ok := l.entries[playerID]
+
+		if !ok {
+			entry = &LeaderboardEntry{PlayerID: playerID}
+			l.entries[playerID] = entry
+		}
+
+		entry.Played++
+
+		if playerID == result.Winner {
+			entry.Wins++
+		}
+	}
+
+	l.season.record(result, time.Now())
+
+	fmt.Printf("Recorded %s %s, won by %s\n", result.GameType, result.GameID, result.Winner)
+}
+
+// SeasonInfo describes the ranked season to the player.
+func (l *Leaderboard) SeasonInfo(playerID string) *SeasonInfoMessage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.season.info(playerID, time.Now())
+}
+
+// Standings returns every player's record, most wins first.
+func (l *Leaderboard) Standings() []LeaderboardEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	standings := make([]LeaderboardEntry, 0, len(l.entries))
+
+	for _, entry := range l.entries {
+		standings = append(standings, *entry)
+	}
+
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Wins != standings[j].Wins {
+			return standings[i].Wins > standings[j].Wins
+		}
+
+		return standings[i].PlayerID < standings[j].PlayerID
+	})
+
+	return standings
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// snapshotSeason returns a copy of the season's ladder, to save.
+func (l *Leaderboard) snapshotSeason() *seasonLadder {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	season := *l.season
+	season.Standings = make(map[string]*SeasonStanding, len(l.season.Standings))
+
+	for id, s := range l.season.Standings {
+		s := *s
+		season.Standings[id] = &s
+	}
+
+	return &season
+}
+
+// restoreSeason brings back a saved ladder. One from a season that's since
+// ended becomes last season's.
+func (l *Leaderboard) restoreSeason(season *seasonLadder) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if season.Standings == nil {
+		season.Standings = make(map[string]*SeasonStanding)
+	}
+
+	l.season = season
+	l.season.roll(time.Now())
+}
+
+// snapshot returns the matches recorded and every player's record, to save.
+func (l *Leaderboard) snapshot() ([]string, []LeaderboardEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	recorded := make([]string, 0, len(l.recorded))
+
+	for gameID := range l.recorded {
+		recorded = append(recorded, gameID)
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(l.entries))
+
+	for _, entry := range l.entries {
+		entries = append(entries, *entry)
+	}
+
+	return recorded, entries
+}
+
+// restore brings back saved records.
+func (l *Leaderboard) restore(entries []LeaderboardEntry, recorded []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, gameID := range recorded {
+		l.recorded[gameID] = true
+	}
+
+	for _, entry := range entries {
+		entry := entry
+		l.entries[entry.PlayerID] = &entry
+	}
+}
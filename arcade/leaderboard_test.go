@@ -0,0 +1,242 @@
+package arcade
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+)
+
+type testReporter struct {
+	id      *Identity
+	session ed25519.PrivateKey
+	token   SessionToken
+}
+
+func newTestReporter() *testReporter {
+	id := NewIdentity()
+	public, session, _ := ed25519.GenerateKey(nil)
+
+	return &testReporter{id: id, session: session, token: id.NewSessionToken(public)}
+}
+
+func (r *testReporter) report(l *Leaderboard, addr string, result MatchResult) (bool, error) {
+	signature := ed25519.Sign(r.session, result.signedBytes())
+	return l.Report(r.id.PlayerID(), addr, r.token, result, signature)
+}
+
+func (r *testReporter) enter(l *Leaderboard, result MatchResult) error {
+	signature := ed25519.Sign(r.session, result.entryBytes())
+	return l.Enter(r.id.PlayerID(), r.token, result, signature)
+}
+
+func newTestResult(reporters ...*testReporter) MatchResult {
+	result := MatchResult{GameID: "game", GameType: "Tron", Scores: make(map[string]int)}
+
+	for _, r := range reporters {
+		result.Players = append(result.Players, r.id.PlayerID())
+	}
+
+	result.Winner = result.Players[0]
+	result.Scores[result.Winner] = 1
+
+	return result
+}
+
+func TestLeaderboardQuorum(t *testing.T) {
+	a, b, c := newTestReporter(), newTestReporter(), newTestReporter()
+	result := newTestResult(a, b, c)
+	l := NewLeaderboard()
+
+	if done, err := a.report(l, "10.0.0.1:4000", result); done || err != nil {
+		t.Fatalf("first report = %v, %v, want no quorum", done, err)
+	}
+
+	if done, err := b.report(l, "10.0.0.2:4000", result); !done || err != nil {
+		t.Fatalf("second report = %v, %v, want quorum", done, err)
+	}
+
+	if entry := l.entries[a.id.PlayerID()]; entry == nil || entry.Wins != 1 {
+		t.Errorf("winner's entry = %+v, want a win", entry)
+	}
+
+	if done, _ := c.report(l, "10.0.0.3:4000", result); done {
+		t.Error("late report recorded the match again")
+	}
+}
+
+func TestLeaderboardHosted(t *testing.T) {
+	a, b := newTestReporter(), newTestReporter()
+	result := newTestResult(a, b)
+	l := NewLeaderboard()
+
+	l.RecordHosted(result)
+
+	if entry := l.entries[a.id.PlayerID()]; entry == nil || entry.Wins != 1 {
+		t.Errorf("winner's entry = %+v, want a win", entry)
+	}
+
+	if done, err := a.report(l, "10.0.0.1:4000", result); done || err != nil {
+		t.Errorf("player's report = %v, %v, want it ignored", done, err)
+	}
+
+	l.RecordHosted(result)
+
+	if entry := l.entries[b.id.PlayerID()]; entry.Played != 1 {
+		t.Errorf("loser played %d, want the match recorded once", entry.Played)
+	}
+}
+
+func TestLeaderboardDisagreement(t *testing.T) {
+	a, b, c := newTestReporter(), newTestReporter(), newTestReporter()
+	result := newTestResult(a, b, c)
+	other := newTestResult(a, b, c)
+	other.Winner = b.id.PlayerID()
+	l := NewLeaderboard()
+
+	a.report(l, "10.0.0.1:4000", result)
+
+	if done, _ := b.report(l, "10.0.0.2:4000", other); done {
+		t.Error("players who disagree reached a quorum")
+	}
+}
+
+func TestLeaderboardOneAddress(t *testing.T) {
+	a, b, c := newTestReporter(), newTestReporter(), newTestReporter()
+	result := newTestResult(a, b, c)
+	l := NewLeaderboard()
+
+	// Players made up on one machine count as one
+	a.report(l, "10.0.0.1:4000", result)
+
+	if done, _ := b.report(l, "10.0.0.1:4001", result); done {
+		t.Error("reports from one address reached a quorum")
+	}
+}
+
+func TestLeaderboardForgedReports(t *testing.T) {
+	a, b := newTestReporter(), newTestReporter()
+	result := newTestResult(a, b)
+	signature := ed25519.Sign(b.session, result.signedBytes())
+	l := NewLeaderboard()
+
+	// b signs for a with b's own token
+	if _, err := l.Report(a.id.PlayerID(), "10.0.0.2:4000", b.token, result, signature); err == nil {
+		t.Error("accepted a report with another player's token")
+	}
+
+	// b vouches for its session key as if it were a's
+	forged := b.id.NewSessionToken(b.token.SessionKey)
+	forged.IdentityKey = a.id.PublicKey()
+
+	if _, err := l.Report(a.id.PlayerID(), "10.0.0.2:4000", forged, result, signature); err == nil {
+		t.Error("accepted a report with a forged token")
+	}
+
+	// a's token with a signature that isn't a's
+	if _, err := l.Report(a.id.PlayerID(), "10.0.0.1:4000", a.token, result, signature); err == nil {
+		t.Error("accepted a report with a bad signature")
+	}
+
+	outsider := newTestReporter()
+
+	if _, err := outsider.report(l, "10.0.0.3:4000", result); err == nil {
+		t.Error("accepted a report from someone not in the match")
+	}
+
+	if len(l.pending) != 0 {
+		t.Errorf("rejected reports left %d pending results", len(l.pending))
+	}
+}
+
+func TestLeaderboardSharedAddress(t *testing.T) {
+	a, b := newTestReporter(), newTestReporter()
+	result := newTestResult(a, b)
+	l := NewLeaderboard()
+
+	// Two people behind one router, playing each other
+	if done, _ := a.report(l, "10.0.0.1:4000", result); done {
+		t.Fatal("one report from the address recorded the match")
+	}
+
+	if done, err := b.report(l, "10.0.0.1:4001", result); !done || err != nil {
+		t.Errorf("both players' reports = %v, %v, want quorum", done, err)
+	}
+}
+
+func TestLeaderboardLoserWithholds(t *testing.T) {
+	a, b := newTestReporter(), newTestReporter()
+	result := newTestResult(a, b)
+	l := NewLeaderboard()
+
+	for _, r := range []*testReporter{a, b} {
+		if err := r.enter(l, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// b lost, and never says so
+	if done, _ := a.report(l, "10.0.0.1:4000", result); done {
+		t.Fatal("the winner's report alone recorded the match")
+	}
+
+	l.sweep(time.Now())
+
+	if l.recorded[result.GameID] {
+		t.Fatal("recorded before reports stopped coming in")
+	}
+
+	l.sweep(time.Now().Add(resultReportTimeout + time.Second))
+
+	if entry := l.entries[b.id.PlayerID()]; entry == nil || entry.Played != 1 || entry.Wins != 0 {
+		t.Errorf("loser's entry = %+v, want a loss", entry)
+	}
+}
+
+func TestLeaderboardUnenteredMatch(t *testing.T) {
+	a, b := newTestReporter(), newTestReporter()
+	result := newTestResult(a, b)
+	l := NewLeaderboard()
+
+	// a claims a win over b, who never entered
+	a.enter(l, result)
+	a.report(l, "10.0.0.1:4000", result)
+	l.sweep(time.Now().Add(resultReportTimeout + time.Second))
+
+	if l.recorded[result.GameID] || len(l.pending) != 0 || len(l.entered) != 0 {
+		t.Error("recorded a match the other player never entered")
+	}
+
+	// Entries to other matches don't count either
+	c := newTestReporter()
+	other := newTestResult(a, b, c)
+	other.GameID = "other"
+	result.GameID = "other"
+
+	b.enter(l, other)
+	a.report(l, "10.0.0.1:4000", result)
+	l.sweep(time.Now().Add(resultReportTimeout + time.Second))
+
+	if l.recorded["other"] {
+		t.Error("recorded a match with an entry for different players")
+	}
+}
+
+func TestLeaderboardContestedSilence(t *testing.T) {
+	a, b, c := newTestReporter(), newTestReporter(), newTestReporter()
+	result := newTestResult(a, b, c)
+	other := newTestResult(a, b, c)
+	other.Winner = b.id.PlayerID()
+	l := NewLeaderboard()
+
+	for _, r := range []*testReporter{a, b, c} {
+		r.enter(l, result)
+	}
+
+	a.report(l, "10.0.0.1:4000", result)
+	b.report(l, "10.0.0.2:4000", other)
+	l.sweep(time.Now().Add(resultReportTimeout + time.Second))
+
+	if l.recorded[result.GameID] {
+		t.Error("recorded a result players disagreed on")
+	}
+}
@@ -0,0 +1,217 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"encoding"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const liveGamesRefreshInterval = 3 * time.Second
+
+// LiveGamesView lists the games being streamed through the distributor, to
+// pick one to watch.
+type LiveGamesView struct {
+	View
+	mgr *ViewManager
+
+	mu sync.RWMutex
+
+	games        []SpectateInfo
+	loaded       bool
+	selectedRow  int
+	stopTickerCh chan bool
+}
+
+var liveGamesFooter = "[W]atch   [R]efresh   [B]ack"
+
+func NewLiveGamesView(mgr *ViewManager) *LiveGamesView {
+	return &LiveGamesView{
+		mgr:          mgr,
+		stopTickerCh: make(chan bool),
+	}
+}
+
+func (v *LiveGamesView) Init() {
+	ticker := time.NewTicker(liveGamesRefreshInterval)
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				v.refresh()
+			case <-v.stopTickerCh:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	go v.refresh()
+}
+
+// refresh asks the distributor which games can be watched.
+func (v *LiveGamesView) refresh() {
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		return
+	}
+
+	res, err := arcade.Server.Network.SendAndReceive(distributor, NewSpectateQueryMessage())
+	reply, ok := res.(*SpectateListMessage)
+
+	if !ok || err != nil {
+		return
+	}
+
+	if profanityFilterEnabled() {
+		for i := range reply.Games {
+			reply.Games[i].LobbyName = FilterProfanity(reply.Games[i].LobbyName)
+		}
+	}
+
+	v.mu.Lock()
+	v.games = reply.Games
+	v.loaded = true
+
+	if v.selectedRow >= len(v.games) {
+		v.selectedRow = len(v.games) - 1
+	}
+
+	if v.selectedRow < 0 {
+		v.selectedRow = 0
+	}
+	v.mu.Unlock()
+
+	v.mgr.RequestRender()
+}
+
+func (v *LiveGamesView) Keymap() *Keymap {
+	return liveGamesKeymap
+}
+
+func (v *LiveGamesView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		v.mu.Lock()
+
+		switch liveGamesKeymap.Action(evt) {
+		case ActionMove:
+			if evt.Key() == tcell.KeyDown && v.selectedRow < len(v.games)-1 {
+				v.selectedRow++
+			} else if evt.Key() == tcell.KeyUp && v.selectedRow > 0 {
+				v.selectedRow--
+			}
+		case ActionWatch:
+			if len(v.games) == 0 {
+				break
+			}
+
+			game := v.games[v.selectedRow]
+			v.mu.Unlock()
+
+			v.mgr.PushView(NewSpectateView(v.mgr, game))
+			return
+		case ActionRefresh:
+			go v.refresh()
+		case ActionBack:
+			v.mu.Unlock()
+			v.mgr.PopView()
+			return
+		}
+
+		v.mu.Unlock()
+	}
+}
+
+func (v *LiveGamesView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *LiveGamesView) Render(s *Screen) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	width, height := s.displaySize()
+
+	const (
+		tableWidth  = 72
+		tableHeight = 14
+	)
+
+	var (
+		tableX1 = (width-tableWidth)/2 - 1
+		tableY1 = 7
+		tableX2 = width - (width-tableWidth)/2
+		tableY2 = tableY1 + tableHeight
+
+		nameColX       = tableX1 + 1
+		gameColX       = tableX1 + 27
+		playersColX    = tableX1 + 36
+		spectatorsColX = tableX1 + 60
+	)
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	selectedSty := tcell.StyleDefault.Background(tcell.ColorDarkGreen).Foreground(tcell.ColorWhite)
+
+	s.DrawBlockText(CenterX, 1, sty, "LIVE GAMES", false)
+
+	s.DrawBox(tableX1-1, 4, tableX2+1, tableY2+1, sty, true)
+	s.DrawText(layout.Center(width, liveGamesFooter), height-2, sty, liveGamesFooter)
+
+	s.DrawText(nameColX, 5, sty, "LOBBY")
+	s.DrawText(gameColX, 5, sty, "GAME")
+	s.DrawText(playersColX, 5, sty, "PLAYERS")
+	s.DrawText(spectatorsColX, 5, sty, "WATCHING")
+
+	s.DrawLine(tableX1, 6, tableX2, 6, sty, true)
+	s.DrawText(tableX1-1, 6, sty, "╠")
+	s.DrawText(tableX2+1, 6, sty, "╣")
+
+	for y := tableY1; y <= tableY2; y++ {
+		s.DrawEmpty(tableX1, y, tableX2, y, sty)
+	}
+
+	if len(v.games) == 0 {
+		msg := "Looking for games..."
+
+		if v.loaded {
+			msg = "Nobody's streaming a game right now."
+		}
+
+		s.DrawText(layout.Center(width, msg), tableY1+1, sty, msg)
+	}
+
+	for i, game := range v.games {
+		y := tableY1 + i
+
+		if y > tableY2 {
+			break
+		}
+
+		rowSty := sty
+
+		if i == v.selectedRow {
+			rowSty = selectedSty
+			s.DrawEmpty(tableX1, y, tableX2, y, rowSty)
+		}
+
+		s.DrawText(nameColX, y, rowSty, layout.Truncate(game.LobbyName, gameColX-nameColX-1))
+		s.DrawText(gameColX, y, rowSty, game.GameType)
+		s.DrawText(playersColX, y, rowSty, fmt.Sprint(len(game.PlayerIDs)))
+		s.DrawText(spectatorsColX, y, rowSty, fmt.Sprint(game.Spectators))
+	}
+}
+
+func (v *LiveGamesView) Unload() {
+	v.stopTickerCh <- true
+}
+
+func (v *LiveGamesView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
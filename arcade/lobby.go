@@ -3,6 +3,7 @@ package arcade
 import (
 	"encoding/json"
 	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
@@ -18,12 +19,85 @@ type Lobby struct {
 	Name             string
 	Code             string
 	Private          bool
+	HasPassword      bool
 	GameType         string
 	Capacity         int
+	Obstacles        bool
 	PlayerIDs        []string
 	HostID           string
 	Ping             int
 	PlayerClientEnds labrpc.ClientEnd
+
+	// Player ready and idle states, reported in heartbeats. Idle is by the
+	// lobby's timeout, while away is by each player's own setting
+	Ready map[string]bool
+	Idle  map[string]bool
+	Away  map[string]bool
+
+	// Player names and avatars, and round trip times to the host in
+	// milliseconds, kept by the host for the lobby's roster
+	Names   map[string]string
+	Avatars map[string]string
+	RTTs    map[string]int
+
+	// Players' season badges, for those who've played this season
+	Ranks map[string]string
+
+	// Region the host's in, and each player's, for those who know theirs
+	Region  string            `json:",omitempty"`
+	Regions map[string]string `json:",omitempty"`
+
+	// Seconds without input before a player is marked idle, or 0 for never,
+	// and whether idle players are unreadied
+	IdleTimeout int
+	AutoUnready bool
+
+	// Whether anyone can watch the lobby's games through the distributor.
+	// Only public lobbies stream them
+	Spectatable bool
+
+	// Coaches, and the player each one is coaching. They watch that player's
+	// games and can talk to them in the lobby, but don't play
+	Coaches map[string]string
+
+	// Players the host has invited, who may join without the lobby's code
+	invited map[string]bool
+
+	// Nonces of the joins the host's taken, so none is taken twice
+	joinNonces map[string]bool
+
+	// Peers players have asked to coach them, and who for
+	coachInvites map[string]string
+
+	// Custom Tron arena, named for the roster and hashed so players can tell
+	// whether they have it. Empty for the classic arena
+	MapName string
+	MapHash string
+
+	// Tron mod the game's set up by, hashed the same way. Empty for none
+	ModName string `json:",omitempty"`
+	ModHash string `json:",omitempty"`
+
+	// What the mod set the match up with, worked out as it starts
+	modRules *TronRules
+
+	// Seconds before games go to sudden death, or 0 for no time limit
+	TimeLimit int `json:",omitempty"`
+
+	// Whether a Tron arena closes in as the game goes on, not just when time's
+	// up
+	ShrinkArena bool `json:",omitempty"`
+
+	// Where the lobby is in its life, moved along by the host
+	State LobbyState `json:",omitempty"`
+
+	// Set for lobbies a server hosts without playing in them, and the seats
+	// its bots fill once the game starts
+	Dedicated bool     `json:",omitempty"`
+	Bots      []string `json:",omitempty"`
+
+	// Only known to the host
+	passwordHash []byte
 }
 
 func NewLobby(name string, private bool, gameType string, capacity int, hostID string) *Lobby {
@@ -35,6 +109,17 @@ func NewLobby(name string, private bool, gameType string, capacity int, hostID s
 		Capacity:  capacity,
 		PlayerIDs: []string{hostID},
 		HostID:    hostID,
+		State:     LobbyOpen,
+
+		Ready:   make(map[string]bool),
+		Idle:    make(map[string]bool),
+		Names:   make(map[string]string),
+		Avatars: make(map[string]string),
+		RTTs:    make(map[string]int),
+		Ranks:   make(map[string]string),
+
+		IdleTimeout: defaultIdleTimeout,
+		AutoUnready: true,
 	}
 
 	if private {
@@ -50,8 +135,242 @@ func (l *Lobby) AddPlayer(playerID string) {
 	l.mu.Unlock()
 }
 
+func (l *Lobby) Invite(playerID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.invited == nil {
+		l.invited = make(map[string]bool)
+	}
+
+	l.invited[playerID] = true
+}
+
+func (l *Lobby) IsInvited(playerID string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.invited[playerID]
+}
+
+// InviteCoach lets the peer join as the player's coach.
+func (l *Lobby) InviteCoach(coachID, playerID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.coachInvites == nil {
+		l.coachInvites = make(map[string]string)
+	}
+
+	l.coachInvites[coachID] = playerID
+}
+
+// IsInvitedCoach returns whether the peer was asked to coach the player.
+func (l *Lobby) IsInvitedCoach(coachID, playerID string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return playerID != "" && l.coachInvites[coachID] == playerID
+}
+
+// AddCoach makes the peer the player's coach.
+func (l *Lobby) AddCoach(coachID, playerID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.Coaches == nil {
+		l.Coaches = make(map[string]string)
+	}
+
+	l.Coaches[coachID] = playerID
+	delete(l.coachInvites, coachID)
+}
+
+// CoachOf returns the player's coach, or "" if they don't have one.
+func (l *Lobby) CoachOf(playerID string) string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.coachOf(playerID)
+}
+
+// coachOf is CoachOf for when the lock is held.
+func (l *Lobby) coachOf(playerID string) string {
+	for coachID, coached := range l.Coaches {
+		if coached == playerID {
+			return coachID
+		}
+	}
+
+	return ""
+}
+
+// Coaching returns the player the peer is coaching, or "" if they aren't a
+// coach here.
+func (l *Lobby) Coaching(coachID string) string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.Coaches[coachID]
+}
+
+// coachIDs returns the lobby's coaches in order. Expects the lock to be held.
+func (l *Lobby) coachIDs() []string {
+	ids := make([]string, 0, len(l.Coaches))
+
+	for id := range l.Coaches {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+func (l *Lobby) HasPlayer(playerID string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.hasPlayer(playerID)
+}
+
+// hasPlayer is HasPlayer for when the lock is held.
+func (l *Lobby) hasPlayer(playerID string) bool {
+	for _, id := range l.PlayerIDs {
+		if id == playerID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetPlayerAway records whether a player is away from the keyboard.
+func (l *Lobby) SetPlayerAway(playerID string, away bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.Away == nil {
+		l.Away = make(map[string]bool)
+	}
+
+	l.Away[playerID] = away
+}
+
+// SetPlayerStatus records a player's ready and idle state.
+func (l *Lobby) SetPlayerStatus(playerID string, ready, idle bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.Ready == nil {
+		l.Ready = make(map[string]bool)
+	}
+
+	if l.Idle == nil {
+		l.Idle = make(map[string]bool)
+	}
+
+	l.Ready[playerID] = ready
+	l.Idle[playerID] = idle
+}
+
+// SetPlayerProfile records the name a player goes by, their avatar and their
+// season badge.
+func (l *Lobby) SetPlayerProfile(playerID, name, avatar, rank string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.Names == nil {
+		l.Names = make(map[string]string)
+	}
+
+	if l.Avatars == nil {
+		l.Avatars = make(map[string]string)
+	}
+
+	if l.Ranks == nil {
+		l.Ranks = make(map[string]string)
+	}
+
+	l.Names[playerID] = name
+	l.Avatars[playerID] = avatar
+	l.Ranks[playerID] = rank
+}
+
+// SetPlayerRegion records the region a player's in, or "" if they can't
+// tell.
+func (l *Lobby) SetPlayerRegion(playerID, region string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.Regions == nil {
+		l.Regions = make(map[string]string)
+	}
+
+	if region == "" {
+		delete(l.Regions, playerID)
+		return
+	}
+
+	l.Regions[playerID] = region
+}
+
+// playerName returns the player's name, or the start of their ID if they
+// haven't said. Other players' names go through the profanity filter, since
+// this is how every view shows them. Expects the lock to be held.
+func (l *Lobby) playerName(playerID string) string {
+	if name := l.Names[playerID]; name != "" {
+		if arcade.Server != nil && playerID == arcade.Server.ID {
+			return name
+		}
+
+		return filterText(name)
+	}
+
+	return shortID(playerID, 8)
+}
+
+// updateRTTs records the round trip time from the host to each player, from
+// their heartbeats.
+func (l *Lobby) updateRTTs() {
+	clients := arcade.Server.GetHeartbeatClients()
+	rtts := make(map[string]int)
+
+	clients.Range(func(key, value interface{}) bool {
+		rtts[key.(string)] = int(value.(ConnectedClientInfo).GetMeanRTT().Milliseconds())
+		return true
+	})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.RTTs = make(map[string]int)
+
+	for _, id := range l.PlayerIDs {
+		if rtt, ok := rtts[id]; ok {
+			l.RTTs[id] = rtt
+		}
+	}
+}
+
 func (l *Lobby) RemovePlayer(playerID string) {
 	l.mu.Lock()
+	delete(l.Ready, playerID)
+	delete(l.Idle, playerID)
+	delete(l.Away, playerID)
+	delete(l.Names, playerID)
+	delete(l.Avatars, playerID)
+	delete(l.Ranks, playerID)
+	delete(l.Regions, playerID)
+	delete(l.RTTs, playerID)
+	delete(l.Coaches, playerID)
+
+	// A coach has no one to watch once their player leaves
+	for coachID, coached := range l.Coaches {
+		if coached == playerID {
+			delete(l.Coaches, coachID)
+		}
+	}
+
 	for i, v := range l.PlayerIDs {
 		if v == playerID {
 			l.PlayerIDs = append(l.PlayerIDs[:i], l.PlayerIDs[i+1:]...)
@@ -73,6 +392,16 @@ func generateCode() string {
 	return code
 }
 
+// MarshalJSON holds the lobby's lock while encoding, since player statuses are
+// updated from heartbeats while the lobby is being sent out.
+func (l *Lobby) MarshalJSON() ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	type lobby Lobby
+	return json.Marshal((*lobby)(l))
+}
+
 func (l *Lobby) MarshalBinary() ([]byte, error) {
 	return json.Marshal(l)
 }
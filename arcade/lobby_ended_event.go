@@ -0,0 +1,15 @@
+package arcade
+
+type LobbyEndedEvent struct {
+	LobbyID string
+}
+
+func NewLobbyEndedEvent(lobbyID string) *LobbyEndedEvent {
+	return &LobbyEndedEvent{
+		LobbyID: lobbyID,
+	}
+}
+
+func (e *LobbyEndedEvent) Topic() EventTopic {
+	return LobbyEvents
+}
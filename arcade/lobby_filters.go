@@ -0,0 +1,233 @@
+package arcade
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Columns the lobby list can be sorted by
+const (
+	SortByName    = "name"
+	SortByPlayers = "players"
+	SortByPing    = "ping"
+)
+
+var lobbySortColumns = []string{SortByName, SortByPlayers, SortByPing}
+
+var (
+	lobbyGameFilters       = []string{"", Tron, Pong}
+	lobbyVisibilityFilters = []string{"", "public", "private"}
+	lobbyPingFilters       = []int{0, 50, 100, 200}
+)
+
+// LobbyFilters are the player's lobby list preferences. They're saved in the
+// profile so they stick around between sessions.
+type LobbyFilters struct {
+	GameType   string `json:"game_type,omitempty"`
+	Visibility string `json:"visibility,omitempty"`
+	OpenSlots  bool   `json:"open_slots,omitempty"`
+	MaxPing    int    `json:"max_ping,omitempty"`
+	Region     string `json:"region,omitempty"`
+	Search     string `json:"search,omitempty"`
+	SortColumn string `json:"sort_column,omitempty"`
+	SortDesc   bool   `json:"sort_desc,omitempty"`
+}
+
+// lobbyListing is a snapshot of the lobby fields shown in the lobby list, so
+// filtering and sorting don't need to hold each lobby's lock.
+type lobbyListing struct {
+	ID          string
+	Name        string
+	GameType    string
+	HostID      string
+	Private     bool
+	HasPassword bool
+	Players     int
+	Capacity    int
+	Ping        int
+	Region      string
+
+	// Hosted by our distributor, rather than a player
+	Neutral bool
+}
+
+func newLobbyListing(lobby *Lobby) lobbyListing {
+	lobby.mu.RLock()
+	defer lobby.mu.RUnlock()
+
+	return lobbyListing{
+		ID:          lobby.ID,
+		Name:        lobby.Name,
+		GameType:    lobby.GameType,
+		HostID:      lobby.HostID,
+		Private:     lobby.Private,
+		Players:     len(lobby.PlayerIDs),
+		Capacity:    lobby.Capacity,
+		Ping:        lobby.Ping,
+		HasPassword: lobby.HasPassword,
+		Region:      lobby.Region,
+	}
+}
+
+func (f LobbyFilters) Matches(l lobbyListing) bool {
+	if f.GameType != "" && f.GameType != l.GameType {
+		return false
+	}
+
+	if (f.Visibility == "public" && l.Private) || (f.Visibility == "private" && !l.Private) {
+		return false
+	}
+
+	if f.OpenSlots && l.Players >= l.Capacity {
+		return false
+	}
+
+	if f.MaxPing > 0 && l.Ping > f.MaxPing {
+		return false
+	}
+
+	if f.Region != "" && f.Region != l.Region {
+		return false
+	}
+
+	if f.Search != "" && !strings.Contains(strings.ToLower(l.Name), strings.ToLower(f.Search)) {
+		return false
+	}
+
+	return true
+}
+
+// Apply filters and sorts the listings. Ties are broken by lobby ID so rows
+// don't jump around between refreshes.
+func (f LobbyFilters) Apply(listings []lobbyListing) []lobbyListing {
+	filtered := make([]lobbyListing, 0, len(listings))
+
+	for _, l := range listings {
+		if f.Matches(l) {
+			filtered = append(filtered, l)
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		a, b := filtered[i], filtered[j]
+
+		if f.SortDesc {
+			a, b = b, a
+		}
+
+		switch f.SortColumn {
+		case SortByPlayers:
+			if a.Players != b.Players {
+				return a.Players < b.Players
+			}
+		case SortByPing:
+			if a.Ping != b.Ping {
+				return a.Ping < b.Ping
+			}
+		default:
+			if an, bn := strings.ToLower(a.Name), strings.ToLower(b.Name); an != bn {
+				return an < bn
+			}
+		}
+
+		return filtered[i].ID < filtered[j].ID
+	})
+
+	return filtered
+}
+
+// String summarizes the active filters for the lobby list header.
+func (f LobbyFilters) String() string {
+	game := "any"
+
+	if f.GameType != "" {
+		game = f.GameType
+	}
+
+	visibility := "any"
+
+	if f.Visibility != "" {
+		visibility = f.Visibility
+	}
+
+	ping := "any"
+
+	if f.MaxPing > 0 {
+		ping = fmt.Sprintf("<%dms", f.MaxPing)
+	}
+
+	region := "any"
+
+	if f.Region != "" {
+		region = f.Region
+	}
+
+	open := "off"
+
+	if f.OpenSlots {
+		open = "on"
+	}
+
+	return fmt.Sprintf(" game:%s  visibility:%s  open:%s  ping:%s  region:%s ", game, visibility, open, ping, region)
+}
+
+func (f *LobbyFilters) CycleGameType() {
+	f.GameType = nextString(lobbyGameFilters, f.GameType)
+}
+
+func (f *LobbyFilters) CycleVisibility() {
+	f.Visibility = nextString(lobbyVisibilityFilters, f.Visibility)
+}
+
+// CycleRegion goes through the regions, starting from any.
+func (f *LobbyFilters) CycleRegion() {
+	f.Region = nextString(append([]string{""}, regions...), f.Region)
+}
+
+func (f *LobbyFilters) CycleMaxPing() {
+	for i, ping := range lobbyPingFilters {
+		if ping == f.MaxPing {
+			f.MaxPing = lobbyPingFilters[(i+1)%len(lobbyPingFilters)]
+			return
+		}
+	}
+
+	f.MaxPing = lobbyPingFilters[0]
+}
+
+// MoveSortColumn moves the sort column left or right.
+func (f *LobbyFilters) MoveSortColumn(delta int) {
+	i := 0
+
+	for j, column := range lobbySortColumns {
+		if column == f.SortColumn {
+			i = j
+		}
+	}
+
+	i = (i + delta + len(lobbySortColumns)) % len(lobbySortColumns)
+	f.SortColumn = lobbySortColumns[i]
+}
+
+func nextString(options []string, current string) string {
+	for i, option := range options {
+		if option == current {
+			return options[(i+1)%len(options)]
+		}
+	}
+
+	return options[0]
+}
+
+func loadLobbyFilters() LobbyFilters {
+	if profile, err := LoadProfile(); err == nil {
+		return profile.LobbyFilters
+	}
+
+	return LobbyFilters{}
+}
+
+func saveLobbyFilters(filters LobbyFilters) error {
+	return UpdateProfile(func(profile *Profile) { profile.LobbyFilters = filters })
+}
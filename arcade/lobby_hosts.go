@@ -0,0 +1,165 @@
+package arcade
+
+import (
+	"arcade/arcade/net"
+	"encoding"
+)
+
+// LobbyHost runs a lobby we host. Messages for the lobby go to it whatever's
+// on screen, so one process can host several lobbies at once.
+type LobbyHost interface {
+	HostedLobby() *Lobby
+	GetHeartbeatMetadata() encoding.BinaryMarshaler
+	ProcessEvent(ev interface{})
+	ProcessMessage(from *net.Client, p interface{}) interface{}
+}
+
+// lobbyMessage is implemented by messages for the host of a particular
+// lobby.
+type lobbyMessage interface {
+	hostedLobbyID() string
+}
+
+func (m *JoinMessage) hostedLobbyID() string         { return m.LobbyID }
+func (m *LeaveMessage) hostedLobbyID() string        { return m.LobbyID }
+func (m *CoachRequestMessage) hostedLobbyID() string { return m.LobbyID }
+
+// HostLobby starts sending the lobby's messages, and events about the
+// network, to the host, in place of any it had before.
+func (s *Server) HostLobby(lobbyID string, host LobbyHost) {
+	s.lobbyHostsMu.Lock()
+	defer s.lobbyHostsMu.Unlock()
+
+	s.lobbyHosts[lobbyID] = host
+}
+
+// StopHostingLobby stops sending the lobby's messages to its host.
+func (s *Server) StopHostingLobby(lobbyID string) {
+	s.lobbyHostsMu.Lock()
+	defer s.lobbyHostsMu.Unlock()
+
+	delete(s.lobbyHosts, lobbyID)
+}
+
+// lobbyHost returns the host of a lobby we're hosting.
+func (s *Server) lobbyHost(lobbyID string) (LobbyHost, bool) {
+	s.lobbyHostsMu.RLock()
+	defer s.lobbyHostsMu.RUnlock()
+
+	host, ok := s.lobbyHosts[lobbyID]
+	return host, ok
+}
+
+// lobbyHostList returns the hosts of every lobby we're hosting.
+func (s *Server) lobbyHostList() []LobbyHost {
+	s.lobbyHostsMu.RLock()
+	defer s.lobbyHostsMu.RUnlock()
+
+	hosts := make([]LobbyHost, 0, len(s.lobbyHosts))
+
+	for _, host := range s.lobbyHosts {
+		hosts = append(hosts, host)
+	}
+
+	return hosts
+}
+
+// HostedLobbies returns the lobbies we're hosting.
+func (s *Server) HostedLobbies() []*Lobby {
+	hosts := s.lobbyHostList()
+	lobbies := make([]*Lobby, len(hosts))
+
+	for i, host := range hosts {
+		lobbies[i] = host.HostedLobby()
+	}
+
+	return lobbies
+}
+
+// routeToLobby hands a message for a lobby we host to its host: one naming
+// the lobby, or anything else from one of its players while it's not on
+// screen. Heartbeats are left to the server, which passes them on as events.
+// The second return value is false if it isn't for one.
+func (s *Server) routeToLobby(c *net.Client, senderID string, msg interface{}) (interface{}, bool) {
+	if _, ok := msg.(*HeartbeatMessage); ok {
+		return nil, false
+	}
+
+	var lobbyID string
+
+	m, named := msg.(lobbyMessage)
+
+	if named {
+		lobbyID = m.hostedLobbyID()
+	} else if info, ok := s.connectedClients.Load(senderID); ok {
+		lobbyID = info.(ConnectedClientInfo).LobbyID
+	}
+
+	host, ok := s.lobbyHost(lobbyID)
+
+	if !ok || !named && s.mgr != nil && s.mgr.showing(host) {
+		return nil, false
+	}
+
+	return host.ProcessMessage(c, msg), true
+}
+
+// announceHostedLobbies tells a peer that's said hello about the lobbies we
+// host other than the one on screen, which answers for itself.
+func (s *Server) announceHostedLobbies(c *net.Client) {
+	for _, host := range s.lobbyHostList() {
+		if s.mgr != nil && s.mgr.showing(host) {
+			continue
+		}
+
+		s.Network.Send(c, NewLobbyUpdateMessage(host.HostedLobby()))
+	}
+}
+
+// answerForHostedLobbies answers what a lobby view on screen would, for
+// servers with none. Hellos get every lobby we host, and probes only time the
+// trip to us, since our players only play through us. The second return
+// value is false if the message isn't one of these.
+func (s *Server) answerForHostedLobbies(c *net.Client, msg interface{}) (interface{}, bool) {
+	switch msg := msg.(type) {
+	case *HelloMessage:
+		go s.announceHostedLobbies(c)
+		return NewLobbyInfoMessage(nil), true
+	case *MatchProbeMessage:
+		return NewMatchProbeReplyMessage(msg.Seq, nil), true
+	}
+
+	return nil, false
+}
+
+// forwardToLobbyHosts hands network events to the hosts of lobbies that
+// aren't on screen. The one that is gets them from the view manager.
+func (s *Server) forwardToLobbyHosts(ev Event) {
+	for _, host := range s.lobbyHostList() {
+		if s.mgr == nil || !s.mgr.showing(host) {
+			host.ProcessEvent(ev)
+		}
+	}
+}
+
+// lobbyHeartbeatMetadata returns what heartbeats to the lobby's members say,
+// which is about the lobby rather than whatever's on screen.
+func (s *Server) lobbyHeartbeatMetadata(host LobbyHost) []byte {
+	metadata, err := newHeartbeatMetadata(host)
+
+	if err != nil {
+		panic(err)
+	}
+
+	if s.mgr != nil {
+		metadata.Away = s.mgr.Away()
+	}
+
+	data, err := metadata.MarshalBinary()
+
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
@@ -0,0 +1,75 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"arcade/arcade/net"
+	"encoding"
+	"testing"
+)
+
+type testLobbyHost struct {
+	lobby    *Lobby
+	messages []interface{}
+}
+
+func (h *testLobbyHost) HostedLobby() *Lobby                            { return h.lobby }
+func (h *testLobbyHost) GetHeartbeatMetadata() encoding.BinaryMarshaler { return h.lobby }
+func (h *testLobbyHost) ProcessEvent(ev interface{})                    {}
+
+func (h *testLobbyHost) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	h.messages = append(h.messages, p)
+	return nil
+}
+
+// testJoin is an unsigned join; routing only looks at the lobby ID.
+func testJoin(lobbyID string) *JoinMessage {
+	return &JoinMessage{Message: message.Message{Type: "join"}, PlayerID: "player", LobbyID: lobbyID}
+}
+
+func TestRouteToLobby(t *testing.T) {
+	s := &Server{lobbyHosts: make(map[string]LobbyHost)}
+	a := &testLobbyHost{lobby: &Lobby{ID: "a"}}
+	b := &testLobbyHost{lobby: &Lobby{ID: "b"}}
+
+	s.HostLobby("a", a)
+	s.HostLobby("b", b)
+
+	for _, msg := range []interface{}{
+		testJoin("a"),
+		NewLeaveMessage("player", "b"),
+		NewCoachRequestMessage("b", "coach", "player"),
+	} {
+		if _, ok := s.routeToLobby(nil, "player", msg); !ok {
+			t.Errorf("%T wasn't routed", msg)
+		}
+	}
+
+	if len(a.messages) != 1 || len(b.messages) != 2 {
+		t.Errorf("a got %d messages and b %d, want 1 and 2", len(a.messages), len(b.messages))
+	}
+
+	if _, ok := s.routeToLobby(nil, "player", testJoin("c")); ok {
+		t.Error("routed a join for a lobby we don't host")
+	}
+
+	// Members' heartbeats are answered by the server, not their lobby
+	s.BeginLobbyHeartbeats("member", "b")
+
+	if _, ok := s.routeToLobby(nil, "member", NewHeartbeatMessage(0, nil)); ok {
+		t.Error("routed a member's heartbeat")
+	}
+
+	if _, ok := s.routeToLobby(nil, "member", NewChatMessage("member", "Member", "b", "hi")); !ok {
+		t.Error("didn't route a member's message")
+	}
+
+	s.StopHostingLobby("a")
+
+	if _, ok := s.routeToLobby(nil, "player", testJoin("a")); ok {
+		t.Error("routed a join for a lobby we stopped hosting")
+	}
+
+	if lobbies := s.HostedLobbies(); len(lobbies) != 1 || lobbies[0].ID != "b" {
+		t.Errorf("hosting %v, want just b", lobbies)
+	}
+}
@@ -24,3 +24,9 @@ func (m LobbyInfoMessage) MarshalBinary() ([]byte, error) {
 func (m LobbyInfoMessage) UnmarshalBinary(data []byte) error {
 	return json.Unmarshal(data, &m)
 }
+
+// RelayInClear is true, so the distributor can hold back listings from hosts
+// past their lobby cap as it relays them.
+func (m LobbyInfoMessage) RelayInClear() bool {
+	return true
+}
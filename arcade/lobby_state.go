@@ -0,0 +1,124 @@
+package arcade
+
+import (
+	"sync"
+)
+
+// Where a lobby is in its life. Lobbies only move forward, and may close from
+// any state, like when the host disbands one or its players all leave.
+type LobbyState string
+
+const (
+	LobbyOpen     LobbyState = "open"
+	LobbyStarting LobbyState = "starting"
+	LobbyInGame   LobbyState = "in-game"
+	LobbyFinished LobbyState = "finished"
+	LobbyClosed   LobbyState = "closed"
+)
+
+// The states each state can move to
+var lobbyTransitions = map[LobbyState][]LobbyState{
+	LobbyOpen:     {LobbyStarting, LobbyClosed},
+	LobbyStarting: {LobbyInGame, LobbyClosed},
+	LobbyInGame:   {LobbyFinished, LobbyClosed},
+	LobbyFinished: {LobbyClosed},
+}
+
+// CanBecome returns whether a lobby in the state can move to the next one.
+// Lobbies from before there were states count as open.
+func (s LobbyState) CanBecome(next LobbyState) bool {
+	if s == "" {
+		s = LobbyOpen
+	}
+
+	for _, allowed := range lobbyTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetState moves the lobby to the state, and returns false if it can't go
+// there from where it is.
+func (l *Lobby) SetState(state LobbyState) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.State.CanBecome(state) {
+		return false
+	}
+
+	l.State = state
+	return true
+}
+
+// hostedLobbies are the lobbies we host that haven't closed yet, so their
+// games ending can finish them after the lobby view's gone.
+var (
+	hostedLobbiesMu sync.Mutex
+	hostedLobbies   = make(map[string]*Lobby)
+)
+
+// announceLobbyState moves a lobby we host to the state, and tells its
+// players, coaches and the distributor. Returns false, having done nothing,
+// if the lobby can't go there from where it is.
+func announceLobbyState(lobby *Lobby, state LobbyState) bool {
+	if !lobby.SetState(state) {
+		return false
+	}
+
+	hostedLobbiesMu.Lock()
+	if state == LobbyClosed {
+		delete(hostedLobbies, lobby.ID)
+	} else {
+		hostedLobbies[lobby.ID] = lobby
+	}
+	hostedLobbiesMu.Unlock()
+
+	lobby.mu.RLock()
+	memberIDs := append(append([]string(nil), lobby.PlayerIDs...), lobby.coachIDs()...)
+	lobby.mu.RUnlock()
+
+	msg := NewLobbyStateMessage(lobby.ID, state)
+
+	for _, memberID := range memberIDs {
+		if memberID == arcade.Server.ID {
+			continue
+		}
+
+		if client, ok := arcade.Server.Network.GetClient(memberID); ok {
+			arcade.Server.Network.Send(client, msg)
+		}
+	}
+
+	if distributor, ok := arcade.Server.Network.GetDistributor(); ok {
+		arcade.Server.Network.Send(distributor, msg)
+	}
+
+	arcade.Server.Events.Publish(NewLobbyStateChangedEvent(lobby.ID, state))
+	return true
+}
+
+// finishHostedLobby finishes and closes the lobby a game we hosted was
+// played in. Lobbies don't outlive their game, so there's nothing to go back
+// to once the result's in.
+func finishHostedLobby(ev Event) {
+	evt, ok := ev.(*GameEndedEvent)
+
+	if !ok {
+		return
+	}
+
+	hostedLobbiesMu.Lock()
+	lobby, ok := hostedLobbies[evt.Result.GameID]
+	hostedLobbiesMu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	announceLobbyState(lobby, LobbyFinished)
+	announceLobbyState(lobby, LobbyClosed)
+}
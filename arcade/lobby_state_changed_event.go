@@ -0,0 +1,19 @@
+package arcade
+
+// LobbyStateChangedEvent is published when a lobby we host or are in moves
+// to a new state.
+type LobbyStateChangedEvent struct {
+	LobbyID string
+	State   LobbyState
+}
+
+func NewLobbyStateChangedEvent(lobbyID string, state LobbyState) *LobbyStateChangedEvent {
+	return &LobbyStateChangedEvent{
+		LobbyID: lobbyID,
+		State:   state,
+	}
+}
+
+func (e *LobbyStateChangedEvent) Topic() EventTopic {
+	return LobbyEvents
+}
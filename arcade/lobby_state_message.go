@@ -0,0 +1,30 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// LobbyStateMessage is sent by a lobby's host to its members and the
+// distributor whenever the lobby moves to a new state.
+type LobbyStateMessage struct {
+	message.Message
+	LobbyID string
+	State   LobbyState
+}
+
+func NewLobbyStateMessage(lobbyID string, state LobbyState) *LobbyStateMessage {
+	return &LobbyStateMessage{
+		Message: message.Message{Type: "lobby_state"},
+		LobbyID: lobbyID,
+		State:   state,
+	}
+}
+
+func (m LobbyStateMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func (m LobbyStateMessage) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, &m)
+}
@@ -0,0 +1,65 @@
+package arcade
+
+import "testing"
+
+func TestLobbyStateTransitions(t *testing.T) {
+	lobby := NewLobby("test", false, Pong, 2, "host")
+
+	if lobby.SetState(LobbyInGame) {
+		t.Error("an open lobby went straight in-game")
+	}
+
+	for _, state := range []LobbyState{LobbyStarting, LobbyInGame, LobbyFinished, LobbyClosed} {
+		if !lobby.SetState(state) {
+			t.Fatalf("couldn't move to %s", state)
+		}
+	}
+
+	if lobby.SetState(LobbyOpen) || lobby.State != LobbyClosed {
+		t.Error("a closed lobby reopened")
+	}
+
+	// Lobbies from before there were states can still close
+	if !(&Lobby{}).SetState(LobbyClosed) {
+		t.Error("a lobby without a state couldn't close")
+	}
+}
+
+func TestDirectoryDropsClosedLobbies(t *testing.T) {
+	d := NewDirectory()
+	summary := &LobbySummary{ID: "lobby", HostID: "host", GameType: Pong, Capacity: 2, Players: 1}
+
+	d.updatePresence(Presence{PlayerID: "host", Lobby: summary})
+	d.updatePresence(Presence{PlayerID: "empty", Lobby: &LobbySummary{ID: "empty", HostID: "empty"}})
+
+	if lobbies := d.Lobbies(); len(lobbies) != 1 || lobbies[0].ID != "lobby" {
+		t.Fatalf("listed %v, want just the lobby with players", lobbies)
+	}
+
+	d.updateLobbyState("host", "lobby", LobbyInGame)
+
+	if lobbies := d.Lobbies(); len(lobbies) != 1 || lobbies[0].State != LobbyInGame {
+		t.Errorf("listed %v, want it in-game", lobbies)
+	}
+
+	d.updateLobbyState("host", "lobby", LobbyClosed)
+
+	// A presence sent before the host heard it closed doesn't bring it back
+	d.updatePresence(Presence{PlayerID: "host", Lobby: summary})
+
+	if lobbies := d.Lobbies(); len(lobbies) != 0 {
+		t.Errorf("listed %v after the lobby closed", lobbies)
+	}
+
+	// Servers list all their lobbies, even while they wait for players
+	d.updatePresence(Presence{PlayerID: "server", Lobbies: []*LobbySummary{
+		{ID: "one", HostID: "server", Dedicated: true},
+		{ID: "two", HostID: "server", Dedicated: true},
+	}})
+
+	d.updateLobbyState("server", "one", LobbyClosed)
+
+	if lobbies := d.Lobbies(); len(lobbies) != 1 || lobbies[0].ID != "two" {
+		t.Errorf("listed %v, want just the server's open lobby", lobbies)
+	}
+}
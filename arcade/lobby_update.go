@@ -0,0 +1,25 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// LobbyUpdateMessage is pushed by a lobby's host to everyone browsing when
+// the lobby is created or changes, so lobby lists stay current between full
+// refreshes. Removed lobbies are announced with a LobbyEndMessage.
+type LobbyUpdateMessage struct {
+	message.Message
+	Lobby *Lobby
+}
+
+func NewLobbyUpdateMessage(lobby *Lobby) *LobbyUpdateMessage {
+	return &LobbyUpdateMessage{
+		Message: message.Message{Type: "lobby_update"},
+		Lobby:   lobby,
+	}
+}
+
+func (m LobbyUpdateMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,16 @@
+package arcade
+
+// LobbyUpdatedEvent is published when we hear about a lobby, new or changed.
+type LobbyUpdatedEvent struct {
+	Lobby *Lobby
+}
+
+func NewLobbyUpdatedEvent(lobby *Lobby) *LobbyUpdatedEvent {
+	return &LobbyUpdatedEvent{
+		Lobby: lobby,
+	}
+}
+
+func (e *LobbyUpdatedEvent) Topic() EventTopic {
+	return LobbyEvents
+}
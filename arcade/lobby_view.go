@@ -1,12 +1,17 @@
 package arcade
 
 import (
+	"arcade/arcade/layout"
 	"arcade/arcade/net"
+	"arcade/arcade/widgets"
 	"encoding"
 	"encoding/json"
 	"fmt"
+	"log"
+	"sort"
+	"strings"
 	"sync"
-	"unicode/utf8"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 )
@@ -17,101 +22,359 @@ type LobbyView struct {
 
 	sync.RWMutex
 	Lobby *Lobby
-}
 
-// const stickmen = []string{
-// 	o   \ o /  _ o         __|    \ /     |__        o _  \ o /   o
-// 	/|\    |     /\   ___\o   \o    |    o/    o/__   /\     |    /|\
-// 	/ \   / \   | \  /)  |    ( \  /o\  / )    |  (\  / |   / \   / \
-// }
+	// Invite picker, used by the host to invite players and by players to
+	// invite a coach
+	inviting    bool
+	coachPicker bool
+	invitePeers []string
+	inviteList  *widgets.ScrollList
+	inviteSent  map[string]bool
+
+	// Player list for muting and blocking
+	managing   bool
+	playerList *widgets.ScrollList
+
+	// Focus for whichever picker is open
+	pickerFocus *widgets.FocusGroup
+
+	ready bool
+
+	// Our name and avatar for the roster
+	name   string
+	avatar string
+
+	// When the lobby opened, and when each player was first seen so they
+	// can wave on arrival
+	opened       time.Time
+	arrived      map[string]time.Time
+	arrivedMu    sync.Mutex
+	stopTickerCh chan bool
+
+	// First row of the roster that's showing
+	rosterOffset int
+
+	// Chat between a player and their coach, while either has one
+	coachChat    *ChatOverlay
+	coachPartner string
 
-// const stickmen_list = [][]string{{" o ","/|\\","/ \\"}, {"\\ o /","  |  "," / \\ "}, }
+	joinLimiter *joinLimiter
 
-// var simple_man = []string {" o ","/|\\","/ \\"};
+	// Hash of the lobby's custom map once we have it, and for the host, the
+	// players it's been sent to
+	mapHash string
+	mapSent map[string]bool
 
+	// The same for the lobby's mod, and the parts of it we've been sent
+	modHash  string
+	modSent  map[string]bool
+	modParts *modAssembler
+}
+
+// The second footer is for players who have a coach
 var lobby_footer_host = []string{
-	"[S]tart game       [C]ancel",
+	"[S]tart game   [I]nvite   [H] Coach   [P]layers   [C]ancel   [?] Help",
+	"[S]tart game  [I]nvite  [Enter] Talk to coach  [P]layers  [C]ancel",
 }
 
 var lobby_footer_nonhost = []string{
-	"[C]ancel",
+	"[R]eady     [H] Coach     [P]layers     [C]ancel     [?] Help",
+	"[R]eady   [Enter] Talk to coach   [P]layers   [C]ancel   [?] Help",
+}
+
+var lobby_footer_coach = []string{
+	"[Enter] Talk to player     [P]layers     [C]ancel     [?] Help",
 }
 
+// Box drawn under the lobby table for the invite and player pickers
+const (
+	lvPickerWidth = 40
+	lvPickerX1    = (displayWidth - lvPickerWidth) / 2
+	lvPickerY1    = 13
+	lvPickerX2    = lvPickerX1 + lvPickerWidth
+	lvPickerY2    = displayHeight - 3
+)
+
 func NewLobbyView(mgr *ViewManager, lobby *Lobby) *LobbyView {
-	return &LobbyView{
-		mgr:   mgr,
-		Lobby: lobby,
+	v := &LobbyView{
+		mgr:          mgr,
+		Lobby:        lobby,
+		joinLimiter:  newJoinLimiter(),
+		opened:       time.Now(),
+		arrived:      make(map[string]time.Time),
+		stopTickerCh: make(chan bool),
+		mapSent:      make(map[string]bool),
+		modSent:      make(map[string]bool),
+		inviteList:   widgets.NewScrollList(lvPickerX1+1, lvPickerY1+1, lvPickerWidth-1, lvPickerY2-lvPickerY1-1),
+		playerList:   widgets.NewScrollList(lvPickerX1+1, lvPickerY1+1, lvPickerWidth-1, lvPickerY2-lvPickerY1-1),
+	}
+
+	v.name = arcade.Server.ID[:8]
+
+	if profile, err := LoadProfile(); err == nil {
+		if profile.Name != "" {
+			v.name = profile.Name
+		}
+
+		v.avatar = profile.Avatar
+	}
+
+	v.inviteList.Placeholder = "No one else is connected."
+	v.inviteList.OnSelect = v.sendInvite
+	v.playerList.Placeholder = "No one else is here yet."
+
+	return v
+}
+
+// Keymap returns the keys for the open picker, or for the lobby itself.
+func (v *LobbyView) Keymap() *Keymap {
+	v.RLock()
+	defer v.RUnlock()
+
+	host := v.Lobby.HostID == arcade.Server.ID
+
+	switch {
+	case v.coachChat != nil && v.coachChat.Typing():
+		return nil
+	case v.inviting && v.coachPicker:
+		return lobbyKeymap.Only(ActionMove, ActionSelect, ActionCoach)
+	case v.inviting:
+		return lobbyKeymap.Only(ActionMove, ActionSelect, ActionInvite)
+	case v.managing && host:
+		return lobbyKeymap.Only(ActionMove, ActionMute, ActionBlock, ActionKick, ActionPlayers)
+	case v.managing:
+		return lobbyKeymap.Only(ActionMove, ActionMute, ActionBlock, ActionPlayers)
+	case v.Lobby.Coaching(arcade.Server.ID) != "":
+		return lobbyKeymap.Only(ActionPlayers, ActionLeave, ActionMove)
+	case host:
+		return lobbyKeymap.Only(ActionStart, ActionInvite, ActionCoach, ActionPlayers, ActionLeave, ActionMove)
+	}
+
+	return lobbyKeymap.Only(ActionReady, ActionCoach, ActionPlayers, ActionLeave, ActionMove)
+}
+
+// Widgets returns the open picker's list, if any.
+func (v *LobbyView) Widgets() *widgets.FocusGroup {
+	v.RLock()
+	defer v.RUnlock()
+
+	if !v.inviting && !v.managing {
+		return nil
 	}
+
+	return v.pickerFocus
 }
 
 func (v *LobbyView) Init() {
+	if _, ok := findTronMap(v.Lobby.MapHash); ok {
+		v.mapHash = v.Lobby.MapHash
+	} else if v.Lobby.MapHash != "" {
+		go v.downloadMap()
+	}
+
+	// Mods only come from the host
+	if _, ok := findTronMod(v.Lobby.ModHash); ok {
+		v.modHash = v.Lobby.ModHash
+	} else if v.Lobby.ModHash != "" {
+		v.modParts = newModAssembler(v.Lobby.ModHash)
+	}
+
+	if v.Lobby.HostID == arcade.Server.ID {
+		v.Lobby.SetPlayerProfile(arcade.Server.ID, v.name, v.avatar, v.mgr.seasonBadge())
+		arcade.Server.HostLobby(v.Lobby.ID, v)
+		go v.broadcastLobbyUpdate()
+	}
+
+	// Coaches are let in by invite, so there's no joining again
+	if v.Lobby.Coaching(arcade.Server.ID) == "" {
+		rememberLobby(v.Lobby)
+	}
+
+	// Stickmen stand still for screen readers
+	if v.mgr.Announcer.Enabled() {
+		return
+	}
+
+	ticker := time.NewTicker(avatarFrameTime)
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				v.mgr.RequestRender()
+			case <-v.stopTickerCh:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// broadcastLobbyUpdate tells everyone who isn't in the lobby about its latest
+// state, so their lobby lists update without waiting for a refresh.
+func (v *LobbyView) broadcastLobbyUpdate() {
+	arcade.Server.Network.ClientsRange(func(client *net.Client) bool {
+		client.RLock()
+		skip := client.State != net.Connected || client.Distributor
+		client.RUnlock()
+
+		if skip || v.Lobby.HasPlayer(client.ID) || v.Lobby.Coaching(client.ID) != "" {
+			return true
+		}
+
+		arcade.Server.Network.Send(client, NewLobbyUpdateMessage(v.Lobby))
+		return true
+	})
 }
 
 func (v *LobbyView) ProcessEvent(evt interface{}) {
 	switch evt := evt.(type) {
 	case *ClientDisconnectedEvent:
-		if v.Lobby.HostID == arcade.Server.ID {
+		if v.Lobby.HostID == arcade.Server.ID && v.Lobby.HasPlayer(evt.ClientID) {
 			v.Lobby.RemovePlayer(evt.ClientID)
+			go v.broadcastLobbyUpdate()
+
+			notify("%s disconnected", shortID(evt.ClientID, 8))
+		} else if evt.ClientID == v.Lobby.HostID {
+			// A lobby whose host has gone silent can't start, so it's over
+			arcade.Server.EndAllHeartbeats()
+			v.backToBrowser()
+
+			notify("Lost connection to the host, so the lobby closed")
 		}
 	case *HeartbeatEvent:
 		if v.Lobby.HostID != arcade.Server.ID {
+			// Coaches wait here while the host plays, and the host's
+			// heartbeats are about the game then
+			if evt.Info.View != "" && evt.Info.View != "LobbyView" {
+				return
+			}
+
 			lobby := new(Lobby)
 			json.Unmarshal(evt.Metadata, lobby)
 			// fmt.Println("lobby updated w heartbeat")
 			v.Lock()
+			previous := v.Lobby
 			v.Lobby = lobby
+			v.updateCoaching()
 			v.Unlock()
+
+			notifyPlayerChanges(previous, lobby)
+
+			if previous.Coaching(arcade.Server.ID) != "" && lobby.Coaching(arcade.Server.ID) == "" {
+				notify("Your player left the lobby")
+				v.leave()
+			}
+		} else {
+			var status LobbyPlayerStatus
+
+			if err := json.Unmarshal(evt.Metadata, &status); err == nil && status.LobbyID == v.Lobby.ID && v.Lobby.HasPlayer(evt.ClientID) {
+				v.Lobby.SetPlayerStatus(evt.ClientID, status.Ready, status.Idle)
+				v.Lobby.SetPlayerAway(evt.ClientID, evt.Info.Away)
+				v.Lobby.SetPlayerProfile(evt.ClientID, status.Name, status.Avatar, status.Rank)
+				v.Lobby.SetPlayerRegion(evt.ClientID, status.Region)
+				v.shareMap(evt.ClientID)
+				v.shareMod(evt.ClientID)
+			} else if err == nil && status.LobbyID == v.Lobby.ID && v.Lobby.Coaching(evt.ClientID) != "" {
+				v.Lobby.SetPlayerProfile(evt.ClientID, status.Name, status.Avatar, status.Rank)
+			}
 		}
 		// do something with lobby
 	case *tcell.EventKey:
-		switch evt.Key() {
-		case tcell.KeyRune:
-			switch evt.Rune() {
-			case 'c':
-				v.Lobby.mu.RLock()
-				if v.Lobby.HostID != arcade.Server.ID {
-					// not the host, just leave the game
-					host, _ := arcade.Server.Network.GetClient(v.Lobby.HostID)
-					v.Lobby.mu.RUnlock()
+		v.RLock()
+		inviting := v.inviting
+		managing := v.managing
+		v.RUnlock()
 
-					arcade.Server.Network.Send(host, NewLeaveMessage(arcade.Server.ID, v.Lobby.ID))
+		if inviting {
+			v.processInviteEvent(evt)
+			return
+		}
 
-					arcade.Server.EndAllHeartbeats()
-					v.mgr.SetView(NewGamesListView(v.mgr))
-				} else {
-					// first extract lobbyID for messages
-					lobbyID := v.Lobby.ID
-					v.Lobby.mu.RUnlock()
+		if managing {
+			v.processPlayersEvent(evt)
+			return
+		}
 
-					arcade.Server.EndAllHeartbeats()
-					// send updates to everyone
+		v.RLock()
+		coachChat := v.coachChat
+		v.RUnlock()
 
-					arcade.Server.Network.ClientsRange(func(client *net.Client) bool {
-						if client.Distributor {
-							return true
-						}
+		if coachChat != nil && coachChat.ProcessEvent(evt) {
+			v.mgr.RequestRender()
+			return
+		}
+
+		coaching := v.Lobby.Coaching(arcade.Server.ID) != ""
 
-						arcade.Server.Network.Send(client, NewLobbyEndMessage(lobbyID))
+		switch lobbyKeymap.Action(evt) {
+		case ActionInvite:
+			if v.Lobby.HostID == arcade.Server.ID {
+				v.openInvitePicker(false)
+			}
+		case ActionCoach:
+			if !coaching && v.Lobby.CoachOf(arcade.Server.ID) == "" {
+				v.openInvitePicker(true)
+			}
+		case ActionReady:
+			if v.Lobby.HostID != arcade.Server.ID && !coaching {
+				v.Lock()
+				missingMap := v.Lobby.MapHash != v.mapHash
+				missingMod := v.Lobby.ModHash != v.modHash
+				v.ready = !v.ready && !missingMap && !missingMod
+				v.Unlock()
 
-						return true
-					})
+				if missingMap {
+					notify("Still waiting for the lobby's map")
+				} else if missingMod {
+					notify("Still waiting for the lobby's mod")
+				}
+			}
+		case ActionPlayers:
+			v.Lobby.mu.RLock()
+			v.updatePlayerList()
+			v.Lobby.mu.RUnlock()
 
-					v.mgr.SetView(NewGamesListView(v.mgr))
+			v.playerList.Select(0)
 
+			v.Lock()
+			v.managing = true
+			v.pickerFocus = widgets.NewFocusGroup(v.playerList)
+			v.Unlock()
+		case ActionLeave:
+			v.leave()
+		case ActionMove:
+			v.scrollRoster(evt.Key())
+		case ActionStart:
+			//start gamex
+			if v.Lobby.HostID == arcade.Server.ID {
+				announceLobbyState(v.Lobby, LobbyStarting)
+				rng := NewMatchRNG()
+
+				// Whether the mod's played with is up to us, so nobody
+				// plays a different game
+				if err := settleTronMod(v.Lobby); err != nil {
+					log.Println("Couldn't run the lobby's mod:", err)
+
+					v.Lobby.mu.Lock()
+					name := v.Lobby.ModName
+					v.Lobby.ModName, v.Lobby.ModHash = "", ""
+					v.Lobby.mu.Unlock()
+
+					notify("The mod %s didn't work, playing without it", name)
 				}
-			case 's':
-				//start gamex
+
 				v.Lobby.mu.RLock()
-				if v.Lobby.HostID == arcade.Server.ID {
-					for _, playerId := range v.Lobby.PlayerIDs {
-						client, ok := arcade.Server.Network.GetClient(playerId)
-						if ok {
-							arcade.Server.Network.Send(client, NewStartGameMessage(v.Lobby.ID))
-						}
+				for _, playerId := range v.Lobby.PlayerIDs {
+					client, ok := arcade.Server.Network.GetClient(playerId)
+					if ok {
+						arcade.Server.Network.Send(client, NewStartGameMessage(v.Lobby.ID, rng.Commitment(), v.Lobby.ModHash))
 					}
-					NewGame(v.mgr, v.Lobby)
 				}
 				v.Lobby.mu.RUnlock()
+
+				announceLobbyState(v.Lobby, LobbyInGame)
+				NewGame(v.mgr, v.Lobby, rng)
 			}
 		}
 	}
@@ -121,22 +384,60 @@ func (v *LobbyView) ProcessMessage(from *net.Client, p interface{}) interface{}
 	switch p := p.(type) {
 	case *HelloMessage:
 		return NewLobbyInfoMessage(v.Lobby)
+	case *MatchProbeMessage:
+		if v.Lobby.HostID != arcade.Server.ID {
+			break
+		}
+
+		v.Lobby.mu.RLock()
+		rtts := make(map[string]int, len(v.Lobby.RTTs))
+
+		for id, rtt := range v.Lobby.RTTs {
+			rtts[id] = rtt
+		}
+		v.Lobby.mu.RUnlock()
+
+		return NewMatchProbeReplyMessage(p.Seq, rtts)
 	case *JoinMessage:
 		if v.Lobby.HostID == arcade.Server.ID {
-			if v.Lobby.ID == p.LobbyID {
+			if v.Lobby.ID == p.LobbyID && p.CoachFor != "" {
+				return v.joinCoach(from, p)
+			} else if v.Lobby.ID == p.LobbyID {
 				v.Lobby.mu.RLock()
 				playerIDlength := len(v.Lobby.PlayerIDs)
 				cap := v.Lobby.Capacity
 				lobby_code := v.Lobby.Code
 				v.Lobby.mu.RUnlock()
 
-				if playerIDlength == cap {
+				invited := p.PlayerID == p.SenderID && v.Lobby.IsInvited(p.PlayerID)
+				key := connectionKey(from, p.Origin)
+
+				if !v.joinLimiter.Allowed(key) {
+					return NewJoinReplyMessage(&Lobby{}, ErrRateLimited)
+				} else if playerIDlength == cap {
 					return NewJoinReplyMessage(&Lobby{}, ErrCapacity)
-				} else if lobby_code != p.Code {
+				} else if lobby_code != p.Code && !invited {
+					v.joinLimiter.Failed(key)
 					return NewJoinReplyMessage(&Lobby{}, ErrWrongCode)
+				} else if !invited && !v.Lobby.CheckPassword(p.Password) {
+					v.joinLimiter.Failed(key)
+					return NewJoinReplyMessage(&Lobby{}, ErrWrongPassword)
+				} else if !v.Lobby.verifyJoin(p) {
+					v.joinLimiter.Failed(key)
+					return NewJoinReplyMessage(&Lobby{}, ErrIdentity)
 				} else {
+					v.joinLimiter.Succeeded(key)
 					v.Lobby.AddPlayer(p.PlayerID)
-					arcade.Server.BeginHeartbeats(p.PlayerID)
+					v.admitStatus(p.PlayerID, p.Status)
+					arcade.Server.BeginLobbyHeartbeats(p.PlayerID, v.Lobby.ID)
+					go v.broadcastLobbyUpdate()
+
+					v.Lobby.mu.RLock()
+					notify("%s joined the lobby", v.Lobby.playerName(p.PlayerID))
+					v.Lobby.mu.RUnlock()
+
+					// The whole lobby as it is now: settings, map, and
+					// everyone's names and ready states, the joiner's too
 					return NewJoinReplyMessage(v.Lobby, OK)
 				}
 			} else {
@@ -148,9 +449,35 @@ func (v *LobbyView) ProcessMessage(from *net.Client, p interface{}) interface{}
 	case *LeaveMessage:
 		if v.Lobby.ID == p.LobbyID && v.Lobby.HostID == arcade.Server.ID {
 			v.Lobby.RemovePlayer(p.PlayerID)
+			go v.broadcastLobbyUpdate()
+
+			notify("%s left the lobby", shortID(p.PlayerID, 8))
 		}
 
 		arcade.Server.EndHeartbeats(p.PlayerID)
+		v.mgr.RequestRender()
+	case *KickMessage:
+		if v.Lobby.ID == p.LobbyID && p.SenderID == v.Lobby.HostID {
+			arcade.Server.EndAllHeartbeats()
+			v.backToBrowser()
+
+			notify("You were removed from the lobby")
+		}
+	case *LobbyStateMessage:
+		if p.SenderID != v.Lobby.HostID || p.LobbyID != v.Lobby.ID {
+			break
+		}
+
+		if p.State == LobbyClosed {
+			arcade.Server.EndAllHeartbeats()
+			v.backToBrowser()
+			break
+		}
+
+		v.Lobby.mu.Lock()
+		v.Lobby.State = p.State
+		v.Lobby.mu.Unlock()
+
 		v.mgr.RequestRender()
 	case *LobbyEndMessage:
 		// get rid of lobby
@@ -158,19 +485,603 @@ func (v *LobbyView) ProcessMessage(from *net.Client, p interface{}) interface{}
 			v.Lobby = &Lobby{}
 
 			arcade.Server.EndAllHeartbeats()
-			v.mgr.SetView(NewGamesListView(v.mgr))
+			v.backToBrowser()
 		}
 	case *StartGameMessage:
-		if p.GameID == v.Lobby.ID {
-			NewGame(v.mgr, v.Lobby)
+		if p.SenderID != v.Lobby.HostID || p.GameID != v.Lobby.ID {
+			return nil
+		}
+
+		v.Lobby.mu.Lock()
+		v.Lobby.ModHash = p.ModHash
+		v.Lobby.mu.Unlock()
+
+		// Playing without the mod the host's using would be playing
+		// another game, so we sit this one out
+		if err := settleTronMod(v.Lobby); err != nil {
+			log.Println("Couldn't run the lobby's mod:", err)
+			notify("Couldn't run the lobby's mod, so you can't play this match")
+			v.leave()
+			return nil
 		}
 
+		NewGame(v.mgr, v.Lobby, NewCommittedMatchRNG(p.SeedCommitment))
 		return nil
+	case *MapShareMessage:
+		if p.SenderID == v.Lobby.HostID && p.LobbyID == v.Lobby.ID && p.Map != nil && p.Map.Hash() == v.Lobby.MapHash {
+			go v.keepMap(p.Map)
+		}
+	case *ModShareMessage:
+		if p.SenderID == v.Lobby.HostID && p.LobbyID == v.Lobby.ID {
+			v.Lock()
+			var m *TronMod
+			done := false
+
+			if v.modParts != nil {
+				m, done = v.modParts.add(p)
+			}
+			v.Unlock()
+
+			if done {
+				go v.keepMod(m)
+			}
+		}
+	case *CoachRequestMessage:
+		if v.Lobby.ID == p.LobbyID && v.Lobby.HostID == arcade.Server.ID && v.Lobby.HasPlayer(p.SenderID) {
+			v.inviteCoach(p.CoachID, p.SenderID, filterText(p.PlayerName))
+		}
+	case *CoachFrameMessage:
+		// The coached player's game has started
+		if playerID := v.Lobby.Coaching(arcade.Server.ID); playerID != "" && p.SenderID == playerID && !p.Ended {
+			v.mgr.ReplaceView(NewCoachView(v.mgr, v.Lobby, playerID))
+		}
+	case *ChatMessage:
+		v.RLock()
+		coachChat := v.coachChat
+		v.RUnlock()
+
+		if coachChat != nil {
+			coachChat.ProcessMessage(p)
+		}
 	}
 
 	return nil
 }
 
+// shareMap sends the lobby's custom map to a player, the first time we hear
+// from them. Only used by the host.
+func (v *LobbyView) shareMap(playerID string) {
+	v.Lock()
+	sent := v.mapSent[playerID]
+	v.mapSent[playerID] = true
+	v.Unlock()
+
+	if sent || v.Lobby.MapHash == "" {
+		return
+	}
+
+	go func() {
+		m, ok := findTronMap(v.Lobby.MapHash)
+		client, connected := arcade.Server.Network.GetClient(playerID)
+
+		if ok && connected {
+			arcade.Server.Network.Send(client, NewMapShareMessage(v.Lobby.ID, m))
+		}
+	}()
+}
+
+// keepMap saves the lobby's map the host sent us.
+func (v *LobbyView) keepMap(m *TronMap) {
+	if err := keepSharedMap(m); err != nil {
+		log.Println("Couldn't keep the lobby's map:", err)
+		return
+	}
+
+	v.gotMap(m, "the host")
+}
+
+// shareMod sends the lobby's mod to a player, the first time we hear from
+// them. Only used by the host.
+func (v *LobbyView) shareMod(playerID string) {
+	v.Lock()
+	sent := v.modSent[playerID]
+	v.modSent[playerID] = true
+	v.Unlock()
+
+	if sent || v.Lobby.ModHash == "" {
+		return
+	}
+
+	go func() {
+		m, ok := findTronMod(v.Lobby.ModHash)
+		client, connected := arcade.Server.Network.GetClient(playerID)
+
+		if !ok || !connected {
+			return
+		}
+
+		for _, msg := range modShareMessages(v.Lobby.ID, m) {
+			arcade.Server.Network.Send(client, msg)
+		}
+	}()
+}
+
+// keepMod saves the lobby's mod the host sent us, and notes we have it.
+func (v *LobbyView) keepMod(m *TronMod) {
+	if err := keepSharedMod(m); err != nil {
+		log.Println("Couldn't keep the lobby's mod:", err)
+		return
+	}
+
+	v.Lock()
+	first := v.modHash == ""
+	v.modHash = m.Hash()
+	v.modParts = nil
+	v.Unlock()
+
+	if first {
+		notify("Got the mod %s from the host", m.Name)
+		v.mgr.RequestRender()
+	}
+}
+
+// downloadMap fetches the lobby's map from the workshop, if it's been
+// published there. Otherwise we wait for the host to send it.
+func (v *LobbyView) downloadMap() {
+	m, err := downloadMap(v.Lobby.MapHash)
+
+	if err != nil {
+		log.Println("Couldn't download the lobby's map:", err)
+		return
+	}
+
+	v.gotMap(m, "the workshop")
+}
+
+// gotMap notes we have the lobby's map, the first time.
+func (v *LobbyView) gotMap(m *TronMap, from string) {
+	v.Lock()
+	first := v.mapHash == ""
+	v.mapHash = m.Hash()
+	v.Unlock()
+
+	if first {
+		notify("Got the map %s from %s", m.Name, from)
+		v.mgr.RequestRender()
+	}
+}
+
+// joinCoach lets in a peer a player asked to coach them.
+func (v *LobbyView) joinCoach(from *net.Client, p *JoinMessage) *JoinReplyMessage {
+	key := connectionKey(from, p.Origin)
+
+	if !v.joinLimiter.Allowed(key) {
+		return NewJoinReplyMessage(&Lobby{}, ErrRateLimited)
+	} else if !v.Lobby.IsInvitedCoach(p.PlayerID, p.CoachFor) || !v.Lobby.HasPlayer(p.CoachFor) || v.Lobby.CoachOf(p.CoachFor) != "" {
+		v.joinLimiter.Failed(key)
+		return NewJoinReplyMessage(&Lobby{}, ErrWrongCode)
+	} else if !v.Lobby.verifyJoin(p) {
+		v.joinLimiter.Failed(key)
+		return NewJoinReplyMessage(&Lobby{}, ErrIdentity)
+	}
+
+	v.joinLimiter.Succeeded(key)
+	v.Lobby.AddCoach(p.PlayerID, p.CoachFor)
+	arcade.Server.BeginLobbyHeartbeats(p.PlayerID, v.Lobby.ID)
+
+	v.Lock()
+	v.updateCoaching()
+	v.Unlock()
+
+	v.Lobby.mu.RLock()
+	notify("%s is coaching %s", shortID(p.PlayerID, 8), v.Lobby.playerName(p.CoachFor))
+	v.Lobby.mu.RUnlock()
+
+	return NewJoinReplyMessage(v.Lobby, OK)
+}
+
+// admitStatus puts what a joining player told us about themselves on the
+// roster, the same as their heartbeats will.
+func (v *LobbyView) admitStatus(playerID string, status LobbyPlayerStatus) {
+	if status.LobbyID != v.Lobby.ID {
+		return
+	}
+
+	v.Lobby.SetPlayerStatus(playerID, false, false)
+	v.Lobby.SetPlayerProfile(playerID, status.Name, status.Avatar, status.Rank)
+	v.Lobby.SetPlayerRegion(playerID, status.Region)
+}
+
+// inviteCoach invites a peer to coach a player, who asked for them. Only
+// used by the host, since only the host can let them in.
+func (v *LobbyView) inviteCoach(coachID, playerID, playerName string) {
+	client, ok := arcade.Server.Network.GetClient(coachID)
+
+	if !ok || v.Lobby.HasPlayer(coachID) || v.Lobby.CoachOf(playerID) != "" {
+		return
+	}
+
+	hostName := ""
+
+	if profile, err := LoadProfile(); err == nil {
+		hostName = profile.Name
+	}
+
+	v.Lobby.InviteCoach(coachID, playerID)
+
+	invite := NewInviteMessage(v.Lobby, hostName)
+	invite.CoachFor = playerID
+	invite.PlayerName = playerName
+
+	go arcade.Server.Network.Send(client, invite)
+}
+
+// updateCoaching keeps the coach chat and the frames sent to our coach in
+// line with the lobby. Expects the lock to be held.
+func (v *LobbyView) updateCoaching() {
+	me := arcade.Server.ID
+	coachID := v.Lobby.CoachOf(me)
+	partner := coachID
+
+	if coached := v.Lobby.Coaching(me); coached != "" {
+		partner = coached
+	}
+
+	v.mgr.coach.setCoach(coachID)
+
+	if partner == v.coachPartner {
+		return
+	}
+
+	v.coachPartner = partner
+	v.coachChat = nil
+
+	if partner == "" {
+		return
+	}
+
+	// The player comes first, so they get the first player color
+	playerIDs := []string{me, partner}
+
+	if coachID == "" {
+		playerIDs = []string{partner, me}
+	}
+
+	v.coachChat = NewChatOverlay("coach:"+v.Lobby.ID, playerIDs)
+}
+
+// disband ends the lobby for everyone and goes back to the lobby list.
+func (v *LobbyView) disband() {
+	// first extract lobbyID for messages
+	v.Lobby.mu.RLock()
+	lobbyID := v.Lobby.ID
+	v.Lobby.mu.RUnlock()
+
+	arcade.Server.EndLobbyHeartbeats(lobbyID)
+	// send updates to everyone
+
+	arcade.Server.Network.ClientsRange(func(client *net.Client) bool {
+		if client.Distributor {
+			return true
+		}
+
+		arcade.Server.Network.Send(client, NewLobbyEndMessage(lobbyID))
+
+		return true
+	})
+
+	v.backToBrowser()
+}
+
+// leave leaves the lobby, or for the host, asks to disband it.
+func (v *LobbyView) leave() {
+	v.Lobby.mu.RLock()
+	if v.Lobby.HostID != arcade.Server.ID {
+		// not the host, just leave the game
+		hostID, lobbyID := v.Lobby.HostID, v.Lobby.ID
+		v.Lobby.mu.RUnlock()
+
+		arcade.Engine.Leave(hostID, lobbyID)
+		v.backToBrowser()
+		return
+	}
+	v.Lobby.mu.RUnlock()
+
+	// Leaving as the host ends the lobby for everyone
+	v.mgr.ShowModal(widgets.NewModal("Leave lobby", []string{
+		"You are the host, so leaving will",
+		"disband the lobby for everyone.",
+	}, []string{"Stay", "Disband"}, func(choice int) {
+		if choice == 1 {
+			v.disband()
+		}
+	}))
+}
+
+// Back closes an open picker, or leaves the lobby properly rather than just
+// dropping the view.
+func (v *LobbyView) Back() bool {
+	v.Lock()
+	picking := v.inviting || v.managing
+	v.inviting = false
+	v.managing = false
+	v.Unlock()
+
+	if picking {
+		v.mgr.RequestRender()
+	} else {
+		v.leave()
+	}
+
+	return true
+}
+
+// backToBrowser returns to the lobby list the lobby was opened from, or a new
+// one if it was opened some other way.
+func (v *LobbyView) backToBrowser() {
+	if !v.mgr.PopView() {
+		v.mgr.SetView(NewGamesListView(v.mgr))
+	}
+}
+
+// kick removes a player from the lobby after asking the host to confirm.
+func (v *LobbyView) kick(playerID string) {
+	v.mgr.ShowModal(widgets.NewModal("Kick player", []string{
+		fmt.Sprintf("Remove %s from the lobby?", shortID(playerID, 8)),
+	}, []string{"Cancel", "Kick"}, func(choice int) {
+		if choice != 1 || !v.Lobby.HasPlayer(playerID) {
+			return
+		}
+
+		v.Lobby.RemovePlayer(playerID)
+		arcade.Server.EndHeartbeats(playerID)
+
+		if client, ok := arcade.Server.Network.GetClient(playerID); ok {
+			go arcade.Server.Network.Send(client, NewKickMessage(v.Lobby.ID))
+		}
+
+		go v.broadcastLobbyUpdate()
+	}))
+}
+
+// notifyPlayerChanges shows who joined or left between two copies of a lobby.
+func notifyPlayerChanges(previous, current *Lobby) {
+	previous.mu.RLock()
+	before := append([]string(nil), previous.PlayerIDs...)
+	previous.mu.RUnlock()
+
+	// Nothing to compare against before the first heartbeat
+	if len(before) == 0 {
+		return
+	}
+
+	for _, id := range before {
+		if id != arcade.Server.ID && !current.HasPlayer(id) {
+			notify("%s left the lobby", id[:8])
+		}
+	}
+
+	for _, id := range current.PlayerIDs {
+		if id != arcade.Server.ID && !previous.HasPlayer(id) {
+			notify("%s joined the lobby", id[:8])
+		}
+	}
+}
+
+// openInvitePicker lists connected peers who aren't already in the lobby, to
+// invite as players or as our coach.
+func (v *LobbyView) openInvitePicker(coach bool) {
+	peers := make([]string, 0)
+
+	arcade.Server.Network.ClientsRange(func(client *net.Client) bool {
+		client.RLock()
+		defer client.RUnlock()
+
+		if client.State != net.Connected || client.Distributor || v.Lobby.HasPlayer(client.ID) || v.Lobby.Coaching(client.ID) != "" || IsBlocked(client.ID) {
+			return true
+		}
+
+		peers = append(peers, client.ID)
+		return true
+	})
+
+	sort.Strings(peers)
+
+	v.Lock()
+	v.inviting = true
+	v.coachPicker = coach
+	v.invitePeers = peers
+	v.pickerFocus = widgets.NewFocusGroup(v.inviteList)
+
+	if v.inviteSent == nil {
+		v.inviteSent = make(map[string]bool)
+	}
+
+	v.updateInviteList()
+	v.Unlock()
+
+	v.inviteList.Select(0)
+}
+
+// updateInviteList refreshes the invite picker rows. Expects the lock to be
+// held.
+func (v *LobbyView) updateInviteList() {
+	rows := make([]string, len(v.invitePeers))
+
+	for i, peerID := range v.invitePeers {
+		rows[i] = " " + shortID(peerID, 8)
+
+		if v.inviteSent[peerID] {
+			rows[i] += "  (invited)"
+		}
+	}
+
+	v.inviteList.SetItems(rows)
+}
+
+// sendInvite invites the peer at the given row of the invite picker.
+func (v *LobbyView) sendInvite(index int) {
+	v.Lock()
+	defer v.Unlock()
+
+	if index < 0 || index >= len(v.invitePeers) {
+		return
+	}
+
+	peerID := v.invitePeers[index]
+	client, ok := arcade.Server.Network.GetClient(peerID)
+
+	if !ok {
+		return
+	}
+
+	// Our name, which the invite is from
+	name := ""
+
+	if profile, err := LoadProfile(); err == nil {
+		name = profile.Name
+	}
+
+	v.inviteSent[peerID] = true
+	v.updateInviteList()
+
+	if v.coachPicker {
+		// Only the host can let a coach in, so the host sends the invite
+		if v.Lobby.HostID == arcade.Server.ID {
+			go v.inviteCoach(peerID, arcade.Server.ID, name)
+		} else if host, ok := arcade.Server.Network.GetClient(v.Lobby.HostID); ok {
+			go arcade.Server.Network.Send(host, NewCoachRequestMessage(v.Lobby.ID, peerID, name))
+		}
+
+		return
+	}
+
+	v.Lobby.Invite(peerID)
+
+	go arcade.Server.Network.Send(client, NewInviteMessage(v.Lobby, name))
+}
+
+func (v *LobbyView) processInviteEvent(evt *tcell.EventKey) {
+	switch lobbyKeymap.Action(evt) {
+	case ActionInvite, ActionCoach:
+		v.Lock()
+		v.inviting = false
+		v.Unlock()
+	}
+}
+
+func (v *LobbyView) renderInvitePicker(s *Screen) {
+	width, _ := s.displaySize()
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+
+	s.DrawEmpty(lvPickerX1, lvPickerY1, lvPickerX2, lvPickerY2, sty)
+	s.DrawBox(lvPickerX1, lvPickerY1, lvPickerX2, lvPickerY2, sty, false)
+
+	header := " Invite a player - [I] to close "
+
+	if v.coachPicker {
+		header = " Invite a coach - [H] to close "
+	}
+	s.DrawText(layout.Center(width, header), lvPickerY1, sty, header)
+
+	v.inviteList.Render(s)
+}
+
+// otherPlayers returns everyone in the lobby except us. Expects the lobby lock
+// to be held.
+func (v *LobbyView) otherPlayers() []string {
+	players := make([]string, 0, len(v.Lobby.PlayerIDs))
+
+	for _, id := range v.Lobby.PlayerIDs {
+		if id != arcade.Server.ID {
+			players = append(players, id)
+		}
+	}
+
+	return players
+}
+
+// updatePlayerList refreshes the player picker rows. Expects the lobby lock to
+// be held.
+func (v *LobbyView) updatePlayerList() {
+	players := v.otherPlayers()
+	rows := make([]string, len(players))
+
+	for i, playerID := range players {
+		name := v.Lobby.playerName(playerID)
+		row := " " + layout.Truncate(name, 16)
+
+		if playerID == v.Lobby.HostID {
+			row += " (host)"
+		} else if v.Lobby.Ready[playerID] {
+			row += " ready"
+		}
+
+		if v.Lobby.Away[playerID] {
+			row += " away"
+		} else if v.Lobby.Idle[playerID] {
+			row += " idle"
+		}
+
+		if IsBlocked(playerID) {
+			row += "  blocked"
+		} else if IsMuted(playerID) {
+			row += "  muted"
+		}
+
+		rows[i] = row
+	}
+
+	v.playerList.SetItems(rows)
+}
+
+func (v *LobbyView) processPlayersEvent(evt *tcell.EventKey) {
+	v.Lobby.mu.RLock()
+	players := v.otherPlayers()
+	v.Lobby.mu.RUnlock()
+
+	i := v.playerList.Selected()
+
+	switch lobbyKeymap.Action(evt) {
+	case ActionPlayers:
+		v.Lock()
+		v.managing = false
+		v.Unlock()
+	case ActionMute:
+		if i >= 0 && i < len(players) {
+			SetMuted(players[i], !IsMuted(players[i]))
+		}
+	case ActionBlock:
+		if i >= 0 && i < len(players) {
+			SetBlocked(players[i], !IsBlocked(players[i]))
+		}
+	case ActionKick:
+		if i >= 0 && i < len(players) && v.Lobby.HostID == arcade.Server.ID {
+			v.kick(players[i])
+		}
+	}
+}
+
+// renderPlayers draws the mute and block list. Expects the lobby lock to be
+// held.
+func (v *LobbyView) renderPlayers(s *Screen) {
+	width, _ := s.displaySize()
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+
+	s.DrawEmpty(lvPickerX1, lvPickerY1, lvPickerX2, lvPickerY2, sty)
+	s.DrawBox(lvPickerX1, lvPickerY1, lvPickerX2, lvPickerY2, sty, false)
+
+	header := " [M]ute  [B]lock  [P] to close "
+
+	if v.Lobby.HostID == arcade.Server.ID {
+		header = " [M]ute  [B]lock  [K]ick  [P] close "
+	}
+	s.DrawText(layout.Center(width, header), lvPickerY1, sty, header)
+
+	v.updatePlayerList()
+	v.playerList.Render(s)
+}
+
 func (v *LobbyView) Render(s *Screen) {
 	v.Lobby.mu.Lock()
 	defer v.Lobby.mu.Unlock()
@@ -194,7 +1105,9 @@ func (v *LobbyView) Render(s *Screen) {
 	sty_bold := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorDarkGreen)
 
 	// Draw GAME header
-	s.DrawBlockText(CenterX, 1, sty, "TRON", false)
+	if v.Lobby.GameType != "" {
+		s.DrawBlockText(CenterX, 1, sty, strings.ToUpper(v.Lobby.GameType), false)
+	}
 
 	// Draw box surrounding games list
 	s.DrawBox(lv_TableX1, lv_TableY1, lv_TableX2, lv_TableY2, sty, true)
@@ -203,9 +1116,9 @@ func (v *LobbyView) Render(s *Screen) {
 
 	// name
 	nameHeader := "Name: "
-	nameString := v.Lobby.Name
-	s.DrawText((width-len(nameHeader+nameString))/2, lv_TableY1+1, sty, nameHeader)
-	s.DrawText((width-len(nameHeader+nameString))/2+utf8.RuneCountInString(nameHeader), lv_TableY1+1, sty_bold, nameString)
+	nameString := layout.Truncate(v.Lobby.Name, tableWidth-layout.Width(nameHeader)-4)
+	s.DrawText(layout.Center(width, nameHeader+nameString), lv_TableY1+1, sty, nameHeader)
+	s.DrawText(layout.Center(width, nameHeader+nameString)+layout.Width(nameHeader), lv_TableY1+1, sty_bold, nameString)
 
 	// private
 	privateHeader := "Visibility: "
@@ -213,31 +1126,391 @@ func (v *LobbyView) Render(s *Screen) {
 	if v.Lobby.Private {
 		privateString = "private, Join Code: " + v.Lobby.Code
 	}
-	s.DrawText((width-len(privateHeader+privateString))/2, lv_TableY1+2, sty, privateHeader)
-	s.DrawText((width-len(privateHeader+privateString))/2+utf8.RuneCountInString(privateHeader), lv_TableY1+2, sty_bold, privateString)
+	if v.Lobby.HasPassword {
+		privateString += ", password"
+	}
+	s.DrawText(layout.Center(width, privateHeader+privateString), lv_TableY1+2, sty, privateHeader)
+	s.DrawText(layout.Center(width, privateHeader+privateString)+layout.Width(privateHeader), lv_TableY1+2, sty_bold, privateString)
 
 	// capacity
 	capacityHeader := "Game capacity: "
 	capacityString := fmt.Sprintf("(%v/%v)", len(v.Lobby.PlayerIDs), v.Lobby.Capacity)
-	s.DrawText((width-len(capacityHeader+capacityString))/2, lv_TableY1+3, sty, capacityHeader)
-	s.DrawText((width-len(capacityHeader+capacityString))/2+utf8.RuneCountInString(capacityHeader), lv_TableY1+3, sty_bold, capacityString)
+	s.DrawText(layout.Center(width, capacityHeader+capacityString), lv_TableY1+3, sty, capacityHeader)
+	s.DrawText(layout.Center(width, capacityHeader+capacityString)+layout.Width(capacityHeader), lv_TableY1+3, sty_bold, capacityString)
+
+	// obstacles
+	if v.Lobby.GameType == Pong && v.Lobby.Obstacles {
+		obstaclesString := "Moving obstacles enabled"
+		s.DrawText(layout.Center(width, obstaclesString), lv_TableY1+4, sty, obstaclesString)
+	}
+
+	// custom map and mod
+	var extras []string
+
+	v.RLock()
+	missingMap := v.mapHash != v.Lobby.MapHash
+	missingMod := v.modHash != v.Lobby.ModHash
+	v.RUnlock()
+
+	if v.Lobby.MapHash != "" {
+		mapString := "Map: " + v.Lobby.MapName
+
+		if missingMap {
+			mapString += " (waiting for it)"
+		}
+
+		extras = append(extras, mapString)
+	}
+
+	if v.Lobby.ModHash != "" {
+		modString := "Mod: " + v.Lobby.ModName
+
+		if missingMod {
+			modString += " (waiting for it)"
+		}
+
+		extras = append(extras, modString)
+	}
+
+	if len(extras) > 0 {
+		extrasString := strings.Join(extras, "   ")
+		s.DrawText(layout.Center(width, extrasString), lv_TableY1+4, sty, extrasString)
+	}
+
+	// time limit and shrinking arena
+	var rules []string
+
+	if v.Lobby.TimeLimit > 0 {
+		rules = append(rules, fmt.Sprintf("%d min, then sudden death", v.Lobby.TimeLimit/60))
+	}
+
+	if v.Lobby.GameType == Tron && v.Lobby.ShrinkArena {
+		rules = append(rules, "arena shrinks")
+	}
+
+	if len(rules) > 0 {
+		rulesHeader := "Rules: "
+		rulesString := strings.Join(rules, ", ")
+		s.DrawText(layout.Center(width, rulesHeader+rulesString), lv_TableY1+7, sty, rulesHeader)
+		s.DrawText(layout.Center(width, rulesHeader+rulesString)+layout.Width(rulesHeader), lv_TableY1+7, sty_bold, rulesString)
+	}
+
+	// ready and idle players
+	readyCount, idleCount, awayCount := 0, 0, 0
+
+	for _, playerID := range v.Lobby.PlayerIDs {
+		if playerID == v.Lobby.HostID {
+			continue
+		}
+
+		if v.Lobby.Ready[playerID] {
+			readyCount++
+		}
+
+		if v.Lobby.Away[playerID] {
+			awayCount++
+		} else if v.Lobby.Idle[playerID] {
+			idleCount++
+		}
+	}
+
+	statusString := fmt.Sprintf("%d/%d players ready", readyCount, len(v.Lobby.PlayerIDs)-1)
+
+	if idleCount > 0 {
+		statusString += fmt.Sprintf(", %d idle", idleCount)
+	}
+
+	if awayCount > 0 {
+		statusString += fmt.Sprintf(", %d away", awayCount)
+	}
+
+	s.DrawEmpty(lv_TableX1+1, lv_TableY1+6, lv_TableX2-1, lv_TableY1+6, sty)
+	s.DrawText(layout.Center(width, statusString), lv_TableY1+6, sty, statusString)
 
 	// Draw footer with navigation keystrokes
+	s.DrawEmpty(1, height-2, width-2, height-2, sty)
+	footer := 0
+
+	if v.Lobby.coachOf(arcade.Server.ID) != "" {
+		footer = 1
+	}
+
 	if arcade.Server.ID == v.Lobby.HostID {
 		// I am host so I should see start game controls
 		hostLabelString := "You are the host."
-		s.DrawText((width-len(hostLabelString))/2, lv_TableY1+5, sty, hostLabelString)
-		s.DrawText((width-len(lobby_footer_host[0]))/2, height-2, sty, lobby_footer_host[0])
+		s.DrawText(layout.Center(width, hostLabelString), lv_TableY1+5, sty, hostLabelString)
+		s.DrawText(layout.Center(width, lobby_footer_host[footer]), height-2, sty, lobby_footer_host[footer])
+	} else if coached := v.Lobby.Coaches[arcade.Server.ID]; coached != "" {
+		coachLabelString := fmt.Sprintf("You are coaching %s.", v.Lobby.playerName(coached))
+		s.DrawText(layout.Center(width, coachLabelString), lv_TableY1+5, sty, coachLabelString)
+		s.DrawText(layout.Center(width, lobby_footer_coach[0]), height-2, sty, lobby_footer_coach[0])
 	} else {
 		participantLabelString := "Waiting for host to start game..."
-		s.DrawText((width-len(participantLabelString))/2, lv_TableY1+5, sty, participantLabelString)
-		s.DrawText((width-len(lobby_footer_nonhost[0]))/2, height-2, sty, lobby_footer_nonhost[0])
+		s.DrawText(layout.Center(width, participantLabelString), lv_TableY1+5, sty, participantLabelString)
+		s.DrawText(layout.Center(width, lobby_footer_nonhost[footer]), height-2, sty, lobby_footer_nonhost[footer])
+	}
+
+	v.RLock()
+	if v.managing {
+		v.renderPlayers(s)
+	} else if v.inviting {
+		v.renderInvitePicker(s)
+	} else {
+		v.renderRoster(s)
+	}
+	v.RUnlock()
+
+	v.renderAvatars(s)
+
+	v.RLock()
+	if v.coachChat != nil {
+		v.coachChat.Render(s)
+	}
+	v.RUnlock()
+}
+
+// Roster columns, from the left of the picker box
+const (
+	lvRosterRows    = lvPickerY2 - lvPickerY1 - 1
+	lvRosterNameX   = 6
+	lvRosterNameW   = 18
+	lvRosterStatusX = 26
+	lvRosterRTTX    = 34
+)
+
+// scrollRoster moves the roster up or down a row.
+func (v *LobbyView) scrollRoster(key tcell.Key) {
+	v.Lobby.mu.RLock()
+	players := len(v.Lobby.PlayerIDs) + len(v.Lobby.Coaches)
+	v.Lobby.mu.RUnlock()
+
+	v.Lock()
+	defer v.Unlock()
+
+	switch key {
+	case tcell.KeyUp:
+		v.rosterOffset--
+	case tcell.KeyDown:
+		v.rosterOffset++
+	}
+
+	if v.rosterOffset > players-lvRosterRows {
+		v.rosterOffset = players - lvRosterRows
+	}
+
+	if v.rosterOffset < 0 {
+		v.rosterOffset = 0
+	}
+}
+
+// renderRoster draws everyone in the lobby with their color, name, state and
+// round trip time to the host. Expects the lobby lock and a read lock to be
+// held.
+func (v *LobbyView) renderRoster(s *Screen) {
+	width, _ := s.displaySize()
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	mutedSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorDarkGreen)
+	hostSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorYellow)
+
+	s.DrawEmpty(lvPickerX1, lvPickerY1, lvPickerX2, lvPickerY2, sty)
+	s.DrawBox(lvPickerX1, lvPickerY1, lvPickerX2, lvPickerY2, sty, false)
+
+	header := fmt.Sprintf(" Players (%d/%d) ", len(v.Lobby.PlayerIDs), v.Lobby.Capacity)
+	s.DrawText(layout.Center(width, header), lvPickerY1, sty, header)
+
+	// Coaches are listed after the players
+	entries := append(append([]string(nil), v.Lobby.PlayerIDs...), v.Lobby.coachIDs()...)
+
+	// The lobby can shrink between scrolling and drawing
+	offset := v.rosterOffset
+
+	if offset > len(entries)-lvRosterRows {
+		offset = len(entries) - lvRosterRows
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	for row := 0; row < lvRosterRows && offset+row < len(entries); row++ {
+		i := offset + row
+		playerID := entries[i]
+		y := lvPickerY1 + 1 + row
+
+		if playerID == v.Lobby.HostID {
+			s.DrawText(lvPickerX1+2, y, hostSty, "♛")
+		}
+
+		// Coaches are shaded in their player's color
+		coached := v.Lobby.Coaches[playerID]
+		swatch := "██"
+
+		if coached != "" {
+			i, swatch = -1, "░░"
+
+			for j, id := range v.Lobby.PlayerIDs {
+				if id == coached {
+					i = j
+				}
+			}
+		}
+
+		if i >= 0 && i < len(TRON_COLORS) {
+			colorSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorNames[TRON_COLORS[i]])
+			s.DrawText(lvPickerX1+3, y, colorSty, swatch)
+		}
+
+		name := v.Lobby.playerName(playerID)
+		nameSty := sty
+
+		if playerID == arcade.Server.ID {
+			name += " (you)"
+			nameSty = nameSty.Bold(true)
+		}
+
+		if badge := v.Lobby.Ranks[playerID]; badge != "" {
+			name = badge + " " + name
+		}
+
+		// Players from far away are the likely cause of lag
+		if region := v.Lobby.Regions[playerID]; !sameRegion(region, v.Lobby.Region) {
+			name = "[" + region + "] " + name
+		}
+
+		s.DrawText(lvPickerX1+lvRosterNameX, y, nameSty, layout.Pad(name, lvRosterNameW))
+
+		status, statusSty := "waiting", mutedSty
+
+		switch {
+		case coached != "":
+			status, statusSty = "coach", sty
+		case playerID == v.Lobby.HostID:
+			status, statusSty = "host", hostSty
+		case v.Lobby.Away[playerID]:
+			status = "away"
+		case v.Lobby.Idle[playerID]:
+			status = "idle"
+		case v.Lobby.Ready[playerID]:
+			status, statusSty = "ready", sty
+		}
+
+		s.DrawText(lvPickerX1+lvRosterStatusX, y, statusSty, status)
+
+		if rtt, ok := v.Lobby.RTTs[playerID]; ok && playerID != v.Lobby.HostID {
+			s.DrawText(lvPickerX1+lvRosterRTTX, y, mutedSty, fmt.Sprintf("%dms", rtt))
+		}
 	}
 
+	// Show that there's more above or below
+	if offset > 0 {
+		s.DrawText(lvPickerX2-1, lvPickerY1+1, sty, "▲")
+	}
+
+	if offset+lvRosterRows < len(entries) {
+		s.DrawText(lvPickerX2-1, lvPickerY2-1, sty, "▼")
+	}
+}
+
+// Stickmen stand either side of the lobby table, in rows of two
+const (
+	lvAvatarLeftX  = 10
+	lvAvatarRightX = 70
+	lvAvatarY      = 4
+	lvAvatarRowH   = avatarHeight + 1
+	lvAvatarNameW  = 16
+)
+
+// renderAvatars draws each player as a stickman in their color: waving when
+// they've just arrived, cheering when they're ready, otherwise fidgeting.
+// Expects the lobby lock to be held.
+func (v *LobbyView) renderAvatars(s *Screen) {
+	nameSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	now := time.Now()
+
+	v.arrivedMu.Lock()
+	defer v.arrivedMu.Unlock()
+
+	for i, playerID := range v.Lobby.PlayerIDs {
+		if i >= len(TRON_COLORS) {
+			break
+		}
+
+		arrived, ok := v.arrived[playerID]
+
+		if !ok {
+			arrived = now
+			v.arrived[playerID] = now
+		}
+
+		pose, since := AvatarIdle, now.Sub(v.opened)
+
+		switch {
+		case now.Sub(arrived) < avatarWaveTime:
+			pose, since = AvatarWave, now.Sub(arrived)
+		case v.Lobby.Ready[playerID] && playerID != v.Lobby.HostID:
+			pose = AvatarReady
+		}
+
+		// Keeps everyone from fidgeting in step
+		since += time.Duration(i) * avatarFrameTime * 3 / 2
+
+		x := lvAvatarLeftX
+
+		if i%2 == 1 {
+			x = lvAvatarRightX
+		}
+
+		y := lvAvatarY + i/2*lvAvatarRowH
+		sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorNames[TRON_COLORS[i]])
+
+		for row, line := range findAvatar(v.Lobby.Avatars[playerID]).Frame(pose, since) {
+			s.DrawText(x-avatarWidth/2, y+row, sty, line)
+		}
+
+		name := v.Lobby.playerName(playerID)
+		name = layout.Truncate(name, lvAvatarNameW)
+		s.DrawText(x-layout.Width(name)/2, y+avatarHeight, nameSty, name)
+	}
+
+	// Forget anyone who left, so they wave again if they come back
+	for playerID := range v.arrived {
+		if !v.Lobby.hasPlayer(playerID) {
+			delete(v.arrived, playerID)
+		}
+	}
+}
+
+func (v *LobbyView) AnnotateHeartbeat(md *HeartbeatMetadata) {
+	v.Lobby.mu.RLock()
+	defer v.Lobby.mu.RUnlock()
+
+	md.Lobby = v.Lobby.summary()
+	md.Players = len(v.Lobby.PlayerIDs)
+}
+
+func (v *LobbyView) StatusInfo() StatusInfo {
+	v.Lobby.mu.RLock()
+	defer v.Lobby.mu.RUnlock()
+
+	return StatusInfo{LobbyName: v.Lobby.Name, HostID: v.Lobby.HostID}
 }
 
 func (v *LobbyView) Unload() {
+	close(v.stopTickerCh)
+
 	if v.Lobby.HostID == arcade.Server.ID {
+		arcade.Server.StopHostingLobby(v.Lobby.ID)
+
+		// The lobby closes unless it's making way for its game, which
+		// finishes it once it ends
+		v.Lobby.mu.RLock()
+		inGame := v.Lobby.State == LobbyInGame
+		v.Lobby.mu.RUnlock()
+
+		if !inGame {
+			announceLobbyState(v.Lobby, LobbyClosed)
+		}
+
 		// send to all the players, similar to 'c'
 		lobbyID := v.Lobby.ID
 
@@ -260,9 +1533,46 @@ func (v *LobbyView) Unload() {
 	}
 }
 
-func (v *LobbyView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+// HostedLobby returns the lobby, for when we're hosting it.
+func (v *LobbyView) HostedLobby() *Lobby {
 	v.RLock()
 	defer v.RUnlock()
 
 	return v.Lobby
 }
+
+func (v *LobbyView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	v.Lock()
+	defer v.Unlock()
+
+	v.Lobby.mu.RLock()
+	hostID := v.Lobby.HostID
+	idle := v.Lobby.IdleTimeout > 0 && v.mgr.IdleFor() > time.Duration(v.Lobby.IdleTimeout)*time.Second
+
+	if idle && v.Lobby.AutoUnready {
+		v.ready = false
+	}
+	v.Lobby.mu.RUnlock()
+
+	v.updateCoaching()
+
+	if hostID == arcade.Server.ID {
+		v.Lobby.SetPlayerStatus(hostID, true, idle)
+		v.Lobby.SetPlayerAway(hostID, v.mgr.Away())
+		v.Lobby.SetPlayerProfile(hostID, v.name, v.avatar, v.mgr.seasonBadge())
+		v.Lobby.SetPlayerRegion(hostID, v.mgr.Region())
+		v.Lobby.updateRTTs()
+		return v.Lobby
+	}
+
+	// Players tell the host whether they're ready instead of echoing the lobby
+	return LobbyPlayerStatus{
+		LobbyID: v.Lobby.ID,
+		Ready:   v.ready,
+		Idle:    idle,
+		Name:    v.name,
+		Avatar:  v.avatar,
+		Rank:    v.mgr.seasonBadge(),
+		Region:  v.mgr.Region(),
+	}
+}
@@ -0,0 +1,493 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"encoding"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// localGame is a game played entirely on this machine, stepped by
+// LocalGameView. Players are numbered from 0.
+type localGame interface {
+	// Input moves a player
+	Input(player int, action Action)
+	Step()
+
+	// BotMove returns what a bot playing as the player does this tick, or ""
+	// for nothing
+	BotMove(player int, skill BotSkill) Action
+
+	// Ended returns true once the game is over, with the winner, or -1 if
+	// nobody won
+	Ended() (bool, int)
+
+	TickPeriod() time.Duration
+	Render(s *Screen)
+}
+
+// challengeGame is a local game that can be a daily challenge, scored so
+// everyone playing it can be ranked.
+type challengeGame interface {
+	// ChallengeScore returns the player's score, higher being better
+	ChallengeScore(player int) int
+}
+
+// localGameType is a game that can be played locally. Everything random in
+// the game is drawn from rng, so games with the same seed play out the same.
+type localGameType struct {
+	New func(mgr *ViewManager, players int, rng *MatchRNG) localGame
+
+	// Players in a practice game, counting the player
+	PracticePlayers int
+
+	// Steps of the game's tutorial, played against a bot
+	Tutorial []tutorialStep
+
+	// Whether holding a key keeps moving, rather than each press moving once
+	HoldKeys bool
+}
+
+// localGameTypes are the games that can be played without a network, by
+// name.
+var localGameTypes = map[string]localGameType{
+	Pong: {
+		New:             func(mgr *ViewManager, players int, rng *MatchRNG) localGame { return newLocalPong(players, rng) },
+		PracticePlayers: 2,
+		Tutorial:        pongTutorial,
+		HoldKeys:        true,
+	},
+	Tron: {
+		New:             func(mgr *ViewManager, players int, rng *MatchRNG) localGame { return newLocalTron(mgr, players, rng) },
+		PracticePlayers: 4,
+		Tutorial:        tronTutorial,
+	},
+}
+
+// localGameNames returns the names of the local games, in order.
+func localGameNames() []string {
+	names := make([]string, 0, len(localGameTypes))
+
+	for name := range localGameTypes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// localController plays one player: either someone on the keyboard, with
+// their keymap, or a bot.
+type localController struct {
+	keymap *Keymap
+	bot    *BotSkill
+
+	// Set for players on the keyboard in games where keys are held down
+	hold *KeyHold
+}
+
+type localRenderState int
+
+const (
+	localCountdown localRenderState = iota
+	localPlaying
+	localGameOver
+)
+
+var localGameOverText = "Press [Enter] to go back"
+
+// LocalGameView plays a game between players sharing the keyboard and bots,
+// with no networking. Each key goes to the player whose keymap it's in.
+type LocalGameView struct {
+	View
+	mgr *ViewManager
+
+	mu           sync.RWMutex
+	gameType     string
+	game         localGame
+	players      []localController
+	renderState  localRenderState
+	countdownNum int
+	stopTickerCh chan bool
+
+	// Set when playing the daily challenge, with our score once it's over
+	challenge      *DailyChallenge
+	challengeScore int
+}
+
+// NewLocalGameView starts a game between players sharing the keyboard.
+func NewLocalGameView(mgr *ViewManager, gameType string) *LocalGameView {
+	players := make([]localController, len(localKeymaps))
+
+	for i, km := range localKeymaps {
+		players[i] = localController{keymap: km}
+	}
+
+	return newLocalGameView(mgr, gameType, players, NewMatchRNG())
+}
+
+// NewPracticeView starts a game against bots, played with the usual game keys.
+func NewPracticeView(mgr *ViewManager, gameType string, skill BotSkill) *LocalGameView {
+	skill.Adapt = botAdaptation(mgr.Config(), skill)
+	return newLocalGameView(mgr, gameType, practicePlayers(gameType, skill), NewMatchRNG())
+}
+
+// NewChallengeView plays the daily challenge, sending our score to the
+// distributor when it's over.
+func NewChallengeView(mgr *ViewManager, challenge DailyChallenge, skill BotSkill) *LocalGameView {
+	rng, err := NewSeededMatchRNG(challenge.Seed)
+
+	// Everyone plays the same bots, however they're doing
+	skill.Adapt = nil
+
+	if err != nil {
+		log.Println("Bad challenge seed, playing a random game:", err)
+		rng = NewMatchRNG()
+	}
+
+	v := newLocalGameView(mgr, challenge.GameType, practicePlayers(challenge.GameType, skill), rng)
+	v.challenge = &challenge
+
+	return v
+}
+
+// practicePlayers is the player on the usual game keys, and enough bots to
+// fill a practice game.
+func practicePlayers(gameType string, skill BotSkill) []localController {
+	players := []localController{{keymap: gameKeymap}}
+
+	for len(players) < localGameTypes[gameType].PracticePlayers {
+		players = append(players, localController{bot: &skill})
+	}
+
+	return players
+}
+
+func newLocalGameView(mgr *ViewManager, gameType string, players []localController, rng *MatchRNG) *LocalGameView {
+	for i := range players {
+		if players[i].keymap != nil && localGameTypes[gameType].HoldKeys {
+			players[i].hold = NewKeyHold(mgr.Config())
+		}
+	}
+
+	return &LocalGameView{
+		mgr:          mgr,
+		gameType:     gameType,
+		game:         localGameTypes[gameType].New(mgr, len(players), rng),
+		players:      players,
+		countdownNum: 3,
+		stopTickerCh: make(chan bool),
+	}
+}
+
+func (v *LocalGameView) Init() {
+	go func() {
+		defer v.mgr.recoverCrash()
+
+		for i := 3; i > 0; i-- {
+			v.mu.Lock()
+			v.countdownNum = i
+			v.mu.Unlock()
+
+			playSound(SoundCountdown)
+			announce("Game starting in %d", i)
+			v.mgr.RequestRender()
+
+			select {
+			case <-time.After(time.Second):
+			case <-v.stopTickerCh:
+				return
+			}
+		}
+
+		v.mu.Lock()
+		v.renderState = localPlaying
+		v.mu.Unlock()
+
+		v.run()
+	}()
+}
+
+// run steps the game until it's over or the view is closed.
+func (v *LocalGameView) run() {
+	ticker := time.NewTicker(v.game.TickPeriod())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			v.mu.Lock()
+			differential := v.scoreDifferential()
+
+			for i, player := range v.players {
+				switch {
+				case player.hold != nil:
+					if action := player.hold.Tick(now); action != "" {
+						v.game.Input(i, action)
+					}
+				case player.bot != nil:
+					if action := v.game.BotMove(i, player.bot.adapted(differential)); action != "" {
+						v.game.Input(i, action)
+					}
+				}
+			}
+
+			v.game.Step()
+			ended, winner := v.game.Ended()
+
+			if ended {
+				v.renderState = localGameOver
+
+				if scorer, ok := v.game.(challengeGame); ok && v.challenge != nil {
+					v.challengeScore = scorer.ChallengeScore(0)
+					go submitChallengeScore(*v.challenge, v.challengeScore)
+				}
+			}
+			v.mu.Unlock()
+
+			v.mgr.RequestRender()
+
+			if ended {
+				announce("Game over, %s", v.result(winner))
+
+				return
+			}
+		case <-v.stopTickerCh:
+			return
+		}
+	}
+}
+
+// scoreDifferential returns how far the player on the keyboard is ahead of
+// the bots, for bots to adapt to, or 0 if there isn't just one player on the
+// keyboard. Expects the lock to be held.
+func (v *LocalGameView) scoreDifferential() int {
+	scorer, ok := v.game.(scoredGame)
+	human := -1
+
+	for i, player := range v.players {
+		if player.bot != nil {
+			continue
+		}
+
+		if human >= 0 {
+			return 0
+		}
+
+		human = i
+	}
+
+	if !ok || human < 0 {
+		return 0
+	}
+
+	return scorer.ScoreDifferential(human)
+}
+
+// result says who won, to someone on the keyboard.
+func (v *LocalGameView) result(winner int) string {
+	humans := 0
+
+	for _, player := range v.players {
+		if player.bot == nil {
+			humans++
+		}
+	}
+
+	switch {
+	case humans == 1 && winner >= 0 && v.players[winner].bot == nil:
+		return "you won"
+	case humans == 1:
+		return "you lost"
+	case winner >= 0:
+		return fmt.Sprintf("player %d won", winner+1)
+	}
+
+	return "nobody won"
+}
+
+// playerForKey returns the player the key belongs to and what it does, or -1
+// if it isn't anyone's.
+func (v *LocalGameView) playerForKey(evt *tcell.EventKey) (int, Action) {
+	for i, player := range v.players {
+		if player.keymap == nil {
+			continue
+		}
+
+		if action := player.keymap.Action(evt); action != "" {
+			return i, action
+		}
+	}
+
+	return -1, ""
+}
+
+func (v *LocalGameView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		v.mu.Lock()
+		state := v.renderState
+
+		if state == localPlaying {
+			if player, action := v.playerForKey(evt); player >= 0 {
+				if hold := v.players[player].hold; hold == nil || !isMoveAction(action) || hold.Press(action, time.Now()) {
+					v.game.Input(player, action)
+				}
+			}
+		}
+		v.mu.Unlock()
+
+		if state == localGameOver && evt.Key() == tcell.KeyEnter {
+			v.mgr.PopView()
+			return
+		}
+
+		v.mgr.RequestRender()
+	}
+}
+
+// Keymap lists every player's keys together.
+func (v *LocalGameView) Keymap() *Keymap {
+	km := &Keymap{ID: "local", Name: "Local play"}
+
+	for i, player := range v.players {
+		if player.keymap == nil {
+			continue
+		}
+
+		km.Bindings = append(km.Bindings, KeyBinding{
+			Action: Action(fmt.Sprintf("p%d", i+1)),
+			Keys:   movementKeys(player.keymap),
+			Help:   fmt.Sprintf("Move player %d", i+1),
+		})
+	}
+
+	return km
+}
+
+func (v *LocalGameView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *LocalGameView) Render(s *Screen) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if panes := v.splitPanes(); panes != nil {
+		s.DrawSplit(panes)
+	} else {
+		v.game.Render(s)
+	}
+
+	width, height := s.displaySize()
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorTeal)
+
+	switch v.renderState {
+	case localCountdown:
+		s.DrawBlockText(CenterX, CenterY, sty, strconv.Itoa(v.countdownNum), true)
+	case localGameOver:
+		// Block letters are wide, so the result is said briefly
+		_, winner := v.game.Ended()
+		banner := "DRAW"
+
+		switch result := v.result(winner); result {
+		case "you won":
+			banner = "YOU WON"
+		case "you lost":
+			banner = "GAME OVER"
+		default:
+			if winner >= 0 {
+				banner = fmt.Sprintf("P%d WON", winner+1)
+			}
+		}
+
+		s.DrawBlockText(CenterX, CenterY, sty, banner, true)
+
+		if v.challenge != nil {
+			score := fmt.Sprintf("Challenge score: %d", v.challengeScore)
+			s.DrawText(layout.Center(width, score), height-7, sty, score)
+		}
+
+		s.DrawText(layout.Center(width, localGameOverText), height-6, sty, localGameOverText)
+	}
+}
+
+// splitPanes returns a view for each player on the keyboard, or nil if the
+// game isn't shown in split screen. Expects the lock to be held.
+func (v *LocalGameView) splitPanes() []splitPane {
+	game, ok := v.game.(perspectiveGame)
+
+	if !ok || !v.mgr.Config().SplitScreen {
+		return nil
+	}
+
+	var panes []splitPane
+
+	for i, player := range v.players {
+		if player.bot != nil {
+			continue
+		}
+
+		i := i
+		keys := make([]string, 0, 4)
+
+		for _, key := range movementKeys(player.keymap) {
+			keys = append(keys, key.String())
+		}
+
+		panes = append(panes, splitPane{
+			Render: func(s *Screen) { game.RenderFor(s, i) },
+			HUD:    fmt.Sprintf(" P%d  %s  %s ", i+1, strings.Join(keys, ""), game.Status(i)),
+		})
+	}
+
+	if len(panes) < 2 {
+		return nil
+	}
+
+	return panes
+}
+
+func (v *LocalGameView) Unload() {
+	// Closed rather than sent on, since the game may be waiting to render
+	close(v.stopTickerCh)
+}
+
+func (v *LocalGameView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
+
+// movementKeys returns the keymap's keys for moving, in up, left, down, right
+// order.
+func movementKeys(km *Keymap) []Key {
+	keys := make([]Key, 0, 4)
+
+	for _, action := range []Action{ActionUp, ActionLeft, ActionDown, ActionRight} {
+		for _, binding := range km.List() {
+			if binding.Action == action {
+				keys = append(keys, binding.Keys...)
+			}
+		}
+	}
+
+	return keys
+}
+
+// movementLabel describes the keymap's keys for moving, like "WASD".
+func movementLabel(km *Keymap) string {
+	labels := make([]string, 0, 4)
+
+	for _, key := range movementKeys(km) {
+		labels = append(labels, key.String())
+	}
+
+	return strings.Join(labels, "")
+}
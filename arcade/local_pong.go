@@ -0,0 +1,245 @@
+package arcade
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// localPong is Pong on one machine, using the same simulation as the host of
+// an online game.
+type localPong struct {
+	playerIDs []string
+	state     PongGameState
+	rng       *MatchRNG
+
+	// Where each bot is aiming on its paddle, by player
+	aim map[int]int
+
+	// Times each player has sent the ball back, by player
+	hits []int
+}
+
+func newLocalPong(players int, rng *MatchRNG) *localPong {
+	playerIDs := make([]string, players)
+
+	for i := range playerIDs {
+		playerIDs[i] = fmt.Sprintf("local-%d", i+1)
+	}
+
+	return &localPong{
+		playerIDs: playerIDs,
+		state:     newPongGameState(playerIDs, false, rng),
+		rng:       rng,
+		aim:       make(map[int]int),
+		hits:      make([]int, players),
+	}
+}
+
+func (g *localPong) Input(player int, action Action) {
+	id := g.playerIDs[player]
+	cs, ok := g.state.ClientStates[id]
+
+	if !ok || cs.Eliminated() {
+		return
+	}
+
+	step := 0
+	vertical := cs.Side == PongLeft || cs.Side == PongRight
+
+	switch {
+	case action == ActionUp && vertical, action == ActionLeft && !vertical:
+		step = -pongPaddleStep(cs.Side)
+	case action == ActionDown && vertical, action == ActionRight && !vertical:
+		step = pongPaddleStep(cs.Side)
+	}
+
+	if step == 0 {
+		return
+	}
+
+	cs.Pos = clampPaddle(cs.Side, cs.Pos+step)
+	g.state = g.state.withClientState(id, cs)
+}
+
+func (g *localPong) Step() {
+	previous := g.state
+	g.state = stepPong(g.state, g.rng)
+
+	for i, id := range g.playerIDs {
+		cs := g.state.ClientStates[id]
+
+		if cs.Lives < previous.ClientStates[id].Lives {
+			playSound(SoundScore)
+		} else if pongReturned(previous.Ball, g.state.Ball, cs.Side) {
+			g.hits[i]++
+		}
+	}
+}
+
+// pongReturned returns true if the ball bounced back from the side between
+// the two ticks.
+func pongReturned(before, after PongBall, side PongSide) bool {
+	centerX := float64(pongCourtX1+pongCourtX2) / 2
+	centerY := float64(pongCourtY1+pongCourtY2) / 2
+
+	switch side {
+	case PongLeft:
+		return before.VX < 0 && after.VX > 0 && after.X < centerX
+	case PongRight:
+		return before.VX > 0 && after.VX < 0 && after.X > centerX
+	case PongTop:
+		return before.VY < 0 && after.VY > 0 && after.Y < centerY
+	case PongBottom:
+		return before.VY > 0 && after.VY < 0 && after.Y > centerY
+	}
+
+	return false
+}
+
+// BotMove moves the paddle toward the ball, the same way the attract mode's
+// bots do.
+func (g *localPong) BotMove(player int, skill BotSkill) Action {
+	cs, ok := g.state.ClientStates[g.playerIDs[player]]
+
+	if !ok || cs.Eliminated() || g.state.Tick%skill.MoveEvery != 0 {
+		return ""
+	}
+
+	if g.state.Tick%attractAimTicks == 0 {
+		g.aim[player] = g.rng.Intn(2*skill.AimSpread+1) - skill.AimSpread
+	}
+
+	target := pongBotTarget(g.state, cs, g.aim[player])
+	vertical := cs.Side == PongLeft || cs.Side == PongRight
+
+	switch {
+	case target > cs.Pos && vertical:
+		return ActionDown
+	case target > cs.Pos:
+		return ActionRight
+	case target < cs.Pos && vertical:
+		return ActionUp
+	case target < cs.Pos:
+		return ActionLeft
+	}
+
+	return ""
+}
+
+func (g *localPong) Observe(player int) BotObservation {
+	return BotObservation{Player: player, PlayerID: g.playerIDs[player], Tick: g.state.Tick, State: g.state}
+}
+
+// ScoreDifferential returns the player's lives less those of whoever has the
+// most of the rest.
+func (g *localPong) ScoreDifferential(player int) int {
+	best := 0
+
+	for i, playerID := range g.playerIDs {
+		if cs, ok := g.state.ClientStates[playerID]; ok && i != player && cs.Lives > best {
+			best = cs.Lives
+		}
+	}
+
+	return g.state.ClientStates[g.playerIDs[player]].Lives - best
+}
+
+func (g *localPong) Ended() (bool, int) {
+	if !g.state.Ended {
+		return false, -1
+	}
+
+	for i, id := range g.playerIDs {
+		if id == g.state.Winner {
+			return true, i
+		}
+	}
+
+	return true, -1
+}
+
+// ChallengeScore counts lives taken from the other players, then lives kept.
+func (g *localPong) ChallengeScore(player int) int {
+	score := 0
+
+	for i, id := range g.playerIDs {
+		lives := g.state.ClientStates[id].Lives
+
+		if i == player {
+			score += lives * 100
+		} else {
+			score += (PongStartingLives - lives) * 1000
+		}
+	}
+
+	return score
+}
+
+func (g *localPong) TickPeriod() time.Duration {
+	return PongTickPeriod
+}
+
+func (g *localPong) Render(s *Screen) {
+	renderPongCourt(s, g.state, g.playerIDs, "")
+}
+
+// pongTutorial teaches moving the paddle, then returning the ball, then
+// getting it past another player.
+var pongTutorial = []tutorialStep{
+	{
+		Text:    "Move your paddle up and down with the arrow keys.",
+		Actions: []Action{ActionUp, ActionDown},
+		Done: func(g localGame, used map[Action]bool, ticks int) bool {
+			return used[ActionUp] && used[ActionDown]
+		},
+	},
+	{
+		Text:    "Here comes the ball. Get your paddle in its way to send it back.",
+		Actions: []Action{ActionUp, ActionDown},
+		Bot:     &botSkills[1],
+		Setup: func(g localGame) {
+			g.(*localPong).serveAt(0)
+		},
+		Done: func(g localGame, used map[Action]bool, ticks int) bool {
+			return g.(*localPong).hits[0] > 0
+		},
+		Failed: pongTutorialScored,
+	},
+	{
+		Text:    "Hit the ball with an end of your paddle to angle it past the bot.",
+		Actions: []Action{ActionUp, ActionDown},
+		Setup: func(g localGame) {
+			g.(*localPong).serveAt(0)
+		},
+		Done: func(g localGame, used map[Action]bool, ticks int) bool {
+			pong := g.(*localPong)
+			return pong.state.ClientStates[pong.playerIDs[1]].Lives < PongStartingLives
+		},
+		Failed: pongTutorialScored,
+	},
+}
+
+// serveAt sends the ball toward the player.
+func (g *localPong) serveAt(player int) {
+	ball := g.state.Ball
+
+	switch g.state.ClientStates[g.playerIDs[player]].Side {
+	case PongLeft:
+		ball.VX = -math.Abs(ball.VX)
+	case PongRight:
+		ball.VX = math.Abs(ball.VX)
+	case PongTop:
+		ball.VY = -math.Abs(ball.VY)
+	case PongBottom:
+		ball.VY = math.Abs(ball.VY)
+	}
+
+	g.state.Ball = ball
+}
+
+// pongTutorialScored returns true once the ball's got past the player.
+func pongTutorialScored(g localGame) bool {
+	pong := g.(*localPong)
+	return pong.state.ClientStates[pong.playerIDs[0]].Lives < PongStartingLives
+}
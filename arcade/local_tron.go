@@ -0,0 +1,342 @@
+package arcade
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	// Turns a local Tron player can queue up, so quick double turns aren't
+	// lost
+	localTronMaxQueued = 2
+
+	// Furthest a bot looks when picking which way to turn
+	localTronMaxLook = 30
+
+	// Timesteps added to a daily challenge score for outlasting everyone, so
+	// winning quickly beats hanging on
+	localTronWinBonus = 500
+)
+
+var tronDirectionActions = map[TronDirection]Action{
+	TronUp:    ActionUp,
+	TronRight: ActionRight,
+	TronDown:  ActionDown,
+	TronLeft:  ActionLeft,
+}
+
+// localTron is Tron on one machine. There's no log to agree on, so commands
+// are applied as soon as their timestep comes, with the same rules and
+// collisions as an online game.
+type localTron struct {
+	// Holds the state, and has the game's rules
+	tg *TronGameView
+
+	playerIDs []string
+	pending   [][]TronDirection
+	timestep  int
+	rng       *MatchRNG
+
+	// Timesteps each player has lasted
+	survived []int
+}
+
+func newLocalTron(mgr *ViewManager, players int, rng *MatchRNG) *localTron {
+	playerIDs := make([]string, players)
+
+	for i := range playerIDs {
+		playerIDs[i] = fmt.Sprintf("local-%d", i+1)
+	}
+
+	g := &localTron{
+		tg: &TronGameView{
+			mgr:  mgr,
+			Game: Game[TronGameState, TronClientState]{PlayerIDs: playerIDs},
+		},
+		playerIDs: playerIDs,
+		pending:   make([][]TronDirection, players),
+		rng:       rng,
+		survived:  make([]int, players),
+	}
+
+	width, height := mgr.screen.displaySize()
+	startingPos, startingDir := g.tg.getStartingPosAndDir()
+	clientStates := make(map[string]TronClientState)
+
+	for i, playerID := range playerIDs {
+		clientStates[playerID] = TronClientState{0, true, TRON_COLORS[i], startingPos[i][0], startingPos[i][1], startingDir[i], i, -1, 0}
+	}
+
+	g.tg.WorkingGameState = TronGameState{width, height, false, "", g.tg.initCollisions(), clientStates, -1, 0, nil, nil, nil}
+
+	return g
+}
+
+func (g *localTron) Input(player int, action Action) {
+	var dir TronDirection
+
+	switch action {
+	case ActionUp:
+		dir = TronUp
+	case ActionRight:
+		dir = TronRight
+	case ActionDown:
+		dir = TronDown
+	case ActionLeft:
+		dir = TronLeft
+	default:
+		return
+	}
+
+	queue := g.pending[player]
+	current := g.tg.WorkingGameState.ClientStates[g.playerIDs[player]].Direction
+
+	if len(queue) > 0 {
+		current = queue[len(queue)-1]
+	}
+
+	if len(queue) < localTronMaxQueued && canMoveInDir(current, dir) {
+		g.pending[player] = append(queue, dir)
+	}
+}
+
+func (g *localTron) Step() {
+	state := g.tg.WorkingGameState
+
+	if state.Ended {
+		return
+	}
+
+	alive := tronAliveCount(state)
+
+	for i, playerID := range g.playerIDs {
+		if len(g.pending[i]) == 0 {
+			continue
+		}
+
+		cmd := TronCommand{Type: TronMoveCmd, Timestep: g.timestep, PlayerID: playerID, Direction: g.pending[i][0]}
+		g.pending[i] = g.pending[i][1:]
+		state = g.tg.applyCommandToGameState(state, cmd)
+	}
+
+	state = g.tg.clientPredictAll(state, 1)
+	g.timestep++
+
+	for i, playerID := range g.playerIDs {
+		if state.ClientStates[playerID].Alive {
+			g.survived[i] = g.timestep
+		}
+	}
+
+	if tronAliveCount(state) < alive {
+		playSound(SoundCollision)
+	}
+
+	if ended, winner := g.tg.shouldWin(state); ended {
+		state.Ended = true
+		state.Winner = winner
+	}
+
+	g.tg.WorkingGameState = state
+}
+
+// BotMove turns when a wall or trail is coming up, toward whichever side has
+// more room.
+func (g *localTron) BotMove(player int, skill BotSkill) Action {
+	cs := g.tg.WorkingGameState.ClientStates[g.playerIDs[player]]
+
+	if !cs.Alive || len(g.pending[player]) > 0 || g.timestep%skill.MoveEvery != 0 {
+		return ""
+	}
+
+	if g.room(cs.X, cs.Y, cs.Direction, skill.Lookahead) >= skill.Lookahead || g.rng.Float64() < skill.Mistakes {
+		return ""
+	}
+
+	best, bestRoom := TronDirection(-1), 0
+
+	for _, dir := range []TronDirection{TronUp, TronRight, TronDown, TronLeft} {
+		if !canMoveInDir(cs.Direction, dir) {
+			continue
+		}
+
+		if room := g.room(cs.X, cs.Y, dir, localTronMaxLook); room > bestRoom {
+			best, bestRoom = dir, room
+		}
+	}
+
+	return tronDirectionActions[best]
+}
+
+// room returns how many cells are free going from the position in the
+// direction, up to the most given.
+func (g *localTron) room(x, y int, dir TronDirection, most int) int {
+	for i := 0; i < most; i++ {
+		switch dir {
+		case TronUp:
+			y--
+		case TronRight:
+			x++
+		case TronDown:
+			y++
+		case TronLeft:
+			x--
+		}
+
+		if collides, _ := g.tg.getCollision(g.tg.WorkingGameState.Collisions, x, y); collides {
+			return i
+		}
+
+		if tronInShrunkWall(g.tg.WorkingGameState, x, y) {
+			return i
+		}
+	}
+
+	return most
+}
+
+func (g *localTron) Observe(player int) BotObservation {
+	return BotObservation{Player: player, PlayerID: g.playerIDs[player], Tick: g.timestep, State: g.tg.WorkingGameState}
+}
+
+// ScoreDifferential returns how many others have crashed while the player's
+// still riding, or how many are still riding once they've crashed.
+func (g *localTron) ScoreDifferential(player int) int {
+	state := g.tg.WorkingGameState
+	differential := 0
+
+	for i, playerID := range g.playerIDs {
+		if i != player && !state.ClientStates[playerID].Alive {
+			differential++
+		}
+	}
+
+	if !state.ClientStates[g.playerIDs[player]].Alive {
+		return differential - (len(g.playerIDs) - 1)
+	}
+
+	return differential
+}
+
+func (g *localTron) Ended() (bool, int) {
+	state := g.tg.WorkingGameState
+
+	if !state.Ended {
+		return false, -1
+	}
+
+	for i, id := range g.playerIDs {
+		if id == state.Winner {
+			return true, i
+		}
+	}
+
+	return true, -1
+}
+
+// ChallengeScore counts the timesteps the player lasted.
+func (g *localTron) ChallengeScore(player int) int {
+	score := g.survived[player]
+
+	if ended, winner := g.Ended(); ended && winner == player {
+		score += localTronWinBonus
+	}
+
+	return score
+}
+
+func (g *localTron) TickPeriod() time.Duration {
+	return TronTimestepPeriod
+}
+
+func (g *localTron) Render(s *Screen) {
+	g.render(s, g.tg)
+}
+
+// RenderFor draws the game with everyone but the player dimmed.
+func (g *localTron) RenderFor(s *Screen, player int) {
+	g.render(s, &TronGameView{
+		mgr:              g.tg.mgr,
+		Game:             Game[TronGameState, TronClientState]{PlayerIDs: g.playerIDs},
+		WorkingGameState: g.tg.WorkingGameState,
+		focus:            g.playerIDs[player],
+		arena:            g.tg.arena,
+	})
+}
+
+func (g *localTron) render(s *Screen, tg *TronGameView) {
+	s.ClearContent()
+
+	width, height := s.displaySize()
+	boxStyle := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorTeal)
+	s.DrawBox(1, 1, width-2, height-2, boxStyle, false)
+
+	tg.renderGame(s)
+}
+
+// Status says whether the player's still riding, and for how long.
+func (g *localTron) Status(player int) string {
+	if !g.tg.WorkingGameState.ClientStates[g.playerIDs[player]].Alive {
+		return "Crashed"
+	}
+
+	return fmt.Sprintf("Riding %ds", g.survived[player]*int(TronTimestepPeriod/time.Millisecond)/1000)
+}
+
+// tronTutorial teaches steering, then turning away from walls, then
+// outlasting another player.
+var tronTutorial = []tutorialStep{
+	{
+		Text:    "Steer with the arrow keys. Try turning a few times.",
+		Actions: []Action{ActionUp, ActionLeft, ActionDown, ActionRight},
+		Bot:     &botSkills[1],
+		Done: func(g localGame, used map[Action]bool, ticks int) bool {
+			return len(used) >= 3
+		},
+		Failed: tronTutorialCrashed,
+	},
+	{
+		Text:    "There's a wall coming up! Turn before you hit it.",
+		Actions: []Action{ActionUp, ActionDown},
+		Bot:     &botSkills[1],
+		Setup: func(g localGame) {
+			g.(*localTron).place(0, displayWidth-14, displayHeight/2, TronRight)
+		},
+		Done: func(g localGame, used map[Action]bool, ticks int) bool {
+			return ticks >= 30
+		},
+		Failed: tronTutorialCrashed,
+	},
+	{
+		Text:    "Trails are walls too. Last longer than the bot to win.",
+		Actions: []Action{ActionUp, ActionLeft, ActionDown, ActionRight},
+		Done: func(g localGame, used map[Action]bool, ticks int) bool {
+			ended, winner := g.Ended()
+			return ended && winner == 0
+		},
+		Failed: tronTutorialCrashed,
+	},
+}
+
+// place moves a player to a cell before the game starts.
+func (g *localTron) place(player, x, y int, dir TronDirection) {
+	states := make(map[string]TronClientState, len(g.playerIDs))
+
+	for id, cs := range g.tg.WorkingGameState.ClientStates {
+		states[id] = cs
+	}
+
+	cs := states[g.playerIDs[player]]
+	cs.X, cs.Y, cs.Direction = x, y, dir
+	states[g.playerIDs[player]] = cs
+
+	g.tg.WorkingGameState.ClientStates = states
+}
+
+// tronTutorialCrashed returns true once the player has crashed.
+func tronTutorialCrashed(g localGame) bool {
+	tron := g.(*localTron)
+	return !tron.tg.WorkingGameState.ClientStates[tron.playerIDs[0]].Alive
+}
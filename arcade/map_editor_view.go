@@ -0,0 +1,379 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"arcade/arcade/widgets"
+	"encoding"
+	"fmt"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var mapEditorFooter = "Arrows Space [D]raw  [W]all [E]rase [1-8] Spawn  [N]ame [S]ave [C]lear [B]ack"
+
+var mapBrushNames = map[byte]string{
+	mapWall:  "Wall",
+	mapFloor: "Erase",
+}
+
+// MapEditorView paints a Tron arena's walls and spawns, with the keyboard or
+// the mouse. The map can only be saved once every spawn can be reached.
+type MapEditorView struct {
+	View
+	mgr *ViewManager
+
+	mu    sync.RWMutex
+	arena *TronMap
+
+	// The name it was last saved under, so saving over another map asks first
+	savedAs string
+	dirty   bool
+
+	// Cursor, what it paints, and whether it paints as it moves
+	x, y    int
+	brush   byte
+	drawing bool
+
+	// Set while typing the map's name, and if it should be saved after
+	naming     bool
+	saveOnName bool
+	nameInput  *widgets.TextInput
+	nameFocus  *widgets.FocusGroup
+}
+
+func NewMapEditorView(mgr *ViewManager, m *TronMap) *MapEditorView {
+	arena := &TronMap{Name: m.Name, Rows: append([]string(nil), m.Rows...)}
+
+	v := &MapEditorView{
+		mgr:       mgr,
+		arena:     arena,
+		savedAs:   m.Name,
+		x:         displayWidth / 2,
+		y:         displayHeight / 2,
+		brush:     mapWall,
+		nameInput: widgets.NewTextInput(7, 0, maxMapNameLength),
+	}
+
+	v.nameInput.MaxLength = maxMapNameLength
+	v.nameInput.Placeholder = "name the map"
+	v.nameInput.SetValue(m.Name)
+	v.nameInput.OnSubmit = v.setName
+	v.nameFocus = widgets.NewFocusGroup(v.nameInput)
+
+	return v
+}
+
+// Widgets returns the name box while the map's being named.
+func (v *MapEditorView) Widgets() *widgets.FocusGroup {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if !v.naming {
+		return nil
+	}
+
+	return v.nameFocus
+}
+
+func (v *MapEditorView) setName(name string) {
+	v.mu.Lock()
+	v.naming = false
+	v.arena.Name = name
+	save := v.saveOnName
+	v.saveOnName = false
+	v.mu.Unlock()
+
+	if save {
+		v.save()
+	}
+
+	v.mgr.RequestRender()
+}
+
+// paint puts the brush down at the cursor. Spawns are moved rather than
+// copied, since each player has one.
+func (v *MapEditorView) paint(x, y int, brush byte) {
+	if !v.arena.inArena(x, y) {
+		return
+	}
+
+	if _, spawn := mapSpawnNum(brush); spawn {
+		for row, line := range v.arena.Rows {
+			for col := 0; col < len(line); col++ {
+				if line[col] == brush {
+					v.arena.Set(col, row, mapFloor)
+				}
+			}
+		}
+	}
+
+	if v.arena.At(x, y) != brush {
+		v.arena.Set(x, y, brush)
+		v.dirty = true
+	}
+}
+
+// save writes the map, asking first if it'd replace a different one.
+func (v *MapEditorView) save() {
+	v.mu.Lock()
+
+	if !validMapName(v.arena.Name) {
+		v.naming = true
+		v.saveOnName = true
+		v.mu.Unlock()
+
+		notify("Name the map to save it")
+		return
+	}
+
+	if err := v.arena.validateLayout(); err != nil {
+		v.mu.Unlock()
+
+		notify("Can't save: %s", err)
+		return
+	}
+
+	name := v.arena.Name
+	replacing := false
+
+	if name != v.savedAs {
+		_, replacing = loadTronMap(name)
+	}
+
+	v.mu.Unlock()
+
+	if !replacing {
+		v.write()
+		return
+	}
+
+	v.mgr.ShowModal(widgets.NewModal("Save map", []string{
+		fmt.Sprintf("There's already a map called %s.", name),
+		"Replace it?",
+	}, []string{"Cancel", "Replace"}, func(choice int) {
+		if choice == 1 {
+			v.write()
+		}
+	}))
+}
+
+func (v *MapEditorView) write() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err := saveTronMap(v.arena); err != nil {
+		notify("Couldn't save the map: %s", err)
+		return
+	}
+
+	v.savedAs = v.arena.Name
+	v.dirty = false
+
+	notify("Saved %s", v.arena.Name)
+}
+
+func (v *MapEditorView) Init() {
+	v.mgr.screen.EnableMouse()
+}
+
+// Back stops naming the map, or asks before throwing away changes.
+func (v *MapEditorView) Back() bool {
+	v.mu.Lock()
+	naming, dirty := v.naming, v.dirty
+	v.naming = false
+	v.saveOnName = false
+	v.mu.Unlock()
+
+	switch {
+	case naming:
+		v.mgr.RequestRender()
+	case dirty:
+		v.mgr.ShowModal(widgets.NewModal("Leave editor", []string{
+			"The map has unsaved changes.",
+		}, []string{"Keep editing", "Discard"}, func(choice int) {
+			if choice == 1 {
+				v.mgr.PopView()
+			}
+		}))
+	default:
+		v.mgr.PopView()
+	}
+
+	return true
+}
+
+func (v *MapEditorView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventMouse:
+		v.processMouse(evt)
+	case *tcell.EventKey:
+		v.mu.Lock()
+		save, back := false, false
+
+		switch evt.Key() {
+		case tcell.KeyUp:
+			v.move(0, -1)
+		case tcell.KeyDown:
+			v.move(0, 1)
+		case tcell.KeyLeft:
+			v.move(-1, 0)
+		case tcell.KeyRight:
+			v.move(1, 0)
+		case tcell.KeyRune:
+			switch r := evt.Rune(); {
+			case r == ' ':
+				v.paint(v.x, v.y, v.brush)
+			case r == 'd':
+				v.drawing = !v.drawing
+			case r == 'w':
+				v.brush = mapWall
+			case r == 'e':
+				v.brush = mapFloor
+			case r >= '1' && r <= '8':
+				v.brush = byte(r)
+			case r == 'n':
+				v.naming = true
+			case r == 'c':
+				v.arena.Rows = NewTronMap("").Rows
+				v.dirty = true
+			case r == 's':
+				save = true
+			case r == 'b':
+				back = true
+			}
+		}
+
+		v.mu.Unlock()
+
+		if save {
+			v.save()
+		} else if back {
+			v.Back()
+		}
+	}
+}
+
+// move moves the cursor, painting the cell it moves to while drawing.
+// Expects the lock to be held.
+func (v *MapEditorView) move(dx, dy int) {
+	if !v.arena.inArena(v.x+dx, v.y+dy) {
+		return
+	}
+
+	v.x += dx
+	v.y += dy
+
+	if v.drawing {
+		v.paint(v.x, v.y, v.brush)
+	}
+}
+
+// processMouse paints with the brush under the left button and erases under
+// the right, dragging included.
+func (v *MapEditorView) processMouse(evt *tcell.EventMouse) {
+	x, y := evt.Position()
+	offsetX, offsetY := v.mgr.screen.offset()
+	x, y = x-offsetX, y-offsetY
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !v.arena.inArena(x, y) {
+		return
+	}
+
+	switch {
+	case evt.Buttons()&tcell.Button1 != 0:
+		v.x, v.y = x, y
+		v.paint(x, y, v.brush)
+	case evt.Buttons()&tcell.Button2 != 0:
+		v.x, v.y = x, y
+		v.paint(x, y, mapFloor)
+	}
+}
+
+func (v *MapEditorView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *MapEditorView) Render(s *Screen) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	s.ClearContent()
+
+	width, height := s.displaySize()
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	boxSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorTeal)
+	wallSty := tcell.StyleDefault.Background(tcell.ColorTeal)
+	errSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorRed)
+
+	s.DrawBox(1, 1, width-2, height-2, boxSty, false)
+
+	for y, row := range v.arena.Rows {
+		for x := 0; x < len(row); x++ {
+			cellSty, cell := sty, " "
+
+			if n, spawn := mapSpawnNum(row[x]); spawn {
+				cellSty, cell = sty.Foreground(tcell.ColorNames[TRON_COLORS[n]]), string(row[x])
+			} else if row[x] == mapWall {
+				cellSty = wallSty
+			} else if x != v.x || y != v.y {
+				continue
+			}
+
+			if x == v.x && y == v.y {
+				cellSty = cellSty.Reverse(true)
+			}
+
+			s.DrawText(x, y, cellSty, cell)
+		}
+	}
+
+	// The map's name and the brush along the top
+	s.DrawText(1, 0, sty, "Map:")
+
+	if v.naming {
+		v.nameFocus.Render(s)
+	} else if v.arena.Name == "" {
+		s.DrawText(7, 0, sty.Foreground(tcell.ColorDarkGreen), "(unnamed)")
+	} else {
+		s.DrawText(7, 0, sty, v.arena.Name)
+	}
+
+	brush, ok := mapBrushNames[v.brush]
+
+	if !ok {
+		brush = "Spawn " + string(v.brush)
+	}
+
+	status := "Brush: " + brush
+
+	if v.drawing {
+		status += "  Drawing"
+	}
+
+	if v.dirty {
+		status += "  *"
+	}
+
+	s.DrawText(width-1-layout.Width(status), 0, sty, status)
+
+	// What's stopping the map being played on, along the bottom of the box
+	if err := v.arena.validateLayout(); err != nil {
+		s.DrawText(3, height-2, errSty, " "+err.Error()+" ")
+	}
+
+	s.DrawText(layout.Center(width, mapEditorFooter), height-1, sty, mapEditorFooter)
+}
+
+func (v *MapEditorView) Unload() {
+	v.mgr.screen.DisableMouse()
+}
+
+func (v *MapEditorView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
@@ -0,0 +1,26 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// MapShareMessage is sent by a lobby's host to each player who joins, when
+// the lobby's on a custom map, so everyone has it when the game starts.
+type MapShareMessage struct {
+	message.Message
+	LobbyID string
+	Map     *TronMap
+}
+
+func NewMapShareMessage(lobbyID string, m *TronMap) *MapShareMessage {
+	return &MapShareMessage{
+		Message: message.Message{Type: "map_share"},
+		LobbyID: lobbyID,
+		Map:     m,
+	}
+}
+
+func (m MapShareMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
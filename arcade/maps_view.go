@@ -0,0 +1,180 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"arcade/arcade/widgets"
+	"encoding"
+	"fmt"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	mapsListX      = 20
+	mapsListY      = 6
+	mapsListWidth  = 40
+	mapsListHeight = 14
+)
+
+var mapsFooter = "↑/↓ Move   Enter Edit   [D]elete   [P]ublish   [W]orkshop   [B]ack"
+
+// MapsView lists our custom Tron maps, to edit, delete or start a new one.
+type MapsView struct {
+	View
+	mgr *ViewManager
+
+	mu   sync.RWMutex
+	maps []*TronMap
+
+	list  *widgets.ScrollList
+	focus *widgets.FocusGroup
+}
+
+func NewMapsView(mgr *ViewManager) *MapsView {
+	v := &MapsView{
+		mgr:  mgr,
+		list: widgets.NewScrollList(mapsListX, mapsListY, mapsListWidth, mapsListHeight),
+	}
+
+	v.list.OnSelect = v.edit
+	v.focus = widgets.NewFocusGroup(v.list)
+
+	return v
+}
+
+func (v *MapsView) Widgets() *widgets.FocusGroup {
+	return v.focus
+}
+
+// reload lists the maps in the maps directory, after a row for a new one.
+func (v *MapsView) reload() {
+	maps := loadTronMaps()
+	rows := []string{" + New map"}
+
+	for _, m := range maps {
+		rows = append(rows, fmt.Sprintf(" %-24s %d spawns", m.Name, len(m.Spawns())))
+	}
+
+	v.mu.Lock()
+	v.maps = maps
+	v.mu.Unlock()
+
+	v.list.SetItems(rows)
+	v.mgr.RequestRender()
+}
+
+// selected returns the map at the row, or nil for the new map row.
+func (v *MapsView) selected(index int) *TronMap {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if index < 1 || index > len(v.maps) {
+		return nil
+	}
+
+	return v.maps[index-1]
+}
+
+func (v *MapsView) edit(index int) {
+	m := v.selected(index)
+
+	if m == nil {
+		m = NewTronMap("")
+	}
+
+	v.mgr.PushView(NewMapEditorView(v.mgr, m))
+}
+
+func (v *MapsView) delete(m *TronMap) {
+	v.mgr.ShowModal(widgets.NewModal("Delete map", []string{
+		fmt.Sprintf("Delete %s for good?", m.Name),
+	}, []string{"Cancel", "Delete"}, func(choice int) {
+		if choice != 1 {
+			return
+		}
+
+		if err := deleteTronMap(m.Name); err != nil {
+			notify("Couldn't delete %s", m.Name)
+		}
+
+		v.reload()
+	}))
+}
+
+// publish shares the map in the distributor's workshop, once the player's
+// sure.
+func (v *MapsView) publish(m *TronMap) {
+	v.mgr.ShowModal(widgets.NewModal("Publish map", []string{
+		fmt.Sprintf("Publish %s to the workshop?", m.Name),
+		"Anyone can download it, with your name on it.",
+	}, []string{"Cancel", "Publish"}, func(choice int) {
+		if choice != 1 {
+			return
+		}
+
+		go func() {
+			if _, err := publishMap(m); err != nil {
+				notify("Couldn't publish %s: %s", m.Name, err)
+				return
+			}
+
+			notify("Published %s", m.Name)
+		}()
+	}))
+}
+
+func (v *MapsView) Init() {
+	v.reload()
+}
+
+func (v *MapsView) OnPause() {
+}
+
+// OnResume lists maps saved in the editor.
+func (v *MapsView) OnResume() {
+	v.reload()
+}
+
+func (v *MapsView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		if evt.Key() != tcell.KeyRune {
+			return
+		}
+
+		switch evt.Rune() {
+		case 'd':
+			if m := v.selected(v.list.Selected()); m != nil {
+				v.delete(m)
+			}
+		case 'b':
+			v.mgr.PopView()
+		}
+	}
+}
+
+func (v *MapsView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *MapsView) Render(s *Screen) {
+	width, height := s.displaySize()
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+
+	s.DrawBlockText(CenterX, 1, sty, "TRON MAPS", false)
+	s.DrawBox(mapsListX-1, mapsListY-1, mapsListX+mapsListWidth, mapsListY+mapsListHeight, sty, false)
+
+	v.focus.Render(s)
+
+	s.DrawText(layout.Center(width, mapsFooter), height-2, sty, mapsFooter)
+}
+
+func (v *MapsView) Unload() {
+}
+
+func (v *MapsView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
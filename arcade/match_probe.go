@@ -0,0 +1,25 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// MatchProbeMessage asks a lobby's host to answer straight away, so quick
+// match can time the round trip before joining.
+type MatchProbeMessage struct {
+	message.Message
+
+	Seq int
+}
+
+func NewMatchProbeMessage(seq int) *MatchProbeMessage {
+	return &MatchProbeMessage{
+		Message: message.Message{Type: "match_probe"},
+		Seq:     seq,
+	}
+}
+
+func (m MatchProbeMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,27 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// MatchProbeReplyMessage answers a probe, with the round trip times from the
+// host to each of its players in milliseconds as it last measured them.
+type MatchProbeReplyMessage struct {
+	message.Message
+
+	Seq  int
+	RTTs map[string]int
+}
+
+func NewMatchProbeReplyMessage(seq int, rtts map[string]int) *MatchProbeReplyMessage {
+	return &MatchProbeReplyMessage{
+		Message: message.Message{Type: "match_probe_reply"},
+		Seq:     seq,
+		RTTs:    rtts,
+	}
+}
+
+func (m MatchProbeReplyMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,175 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// MatchResult is the final score of a match, as one player saw it. Each
+// player signs it with their session key and reports it to the distributor,
+// which records it once most of the players report the same thing.
+type MatchResult struct {
+	GameID   string
+	GameType string
+	Players  []string
+	Winner   string
+
+	// Score by player, in whatever unit the game keeps
+	Scores map[string]int
+}
+
+// signedBytes returns what players sign. Maps are marshaled with sorted
+// keys, so everyone who saw the same result signs the same bytes.
+func (r MatchResult) signedBytes() []byte {
+	data, _ := json.Marshal(r)
+	return data
+}
+
+// digest identifies the result, to tell whether players agree on it.
+func (r MatchResult) digest() string {
+	sum := sha256.Sum256(r.signedBytes())
+	return hex.EncodeToString(sum[:])
+}
+
+// entryBytes returns what players sign as the match starts: that they're
+// playing it, with these players, whoever wins. It's told apart from a
+// result, so an entry can't be passed off as one.
+func (r MatchResult) entryBytes() []byte {
+	data, _ := json.Marshal(MatchResult{GameID: r.GameID, GameType: r.GameType, Players: r.Players})
+	return append([]byte("entry:"), data...)
+}
+
+// entryDigest identifies the match the result is for, to tell whether
+// players entered the same one.
+func (r MatchResult) entryDigest() string {
+	sum := sha256.Sum256(r.entryBytes())
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify returns true if the signature is the player's. The session key it's
+// checked with is taken from the token, which the player's identity vouches
+// for, so no one can sign for a player by naming a key of their own.
+func (r MatchResult) Verify(playerID string, token SessionToken, signature []byte) bool {
+	if !token.Verify(playerID) {
+		return false
+	}
+
+	return ed25519.Verify(token.SessionKey, r.signedBytes(), signature)
+}
+
+// VerifyEntry returns true if the signature is the player's entry to the
+// match, checked like Verify.
+func (r MatchResult) VerifyEntry(playerID string, token SessionToken, signature []byte) bool {
+	if !token.Verify(playerID) {
+		return false
+	}
+
+	return ed25519.Verify(token.SessionKey, r.entryBytes(), signature)
+}
+
+type ResultReportMessage struct {
+	message.Message
+	Result    MatchResult
+	Signature []byte
+
+	// Vouches for the session key the result was signed with
+	Token SessionToken
+
+	// Whether this is the player's entry as the match starts, rather than
+	// its result
+	Entry bool `json:",omitempty"`
+}
+
+func NewResultReportMessage(result MatchResult, signature []byte, token SessionToken) *ResultReportMessage {
+	return &ResultReportMessage{
+		Message:   message.Message{Type: "result_report"},
+		Result:    result,
+		Signature: signature,
+		Token:     token,
+	}
+}
+
+func NewMatchEntryMessage(entry MatchResult, signature []byte, token SessionToken) *ResultReportMessage {
+	msg := NewResultReportMessage(entry, signature, token)
+	msg.Entry = true
+
+	return msg
+}
+
+func (m ResultReportMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// reportMatchResult publishes the end of the game, then signs the result and
+// sends it to the distributor, if we're connected to one. Distributors only
+// play the matches they host, and record those themselves.
+func reportMatchResult(result MatchResult) {
+	if arcade.Server == nil {
+		return
+	}
+
+	arcade.Server.Events.Publish(NewGameEndedEvent(result))
+
+	if arcade.Distributor {
+		arcade.Server.leaderboard.RecordHosted(result)
+		return
+	}
+
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		log.Println("No distributor to report the result of", result.GameID)
+		return
+	}
+
+	signature := ed25519.Sign(arcade.Server.SessionKey, result.signedBytes())
+	arcade.Server.Network.Send(distributor, NewResultReportMessage(result, signature, arcade.Server.SessionToken))
+}
+
+// reportMatchEntry signs that we're starting the match and sends it to the
+// distributor, if we're connected to one, so it can record the match even
+// if some players don't report how it ended.
+func reportMatchEntry(lobby *Lobby) {
+	if arcade.Server == nil || arcade.Distributor {
+		return
+	}
+
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		return
+	}
+
+	lobby.mu.RLock()
+	entry := MatchResult{
+		GameID:   lobby.ID,
+		GameType: lobby.GameType,
+		Players:  append([]string(nil), lobby.PlayerIDs...),
+	}
+	lobby.mu.RUnlock()
+
+	signature := ed25519.Sign(arcade.Server.SessionKey, entry.entryBytes())
+	arcade.Server.Network.Send(distributor, NewMatchEntryMessage(entry, signature, arcade.Server.SessionToken))
+}
+
+// sweepResults settles the matches whose players have stopped reporting,
+// until the context's done.
+func (s *Server) sweepResults(ctx context.Context) {
+	ticker := time.NewTicker(resultReportTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.leaderboard.Sweep()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package arcade
+
+import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"sync"
+)
+
+const matchSeedSize = 32
+
+var errSeedMismatch = errors.New("seed doesn't match the host's commitment")
+
+// MatchRNG is the random number source for one match. The host picks the
+// seed, and commits to it at the start by sending its hash. Players who get
+// the seed up front, like lockstep games, draw the same numbers as the host;
+// anyone else gets it once the match is over, and can check that the host
+// didn't swap seeds partway through.
+type MatchRNG struct {
+	mu sync.Mutex
+
+	commitment string
+	seed       []byte
+	rand       *rand.Rand
+}
+
+// NewMatchRNG picks a seed for a match we're hosting.
+func NewMatchRNG() *MatchRNG {
+	seed := make([]byte, matchSeedSize)
+
+	if _, err := crand.Read(seed); err != nil {
+		panic(err)
+	}
+
+	r := &MatchRNG{}
+	r.setSeed(seed)
+
+	return r
+}
+
+// NewSeededMatchRNG draws from a seed everyone knows, like the daily
+// challenge's, so every game from it goes the same way.
+func NewSeededMatchRNG(seed string) (*MatchRNG, error) {
+	data, err := hex.DecodeString(seed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) != matchSeedSize {
+		return nil, errSeedMismatch
+	}
+
+	r := &MatchRNG{}
+	r.setSeed(data)
+
+	return r, nil
+}
+
+// NewCommittedMatchRNG is for a match someone else is hosting, whose seed
+// isn't known until it's revealed.
+func NewCommittedMatchRNG(commitment string) *MatchRNG {
+	return &MatchRNG{commitment: commitment}
+}
+
+func seedCommitment(seed []byte) string {
+	sum := sha256.Sum256(seed)
+	return hex.EncodeToString(sum[:])
+}
+
+// setSeed starts drawing from the seed. Expects the lock to be held, or r not
+// to be shared yet.
+func (r *MatchRNG) setSeed(seed []byte) {
+	r.seed = seed
+	r.commitment = seedCommitment(seed)
+	r.rand = rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(seed))))
+}
+
+// Commitment returns the hash of the seed, which is safe to share before the
+// match.
+func (r *MatchRNG) Commitment() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.commitment
+}
+
+// Seed returns the seed to reveal, or "" if it isn't known.
+func (r *MatchRNG) Seed() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return hex.EncodeToString(r.seed)
+}
+
+// Known returns true once numbers can be drawn.
+func (r *MatchRNG) Known() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rand != nil
+}
+
+// Reveal checks the host's seed against its commitment, and starts drawing
+// from it if it matches.
+func (r *MatchRNG) Reveal(seed string) error {
+	data, err := hex.DecodeString(seed)
+
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(data) != matchSeedSize || seedCommitment(data) != r.commitment {
+		return errSeedMismatch
+	}
+
+	if r.rand == nil {
+		r.setSeed(data)
+	}
+
+	return nil
+}
+
+// Replay returns a new source for the same seed, starting from the first
+// number, to check draws against after the match.
+func (r *MatchRNG) Replay() *MatchRNG {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	replay := &MatchRNG{commitment: r.commitment}
+
+	if r.seed != nil {
+		replay.setSeed(r.seed)
+	}
+
+	return replay
+}
+
+// Intn returns a number in [0, n). Panics if the seed isn't known yet.
+func (r *MatchRNG) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rand.Intn(n)
+}
+
+// Float64 returns a number in [0, 1). Panics if the seed isn't known yet.
+func (r *MatchRNG) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rand.Float64()
+}
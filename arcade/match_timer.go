@@ -0,0 +1,117 @@
+package arcade
+
+import (
+	"fmt"
+	"time"
+)
+
+// Time limits a lobby can be given, as the lobby form shows them
+var matchTimeLimits = []string{"none", "1 min", "2 min", "3 min", "5 min"}
+
+const (
+	// How often sudden death gets worse in each game, once it's started
+	pongSuddenDeathEvery = 10 * time.Second
+	tronSuddenDeathEvery = 2 * time.Second
+
+	// Time left when the clock starts warning the players
+	matchTimerWarning = 10 * time.Second
+)
+
+// MatchTimer is a game's time limit, after which it goes to sudden death,
+// getting harder every so often until someone wins. It's counted in the
+// game's own ticks, so every player works out the same thing at the same
+// point in the game, and it's kept in the game's state so everyone watching
+// can show it. A game without a time limit has none, so it's left out of the
+// state, and replays recorded before there were time limits still match.
+type MatchTimer struct {
+	// Ticks before sudden death, or 0 for no time limit
+	Limit int `json:",omitempty"`
+
+	// Ticks between each step of sudden death
+	Every int `json:",omitempty"`
+}
+
+// NewMatchTimer returns a timer for a game with ticks of the period, or nil
+// for a limit of 0.
+func NewMatchTimer(limit, every, period time.Duration) *MatchTimer {
+	if limit <= 0 {
+		return nil
+	}
+
+	return &MatchTimer{
+		Limit: int(limit / period),
+		Every: int(every / period),
+	}
+}
+
+// SuddenDeath returns true once time's run out.
+func (t *MatchTimer) SuddenDeath(tick int) bool {
+	return t != nil && t.Limit > 0 && tick >= t.Limit
+}
+
+// Steps returns how many steps of sudden death there have been by the tick,
+// starting from 1 when time runs out.
+func (t *MatchTimer) Steps(tick int) int {
+	if !t.SuddenDeath(tick) {
+		return 0
+	}
+
+	if t.Every <= 0 {
+		return 1
+	}
+
+	return 1 + (tick-t.Limit)/t.Every
+}
+
+// StepStarts returns true on the tick each step of sudden death starts.
+func (t *MatchTimer) StepStarts(tick int) bool {
+	if !t.SuddenDeath(tick) {
+		return false
+	}
+
+	return tick == t.Limit || t.Every > 0 && (tick-t.Limit)%t.Every == 0
+}
+
+// Left returns the time before sudden death.
+func (t *MatchTimer) Left(tick int, period time.Duration) time.Duration {
+	if t.SuddenDeath(tick) {
+		return 0
+	}
+
+	return time.Duration(t.Limit-tick) * period
+}
+
+// HUDItem returns the clock for the game's HUD, and false if there's no time
+// limit.
+func (t *MatchTimer) HUDItem(tick int, period time.Duration) (HUDItem, bool) {
+	if t == nil || t.Limit <= 0 {
+		return HUDItem{}, false
+	}
+
+	if t.SuddenDeath(tick) {
+		return HUDItem{Label: "SUDDEN DEATH", Warn: true}, true
+	}
+
+	left := t.Left(tick, period)
+
+	// Rounded up, so it says 0:00 as it runs out
+	seconds := int((left + time.Second - 1) / time.Second)
+
+	return HUDItem{
+		Label: "Time",
+		Value: fmt.Sprintf("%d:%02d", seconds/60, seconds%60),
+		Warn:  left <= matchTimerWarning,
+	}, true
+}
+
+// parseTimeLimit reads a time limit as it's shown in the lobby form, in
+// seconds.
+func parseTimeLimit(value string) int {
+	var minutes int
+
+	if _, err := fmt.Sscanf(value, "%d min", &minutes); err != nil {
+		return 0
+	}
+
+	return minutes * 60
+}
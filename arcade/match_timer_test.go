@@ -0,0 +1,78 @@
+package arcade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatchTimerSteps(t *testing.T) {
+	timer := NewMatchTimer(time.Minute, 10*time.Second, time.Second)
+
+	if timer.SuddenDeath(59) || timer.Steps(59) != 0 || timer.StepStarts(59) {
+		t.Errorf("sudden death before time's up")
+	}
+
+	if !timer.StepStarts(60) || timer.Steps(60) != 1 {
+		t.Errorf("sudden death didn't start when time ran out")
+	}
+
+	if timer.StepStarts(65) || timer.Steps(69) != 1 || !timer.StepStarts(70) || timer.Steps(70) != 2 {
+		t.Errorf("sudden death steps at the wrong ticks")
+	}
+
+	if none := NewMatchTimer(0, time.Second, time.Second); none != nil || none.SuddenDeath(1000) {
+		t.Errorf("no time limit ran out")
+	}
+}
+
+func TestMatchTimerHUDItem(t *testing.T) {
+	timer := NewMatchTimer(2*time.Minute, time.Second, time.Second)
+
+	tests := []struct {
+		tick  int
+		value string
+		warn  bool
+	}{
+		{0, "2:00", false},
+		{59, "1:01", false},
+		{110, "0:10", true},
+	}
+
+	for _, test := range tests {
+		item, ok := timer.HUDItem(test.tick, time.Second)
+
+		if !ok || item.Value != test.value || item.Warn != test.warn {
+			t.Errorf("tick %d: got %+v", test.tick, item)
+		}
+	}
+
+	if item, _ := timer.HUDItem(120, time.Second); item.Label != "SUDDEN DEATH" || !item.Warn {
+		t.Errorf("no sudden death shown: %+v", item)
+	}
+
+	if _, ok := (*MatchTimer)(nil).HUDItem(0, time.Second); ok {
+		t.Errorf("clock shown without a time limit")
+	}
+}
+
+func TestParseTimeLimit(t *testing.T) {
+	for value, seconds := range map[string]int{"none": 0, "1 min": 60, "5 min": 300} {
+		if got := parseTimeLimit(value); got != seconds {
+			t.Errorf("%q: got %d, want %d", value, got, seconds)
+		}
+	}
+}
+
+func TestSpeedUpPongBall(t *testing.T) {
+	ball := speedUpPongBall(PongBall{VX: 0.5, VY: -0.5}, 1)
+
+	if ball.VX <= 0.5 || ball.VY >= -0.5 {
+		t.Errorf("ball didn't speed up: %+v", ball)
+	}
+
+	ball = speedUpPongBall(PongBall{VX: 0.5, VY: -0.5}, 100)
+
+	if ball.VX != pongMaxBallSpeed || ball.VY != -pongMaxBallSpeed {
+		t.Errorf("ball sped past the cap: %+v", ball)
+	}
+}
@@ -1,9 +1,9 @@
 package message
 
 import (
-	"encoding/json"
 	"log"
 	"reflect"
+	"sync"
 )
 
 type Listener struct {
@@ -11,19 +11,29 @@ type Listener struct {
 	// messages to the correct client. All others should have this set to false
 	Distributor bool
 
+	// The server listened for. Only messages it receives are handled, in case
+	// several are running in one process, like in tests
 	ServerID string
 
 	// The function to call when a message is received
 	Handle func(c, data interface{}) interface{}
 }
 
-var listeners = make([]Listener, 0)
+var (
+	listenersMu sync.RWMutex
+	listeners   = make([]Listener, 0)
+)
 
 func AddListener(listener Listener) {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+
 	listeners = append(listeners, listener)
 }
 
-func Notify(c interface{}, data []byte) []interface{} {
+// Notify hands a message the server with the ID received to its listeners, and
+// returns their replies.
+func Notify(serverID string, c interface{}, data []byte) []interface{} {
 
 	// defer func() {
 	// 	if r := recover(); r != nil {
@@ -32,26 +42,30 @@ func Notify(c interface{}, data []byte) []interface{} {
 	// }()
 
 	msg, err := parse(data)
-	recipientID := reflect.ValueOf(msg).Elem().FieldByName("Message").FieldByName("RecipientID").String()
 
+	// Anyone can send anything, so what can't be parsed is dropped
 	if err != nil {
-		log.Println("FUCKKKKK")
-		// panic(err)
-		res := struct {
-			Type string
-		}{}
-
-		if err := json.Unmarshal(data, &res); err != nil {
-			log.Println(res)
-		}
+		log.Println("Dropped message:", err)
+		return nil
 	}
 
+	recipientID := reflect.ValueOf(msg).Elem().FieldByName("Message").FieldByName("RecipientID").String()
+
 	// log.Println("Received message:", msg)
 	// log.Println("notify parsed", msg, reflect.TypeOf(msg))
 
 	replies := make([]interface{}, 0)
 
-	for _, listener := range listeners {
+	// Servers can be made while others are receiving, like in tests
+	listenersMu.RLock()
+	current := listeners
+	listenersMu.RUnlock()
+
+	for _, listener := range current {
+		if listener.ServerID != "" && listener.ServerID != serverID {
+			continue
+		}
+
 		if listener.ServerID != "" && listener.ServerID != recipientID && !listener.Distributor {
 			continue
 		}
@@ -7,6 +7,10 @@ type Message struct {
 	RecipientID string
 	MessageID   string
 	Type        string
+
+	// Set by the distributor on messages it relays, standing for the
+	// connection they came in on. Whatever the sender put here is replaced
+	Origin string `json:",omitempty"`
 }
 
 func (m Message) UnmarshalBinary(data []byte) error {
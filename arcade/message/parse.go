@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"reflect"
+	"sort"
 )
 
 var types = map[string]interface{}{}
@@ -39,7 +40,7 @@ func parse(data []byte) (interface{}, error) {
 		p := reflect.New(reflect.TypeOf(types[messageType])).Interface()
 
 		if err := json.Unmarshal(data, p); err != nil {
-			panic(err)
+			return nil, err
 		}
 
 		return reflect.ValueOf(p).Interface(), nil
@@ -47,3 +48,28 @@ func parse(data []byte) (interface{}, error) {
 
 	return nil, errors.New("unknown message type '" + res.Type + "'")
 }
+
+// Example returns the registered example of the message type, if there is
+// one.
+func Example(messageType string) (interface{}, bool) {
+	msg, ok := types[messageType]
+	return msg, ok
+}
+
+// Registered returns an example of each registered message, ordered by type.
+func Registered() []interface{} {
+	messageTypes := make([]string, 0, len(types))
+
+	for messageType := range types {
+		messageTypes = append(messageTypes, messageType)
+	}
+
+	sort.Strings(messageTypes)
+	msgs := make([]interface{}, len(messageTypes))
+
+	for i, messageType := range messageTypes {
+		msgs[i] = types[messageType]
+	}
+
+	return msgs
+}
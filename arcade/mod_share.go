@@ -0,0 +1,106 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// Bytes of a mod's script in each share message, so one fits in a packet
+const modChunkSize = 640
+
+// ModShareMessage carries part of a lobby's mod. The host sends one for each
+// part to each player who joins, so everyone runs the same script when the
+// game starts.
+type ModShareMessage struct {
+	message.Message
+	LobbyID string
+	Name    string
+	Hash    string
+	Index   int
+	Total   int
+	Data    []byte
+}
+
+func NewModShareMessage(lobbyID string, m *TronMod, index, total int, data []byte) *ModShareMessage {
+	return &ModShareMessage{
+		Message: message.Message{Type: "mod_share"},
+		LobbyID: lobbyID,
+		Name:    m.Name,
+		Hash:    m.Hash(),
+		Index:   index,
+		Total:   total,
+		Data:    data,
+	}
+}
+
+func (m ModShareMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// modShareMessages splits the mod into the messages it's shared in.
+func modShareMessages(lobbyID string, m *TronMod) []*ModShareMessage {
+	source := []byte(m.Source)
+	total := (len(source) + modChunkSize - 1) / modChunkSize
+
+	if total == 0 {
+		total = 1
+	}
+
+	msgs := make([]*ModShareMessage, 0, total)
+
+	for i := 0; i < total; i++ {
+		end := (i + 1) * modChunkSize
+
+		if end > len(source) {
+			end = len(source)
+		}
+
+		msgs = append(msgs, NewModShareMessage(lobbyID, m, i, total, source[i*modChunkSize:end]))
+	}
+
+	return msgs
+}
+
+// modAssembler puts a mod back together from the messages it was shared in.
+type modAssembler struct {
+	hash  string
+	parts map[int][]byte
+}
+
+func newModAssembler(hash string) *modAssembler {
+	return &modAssembler{hash: hash, parts: make(map[int][]byte)}
+}
+
+// add keeps the message's part, and returns the mod once all of it's here
+// and it's the one we're waiting for.
+func (a *modAssembler) add(msg *ModShareMessage) (*TronMod, bool) {
+	maxParts := (maxModSize + modChunkSize - 1) / modChunkSize
+
+	if msg.Hash != a.hash || msg.Total < 1 || msg.Total > maxParts || msg.Index < 0 || msg.Index >= msg.Total || len(msg.Data) > modChunkSize {
+		return nil, false
+	}
+
+	a.parts[msg.Index] = msg.Data
+
+	var source []byte
+
+	for i := 0; i < msg.Total; i++ {
+		part, ok := a.parts[i]
+
+		if !ok {
+			return nil, false
+		}
+
+		source = append(source, part...)
+	}
+
+	m := &TronMod{Name: msg.Name, Source: string(source)}
+
+	// Parts from a different split of the same mod don't add up to it
+	if m.Hash() != a.hash {
+		a.parts = make(map[int][]byte)
+		return nil, false
+	}
+
+	return m, true
+}
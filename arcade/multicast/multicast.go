@@ -2,70 +2,207 @@ package multicast
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"sync"
 	"time"
 
 	"golang.org/x/net/ipv4"
 )
 
-var multicastConn *net.UDPConn
-var multicastGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 250)}
 var multicastAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 250), Port: 36824}
 
-func Listen(selfID string, delegate MulticastDiscoveryDelegate, startCh chan error) {
-	var err error
-	multicastConn, err = net.ListenUDP("udp4", multicastAddr)
+// ifaceConn is the group joined on one interface, with the address peers
+// there reach us at.
+type ifaceConn struct {
+	iface net.Interface
+	ip    net.IP
+	conn  *net.UDPConn
+}
+
+var (
+	connsMu sync.RWMutex
+	conns   []ifaceConn
+)
+
+// Interfaces returns the interfaces players can be found on: those that are
+// up, take multicast and have an IPv4 address, other than loopback.
+func Interfaces() ([]net.Interface, error) {
+	all, err := net.Interfaces()
 
 	if err != nil {
-		startCh <- err
-		return
+		return nil, err
+	}
+
+	var ifaces []net.Interface
+
+	for _, iface := range all {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		if ip := interfaceIP(iface); ip != nil {
+			ifaces = append(ifaces, iface)
+		}
+	}
+
+	return ifaces, nil
+}
+
+// InterfaceNames returns the names of the interfaces players can be found
+// on, for settings.
+func InterfaceNames() []string {
+	ifaces, _ := Interfaces()
+	names := make([]string, len(ifaces))
+
+	for i, iface := range ifaces {
+		names[i] = iface.Name
 	}
 
-	pc := ipv4.NewPacketConn(multicastConn)
+	return names
+}
 
-	// TODO: Add en1
-	iface, err := net.InterfaceByName("en0")
+// interfaceIP returns the interface's first IPv4 address, or nil if it has
+// none.
+func interfaceIP(iface net.Interface) net.IP {
+	addrs, err := iface.Addrs()
+
+	if err != nil {
+		return nil
+	}
+
+	for _, a := range addrs {
+		if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+			return ipNet.IP
+		}
+	}
+
+	return nil
+}
+
+// Listen joins the discovery group on every interface, or only the one
+// named if it isn't empty, and hands the peers it hears of to the delegate.
+// Machines with Docker or VPN interfaces would otherwise only listen on
+// whichever the system picked, which is often the wrong one.
+func Listen(selfID string, selfPort int, ifaceName string, delegate MulticastDiscoveryDelegate, startCh chan error) {
+	ifaces, err := Interfaces()
 
 	if err != nil {
 		startCh <- err
 		return
 	}
 
-	if err := pc.JoinGroup(iface, multicastGroup); err != nil {
-		startCh <- err
+	var joined []ifaceConn
+
+	for _, iface := range ifaces {
+		if ifaceName != "" && iface.Name != ifaceName {
+			continue
+		}
+
+		ic, err := join(iface)
+
+		if err != nil {
+			log.Printf("Couldn't find players on %s: %v\n", iface.Name, err)
+			continue
+		}
+
+		joined = append(joined, ic)
+	}
+
+	if len(joined) == 0 {
+		if ifaceName != "" {
+			startCh <- fmt.Errorf("no interface %s to find players on", ifaceName)
+		} else {
+			startCh <- errors.New("no interfaces to find players on")
+		}
+
 		return
 	}
 
+	connsMu.Lock()
+	conns = joined
+	connsMu.Unlock()
+
+	startCh <- nil
+
+	var wg sync.WaitGroup
+
+	for _, ic := range joined {
+		wg.Add(1)
+
+		go func(ic ifaceConn) {
+			defer wg.Done()
+			listenOn(ic, selfID, selfPort, delegate)
+		}(ic)
+	}
+
+	wg.Wait()
+}
+
+// join joins the group on the interface, sending from it too.
+func join(iface net.Interface) (ifaceConn, error) {
+	ip := interfaceIP(iface)
+
+	if ip == nil {
+		return ifaceConn{}, errors.New("no IPv4 address")
+	}
+
+	// Unlike a plain UDP socket, this can share the port with other instances
+	// on the same machine, so every one of them hears announcements
+	conn, err := net.ListenMulticastUDP("udp4", &iface, multicastAddr)
+
+	if err != nil {
+		return ifaceConn{}, err
+	}
+
+	pc := ipv4.NewPacketConn(conn)
+
+	if err := pc.SetMulticastInterface(&iface); err != nil {
+		conn.Close()
+		return ifaceConn{}, err
+	}
+
 	if loop, err := pc.MulticastLoopback(); err == nil {
 		if !loop {
 			if err := pc.SetMulticastLoopback(true); err != nil {
-				startCh <- err
-				return
+				conn.Close()
+				return ifaceConn{}, err
 			}
 		}
 	}
 
+	return ifaceConn{iface: iface, ip: ip, conn: conn}, nil
+}
+
+func listenOn(ic ifaceConn, selfID string, selfPort int, delegate MulticastDiscoveryDelegate) {
 	buf := make([]byte, 1024)
-	startCh <- nil
 
 	for {
-		n, _, err := multicastConn.ReadFrom(buf)
+		n, _, err := ic.conn.ReadFrom(buf)
 
 		if err != nil {
-			panic(err)
+			log.Printf("Multicast listen on %s stopped: %v\n", ic.iface.Name, err)
+			return
 		}
 
 		var msg MulticastDiscoveryMessage
 		json.Unmarshal(buf[:n], &msg)
 
 		if msg.ID == selfID {
-			log.Println("Multicast discovery of self")
+			// Another instance sharing our identity would take over our
+			// connections, so it's ignored
+			if !isSelf(msg.Addr, selfPort) {
+				log.Println("Multicast discovery of another instance with our ID at", msg.Addr, "- run each with its own -profile")
+			} else {
+				log.Println("Multicast discovery of self")
+			}
+
 			continue
 		}
 
-		log.Println("Multicast discovery", msg.Addr, msg.ID)
+		log.Println("Multicast discovery", msg.Addr, msg.ID, "on", ic.iface.Name)
 		delegate.ClientDiscovered(msg.Addr, msg.ID)
 
 		// Who knows why this fixes the problem
@@ -73,27 +210,39 @@ func Listen(selfID string, delegate MulticastDiscoveryDelegate, startCh chan err
 	}
 }
 
-func Discover(addr, id string, port int) {
-	if multicastConn == nil {
-		return
-	}
+// isSelf returns true if the address is ours on any interface we joined on.
+func isSelf(addr string, selfPort int) bool {
+	connsMu.RLock()
+	defer connsMu.RUnlock()
 
-	ip, err := GetLocalIP()
-
-	if err != nil {
-		panic(err)
+	for _, ic := range conns {
+		if addr == net.JoinHostPort(ic.ip.String(), fmt.Sprint(selfPort)) {
+			return true
+		}
 	}
 
-	msg := MulticastDiscoveryMessage{
-		Addr: fmt.Sprintf("%s:%d", ip, port),
-		ID:   id,
-	}
+	return false
+}
 
-	data, _ := json.Marshal(msg)
+// Discover announces us on every interface we joined on, each with our
+// address on that interface, so peers are told one they can reach.
+func Discover(addr, id string, port int) {
+	connsMu.RLock()
+	joined := conns
+	connsMu.RUnlock()
+
+	for _, ic := range joined {
+		msg := MulticastDiscoveryMessage{
+			Addr: net.JoinHostPort(ic.ip.String(), fmt.Sprint(port)),
+			ID:   id,
+		}
 
-	log.Println("Writing to multicast...")
+		data, _ := json.Marshal(msg)
 
-	if _, err := multicastConn.WriteTo(data, multicastAddr); err != nil {
-		panic(err)
+		log.Println("Writing to multicast on", ic.iface.Name)
+
+		if _, err := ic.conn.WriteTo(data, multicastAddr); err != nil {
+			log.Printf("Couldn't write to multicast on %s: %v\n", ic.iface.Name, err)
+		}
 	}
 }
@@ -4,12 +4,21 @@ import (
 	"encoding"
 	"net"
 	"sync"
+	"time"
 )
 
 // Actually can't be increased past this number -- kcp-go enforces a packet
 // size limit of 1500 bytes, and 128 bytes are reserved for the header.
 const maxBufferSize = 1372
 
+// How long a disconnecting client has to write the control messages still
+// queued for it
+const drainTimeout = time.Second
+
+// How long a connection a client's moved off of is kept open, for what was
+// in flight on it
+const rebindGrace = 5 * time.Second
+
 type ClientRoutingInfo struct {
 	// Distance to this client. Right now, this is just the number of nodes
 	// packets need to travel through in order to reach this client. In the
@@ -56,11 +65,43 @@ type Client struct {
 
 	conn net.Conn
 
-	sendCh chan []byte
-	recvCh chan []byte
+	// True if we dialed the connection, rather than accepted it
+	dialed bool
+
+	// How to move the connection if our address changes: the token the
+	// client gave us, and the ports it takes connections and error
+	// corrected ones on
+	rebindToken string
+	listenPort  int
+	fecPort     int
+
+	// Readers of the connection, and of any it was swapped for that haven't
+	// closed yet. The last to stop closes recvCh
+	readers int
+
+	// Where to send unreliable messages, if the client takes them, and the
+	// port it said they go to
+	unreliableAddr *net.UDPAddr
+	unreliablePort int
+
+	// Keys unreliable messages are signed with, on the way to the client
+	// and on the way from it
+	unreliableSendKey []byte
+	unreliableRecvKey []byte
+
+	sendQueue *sendQueue
+	recvCh    chan []byte
 
 	State          ConnectionState
 	TimeoutRetries int
+
+	// Frames from the client that couldn't be read, and were dropped
+	BadFrames int
+
+	// KCP profile the connection's tuned with, and whether it has error
+	// correction
+	KCPProfile string
+	FEC        bool
 }
 
 // start begins reading and writing messages with this client.
@@ -68,12 +109,38 @@ func (c *Client) start(conn net.Conn) {
 	c.conn = conn
 
 	c.recvCh = make(chan []byte, maxBufferSize)
-	c.sendCh = make(chan []byte, maxBufferSize)
+	c.sendQueue = newSendQueue()
+	c.readers = 1
 
-	go c.readPump()
+	go c.readPump(conn)
 	go c.writePump()
 }
 
+// rebind moves the client to a new connection. Messages still arriving on
+// the old one are read until it's closed, a moment later, so nothing in
+// flight is lost.
+func (c *Client) rebind(conn net.Conn) {
+	c.Lock()
+	old := c.conn
+	c.conn = conn
+	c.readers++
+	c.Unlock()
+
+	go c.readPump(conn)
+
+	time.AfterFunc(rebindGrace, func() {
+		old.Close()
+	})
+}
+
+// currentConn returns the connection messages are written to.
+func (c *Client) currentConn() net.Conn {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.conn
+}
+
 func (c *Client) disconnect() {
 	c.Lock()
 	if c.State != Connected && c.State != Connecting {
@@ -83,12 +150,14 @@ func (c *Client) disconnect() {
 
 	c.State = Disconnected
 
+	// The writer closes the connection once it's written what control
+	// messages are left, which it's given a moment for, and the reader
+	// stops when it's closed
 	if c.NextHop == "" {
-		close(c.sendCh)
-		close(c.recvCh)
+		c.sendQueue.close()
 
 		if c.conn != nil {
-			c.conn.Close()
+			c.conn.SetWriteDeadline(time.Now().Add(drainTimeout))
 		}
 	}
 	c.Unlock()
@@ -97,14 +166,20 @@ func (c *Client) disconnect() {
 }
 
 // readPump pumps messages from the UDP connection to processMessage.
-func (c *Client) readPump() {
+func (c *Client) readPump(conn net.Conn) {
+	defer c.stopReading()
+
 	buf := make([]byte, maxBufferSize)
 
 	for {
-		n, err := c.conn.Read(buf)
+		n, err := conn.Read(buf)
 
 		if err != nil {
-			c.disconnect()
+			// Connections swapped for another are closed on purpose
+			if c.currentConn() == conn {
+				c.disconnect()
+			}
+
 			return
 		}
 
@@ -125,37 +200,86 @@ func (c *Client) readPump() {
 	}
 }
 
-// writePump pumps messages from the sendCh to the client's UDP connection.
+func (c *Client) stopReading() {
+	c.Lock()
+	c.readers--
+	last := c.readers == 0
+	c.Unlock()
+
+	if last {
+		close(c.recvCh)
+	}
+}
+
+// writePump pumps messages from the send queue to the client's UDP
+// connection, most urgent first.
 func (c *Client) writePump() {
 	for {
-		data, ok := <-c.sendCh
+		data, ok := c.sendQueue.pop()
 		// log.Println("Sending message:", string(data))
 
+		conn := c.currentConn()
+
 		if !ok {
 			c.disconnect()
+			conn.Close()
 			return
 		}
 
-		_, err := c.conn.Write(data)
+		_, err := conn.Write(data)
+
+		// It may have been swapped for another while we were writing
+		if err != nil && c.currentConn() != conn {
+			conn = c.currentConn()
+			_, err = conn.Write(data)
+		}
 
 		if err != nil {
 			c.disconnect()
+			conn.Close()
 			return
 		}
 	}
 }
 
-// send sends a message to the client.
+// Send queues a message for the client. Returns false if the client isn't
+// connected, or its queue is too full to take the message.
 func (c *Client) Send(msg interface{}) bool {
+	// log.Println("SENDING: ", msg)
+	data, _ := msg.(encoding.BinaryMarshaler).MarshalBinary()
+	return c.sendData(sendPriority(msg), coalesceKey(msg), data)
+}
+
+// sendData queues a message that's already been marshaled.
+func (c *Client) sendData(priority Priority, key string, data []byte) bool {
 	c.RLock()
-	if c.State != Connecting && c.State != Connected {
+	if (c.State != Connecting && c.State != Connected) || c.sendQueue == nil {
 		c.RUnlock()
 		return false
 	}
+	queue := c.sendQueue
 	c.RUnlock()
 
-	// log.Println("SENDING: ", msg)
-	data, _ := msg.(encoding.BinaryMarshaler).MarshalBinary()
-	c.sendCh <- data
-	return true
+	return queue.push(priority, key, data)
+}
+
+// Congested returns true if messages are piling up faster than they can be
+// written to the client, so senders can hold back updates that can wait.
+func (c *Client) Congested() bool {
+	backlog, _ := c.SendStats()
+	return backlog >= congestedBacklog
+}
+
+// SendStats returns how many messages are waiting to be written to the
+// client, and how many have been dropped because its queue was full.
+func (c *Client) SendStats() (backlog, dropped int) {
+	c.RLock()
+	queue := c.sendQueue
+	c.RUnlock()
+
+	if queue == nil {
+		return 0, 0
+	}
+
+	return queue.stats()
 }
@@ -0,0 +1,58 @@
+package net
+
+import (
+	"testing"
+	"time"
+)
+
+type nopDelegate struct{}
+
+func (nopDelegate) ClientDisconnected(string) {}
+
+func TestClientRebind(t *testing.T) {
+	transport := NewMemoryTransport(0)
+	oldOurs, oldTheirs := newMemoryPipe(transport, "a", "b")
+	newOurs, newTheirs := newMemoryPipe(transport, "c", "d")
+
+	c := &Client{Delegate: nopDelegate{}, State: Connected}
+	c.start(oldOurs)
+
+	oldTheirs.Write([]byte("before"))
+
+	if got := string(<-c.recvCh); got != "before" {
+		t.Fatalf("read %q before moving", got)
+	}
+
+	c.rebind(newOurs)
+
+	// What's still in flight on the old connection arrives, and the new
+	// one's read too
+	oldTheirs.Write([]byte("late"))
+	newTheirs.Write([]byte("after"))
+
+	got := map[string]bool{string(<-c.recvCh): true, string(<-c.recvCh): true}
+
+	if !got["late"] || !got["after"] {
+		t.Errorf("read %v after moving", got)
+	}
+
+	c.sendQueue.push(PriorityControl, "", []byte("written"))
+	newTheirs.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 16)
+
+	if n, err := newTheirs.Read(buf); err != nil || string(buf[:n]) != "written" {
+		t.Errorf("wrote %q, %v to the new connection", buf[:n], err)
+	}
+
+	// Closing the old connection doesn't disconnect the client
+	oldTheirs.Close()
+	time.Sleep(10 * time.Millisecond)
+
+	c.RLock()
+	state := c.State
+	c.RUnlock()
+
+	if state != Connected {
+		t.Errorf("state = %v after the old connection closed", state)
+	}
+}
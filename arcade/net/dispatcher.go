@@ -0,0 +1,125 @@
+package net
+
+import (
+	"log"
+	"sync"
+)
+
+// Most messages handled at once, across every sender
+const messageWorkers = 8
+
+// Messages waiting from one sender before more are dropped, so a flood can't
+// grow the queue without end
+const maxQueuedPerSender = 256
+
+// Messages waiting from one connection, across every sender ID seen on it, so
+// making up IDs doesn't get a flood past the limit above
+const maxQueuedPerSource = 1024
+
+// dispatcher hands received messages to a pool of workers, so a slow handler
+// only holds up messages from the same sender. Each sender's messages are
+// handled one at a time, in the order they arrived.
+//
+// Senders are told apart by the connection their messages came in on as well
+// as the ID they claim, so nobody can get in the queue of someone else's ID.
+// Workers are started as they're needed and stop when there's nothing left.
+type dispatcher struct {
+	mu sync.Mutex
+
+	maxWorkers int
+	workers    int
+
+	// Senders with messages waiting or being handled
+	queues map[queueKey]*senderQueue
+
+	// Messages waiting from each connection
+	queued map[interface{}]int
+
+	// Senders with messages waiting and nothing being handled, in the order
+	// they should get a worker
+	ready []*senderQueue
+}
+
+type queueKey struct {
+	source   interface{}
+	senderID string
+}
+
+type senderQueue struct {
+	key  queueKey
+	jobs []func()
+}
+
+func newDispatcher(workers int) *dispatcher {
+	return &dispatcher{
+		maxWorkers: workers,
+		queues:     make(map[queueKey]*senderQueue),
+		queued:     make(map[interface{}]int),
+	}
+}
+
+// dispatch queues the job behind the others from the sender on the source
+// connection. It returns false if too many are already waiting and the job
+// was dropped.
+func (d *dispatcher) dispatch(source interface{}, senderID string, job func()) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := queueKey{source, senderID}
+	q, ok := d.queues[key]
+
+	if (ok && len(q.jobs) >= maxQueuedPerSender) || d.queued[source] >= maxQueuedPerSource {
+		log.Printf("Dropped a message from %s, too many waiting\n", senderID)
+		return false
+	}
+
+	if !ok {
+		q = &senderQueue{key: key}
+		d.queues[key] = q
+		d.ready = append(d.ready, q)
+
+		if d.workers < d.maxWorkers {
+			d.workers++
+			go d.work()
+		}
+	}
+
+	q.jobs = append(q.jobs, job)
+	d.queued[source]++
+
+	return true
+}
+
+// work handles one job at a time from whichever sender's been waiting longest,
+// until none are. Senders go to the back after each job, so a busy one can't
+// keep a worker to itself.
+func (d *dispatcher) work() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for len(d.ready) > 0 {
+		q := d.ready[0]
+		d.ready[0] = nil
+		d.ready = d.ready[1:]
+
+		job := q.jobs[0]
+		q.jobs[0] = nil
+		q.jobs = q.jobs[1:]
+
+		d.mu.Unlock()
+		job()
+		d.mu.Lock()
+
+		if d.queued[q.key.source]--; d.queued[q.key.source] == 0 {
+			delete(d.queued, q.key.source)
+		}
+
+		if len(q.jobs) > 0 {
+			d.ready = append(d.ready, q)
+		} else {
+			delete(d.queues, q.key)
+		}
+	}
+
+	d.workers--
+}
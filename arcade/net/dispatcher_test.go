@@ -0,0 +1,168 @@
+package net
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatcherKeepsEachSendersOrder(t *testing.T) {
+	d := newDispatcher(4)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	handled := make(map[string][]int)
+
+	for i := 0; i < 100; i++ {
+		for _, sender := range []string{"a", "b", "c"} {
+			sender, i := sender, i
+			wg.Add(1)
+
+			d.dispatch("conn", sender, func() {
+				defer wg.Done()
+
+				mu.Lock()
+				handled[sender] = append(handled[sender], i)
+				mu.Unlock()
+			})
+		}
+	}
+
+	wg.Wait()
+
+	for sender, order := range handled {
+		for i, got := range order {
+			if got != i {
+				t.Fatalf("%s's message %d was handled %dth", sender, got, i)
+			}
+		}
+	}
+}
+
+func TestDispatcherDoesntWaitOnOtherSenders(t *testing.T) {
+	d := newDispatcher(2)
+
+	unblock := make(chan bool)
+	defer close(unblock)
+
+	d.dispatch("conn", "slow", func() {
+		<-unblock
+	})
+
+	done := make(chan bool)
+
+	d.dispatch("conn", "fast", func() {
+		done <- true
+	})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a slow sender held up another")
+	}
+}
+
+func TestDispatcherDropsFloods(t *testing.T) {
+	d := newDispatcher(1)
+
+	unblock := make(chan bool)
+	defer close(unblock)
+
+	started := make(chan bool)
+
+	d.dispatch("conn", "flood", func() {
+		started <- true
+		<-unblock
+	})
+
+	<-started
+
+	for i := 0; i < maxQueuedPerSender; i++ {
+		if !d.dispatch("conn", "flood", func() {}) {
+			t.Fatalf("dropped message %d of %d", i, maxQueuedPerSender)
+		}
+	}
+
+	if d.dispatch("conn", "flood", func() {}) {
+		t.Error("queued more than the limit")
+	}
+}
+
+func TestDispatcherCapsEachConnection(t *testing.T) {
+	d := newDispatcher(1)
+
+	unblock := make(chan bool)
+	defer close(unblock)
+
+	started := make(chan bool)
+
+	d.dispatch("victim", "alice", func() {
+		started <- true
+		<-unblock
+	})
+
+	<-started
+
+	// Making up an ID for every message gets no further than one sender
+	// would, and doesn't get anywhere near alice's own queue
+	queued := 0
+
+	for i := 0; i < 2*maxQueuedPerSource; i++ {
+		if d.dispatch("flood", strconv.Itoa(i), func() {}) {
+			queued++
+		}
+	}
+
+	if queued != maxQueuedPerSource {
+		t.Errorf("queued %d from one connection, want %d", queued, maxQueuedPerSource)
+	}
+
+	if d.dispatch("flood", "alice", func() {}) {
+		t.Error("queued past the connection's limit by claiming alice's ID")
+	}
+
+	if !d.dispatch("victim", "alice", func() {}) {
+		t.Error("alice's own connection was turned away")
+	}
+}
+
+func TestDispatcherWorkersStop(t *testing.T) {
+	d := newDispatcher(4)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		d.dispatch("conn", strconv.Itoa(i%10), wg.Done)
+	}
+
+	wg.Wait()
+
+	deadline := time.Now().Add(time.Second)
+
+	for {
+		d.mu.Lock()
+		workers, queues, queued := d.workers, len(d.queues), len(d.queued)
+		d.mu.Unlock()
+
+		if workers == 0 && queues == 0 && queued == 0 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("%d workers still running with %d queues", workers, queues)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan bool)
+	d.dispatch("conn", "a", func() { done <- true })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("nothing started handling a message after the workers stopped")
+	}
+}
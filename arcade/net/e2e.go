@@ -0,0 +1,459 @@
+package net
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"arcade/arcade/message"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Messages held for a peer while we agree on a key with it, past which the
+// oldest are dropped
+const maxPendingSealed = 64
+
+// How far behind the newest sealed message from a peer one can be and still
+// be opened. Messages are queued by how urgent they are, so they don't
+// always arrive in the order they were sealed.
+const sealedWindowSize = 1024
+
+// Public messages are read by the distributor as it relays them, like lobby
+// listings it limits, so they're relayed without end-to-end encryption.
+type Public interface {
+	RelayInClear() bool
+}
+
+// SessionKeyMessage is half of an end-to-end key exchange: the sender's
+// X25519 key for the session with the recipient, and proof from the player
+// it's from that it's theirs.
+type SessionKeyMessage struct {
+	message.Message
+
+	PublicKey []byte
+	Proof     []byte
+
+	// Whether this answers the recipient's key, which isn't answered again
+	Reply bool
+}
+
+func NewSessionKeyMessage(publicKey, proof []byte, reply bool) *SessionKeyMessage {
+	return &SessionKeyMessage{
+		Message:   message.Message{Type: "session_key"},
+		PublicKey: publicKey,
+		Proof:     proof,
+		Reply:     reply,
+	}
+}
+
+func (m SessionKeyMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func (m SessionKeyMessage) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, &m)
+}
+
+// SealedMessage is a message to a player reached through the distributor,
+// compressed and encrypted with the session key only the two of them have.
+// How urgent it is stays readable, so it's still queued like the message
+// inside would be.
+type SealedMessage struct {
+	message.Message
+
+	Priority Priority
+	Coalesce string
+	Nonce    []byte
+	Box      []byte
+
+	// Counts up with each message sealed for the recipient, so the
+	// distributor can't pass one on twice
+	Seq uint64
+}
+
+func (m SealedMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func (m SealedMessage) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, &m)
+}
+
+func (m SealedMessage) SendPriority() Priority {
+	return m.Priority
+}
+
+func (m SealedMessage) CoalesceKey() string {
+	return m.Coalesce
+}
+
+// e2eSession is what we have of the end-to-end session with a peer.
+type e2eSession struct {
+	private, public []byte
+
+	// Their key, and the cipher the two make, once they've sent it
+	theirs []byte
+	aead   cipher.AEAD
+
+	// Messages to send once there's a cipher, and when we last asked for it
+	pending []pendingSeal
+	asked   time.Time
+
+	// Sequence number of the last message we sealed for them, and which of
+	// theirs we've opened with the cipher
+	sent   uint64
+	opened sealedWindow
+}
+
+// sealedWindow remembers which sequence numbers have been opened, of those
+// not too far behind the newest.
+type sealedWindow struct {
+	newest uint64
+	seen   [sealedWindowSize / 64]uint64
+}
+
+// accept returns true if the sequence number's new, and remembers it.
+func (w *sealedWindow) accept(seq uint64) bool {
+	if seq == 0 {
+		return false
+	}
+
+	if seq > w.newest {
+		// Forget what's fallen out of the window
+		if seq-w.newest >= sealedWindowSize {
+			w.seen = [sealedWindowSize / 64]uint64{}
+		} else {
+			for i := w.newest + 1; i <= seq; i++ {
+				w.seen[i%sealedWindowSize/64] &^= 1 << (i % 64)
+			}
+		}
+
+		w.newest = seq
+	} else if w.newest-seq >= sealedWindowSize {
+		return false
+	}
+
+	word, bit := seq%sealedWindowSize/64, uint64(1)<<(seq%64)
+
+	if w.seen[word]&bit != 0 {
+		return false
+	}
+
+	w.seen[word] |= bit
+	return true
+}
+
+type pendingSeal struct {
+	client *Client
+	msg    interface{}
+}
+
+// seal encrypts the message for the client. Until we've agreed a key with
+// it, the message is held and nil returned, with whether it was held.
+func (n *Network) seal(client *Client, msg interface{}, data []byte) ([]byte, bool) {
+	client.RLock()
+	id := client.ID
+	client.RUnlock()
+
+	n.e2eMu.Lock()
+	s := n.e2eSession(id)
+
+	if s.aead == nil {
+		s.pending = append(s.pending, pendingSeal{client, msg})
+
+		if len(s.pending) > maxPendingSealed {
+			s.pending = s.pending[1:]
+		}
+
+		ask := time.Since(s.asked) >= sendAndReceiveTimeout
+		public := s.public
+
+		if ask {
+			s.asked = time.Now()
+		}
+		n.e2eMu.Unlock()
+
+		if ask {
+			n.sendSessionKey(client, public, false)
+		}
+
+		return nil, true
+	}
+
+	aead := s.aead
+	s.sent++
+	seq := s.sent
+	n.e2eMu.Unlock()
+
+	var compressed bytes.Buffer
+	w, _ := flate.NewWriter(&compressed, flate.BestSpeed)
+	w.Write(data)
+	w.Close()
+
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, false
+	}
+
+	sealed := &SealedMessage{
+		Message:  message.Message{Type: "sealed", SenderID: n.me, RecipientID: id},
+		Priority: sendPriority(msg),
+		Coalesce: coalesceKey(msg),
+		Nonce:    nonce,
+		Box:      aead.Seal(nil, nonce, compressed.Bytes(), sealedWith(n.me, id, seq)),
+		Seq:      seq,
+	}
+
+	sealedData, _ := sealed.MarshalBinary()
+
+	return sealedData, true
+}
+
+// open decrypts a sealed message from the sender, returning false if it
+// can't be, in which case the sender's asked for a new key. Ones opened
+// before are dropped.
+func (n *Network) open(senderID string, data []byte) ([]byte, bool) {
+	var sealed SealedMessage
+
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return nil, false
+	}
+
+	n.e2eMu.Lock()
+	s := n.e2eSession(senderID)
+	aead := s.aead
+	n.e2eMu.Unlock()
+
+	if aead != nil && len(sealed.Nonce) == aead.NonceSize() {
+		compressed, err := aead.Open(nil, sealed.Nonce, sealed.Box, sealedWith(senderID, n.me, sealed.Seq))
+
+		if err == nil {
+			n.e2eMu.Lock()
+			fresh := s.aead == aead && s.opened.accept(sealed.Seq)
+			n.e2eMu.Unlock()
+
+			if !fresh {
+				log.Println("Dropped a sealed message from", senderID, "that was opened before")
+				return nil, false
+			}
+
+			return stampOrigin(compressed, sealed.Origin)
+		}
+	}
+
+	// They've a key we don't, like if we've restarted since agreeing one
+	n.e2eMu.Lock()
+	ask := time.Since(s.asked) >= sendAndReceiveTimeout
+	public := s.public
+
+	if ask {
+		s.asked = time.Now()
+	}
+	n.e2eMu.Unlock()
+
+	if client, ok := n.GetClient(senderID); ok && ask {
+		n.sendSessionKey(client, public, false)
+	}
+
+	return nil, false
+}
+
+// stampOrigin decompresses an opened message, and gives it the origin the
+// distributor stamped on the sealed one, so it's limited as coming from
+// where the sealed one did.
+func stampOrigin(compressed []byte, origin string) ([]byte, bool) {
+	plain, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(compressed)), maxBufferSize*16))
+
+	if err != nil {
+		return nil, false
+	}
+
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(plain, &fields); err != nil {
+		return nil, false
+	}
+
+	delete(fields, "Origin")
+
+	if origin != "" {
+		fields["Origin"], _ = json.Marshal(origin)
+	}
+
+	plain, err = json.Marshal(fields)
+	return plain, err == nil
+}
+
+// receiveSessionKey takes the sender's half of a key exchange, answering it
+// with ours, and sends what was waiting for the key.
+func (n *Network) receiveSessionKey(senderID string, data []byte) {
+	var msg SessionKeyMessage
+
+	if err := json.Unmarshal(data, &msg); err != nil || len(msg.PublicKey) != curve25519.PointSize {
+		return
+	}
+
+	// Without proof from the player, the distributor could swap in its own
+	// key and read everything
+	if n.VerifySessionKey != nil && !n.VerifySessionKey(senderID, msg.PublicKey, msg.Proof) {
+		log.Println("Dropped a session key that isn't from", senderID)
+		return
+	}
+
+	n.e2eMu.Lock()
+	s := n.e2eSession(senderID)
+	public := s.public
+	var pending []pendingSeal
+
+	if s.aead == nil || !bytes.Equal(s.theirs, msg.PublicKey) {
+		aead, err := sessionCipher(s.private, msg.PublicKey, n.me, senderID)
+
+		if err != nil {
+			n.e2eMu.Unlock()
+			return
+		}
+
+		s.theirs = msg.PublicKey
+		s.aead = aead
+		s.opened = sealedWindow{}
+		pending = s.pending
+		s.pending = nil
+	}
+	n.e2eMu.Unlock()
+
+	client, ok := n.GetClient(senderID)
+
+	if !ok {
+		return
+	}
+
+	if !msg.Reply {
+		n.sendSessionKey(client, public, true)
+	}
+
+	for _, p := range pending {
+		n.SendRaw(p.client, p.msg)
+	}
+}
+
+func (n *Network) sendSessionKey(client *Client, public []byte, reply bool) {
+	var proof []byte
+
+	if n.SignSessionKey != nil {
+		proof = n.SignSessionKey(public)
+	}
+
+	n.Send(client, NewSessionKeyMessage(public, proof, reply))
+}
+
+// e2eSession returns the session with the peer, starting one with a new key
+// if there isn't one. Expects e2eMu to be held.
+func (n *Network) e2eSession(id string) *e2eSession {
+	if s, ok := n.e2eSessions[id]; ok {
+		return s
+	}
+
+	private := make([]byte, curve25519.ScalarSize)
+	rand.Read(private)
+	public, _ := curve25519.X25519(private, curve25519.Basepoint)
+
+	s := &e2eSession{private: private, public: public}
+	n.e2eSessions[id] = s
+
+	return s
+}
+
+// endSession forgets the session with the peer, so the next one starts with
+// new keys.
+func (n *Network) endSession(id string) {
+	n.e2eMu.Lock()
+	defer n.e2eMu.Unlock()
+
+	delete(n.e2eSessions, id)
+}
+
+// sealable returns true if the message to the client, which it's relayed to,
+// should be sealed: everything but key exchanges, public messages and what's
+// for distributors.
+func (n *Network) sealable(client *Client, msg interface{}) bool {
+	client.RLock()
+	distributor := client.Distributor
+	client.RUnlock()
+
+	switch msg := msg.(type) {
+	case *SessionKeyMessage, *SealedMessage:
+		return false
+	case Public:
+		if msg.RelayInClear() {
+			return false
+		}
+	}
+
+	return !n.distributor && !distributor
+}
+
+// mustBeSealed returns true if the message for us, which came in on the
+// connection, should have been sealed but wasn't. That's anything a peer
+// we've agreed a key with would have sealed, passed on by the distributor.
+func (n *Network) mustBeSealed(c *Client, senderID, messageType string) bool {
+	c.RLock()
+	relayed := c.Distributor && c.ID != senderID
+	c.RUnlock()
+
+	if n.distributor || !relayed || messageType == "session_key" || messageType == "sealed" {
+		return false
+	}
+
+	if msg, ok := message.Example(messageType); ok {
+		if public, ok := msg.(Public); ok && public.RelayInClear() {
+			return false
+		}
+	}
+
+	n.e2eMu.Lock()
+	defer n.e2eMu.Unlock()
+
+	s, ok := n.e2eSessions[senderID]
+	return ok && s.aead != nil
+}
+
+// sessionCipher makes the cipher for a session from our private key and the
+// peer's public one. Both ends get the same, whichever of them works it out.
+func sessionCipher(private, theirs []byte, ourID, theirID string) (cipher.AEAD, error) {
+	shared, err := curve25519.X25519(private, theirs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids := ourID + theirID
+
+	if theirID < ourID {
+		ids = theirID + ourID
+	}
+
+	key := make([]byte, chacha20poly1305.KeySize)
+
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, []byte(ids), []byte("arcade session")), key); err != nil {
+		return nil, err
+	}
+
+	return chacha20poly1305.NewX(key)
+}
+
+// sealedWith is what a sealed message is bound to, so the distributor can't
+// pass it off as from or to someone else, or as another in the sequence.
+func sealedWith(senderID, recipientID string, seq uint64) []byte {
+	return []byte(senderID + "/" + recipientID + "/" + strconv.FormatUint(seq, 10))
+}
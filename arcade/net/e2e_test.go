@@ -0,0 +1,237 @@
+package net
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"arcade/arcade/message"
+)
+
+type secretMessage struct {
+	message.Message
+	Secret string
+}
+
+func (m secretMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// relayAll makes the network pass on messages that aren't for it, like a
+// distributor, and records the types it saw.
+func relayAll(n *Network, seen func(messageType string)) {
+	message.AddListener(message.Listener{
+		ServerID:    n.me,
+		Distributor: true,
+		Handle: func(c, msg interface{}) interface{} {
+			base := reflect.ValueOf(msg).Elem().FieldByName("Message").Interface().(message.Message)
+
+			if base.RecipientID == n.me {
+				return nil
+			}
+
+			seen(base.Type)
+
+			if recipient, ok := n.GetClient(base.RecipientID); ok {
+				n.SendRaw(recipient, msg)
+			}
+
+			return nil
+		},
+	})
+}
+
+func TestSealedRelay(t *testing.T) {
+	message.Register(secretMessage{Message: message.Message{Type: "secret"}})
+
+	transport := NewMemoryTransport(time.Millisecond)
+
+	distributor := NewNetwork("e2e-distributor", 1, true)
+	distributor.Transport = transport
+	signalReplies(distributor)
+	listenMemory(t, distributor, transport, "distributor:1")
+
+	var mu sync.Mutex
+	relayed := make(map[string]int)
+
+	relayAll(distributor, func(messageType string) {
+		mu.Lock()
+		relayed[messageType]++
+		mu.Unlock()
+	})
+
+	received := make(chan string, 1)
+	players := make([]*Network, 2)
+
+	for i, id := range []string{"e2e-a", "e2e-b"} {
+		players[i] = NewNetwork(id, i+2, false)
+		players[i].Transport = transport
+		signalReplies(players[i])
+
+		if _, err := players[i].Connect("distributor:1", "", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	message.AddListener(message.Listener{
+		ServerID: "e2e-b",
+		Handle: func(c, msg interface{}) interface{} {
+			if secret, ok := msg.(*secretMessage); ok {
+				select {
+				case received <- secret.Secret:
+				default:
+				}
+			}
+
+			return nil
+		},
+	})
+
+	// Routes to each other go out from the distributor
+	var toB *Client
+
+	for deadline := time.Now().Add(2 * time.Second); toB == nil && time.Now().Before(deadline); {
+		distributor.PropagateRoutes()
+		time.Sleep(20 * time.Millisecond)
+		toB, _ = players[0].GetClient("e2e-b")
+	}
+
+	if toB == nil || toB.NextHop == "" {
+		t.Fatal("a never heard of b through the distributor")
+	}
+
+	players[0].Send(toB, &secretMessage{Message: message.Message{Type: "secret"}, Secret: "hunter2"})
+
+	select {
+	case secret := <-received:
+		if secret != "hunter2" {
+			t.Errorf("b got %q", secret)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("b never got the message")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if relayed["secret"] != 0 || relayed["sealed"] == 0 || relayed["session_key"] < 2 {
+		t.Errorf("the distributor relayed %v", relayed)
+	}
+}
+
+func TestSessionKeyProof(t *testing.T) {
+	a := NewNetwork("e2e-proof-a", 1, false)
+	b := NewNetwork("e2e-proof-b", 2, false)
+
+	a.SignSessionKey = func(publicKey []byte) []byte { return []byte("a") }
+	b.VerifySessionKey = func(id string, publicKey, proof []byte) bool { return string(proof) == "b" }
+
+	a.e2eMu.Lock()
+	data, _ := NewSessionKeyMessage(a.e2eSession("e2e-proof-b").public, a.SignSessionKey(nil), false).MarshalBinary()
+	a.e2eMu.Unlock()
+
+	b.receiveSessionKey("e2e-proof-a", data)
+
+	b.e2eMu.Lock()
+	defer b.e2eMu.Unlock()
+
+	if s := b.e2eSessions["e2e-proof-a"]; s != nil && s.aead != nil {
+		t.Error("took a key without proof it was theirs")
+	}
+}
+
+// agreeKeys has the networks agree a session key, as if they'd swapped
+// session_key messages.
+func agreeKeys(a, b *Network) {
+	a.e2eMu.Lock()
+	fromA, _ := NewSessionKeyMessage(a.e2eSession(b.me).public, nil, false).MarshalBinary()
+	a.e2eMu.Unlock()
+
+	b.e2eMu.Lock()
+	fromB, _ := NewSessionKeyMessage(b.e2eSession(a.me).public, nil, true).MarshalBinary()
+	b.e2eMu.Unlock()
+
+	b.receiveSessionKey(a.me, fromA)
+	a.receiveSessionKey(b.me, fromB)
+}
+
+func TestSealedReplays(t *testing.T) {
+	a := NewNetwork("e2e-replay-a", 1, false)
+	b := NewNetwork("e2e-replay-b", 2, false)
+	agreeKeys(a, b)
+
+	msg := &secretMessage{Message: message.Message{Type: "secret", SenderID: a.me, RecipientID: b.me}, Secret: "hunter2"}
+	data, _ := msg.MarshalBinary()
+
+	first, _ := a.seal(&Client{ID: b.me}, msg, data)
+	second, _ := a.seal(&Client{ID: b.me}, msg, data)
+
+	if _, ok := b.open(a.me, second); !ok {
+		t.Fatal("couldn't open a sealed message")
+	}
+
+	// Arriving out of order is fine, twice isn't
+	if _, ok := b.open(a.me, first); !ok {
+		t.Error("couldn't open a message that came in late")
+	}
+
+	if _, ok := b.open(a.me, second); ok {
+		t.Error("opened the same message twice")
+	}
+
+	// Nor can it be passed off as another in the sequence
+	var sealed SealedMessage
+	json.Unmarshal(first, &sealed)
+	sealed.Seq = 5
+	renumbered, _ := sealed.MarshalBinary()
+
+	if _, ok := b.open(a.me, renumbered); ok {
+		t.Error("opened a message given a new sequence number")
+	}
+}
+
+func TestSealedWindow(t *testing.T) {
+	var w sealedWindow
+
+	if w.accept(0) {
+		t.Error("accepted a message that wasn't numbered")
+	}
+
+	for _, seq := range []uint64{1, 3, 2, sealedWindowSize + 10, 20} {
+		if !w.accept(seq) {
+			t.Errorf("refused %d", seq)
+		}
+	}
+
+	for _, seq := range []uint64{3, 5, 20, sealedWindowSize + 10} {
+		if w.accept(seq) {
+			t.Errorf("accepted %d again or too late", seq)
+		}
+	}
+}
+
+func TestUnsealedRelayRefused(t *testing.T) {
+	a := NewNetwork("e2e-clear-a", 1, false)
+	b := NewNetwork("e2e-clear-b", 2, false)
+	distributor := &Client{ID: "e2e-clear-distributor", ClientRoutingInfo: ClientRoutingInfo{Distributor: true}}
+
+	if b.mustBeSealed(distributor, a.me, "secret") {
+		t.Error("refused a message from a peer we've no key with")
+	}
+
+	agreeKeys(a, b)
+
+	if !b.mustBeSealed(distributor, a.me, "secret") {
+		t.Error("took a message in the clear from a peer we've a key with")
+	}
+
+	if b.mustBeSealed(distributor, a.me, "session_key") || b.mustBeSealed(distributor, distributor.ID, "secret") {
+		t.Error("refused a message that's never sealed")
+	}
+
+	if b.mustBeSealed(&Client{ID: a.me}, a.me, "secret") {
+		t.Error("refused a message straight from the peer")
+	}
+}
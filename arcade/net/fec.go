@@ -0,0 +1,181 @@
+package net
+
+import (
+	"log"
+	"net"
+	"strconv"
+
+	"github.com/xtaci/kcp-go/v5"
+)
+
+// Reed-Solomon shards for connections with error correction: every ten
+// packets are sent with three more, so any three of the thirteen can be lost
+// without waiting for a resend, for 30% more bandwidth.
+const (
+	fecDataShards   = 10
+	fecParityShards = 3
+)
+
+// Whether a peer dialing us can use error correction, and would like to
+type FECMode string
+
+const (
+	FECOff FECMode = "off"
+	FECOn  FECMode = "on"
+)
+
+// ListenFEC opens a second KCP port, with error correction, that peers who
+// want it move their connection to after connecting. KCP can't turn error
+// correction on for a connection that's already open, and both ends have to
+// agree on it, so it gets a port of its own.
+func (n *Network) ListenFEC() error {
+	listener, err := kcp.ListenWithOptions(":0", nil, fecDataShards, fecParityShards)
+
+	if err != nil {
+		return err
+	}
+
+	n.Lock()
+	n.fecListener = listener
+	n.Unlock()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+
+			if err != nil {
+				return
+			}
+
+			go n.adoptFEC(conn)
+		}
+	}()
+
+	return nil
+}
+
+// FECPort returns the port error corrected connections are taken on, or 0 if
+// there isn't one.
+func (n *Network) FECPort() int {
+	n.RLock()
+	defer n.RUnlock()
+
+	if n.fecListener == nil {
+		return 0
+	}
+
+	return n.fecListener.Addr().(*net.UDPAddr).Port
+}
+
+// SetFEC picks whether we ask for error correction on connections we make,
+// and give it to peers that dial us, from now on.
+func (n *Network) SetFEC(on bool) {
+	n.Lock()
+	defer n.Unlock()
+
+	n.fec = on
+}
+
+// fecOffer returns what we say about error correction when dialing the
+// client. Only the side that dialed moves the connection, so the other
+// doesn't offer.
+func (n *Network) fecOffer(c *Client) FECMode {
+	c.RLock()
+	dialed, fec := c.dialed, c.FEC
+	c.RUnlock()
+
+	if !dialed || fec || n.Transport != KCP {
+		return ""
+	}
+
+	n.RLock()
+	defer n.RUnlock()
+
+	if n.fec {
+		return FECOn
+	}
+
+	return FECOff
+}
+
+// acceptFEC decides whether a peer that dialed us moves to error
+// correction, which it does if either of us wants it, and returns the port
+// for it to move to, or 0 if not.
+func (n *Network) acceptFEC(offer FECMode) int {
+	port := n.FECPort()
+
+	n.RLock()
+	want := n.fec
+	n.RUnlock()
+
+	if port == 0 || offer == "" || offer == FECOff && !want {
+		return 0
+	}
+
+	return port
+}
+
+// adoptFEC moves a peer onto an error corrected connection, once it says
+// which of our connections it takes over from.
+func (n *Network) adoptFEC(conn net.Conn) {
+	first, err := readFirst(conn)
+
+	if err != nil || !n.adopt(conn, first, true) {
+		conn.Close()
+	}
+}
+
+// moveToFEC moves our connection to the client onto the error corrected
+// port it offered.
+func (n *Network) moveToFEC(c *Client, port int) {
+	c.RLock()
+	host, _, err := net.SplitHostPort(c.Addr)
+	token := c.rebindToken
+	c.RUnlock()
+
+	if err != nil || token == "" {
+		return
+	}
+
+	conn, err := dialFEC(net.JoinHostPort(host, strconv.Itoa(port)))
+
+	if err != nil {
+		log.Println("Couldn't turn on error correction:", err)
+		return
+	}
+
+	data, _ := NewRebindMessage(token).MarshalBinary()
+
+	if _, err := conn.Write(data); err != nil {
+		log.Println("Couldn't turn on error correction:", err)
+		conn.Close()
+		return
+	}
+
+	c.rebind(conn)
+
+	c.Lock()
+	c.FEC = true
+	c.fecPort = port
+	profile := c.KCPProfile
+	c.Unlock()
+
+	c.setKCPProfile(profile)
+}
+
+func dialFEC(addr string) (net.Conn, error) {
+	return kcp.DialWithOptions(addr, nil, fecDataShards, fecParityShards)
+}
+
+// KCPStats returns the fraction of KCP segments we've sent that were resent,
+// and how many lost packets error correction has recovered, across every
+// connection.
+func KCPStats() (resent float64, recovered uint64) {
+	snmp := kcp.DefaultSnmp.Copy()
+
+	if snmp.OutSegs > 0 {
+		resent = float64(snmp.RetransSegs) / float64(snmp.OutSegs)
+	}
+
+	return resent, snmp.FECRecovered
+}
@@ -0,0 +1,138 @@
+package net
+
+import (
+	"github.com/xtaci/kcp-go/v5"
+)
+
+// KCPProfile is a set of KCP settings for a kind of link. kcp-go's own
+// defaults wait too long to resend for fast games.
+type KCPProfile struct {
+	Name string
+
+	// Resend without waiting out the usual timeout, and every Interval
+	// milliseconds, after Resend later packets are acked, and without
+	// backing off when packets are lost
+	NoDelay      bool
+	Interval     int
+	Resend       int
+	NoCongestion bool
+
+	// Packets in flight each way, and the largest we send
+	SendWindow int
+	RecvWindow int
+	MTU        int
+}
+
+// KCPProfiles are the profiles players can pick from, from the most eager to
+// the most cautious. When two peers pick different ones, the connection uses
+// whichever's later.
+var KCPProfiles = []KCPProfile{
+	{Name: "low-latency", NoDelay: true, Interval: 10, Resend: 2, NoCongestion: true, SendWindow: 128, RecvWindow: 128, MTU: 1400},
+	{Name: "lossy-wifi", NoDelay: true, Interval: 20, Resend: 2, NoCongestion: true, SendWindow: 256, RecvWindow: 256, MTU: 1200},
+	{Name: "conservative", NoDelay: false, Interval: 40, Resend: 0, NoCongestion: false, SendWindow: 32, RecvWindow: 128, MTU: 1400},
+}
+
+// The profile used until another's picked
+const DefaultKCPProfile = "low-latency"
+
+// KCPProfileNames returns the names of every profile, for settings.
+func KCPProfileNames() []string {
+	names := make([]string, len(KCPProfiles))
+
+	for i, p := range KCPProfiles {
+		names[i] = p.Name
+	}
+
+	return names
+}
+
+// findKCPProfile returns the profile with the name, and its place in
+// KCPProfiles.
+func findKCPProfile(name string) (KCPProfile, int, bool) {
+	for i, p := range KCPProfiles {
+		if p.Name == name {
+			return p, i, true
+		}
+	}
+
+	return KCPProfile{}, 0, false
+}
+
+// negotiateKCPProfile returns the profile for a connection between peers
+// that picked the two given: the more cautious one, so a peer on a bad link
+// isn't flooded by one that assumes a good one. Names we don't know are
+// ignored, in case the other end's newer than us.
+func negotiateKCPProfile(ours, theirs string) string {
+	_, i, ok := findKCPProfile(ours)
+
+	if !ok {
+		ours, i = DefaultKCPProfile, 0
+	}
+
+	if _, j, ok := findKCPProfile(theirs); ok && j > i {
+		return theirs
+	}
+
+	return ours
+}
+
+// SetKCPProfile picks the profile we ask for on connections made from now
+// on. Returns false if there isn't one with the name.
+func (n *Network) SetKCPProfile(name string) bool {
+	if _, _, ok := findKCPProfile(name); !ok {
+		return false
+	}
+
+	n.Lock()
+	defer n.Unlock()
+
+	n.kcpProfile = name
+	return true
+}
+
+// KCPProfile returns the profile we ask for on new connections.
+func (n *Network) KCPProfile() string {
+	n.RLock()
+	defer n.RUnlock()
+
+	return n.kcpProfile
+}
+
+// setKCPProfile tunes the connection to the client with the profile, if it
+// runs over KCP.
+func (c *Client) setKCPProfile(name string) {
+	p, _, ok := findKCPProfile(name)
+
+	if !ok {
+		return
+	}
+
+	c.Lock()
+	c.KCPProfile = name
+	conn := c.conn
+	c.Unlock()
+
+	if peeked, ok := conn.(*peekedConn); ok {
+		conn = peeked.Conn
+	}
+
+	session, ok := conn.(*kcp.UDPSession)
+
+	if !ok {
+		return
+	}
+
+	noDelay, noCongestion := 0, 0
+
+	if p.NoDelay {
+		noDelay = 1
+	}
+
+	if p.NoCongestion {
+		noCongestion = 1
+	}
+
+	session.SetNoDelay(noDelay, p.Interval, p.Resend, noCongestion)
+	session.SetWindowSize(p.SendWindow, p.RecvWindow)
+	session.SetMtu(p.MTU)
+}
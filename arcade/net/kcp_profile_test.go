@@ -0,0 +1,20 @@
+package net
+
+import "testing"
+
+func TestNegotiateKCPProfile(t *testing.T) {
+	for _, c := range []struct {
+		ours, theirs, want string
+	}{
+		{"low-latency", "low-latency", "low-latency"},
+		{"low-latency", "conservative", "conservative"},
+		{"conservative", "lossy-wifi", "conservative"},
+		{"lossy-wifi", "", "lossy-wifi"},
+		{"lossy-wifi", "carrier-pigeon", "lossy-wifi"},
+		{"", "lossy-wifi", "lossy-wifi"},
+	} {
+		if got := negotiateKCPProfile(c.ours, c.theirs); got != c.want {
+			t.Errorf("%q and %q: got %q, want %q", c.ours, c.theirs, got, c.want)
+		}
+	}
+}
@@ -33,7 +33,22 @@ func (n *Network) processMessage(client, msg interface{}) interface{} {
 
 		n.clients.Store(msg.Message.SenderID, c)
 
-		return NewPongMessage(n.distributor)
+		c.setUnreliablePort(msg.UnreliablePort)
+		c.setUnreliableSendKey(msg.UnreliableKey)
+		c.setRebindInfo(msg.RebindToken, msg.Port)
+		n.resetUnreliable(msg.Message.SenderID)
+
+		pong := NewPongMessage(n.distributor)
+		pong.UnreliablePort = n.UnreliablePort()
+		pong.UnreliableKey = c.issueUnreliableKey()
+		pong.KCPProfile = negotiateKCPProfile(n.KCPProfile(), msg.KCPProfile)
+		pong.FECPort = n.acceptFEC(msg.FEC)
+		pong.RebindToken = n.issueRebindToken(c)
+		pong.Port = n.Port()
+
+		c.setKCPProfile(pong.KCPProfile)
+
+		return pong
 	case *RoutingMessage:
 		n.UpdateRoutes(c, msg.Distances)
 	}
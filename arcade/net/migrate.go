@@ -0,0 +1,279 @@
+package net
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"arcade/arcade/message"
+
+	"github.com/google/uuid"
+)
+
+// How long a new connection has to send its first message, which says
+// whether it's a peer moving an old connection
+const handshakeTimeout = 5 * time.Second
+
+// How often we check whether our machine's addresses have changed
+const addrCheckInterval = time.Second
+
+// RebindMessage is the first message on a connection that takes over from
+// another, saying which one with the token it was given.
+type RebindMessage struct {
+	message.Message
+	Token string
+}
+
+func NewRebindMessage(token string) *RebindMessage {
+	return &RebindMessage{
+		Message: message.Message{Type: "rebind"},
+		Token:   token,
+	}
+}
+
+func (m RebindMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func (m RebindMessage) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, &m)
+}
+
+// peekedConn gives back a message that was read from the connection before
+// it was handed on.
+type peekedConn struct {
+	net.Conn
+	first []byte
+}
+
+func (c *peekedConn) Read(b []byte) (int, error) {
+	if c.first != nil {
+		n := copy(b, c.first)
+		c.first = nil
+		return n, nil
+	}
+
+	return c.Conn.Read(b)
+}
+
+// Accept takes a connection made to us, which is either a peer moving one
+// of its connections to a new address or a new peer.
+func (n *Network) Accept(conn net.Conn) {
+	first, err := readFirst(conn)
+
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	if n.adopt(conn, first, false) {
+		return
+	}
+
+	n.Connect(conn.RemoteAddr().String(), "", &peekedConn{Conn: conn, first: first})
+}
+
+// readFirst reads the first message on a new connection. Whoever dialed
+// speaks first, with a ping or to rebind.
+func readFirst(conn net.Conn) ([]byte, error) {
+	buf := make([]byte, maxBufferSize)
+
+	conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	size, err := conn.Read(buf)
+	conn.SetReadDeadline(time.Time{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return buf[:size], nil
+}
+
+// adopt moves a client onto the connection if the message is a rebind with
+// a token we gave them, and returns whether it was.
+func (n *Network) adopt(conn net.Conn, first []byte, fec bool) bool {
+	var msg RebindMessage
+
+	if err := json.Unmarshal(first, &msg); err != nil || msg.Type != "rebind" {
+		return false
+	}
+
+	n.rebindTokensMu.Lock()
+	c, ok := n.rebindTokens[msg.Token]
+	n.rebindTokensMu.Unlock()
+
+	if ok {
+		c.RLock()
+		ok = c.State == Connected && c.NextHop == ""
+		c.RUnlock()
+	}
+
+	if !ok {
+		conn.Close()
+		return true
+	}
+
+	c.rebind(conn)
+
+	// They dialed us, so wherever they dialed from is where they are now
+	c.Lock()
+	c.Addr = conn.RemoteAddr().String()
+	c.dialed = false
+	c.FEC = fec
+	port := c.unreliablePort
+	profile := c.KCPProfile
+	c.Unlock()
+
+	c.setUnreliablePort(port)
+	c.setKCPProfile(profile)
+
+	return true
+}
+
+// issueRebindToken returns a token the client can move its connection to us
+// with.
+func (n *Network) issueRebindToken(c *Client) string {
+	token := uuid.NewString()
+
+	n.rebindTokensMu.Lock()
+	n.rebindTokens[token] = c
+	n.rebindTokensMu.Unlock()
+
+	return token
+}
+
+// dropRebindTokens forgets the tokens given to clients that are gone.
+func (n *Network) dropRebindTokens() {
+	n.rebindTokensMu.Lock()
+	defer n.rebindTokensMu.Unlock()
+
+	for token, c := range n.rebindTokens {
+		c.RLock()
+		gone := c.State == Disconnected || c.State == TimedOut
+		c.RUnlock()
+
+		if gone {
+			delete(n.rebindTokens, token)
+		}
+	}
+}
+
+// setRebindInfo records how to move our connection to the client: the
+// token it gave us, and the port it listens on.
+func (c *Client) setRebindInfo(token string, port int) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.rebindToken = token
+	c.listenPort = port
+}
+
+// WatchAddrs moves every connection to a new one whenever our machine's
+// addresses change, like when a laptop goes from WiFi to ethernet, so peers
+// carry on with us where we are now. Runs until done is closed.
+func (n *Network) WatchAddrs(done <-chan struct{}) {
+	addrs := localAddrs()
+	ticker := time.NewTicker(addrCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		current := localAddrs()
+
+		if current == addrs {
+			continue
+		}
+
+		addrs = current
+		log.Println("Addresses changed, moving connections:", current)
+
+		n.ClientsRange(func(c *Client) bool {
+			go n.migrate(c)
+			return true
+		})
+	}
+}
+
+// localAddrs lists our machine's addresses, other than loopback ones.
+func localAddrs() string {
+	ifaceAddrs, err := net.InterfaceAddrs()
+
+	if err != nil {
+		return ""
+	}
+
+	var addrs []string
+
+	for _, a := range ifaceAddrs {
+		if ipNet, ok := a.(*net.IPNet); ok && !ipNet.IP.IsLoopback() {
+			addrs = append(addrs, ipNet.IP.String())
+		}
+	}
+
+	sort.Strings(addrs)
+	return strings.Join(addrs, ",")
+}
+
+// migrate dials the client afresh and moves our connection to it onto the
+// new one. Peers who dialed us are dialed back on the port they listen on.
+func (n *Network) migrate(c *Client) {
+	c.RLock()
+	addr, dialed, port, token := c.Addr, c.dialed, c.listenPort, c.rebindToken
+	fec, fecPort := c.FEC, c.fecPort
+	ok := c.NextHop == "" && c.State == Connected && token != ""
+	c.RUnlock()
+
+	host, _, err := net.SplitHostPort(addr)
+
+	if !ok || err != nil || !dialed && port == 0 {
+		return
+	}
+
+	if !dialed {
+		addr = net.JoinHostPort(host, strconv.Itoa(port))
+	}
+
+	var conn net.Conn
+
+	// We only know where peers we dialed take error corrected connections,
+	// so the rest go back to plain ones
+	if fec && dialed && fecPort != 0 {
+		conn, err = dialFEC(net.JoinHostPort(host, strconv.Itoa(fecPort)))
+	} else {
+		fec = false
+		conn, err = n.Transport.Dial(addr)
+	}
+
+	if err != nil {
+		log.Println("Couldn't move connection:", err)
+		return
+	}
+
+	data, _ := NewRebindMessage(token).MarshalBinary()
+
+	if _, err := conn.Write(data); err != nil {
+		log.Println("Couldn't move connection:", err)
+		conn.Close()
+		return
+	}
+
+	c.rebind(conn)
+
+	c.Lock()
+	c.Addr = addr
+	c.dialed = true
+	c.FEC = fec
+	profile := c.KCPProfile
+	c.Unlock()
+
+	c.setKCPProfile(profile)
+}
@@ -0,0 +1,108 @@
+package net
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"arcade/arcade/message"
+)
+
+// signalReplies hands replies to the network's requests back to it, like the
+// server does.
+func signalReplies(n *Network) {
+	message.AddListener(message.Listener{
+		ServerID: n.me,
+		Handle: func(c, msg interface{}) interface{} {
+			messageID := reflect.ValueOf(msg).Elem().FieldByName("Message").FieldByName("MessageID").String()
+			n.SignalReceived(messageID, msg)
+			return nil
+		},
+	})
+}
+
+// listenMemory accepts connections to the network on the transport until
+// the test ends.
+func listenMemory(t *testing.T, n *Network, transport *MemoryTransport, addr string) {
+	listener, err := transport.Listen(addr)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+
+			if err != nil {
+				return
+			}
+
+			go n.Accept(conn)
+		}
+	}()
+}
+
+func TestMigrate(t *testing.T) {
+	transport := NewMemoryTransport(time.Millisecond)
+
+	host := NewNetwork("migrate-host", 1, false)
+	host.Transport = transport
+	signalReplies(host)
+	listenMemory(t, host, transport, "host:1")
+
+	player := NewNetwork("migrate-player", 2, false)
+	player.Transport = transport
+	signalReplies(player)
+
+	toHost, err := player.Connect("host:1", "", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The host's side is set up once the player's ping arrives
+	var toPlayer *Client
+
+	for deadline := time.Now().Add(time.Second); toPlayer == nil && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+		toPlayer, _ = host.GetClient("migrate-player")
+	}
+
+	if toPlayer == nil {
+		t.Fatal("host never saw the player")
+	}
+
+	oldAddr := toPlayer.Addr
+	player.migrate(toHost)
+
+	if _, err := player.SendAndReceive(toHost, NewPingMessage(false)); err != nil {
+		t.Fatalf("couldn't reach the host after moving: %v", err)
+	}
+
+	if c, _ := host.GetClient("migrate-player"); c != toPlayer {
+		t.Error("the host made a new client rather than moving the old one")
+	}
+
+	toPlayer.RLock()
+	addr, state := toPlayer.Addr, toPlayer.State
+	toPlayer.RUnlock()
+
+	if addr == oldAddr || state != Connected {
+		t.Errorf("host's client is at %s, %v after moving from %s", addr, state, oldAddr)
+	}
+
+	// Tokens only move connections they were given for
+	conn, _ := transport.Dial("host:1")
+	data, _ := NewRebindMessage("not-a-token").MarshalBinary()
+	conn.Write(data)
+
+	buf := make([]byte, maxBufferSize)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	if _, err := conn.Read(buf); err == nil {
+		t.Error("a made up token was answered")
+	}
+}
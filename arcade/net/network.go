@@ -2,6 +2,7 @@ package net
 
 import (
 	"arcade/arcade/message"
+	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,7 +15,6 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/xtaci/kcp-go/v5"
 )
 
 type Network struct {
@@ -22,6 +22,9 @@ type Network struct {
 
 	Delegate NetworkDelegate
 
+	// How connections are made, KCP unless set before connecting
+	Transport Transport
+
 	clients     sync.Map
 	distributor bool
 	dropRate    float64
@@ -30,23 +33,74 @@ type Network struct {
 
 	pendingMessagesMux sync.RWMutex
 	pendingMessages    map[string]chan interface{}
+
+	// UDP socket for unreliable messages, if it's open
+	unreliable *unreliableChannel
+
+	// Handles received messages, in order for each sender
+	dispatcher *dispatcher
+
+	// Decides whether a received message is worth handling, before it's
+	// queued, so floods are turned away before they take up room. Everything
+	// is let through if it's not set.
+	Admit func(c *Client, senderID, messageType, origin string) bool
+
+	// Bandwidth used, and the cap on what we send
+	usage *usageMeter
+
+	// KCP profile we ask for on new connections
+	kcpProfile string
+
+	// Whether we want error correction, and the port for connections with
+	// it if it's open
+	fec         bool
+	fecListener net.Listener
+
+	// Port peers behind proxies connect to over TCP, if it's open
+	tcpListener net.Listener
+
+	// Clients we've said can move their connections to us, by the token we
+	// gave them
+	rebindTokensMu sync.Mutex
+	rebindTokens   map[string]*Client
+
+	// End-to-end sessions with players reached through the distributor
+	e2eMu       sync.Mutex
+	e2eSessions map[string]*e2eSession
+
+	// Prove our session keys are ours, and check peers' are theirs. Without
+	// them keys aren't checked, and a distributor could read what it relays
+	// by swapping in its own
+	SignSessionKey   func(publicKey []byte) []byte
+	VerifySessionKey func(id string, publicKey, proof []byte) bool
 }
 
 const maxTimeoutRetries = 1
 const timeoutInterval = time.Second
 const sendAndReceiveTimeout = 500 * time.Millisecond
 
+// Frames from a peer that can't be read before it's disconnected
+const maxBadFrames = 16
+
 func NewNetwork(me string, port int, distributor bool) *Network {
 	message.Register(PingMessage{Message: message.Message{Type: "ping"}})
 	message.Register(PongMessage{Message: message.Message{Type: "pong"}})
 	message.Register(RoutingMessage{Message: message.Message{Type: "routing"}})
+	message.Register(SessionKeyMessage{Message: message.Message{Type: "session_key"}})
+	message.Register(SealedMessage{Message: message.Message{Type: "sealed"}})
 
 	n := &Network{
 		clients:         sync.Map{},
+		Transport:       KCP,
 		me:              me,
 		port:            port,
 		distributor:     distributor,
 		pendingMessages: make(map[string]chan interface{}),
+		dispatcher:      newDispatcher(messageWorkers),
+		usage:           newUsageMeter(),
+		kcpProfile:      DefaultKCPProfile,
+		rebindTokens:    make(map[string]*Client),
+		e2eSessions:     make(map[string]*e2eSession),
 	}
 
 	message.AddListener(message.Listener{
@@ -58,8 +112,28 @@ func NewNetwork(me string, port int, distributor bool) *Network {
 	return n
 }
 
+// SetPort changes the port we say we're listening on.
+func (n *Network) SetPort(port int) {
+	n.Lock()
+	defer n.Unlock()
+
+	n.port = port
+}
+
+// Port returns the port we say we're listening on.
+func (n *Network) Port() int {
+	n.RLock()
+	defer n.RUnlock()
+
+	return n.port
+}
+
 func (n *Network) Addr() string {
 	ip, _ := GetLocalIP()
+
+	n.RLock()
+	defer n.RUnlock()
+
 	return fmt.Sprintf("%s:%d", ip, n.port)
 }
 
@@ -120,11 +194,12 @@ func (n *Network) Connect(addr, id string, conn net.Conn) (*Client, error) {
 		ID:       id,
 		Neighbor: true,
 		State:    Connecting,
+		dialed:   conn == nil,
 	}
 
 	if conn == nil {
 		var err error
-		conn, err = kcp.Dial(c.Addr)
+		conn, err = n.Transport.Dial(c.Addr)
 
 		if err != nil {
 			return nil, err
@@ -132,6 +207,7 @@ func (n *Network) Connect(addr, id string, conn net.Conn) (*Client, error) {
 	}
 
 	c.start(conn)
+	c.setKCPProfile(n.KCPProfile())
 	go n.handleMessages(c)
 
 	return c, n.ConnectClient(c, true)
@@ -140,7 +216,15 @@ func (n *Network) Connect(addr, id string, conn net.Conn) (*Client, error) {
 func (n *Network) ConnectClient(c *Client, retry bool) error {
 	// Send ping and wait for reply
 	start := time.Now()
-	res, err := n.SendAndReceive(c, NewPingMessage(n.distributor))
+	ping := NewPingMessage(n.distributor)
+	ping.UnreliablePort = n.UnreliablePort()
+	ping.UnreliableKey = c.issueUnreliableKey()
+	ping.KCPProfile = n.KCPProfile()
+	ping.FEC = n.fecOffer(c)
+	ping.RebindToken = n.issueRebindToken(c)
+	ping.Port = n.Port()
+
+	res, err := n.SendAndReceive(c, ping)
 	end := time.Now()
 
 	p, ok := res.(*PongMessage)
@@ -183,6 +267,17 @@ func (n *Network) ConnectClient(c *Client, retry bool) error {
 	c.TimeoutRetries = 0
 	c.Unlock()
 
+	c.setUnreliablePort(p.UnreliablePort)
+	c.setUnreliableSendKey(p.UnreliableKey)
+	c.setKCPProfile(negotiateKCPProfile(n.KCPProfile(), p.KCPProfile))
+	c.setRebindInfo(p.RebindToken, p.Port)
+
+	if p.FECPort != 0 {
+		n.moveToFEC(c, p.FECPort)
+	}
+
+	n.resetUnreliable(clientID)
+
 	n.clients.Store(clientID, c)
 
 	if !p.Distributor && n.Delegate != nil {
@@ -214,6 +309,25 @@ func (n *Network) GetClient(id string) (*Client, bool) {
 	return c.(*Client), true
 }
 
+// GetDistributor returns a distributor we are directly connected to, if any.
+func (n *Network) GetDistributor() (*Client, bool) {
+	var distributor *Client
+
+	n.ClientsRange(func(c *Client) bool {
+		c.RLock()
+		defer c.RUnlock()
+
+		if c.Distributor && c.Neighbor && c.State == Connected {
+			distributor = c
+			return false
+		}
+
+		return true
+	})
+
+	return distributor, distributor != nil
+}
+
 func (n *Network) ClientsRange(f func(*Client) bool) {
 	n.clients.Range(func(key, value interface{}) bool {
 		return f(value.(*Client))
@@ -221,28 +335,76 @@ func (n *Network) ClientsRange(f func(*Client) bool) {
 }
 
 func (n *Network) SendRaw(client *Client, msg interface{}) bool {
-	client.RLock()
-	if client.NextHop == "" {
-		client.RUnlock()
-		client.Send(msg)
-		return true
+	hop, ok := n.nextHop(client)
+
+	if !ok {
+		log.Println("Send Failed: load")
+		return false
 	}
-	client.RUnlock()
 
-	n.RLock()
-	defer n.RUnlock()
+	data, _ := msg.(encoding.BinaryMarshaler).MarshalBinary()
+	priority := sendPriority(msg)
 
-	servicer, ok := n.clients.Load(client.NextHop)
+	// Only the player a relayed message is for can read it
+	if hop != client && n.sealable(client, msg) {
+		sealed, ok := n.seal(client, msg, data)
 
-	if !ok {
-		log.Println("Send Failed: load")
+		if sealed == nil {
+			return ok
+		}
+
+		data = sealed
+	}
+
+	if !n.usage.allowSend(priority, len(data), time.Now()) {
 		return false
 	}
 
-	servicer.(*Client).Send(msg)
+	if !hop.sendData(priority, coalesceKey(msg), data) {
+		return false
+	}
+
+	client.RLock()
+	clientID := client.ID
+	client.RUnlock()
+
+	n.usage.countSent(clientID, messageType(msg), len(data))
 	return true
 }
 
+// nextHop returns the neighbor messages to the client are written to.
+func (n *Network) nextHop(client *Client) (*Client, bool) {
+	client.RLock()
+	nextHop := client.NextHop
+	client.RUnlock()
+
+	if nextHop == "" {
+		return client, true
+	}
+
+	return n.GetClient(nextHop)
+}
+
+// Congested returns true if the connection to the client is backed up.
+// Game loops check this to skip updates rather than queue them up.
+func (n *Network) Congested(client *Client) bool {
+	hop, ok := n.nextHop(client)
+	return ok && hop.Congested()
+}
+
+// Backlog returns how many messages are waiting to be written on the way to
+// the client.
+func (n *Network) Backlog(client *Client) int {
+	hop, ok := n.nextHop(client)
+
+	if !ok {
+		return 0
+	}
+
+	backlog, _ := hop.SendStats()
+	return backlog
+}
+
 func (n *Network) Send(client *Client, msg interface{}) bool {
 	client.RLock()
 	if client.State == Disconnected || client.State == TimedOut {
@@ -326,7 +488,7 @@ func (n *Network) SendNeighbors(msg interface{}) {
 		reflect.ValueOf(msg).Elem().FieldByName("Message").FieldByName("RecipientID").Set(reflect.ValueOf(client.ID))
 
 		client.RUnlock()
-		client.Send(msg)
+		n.SendRaw(client, msg)
 		return true
 	})
 }
@@ -358,18 +520,26 @@ func (n *Network) UpdateRoutes(from *Client, routingTable map[string]ClientRouti
 
 	changes := 0
 
+	from.RLock()
+	fromID := from.ID
+	from.RUnlock()
+
 	n.clients.Range(func(key, value any) bool {
 		clientID := key.(string)
 		client := value.(*Client)
 
 		delete(routingTable, clientID)
 
-		if clientID == from.ID {
+		if clientID == fromID {
 			return true
 		}
 
+		client.RLock()
+		distance, nextHop := client.Distance, client.NextHop
+		client.RUnlock()
+
 		// Bellman-Ford equation: Update least-cost paths to all other clients
-		if c, ok := routingTable[clientID]; ok && c.Distance < client.Distance && client.NextHop != "" {
+		if c, ok := routingTable[clientID]; ok && c.Distance < distance && nextHop != "" {
 			log.Println("New path to", clientID, "cost=", c.Distance)
 
 			client.Lock()
@@ -413,44 +583,112 @@ func (n *Network) UpdateRoutes(from *Client, routingTable map[string]ClientRouti
 	go n.PropagateRoutes()
 }
 
+// handleMessages handles what arrives on the client's connection until it
+// closes. Frames that can't be read are dropped, but a peer that keeps
+// sending them is broken or up to something, and is disconnected.
 func (n *Network) handleMessages(c *Client) {
-	for {
-		data, ok := <-c.recvCh
-
-		// // Randomly drop packets if debugging
-		dropRate := n.GetDropRate()
+	bad := 0
 
-		if dropRate > 0 && rand.Float64() < dropRate {
+	for data := range c.recvCh {
+		// Randomly drop packets if debugging
+		if dropRate := n.GetDropRate(); dropRate > 0 && rand.Float64() < dropRate {
 			continue
 		}
 
-		if !ok {
-			break
+		// Disconnected, so what's left is drained until the reader stops
+		if bad > maxBadFrames {
+			continue
 		}
 
-		if !ok {
-			break
+		if n.receive(c, data) {
+			continue
 		}
 
-		// Get sender ID
-		res := struct {
-			SenderID string
-		}{}
+		c.Lock()
+		c.BadFrames++
+		id := c.ID
+		c.Unlock()
 
-		if err := json.Unmarshal(data, &res); err != nil {
-			break
+		if bad++; bad > maxBadFrames {
+			log.Printf("Disconnecting %s after %d frames that couldn't be read\n", id, bad)
+			c.disconnect()
 		}
+	}
+}
+
+// receive handles a frame from the client's connection. Returns false if it
+// couldn't be read.
+func (n *Network) receive(c *Client, data []byte) bool {
+	// Get sender ID
+	res := struct {
+		SenderID    string
+		RecipientID string
+		Type        string
+		Origin      string
+	}{}
+
+	if err := json.Unmarshal(data, &res); err != nil {
+		return false
+	}
+
+	size := len(data)
+
+	// End-to-end messages for us are opened here, and ones for others
+	// are relayed as they are
+	if res.RecipientID == n.me {
+		switch res.Type {
+		case "session_key":
+			n.usage.countReceived(res.SenderID, res.Type, size)
 
-		sender, ok := n.GetClient(res.SenderID)
+			if n.admit(c, res.SenderID, res.Type, res.Origin) {
+				n.receiveSessionKey(res.SenderID, data)
+			}
+
+			return true
+		case "sealed":
+			senderID := res.SenderID
+			plain, ok := n.open(senderID, data)
 
-		if !ok {
-			sender = c
+			if !ok || json.Unmarshal(plain, &res) != nil || res.SenderID != senderID || res.RecipientID != n.me {
+				return true
+			}
+
+			data = plain
+		default:
+			// The distributor could forge anything it likes in the clear
+			if n.mustBeSealed(c, res.SenderID, res.Type) {
+				log.Printf("Dropped '%s' from %s that should have been sealed\n", res.Type, res.SenderID)
+				return true
+			}
 		}
+	}
 
-		for _, reply := range message.Notify(c, data) {
+	n.usage.countReceived(res.SenderID, res.Type, size)
+
+	if !n.admit(c, res.SenderID, res.Type, res.Origin) {
+		return true
+	}
+
+	sender, ok := n.GetClient(res.SenderID)
+
+	if !ok {
+		sender = c
+	}
+
+	// Messages relayed through the distributor all arrive here, so
+	// they're handled by who sent them rather than who passed them on
+	n.dispatcher.dispatch(c, res.SenderID, func() {
+		for _, reply := range message.Notify(n.me, c, data) {
 			n.Send(sender, reply)
 		}
-	}
+	})
+
+	return true
+}
+
+// admit asks Admit, if it's set, whether to handle the message.
+func (n *Network) admit(c *Client, senderID, messageType, origin string) bool {
+	return n.Admit == nil || n.Admit(c, senderID, messageType, origin)
 }
 
 func (n *Network) GetDropRate() float64 {
@@ -475,6 +713,8 @@ func (n *Network) SetDropRate(rate float64) {
 
 func (n *Network) ClientDisconnected(clientID string) {
 	n.clients.Delete(clientID)
+	n.dropRebindTokens()
+	n.endSession(clientID)
 
 	if n.Delegate != nil {
 		n.Delegate.ClientDisconnected(clientID)
@@ -0,0 +1,129 @@
+package net
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"arcade/arcade/message"
+)
+
+// receivingClient is a client of the network, as if it had connected, with
+// the other end of its connection to write frames to.
+func receivingClient(n *Network, id string) (*Client, *memoryConn) {
+	ours, theirs := newMemoryPipe(NewMemoryTransport(0), memoryAddr(id+"-ours"), memoryAddr(id+"-theirs"))
+
+	c := &Client{ID: id, Delegate: n, State: Connected}
+	c.start(ours)
+	n.clients.Store(id, c)
+
+	return c, theirs
+}
+
+func TestBadFrames(t *testing.T) {
+	message.Register(secretMessage{Message: message.Message{Type: "secret"}})
+
+	n := NewNetwork("bad-frames", 1, false)
+	c, theirs := receivingClient(n, "bad-frames-peer")
+	go n.handleMessages(c)
+
+	received := make(chan string, 1)
+
+	message.AddListener(message.Listener{
+		ServerID: "bad-frames",
+		Handle: func(c, msg interface{}) interface{} {
+			if secret, ok := msg.(*secretMessage); ok {
+				received <- secret.Secret
+			}
+
+			return nil
+		},
+	})
+
+	// One bad frame is dropped, and the connection carries on
+	good, _ := secretMessage{Message: message.Message{Type: "secret", SenderID: "bad-frames-peer", RecipientID: "bad-frames"}, Secret: "still here"}.MarshalBinary()
+	theirs.Write([]byte("{not json"))
+	theirs.Write(good)
+
+	select {
+	case got := <-received:
+		if got != "still here" {
+			t.Errorf("got %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("a bad frame stopped the next from being handled")
+	}
+
+	// Too many, and the peer's disconnected
+	for i := 0; i < maxBadFrames; i++ {
+		theirs.Write([]byte{0xff})
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for {
+		c.RLock()
+		state, bad := c.State, c.BadFrames
+		c.RUnlock()
+
+		if state == Disconnected {
+			if bad != maxBadFrames+1 {
+				t.Errorf("counted %d bad frames, sent %d", bad, maxBadFrames+1)
+			}
+
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("still %v after %d bad frames", state, bad)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// FuzzReceive feeds frames, as they'd arrive on a connection from anyone,
+// through reading their header, opening sealed ones and key exchanges, and
+// on to the handlers. Nothing it's sent may panic.
+func FuzzReceive(f *testing.F) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	n := NewNetwork("fuzz-receive", 1, false)
+	c, _ := receivingClient(n, "fuzz-peer")
+
+	seeds := []interface{}{
+		NewPingMessage(false),
+		NewPongMessage(false),
+		&RoutingMessage{Message: message.Message{Type: "routing"}, Distances: map[string]ClientRoutingInfo{"x": {Distance: 1}}},
+		NewSessionKeyMessage(make([]byte, 32), []byte("proof"), false),
+		&SealedMessage{Message: message.Message{Type: "sealed"}, Nonce: make([]byte, 12), Box: []byte("box")},
+	}
+
+	for _, seed := range seeds {
+		base := reflect.ValueOf(seed).Elem().FieldByName("Message")
+		base.FieldByName("SenderID").SetString("fuzz-peer")
+		base.FieldByName("RecipientID").SetString("fuzz-receive")
+
+		data, err := json.Marshal(seed)
+
+		if err != nil {
+			f.Fatal(err)
+		}
+
+		f.Add(data)
+	}
+
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"Type":"sealed","RecipientID":"fuzz-receive","Box":null}`))
+	f.Add([]byte(`{"SenderID":1}`))
+	f.Add([]byte(`null`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		n.receive(c, data)
+	})
+}
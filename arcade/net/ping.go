@@ -9,6 +9,23 @@ import (
 type PingMessage struct {
 	message.Message
 	Distributor bool
+
+	// Where the sender takes unreliable messages, or 0 if it doesn't, and
+	// the key they have to be signed with
+	UnreliablePort int
+	UnreliableKey  []byte `json:",omitempty"`
+
+	// KCP profile the sender would like the connection to use
+	KCPProfile string `json:",omitempty"`
+
+	// Whether the sender can move the connection to error correction, and
+	// would like to. Empty if it can't
+	FEC FECMode `json:",omitempty"`
+
+	// Token to move the connection to the sender with, and the port to
+	// dial it on, if our address changes
+	RebindToken string `json:",omitempty"`
+	Port        int    `json:",omitempty"`
 }
 
 func NewPingMessage(distributor bool) *PingMessage {
@@ -10,6 +10,22 @@ type PongMessage struct {
 	message.Message
 
 	Distributor bool
+
+	// Where the sender takes unreliable messages, or 0 if it doesn't, and
+	// the key they have to be signed with
+	UnreliablePort int
+	UnreliableKey  []byte `json:",omitempty"`
+
+	// KCP profile the connection uses, from both ends' picks
+	KCPProfile string `json:",omitempty"`
+
+	// Where to move the connection for error correction, if it's to have it
+	FECPort int `json:",omitempty"`
+
+	// Token to move the connection to the sender with, and the port to
+	// dial it on, if our address changes
+	RebindToken string `json:",omitempty"`
+	Port        int    `json:",omitempty"`
 }
 
 func NewPongMessage(distributor bool) *PongMessage {
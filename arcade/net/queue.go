@@ -0,0 +1,207 @@
+package net
+
+import (
+	"sync"
+)
+
+// Priority decides which queued messages are written to a client first.
+type Priority int
+
+const (
+	// Connection upkeep, lobby changes and anything else that must arrive
+	PriorityControl Priority = iota
+
+	// Game state updates, which are soon out of date
+	PriorityState
+
+	// Chat, which can wait for everything else
+	PriorityChat
+
+	numPriorities
+)
+
+// Prioritized messages say how urgent they are. Anything else is sent as
+// control.
+type Prioritized interface {
+	SendPriority() Priority
+}
+
+// Coalescing messages replace a queued message with the same key that hasn't
+// been written yet, for updates where only the newest matters.
+type Coalescing interface {
+	CoalesceKey() string
+}
+
+// sendPriority returns how urgent a message is.
+func sendPriority(msg interface{}) Priority {
+	if p, ok := msg.(Prioritized); ok {
+		return p.SendPriority()
+	}
+
+	return PriorityControl
+}
+
+// coalesceKey returns the key a message replaces queued ones by, or "" if it
+// doesn't.
+func coalesceKey(msg interface{}) string {
+	if co, ok := msg.(Coalescing); ok {
+		return co.CoalesceKey()
+	}
+
+	return ""
+}
+
+// How many messages each class holds before the drop policy kicks in
+var queueLimits = [numPriorities]int{
+	PriorityControl: maxBufferSize,
+	PriorityState:   64,
+	PriorityChat:    32,
+}
+
+// A client is congested once this many messages are waiting to be written,
+// across all classes.
+const congestedBacklog = 48
+
+type queuedMessage struct {
+	data []byte
+	key  string
+}
+
+// sendQueue holds messages waiting to be written to a client, by priority.
+// Adding to it never blocks: when a class is full, stale state updates are
+// dropped to make room, and control and chat messages are refused.
+type sendQueue struct {
+	mu sync.Mutex
+
+	classes [numPriorities][]queuedMessage
+	closed  bool
+
+	// Messages refused, or evicted to make room, since the queue was made
+	dropped int
+
+	// Has a value while there's something to write
+	ready chan struct{}
+}
+
+func newSendQueue() *sendQueue {
+	return &sendQueue{ready: make(chan struct{}, 1)}
+}
+
+// push queues a message. Returns false if it was refused.
+func (q *sendQueue) push(priority Priority, key string, data []byte) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return false
+	}
+
+	class := q.classes[priority]
+
+	// Replacing an update with a newer one isn't a drop
+	if key != "" {
+		for i := range class {
+			if class[i].key == key {
+				class[i].data = data
+				return true
+			}
+		}
+	}
+
+	if len(class) >= queueLimits[priority] {
+		if priority != PriorityState {
+			q.dropped++
+			return false
+		}
+
+		class = class[1:]
+		q.dropped++
+	}
+
+	q.classes[priority] = append(class, queuedMessage{data, key})
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+
+	return true
+}
+
+// pop waits for the most urgent message. Once the queue is closed, the
+// control messages left are still handed out, and then it returns false.
+func (q *sendQueue) pop() ([]byte, bool) {
+	for {
+		q.mu.Lock()
+
+		if q.closed {
+			control := q.classes[PriorityControl]
+
+			if len(control) == 0 {
+				q.mu.Unlock()
+				return nil, false
+			}
+
+			q.classes[PriorityControl] = control[1:]
+			q.mu.Unlock()
+
+			return control[0].data, true
+		}
+
+		for p := range q.classes {
+			if len(q.classes[p]) > 0 {
+				msg := q.classes[p][0]
+				q.classes[p] = q.classes[p][1:]
+				more := q.len() > 0
+				q.mu.Unlock()
+
+				// Keep the writer going if there's more
+				if more {
+					select {
+					case q.ready <- struct{}{}:
+					default:
+					}
+				}
+
+				return msg.data, true
+			}
+		}
+
+		q.mu.Unlock()
+
+		if _, ok := <-q.ready; !ok {
+			return nil, false
+		}
+	}
+}
+
+// len returns how many messages are waiting. Expects the lock to be held.
+func (q *sendQueue) len() int {
+	n := 0
+
+	for _, class := range q.classes {
+		n += len(class)
+	}
+
+	return n
+}
+
+// stats returns how many messages are waiting and how many have been dropped.
+func (q *sendQueue) stats() (backlog, dropped int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.len(), q.dropped
+}
+
+func (q *sendQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+
+	q.closed = true
+	close(q.ready)
+}
@@ -0,0 +1,115 @@
+package net
+
+import "testing"
+
+func popAll(q *sendQueue) []string {
+	var popped []string
+
+	for {
+		q.mu.Lock()
+		n := q.len()
+		q.mu.Unlock()
+
+		if n == 0 {
+			return popped
+		}
+
+		data, _ := q.pop()
+		popped = append(popped, string(data))
+	}
+}
+
+func TestSendQueuePriorityOrder(t *testing.T) {
+	q := newSendQueue()
+
+	q.push(PriorityChat, "", []byte("chat"))
+	q.push(PriorityState, "", []byte("state"))
+	q.push(PriorityControl, "", []byte("control 1"))
+	q.push(PriorityControl, "", []byte("control 2"))
+
+	got := popAll(q)
+	want := []string{"control 1", "control 2", "state", "chat"}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSendQueueCoalesces(t *testing.T) {
+	q := newSendQueue()
+
+	q.push(PriorityState, "game", []byte("old"))
+	q.push(PriorityState, "game", []byte("new"))
+	q.push(PriorityState, "other", []byte("other"))
+
+	if got := popAll(q); len(got) != 2 || got[0] != "new" || got[1] != "other" {
+		t.Errorf("got %v", got)
+	}
+
+	if _, dropped := q.stats(); dropped != 0 {
+		t.Errorf("coalescing counted %d drops", dropped)
+	}
+}
+
+func TestSendQueueEvictsStaleState(t *testing.T) {
+	q := newSendQueue()
+	limit := queueLimits[PriorityState]
+
+	for i := 0; i <= limit; i++ {
+		if !q.push(PriorityState, "", []byte{byte(i)}) {
+			t.Fatalf("state update %d refused", i)
+		}
+	}
+
+	got := popAll(q)
+
+	if len(got) != limit || got[0] != string([]byte{1}) {
+		t.Errorf("got %d updates starting with %v", len(got), []byte(got[0]))
+	}
+
+	if _, dropped := q.stats(); dropped != 1 {
+		t.Errorf("got %d drops, want 1", dropped)
+	}
+}
+
+func TestSendQueueRefusesFullChat(t *testing.T) {
+	q := newSendQueue()
+
+	for i := 0; i < queueLimits[PriorityChat]; i++ {
+		q.push(PriorityChat, "", []byte("chat"))
+	}
+
+	if q.push(PriorityChat, "", []byte("one too many")) {
+		t.Error("full chat class took another message")
+	}
+
+	if _, dropped := q.stats(); dropped != 1 {
+		t.Errorf("got %d drops, want 1", dropped)
+	}
+}
+
+func TestSendQueueDrainsControlAfterClose(t *testing.T) {
+	q := newSendQueue()
+
+	q.push(PriorityState, "", []byte("state"))
+	q.push(PriorityControl, "", []byte("disconnect"))
+	q.close()
+
+	if data, ok := q.pop(); !ok || string(data) != "disconnect" {
+		t.Errorf("got %q, %v", data, ok)
+	}
+
+	if _, ok := q.pop(); ok {
+		t.Error("state update handed out after close")
+	}
+
+	if q.push(PriorityControl, "", []byte("late")) {
+		t.Error("closed queue took a message")
+	}
+}
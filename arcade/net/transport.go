@@ -0,0 +1,270 @@
+package net
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/xtaci/kcp-go/v5"
+)
+
+// Transport makes the connections clients talk over. Each read from one of
+// its connections is a whole message, as written by the other end.
+type Transport interface {
+	Dial(addr string) (net.Conn, error)
+	Listen(addr string) (net.Listener, error)
+}
+
+type kcpTransport struct{}
+
+func (kcpTransport) Dial(addr string) (net.Conn, error) {
+	return kcp.Dial(addr)
+}
+
+func (kcpTransport) Listen(addr string) (net.Listener, error) {
+	return kcp.Listen(addr)
+}
+
+// KCP is the transport used between real players, and the default.
+var KCP Transport = kcpTransport{}
+
+var errAddrInUse = errors.New("address already in use")
+var errNoListener = errors.New("connection refused")
+
+// MemoryTransport connects networks in the same process to each other, so
+// networking code can be tested without sockets. Every message takes the
+// transport's latency to arrive.
+type MemoryTransport struct {
+	mu sync.Mutex
+
+	latency   time.Duration
+	listeners map[string]*memoryListener
+	dialed    int
+}
+
+func NewMemoryTransport(latency time.Duration) *MemoryTransport {
+	return &MemoryTransport{
+		latency:   latency,
+		listeners: make(map[string]*memoryListener),
+	}
+}
+
+// SetLatency changes how long messages take, from the next one written.
+func (t *MemoryTransport) SetLatency(latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.latency = latency
+}
+
+// Latency returns how long messages take to arrive.
+func (t *MemoryTransport) Latency() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.latency
+}
+
+func (t *MemoryTransport) Listen(addr string) (net.Listener, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.listeners[addr]; ok {
+		return nil, errAddrInUse
+	}
+
+	l := &memoryListener{
+		transport: t,
+		addr:      memoryAddr(addr),
+		acceptCh:  make(chan net.Conn),
+		done:      make(chan bool),
+	}
+
+	t.listeners[addr] = l
+	return l, nil
+}
+
+func (t *MemoryTransport) Dial(addr string) (net.Conn, error) {
+	t.mu.Lock()
+	l, ok := t.listeners[addr]
+	t.dialed++
+	local := memoryAddr(fmt.Sprintf("memory-%d", t.dialed))
+	t.mu.Unlock()
+
+	if !ok {
+		return nil, errNoListener
+	}
+
+	ours, theirs := newMemoryPipe(t, local, l.addr)
+
+	select {
+	case l.acceptCh <- theirs:
+		return ours, nil
+	case <-l.done:
+		return nil, errNoListener
+	}
+}
+
+type memoryAddr string
+
+func (a memoryAddr) Network() string {
+	return "memory"
+}
+
+func (a memoryAddr) String() string {
+	return string(a)
+}
+
+type memoryListener struct {
+	transport *MemoryTransport
+	addr      memoryAddr
+	acceptCh  chan net.Conn
+
+	closeOnce sync.Once
+	done      chan bool
+}
+
+func (l *memoryListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.acceptCh:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *memoryListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+
+		l.transport.mu.Lock()
+		delete(l.transport.listeners, string(l.addr))
+		l.transport.mu.Unlock()
+	})
+
+	return nil
+}
+
+func (l *memoryListener) Addr() net.Addr {
+	return l.addr
+}
+
+type memoryPacket struct {
+	data      []byte
+	deliverAt time.Time
+}
+
+// memoryConn is one end of a pipe. Messages written to it are read whole from
+// the other end, once the transport's latency has passed.
+type memoryConn struct {
+	transport     *MemoryTransport
+	local, remote memoryAddr
+
+	// Messages to us, and to the other end
+	inbox, outbox chan memoryPacket
+
+	// Closed when either end is
+	closeOnce *sync.Once
+	done      chan bool
+
+	mu           sync.Mutex
+	readDeadline time.Time
+}
+
+// Messages in flight each way before writes block
+const memoryPipeBuffer = 256
+
+func newMemoryPipe(t *MemoryTransport, a, b memoryAddr) (*memoryConn, *memoryConn) {
+	aToB := make(chan memoryPacket, memoryPipeBuffer)
+	bToA := make(chan memoryPacket, memoryPipeBuffer)
+	closeOnce := &sync.Once{}
+	done := make(chan bool)
+
+	return &memoryConn{transport: t, local: a, remote: b, inbox: bToA, outbox: aToB, closeOnce: closeOnce, done: done},
+		&memoryConn{transport: t, local: b, remote: a, inbox: aToB, outbox: bToA, closeOnce: closeOnce, done: done}
+}
+
+func (c *memoryConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	deadline := c.readDeadline
+	c.mu.Unlock()
+
+	var timeout <-chan time.Time
+
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case packet := <-c.inbox:
+		if wait := time.Until(packet.deliverAt); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		// Like a datagram, whatever doesn't fit is lost
+		return copy(b, packet.data), nil
+	case <-c.done:
+		return 0, io.EOF
+	case <-timeout:
+		return 0, os.ErrDeadlineExceeded
+	}
+}
+
+func (c *memoryConn) Write(b []byte) (int, error) {
+	data := make([]byte, len(b))
+	copy(data, b)
+
+	packet := memoryPacket{data: data, deliverAt: time.Now().Add(c.transport.Latency())}
+
+	select {
+	case <-c.done:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
+	select {
+	case c.outbox <- packet:
+		return len(b), nil
+	case <-c.done:
+		return 0, io.ErrClosedPipe
+	}
+}
+
+func (c *memoryConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+
+	return nil
+}
+
+func (c *memoryConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *memoryConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *memoryConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+func (c *memoryConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.readDeadline = t
+	return nil
+}
+
+// Write deadlines aren't kept, since writes only wait while the pipe is full.
+func (c *memoryConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
@@ -0,0 +1,105 @@
+package net
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMemoryTransportDelivers(t *testing.T) {
+	latency := 30 * time.Millisecond
+	transport := NewMemoryTransport(latency)
+
+	listener, err := transport.Listen("server:1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	if _, err := transport.Listen("server:1"); err == nil {
+		t.Error("listened twice on one address")
+	}
+
+	accepted := make(chan net.Conn, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	client, err := transport.Dial("server:1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := <-accepted
+	start := time.Now()
+
+	for _, msg := range []string{"first", "second", "third"} {
+		if _, err := client.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf := make([]byte, maxBufferSize)
+
+	// Each read is one whole message, in the order they were written
+	for _, want := range []string{"first", "second", "third"} {
+		n, err := server.Read(buf)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := string(buf[:n]); got != want {
+			t.Errorf("read %q, expected %q", got, want)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < latency {
+		t.Errorf("arrived after %v, expected at least %v", elapsed, latency)
+	}
+
+	client.Close()
+
+	if _, err := server.Read(buf); err != io.EOF {
+		t.Errorf("read from a closed pipe returned %v", err)
+	}
+
+	if _, err := server.Write([]byte("late")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("write to a closed pipe returned %v", err)
+	}
+}
+
+func TestMemoryTransportRefuses(t *testing.T) {
+	transport := NewMemoryTransport(0)
+
+	if _, err := transport.Dial("nobody:1"); err == nil {
+		t.Error("dialed an address nobody listens on")
+	}
+
+	listener, err := transport.Listen("server:1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener.Close()
+
+	if _, err := listener.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("accept after closing returned %v", err)
+	}
+
+	// The address is free again
+	if _, err := transport.Listen("server:1"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,314 @@
+package net
+
+import (
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"math/rand"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"arcade/arcade/message"
+)
+
+const (
+	// Datagrams start with an 8 byte sequence number and the channel name,
+	// prefixed by its length, and end with a tag signing the rest
+	datagramHeaderSize = 9
+	datagramTagSize    = 16
+
+	// Furthest a channel's sequence number can jump ahead at once. Even a
+	// snapshot every tick would take hours of losing every one to get this
+	// far, so further is a forgery meant to make the real ones look stale
+	maxSeqJump = 1 << 20
+)
+
+// unreliableChannel sends messages over plain UDP, next to KCP. Nothing is
+// resent or reordered: each channel only delivers messages newer than the
+// last one it delivered, so a late update never replaces a fresher one and a
+// lost one never holds up the next.
+type unreliableChannel struct {
+	mu sync.Mutex
+
+	conn *net.UDPConn
+
+	// Last sequence numbers sent and delivered, by peer and channel
+	sent      map[string]uint64
+	delivered map[string]uint64
+}
+
+// ListenUnreliable opens the UDP socket for unreliable messages, on a port of
+// its own that peers learn when they connect.
+func (n *Network) ListenUnreliable() error {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{})
+
+	if err != nil {
+		return err
+	}
+
+	n.Lock()
+	n.unreliable = &unreliableChannel{
+		conn:      conn,
+		sent:      make(map[string]uint64),
+		delivered: make(map[string]uint64),
+	}
+	n.Unlock()
+
+	go n.readUnreliable()
+
+	return nil
+}
+
+// UnreliablePort returns the port unreliable messages are received on, or 0
+// if there isn't one.
+func (n *Network) UnreliablePort() int {
+	n.RLock()
+	defer n.RUnlock()
+
+	if n.unreliable == nil {
+		return 0
+	}
+
+	return n.unreliable.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// SendUnreliable sends a message on the channel, where only the newest
+// message matters. Clients that can't be reached directly over UDP, and
+// messages too big for one datagram, are sent reliably instead.
+func (n *Network) SendUnreliable(client *Client, channel string, msg interface{}) bool {
+	n.RLock()
+	u := n.unreliable
+	n.RUnlock()
+
+	client.RLock()
+	addr := client.unreliableAddr
+	key := client.unreliableSendKey
+	state := client.State
+	client.RUnlock()
+
+	if u == nil || addr == nil || key == nil || state != Connected {
+		return n.Send(client, msg)
+	}
+
+	client.RLock()
+	reflect.ValueOf(msg).Elem().FieldByName("Message").FieldByName("SenderID").Set(reflect.ValueOf(n.me))
+	reflect.ValueOf(msg).Elem().FieldByName("Message").FieldByName("RecipientID").Set(reflect.ValueOf(client.ID))
+	seqKey := client.ID + "/" + channel
+	client.RUnlock()
+
+	data, err := msg.(encoding.BinaryMarshaler).MarshalBinary()
+
+	if err != nil || len(channel) > 255 || datagramHeaderSize+len(channel)+len(data)+datagramTagSize > maxBufferSize {
+		return n.Send(client, msg)
+	}
+
+	if !n.usage.allowSend(sendPriority(msg), len(data), time.Now()) {
+		return false
+	}
+
+	u.mu.Lock()
+	u.sent[seqKey]++
+	seq := u.sent[seqKey]
+	u.mu.Unlock()
+
+	packet := make([]byte, datagramHeaderSize, datagramHeaderSize+len(channel)+len(data)+datagramTagSize)
+	binary.BigEndian.PutUint64(packet, seq)
+	packet[8] = byte(len(channel))
+	packet = append(packet, channel...)
+	packet = append(packet, data...)
+	packet = append(packet, datagramTag(key, packet)...)
+
+	if _, err = u.conn.WriteToUDP(packet, addr); err != nil {
+		return false
+	}
+
+	client.RLock()
+	clientID := client.ID
+	client.RUnlock()
+
+	n.usage.countSent(clientID, messageType(msg), len(packet))
+	return true
+}
+
+// readUnreliable delivers datagrams that are newer than the last one on
+// their channel, and signed by the peer they say they're from.
+func (n *Network) readUnreliable() {
+	n.RLock()
+	u := n.unreliable
+	n.RUnlock()
+
+	buf := make([]byte, maxBufferSize)
+
+	for {
+		size, from, err := u.conn.ReadFromUDP(buf)
+
+		if err != nil {
+			return
+		}
+
+		if size < datagramHeaderSize || size < datagramHeaderSize+int(buf[8])+datagramTagSize {
+			continue
+		}
+
+		// Randomly drop packets if debugging
+		if dropRate := n.GetDropRate(); dropRate > 0 && rand.Float64() < dropRate {
+			continue
+		}
+
+		seq := binary.BigEndian.Uint64(buf)
+		channel := string(buf[datagramHeaderSize : datagramHeaderSize+int(buf[8])])
+
+		signed := buf[:size-datagramTagSize]
+		tag := buf[size-datagramTagSize : size]
+
+		data := make([]byte, len(signed)-datagramHeaderSize-len(channel))
+		copy(data, signed[datagramHeaderSize+len(channel):])
+
+		res := struct {
+			SenderID string
+			Type     string
+		}{}
+
+		if err := json.Unmarshal(data, &res); err != nil {
+			continue
+		}
+
+		// Only peers we're connected to can send these, from where they
+		// said, signed with the key we gave them
+		sender, ok := n.GetClient(res.SenderID)
+
+		if !ok {
+			continue
+		}
+
+		sender.RLock()
+		addr := sender.unreliableAddr
+		key := sender.unreliableRecvKey
+		sender.RUnlock()
+
+		if addr == nil || !addr.IP.Equal(from.IP) || addr.Port != from.Port {
+			continue
+		}
+
+		if key == nil || !hmac.Equal(tag, datagramTag(key, signed)) {
+			continue
+		}
+
+		n.usage.countReceived(res.SenderID, res.Type, size)
+
+		seqKey := res.SenderID + "/" + channel
+
+		u.mu.Lock()
+		last := u.delivered[seqKey]
+		stale := seq <= last || seq-last > maxSeqJump
+
+		if !stale {
+			u.delivered[seqKey] = seq
+		}
+		u.mu.Unlock()
+
+		if stale || !n.admit(sender, res.SenderID, res.Type, "") {
+			continue
+		}
+
+		n.dispatcher.dispatch(sender, res.SenderID, func() {
+			for _, reply := range message.Notify(n.me, sender, data) {
+				n.Send(sender, reply)
+			}
+		})
+	}
+}
+
+// datagramTag signs the datagram with the key.
+func datagramTag(key, datagram []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(datagram)
+
+	return mac.Sum(nil)[:datagramTagSize]
+}
+
+// issueUnreliableKey makes a new key for the client to sign the unreliable
+// messages it sends us with. It's given to the client over the connection,
+// so someone sharing its address can't forge them.
+func (c *Client) issueUnreliableKey() []byte {
+	key := make([]byte, sha256.Size)
+
+	if _, err := crand.Read(key); err != nil {
+		return nil
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.unreliableRecvKey = key
+	return key
+}
+
+// setUnreliableSendKey records the key the client wants its unreliable
+// messages signed with. Without one, they're sent reliably instead.
+func (c *Client) setUnreliableSendKey(key []byte) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.unreliableSendKey = key
+}
+
+// resetUnreliable starts the client's channels over, for when it's
+// reconnected and numbering from the start again.
+func (n *Network) resetUnreliable(clientID string) {
+	n.RLock()
+	u := n.unreliable
+	n.RUnlock()
+
+	if u == nil {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for key := range u.delivered {
+		if strings.HasPrefix(key, clientID+"/") {
+			delete(u.delivered, key)
+		}
+	}
+
+	for key := range u.sent {
+		if strings.HasPrefix(key, clientID+"/") {
+			delete(u.sent, key)
+		}
+	}
+}
+
+// setUnreliablePort records where the client receives unreliable messages,
+// from the port it said in its ping or pong.
+func (c *Client) setUnreliablePort(port int) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.unreliableAddr = nil
+	c.unreliablePort = port
+
+	if port == 0 || c.Addr == "" {
+		return
+	}
+
+	host, _, err := net.SplitHostPort(c.Addr)
+
+	if err != nil {
+		return
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(host, strconv.Itoa(port)))
+
+	if err == nil {
+		c.unreliableAddr = addr
+	}
+}
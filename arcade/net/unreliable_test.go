@@ -0,0 +1,117 @@
+package net
+
+import (
+	"encoding/binary"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"arcade/arcade/message"
+)
+
+// unreliablePeer is how one network knows the other, as if they'd connected
+// and swapped ports and keys.
+func unreliablePeer(t *testing.T, id string, port int, sendKey, recvKey []byte) *Client {
+	addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Client{
+		ID:                id,
+		State:             Connected,
+		unreliableAddr:    addr,
+		unreliableSendKey: sendKey,
+		unreliableRecvKey: recvKey,
+	}
+}
+
+// forge sends a datagram on the channel as if it were from sender, signed
+// with the key.
+func forge(t *testing.T, conn *net.UDPConn, to *net.UDPAddr, seq uint64, key []byte, secret string) {
+	data, _ := secretMessage{Message: message.Message{Type: "secret", SenderID: "unreliable-a", RecipientID: "unreliable-b"}, Secret: secret}.MarshalBinary()
+
+	packet := make([]byte, datagramHeaderSize)
+	binary.BigEndian.PutUint64(packet, seq)
+	packet[8] = byte(len("state"))
+	packet = append(packet, "state"...)
+	packet = append(packet, data...)
+	packet = append(packet, datagramTag(key, packet)...)
+
+	if _, err := conn.WriteToUDP(packet, to); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnreliableRejectsForgeries(t *testing.T) {
+	message.Register(secretMessage{Message: message.Message{Type: "secret"}})
+
+	a := NewNetwork("unreliable-a", 1, false)
+	b := NewNetwork("unreliable-b", 2, false)
+
+	for _, n := range []*Network{a, b} {
+		if err := n.ListenUnreliable(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key := []byte("a's key for b's unreliable messages")
+	toB := unreliablePeer(t, "unreliable-b", b.UnreliablePort(), key, nil)
+	b.clients.Store("unreliable-a", unreliablePeer(t, "unreliable-a", a.UnreliablePort(), nil, key))
+
+	received := make(chan string, 8)
+
+	message.AddListener(message.Listener{
+		ServerID: "unreliable-b",
+		Handle: func(c, msg interface{}) interface{} {
+			if secret, ok := msg.(*secretMessage); ok {
+				received <- secret.Secret
+			}
+
+			return nil
+		},
+	})
+
+	expect := func(want string) {
+		t.Helper()
+
+		select {
+		case got := <-received:
+			if got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("never got %q", want)
+		}
+	}
+
+	a.SendUnreliable(toB, "state", &secretMessage{Message: message.Message{Type: "secret"}, Secret: "first"})
+	expect("first")
+
+	// Someone else on the same machine, from another port, and a's own
+	// socket with the wrong key
+	other, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer other.Close()
+
+	forge(t, other, toB.unreliableAddr, 1<<40, key, "other port")
+	forge(t, a.unreliable.conn, toB.unreliableAddr, 1<<40, []byte("wrong"), "wrong key")
+
+	// Even signed, a jump far enough to make everything after it stale
+	forge(t, a.unreliable.conn, toB.unreliableAddr, 1<<40, key, "too far")
+
+	a.SendUnreliable(toB, "state", &secretMessage{Message: message.Message{Type: "secret"}, Secret: "second"})
+	expect("second")
+
+	select {
+	case got := <-received:
+		t.Errorf("delivered %q", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,190 @@
+package net
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Traffic is how many bytes went each way.
+type Traffic struct {
+	Sent     int64
+	Received int64
+}
+
+func (t Traffic) add(o Traffic) Traffic {
+	return Traffic{t.Sent + o.Sent, t.Received + o.Received}
+}
+
+func (t Traffic) sub(o Traffic) Traffic {
+	return Traffic{t.Sent - o.Sent, t.Received - o.Received}
+}
+
+// UsageReport is the bandwidth used since the network was made, in all, by
+// peer and by message type.
+type UsageReport struct {
+	Total Traffic
+	Peers map[string]Traffic
+	Types map[string]Traffic
+
+	// Messages not sent to stay under the send cap
+	Dropped int
+}
+
+// Since returns the traffic between an earlier report and this one.
+func (r UsageReport) Since(before UsageReport) UsageReport {
+	diff := UsageReport{
+		Total:   r.Total.sub(before.Total),
+		Peers:   make(map[string]Traffic),
+		Types:   make(map[string]Traffic),
+		Dropped: r.Dropped - before.Dropped,
+	}
+
+	for id, t := range r.Peers {
+		if t = t.sub(before.Peers[id]); t != (Traffic{}) {
+			diff.Peers[id] = t
+		}
+	}
+
+	for kind, t := range r.Types {
+		if t = t.sub(before.Types[kind]); t != (Traffic{}) {
+			diff.Types[kind] = t
+		}
+	}
+
+	return diff
+}
+
+// Busiest returns the message type that used the most bandwidth, or "" if
+// nothing was sent or received.
+func (r UsageReport) Busiest() string {
+	busiest := ""
+	var most int64
+
+	for kind, t := range r.Types {
+		if total := t.Sent + t.Received; total > most || total == most && kind < busiest {
+			busiest, most = kind, total
+		}
+	}
+
+	return busiest
+}
+
+// usageMeter counts bytes sent and received, and drops what can be done
+// without once the send cap's reached.
+type usageMeter struct {
+	mu sync.Mutex
+
+	report UsageReport
+
+	// Bytes a second we can send, or 0 for no cap, and how many we can send
+	// right now. Up to a second's worth builds up while we're quiet
+	limit    int
+	tokens   float64
+	refilled time.Time
+}
+
+func newUsageMeter() *usageMeter {
+	return &usageMeter{
+		report: UsageReport{
+			Peers: make(map[string]Traffic),
+			Types: make(map[string]Traffic),
+		},
+	}
+}
+
+// allowSend returns whether a message can be sent under the cap, and takes
+// its size from what's left if so. Control messages always go, even past
+// the cap, so connections aren't lost. State and chat are dropped until
+// the debt's paid off, not queued: newer state replaces what was dropped,
+// and senders of chat are told it didn't go.
+func (u *usageMeter) allowSend(priority Priority, size int, now time.Time) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.limit == 0 {
+		return true
+	}
+
+	u.tokens += now.Sub(u.refilled).Seconds() * float64(u.limit)
+	u.refilled = now
+
+	if u.tokens > float64(u.limit) {
+		u.tokens = float64(u.limit)
+	}
+
+	if priority != PriorityControl && u.tokens < float64(size) {
+		u.report.Dropped++
+		return false
+	}
+
+	u.tokens -= float64(size)
+	return true
+}
+
+func (u *usageMeter) count(peerID, kind string, t Traffic) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.report.Total = u.report.Total.add(t)
+	u.report.Peers[peerID] = u.report.Peers[peerID].add(t)
+	u.report.Types[kind] = u.report.Types[kind].add(t)
+}
+
+func (u *usageMeter) countSent(peerID, kind string, size int) {
+	u.count(peerID, kind, Traffic{Sent: int64(size)})
+}
+
+func (u *usageMeter) countReceived(peerID, kind string, size int) {
+	u.count(peerID, kind, Traffic{Received: int64(size)})
+}
+
+func (u *usageMeter) setLimit(bytesPerSecond int, now time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.limit = bytesPerSecond
+	u.tokens = float64(bytesPerSecond)
+	u.refilled = now
+}
+
+func (u *usageMeter) snapshot() UsageReport {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	r := u.report
+	r.Peers = make(map[string]Traffic, len(u.report.Peers))
+	r.Types = make(map[string]Traffic, len(u.report.Types))
+
+	for id, t := range u.report.Peers {
+		r.Peers[id] = t
+	}
+
+	for kind, t := range u.report.Types {
+		r.Types[kind] = t
+	}
+
+	return r
+}
+
+// Usage returns how much bandwidth we've used, for the debug overlay and
+// reports after matches.
+func (n *Network) Usage() UsageReport {
+	return n.usage.snapshot()
+}
+
+// SetSendCap limits how many bytes a second we send, or lifts the limit if
+// 0, for players on metered connections. Past it, game state and chat are
+// dropped rather than queued, and games carry on at a lower rate.
+func (n *Network) SetSendCap(bytesPerSecond int) {
+	if bytesPerSecond < 0 {
+		bytesPerSecond = 0
+	}
+
+	n.usage.setLimit(bytesPerSecond, time.Now())
+}
+
+// messageType returns the type a message was registered under.
+func messageType(msg interface{}) string {
+	return reflect.ValueOf(msg).Elem().FieldByName("Message").FieldByName("Type").String()
+}
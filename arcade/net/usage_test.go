@@ -0,0 +1,74 @@
+package net
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUsageCounts(t *testing.T) {
+	u := newUsageMeter()
+
+	u.countSent("a", "pong_state", 100)
+	u.countSent("b", "pong_state", 100)
+	u.countReceived("a", "heartbeat", 30)
+
+	before := u.snapshot()
+
+	u.countSent("a", "chat", 20)
+	u.countReceived("b", "pong_state", 50)
+
+	r := u.snapshot()
+
+	if r.Total != (Traffic{220, 80}) || r.Peers["a"] != (Traffic{120, 30}) {
+		t.Errorf("got %+v", r)
+	}
+
+	if busiest := r.Busiest(); busiest != "pong_state" {
+		t.Errorf("busiest = %q, want pong_state", busiest)
+	}
+
+	diff := r.Since(before)
+
+	if diff.Total != (Traffic{20, 50}) || len(diff.Peers) != 2 || len(diff.Types) != 2 {
+		t.Errorf("since: got %+v", diff)
+	}
+
+	// Reports are copies
+	r.Peers["a"] = Traffic{}
+
+	if u.snapshot().Peers["a"] == (Traffic{}) {
+		t.Error("changing a report changed the meter")
+	}
+}
+
+func TestUsageSendCap(t *testing.T) {
+	u := newUsageMeter()
+	now := time.Now()
+
+	if !u.allowSend(PriorityState, 1<<20, now) {
+		t.Error("held back a message with no cap")
+	}
+
+	u.setLimit(1000, now)
+
+	if !u.allowSend(PriorityState, 800, now) || u.allowSend(PriorityState, 800, now) {
+		t.Error("state wasn't capped at a second's worth")
+	}
+
+	// Control messages go regardless, and put us in debt
+	if !u.allowSend(PriorityControl, 1000, now) {
+		t.Error("held back a control message")
+	}
+
+	if u.allowSend(PriorityChat, 100, now.Add(500*time.Millisecond)) {
+		t.Error("sent chat while in debt")
+	}
+
+	if !u.allowSend(PriorityChat, 100, now.Add(2*time.Second)) {
+		t.Error("the cap didn't refill")
+	}
+
+	if dropped := u.snapshot().Dropped; dropped != 2 {
+		t.Errorf("dropped = %d, want 2", dropped)
+	}
+}
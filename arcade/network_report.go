@@ -0,0 +1,24 @@
+package arcade
+
+import (
+	"arcade/arcade/net"
+	"fmt"
+)
+
+// networkReport sums up our network use since the report given, for the
+// end of a match, or returns "" if there was none.
+func networkReport(since net.UsageReport) string {
+	usage := arcade.Server.Network.Usage().Since(since)
+
+	if usage.Total == (net.Traffic{}) {
+		return ""
+	}
+
+	report := fmt.Sprintf("Network: %s sent, %s received, mostly %s", formatBytes(uint64(usage.Total.Sent)), formatBytes(uint64(usage.Total.Received)), usage.Busiest())
+
+	if usage.Dropped > 0 {
+		report += fmt.Sprintf(", %d messages dropped by the cap", usage.Dropped)
+	}
+
+	return report
+}
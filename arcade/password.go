@@ -0,0 +1,127 @@
+package arcade
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"sync"
+	"time"
+)
+
+const (
+	maxPasswordLength = 20
+
+	// Failed join attempts allowed before a client is locked out
+	maxJoinFailures = 3
+
+	// How long the first lockout lasts. Each further lockout doubles it, up
+	// to this many times
+	joinLockout           = 30 * time.Second
+	maxJoinLockoutDoubles = 6
+
+	// Clients that haven't failed to join in this long start over
+	joinForgetAfter = time.Hour
+)
+
+func hashLobbyPassword(lobbyID, password string) []byte {
+	sum := sha256.Sum256([]byte(lobbyID + ":" + password))
+	return sum[:]
+}
+
+// SetPassword protects the lobby with a password. Only a hash is kept, and it
+// is never sent to other players.
+func (l *Lobby) SetPassword(password string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if password == "" {
+		l.HasPassword = false
+		l.passwordHash = nil
+		return
+	}
+
+	l.HasPassword = true
+	l.passwordHash = hashLobbyPassword(l.ID, password)
+}
+
+func (l *Lobby) CheckPassword(password string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if !l.HasPassword {
+		return true
+	}
+
+	return subtle.ConstantTimeCompare(l.passwordHash, hashLobbyPassword(l.ID, password)) == 1
+}
+
+type joinAttempts struct {
+	failures    int
+	lockouts    int
+	lockedUntil time.Time
+	lastFailure time.Time
+}
+
+// joinLimiter keeps clients from brute forcing a lobby's code or password by
+// locking them out after a few failed attempts. Clients are told apart by
+// connectionKey, since they pick their own IDs.
+type joinLimiter struct {
+	mu sync.Mutex
+
+	attempts map[string]*joinAttempts
+}
+
+func newJoinLimiter() *joinLimiter {
+	return &joinLimiter{
+		attempts: make(map[string]*joinAttempts),
+	}
+}
+
+// Allowed returns false while the client is locked out.
+func (jl *joinLimiter) Allowed(clientID string) bool {
+	jl.mu.Lock()
+	defer jl.mu.Unlock()
+
+	a, ok := jl.attempts[clientID]
+	return !ok || time.Now().After(a.lockedUntil)
+}
+
+func (jl *joinLimiter) Failed(clientID string) {
+	jl.mu.Lock()
+	defer jl.mu.Unlock()
+
+	a, ok := jl.attempts[clientID]
+
+	if !ok {
+		a = &joinAttempts{}
+		jl.attempts[clientID] = a
+	}
+
+	now := time.Now()
+	a.failures++
+	a.lastFailure = now
+
+	if a.failures >= maxJoinFailures {
+		doubles := a.lockouts
+
+		if doubles > maxJoinLockoutDoubles {
+			doubles = maxJoinLockoutDoubles
+		}
+
+		a.failures = 0
+		a.lockedUntil = now.Add(joinLockout << doubles)
+		a.lockouts++
+	}
+
+	for key, a := range jl.attempts {
+		if now.After(a.lockedUntil) && now.Sub(a.lastFailure) > joinForgetAfter {
+			delete(jl.attempts, key)
+		}
+	}
+}
+
+func (jl *joinLimiter) Succeeded(clientID string) {
+	jl.mu.Lock()
+	defer jl.mu.Unlock()
+
+	delete(jl.attempts, clientID)
+}
@@ -0,0 +1,57 @@
+package arcade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJoinLimiterLocksOut(t *testing.T) {
+	jl := newJoinLimiter()
+
+	for i := 0; i < maxJoinFailures; i++ {
+		if !jl.Allowed("a") {
+			t.Fatalf("locked out after %d failures", i)
+		}
+
+		jl.Failed("a")
+	}
+
+	if jl.Allowed("a") {
+		t.Error("not locked out")
+	}
+
+	if !jl.Allowed("b") {
+		t.Error("another client was locked out")
+	}
+
+	jl.Succeeded("a")
+
+	if !jl.Allowed("a") {
+		t.Error("still locked out after succeeding")
+	}
+}
+
+func TestJoinLimiterCapsLockouts(t *testing.T) {
+	jl := newJoinLimiter()
+	longest := joinLockout << maxJoinLockoutDoubles
+
+	for i := 0; i < 100*maxJoinFailures; i++ {
+		jl.Failed("a")
+	}
+
+	until := time.Until(jl.attempts["a"].lockedUntil)
+
+	if until <= 0 || until > longest {
+		t.Errorf("locked out for %v, want up to %v", until, longest)
+	}
+}
+
+func TestMessageOrigin(t *testing.T) {
+	if messageOrigin("10.0.0.1:6824") != messageOrigin("10.0.0.1:7000") {
+		t.Error("ports of one host have different origins")
+	}
+
+	if messageOrigin("10.0.0.1:6824") == messageOrigin("10.0.0.2:6824") {
+		t.Error("two hosts have the same origin")
+	}
+}
@@ -0,0 +1,1388 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/message"
+	"arcade/arcade/net"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+type PongSide int
+
+// Sides are handed out to players in this order, so a two player game is
+// classic left vs. right Pong and a four player game has a paddle on every
+// wall. Sides without a player (or whose player has been eliminated) act as
+// walls.
+const (
+	PongLeft PongSide = iota
+	PongRight
+	PongTop
+	PongBottom
+)
+
+const (
+	PongMaxPlayers      = 4
+	PongStartingLives   = 3
+	PongTickPeriod      = 50 * time.Millisecond
+	pongVerticalPaddle  = 5
+	pongHorizPaddle     = 10
+	pongBallSpeedX      = 1.0
+	pongBallSpeedY      = 0.5
+	pongObstacleTicks   = 4
+	pongMaxBounceOffset = 0.6
+
+	// How much faster the ball gets each step of sudden death, and the
+	// fastest it goes, so it can't skip over an obstacle
+	pongSuddenDeathSpeedup = 1.2
+	pongMaxBallSpeed       = 2.0
+)
+
+// Court bounds, inclusive, in display coordinates. These line up with the box
+// drawn around the game, same as Tron.
+const (
+	pongCourtX1 = 2
+	pongCourtY1 = 2
+	pongCourtX2 = displayWidth - 3
+	pongCourtY2 = displayHeight - 3
+)
+
+type PongClientState struct {
+	Side  PongSide
+	Pos   int
+	Lives int
+	Color string
+}
+
+func (cs PongClientState) Eliminated() bool {
+	return cs.Lives <= 0
+}
+
+type PongBall struct {
+	X, Y   float64
+	VX, VY float64
+}
+
+type PongObstacle struct {
+	X, Y          int
+	Width, Height int
+	Dir           int
+}
+
+func (o PongObstacle) contains(x, y int) bool {
+	return x >= o.X && x < o.X+o.Width && y >= o.Y && y < o.Y+o.Height
+}
+
+type PongGameState struct {
+	Tick         int
+	Ball         PongBall
+	ClientStates map[string]PongClientState
+	Obstacles    []PongObstacle
+	Ended        bool
+	Winner       string
+
+	// Balls served so far, each drawn from the match's random numbers
+	Serves int
+
+	// The time limit, after which the ball speeds up
+	Timer *MatchTimer `json:",omitempty"`
+
+	// The match's random seed, revealed once it's over
+	Seed string
+
+	// Player whose connection the game's paused for, or empty while it's
+	// playing
+	Paused string `json:",omitempty"`
+
+	// Set while the game's held still after the host stalled
+	Stalled bool `json:",omitempty"`
+}
+
+// withClientState returns a copy of the state with the given player updated.
+// States are shared with in-flight network messages, so their maps are never
+// written to in place.
+func (state PongGameState) withClientState(playerID string, cs PongClientState) PongGameState {
+	clientStates := make(map[string]PongClientState, len(state.ClientStates))
+
+	for id, cs := range state.ClientStates {
+		clientStates[id] = cs
+	}
+
+	clientStates[playerID] = cs
+	state.ClientStates = clientStates
+
+	return state
+}
+
+type PongGameRenderState int
+
+const (
+	PongInitScreen PongGameRenderState = iota
+	PongGameScreen
+	PongWinScreen
+)
+
+type PongGameView struct {
+	View
+	mgr *ViewManager
+	Game[PongGameState, PongClientState]
+
+	mu           sync.RWMutex
+	state        PongGameState
+	renderState  PongGameRenderState
+	countdownNum int
+	stopTickerCh chan bool
+	chat         *ChatOverlay
+
+	// The host sends each player deltas from the last state they acked
+	snapshots *SnapshotEncoder
+	decoder   *SnapshotDecoder
+	pacer     *SnapshotPacer
+
+	inputs *InputValidator
+
+	// The host compares players' checksums with its state; players send one
+	// every so often
+	desync       *DesyncDetector
+	lastChecksum int
+
+	// Serves seen as they happened, by number, to check against the seed
+	serves map[int]PongBall
+
+	// Set if we're hosting and the lobby can be watched
+	spectate *spectateStream
+
+	// Set unless replays are turned off
+	replay *replayRecorder
+
+	// Moves our paddle every tick while its key's held, and is stopped once
+	// the view's unloaded
+	hold     *KeyHold
+	holdStop chan struct{}
+
+	// Players that are a dedicated server's bots, whose paddles the host
+	// moves
+	bots []string
+
+	// The host holds the game still after it stalls. Players note when the
+	// host was last heard from, and whether they've given up waiting on it
+	stall       HostStall
+	lastHeard   time.Time
+	hostStalled bool
+}
+
+func NewPongGameView(mgr *ViewManager, lobby *Lobby, rng *MatchRNG) *PongGameView {
+	lobby.mu.RLock()
+	defer lobby.mu.RUnlock()
+
+	playerIDs := make([]string, len(lobby.PlayerIDs))
+	copy(playerIDs, lobby.PlayerIDs)
+
+	v := &PongGameView{
+		mgr: mgr,
+		Game: Game[PongGameState, PongClientState]{
+			ID:             lobby.ID,
+			PlayerIDs:      playerIDs,
+			Name:           lobby.Name,
+			Me:             arcade.Server.ID,
+			HostID:         lobby.HostID,
+			TimestepPeriod: int(PongTickPeriod.Milliseconds()),
+			RNG:            rng,
+
+			PauseOnInterrupt: true,
+			NetworkStart:     arcade.Server.Network.Usage(),
+		},
+		countdownNum: 3,
+		stopTickerCh: make(chan bool),
+		chat:         NewChatOverlay(lobby.ID, playerIDs),
+		snapshots:    NewSnapshotEncoder(),
+		pacer:        NewSnapshotPacer(),
+		decoder:      NewSnapshotDecoder(),
+		inputs:       NewInputValidator(Pong, mgr.Config().KickCheaters),
+		desync:       NewDesyncDetector(lobby.ID),
+		serves:       make(map[int]PongBall),
+		spectate:     newSpectateStream(lobby),
+		hold:         NewKeyHold(mgr.Config()),
+		holdStop:     make(chan struct{}),
+		replay:       newReplayRecorder(mgr, lobby),
+		bots:         append([]string(nil), lobby.Bots...),
+	}
+
+	// Players draw a placeholder until the host's first state arrives
+	if !rng.Known() {
+		rng = NewMatchRNG()
+	}
+
+	v.state = newPongGameState(playerIDs, lobby.Obstacles, rng)
+	v.state.Timer = NewMatchTimer(time.Duration(lobby.TimeLimit)*time.Second, pongSuddenDeathEvery, PongTickPeriod)
+
+	return v
+}
+
+func NewPongClientUpdateMessage(playerID string, update PongClientState) *ClientUpdateMessage[PongClientState] {
+	return &ClientUpdateMessage[PongClientState]{
+		Message: message.Message{Type: "pong_client_update"},
+		Id:      playerID,
+		Update:  update,
+	}
+}
+
+func newPongGameState(playerIDs []string, obstacles bool, rng *MatchRNG) PongGameState {
+	state := PongGameState{
+		ClientStates: make(map[string]PongClientState),
+	}
+
+	for i, playerID := range playerIDs {
+		if i >= PongMaxPlayers {
+			break
+		}
+
+		side := PongSide(i)
+		state.ClientStates[playerID] = PongClientState{
+			Side:  side,
+			Pos:   pongPaddleCenter(side),
+			Lives: PongStartingLives,
+			Color: TRON_COLORS[i],
+		}
+	}
+
+	if obstacles {
+		centerX := (pongCourtX1 + pongCourtX2) / 2
+
+		state.Obstacles = []PongObstacle{
+			{X: centerX - 12, Y: pongCourtY1 + 4, Width: 2, Height: 3, Dir: 1},
+			{X: centerX + 11, Y: pongCourtY2 - 6, Width: 2, Height: 3, Dir: -1},
+		}
+	}
+
+	state.Ball = newPongBall(rng)
+	return state
+}
+
+func newPongBall(rng *MatchRNG) PongBall {
+	vx, vy := pongBallSpeedX, pongBallSpeedY
+
+	if rng.Intn(2) == 0 {
+		vx = -vx
+	}
+
+	if rng.Intn(2) == 0 {
+		vy = -vy
+	}
+
+	return PongBall{
+		X:  float64(pongCourtX1+pongCourtX2) / 2,
+		Y:  float64(pongCourtY1+pongCourtY2) / 2,
+		VX: vx,
+		VY: vy,
+	}
+}
+
+func pongPaddleCenter(side PongSide) int {
+	switch side {
+	case PongTop, PongBottom:
+		return (pongCourtX1 + pongCourtX2) / 2
+	default:
+		return (pongCourtY1 + pongCourtY2) / 2
+	}
+}
+
+// pongPaddleLine returns the row or column the paddle on the given side moves
+// along.
+func pongPaddleLine(side PongSide) int {
+	switch side {
+	case PongLeft:
+		return pongCourtX1 + 1
+	case PongRight:
+		return pongCourtX2 - 1
+	case PongTop:
+		return pongCourtY1 + 1
+	default:
+		return pongCourtY2 - 1
+	}
+}
+
+func pongPaddleLength(side PongSide) int {
+	if side == PongTop || side == PongBottom {
+		return pongHorizPaddle
+	}
+
+	return pongVerticalPaddle
+}
+
+// clampPaddle keeps the paddle fully inside the court, and out of the corners
+// so that horizontal and vertical paddles never overlap.
+// pongPaddleStep returns how far a paddle moves for one key press. Paddles
+// along the top and bottom move further, since cells are taller than wide.
+func pongPaddleStep(side PongSide) int {
+	if side == PongTop || side == PongBottom {
+		return 2
+	}
+
+	return 1
+}
+
+func clampPaddle(side PongSide, pos int) int {
+	half := pongPaddleLength(side) / 2
+	min, max := pongCourtY1+2+half, pongCourtY2-2-half
+
+	if side == PongTop || side == PongBottom {
+		min, max = pongCourtX1+2+half, pongCourtX2-2-half
+	}
+
+	if pos < min {
+		return min
+	} else if pos > max {
+		return max
+	}
+
+	return pos
+}
+
+func (v *PongGameView) Init() {
+	go func() {
+		defer v.mgr.recoverCrash()
+
+		for i := 3; i > 0; i-- {
+			v.mu.Lock()
+			v.countdownNum = i
+			v.mu.Unlock()
+
+			playSound(SoundCountdown)
+			announce("Game starting in %d", i)
+			v.mgr.RequestRender()
+			time.Sleep(time.Second)
+		}
+
+		v.mu.Lock()
+		v.renderState = PongGameScreen
+		v.Game.start()
+		v.mu.Unlock()
+
+		if v.Me == v.HostID {
+			v.startHostLoop()
+		}
+
+		v.moveHeld()
+	}()
+}
+
+// moveHeld moves our paddle each tick while its key's held down, until the
+// view's unloaded.
+func (v *PongGameView) moveHeld() {
+	ticker := time.NewTicker(PongTickPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			if action := v.hold.Tick(now); action != "" {
+				v.move(action)
+			}
+
+			v.watchHost(now)
+		case <-v.holdStop:
+			return
+		}
+	}
+}
+
+// startHostLoop runs the authoritative simulation. Only the host moves the ball
+// and keeps score; every other player just renders the state it receives.
+func (v *PongGameView) startHostLoop() {
+	ticker := time.NewTicker(PongTickPeriod)
+
+	go func() {
+		defer v.mgr.recoverCrash()
+
+		// What the last tick left each paddle as, so the replay only keeps
+		// the moves players made since
+		stepped := make(map[string]PongClientState)
+
+		for {
+			select {
+			case now := <-ticker.C:
+				if held, returned := v.stall.Tick(now); held {
+					v.holdForStall(returned)
+					continue
+				}
+
+				if waiting := v.waitingFor(); waiting != "" {
+					v.holdFor(waiting)
+					continue
+				}
+
+				v.mu.Lock()
+				v.state = movePongBots(v.state, v.bots)
+				previous := v.state
+				v.state.Paused = ""
+				v.state.Stalled = false
+				v.state = stepPong(v.state, v.RNG)
+				v.Timestep = v.state.Tick
+
+				if v.state.Ended {
+					v.state.Seed = v.RNG.Seed()
+				}
+				state := v.state
+				v.mu.Unlock()
+
+				for id, cs := range previous.ClientStates {
+					if last, ok := stepped[id]; !ok || last != cs {
+						v.replay.input(previous.Tick, id, cs)
+					}
+				}
+
+				stepped = state.ClientStates
+
+				checksum := newStateChecksum(state.Tick, state)
+				v.desync.Record(checksum)
+
+				v.stateChanged(previous, state)
+
+				v.broadcastState(state)
+				v.spectate.send(state, state.Ended)
+
+				if state.Ended {
+					v.replay.conclude(state.Seed, checksum)
+				}
+
+				v.replay.record(state, state.Winner, state.Ended)
+
+				if state.Ended {
+					v.mu.Lock()
+					v.renderState = PongWinScreen
+					v.mu.Unlock()
+				}
+
+				v.mgr.RequestRender()
+
+				if state.Ended {
+					ticker.Stop()
+					return
+				}
+			case <-v.stopTickerCh:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// waitingFor returns a player still in the game whose connection to us is
+// interrupted, or "" if there isn't one.
+func (v *PongGameView) waitingFor() string {
+	if !v.PauseOnInterrupt {
+		return ""
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	for _, playerID := range v.PlayerIDs {
+		cs, ok := v.state.ClientStates[playerID]
+
+		if playerID == v.Me || !ok || cs.Eliminated() {
+			continue
+		}
+
+		if q, ok := arcade.Server.ConnectionQuality(playerID); ok && q.Interrupted {
+			return playerID
+		}
+	}
+
+	return ""
+}
+
+// holdFor keeps the ball where it is for a tick while the player's
+// connection is interrupted. Once it's back, or they're dropped for timing
+// out, the game carries on.
+func (v *PongGameView) holdFor(playerID string) {
+	v.mu.Lock()
+	previous := v.state
+	v.state.Paused = playerID
+	state := v.state
+	v.mu.Unlock()
+
+	v.stateChanged(previous, state)
+	v.broadcastState(state)
+	v.spectate.send(state, false)
+	v.mgr.RequestRender()
+}
+
+// holdForStall keeps the ball where it is for a tick while the game catches
+// up after we stalled. Everyone's sent the whole state once we're back, since
+// the deltas they'd get are from before they could have fallen behind.
+func (v *PongGameView) holdForStall(returned bool) {
+	if returned {
+		log.Println("Stalled, holding the game while everyone catches up")
+
+		for _, playerID := range v.PlayerIDs {
+			v.snapshots.Resync(playerID)
+		}
+	}
+
+	v.mu.Lock()
+	previous := v.state
+	v.state.Stalled = true
+	state := v.state
+	v.mu.Unlock()
+
+	v.stateChanged(previous, state)
+	v.broadcastState(state)
+	v.spectate.send(state, false)
+	v.mgr.RequestRender()
+}
+
+// watchHost shows that the host's stalled once we've gone too long without
+// a state from it, rather than let us play on in a game that isn't moving.
+func (v *PongGameView) watchHost(now time.Time) {
+	v.mu.Lock()
+	stalled := v.Me != v.HostID && v.renderState == PongGameScreen && hostQuiet(v.lastHeard, now)
+	changed := stalled && !v.hostStalled
+	v.hostStalled = v.hostStalled || stalled
+	v.mu.Unlock()
+
+	if changed {
+		announce("Waiting for the host")
+		v.mgr.RequestRender()
+	}
+}
+
+// movePongBots moves each bot's paddle a step toward the ball.
+func movePongBots(state PongGameState, bots []string) PongGameState {
+	for _, id := range bots {
+		cs, ok := state.ClientStates[id]
+
+		if ok && !cs.Eliminated() {
+			state = state.withClientState(id, movePongBot(state, cs, 0))
+		}
+	}
+
+	return state
+}
+
+func (v *PongGameView) broadcastState(state PongGameState) {
+	if _, err := v.snapshots.Add(state); err != nil {
+		return
+	}
+
+	for _, playerID := range v.PlayerIDs {
+		if playerID == v.Me {
+			continue
+		}
+
+		client, ok := arcade.Server.Network.GetClient(playerID)
+
+		if !ok {
+			continue
+		}
+
+		// A player who can't keep up gets the next tick instead, and one on
+		// a struggling link gets fewer ticks, but everyone hears how the game
+		// ended
+		if !state.Ended && (arcade.Server.Network.Congested(client) || !v.snapshotDue(client, playerID, state.Tick)) {
+			continue
+		}
+
+		// The last state goes whole, since there's no next one to catch up
+		snapshot, err := v.snapshots.Encode(playerID, state.Ended)
+
+		if err != nil {
+			continue
+		}
+
+		msg := NewGameSnapshotMessage(v.ID, snapshot)
+
+		// Only the newest tick matters, until the last one
+		if state.Ended {
+			arcade.Server.Network.Send(client, msg)
+		} else {
+			arcade.Server.Network.SendUnreliable(client, "pong_state", msg)
+		}
+	}
+}
+
+// snapshotDue returns whether the player's link is keeping up well enough
+// for them to get the tick's state.
+func (v *PongGameView) snapshotDue(client *net.Client, playerID string, tick int) bool {
+	rtt := time.Duration(-1)
+
+	if q, ok := arcade.Server.ConnectionQuality(playerID); ok {
+		rtt = q.RTT
+	}
+
+	return v.pacer.Due(playerID, tick, arcade.Server.Network.Backlog(client), rtt)
+}
+
+// stepPong advances the game by one tick. It never modifies the given state's
+// maps in place, so the result can be safely sent over the network while the
+// next tick is being computed.
+func stepPong(state PongGameState, rng *MatchRNG) PongGameState {
+	if state.Ended {
+		return state
+	}
+
+	clientStates := make(map[string]PongClientState, len(state.ClientStates))
+
+	for id, cs := range state.ClientStates {
+		clientStates[id] = cs
+	}
+
+	obstacles := make([]PongObstacle, len(state.Obstacles))
+	copy(obstacles, state.Obstacles)
+
+	state.ClientStates = clientStates
+	state.Obstacles = obstacles
+	state.Tick++
+
+	if state.Tick%pongObstacleTicks == 0 {
+		for i := range state.Obstacles {
+			o := &state.Obstacles[i]
+
+			if o.Y+o.Dir < pongCourtY1+2 || o.Y+o.Height+o.Dir > pongCourtY2-1 {
+				o.Dir = -o.Dir
+			}
+
+			o.Y += o.Dir
+		}
+	}
+
+	ball := state.Ball
+
+	if state.Timer.StepStarts(state.Tick) {
+		ball = speedUpPongBall(ball, 1)
+	}
+
+	nextX, nextY := ball.X+ball.VX, ball.Y+ball.VY
+
+	// Obstacles
+	for _, o := range state.Obstacles {
+		cx, cy := int(math.Round(ball.X)), int(math.Round(ball.Y))
+		nx, ny := int(math.Round(nextX)), int(math.Round(nextY))
+
+		if !o.contains(nx, ny) {
+			continue
+		}
+
+		hitX, hitY := o.contains(nx, cy), o.contains(cx, ny)
+
+		if hitX || !hitY {
+			ball.VX = -ball.VX
+			nextX = ball.X + ball.VX
+		}
+
+		if hitY || !hitX {
+			ball.VY = -ball.VY
+			nextY = ball.Y + ball.VY
+		}
+	}
+
+	// Walls and paddles
+	for _, side := range []PongSide{PongLeft, PongRight, PongTop, PongBottom} {
+		playerID, paddle, ok := pongPlayerOnSide(state, side)
+		line := float64(pongPaddleLine(side))
+
+		var crossing bool
+		var along float64
+
+		switch side {
+		case PongLeft:
+			crossing, along = ball.VX < 0 && nextX <= line, nextY
+		case PongRight:
+			crossing, along = ball.VX > 0 && nextX >= line, nextY
+		case PongTop:
+			crossing, along = ball.VY < 0 && nextY <= line, nextX
+		case PongBottom:
+			crossing, along = ball.VY > 0 && nextY >= line, nextX
+		}
+
+		if !crossing {
+			continue
+		}
+
+		if !ok {
+			// Nobody defends this side, bounce off the wall
+			ball, nextX, nextY = reflectPongBall(ball, side, 0)
+			continue
+		}
+
+		offset := (along - float64(paddle.Pos)) / (float64(pongPaddleLength(side)) / 2)
+
+		if math.Abs(offset) <= 1 {
+			ball, nextX, nextY = reflectPongBall(ball, side, offset)
+			continue
+		}
+
+		// Missed, this side loses a life and the ball is served again
+		paddle.Lives--
+		state.ClientStates[playerID] = paddle
+
+		ball = speedUpPongBall(newPongBall(rng), state.Timer.Steps(state.Tick))
+		state.Serves++
+		nextX, nextY = ball.X, ball.Y
+		break
+	}
+
+	ball.X, ball.Y = nextX, nextY
+	state.Ball = ball
+
+	remaining := make([]string, 0, len(state.ClientStates))
+
+	for id, cs := range state.ClientStates {
+		if !cs.Eliminated() {
+			remaining = append(remaining, id)
+		}
+	}
+
+	if len(remaining) <= 1 && len(state.ClientStates) > 1 {
+		state.Ended = true
+
+		if len(remaining) == 1 {
+			state.Winner = remaining[0]
+		}
+	}
+
+	return state
+}
+
+// speedUpPongBall makes the ball faster for the steps of sudden death, up to
+// the fastest it can go.
+func speedUpPongBall(ball PongBall, steps int) PongBall {
+	for i := 0; i < steps; i++ {
+		ball.VX *= pongSuddenDeathSpeedup
+		ball.VY *= pongSuddenDeathSpeedup
+	}
+
+	ball.VX = math.Max(-pongMaxBallSpeed, math.Min(ball.VX, pongMaxBallSpeed))
+	ball.VY = math.Max(-pongMaxBallSpeed, math.Min(ball.VY, pongMaxBallSpeed))
+
+	return ball
+}
+
+// simulatePongReplay plays a game from its seed and the paddle moves recorded
+// by its host, up to the tick it ended at.
+func simulatePongReplay(replay *Replay) (PongGameState, error) {
+	if !replay.Simulated() {
+		return PongGameState{}, errors.New("replay can't be simulated")
+	}
+
+	data, err := hex.DecodeString(replay.Seed)
+
+	if err != nil {
+		return PongGameState{}, err
+	}
+
+	rng := NewCommittedMatchRNG(seedCommitment(data))
+
+	if err := rng.Reveal(replay.Seed); err != nil {
+		return PongGameState{}, err
+	}
+
+	state := newPongGameState(replay.Info.PlayerIDs, replay.Info.Obstacles, rng)
+	state.Timer = NewMatchTimer(time.Duration(replay.Info.TimeLimit)*time.Second, pongSuddenDeathEvery, PongTickPeriod)
+	inputs := replay.Inputs
+
+	for state.Tick < replay.Final.Tick && !state.Ended {
+		for len(inputs) > 0 && inputs[0].Tick <= state.Tick {
+			var cs PongClientState
+
+			if err := json.Unmarshal(inputs[0].Input, &cs); err != nil {
+				return state, err
+			}
+
+			state = state.withClientState(inputs[0].PlayerID, cs)
+			inputs = inputs[1:]
+		}
+
+		state = stepPong(state, rng)
+	}
+
+	if state.Ended {
+		state.Seed = rng.Seed()
+	}
+
+	return state, nil
+}
+
+// reflectPongBall bounces the ball off of the given side. offset is where along
+// the paddle the ball hit, from -1 to 1, and is used to angle the ball.
+func reflectPongBall(ball PongBall, side PongSide, offset float64) (PongBall, float64, float64) {
+	switch side {
+	case PongLeft, PongRight:
+		ball.VX = -ball.VX
+
+		if offset != 0 {
+			ball.VY = pongBallSpeedY * (offset / math.Abs(offset)) * math.Max(math.Abs(offset), pongMaxBounceOffset)
+		}
+	case PongTop, PongBottom:
+		ball.VY = -ball.VY
+
+		if offset != 0 {
+			ball.VX = pongBallSpeedX * (offset / math.Abs(offset)) * math.Max(math.Abs(offset), pongMaxBounceOffset)
+		}
+	}
+
+	return ball, ball.X + ball.VX, ball.Y + ball.VY
+}
+
+// stateChanged makes a sound when someone misses the ball, and says who it
+// was and how the game ended.
+func (v *PongGameView) stateChanged(previous, state PongGameState) {
+	for i, id := range v.PlayerIDs {
+		cs, ok := state.ClientStates[id]
+		before, wasOk := previous.ClientStates[id]
+
+		if !ok || !wasOk || cs.Lives >= before.Lives {
+			continue
+		}
+
+		playSound(SoundScore)
+
+		who := fmt.Sprintf("P%d", i+1)
+		if id == v.Me {
+			who = "You"
+		}
+
+		switch {
+		case cs.Eliminated():
+			announce("%s missed, out of the game", who)
+		case cs.Lives == 1:
+			announce("%s missed, 1 life left", who)
+		default:
+			announce("%s missed, %d lives left", who, cs.Lives)
+		}
+	}
+
+	if state.Stalled && !previous.Stalled {
+		announce("The host stalled, catching up")
+	} else if !state.Stalled && previous.Stalled {
+		announce("Caught up, playing on")
+	}
+
+	if state.Paused != "" && previous.Paused == "" {
+		announce("Paused, waiting for %s", v.waitingLabel(state.Paused))
+	} else if state.Paused == "" && previous.Paused != "" {
+		announce("Connection's back, playing on")
+	}
+
+	if state.Timer.SuddenDeath(state.Tick) && !previous.Timer.SuddenDeath(previous.Tick) {
+		playSound(SoundCountdown)
+		announce("Time's up, sudden death. The ball speeds up from now on")
+	}
+
+	if state.Ended && !previous.Ended {
+		go reportMatchResult(v.matchResult(state))
+
+		if state.Winner == v.Me {
+			announce("Game over, you won")
+		} else {
+			announce("Game over, you lost")
+		}
+	}
+}
+
+// waitingLabel says whose connection the game's paused for.
+func (v *PongGameView) waitingLabel(playerID string) string {
+	if playerID == v.Me {
+		return "your connection"
+	}
+
+	for i, id := range v.PlayerIDs {
+		if id == playerID {
+			return fmt.Sprintf("P%d's connection", i+1)
+		}
+	}
+
+	return "a player's connection"
+}
+
+func pongPlayerOnSide(state PongGameState, side PongSide) (string, PongClientState, bool) {
+	for id, cs := range state.ClientStates {
+		if cs.Side == side && !cs.Eliminated() {
+			return id, cs, true
+		}
+	}
+
+	return "", PongClientState{}, false
+}
+
+func (v *PongGameView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		v.mu.RLock()
+		ended := v.state.Ended
+		v.mu.RUnlock()
+
+		if ended {
+			if evt.Key() == tcell.KeyEnter {
+				v.mgr.SetView(NewGamesListView(v.mgr))
+			}
+
+			return
+		}
+
+		if v.chat.ProcessEvent(evt) {
+			v.mgr.RequestRender()
+			return
+		}
+
+		if action := gameKeymap.Action(evt); isMoveAction(action) && v.hold.Press(action, time.Now()) {
+			v.move(action)
+		}
+	}
+}
+
+// Keymap returns the game keys, or nil while typing a chat message.
+func (v *PongGameView) Keymap() *Keymap {
+	if v.chat.Typing() {
+		return nil
+	}
+
+	return gameKeymap
+}
+
+// move moves our paddle a step, if the action's along its side.
+func (v *PongGameView) move(action Action) {
+	v.mu.Lock()
+	me, ok := v.state.ClientStates[v.Me]
+
+	// Nothing moves while the host's stalled
+	if !ok || me.Eliminated() || v.renderState != PongGameScreen || v.state.Stalled || v.hostStalled {
+		v.mu.Unlock()
+		return
+	}
+
+	step := 0
+	vertical := me.Side == PongLeft || me.Side == PongRight
+
+	switch action {
+	case ActionUp:
+		if vertical {
+			step = -pongPaddleStep(me.Side)
+		}
+	case ActionDown:
+		if vertical {
+			step = pongPaddleStep(me.Side)
+		}
+	case ActionLeft:
+		if !vertical {
+			step = -pongPaddleStep(me.Side)
+		}
+	case ActionRight:
+		if !vertical {
+			step = pongPaddleStep(me.Side)
+		}
+	}
+
+	if step == 0 {
+		v.mu.Unlock()
+		return
+	}
+
+	me.Pos = clampPaddle(me.Side, me.Pos+step)
+	v.state = v.state.withClientState(v.Me, me)
+	v.mu.Unlock()
+
+	if v.Me != v.HostID {
+		if host, ok := arcade.Server.Network.GetClient(v.HostID); ok {
+			arcade.Server.Network.Send(host, NewPongClientUpdateMessage(v.Me, me))
+		}
+	}
+
+	v.mgr.RequestRender()
+}
+
+func (v *PongGameView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	if v.chat.ProcessMessage(p) {
+		return nil
+	}
+
+	switch p := p.(type) {
+	case *GameSnapshotMessage:
+		if p.GameID != v.ID || p.SenderID != v.HostID {
+			break
+		}
+
+		var state PongGameState
+
+		if err := v.decoder.Decode(p.Snapshot, &state); err != nil {
+			break
+		}
+
+		v.replay.record(state, state.Winner, state.Ended)
+
+		// Checked before our paddle is predicted, so it's the host's state
+		v.mu.Lock()
+		sendChecksum := state.Tick-v.lastChecksum >= stateChecksumInterval
+
+		if sendChecksum {
+			v.lastChecksum = state.Tick
+		}
+		v.mu.Unlock()
+
+		if host, ok := arcade.Server.Network.GetClient(v.HostID); ok {
+			arcade.Server.Network.SendUnreliable(host, "pong_ack", NewGameSnapshotAckMessage(v.ID, p.Snapshot.Seq))
+
+			if sendChecksum {
+				arcade.Server.Network.Send(host, NewStateChecksumMessage(v.ID, newStateChecksum(state.Tick, state)))
+			}
+		}
+
+		v.mu.Lock()
+		v.lastHeard = time.Now()
+
+		// Our own paddle is predicted locally so that it doesn't lag behind
+		// the keyboard, except after the host stalled, when we go back to
+		// where it has us
+		if v.hostStalled || state.Stalled {
+			v.hostStalled = false
+		} else if me, ok := v.state.ClientStates[v.Me]; ok {
+			if cs, ok := state.ClientStates[v.Me]; ok {
+				cs.Pos = me.Pos
+				state.ClientStates[v.Me] = cs
+			}
+		}
+
+		previous := v.state
+		v.state = state
+		v.Timestep = v.state.Tick
+
+		if v.state.Ended {
+			v.renderState = PongWinScreen
+		}
+
+		// A ball in the middle was just served, unless we've seen this serve
+		_, seen := v.serves[state.Serves]
+
+		if !seen && state.Ball.X == float64(pongCourtX1+pongCourtX2)/2 && state.Ball.Y == float64(pongCourtY1+pongCourtY2)/2 {
+			v.serves[state.Serves] = state.Ball
+		}
+		v.mu.Unlock()
+
+		v.stateChanged(previous, state)
+
+		if state.Ended && !previous.Ended {
+			v.verifySeed(state.Seed)
+		}
+	case *GameSnapshotAckMessage:
+		if p.GameID == v.ID && v.Me == v.HostID {
+			v.snapshots.Ack(p.SenderID, p.Seq)
+		}
+	case *StateChecksumMessage:
+		// The next snapshot they get is whole, rather than a delta from a
+		// state they got wrong
+		if p.GameID == v.ID && v.Me == v.HostID && v.desync.Check(p.SenderID, p.Checksum) {
+			v.snapshots.Resync(p.SenderID)
+		}
+	case *ClientUpdateMessage[PongClientState]:
+		if v.Me != v.HostID || p.Id != p.SenderID {
+			break
+		}
+
+		v.mu.Lock()
+		cs, ok := v.state.ClientStates[p.Id]
+		tick := v.state.Tick
+		v.mu.Unlock()
+
+		if !ok || cs.Eliminated() {
+			break
+		}
+
+		// Updates can be merged on the way, so allow a tick's worth of presses
+		reason := ""
+		distance := p.Update.Pos - cs.Pos
+
+		if distance < 0 {
+			distance = -distance
+		}
+
+		if !v.inputs.Input(p.Id, tick) {
+			reason = "too many inputs"
+		} else if distance > maxInputsPerTick*pongPaddleStep(cs.Side) {
+			reason = fmt.Sprintf("paddle moved %d", distance)
+		}
+
+		if reason != "" {
+			if v.inputs.Violation(p.Id, reason) {
+				v.kickPlayer(p.Id)
+			}
+
+			break
+		}
+
+		v.mu.Lock()
+		if cs, ok := v.state.ClientStates[p.Id]; ok {
+			cs.Pos = clampPaddle(cs.Side, p.Update.Pos)
+			v.state = v.state.withClientState(p.Id, cs)
+		}
+		v.mu.Unlock()
+	case *KickMessage:
+		if p.LobbyID == v.ID && p.SenderID == v.HostID {
+			arcade.Server.EndAllHeartbeats()
+			v.mgr.SetView(NewGamesListView(v.mgr))
+
+			notify("You were removed from the game")
+		}
+	}
+
+	return nil
+}
+
+// matchResult is the final score to report, with the lives each player had
+// left.
+func (v *PongGameView) matchResult(state PongGameState) MatchResult {
+	scores := make(map[string]int, len(state.ClientStates))
+
+	for id, cs := range state.ClientStates {
+		scores[id] = cs.Lives
+	}
+
+	return MatchResult{
+		GameID:   v.ID,
+		GameType: Pong,
+		Players:  v.PlayerIDs,
+		Winner:   state.Winner,
+		Scores:   scores,
+	}
+}
+
+// verifySeed checks the seed the host revealed against the one it committed
+// to, and the serves we saw against the ones the seed gives.
+func (v *PongGameView) verifySeed(seed string) {
+	if err := v.RNG.Reveal(seed); err != nil {
+		log.Println("Pong: host's seed rejected:", err)
+		notify("The host's random seed didn't match, results may be rigged")
+		return
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	replay := v.RNG.Replay()
+	last := 0
+
+	for n := range v.serves {
+		if n > last {
+			last = n
+		}
+	}
+
+	for n := 0; n <= last; n++ {
+		ball := newPongBall(replay)
+
+		if seen, ok := v.serves[n]; ok && (seen.VX != ball.VX || seen.VY != ball.VY) {
+			log.Printf("Pong: serve %d was %v, seed gives %v\n", n, seen, ball)
+			notify("The host's serves didn't match its seed, results may be rigged")
+			return
+		}
+	}
+}
+
+// kickPlayer takes a cheating player's paddle out of the game and sends them
+// back to the games list.
+func (v *PongGameView) kickPlayer(playerID string) {
+	v.mu.Lock()
+	if cs, ok := v.state.ClientStates[playerID]; ok {
+		cs.Lives = 0
+		v.state = v.state.withClientState(playerID, cs)
+	}
+	v.mu.Unlock()
+
+	arcade.Server.EndHeartbeats(playerID)
+
+	if client, ok := arcade.Server.Network.GetClient(playerID); ok {
+		go arcade.Server.Network.Send(client, NewKickMessage(v.ID))
+	}
+
+	notify("%s was removed for impossible inputs", shortID(playerID, 8))
+}
+
+func (v *PongGameView) Render(s *Screen) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var warnings []HUDItem
+
+	if warning, ok := connectionWarning(v.HostID); ok {
+		warnings = append(warnings, warning)
+	}
+
+	renderPongCourt(s, v.state, v.PlayerIDs, v.Me, warnings...)
+
+	displayWidth, displayHeight := s.displaySize()
+	boxStyle := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorTeal)
+
+	switch v.renderState {
+	case PongInitScreen:
+		s.DrawBlockText(CenterX, CenterY, boxStyle, strconv.Itoa(v.countdownNum), true)
+	case PongWinScreen:
+		if v.state.Winner == v.Me {
+			s.DrawBlockText(CenterX, CenterY, boxStyle, "YOU WON", true)
+		} else {
+			s.DrawBlockText(CenterX, CenterY, boxStyle, "GAME OVER", true)
+		}
+
+		s.DrawText(layout.Center(displayWidth, returnToLobbyText), displayHeight-6, boxStyle, returnToLobbyText)
+
+		if report := networkReport(v.NetworkStart); report != "" {
+			s.DrawText(layout.Center(displayWidth, report), displayHeight-5, boxStyle, report)
+		}
+	case PongGameScreen:
+		waiting := ""
+
+		switch {
+		case v.hostStalled:
+			waiting = "Waiting for the host..."
+		case v.state.Stalled:
+			waiting = "The host stalled, catching up..."
+		case v.state.Paused != "":
+			waiting = "Paused, waiting for " + v.waitingLabel(v.state.Paused) + "..."
+		}
+
+		if waiting != "" {
+			s.DrawText(layout.Center(displayWidth, waiting), displayHeight-6, boxStyle, waiting)
+		}
+	}
+
+	v.chat.Render(s)
+}
+
+// renderPongCourt draws the court, paddles, HUD and ball. The lives of the
+// player with the given ID are in bold, and any warnings go on the right of
+// the HUD.
+func renderPongCourt(s *Screen, state PongGameState, playerIDs []string, me string, warnings ...HUDItem) {
+	s.ClearContent()
+
+	displayWidth, displayHeight := s.displaySize()
+	boxStyle := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorTeal)
+	s.DrawBox(1, 1, displayWidth-2, displayHeight-2, boxStyle, false)
+
+	for _, o := range state.Obstacles {
+		sty := tcell.StyleDefault.Background(tcell.ColorGray)
+		s.DrawEmpty(o.X, o.Y, o.X+o.Width-1, o.Y+o.Height-1, sty)
+	}
+
+	for _, playerID := range playerIDs {
+		if cs, ok := state.ClientStates[playerID]; ok {
+			renderPongPaddle(s, cs)
+		}
+	}
+
+	hud := pongHUD(state, playerIDs, me)
+	hud.Right = append(warnings, hud.Right...)
+	s.DrawHUD(hud)
+
+	ballSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorWhite)
+	s.DrawText(int(math.Round(state.Ball.X)), int(math.Round(state.Ball.Y)), ballSty, "●")
+}
+
+func renderPongPaddle(s *Screen, cs PongClientState) {
+	line := pongPaddleLine(cs.Side)
+
+	if cs.Eliminated() {
+		// An eliminated side turns into a wall
+		sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGray)
+
+		switch cs.Side {
+		case PongLeft, PongRight:
+			s.DrawLine(line, pongCourtY1, line, pongCourtY2, sty, false)
+		default:
+			s.DrawLine(pongCourtX1, line, pongCourtX2, line, sty, false)
+		}
+
+		return
+	}
+
+	sty := tcell.StyleDefault.Background(tcell.ColorNames[cs.Color])
+	half := pongPaddleLength(cs.Side) / 2
+
+	switch cs.Side {
+	case PongLeft, PongRight:
+		s.DrawEmpty(line, cs.Pos-half, line, cs.Pos+half, sty)
+	default:
+		s.DrawEmpty(cs.Pos-half, line, cs.Pos+half-1, line, sty)
+	}
+}
+
+func (v *PongGameView) StatusInfo() StatusInfo {
+	return StatusInfo{LobbyName: v.Name, HostID: v.HostID, UnreadChat: v.chat.Unread()}
+}
+
+func (v *PongGameView) PixelArea() (int, int, int, int) {
+	return pongCourtX1, pongCourtY1, pongCourtX2, pongCourtY2
+}
+
+// RenderPixels draws the court as pixels while the game is being played. The
+// countdown, results and chat are left as text.
+func (v *PongGameView) RenderPixels(c *PixelCanvas) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.renderState != PongGameScreen || v.chat.Showing() {
+		return false
+	}
+
+	renderPongPixels(c, v.state)
+	return true
+}
+
+// renderPongPixels draws the inside of the court. Unlike the text version,
+// the ball is drawn where it really is rather than snapped to a cell.
+func renderPongPixels(c *PixelCanvas, state PongGameState) {
+	c.FillCells(0, 0, pongCourtX2-pongCourtX1, pongCourtY2-pongCourtY1, tcell.ColorBlack)
+
+	for _, o := range state.Obstacles {
+		c.FillCells(o.X-pongCourtX1, o.Y-pongCourtY1, o.X+o.Width-1-pongCourtX1, o.Y+o.Height-1-pongCourtY1, tcell.ColorGray)
+	}
+
+	for _, cs := range state.ClientStates {
+		line := pongPaddleLine(cs.Side)
+		half := pongPaddleLength(cs.Side) / 2
+
+		switch {
+		case cs.Eliminated() && (cs.Side == PongLeft || cs.Side == PongRight):
+			x := float64(line - pongCourtX1)
+			c.FillRect(x+0.4, 0, x+0.6, float64(pongCourtY2-pongCourtY1+1), tcell.ColorGray)
+		case cs.Eliminated():
+			y := float64(line - pongCourtY1)
+			c.FillRect(0, y+0.4, float64(pongCourtX2-pongCourtX1+1), y+0.6, tcell.ColorGray)
+		case cs.Side == PongLeft || cs.Side == PongRight:
+			c.FillCells(line-pongCourtX1, cs.Pos-half-pongCourtY1, line-pongCourtX1, cs.Pos+half-pongCourtY1, tcell.ColorNames[cs.Color])
+		default:
+			c.FillCells(cs.Pos-half-pongCourtX1, line-pongCourtY1, cs.Pos+half-1-pongCourtX1, line-pongCourtY1, tcell.ColorNames[cs.Color])
+		}
+	}
+
+	c.FillCircle(state.Ball.X-pongCourtX1+0.5, state.Ball.Y-pongCourtY1+0.5, 0.5, tcell.ColorWhite)
+}
+
+func (v *PongGameView) Unload() {
+	close(v.holdStop)
+
+	// Closed rather than sent on, so the host loop sees it even if it's busy
+	// with a tick
+	close(v.stopTickerCh)
+}
+
+func (v *PongGameView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
+
+func (v *PongGameView) AnnotateHeartbeat(md *HeartbeatMetadata) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	md.Lobby = &LobbySummary{ID: v.ID, Name: v.Name, HostID: v.HostID, GameType: Pong, Capacity: len(v.PlayerIDs), Players: len(v.PlayerIDs), State: LobbyInGame}
+	md.Players = len(v.PlayerIDs)
+	md.StateHash = hashState(v.state)
+}
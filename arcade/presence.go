@@ -0,0 +1,45 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// Presence describes what a player is currently doing. It is reported to the
+// distributor so that friends can see each other and join each other's games.
+type Presence struct {
+	PlayerID string
+	Name     string
+	Activity string
+
+	// Set when the player hasn't touched the keyboard in a while, so they
+	// aren't matched into games
+	Away bool `json:",omitempty"`
+
+	// Set when the player is in a lobby that others may join
+	LobbyID string
+	HostID  string
+
+	// Set when the player is hosting a lobby, for the distributor's list of
+	// what's being played
+	Lobby *LobbySummary `json:",omitempty"`
+
+	// Set instead by servers hosting several lobbies
+	Lobbies []*LobbySummary `json:",omitempty"`
+}
+
+type PresenceMessage struct {
+	message.Message
+	Presence Presence
+}
+
+func NewPresenceMessage(presence Presence) *PresenceMessage {
+	return &PresenceMessage{
+		Message:  message.Message{Type: "presence"},
+		Presence: presence,
+	}
+}
+
+func (m PresenceMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
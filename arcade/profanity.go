@@ -0,0 +1,177 @@
+package arcade
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Words hidden by the profanity filter. Matching is done on whole words after
+// normalizing case and leetspeak, so words like "class" are left alone.
+var profanities = []string{
+	"ass", "asshole", "bastard", "bitch", "bollocks", "bullshit", "cock",
+	"crap", "cunt", "damn", "dick", "douche", "fag", "faggot", "fuck",
+	"motherfucker", "nigger", "piss", "prick", "pussy", "retard", "shit",
+	"slut", "twat", "wanker", "whore",
+}
+
+// Suffixes that are still caught when added to a word in the list.
+var profanitySuffixes = []string{"", "s", "es", "ed", "er", "ers", "ing", "y"}
+
+var leetspeak = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'9': 'g',
+	'@': 'a',
+	'$': 's',
+	'!': 'i',
+	'|': 'l',
+	'+': 't',
+}
+
+var profanitySet = buildProfanitySet()
+
+func buildProfanitySet() map[string]bool {
+	set := make(map[string]bool)
+
+	for _, word := range profanities {
+		for _, suffix := range profanitySuffixes {
+			set[word+suffix] = true
+			set[collapseRepeats(word+suffix)] = true
+		}
+	}
+
+	return set
+}
+
+// normalizeWord lowercases the word, undoes leetspeak and drops anything that
+// isn't a letter. Punctuation after the word goes first, so "shit!" doesn't
+// read as "shiti".
+func normalizeWord(word string) string {
+	var b strings.Builder
+
+	word = strings.TrimRightFunc(word, unicode.IsPunct)
+
+	for _, r := range strings.ToLower(word) {
+		if l, ok := leetspeak[r]; ok {
+			r = l
+		}
+
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// collapseRepeats squashes runs of the same letter, so "fuuuck" becomes "fuck".
+func collapseRepeats(word string) string {
+	var b strings.Builder
+	var last rune
+
+	for i, r := range word {
+		if i > 0 && r == last {
+			continue
+		}
+
+		b.WriteRune(r)
+		last = r
+	}
+
+	return b.String()
+}
+
+func isProfane(word string) bool {
+	normalized := normalizeWord(word)
+
+	if normalized == "" {
+		return false
+	}
+
+	return profanitySet[normalized] || profanitySet[collapseRepeats(normalized)]
+}
+
+// FilterProfanity replaces profane words in the text with asterisks.
+func FilterProfanity(text string) string {
+	var b strings.Builder
+	start := -1
+
+	flush := func(end int) {
+		word := text[start:end]
+
+		if isProfane(word) {
+			b.WriteString(strings.Repeat("*", utf8.RuneCountInString(word)))
+		} else {
+			b.WriteString(word)
+		}
+
+		start = -1
+	}
+
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				flush(i)
+			}
+
+			b.WriteRune(r)
+		} else if start < 0 {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		flush(len(text))
+	}
+
+	return b.String()
+}
+
+// The player's filter setting, read from the profile the first time it's
+// needed and kept up to date as the profile's saved, so chat lines don't
+// each read the profile from disk.
+var profanitySetting struct {
+	sync.Mutex
+	loaded bool
+	allow  bool
+}
+
+// profanityFilterEnabled returns true unless the player has turned the filter
+// off in their profile.
+func profanityFilterEnabled() bool {
+	profanitySetting.Lock()
+	defer profanitySetting.Unlock()
+
+	if !profanitySetting.loaded {
+		profile, err := LoadProfile()
+		profanitySetting.allow = err == nil && profile.AllowProfanity
+		profanitySetting.loaded = true
+	}
+
+	return !profanitySetting.allow
+}
+
+// setProfanityAllowed updates the cached setting when the profile changes.
+func setProfanityAllowed(allow bool) {
+	profanitySetting.Lock()
+	defer profanitySetting.Unlock()
+
+	profanitySetting.allow = allow
+	profanitySetting.loaded = true
+}
+
+// filterText applies the profanity filter if the player has it enabled.
+func filterText(text string) string {
+	if !profanityFilterEnabled() {
+		return text
+	}
+
+	return FilterProfanity(text)
+}
@@ -0,0 +1,69 @@
+package arcade
+
+import "testing"
+
+func TestNormalizeWord(t *testing.T) {
+	for _, c := range []struct {
+		word string
+		want string
+	}{
+		{"Shit", "shit"},
+		{"sh1t", "shit"},
+		{"$h!t", "shit"},
+		{"shit!", "shit"},
+		{"shit?!", "shit"},
+		{"shit.", "shit"},
+		{"@ss", "ass"},
+		{"a$$", "ass"},
+		{"f-u-c-k", "fuck"},
+		{"!!!", ""},
+		{"", ""},
+	} {
+		if got := normalizeWord(c.word); got != c.want {
+			t.Errorf("normalizeWord(%q) = %q, want %q", c.word, got, c.want)
+		}
+	}
+}
+
+func TestFilterProfanity(t *testing.T) {
+	for _, c := range []struct {
+		text string
+		want string
+	}{
+		{"well shit!", "well *****"},
+		{"SHIT", "****"},
+		{"fuuuuck this", "******* this"},
+		{"f*ck", "f*ck"},
+		{"fuckers", "*******"},
+		{"a class act", "a class act"},
+		{"assassin", "assassin"},
+		{"hello  world", "hello  world"},
+		{"", ""},
+	} {
+		if got := FilterProfanity(c.text); got != c.want {
+			t.Errorf("FilterProfanity(%q) = %q, want %q", c.text, got, c.want)
+		}
+	}
+}
+
+func TestPlayerNamesFiltered(t *testing.T) {
+	setProfanityAllowed(false)
+	defer setProfanityAllowed(false)
+
+	lobby := &Lobby{Names: map[string]string{"a": "sh1t head"}}
+	spectate := &SpectateView{info: SpectateInfo{Names: lobby.Names}}
+
+	if got := lobby.playerName("a"); got != "**** head" {
+		t.Errorf("lobby shows %q", got)
+	}
+
+	if got := spectate.playerName("a"); got != "**** head" {
+		t.Errorf("spectators see %q", got)
+	}
+
+	setProfanityAllowed(true)
+
+	if got := lobby.playerName("a"); got != "sh1t head" {
+		t.Errorf("filtered with the filter off: %q", got)
+	}
+}
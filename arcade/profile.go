@@ -2,34 +2,65 @@ package arcade
 
 import (
 	"encoding/json"
-	"io"
+	"errors"
+	"io/fs"
 	"os"
 	"path"
+	"sync"
 )
 
 const PROFILE_FILENAME = ".asciiarcade"
 
 type Profile struct {
-	Name  string `json:"name"`
-	Color string `json:"color"`
+	Name    string   `json:"name"`
+	Color   string   `json:"color"`
+	Avatar  string   `json:"avatar,omitempty"`
+	Friends []Friend `json:"friends,omitempty"`
+	Muted   []string `json:"muted,omitempty"`
+	Blocked []string `json:"blocked,omitempty"`
+
+	// Turns off the profanity filter for chat and player names
+	AllowProfanity bool `json:"allow_profanity,omitempty"`
+
+	LobbyFilters LobbyFilters `json:"lobby_filters"`
 }
 
-func LoadProfile() (*Profile, error) {
+// profilePath returns where the profile is kept, with instances run with a
+// profile name each keeping their own.
+func profilePath() (string, error) {
 	homeDir, err := os.UserHomeDir()
 
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	configPath := path.Join(homeDir, PROFILE_FILENAME)
-	f, err := os.Open(configPath)
+	if arcade.Profile != "" {
+		return path.Join(homeDir, PROFILE_FILENAME+"-"+arcade.Profile), nil
+	}
+
+	return path.Join(homeDir, PROFILE_FILENAME), nil
+}
+
+// Serializes reading and writing the profile, which is changed from several
+// views and goroutines
+var profileMu sync.Mutex
+
+func LoadProfile() (*Profile, error) {
+	profileMu.Lock()
+	defer profileMu.Unlock()
+
+	return loadProfile()
+}
+
+// loadProfile is LoadProfile for when profileMu is held.
+func loadProfile() (*Profile, error) {
+	configPath, err := profilePath()
 
 	if err != nil {
 		return nil, err
 	}
 
-	defer f.Close()
-	data, err := io.ReadAll(f)
+	data, err := os.ReadFile(configPath)
 
 	if err != nil {
 		return nil, err
@@ -44,23 +75,55 @@ func LoadProfile() (*Profile, error) {
 	return p, nil
 }
 
+// UpdateProfile makes a change to the saved profile, starting from an empty
+// one if there isn't one yet. If the profile can't be read, nothing's written,
+// so a bad read can't wipe it.
+func UpdateProfile(update func(p *Profile)) error {
+	profileMu.Lock()
+	defer profileMu.Unlock()
+
+	p, err := loadProfile()
+
+	if errors.Is(err, fs.ErrNotExist) {
+		p = &Profile{}
+	} else if err != nil {
+		return err
+	}
+
+	update(p)
+	return p.save()
+}
+
 func (p *Profile) Save() error {
-	homeDir, err := os.UserHomeDir()
+	profileMu.Lock()
+	defer profileMu.Unlock()
+
+	return p.save()
+}
+
+// save is Save for when profileMu is held. The profile is written to a
+// temporary file first, so it's never seen half written.
+func (p *Profile) save() error {
+	configPath, err := profilePath()
 
 	if err != nil {
 		return err
 	}
 
-	configPath := path.Join(homeDir, PROFILE_FILENAME)
 	data, err := json.MarshalIndent(p, "", " ")
 
 	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	if err := os.WriteFile(configPath+".tmp", data, 0644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(configPath+".tmp", configPath); err != nil {
 		return err
 	}
 
+	setProfanityAllowed(p.AllowProfanity)
 	return nil
 }
@@ -0,0 +1,49 @@
+package arcade
+
+import (
+	"os"
+	"path"
+	"testing"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := UpdateProfile(func(p *Profile) { p.Name = "ada" }); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateProfile(func(p *Profile) { p.Muted = []string{"x"} }); err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := LoadProfile()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Name != "ada" || len(profile.Muted) != 1 {
+		t.Errorf("got %+v", profile)
+	}
+}
+
+func TestUpdateProfileKeepsUnreadableProfile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	file := path.Join(home, PROFILE_FILENAME)
+	corrupt := []byte(`{"name": "ada", "friends": [`)
+
+	if err := os.WriteFile(file, corrupt, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateProfile(func(p *Profile) { p.Muted = []string{"x"} }); err == nil {
+		t.Error("expected an error updating an unreadable profile")
+	}
+
+	if data, _ := os.ReadFile(file); string(data) != string(corrupt) {
+		t.Errorf("profile was overwritten with %q", data)
+	}
+}
@@ -3,6 +3,7 @@ package arcade
 import (
 	"arcade/arcade/net"
 	"encoding"
+	"log"
 
 	"github.com/gdamore/tcell/v2"
 )
@@ -11,8 +12,9 @@ type ProfileView struct {
 	BaseView
 	View
 
-	nameField   *TextField
-	colorPicker *ColorPicker
+	nameField      *TextField
+	colorPicker    *ColorPicker
+	avatarSelector *Selector
 }
 
 func NewProfileView(mgr *ViewManager) *ProfileView {
@@ -21,17 +23,29 @@ func NewProfileView(mgr *ViewManager) *ProfileView {
 	}
 
 	v.nameField = NewTextField(CenterX, 7, 30, "Pick a username")
+
+	// Start from the default name in the settings, if there is one
+	if name := mgr.Config().PlayerName; name != "" {
+		v.nameField.value = name
+		v.nameField.cursorPos = len(name)
+	}
 	v.colorPicker = NewColorPicker(CenterX, 11)
+	v.avatarSelector = NewSelector(CenterX, 19, 30, "Avatar", avatarNames())
 
 	v.SetComponents(v, []Component{
 		v.nameField,
 		v.colorPicker,
-		NewButton(CenterX, 19, 20, "CONTINUE", func() {
-			profile := &Profile{
-				Name:  v.nameField.value,
-				Color: v.colorPicker.SelectedColor(),
+		v.avatarSelector,
+		NewButton(CenterX, 21, 20, "CONTINUE", func() {
+			err := UpdateProfile(func(profile *Profile) {
+				profile.Name = v.nameField.value
+				profile.Color = v.colorPicker.SelectedColor()
+				profile.Avatar = v.avatarSelector.Value()
+			})
+
+			if err != nil {
+				log.Println("Couldn't save profile:", err)
 			}
-			profile.Save()
 
 			mgr.SetView(NewGamesListView(mgr))
 		}),
@@ -65,6 +79,13 @@ func (v *ProfileView) Render(s *Screen) {
 	// Green text on default background
 	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
 	s.DrawBlockText(CenterX, 2, sty, "ASCII ARCADE", false)
+
+	// How the avatar looks in lobbies
+	width, _ := s.displaySize()
+
+	for i, line := range findAvatar(v.avatarSelector.Value()).Frame(AvatarWave, 0) {
+		s.DrawText((width+30)/2+3, 18+i, sty, line)
+	}
 }
 
 func (v *ProfileView) Unload() {
@@ -0,0 +1,146 @@
+package arcade
+
+import (
+	"arcade/arcade/net"
+	"sort"
+	"time"
+)
+
+const (
+	// Most round trip time, in milliseconds, quick match puts between any
+	// two players
+	quickMatchMaxRTT = 200
+
+	// Probes sent to each host before joining, and hosts probed before
+	// giving up
+	quickMatchProbes     = 3
+	quickMatchCandidates = 5
+)
+
+// quickMatchLobbies returns the lobbies quick match could join: public ones
+// without a password that have room, of the game and in the region if
+// they're given, whose hosts answered quickly enough when they were found.
+// Ranked matches the distributor hosts come first, so no one has the host's
+// advantage, then the nearest.
+func quickMatchLobbies(listings []lobbyListing, gameType, region string) []lobbyListing {
+	var lobbies []lobbyListing
+
+	for _, l := range listings {
+		if l.Private || l.HasPassword || l.Players >= l.Capacity || l.Ping > quickMatchMaxRTT {
+			continue
+		}
+
+		if gameType != "" && l.GameType != gameType {
+			continue
+		}
+
+		if region != "" && !sameRegion(l.Region, region) {
+			continue
+		}
+
+		lobbies = append(lobbies, l)
+	}
+
+	sort.SliceStable(lobbies, func(i, j int) bool {
+		if lobbies[i].Neutral != lobbies[j].Neutral {
+			return lobbies[i].Neutral
+		}
+
+		return lobbies[i].Ping < lobbies[j].Ping
+	})
+
+	return lobbies
+}
+
+// probeHost times a few round trips to the host, along the same route as the
+// game's messages would take, relayed by the distributor if that's how we
+// reach it. Returns the middle time, in milliseconds, with the host's own
+// times to its players, or false if it didn't answer every probe.
+func probeHost(host *net.Client) (int, map[string]int, bool) {
+	times := make([]int, 0, quickMatchProbes)
+	var rtts map[string]int
+
+	for i := 0; i < quickMatchProbes; i++ {
+		start := time.Now()
+		res, err := arcade.Server.Network.SendAndReceive(host, NewMatchProbeMessage(i))
+		reply, ok := res.(*MatchProbeReplyMessage)
+
+		if err != nil || !ok || reply.Seq != i {
+			return 0, nil, false
+		}
+
+		times = append(times, int(time.Since(start).Milliseconds()))
+		rtts = reply.RTTs
+	}
+
+	sort.Ints(times)
+	return times[len(times)/2], rtts, true
+}
+
+// matchLatency returns the worst round trip time we'd have with anyone in
+// the lobby. The host's is probed, while each player's is taken to be by way
+// of the host, which is as far apart as the two of us can be.
+func matchLatency(hostRTT int, playerRTTs map[string]int) int {
+	worst := hostRTT
+
+	for _, rtt := range playerRTTs {
+		if hostRTT+rtt > worst {
+			worst = hostRTT + rtt
+		}
+	}
+
+	return worst
+}
+
+// quickMatch joins the nearest lobby that's close enough to everyone in it,
+// probing hosts before joining, or says there isn't one.
+func (v *GamesListView) quickMatch() {
+	distributor, hasDistributor := arcade.Server.Network.GetDistributor()
+
+	v.mu.RLock()
+	all := make([]lobbyListing, 0, len(v.lobbies))
+
+	for _, lobby := range v.lobbies {
+		listing := newLobbyListing(lobby)
+		listing.Neutral = hasDistributor && listing.HostID == distributor.ID
+		all = append(all, listing)
+	}
+
+	gameType := v.filters.GameType
+	v.mu.RUnlock()
+
+	lobbies := quickMatchLobbies(all, gameType, v.mgr.matchRegion())
+
+	if len(lobbies) > quickMatchCandidates {
+		lobbies = lobbies[:quickMatchCandidates]
+	}
+
+	notify("Finding a match...")
+
+	for _, l := range lobbies {
+		host, ok := arcade.Server.Network.GetClient(l.HostID)
+
+		if !ok {
+			continue
+		}
+
+		hostRTT, rtts, ok := probeHost(host)
+
+		if !ok || matchLatency(hostRTT, rtts) > quickMatchMaxRTT {
+			continue
+		}
+
+		if v.mgr.tutorialUnseen(l.GameType) {
+			v.mgr.offerTutorial(l.GameType, func() {
+				go arcade.Engine.Join(l.HostID, l.ID, "", "")
+			})
+
+			return
+		}
+
+		arcade.Engine.Join(l.HostID, l.ID, "", "")
+		return
+	}
+
+	notify("No games close enough, try creating one")
+}
@@ -0,0 +1,59 @@
+package arcade
+
+import "testing"
+
+func TestQuickMatchLobbies(t *testing.T) {
+	listings := []lobbyListing{
+		{ID: "far", GameType: Tron, Players: 1, Capacity: 4, Ping: 250},
+		{ID: "full", GameType: Tron, Players: 4, Capacity: 4, Ping: 10},
+		{ID: "private", GameType: Tron, Private: true, Players: 1, Capacity: 4, Ping: 10},
+		{ID: "password", GameType: Tron, HasPassword: true, Players: 1, Capacity: 4, Ping: 10},
+		{ID: "pong", GameType: Pong, Players: 1, Capacity: 2, Ping: 5},
+		{ID: "near", GameType: Tron, Players: 2, Capacity: 4, Ping: 40},
+		{ID: "nearer", GameType: Tron, Players: 3, Capacity: 4, Ping: 20},
+	}
+
+	lobbies := quickMatchLobbies(listings, Tron, "")
+
+	if len(lobbies) != 2 || lobbies[0].ID != "nearer" || lobbies[1].ID != "near" {
+		t.Errorf("got %+v", lobbies)
+	}
+
+	if lobbies := quickMatchLobbies(listings, "", ""); len(lobbies) != 3 || lobbies[0].ID != "pong" {
+		t.Errorf("any game: got %+v", lobbies)
+	}
+
+	// Ranked matches are picked over nearer lobbies players host
+	listings = append(listings, lobbyListing{ID: "ranked", GameType: Tron, Players: 1, Capacity: 4, Ping: 80, Neutral: true})
+
+	if lobbies := quickMatchLobbies(listings, Tron, ""); len(lobbies) != 3 || lobbies[0].ID != "ranked" {
+		t.Errorf("with a ranked match: got %+v", lobbies)
+	}
+}
+
+func TestQuickMatchRegions(t *testing.T) {
+	listings := []lobbyListing{
+		{ID: "eu", GameType: Pong, Players: 1, Capacity: 2, Ping: 10, Region: "eu"},
+		{ID: "asia", GameType: Pong, Players: 1, Capacity: 2, Ping: 5, Region: "asia"},
+		{ID: "unknown", GameType: Pong, Players: 1, Capacity: 2, Ping: 20},
+	}
+
+	// Lobbies that don't say where they are could be anywhere
+	if lobbies := quickMatchLobbies(listings, "", "eu"); len(lobbies) != 2 || lobbies[0].ID != "eu" || lobbies[1].ID != "unknown" {
+		t.Errorf("in eu: got %+v", lobbies)
+	}
+
+	if lobbies := quickMatchLobbies(listings, "", ""); len(lobbies) != 3 || lobbies[0].ID != "asia" {
+		t.Errorf("any region: got %+v", lobbies)
+	}
+}
+
+func TestMatchLatency(t *testing.T) {
+	if got := matchLatency(50, nil); got != 50 {
+		t.Errorf("host alone: got %d", got)
+	}
+
+	if got := matchLatency(50, map[string]int{"a": 20, "b": 180}); got != 230 {
+		t.Errorf("players through the host: got %d", got)
+	}
+}
@@ -0,0 +1,210 @@
+package arcade
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// Limit for message types without one of their own
+	defaultRateLimitKey = "*"
+
+	// Over-limit messages within a second before a client is throttled
+	floodDropsPerSecond = 20
+
+	// How long a throttled client's messages are all dropped
+	throttleDuration = 5 * time.Second
+
+	// Throttled this many times without a quiet minute between, and the
+	// client is disconnected
+	floodThrottlesToDisconnect = 3
+	floodForgiveAfter          = time.Minute
+
+	// Connections quiet for this long are forgotten, checked this often
+	rateForgetAfter   = 2 * floodForgiveAfter
+	ratePruneInterval = time.Minute
+)
+
+// Inbound messages per second allowed from each client, by message type. Each
+// type can burst to twice its rate.
+var defaultRateLimits = map[string]float64{
+	defaultRateLimitKey:  100,
+	"chat":               5,
+	"client_update":      60,
+	"friends_query":      2,
+	"heartbeat":          20,
+	"invite":             2,
+	"join":               2,
+	"lobby_state":        5,
+	"pong_client_update": 60,
+	"presence":           2,
+	"replay_chunk":       50,
+	"replay_query":       2,
+	"replay_request":     50,
+	"spectate_frame":     25,
+	"spectate_query":     2,
+	"spectate_subscribe": 5,
+
+	// Everything relayed between players is sealed, and its limits apply
+	// once it's opened
+	"sealed":      300,
+	"session_key": 5,
+}
+
+// RateVerdict is what to do with a message from a client.
+type RateVerdict int
+
+const (
+	RateAllow RateVerdict = iota
+	RateDrop
+	RateDisconnect
+)
+
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type clientRate struct {
+	buckets  map[string]*rateBucket
+	lastSeen time.Time
+
+	// Over-limit messages in the current second
+	drops       int
+	dropsSecond time.Time
+
+	throttledUntil time.Time
+	throttles      int
+	lastThrottle   time.Time
+}
+
+// RateLimiter keeps a token bucket per connection and message type, and
+// throttles, then disconnects, connections that keep going over their limits.
+// Connections are named by connectionKey, so changing the sender ID on each
+// message doesn't get a flooder a fresh bucket.
+type RateLimiter struct {
+	mu sync.Mutex
+
+	limits    map[string]float64
+	clients   map[string]*clientRate
+	lastPrune time.Time
+}
+
+func NewRateLimiter() *RateLimiter {
+	r := &RateLimiter{
+		limits:  make(map[string]float64),
+		clients: make(map[string]*clientRate),
+	}
+
+	for messageType, rate := range defaultRateLimits {
+		r.limits[messageType] = rate
+	}
+
+	return r
+}
+
+// Configure overrides the limits for the given message types. A rate of 0 or
+// less removes the limit for that type.
+func (r *RateLimiter) Configure(limits map[string]float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for messageType, rate := range limits {
+		r.limits[messageType] = rate
+	}
+}
+
+// Allow records a message of the type from the connection, and returns
+// whether to handle it.
+func (r *RateLimiter) Allow(key, messageType string) RateVerdict {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.prune(now)
+
+	c, ok := r.clients[key]
+
+	if !ok {
+		c = &clientRate{buckets: make(map[string]*rateBucket)}
+		r.clients[key] = c
+	}
+
+	c.lastSeen = now
+
+	if now.Before(c.throttledUntil) {
+		return RateDrop
+	}
+
+	rate, ok := r.limits[messageType]
+
+	if !ok {
+		rate = r.limits[defaultRateLimitKey]
+	}
+
+	if rate <= 0 {
+		return RateAllow
+	}
+
+	b, ok := c.buckets[messageType]
+
+	if !ok {
+		b = &rateBucket{tokens: 2 * rate, last: now}
+		c.buckets[messageType] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	b.last = now
+
+	if b.tokens > 2*rate {
+		b.tokens = 2 * rate
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return RateAllow
+	}
+
+	if now.Sub(c.dropsSecond) >= time.Second {
+		c.drops = 0
+		c.dropsSecond = now
+	}
+
+	c.drops++
+
+	if c.drops < floodDropsPerSecond {
+		return RateDrop
+	}
+
+	// Flooding, so stop listening for a while
+	if now.Sub(c.lastThrottle) >= floodForgiveAfter {
+		c.throttles = 0
+	}
+
+	c.drops = 0
+	c.throttles++
+	c.lastThrottle = now
+	c.throttledUntil = now.Add(throttleDuration)
+
+	if c.throttles >= floodThrottlesToDisconnect {
+		return RateDisconnect
+	}
+
+	return RateDrop
+}
+
+// prune forgets connections that have gone quiet, unless they're still
+// throttled. Expects the lock to be held.
+func (r *RateLimiter) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < ratePruneInterval {
+		return
+	}
+
+	r.lastPrune = now
+
+	for key, c := range r.clients {
+		if now.Sub(c.lastSeen) > rateForgetAfter && now.After(c.throttledUntil) {
+			delete(r.clients, key)
+		}
+	}
+}
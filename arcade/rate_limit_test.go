@@ -0,0 +1,120 @@
+package arcade
+
+import (
+	"arcade/arcade/net"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBucket(t *testing.T) {
+	r := NewRateLimiter()
+	r.Configure(map[string]float64{"chat": 5})
+
+	// Bursts to twice the rate
+	for i := 0; i < 10; i++ {
+		if v := r.Allow("addr:a", "chat"); v != RateAllow {
+			t.Fatalf("message %d: got %v", i, v)
+		}
+	}
+
+	if v := r.Allow("addr:a", "chat"); v != RateDrop {
+		t.Errorf("over the burst: got %v", v)
+	}
+
+	if v := r.Allow("addr:a", "heartbeat"); v != RateAllow {
+		t.Errorf("other type: got %v", v)
+	}
+
+	if v := r.Allow("addr:b", "chat"); v != RateAllow {
+		t.Errorf("other connection: got %v", v)
+	}
+}
+
+// flood sends chat from the connection until it's throttled, returning the
+// verdict it was throttled with.
+func flood(r *RateLimiter, key string) RateVerdict {
+	for i := 0; i < 1000; i++ {
+		r.mu.Lock()
+		throttled := time.Now().Before(r.clients[key].throttledUntil)
+		r.mu.Unlock()
+
+		v := r.Allow(key, "chat")
+
+		if v == RateDisconnect {
+			return v
+		}
+
+		r.mu.Lock()
+		nowThrottled := time.Now().Before(r.clients[key].throttledUntil)
+		r.mu.Unlock()
+
+		if !throttled && nowThrottled {
+			return v
+		}
+	}
+
+	return RateAllow
+}
+
+func TestRateLimiterThrottlesThenDisconnects(t *testing.T) {
+	r := NewRateLimiter()
+	r.Allow("addr:a", "chat")
+
+	for i := 1; i < floodThrottlesToDisconnect; i++ {
+		if v := flood(r, "addr:a"); v != RateDrop {
+			t.Fatalf("throttle %d: got %v", i, v)
+		}
+
+		if v := r.Allow("addr:a", "heartbeat"); v != RateDrop {
+			t.Fatalf("throttle %d: other types got %v while throttled", i, v)
+		}
+
+		// Let the throttle run out
+		r.mu.Lock()
+		r.clients["addr:a"].throttledUntil = time.Time{}
+		r.mu.Unlock()
+	}
+
+	if v := flood(r, "addr:a"); v != RateDisconnect {
+		t.Errorf("got %v, want a disconnect", v)
+	}
+}
+
+func TestRateLimiterForgetsQuietConnections(t *testing.T) {
+	r := NewRateLimiter()
+	r.Allow("addr:a", "chat")
+	r.Allow("addr:b", "chat")
+
+	r.mu.Lock()
+	r.clients["addr:a"].lastSeen = time.Now().Add(-2 * rateForgetAfter)
+	r.lastPrune = time.Time{}
+	r.mu.Unlock()
+
+	r.Allow("addr:b", "chat")
+
+	if _, ok := r.clients["addr:a"]; ok {
+		t.Error("quiet connection wasn't forgotten")
+	}
+
+	if _, ok := r.clients["addr:b"]; !ok {
+		t.Error("busy connection was forgotten")
+	}
+}
+
+func TestConnectionKey(t *testing.T) {
+	peer := &net.Client{Addr: "10.0.0.1:6824"}
+	distributor := &net.Client{Addr: "149.28.43.157:6824"}
+	distributor.Distributor = true
+
+	if connectionKey(peer, "") != connectionKey(&net.Client{Addr: "10.0.0.1:6900"}, "spoofed") {
+		t.Error("a direct peer's key depends on more than its host")
+	}
+
+	if connectionKey(distributor, "a") == connectionKey(distributor, "b") {
+		t.Error("relayed messages from two origins share a key")
+	}
+
+	if connectionKey(distributor, "") == connectionKey(distributor, "a") {
+		t.Error("the distributor's own messages share a key with a relayed player")
+	}
+}
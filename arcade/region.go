@@ -0,0 +1,82 @@
+package arcade
+
+import "strings"
+
+// Regions players, lobbies and distributors can be in, kept short to fit a
+// column in the lobby list
+var regions = []string{"na-east", "na-west", "sa", "eu", "africa", "asia", "oceania"}
+
+const (
+	// Region setting that takes the distributor's region, if we're near it
+	RegionAuto = "auto"
+
+	// Most round trip time, in milliseconds, to a distributor for us to
+	// count as in its region
+	regionMaxRTT = 80
+)
+
+func validRegion(region string) bool {
+	return containsString(regions, region)
+}
+
+// regionOptions is every choice for the region setting.
+func regionOptions() []string {
+	return append([]string{RegionAuto}, regions...)
+}
+
+// regionList joins the regions for help and error messages.
+func regionList() string {
+	return strings.Join(regions, ", ")
+}
+
+// sameRegion returns false only if both regions are known and differ, so
+// lobbies and players that don't say are never kept apart.
+func sameRegion(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
+
+// setDistributorRegion records the region our distributor said it serves,
+// and how far away it was when we connected.
+func (s *Server) setDistributorRegion(region string, rtt int) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.distributorRegion = ""
+
+	if validRegion(region) && rtt <= regionMaxRTT {
+		s.distributorRegion = region
+	}
+}
+
+// DistributorRegion returns our distributor's region if we're near enough
+// to it to count as in it, or "" if not or it hasn't said.
+func (s *Server) DistributorRegion() string {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.distributorRegion
+}
+
+// Region returns the region we're in: the one in settings, or our
+// distributor's with auto. Empty if we can't tell.
+func (mgr *ViewManager) Region() string {
+	if region := mgr.Config().Region; region != RegionAuto && region != "" {
+		return region
+	}
+
+	if arcade.Server == nil {
+		return ""
+	}
+
+	return arcade.Server.DistributorRegion()
+}
+
+// matchRegion returns the region quick match keeps to, or "" for any, for
+// players who've opted into matches across regions.
+func (mgr *ViewManager) matchRegion() string {
+	if mgr.Config().CrossRegion {
+		return ""
+	}
+
+	return mgr.Region()
+}
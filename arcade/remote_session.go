@@ -0,0 +1,145 @@
+package arcade
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Sessions served at once by each server, each its own arcade process
+const maxSessions = 16
+
+// sessionProfiles hands out a profile for each session of a remote server.
+// Players who've proven who they are get the same one each time, so they
+// come back to their identity and settings. Anyone else plays as a guest,
+// with a profile that's thrown away when they leave, since a name or an
+// address is no proof of anything.
+type sessionProfiles struct {
+	mu sync.Mutex
+
+	// Profiles of the sessions running now
+	running map[string]bool
+
+	// Profiles of the running sessions that are deleted when they end
+	guests map[string]bool
+}
+
+func newSessionProfiles() *sessionProfiles {
+	return &sessionProfiles{running: make(map[string]bool), guests: make(map[string]bool)}
+}
+
+// claimGuest makes a profile for a session under the prefix, deleted when
+// it's released. It returns false if the server's full.
+func (p *sessionProfiles) claimGuest(prefix string) (string, bool) {
+	id := make([]byte, 6)
+
+	if _, err := rand.Read(id); err != nil {
+		return "", false
+	}
+
+	profile, ok := p.claim(prefix, "guest-"+hex.EncodeToString(id))
+
+	if ok {
+		p.mu.Lock()
+		p.guests[profile] = true
+		p.mu.Unlock()
+
+		// Anything left over from a guest of the same name before a crash
+		// isn't theirs
+		removeProfile(profile)
+	}
+
+	return profile, ok
+}
+
+// claim picks a free profile for the name, under the prefix. The name has to
+// be something the player's proven is theirs. It returns false if the
+// server's full.
+func (p *sessionProfiles) claim(prefix, name string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.running) >= maxSessions {
+		return "", false
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+
+		// Like the dots in an address
+		if r == '.' || r == ':' {
+			return '-'
+		}
+
+		return -1
+	}, name)
+
+	// Room for the prefix and a number after
+	if len(name) > 24 {
+		name = name[:24]
+	}
+
+	profile := prefix + "-" + name
+
+	for i := 2; p.running[profile]; i++ {
+		profile = fmt.Sprintf("%s-%s-%d", prefix, name, i)
+	}
+
+	p.running[profile] = true
+	return profile, true
+}
+
+func (p *sessionProfiles) release(profile string) {
+	p.mu.Lock()
+	guest := p.guests[profile]
+	delete(p.running, profile)
+	delete(p.guests, profile)
+	p.mu.Unlock()
+
+	if guest {
+		removeProfile(profile)
+	}
+}
+
+// removeProfile deletes the profile's files.
+func removeProfile(profile string) {
+	dir, err := profileDir(profile)
+
+	if err != nil {
+		return
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		log.Println("Couldn't remove a guest's profile:", err)
+	}
+}
+
+// startSessionArcade starts an arcade process reading the session from stdin
+// and drawing on out, with window sizes as "cols rows" lines on sizes. Flags
+// after the profile's are in args.
+//
+// Wait would wait on copying anything but a file to stdin, which only ends
+// when the player next types, so the caller copies the session to a pipe.
+func startSessionArcade(stdin, sizes *os.File, out io.Writer, term, profile string, args []string) (*exec.Cmd, error) {
+	executable, err := os.Executable()
+
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(executable, append([]string{"-session", "-profile", profile}, args...)...)
+	cmd.Stdin = stdin
+	cmd.Stdout = out
+	cmd.ExtraFiles = []*os.File{sizes}
+	cmd.Env = append(os.Environ(), "TERM="+term)
+
+	return cmd, cmd.Start()
+}
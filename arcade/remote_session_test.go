@@ -0,0 +1,57 @@
+package arcade
+
+import (
+	"os"
+	"testing"
+)
+
+func TestGuestProfilesAreThrownAway(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	p := newSessionProfiles()
+
+	first, _ := p.claimGuest("telnet")
+	second, _ := p.claimGuest("telnet")
+
+	if first == second {
+		t.Fatalf("two guests both got %s", first)
+	}
+
+	if !validProfileName(first) {
+		t.Fatalf("guest profile %q can't be used", first)
+	}
+
+	dir, err := profileDir(first)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	p.release(first)
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("a guest's profile was kept after they left")
+	}
+
+	// Players who've proven who they are keep theirs
+	kept, _ := p.claim("ssh", "key")
+
+	if dir, err = profileDir(kept); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	p.release(kept)
+
+	if _, err := os.Stat(dir); err != nil {
+		t.Error("a player's profile was thrown away:", err)
+	}
+}
@@ -0,0 +1,424 @@
+package arcade
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	REPLAYS_DIRNAME  = "replays"
+	REPLAY_EXTENSION = ".replay"
+
+	// How often a state is kept, the same as spectators get them
+	replayInterval = spectateInterval
+
+	// Longest game recorded, after which the rest is left out
+	maxReplayFrames = 3000
+
+	// Most inputs kept for simulating a game again. Past this the replay can
+	// still be watched, but not simulated
+	maxReplayInputs = 50000
+
+	// Largest a replay file may be, compressed and not
+	maxReplaySize       = 1 << 20
+	maxReplayStatesSize = 16 << 20
+)
+
+var errReplayTooLarge = errors.New("replay too large")
+
+// ReplayInfo describes a recorded game.
+type ReplayInfo struct {
+	ID          string
+	LobbyName   string
+	GameType    string
+	PlayerIDs   []string
+	Names       map[string]string `json:",omitempty"`
+	Winner      string            `json:",omitempty"`
+	RecordedAt  time.Time
+	Duration    time.Duration
+	Obstacles   bool `json:",omitempty"`
+	TimeLimit   int  `json:",omitempty"`
+	ShrinkArena bool `json:",omitempty"`
+
+	// Filled in by the distributor for shared replays
+	SharedBy  string `json:",omitempty"`
+	Size      int    `json:",omitempty"`
+	Downloads int    `json:",omitempty"`
+}
+
+// ReplayFrame is the game's state at a moment after it started.
+type ReplayFrame struct {
+	At    time.Duration
+	State json.RawMessage
+}
+
+// ReplayInput is something a player did that the simulation can't work out
+// for itself, applied before the tick it was taken at.
+type ReplayInput struct {
+	Tick     int
+	PlayerID string
+	Input    json.RawMessage
+}
+
+// Replay is a recorded game, played back by going through its frames.
+type Replay struct {
+	Info   ReplayInfo
+	Frames []ReplayFrame
+
+	// Custom Tron map the game was played on, and what its mod set it up with
+	Map   *TronMap   `json:",omitempty"`
+	Rules *TronRules `json:",omitempty"`
+
+	// Kept by the host, so the game can be simulated again from the start and
+	// checked against how it ended. Missing from other players' replays
+	Seed   string         `json:",omitempty"`
+	Inputs []ReplayInput  `json:",omitempty"`
+	Final  *StateChecksum `json:",omitempty"`
+}
+
+// Simulated returns true if the replay has what it takes to simulate the game
+// again.
+func (r *Replay) Simulated() bool {
+	return r.Final != nil
+}
+
+// replayRecorder keeps the states of a game we're playing in, to save as a
+// replay once it's over.
+type replayRecorder struct {
+	mu sync.Mutex
+
+	replay   Replay
+	keep     int
+	started  time.Time
+	lastSent time.Time
+	done     bool
+
+	// Set once there were too many inputs to keep
+	tooManyInputs bool
+}
+
+// newReplayRecorder returns a recorder for the lobby's game, or nil if
+// replays are turned off. Expects the lobby's lock to be held.
+func newReplayRecorder(mgr *ViewManager, lobby *Lobby) *replayRecorder {
+	keep := mgr.Config().KeepReplays
+
+	if keep <= 0 {
+		return nil
+	}
+
+	playerIDs := make([]string, len(lobby.PlayerIDs))
+	copy(playerIDs, lobby.PlayerIDs)
+
+	names := make(map[string]string, len(lobby.Names))
+
+	for id, name := range lobby.Names {
+		names[id] = name
+	}
+
+	return &replayRecorder{
+		replay: Replay{
+			Info: ReplayInfo{
+				ID:          lobby.ID,
+				LobbyName:   lobby.Name,
+				GameType:    lobby.GameType,
+				PlayerIDs:   playerIDs,
+				Names:       names,
+				Obstacles:   lobby.Obstacles,
+				TimeLimit:   lobby.TimeLimit,
+				ShrinkArena: lobby.ShrinkArena,
+			},
+		},
+		keep: keep,
+	}
+}
+
+// record keeps the state if a frame is due, and saves the replay once the game
+// has ended. It's safe to call on a nil recorder.
+func (r *replayRecorder) record(state interface{}, winner string, ended bool) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done || (!ended && time.Since(r.lastSent) < replayInterval) {
+		return
+	}
+
+	now := time.Now()
+
+	if r.started.IsZero() {
+		r.started = now
+	}
+
+	if len(r.replay.Frames) < maxReplayFrames || ended {
+		data, err := json.Marshal(state)
+
+		if err != nil {
+			return
+		}
+
+		r.replay.Frames = append(r.replay.Frames, ReplayFrame{At: now.Sub(r.started), State: data})
+	}
+
+	r.lastSent = now
+
+	if !ended {
+		return
+	}
+
+	r.done = true
+	r.replay.Info.Winner = winner
+	r.replay.Info.RecordedAt = r.started
+	r.replay.Info.Duration = now.Sub(r.started)
+
+	replay, keep := r.replay, r.keep
+
+	go func() {
+		if err := saveReplay(&replay, keep); err != nil {
+			log.Println("Couldn't save replay:", err)
+		}
+	}()
+}
+
+// input keeps something a player did before the tick, for simulating the game
+// again. It's safe to call on a nil recorder.
+func (r *replayRecorder) input(tick int, playerID string, input interface{}) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done || r.tooManyInputs {
+		return
+	}
+
+	if len(r.replay.Inputs) >= maxReplayInputs {
+		r.tooManyInputs = true
+		r.replay.Inputs = nil
+		return
+	}
+
+	data, err := json.Marshal(input)
+
+	if err != nil {
+		return
+	}
+
+	r.replay.Inputs = append(r.replay.Inputs, ReplayInput{Tick: tick, PlayerID: playerID, Input: data})
+}
+
+// conclude keeps the seed and how the game ended, for checking a simulation of
+// it against. Called by the host before the last state is recorded.
+func (r *replayRecorder) conclude(seed string, final StateChecksum) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done || r.tooManyInputs {
+		return
+	}
+
+	r.replay.Seed = seed
+	r.replay.Final = &final
+}
+
+func replaysDir() (string, error) {
+	dir, err := configDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, REPLAYS_DIRNAME), nil
+}
+
+// encodeReplay compresses a replay the way it's saved and shared.
+func encodeReplay(replay *Replay) ([]byte, error) {
+	return encodeSpectateState(replay)
+}
+
+// decodeReplay reads a replay compressed by encodeReplay, refusing ones that
+// are too large.
+func decodeReplay(data []byte) (*Replay, error) {
+	if len(data) > maxReplaySize {
+		return nil, errReplayTooLarge
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	var replay Replay
+
+	if err := json.NewDecoder(io.LimitReader(r, maxReplayStatesSize)).Decode(&replay); err != nil {
+		return nil, err
+	}
+
+	if replay.Info.ID == "" || len(replay.Frames) == 0 {
+		return nil, errors.New("empty replay")
+	}
+
+	return &replay, nil
+}
+
+// saveReplay writes the replay to the replays directory, and deletes the
+// oldest ones past how many the player keeps.
+func saveReplay(replay *Replay, keep int) error {
+	data, err := encodeReplay(replay)
+
+	if err != nil {
+		return err
+	}
+
+	return saveReplayData(replay.Info.ID, data, keep)
+}
+
+// saveReplayData writes an encoded replay, as recorded or downloaded.
+func saveReplayData(id string, data []byte, keep int) error {
+	dir, err := replaysDir()
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path.Join(dir, path.Base(id)+REPLAY_EXTENSION), data, 0644); err != nil {
+		return err
+	}
+
+	pruneReplays(keep)
+	return nil
+}
+
+// pruneReplays deletes the oldest replays past how many to keep.
+func pruneReplays(keep int) {
+	files, err := replayFiles()
+
+	if err != nil || len(files) <= keep {
+		return
+	}
+
+	for _, file := range files[keep:] {
+		os.Remove(file.path)
+	}
+}
+
+type replayFile struct {
+	path    string
+	modTime time.Time
+}
+
+// replayFiles returns the saved replays, newest first.
+func replayFiles() ([]replayFile, error) {
+	dir, err := replaysDir()
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	files := make([]replayFile, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), REPLAY_EXTENSION) {
+			continue
+		}
+
+		info, err := entry.Info()
+
+		if err != nil {
+			continue
+		}
+
+		files = append(files, replayFile{path.Join(dir, entry.Name()), info.ModTime()})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	return files, nil
+}
+
+// loadReplays returns the saved replays' descriptions, newest first.
+func loadReplays() []ReplayInfo {
+	files, err := replayFiles()
+
+	if err != nil {
+		return nil
+	}
+
+	replays := make([]ReplayInfo, 0, len(files))
+
+	for _, file := range files {
+		if replay, err := loadReplayFile(file.path); err == nil {
+			replays = append(replays, replay.Info)
+		}
+	}
+
+	return replays
+}
+
+func loadReplayFile(file string) (*Replay, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeReplay(data)
+}
+
+// loadReplayData returns a saved replay, encoded as it's shared.
+func loadReplayData(id string) ([]byte, error) {
+	dir, err := replaysDir()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(path.Join(dir, path.Base(id)+REPLAY_EXTENSION))
+}
+
+// loadReplay returns a saved replay.
+func loadReplay(id string) (*Replay, error) {
+	data, err := loadReplayData(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeReplay(data)
+}
@@ -0,0 +1,31 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// ReplayChunkMessage carries part of an encoded replay, when a player shares
+// one with the distributor or downloads one from it. The distributor
+// acknowledges each shared chunk with an empty one of the same index.
+type ReplayChunkMessage struct {
+	message.Message
+	ReplayID string
+	Index    int
+	Total    int
+	Data     []byte `json:",omitempty"`
+}
+
+func NewReplayChunkMessage(replayID string, index, total int, data []byte) *ReplayChunkMessage {
+	return &ReplayChunkMessage{
+		Message:  message.Message{Type: "replay_chunk"},
+		ReplayID: replayID,
+		Index:    index,
+		Total:    total,
+		Data:     data,
+	}
+}
+
+func (m ReplayChunkMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
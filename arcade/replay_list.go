@@ -0,0 +1,23 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// ReplayListMessage is the distributor's reply to a ReplayQueryMessage.
+type ReplayListMessage struct {
+	message.Message
+	Replays []ReplayInfo
+}
+
+func NewReplayListMessage(replays []ReplayInfo) *ReplayListMessage {
+	return &ReplayListMessage{
+		Message: message.Message{Type: "replay_list"},
+		Replays: replays,
+	}
+}
+
+func (m ReplayListMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
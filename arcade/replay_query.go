@@ -0,0 +1,21 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// ReplayQueryMessage asks the distributor for its notable shared replays.
+type ReplayQueryMessage struct {
+	message.Message
+}
+
+func NewReplayQueryMessage() *ReplayQueryMessage {
+	return &ReplayQueryMessage{
+		Message: message.Message{Type: "replay_query"},
+	}
+}
+
+func (m ReplayQueryMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,25 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// ReplayRequestMessage asks the distributor for one chunk of a shared replay.
+type ReplayRequestMessage struct {
+	message.Message
+	ReplayID string
+	Index    int
+}
+
+func NewReplayRequestMessage(replayID string, index int) *ReplayRequestMessage {
+	return &ReplayRequestMessage{
+		Message:  message.Message{Type: "replay_request"},
+		ReplayID: replayID,
+		Index:    index,
+	}
+}
+
+func (m ReplayRequestMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,246 @@
+package arcade
+
+import (
+	"arcade/arcade/net"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// Bytes of a replay in each chunk, so a chunk fits in one packet
+	replayChunkSize = 640
+
+	// Shared replays the distributor keeps, and how many of them one player
+	// can have shared
+	maxSharedReplays   = 50
+	maxSharedPerPlayer = 5
+
+	// Shared replays are forgotten after this long
+	sharedReplayMaxAge = 7 * 24 * time.Hour
+
+	// Shared replays listed as notable
+	notableReplays = 20
+
+	// How long a player has to finish sharing a replay once they've started
+	replayUploadTimeout = 2 * time.Minute
+)
+
+type sharedReplay struct {
+	info     ReplayInfo
+	data     []byte
+	sharedAt time.Time
+}
+
+type replayUpload struct {
+	replayID string
+	total    int
+	data     []byte
+	started  time.Time
+}
+
+// ReplayStore keeps the replays players have shared with the distributor, for
+// anyone to download. They're only kept in memory.
+type ReplayStore struct {
+	mu sync.Mutex
+
+	replays map[string]*sharedReplay
+
+	// Replays being shared, by the player sharing them. One at a time each
+	uploads map[string]*replayUpload
+}
+
+func NewReplayStore() *ReplayStore {
+	return &ReplayStore{
+		replays: make(map[string]*sharedReplay),
+		uploads: make(map[string]*replayUpload),
+	}
+}
+
+// handleMessage processes messages addressed to the distributor itself. The
+// second return value is false if the message isn't about replays.
+func (r *ReplayStore) handleMessage(c *net.Client, msg interface{}) (interface{}, bool) {
+	switch msg := msg.(type) {
+	case *ReplayChunkMessage:
+		if err := r.receiveChunk(msg.SenderID, msg); err != nil {
+			return NewErrorMessage(err.Error()), true
+		}
+
+		return NewReplayChunkMessage(msg.ReplayID, msg.Index, msg.Total, nil), true
+	case *ReplayRequestMessage:
+		return r.chunk(msg.ReplayID, msg.Index), true
+	case *ReplayQueryMessage:
+		return NewReplayListMessage(r.notable()), true
+	}
+
+	return nil, false
+}
+
+// receiveChunk adds the next chunk of a replay the player is sharing, and
+// stores the replay once it's all there.
+func (r *ReplayStore) receiveChunk(playerID string, msg *ReplayChunkMessage) error {
+	if msg.Total <= 0 || msg.Total > (maxReplaySize+replayChunkSize-1)/replayChunkSize || len(msg.Data) > replayChunkSize {
+		return errReplayTooLarge
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	upload, ok := r.uploads[playerID]
+
+	if msg.Index == 0 {
+		if _, shared := r.replays[msg.ReplayID]; shared {
+			return errors.New("already shared")
+		}
+
+		upload = &replayUpload{replayID: msg.ReplayID, total: msg.Total, started: time.Now()}
+		r.uploads[playerID] = upload
+	} else if !ok || upload.replayID != msg.ReplayID || upload.total != msg.Total || time.Since(upload.started) > replayUploadTimeout {
+		delete(r.uploads, playerID)
+		return errors.New("no upload in progress")
+	}
+
+	if msg.Index != len(upload.data)/replayChunkSize || len(upload.data)%replayChunkSize != 0 {
+		delete(r.uploads, playerID)
+		return errors.New("chunk out of order")
+	}
+
+	upload.data = append(upload.data, msg.Data...)
+
+	if msg.Index < upload.total-1 {
+		return nil
+	}
+
+	delete(r.uploads, playerID)
+
+	replay, err := decodeReplay(upload.data)
+
+	if err != nil {
+		return err
+	}
+
+	if replay.Info.ID != msg.ReplayID || !containsString(replay.Info.PlayerIDs, playerID) {
+		return errors.New("not your replay")
+	}
+
+	info := replay.Info
+	info.SharedBy = playerID
+	info.Size = len(upload.data)
+	info.Downloads = 0
+
+	r.replays[info.ID] = &sharedReplay{info: info, data: upload.data, sharedAt: time.Now()}
+	r.evict(playerID)
+
+	fmt.Printf("%s shared a %s replay of %s\n", shortID(playerID, 4), info.GameType, info.LobbyName)
+	return nil
+}
+
+// evict drops expired replays, the player's oldest past how many they can
+// share, and the oldest overall past how many are kept. Expects the lock to
+// be held.
+func (r *ReplayStore) evict(playerID string) {
+	byAge := make([]*sharedReplay, 0, len(r.replays))
+
+	for id, replay := range r.replays {
+		if time.Since(replay.sharedAt) > sharedReplayMaxAge {
+			delete(r.replays, id)
+			continue
+		}
+
+		byAge = append(byAge, replay)
+	}
+
+	sort.Slice(byAge, func(i, j int) bool {
+		return byAge[i].sharedAt.After(byAge[j].sharedAt)
+	})
+
+	kept, theirs := 0, 0
+
+	for _, replay := range byAge {
+		if replay.info.SharedBy == playerID {
+			theirs++
+		}
+
+		if kept >= maxSharedReplays || (replay.info.SharedBy == playerID && theirs > maxSharedPerPlayer) {
+			delete(r.replays, replay.info.ID)
+			continue
+		}
+
+		kept++
+	}
+}
+
+// chunk returns part of a shared replay, or an error if there's no such
+// replay or part.
+func (r *ReplayStore) chunk(replayID string, index int) interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	replay, ok := r.replays[replayID]
+
+	if !ok {
+		return NewErrorMessage("no such replay")
+	}
+
+	total := (len(replay.data) + replayChunkSize - 1) / replayChunkSize
+
+	if index < 0 || index >= total {
+		return NewErrorMessage("no such chunk")
+	}
+
+	if index == 0 {
+		replay.info.Downloads++
+	}
+
+	end := (index + 1) * replayChunkSize
+
+	if end > len(replay.data) {
+		end = len(replay.data)
+	}
+
+	return NewReplayChunkMessage(replayID, index, total, replay.data[index*replayChunkSize:end])
+}
+
+// notable returns the shared replays most worth watching: bigger, longer and
+// more downloaded games, with newer ones ahead of older.
+func (r *ReplayStore) notable() []ReplayInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type scored struct {
+		info  ReplayInfo
+		score float64
+	}
+
+	replays := make([]scored, 0, len(r.replays))
+
+	for id, replay := range r.replays {
+		age := time.Since(replay.sharedAt)
+
+		if age > sharedReplayMaxAge {
+			delete(r.replays, id)
+			continue
+		}
+
+		score := float64(len(replay.info.PlayerIDs)) + replay.info.Duration.Minutes() + float64(replay.info.Downloads)/2
+		replays = append(replays, scored{replay.info, score / (1 + age.Hours()/24)})
+	}
+
+	sort.Slice(replays, func(i, j int) bool {
+		return replays[i].score > replays[j].score
+	})
+
+	if len(replays) > notableReplays {
+		replays = replays[:notableReplays]
+	}
+
+	infos := make([]ReplayInfo, len(replays))
+
+	for i, replay := range replays {
+		infos[i] = replay.info
+	}
+
+	return infos
+}
@@ -0,0 +1,103 @@
+package arcade
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Replays recorded by a game's host have its inputs, and a checksum of how it
+// ended. Each one in testdata/replays is simulated again and has to end the
+// same way, so changes to how the games play are caught. Copy a replay over
+// from the replays folder to add it, once whatever it shows is the intended
+// behavior.
+func TestReplaysSimulate(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", REPLAYS_DIRNAME, "*"+REPLAY_EXTENSION))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) == 0 {
+		t.Fatal("no replays in testdata")
+	}
+
+	// The games log every tick they take
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, file := range files {
+		file := file
+
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			replay, err := loadReplayFile(file)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !replay.Simulated() {
+				t.Skip("not recorded by the host")
+			}
+
+			var got StateChecksum
+
+			switch replay.Info.GameType {
+			case Pong:
+				state, err := simulatePongReplay(replay)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got = newStateChecksum(state.Tick, state)
+			case Tron:
+				state, err := simulateTronReplay(replay)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got = newStateChecksum(state.CommitedTimeStep, state)
+			default:
+				t.Skipf("can't simulate %s", replay.Info.GameType)
+			}
+
+			if got.Tick != replay.Final.Tick {
+				t.Errorf("ended at tick %d, expected %d", got.Tick, replay.Final.Tick)
+			}
+
+			if got.Hash != replay.Final.Hash {
+				t.Errorf("final state differs in %v", replay.Final.diff(got))
+			}
+		})
+	}
+}
+
+// A replay that's been tampered with has to fail, or the test above can't
+// catch anything.
+func TestReplaysDetectChanges(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	replay, err := loadReplayFile(filepath.Join("testdata", REPLAYS_DIRNAME, "pong_three_obstacles"+REPLAY_EXTENSION))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Paddles that never move miss balls they used to hit
+	replay.Inputs = nil
+
+	state, err := simulatePongReplay(replay)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if newStateChecksum(state.Tick, state).Hash == replay.Final.Hash {
+		t.Error("missing inputs went unnoticed")
+	}
+}
@@ -0,0 +1,500 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"arcade/arcade/widgets"
+	"encoding"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	replaysSaved = iota
+	replaysNotable
+)
+
+// ReplaysView lists our saved replays, which can be played back or shared,
+// and the notable ones others have shared with the distributor, which can be
+// downloaded.
+type ReplaysView struct {
+	View
+	mgr *ViewManager
+
+	mu sync.RWMutex
+
+	tab         int
+	saved       []ReplayInfo
+	notable     []ReplayInfo
+	loaded      bool
+	selectedRow int
+
+	// What's being shared or downloaded, and how far along it is
+	busy   string
+	errMsg string
+}
+
+var replaysFooter = []string{
+	"[Enter] Play   [U] Share   [Tab] Notable   [B]ack",
+	"[Enter] Download and play   [R]efresh   [Tab] Saved   [B]ack",
+}
+
+func NewReplaysView(mgr *ViewManager) *ReplaysView {
+	return &ReplaysView{mgr: mgr}
+}
+
+func (v *ReplaysView) Init() {
+	go v.refresh()
+}
+
+// refresh reads our saved replays, and asks the distributor for the notable
+// ones.
+func (v *ReplaysView) refresh() {
+	saved := loadReplays()
+
+	v.mu.Lock()
+	v.saved = saved
+	v.clampSelection()
+	v.mu.Unlock()
+
+	v.mgr.RequestRender()
+
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		return
+	}
+
+	res, err := arcade.Server.Network.SendAndReceive(distributor, NewReplayQueryMessage())
+	reply, ok := res.(*ReplayListMessage)
+
+	if !ok || err != nil {
+		return
+	}
+
+	if profanityFilterEnabled() {
+		for i := range reply.Replays {
+			reply.Replays[i].LobbyName = FilterProfanity(reply.Replays[i].LobbyName)
+		}
+	}
+
+	v.mu.Lock()
+	v.notable = reply.Replays
+	v.loaded = true
+	v.clampSelection()
+	v.mu.Unlock()
+
+	v.mgr.RequestRender()
+}
+
+// rows returns the replays on the current tab. Expects the lock to be held.
+func (v *ReplaysView) rows() []ReplayInfo {
+	if v.tab == replaysNotable {
+		return v.notable
+	}
+
+	return v.saved
+}
+
+// clampSelection keeps the selection on the list. Expects the lock to be held.
+func (v *ReplaysView) clampSelection() {
+	if v.selectedRow >= len(v.rows()) {
+		v.selectedRow = len(v.rows()) - 1
+	}
+
+	if v.selectedRow < 0 {
+		v.selectedRow = 0
+	}
+}
+
+func (v *ReplaysView) Keymap() *Keymap {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.busy != "" || v.errMsg != "" {
+		return nil
+	}
+
+	return replaysKeymap
+}
+
+func (v *ReplaysView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		v.mu.Lock()
+
+		if v.busy != "" {
+			v.mu.Unlock()
+			return
+		}
+
+		if v.errMsg != "" {
+			v.errMsg = ""
+			v.mu.Unlock()
+			return
+		}
+
+		rows := v.rows()
+
+		switch replaysKeymap.Action(evt) {
+		case ActionMove:
+			if evt.Key() == tcell.KeyDown && v.selectedRow < len(rows)-1 {
+				v.selectedRow++
+			} else if evt.Key() == tcell.KeyUp && v.selectedRow > 0 {
+				v.selectedRow--
+			}
+		case ActionSwitchTab:
+			v.tab = (v.tab + 1) % 2
+			v.selectedRow = 0
+		case ActionWatch:
+			if len(rows) == 0 {
+				break
+			}
+
+			info := rows[v.selectedRow]
+
+			if v.tab == replaysNotable {
+				v.busy = "Downloading..."
+				go v.download(info)
+			} else if replay, err := loadReplay(info.ID); err != nil {
+				v.errMsg = "Couldn't read that replay."
+			} else {
+				v.mu.Unlock()
+				v.mgr.PushView(NewReplayView(v.mgr, replay))
+				return
+			}
+		case ActionShare:
+			if v.tab != replaysSaved || len(rows) == 0 {
+				break
+			}
+
+			info := rows[v.selectedRow]
+			v.mu.Unlock()
+
+			v.confirmShare(info)
+			return
+		case ActionRefresh:
+			go v.refresh()
+		case ActionBack:
+			v.mu.Unlock()
+			v.mgr.PopView()
+			return
+		}
+
+		v.mu.Unlock()
+	}
+}
+
+// confirmShare makes sure the player wants everyone to be able to download
+// the replay before sharing it.
+func (v *ReplaysView) confirmShare(info ReplayInfo) {
+	v.mgr.ShowModal(widgets.NewModal("Share replay", []string{
+		fmt.Sprintf("Share the replay of '%s'?", info.LobbyName),
+		"Anyone can download it from the distributor,",
+		"with the names of everyone who played.",
+	}, []string{"Share", "Cancel"}, func(choice int) {
+		if choice == 0 {
+			v.mu.Lock()
+			v.busy = "Sharing..."
+			v.mu.Unlock()
+
+			go v.share(info)
+		}
+	}))
+}
+
+// share sends the replay to the distributor a chunk at a time.
+func (v *ReplaysView) share(info ReplayInfo) {
+	err := v.upload(info.ID)
+
+	v.mu.Lock()
+	v.busy = ""
+
+	if err != nil {
+		v.errMsg = "Couldn't share it: " + err.Error() + "."
+	}
+	v.mu.Unlock()
+
+	if err == nil {
+		notify("Shared the replay of %s", info.LobbyName)
+		go v.refresh()
+	}
+
+	v.mgr.RequestRender()
+}
+
+func (v *ReplaysView) upload(replayID string) error {
+	data, err := loadReplayData(replayID)
+
+	if err != nil {
+		return err
+	}
+
+	if len(data) > maxReplaySize {
+		return errReplayTooLarge
+	}
+
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		return errors.New("not connected to the distributor")
+	}
+
+	total := (len(data) + replayChunkSize - 1) / replayChunkSize
+
+	for i := 0; i < total; i++ {
+		end := (i + 1) * replayChunkSize
+
+		if end > len(data) {
+			end = len(data)
+		}
+
+		res, err := arcade.Server.Network.SendAndReceive(distributor, NewReplayChunkMessage(replayID, i, total, data[i*replayChunkSize:end]))
+
+		if err != nil {
+			return err
+		}
+
+		if e, ok := res.(*ErrorMessage); ok {
+			return errors.New(e.Text)
+		}
+
+		v.setProgress("Sharing", i+1, total)
+	}
+
+	return nil
+}
+
+// download fetches a shared replay a chunk at a time, saves it with ours and
+// plays it.
+func (v *ReplaysView) download(info ReplayInfo) {
+	replay, err := v.fetch(info.ID)
+
+	v.mu.Lock()
+	v.busy = ""
+
+	if err != nil {
+		v.errMsg = "Couldn't download it: " + err.Error() + "."
+	}
+	v.mu.Unlock()
+
+	if err != nil {
+		v.mgr.RequestRender()
+		return
+	}
+
+	v.mgr.PushView(NewReplayView(v.mgr, replay))
+}
+
+func (v *ReplaysView) fetch(replayID string) (*Replay, error) {
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		return nil, errors.New("not connected to the distributor")
+	}
+
+	data := make([]byte, 0)
+
+	for i, total := 0, 1; i < total; i++ {
+		res, err := arcade.Server.Network.SendAndReceive(distributor, NewReplayRequestMessage(replayID, i))
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch res := res.(type) {
+		case *ReplayChunkMessage:
+			total = res.Total
+			data = append(data, res.Data...)
+		case *ErrorMessage:
+			return nil, errors.New(res.Text)
+		default:
+			return nil, errors.New("unexpected reply")
+		}
+
+		if len(data) > maxReplaySize {
+			return nil, errReplayTooLarge
+		}
+
+		v.setProgress("Downloading", i+1, total)
+	}
+
+	replay, err := decodeReplay(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := saveReplayData(replay.Info.ID, data, v.mgr.Config().KeepReplays); err != nil {
+		return nil, err
+	}
+
+	return replay, nil
+}
+
+func (v *ReplaysView) setProgress(verb string, done, total int) {
+	v.mu.Lock()
+	v.busy = fmt.Sprintf("%s... %d%%", verb, done*100/total)
+	v.mu.Unlock()
+
+	v.mgr.RequestRender()
+}
+
+// replayPlayerNames returns the names of the replay's players, as best we
+// know them.
+func replayPlayerNames(info ReplayInfo) string {
+	names := ""
+
+	for i, playerID := range info.PlayerIDs {
+		if i > 0 {
+			names += ", "
+		}
+
+		if name := info.Names[playerID]; name != "" {
+			names += filterText(name)
+		} else {
+			names += shortID(playerID, 8)
+		}
+	}
+
+	return names
+}
+
+func (v *ReplaysView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *ReplaysView) Render(s *Screen) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	width, height := s.displaySize()
+
+	const (
+		tableWidth  = 72
+		tableHeight = 14
+	)
+
+	var (
+		tableX1 = (width-tableWidth)/2 - 1
+		tableY1 = 7
+		tableX2 = width - (width-tableWidth)/2
+		tableY2 = tableY1 + tableHeight
+
+		nameColX    = tableX1 + 1
+		gameColX    = tableX1 + 23
+		playersColX = tableX1 + 30
+		whenColX    = tableX1 + 58
+
+		boxX1 = tableX1 + 4
+		boxY1 = tableY1 + 2
+		boxX2 = tableX2 - 4
+		boxY2 = tableY2 - 3
+	)
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	selectedSty := tcell.StyleDefault.Background(tcell.ColorDarkGreen).Foreground(tcell.ColorWhite)
+	boldSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorLightGreen)
+
+	s.DrawBlockText(CenterX, 1, sty, "REPLAYS", false)
+
+	s.DrawBox(tableX1-1, 4, tableX2+1, tableY2+1, sty, true)
+	s.DrawText(layout.Center(width, replaysFooter[v.tab]), height-2, sty, replaysFooter[v.tab])
+
+	tabs := []string{" SAVED ", " NOTABLE "}
+	tabX := tableX1 + 1
+
+	for i, tab := range tabs {
+		tabSty := sty
+
+		if i == v.tab {
+			tabSty = sty.Reverse(true)
+		}
+
+		s.DrawText(tabX, 4, tabSty, tab)
+		tabX += len(tab) + 1
+	}
+
+	whenLabel := "RECORDED"
+
+	if v.tab == replaysNotable {
+		whenLabel = "DOWNLOADS"
+	}
+
+	s.DrawText(nameColX, 5, sty, "LOBBY")
+	s.DrawText(gameColX, 5, sty, "GAME")
+	s.DrawText(playersColX, 5, sty, "PLAYERS")
+	s.DrawText(whenColX, 5, sty, whenLabel)
+
+	s.DrawLine(tableX1, 6, tableX2, 6, sty, true)
+	s.DrawText(tableX1-1, 6, sty, "╠")
+	s.DrawText(tableX2+1, 6, sty, "╣")
+
+	for y := tableY1; y <= tableY2; y++ {
+		s.DrawEmpty(tableX1, y, tableX2, y, sty)
+	}
+
+	rows := v.rows()
+
+	if len(rows) == 0 {
+		msg := "No replays yet. Your online games are saved here."
+
+		if v.tab == replaysNotable {
+			msg = "Looking for replays..."
+
+			if v.loaded {
+				msg = "Nobody has shared a replay lately."
+			}
+		}
+
+		s.DrawText(layout.Center(width, msg), tableY1+1, sty, msg)
+	}
+
+	for i, info := range rows {
+		y := tableY1 + i
+
+		if y > tableY2 {
+			break
+		}
+
+		rowSty := sty
+
+		if i == v.selectedRow {
+			rowSty = selectedSty
+			s.DrawEmpty(tableX1, y, tableX2, y, rowSty)
+		}
+
+		when := info.RecordedAt.Format("Jan 2 15:04")
+
+		if v.tab == replaysNotable {
+			when = fmt.Sprint(info.Downloads)
+		}
+
+		s.DrawText(nameColX, y, rowSty, layout.Truncate(info.LobbyName, gameColX-nameColX-1))
+		s.DrawText(gameColX, y, rowSty, info.GameType)
+		s.DrawText(playersColX, y, rowSty, layout.Truncate(replayPlayerNames(info), whenColX-playersColX-1))
+		s.DrawText(whenColX, y, rowSty, when)
+	}
+
+	if v.busy != "" || v.errMsg != "" {
+		s.DrawEmpty(boxX1, boxY1, boxX2, boxY2, sty)
+		s.DrawBox(boxX1, boxY1, boxX2, boxY2, sty, true)
+	}
+
+	if v.busy != "" {
+		s.DrawText(layout.Center(width, v.busy), boxY1+3, boldSty, v.busy)
+	} else if v.errMsg != "" {
+		msg := v.errMsg + " Press any key to continue."
+		s.DrawText(layout.Center(width, msg), boxY1+3, boldSty, msg)
+	}
+}
+
+func (v *ReplaysView) Unload() {
+}
+
+func (v *ReplaysView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
@@ -1,8 +1,8 @@
 package arcade
 
 import (
+	"arcade/arcade/layout"
 	"sync"
-	"unicode/utf8"
 
 	"github.com/gdamore/tcell/v2"
 )
@@ -10,6 +10,16 @@ import (
 type Screen struct {
 	tcell.Screen
 	sync.RWMutex
+
+	theme     *Theme
+	asciiMode bool
+
+	// Set for terminals without colors. Never changes once drawing starts
+	noColor bool
+
+	// Set on screens that draw into a frame instead of the terminal, for views
+	// shown somewhere other than full screen. Anything outside it is cut off
+	target frame
 }
 
 type CursorStyle int
@@ -26,14 +36,98 @@ func (s *Screen) displaySize() (int, int) {
 	return displayWidth, displayHeight
 }
 
+// DisplaySize returns the size of the area views draw in.
+func (s *Screen) DisplaySize() (int, int) {
+	return s.displaySize()
+}
+
 func (s *Screen) Size() (int, int) {
+	if s.target != nil {
+		return s.displaySize()
+	}
+
 	s.RLock()
 	defer s.RUnlock()
 
 	return s.Screen.Size()
 }
 
+// SetTheme changes the colors and drawing characters everything is drawn with.
+func (s *Screen) SetTheme(theme *Theme, asciiMode bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.theme = theme
+	s.asciiMode = asciiMode
+}
+
+// SetContent draws a cell in the current theme.
+func (s *Screen) SetContent(x, y int, primary rune, combining []rune, style tcell.Style) {
+	// The theme's applied once the frame is drawn on the terminal
+	if s.target != nil {
+		if y >= 0 && y < len(s.target) && x >= 0 && x < len(s.target[y]) {
+			s.target[y][x] = frameCell{primary, combining, style}
+		}
+
+		return
+	}
+
+	s.RLock()
+	theme := s.theme
+	asciiMode := s.asciiMode
+	s.RUnlock()
+
+	if s.noColor {
+		style = colorless(style)
+	} else if theme != nil {
+		style = theme.apply(style)
+	}
+
+	if asciiMode {
+		primary = asciiRune(primary)
+		combining = nil
+	}
+
+	s.Screen.SetContent(x, y, primary, combining, style)
+}
+
+// Canvas returns a screen the size of the display that draws into a frame
+// instead of the terminal.
+func (s *Screen) Canvas() (*Screen, frame) {
+	width, height := s.displaySize()
+	f := make(frame, height)
+
+	for y := range f {
+		f[y] = make([]frameCell, width)
+
+		for x := range f[y] {
+			f[y][x] = frameCell{primary: ' '}
+		}
+	}
+
+	return &Screen{target: f}, f
+}
+
+// colorless draws the style in the terminal's own colors. Anything drawn on a
+// background color, like a selection, is reversed instead so it still stands
+// out.
+func colorless(style tcell.Style) tcell.Style {
+	_, bg, attrs := style.Decompose()
+	plain := tcell.StyleDefault.Attributes(attrs)
+
+	if bg != tcell.ColorDefault && bg != tcell.ColorBlack {
+		plain = plain.Reverse(true)
+	}
+
+	return plain
+}
+
 func (s *Screen) Clear() {
+	if s.target != nil {
+		s.DrawEmpty(0, 0, len(s.target[0])-1, len(s.target)-1, tcell.StyleDefault)
+		return
+	}
+
 	s.Lock()
 	defer s.Unlock()
 
@@ -47,6 +141,10 @@ func (s *Screen) ClearContent() {
 }
 
 func (s *Screen) offset() (int, int) {
+	if s.target != nil {
+		return 0, 0
+	}
+
 	currentWidth, currentHeight := s.Size()
 	displayWidth, displayHeight := s.displaySize()
 
@@ -59,7 +157,7 @@ func (s *Screen) DrawBlockText(x, y int, style tcell.Style, text string, big boo
 
 	switch x {
 	case CenterX:
-		x = (w - utf8.RuneCountInString(t[0])) / 2
+		x = layout.Center(w, t[0])
 	}
 
 	switch y {
@@ -78,7 +176,7 @@ func (s *Screen) DrawText(x, y int, style tcell.Style, text string) {
 
 	switch x {
 	case CenterX:
-		x = (w - utf8.RuneCountInString(text)) / 2
+		x = layout.Center(w, text)
 	}
 
 	switch y {
@@ -91,7 +189,13 @@ func (s *Screen) DrawText(x, y int, style tcell.Style, text string) {
 
 	for _, r := range text {
 		s.SetContent(startX+col, startY+row, r, nil, style)
-		col++
+
+		// Wide runes cover the next cell too
+		if rw := layout.RuneWidth(r); rw > 1 {
+			col += rw
+		} else {
+			col++
+		}
 
 		if r == '\n' {
 			row++
@@ -170,7 +274,14 @@ func (s *Screen) DrawBox(x1, y1, x2, y2 int, style tcell.Style, thicker bool) {
 func (s *Screen) Reset() {
 	// Set default text style
 	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
-	s.SetStyle(sty)
+
+	s.RLock()
+	if s.theme != nil {
+		s.SetStyle(s.theme.apply(sty))
+	} else {
+		s.SetStyle(sty)
+	}
+	s.RUnlock()
 
 	// Clear screen
 	s.Clear()
@@ -0,0 +1,255 @@
+package arcade
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	seasonLength = 28 * 24 * time.Hour
+
+	// Games before a player is given a tier. Ratings move twice as fast
+	// until then, so players find their level quickly
+	placementMatches = 5
+
+	startingRating = 1000
+	ratingK        = 32
+
+	// Players listed at the top of the ladder
+	seasonTopPlayers = 10
+)
+
+// Seasons are counted from here, so every distributor agrees on when they
+// start and end
+var seasonEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// RankTier is a band of ratings, shown as a badge next to the player's name.
+type RankTier struct {
+	Name      string
+	Badge     string
+	MinRating int
+
+	// Drawn in the season view
+	Art []string
+}
+
+// Lowest first
+var rankTiers = []RankTier{
+	{Name: "Bronze", Badge: "[B]", MinRating: 0, Art: []string{"  ___  ", " / B \\ ", " \\___/ "}},
+	{Name: "Silver", Badge: "[S]", MinRating: 1050, Art: []string{"  ___  ", " /=S=\\ ", " \\___/ "}},
+	{Name: "Gold", Badge: "[G]", MinRating: 1150, Art: []string{" \\___/ ", " |*G*| ", "  \\_/  "}},
+	{Name: "Platinum", Badge: "[P]", MinRating: 1250, Art: []string{" _/^\\_ ", " |*P*| ", "  \\_/  "}},
+	{Name: "Diamond", Badge: "<D>", MinRating: 1400, Art: []string{"  /\\   ", " <DD>  ", "  \\/   "}},
+}
+
+// What players still in their placement matches show instead of a tier
+var placementBadge = "[?]"
+
+// seasonAt returns the number of the season at t, counting from 1.
+func seasonAt(t time.Time) int {
+	return int(t.Sub(seasonEpoch)/seasonLength) + 1
+}
+
+// seasonEnds returns when the season ends and the next one starts.
+func seasonEnds(season int) time.Time {
+	return seasonEpoch.Add(time.Duration(season) * seasonLength)
+}
+
+// SeasonStanding is a player's record in a season.
+type SeasonStanding struct {
+	PlayerID string
+	Rating   int
+	Played   int
+	Wins     int
+}
+
+// Placed returns whether the player has played their placement matches.
+func (s SeasonStanding) Placed() bool {
+	return s.Played >= placementMatches
+}
+
+// Tier returns the player's tier, or false if they haven't been placed.
+func (s SeasonStanding) Tier() (RankTier, bool) {
+	if !s.Placed() {
+		return RankTier{}, false
+	}
+
+	tier := rankTiers[0]
+
+	for _, t := range rankTiers {
+		if s.Rating >= t.MinRating {
+			tier = t
+		}
+	}
+
+	return tier, true
+}
+
+// Badge returns the player's tier badge, or the placement badge.
+func (s SeasonStanding) Badge() string {
+	if tier, ok := s.Tier(); ok {
+		return tier.Badge
+	}
+
+	return placementBadge
+}
+
+// seasonLadder is the distributor's ratings for the season, on top of the
+// leaderboard's all-time records. It's guarded by the leaderboard's lock.
+type seasonLadder struct {
+	Season    int
+	Standings map[string]*SeasonStanding
+
+	// How the last season finished, placed players only, best first
+	LastSeason    int
+	LastStandings []SeasonStanding
+}
+
+func newSeasonLadder(now time.Time) *seasonLadder {
+	return &seasonLadder{
+		Season:    seasonAt(now),
+		Standings: make(map[string]*SeasonStanding),
+	}
+}
+
+// roll ends the season if it's over, keeping how it finished and starting
+// everyone over.
+func (l *seasonLadder) roll(now time.Time) {
+	season := seasonAt(now)
+
+	if season == l.Season {
+		return
+	}
+
+	// Nothing was played in a season that came in between
+	if season == l.Season+1 {
+		l.LastSeason, l.LastStandings = l.Season, l.ranked()
+	} else {
+		l.LastSeason, l.LastStandings = season-1, nil
+	}
+
+	l.Season = season
+	l.Standings = make(map[string]*SeasonStanding)
+}
+
+func (l *seasonLadder) standing(playerID string) *SeasonStanding {
+	s, ok := l.Standings[playerID]
+
+	if !ok {
+		s = &SeasonStanding{PlayerID: playerID, Rating: startingRating}
+		l.Standings[playerID] = s
+	}
+
+	return s
+}
+
+// record rates a match. The winner takes points from each player they beat,
+// more for beating players rated above them.
+func (l *seasonLadder) record(result MatchResult, now time.Time) {
+	l.roll(now)
+
+	for _, playerID := range result.Players {
+		s := l.standing(playerID)
+		s.Played++
+
+		if playerID == result.Winner {
+			s.Wins++
+		}
+	}
+
+	if !containsString(result.Players, result.Winner) {
+		return
+	}
+
+	winner := l.standing(result.Winner)
+	before := winner.Rating
+
+	for _, playerID := range result.Players {
+		if playerID == result.Winner {
+			continue
+		}
+
+		loser := l.standing(playerID)
+		expected := 1 / (1 + math.Pow(10, float64(loser.Rating-before)/400))
+		change := ratingK * (1 - expected)
+
+		winner.Rating += int(math.Round(change * winner.ratingSpeed()))
+		loser.Rating -= int(math.Round(change * loser.ratingSpeed()))
+	}
+}
+
+// ratingSpeed is how much faster than usual the player's rating moves.
+// Expects Played to count the match being rated.
+func (s *SeasonStanding) ratingSpeed() float64 {
+	if s.Played <= placementMatches {
+		return 2
+	}
+
+	return 1
+}
+
+// ranked returns the placed players, best first.
+func (l *seasonLadder) ranked() []SeasonStanding {
+	ranked := make([]SeasonStanding, 0, len(l.Standings))
+
+	for _, s := range l.Standings {
+		if s.Placed() {
+			ranked = append(ranked, *s)
+		}
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Rating != ranked[j].Rating {
+			return ranked[i].Rating > ranked[j].Rating
+		}
+
+		return ranked[i].PlayerID < ranked[j].PlayerID
+	})
+
+	return ranked
+}
+
+// info describes the season to the player: the top of the ladder, where they
+// stand, and how they finished last season.
+func (l *seasonLadder) info(playerID string, now time.Time) *SeasonInfoMessage {
+	l.roll(now)
+
+	ranked := l.ranked()
+	msg := NewSeasonInfoMessage(l.Season, seasonEnds(l.Season))
+	msg.Players = len(ranked)
+	msg.Top = ranked
+
+	if len(msg.Top) > seasonTopPlayers {
+		msg.Top = msg.Top[:seasonTopPlayers]
+	}
+
+	if s, ok := l.Standings[playerID]; ok {
+		mine := *s
+		msg.Mine = &mine
+		msg.Rank = standingRank(ranked, playerID)
+	}
+
+	msg.LastSeason = l.LastSeason
+	msg.LastPlayers = len(l.LastStandings)
+
+	if rank := standingRank(l.LastStandings, playerID); rank > 0 {
+		last := l.LastStandings[rank-1]
+		msg.LastMine = &last
+		msg.LastRank = rank
+	}
+
+	return msg
+}
+
+// standingRank returns the player's place in the ranked standings, or 0 if
+// they aren't in them.
+func standingRank(ranked []SeasonStanding, playerID string) int {
+	for i, s := range ranked {
+		if s.PlayerID == playerID {
+			return i + 1
+		}
+	}
+
+	return 0
+}
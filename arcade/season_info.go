@@ -0,0 +1,40 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+	"time"
+)
+
+// SeasonInfoMessage is the distributor's reply to a SeasonQueryMessage.
+type SeasonInfoMessage struct {
+	message.Message
+	Season int
+	Ends   time.Time
+
+	// The best placed players, and how many have been placed
+	Top     []SeasonStanding
+	Players int
+
+	// Set once we've played this season, with our place if we've been placed
+	Mine *SeasonStanding
+	Rank int
+
+	// How last season finished for us, if we were placed in it
+	LastSeason  int
+	LastPlayers int
+	LastMine    *SeasonStanding
+	LastRank    int
+}
+
+func NewSeasonInfoMessage(season int, ends time.Time) *SeasonInfoMessage {
+	return &SeasonInfoMessage{
+		Message: message.Message{Type: "season_info"},
+		Season:  season,
+		Ends:    ends,
+	}
+}
+
+func (m SeasonInfoMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,21 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// SeasonQueryMessage asks the distributor how the ranked season is going.
+type SeasonQueryMessage struct {
+	message.Message
+}
+
+func NewSeasonQueryMessage() *SeasonQueryMessage {
+	return &SeasonQueryMessage{
+		Message: message.Message{Type: "season_query"},
+	}
+}
+
+func (m SeasonQueryMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,80 @@
+package arcade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSeasonRatingsMoveWithResults(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := newSeasonLadder(now)
+
+	for i := 0; i < placementMatches-1; i++ {
+		l.record(MatchResult{Players: []string{"a", "b"}, Winner: "a"}, now)
+	}
+
+	a, b := l.Standings["a"], l.Standings["b"]
+
+	if a.Rating <= startingRating || b.Rating >= startingRating {
+		t.Fatalf("winner has %d and loser has %d", a.Rating, b.Rating)
+	}
+
+	if _, ok := a.Tier(); ok || a.Badge() != placementBadge {
+		t.Errorf("placed after %d games", a.Played)
+	}
+
+	l.record(MatchResult{Players: []string{"a", "b"}, Winner: "a"}, now)
+
+	if tier, ok := a.Tier(); !ok || tier.Name == rankTiers[0].Name {
+		t.Errorf("winning every placement match placed at %q", tier.Name)
+	}
+
+	if tier, ok := b.Tier(); !ok || tier.Name != rankTiers[0].Name {
+		t.Errorf("losing every placement match placed at %q", tier.Name)
+	}
+
+	// Beating a stronger player is worth more than beating a weaker one
+	before := b.Rating
+	l.record(MatchResult{Players: []string{"a", "b"}, Winner: "b"}, now)
+	upset := b.Rating - before
+
+	before = a.Rating
+	l.record(MatchResult{Players: []string{"a", "b"}, Winner: "a"}, now)
+
+	if expected := a.Rating - before; upset <= expected {
+		t.Errorf("upset gained %d, expected win gained %d", upset, expected)
+	}
+}
+
+func TestSeasonRollKeepsLastStandings(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := newSeasonLadder(now)
+	season := l.Season
+
+	for i := 0; i < placementMatches; i++ {
+		l.record(MatchResult{Players: []string{"a", "b", "c"}, Winner: "a"}, now)
+	}
+
+	l.record(MatchResult{Players: []string{"d", "a"}, Winner: "d"}, now)
+
+	info := l.info("a", seasonEnds(season).Add(time.Hour))
+
+	if info.Season != season+1 || info.LastSeason != season {
+		t.Fatalf("got season %d after %d", info.Season, info.LastSeason)
+	}
+
+	if info.Mine != nil || info.Players != 0 {
+		t.Errorf("standings weren't reset: %+v", info.Mine)
+	}
+
+	// d wasn't placed, so only a, b and c finished the season
+	if info.LastPlayers != 3 || info.LastRank != 1 || info.LastMine == nil || info.LastMine.PlayerID != "a" {
+		t.Errorf("got rank %d of %d last season", info.LastRank, info.LastPlayers)
+	}
+
+	info = l.info("a", seasonEnds(season+2).Add(time.Hour))
+
+	if info.LastSeason != season+2 || info.LastPlayers != 0 {
+		t.Errorf("empty season %d kept %d players", info.LastSeason, info.LastPlayers)
+	}
+}
@@ -0,0 +1,233 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"encoding"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	// How long after a game we ask for our standing again, giving the other
+	// players time to report the result
+	seasonRefreshDelay = 10 * time.Second
+
+	// How often we ask anyway, so a new season shows up
+	seasonRefreshInterval = 5 * time.Minute
+)
+
+var seasonFooter = "[R]efresh    [B]ack"
+
+// seasonCache is our ranked standing, as the distributor last told us. It
+// has its own lock, since lobbies read it while the view manager's is held.
+type seasonCache struct {
+	mu   sync.Mutex
+	info *SeasonInfoMessage
+}
+
+// refreshSeason asks the distributor how our season's going.
+func (mgr *ViewManager) refreshSeason() (*SeasonInfoMessage, bool) {
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		return nil, false
+	}
+
+	res, err := arcade.Server.Network.SendAndReceive(distributor, NewSeasonQueryMessage())
+	info, ok := res.(*SeasonInfoMessage)
+
+	if !ok || err != nil {
+		return nil, false
+	}
+
+	mgr.season.mu.Lock()
+	mgr.season.info = info
+	mgr.season.mu.Unlock()
+
+	return info, true
+}
+
+// startSeasonUpdates keeps our standing fresh for lobby badges.
+func startSeasonUpdates(mgr *ViewManager) {
+	for {
+		interval := seasonRefreshInterval
+
+		// Keep asking until the distributor's connected
+		if _, ok := mgr.refreshSeason(); !ok {
+			interval = presenceInterval
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// seasonBadge returns our badge for lobbies, or "" if we haven't played this
+// season.
+func (mgr *ViewManager) seasonBadge() string {
+	mgr.season.mu.Lock()
+	defer mgr.season.mu.Unlock()
+
+	if mgr.season.info == nil || mgr.season.info.Mine == nil {
+		return ""
+	}
+
+	return mgr.season.info.Mine.Badge()
+}
+
+// SeasonView shows where we stand in the ranked season, the top of the
+// ladder, and how we finished last season.
+type SeasonView struct {
+	View
+	mgr *ViewManager
+
+	mu     sync.RWMutex
+	info   *SeasonInfoMessage
+	loaded bool
+}
+
+func NewSeasonView(mgr *ViewManager) *SeasonView {
+	return &SeasonView{mgr: mgr}
+}
+
+func (v *SeasonView) Init() {
+	go v.refresh()
+}
+
+func (v *SeasonView) refresh() {
+	info, ok := v.mgr.refreshSeason()
+
+	v.mu.Lock()
+	if ok {
+		v.info = info
+	}
+	v.loaded = true
+	v.mu.Unlock()
+
+	v.mgr.RequestRender()
+}
+
+func (v *SeasonView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		if evt.Key() != tcell.KeyRune {
+			return
+		}
+
+		switch evt.Rune() {
+		case 'r':
+			go v.refresh()
+		case 'b':
+			v.mgr.PopView()
+		}
+	}
+}
+
+func (v *SeasonView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *SeasonView) Render(s *Screen) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	width, height := s.displaySize()
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	mutedSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorDarkGreen)
+	tierSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorYellow)
+
+	s.DrawBlockText(CenterX, 1, sty, "RANKED", false)
+	s.DrawText(layout.Center(width, seasonFooter), height-2, sty, seasonFooter)
+
+	if v.info == nil {
+		msg := "Asking the distributor..."
+
+		if v.loaded {
+			msg = "The ladder needs a connection to the distributor."
+		}
+
+		s.DrawText(layout.Center(width, msg), 10, mutedSty, msg)
+		return
+	}
+
+	info := v.info
+	header := fmt.Sprintf("Season %d, ends in %s", info.Season, formatSeasonLeft(time.Until(info.Ends)))
+	s.DrawText(layout.Center(width, header), 5, sty, header)
+
+	// Our standing on the left, the ladder on the right
+	leftX, rightX := 4, 40
+
+	switch {
+	case info.Mine == nil:
+		s.DrawText(leftX, 7, mutedSty, "Play online games to be ranked.")
+	case !info.Mine.Placed():
+		s.DrawText(leftX, 7, sty, "Placement matches")
+		s.DrawText(leftX, 8, sty, fmt.Sprintf("%d of %d played", info.Mine.Played, placementMatches))
+	default:
+		tier, _ := info.Mine.Tier()
+
+		s.DrawSprite(leftX, 7, tierSty, NewSprite(tier.Art...))
+
+		s.DrawText(leftX+9, 7, tierSty, tier.Name)
+		s.DrawText(leftX+9, 8, sty, fmt.Sprintf("Rating %d", info.Mine.Rating))
+		s.DrawText(leftX+9, 9, sty, fmt.Sprintf("#%d of %d", info.Rank, info.Players))
+	}
+
+	if info.Mine != nil {
+		s.DrawText(leftX, 11, mutedSty, fmt.Sprintf("%d wins in %d games", info.Mine.Wins, info.Mine.Played))
+	}
+
+	if info.LastSeason > 0 {
+		s.DrawText(leftX, 14, sty, fmt.Sprintf("Season %d", info.LastSeason))
+
+		if info.LastMine != nil {
+			tier, _ := info.LastMine.Tier()
+			s.DrawText(leftX, 15, sty, fmt.Sprintf("Finished %s, #%d of %d", tier.Name, info.LastRank, info.LastPlayers))
+			s.DrawText(leftX, 16, mutedSty, fmt.Sprintf("Rating %d, %d wins in %d games", info.LastMine.Rating, info.LastMine.Wins, info.LastMine.Played))
+		} else {
+			s.DrawText(leftX, 15, mutedSty, "You weren't placed.")
+		}
+	}
+
+	s.DrawText(rightX, 7, sty, "TOP PLAYERS")
+
+	if len(info.Top) == 0 {
+		s.DrawText(rightX, 9, mutedSty, "Nobody's been placed yet.")
+	}
+
+	for i, standing := range info.Top {
+		rowSty := sty
+
+		if standing.PlayerID == arcade.Server.ID {
+			rowSty = rowSty.Bold(true)
+		}
+
+		row := fmt.Sprintf("%2d. %s %s %5d", i+1, standing.Badge(), layout.Pad(shortID(standing.PlayerID, 8), 10), standing.Rating)
+		s.DrawText(rightX, 9+i, rowSty, row)
+	}
+}
+
+// formatSeasonLeft says roughly how long is left in the season.
+func formatSeasonLeft(d time.Duration) string {
+	days := int(d.Hours()) / 24
+
+	switch {
+	case days > 1:
+		return fmt.Sprintf("%d days", days)
+	case d > time.Hour:
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	}
+
+	return fmt.Sprintf("%d minutes", int(d.Minutes()))
+}
+
+func (v *SeasonView) Unload() {
+}
+
+func (v *SeasonView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
@@ -0,0 +1,168 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Selector is a single row component that picks one of a few options with the
+// left and right arrow keys.
+type Selector struct {
+	BaseComponent
+
+	x, y, width int
+	label       string
+	options     []string
+	index       int
+	active      bool
+	onChange    func(value string)
+}
+
+func NewSelector(x, y, width int, label string, options []string) *Selector {
+	return &Selector{
+		x:       x,
+		y:       y,
+		width:   width,
+		label:   label,
+		options: options,
+	}
+}
+
+// OnChange sets a function to call whenever the selected option changes.
+func (sel *Selector) OnChange(f func(value string)) {
+	sel.Lock()
+	defer sel.Unlock()
+
+	sel.onChange = f
+}
+
+func (sel *Selector) Value() string {
+	sel.RLock()
+	defer sel.RUnlock()
+
+	if len(sel.options) == 0 {
+		return ""
+	}
+
+	return sel.options[sel.index]
+}
+
+// SetValue selects the option, if it's one of them.
+func (sel *Selector) SetValue(value string) {
+	sel.Lock()
+	defer sel.Unlock()
+
+	for i, option := range sel.options {
+		if option == value {
+			sel.index = i
+			return
+		}
+	}
+}
+
+// SetLabel replaces the label, for selectors that mean something different
+// depending on another.
+func (sel *Selector) SetLabel(label string) {
+	sel.Lock()
+	defer sel.Unlock()
+
+	sel.label = label
+}
+
+// SetOptions replaces the options, keeping the selection if it's still valid.
+func (sel *Selector) SetOptions(options []string) {
+	sel.Lock()
+	defer sel.Unlock()
+
+	sel.options = options
+
+	if sel.index >= len(options) {
+		sel.index = 0
+	}
+}
+
+func (sel *Selector) Focus() {
+	sel.Lock()
+	defer sel.Unlock()
+
+	sel.active = true
+}
+
+func (sel *Selector) ProcessEvent(evt interface{}) {
+	sel.Lock()
+
+	var changed func(value string)
+	var value string
+
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		switch evt.Key() {
+		case tcell.KeyDown, tcell.KeyTab, tcell.KeyEnter:
+			if sel.delegate.NavigateForward() {
+				sel.active = false
+			}
+		case tcell.KeyUp, tcell.KeyBacktab:
+			if sel.delegate.NavigateBackward() {
+				sel.active = false
+			}
+		case tcell.KeyLeft:
+			if sel.index > 0 {
+				sel.index--
+				changed, value = sel.onChange, sel.options[sel.index]
+			}
+		case tcell.KeyRight:
+			if sel.index < len(sel.options)-1 {
+				sel.index++
+				changed, value = sel.onChange, sel.options[sel.index]
+			}
+		}
+	}
+
+	sel.Unlock()
+
+	// Called without the lock so it can update other components
+	if changed != nil {
+		changed(value)
+	}
+}
+
+func (sel *Selector) Render(s *Screen) {
+	sel.RLock()
+	defer sel.RUnlock()
+
+	screenW, _ := s.displaySize()
+
+	x := sel.x
+
+	switch x {
+	case CenterX:
+		x = (screenW - sel.width) / 2
+	}
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+
+	if sel.active {
+		sty = tcell.StyleDefault.Background(tcell.ColorDarkGreen).Foreground(tcell.ColorWhite)
+	}
+
+	value := ""
+
+	if len(sel.options) > 0 {
+		value = sel.options[sel.index]
+	}
+
+	if sel.index > 0 {
+		value = "← " + value
+	} else {
+		value = "  " + value
+	}
+
+	if sel.index < len(sel.options)-1 {
+		value += " →"
+	}
+
+	s.DrawEmpty(x, sel.y, x+sel.width-1, sel.y, sty)
+	s.DrawText(x+1, sel.y, sty, sel.label)
+	s.DrawText(x+sel.width-layout.Width(value)-1, sel.y, sty, value)
+}
@@ -0,0 +1,253 @@
+package arcade
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File `arcade serve` reads its lobbies from, unless it's told another
+const defaultServeConfigFile = "serve.yaml"
+
+// ServeConfig is what `arcade serve` hosts: a name for the server, and the
+// lobbies it keeps open.
+type ServeConfig struct {
+	Name    string              `yaml:"name"`
+	Lobbies []ServedLobbyConfig `yaml:"lobbies"`
+}
+
+// ServedLobbyConfig is one lobby a server keeps open. Once its game ends, a
+// new one just like it takes its place.
+type ServedLobbyConfig struct {
+	Name      string `yaml:"name"`
+	Game      string `yaml:"game"`
+	Capacity  int    `yaml:"capacity"`
+	Private   bool   `yaml:"private"`
+	Obstacles bool   `yaml:"obstacles"`
+
+	// Seconds before games go to sudden death, or 0 for no time limit
+	TimeLimit int `yaml:"time_limit"`
+
+	// Start as soon as everyone who's joined is ready, with bots in the
+	// empty seats
+	Bots bool `yaml:"bots"`
+}
+
+// LoadServeConfig reads the lobbies to serve from the file.
+func LoadServeConfig(file string) (*ServeConfig, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseServeConfig(data)
+}
+
+// ParseServeConfig reads the lobbies to serve, and checks they can be.
+func ParseServeConfig(data []byte) (*ServeConfig, error) {
+	config := &ServeConfig{}
+
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+
+	if len(config.Lobbies) == 0 {
+		return nil, errors.New("no lobbies to serve")
+	}
+
+	for i, lobby := range config.Lobbies {
+		// Tron games are run by their players together, so there's no
+		// game for a server to run
+		if lobby.Game != Pong {
+			return nil, fmt.Errorf("lobby %d: only %s can be served", i+1, Pong)
+		}
+
+		if !validCapacity(lobby.Game, lobby.Capacity) {
+			return nil, fmt.Errorf("lobby %d: %s is for %s players", i+1, lobby.Game, capacityRange(lobby.Game))
+		}
+
+		if lobby.Name == "" {
+			config.Lobbies[i].Name = fmt.Sprintf("%s %d", lobby.Game, i+1)
+		}
+	}
+
+	return config, nil
+}
+
+// validCapacity returns whether the game can be played by that many.
+func validCapacity(gameType string, capacity int) bool {
+	for _, option := range lobbyCapacities[gameType] {
+		if option == strconv.Itoa(capacity) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// capacityRange describes how many players the game's for, like "2-4".
+func capacityRange(gameType string) string {
+	options := lobbyCapacities[gameType]
+	return options[0] + "-" + options[len(options)-1]
+}
+
+// lobbyServer keeps the configured lobbies open, opening each again once its
+// game's over.
+type lobbyServer struct {
+	mu sync.Mutex
+
+	name    string
+	mgr     *ViewManager
+	lobbies map[string]*ServedLobby
+}
+
+// runServeCommand hosts the lobbies in the config file with no screen, until
+// it's interrupted, logging what happens to stdout.
+func runServeCommand(args []string, opts EngineOptions) int {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	configFile := flags.String("lobbies", defaultServeConfigFile, "File listing the lobbies to host")
+	flags.Parse(args)
+
+	log.SetOutput(os.Stdout)
+
+	config, err := LoadServeConfig(*configFile)
+
+	if err != nil {
+		log.Println("Couldn't load the lobbies to serve:", err)
+		return 1
+	}
+
+	arcade.Engine = NewEngine(opts, nil)
+	arcade.Server.Headless = true
+
+	server := newLobbyServer(config.Name, config.Lobbies)
+
+	go arcade.Engine.Start()
+	go server.reportPresence()
+
+	log.Printf("Serving %d lobbies as %s on port %d\n", len(config.Lobbies), arcade.Server.ID, opts.Port)
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	<-interrupted
+
+	log.Println("Closing lobbies")
+	server.closeAll()
+	arcade.Engine.Stop()
+
+	return 0
+}
+
+// newLobbyServer opens the lobbies, and keeps them open until it's closed.
+func newLobbyServer(name string, lobbies []ServedLobbyConfig) *lobbyServer {
+	s := &lobbyServer{
+		name: name,
+		// Games run on a manager with no screen, which only they use
+		mgr:     NewViewManager(DefaultConfig()),
+		lobbies: make(map[string]*ServedLobby),
+	}
+
+	for _, config := range lobbies {
+		s.open(config)
+	}
+
+	arcade.Server.Events.Subscribe(s.gameEnded, GameEvents)
+	return s
+}
+
+// open starts hosting a new lobby as the config says.
+func (s *lobbyServer) open(config ServedLobbyConfig) {
+	served := NewServedLobby(config, s.mgr)
+	lobby := served.HostedLobby()
+
+	s.mu.Lock()
+	s.lobbies[lobby.ID] = served
+	s.mu.Unlock()
+
+	arcade.Server.HostLobby(lobby.ID, served)
+	go broadcastServedLobby(lobby)
+
+	if lobby.Code != "" {
+		log.Printf("Opened %s, with code %s\n", lobby.Name, lobby.Code)
+	} else {
+		log.Printf("Opened %s\n", lobby.Name)
+	}
+}
+
+// gameEnded opens a lobby in place of the one whose game just ended.
+func (s *lobbyServer) gameEnded(ev Event) {
+	evt, ok := ev.(*GameEndedEvent)
+
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	served, ok := s.lobbies[evt.Result.GameID]
+	delete(s.lobbies, evt.Result.GameID)
+	s.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	log.Printf("%s ended: %s won\n", served.config.Name, evt.Result.Winner)
+
+	// Players see how it ended before the game goes away
+	time.AfterFunc(heartbeatInterval, func() {
+		served.stop()
+		arcade.Server.StopHostingLobby(evt.Result.GameID)
+		s.open(served.config)
+	})
+}
+
+// closeAll closes every lobby, telling their players.
+func (s *lobbyServer) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, served := range s.lobbies {
+		announceLobbyState(served.HostedLobby(), LobbyClosed)
+		served.stop()
+		arcade.Server.StopHostingLobby(id)
+		delete(s.lobbies, id)
+	}
+}
+
+// reportPresence tells the distributor about our lobbies every so often, so
+// they're listed.
+func (s *lobbyServer) reportPresence() {
+	for {
+		if distributor, ok := arcade.Server.Network.GetDistributor(); ok {
+			arcade.Server.Network.Send(distributor, NewPresenceMessage(s.presence()))
+		}
+
+		time.Sleep(presenceInterval)
+	}
+}
+
+// presence describes the server and its lobbies for the distributor.
+func (s *lobbyServer) presence() Presence {
+	presence := Presence{
+		PlayerID: arcade.Server.ID,
+		Name:     s.name,
+		Activity: "serving lobbies",
+	}
+
+	for _, lobby := range arcade.Server.HostedLobbies() {
+		lobby.mu.RLock()
+		presence.Lobbies = append(presence.Lobbies, lobby.summary())
+		lobby.mu.RUnlock()
+	}
+
+	return presence
+}
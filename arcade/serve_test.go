@@ -0,0 +1,35 @@
+package arcade
+
+import "testing"
+
+func TestParseServeConfig(t *testing.T) {
+	config, err := ParseServeConfig([]byte(`
+name: Community arcade
+lobbies:
+  - name: Quick pong
+    game: Pong
+    capacity: 2
+    bots: true
+  - game: Pong
+    capacity: 4
+    private: true
+`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(config.Lobbies) != 2 || !config.Lobbies[0].Bots || config.Lobbies[1].Name != "Pong 2" {
+		t.Errorf("got %+v", config)
+	}
+
+	for _, bad := range []string{
+		"name: empty",
+		"lobbies: [{game: Tron, capacity: 4}]",
+		"lobbies: [{game: Pong, capacity: 5}]",
+	} {
+		if _, err := ParseServeConfig([]byte(bad)); err == nil {
+			t.Errorf("accepted %q", bad)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package arcade
+
+import (
+	"arcade/arcade/net"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+)
+
+// ServedLobby is a lobby `arcade serve` hosts with no one at the keyboard.
+// The server isn't one of the players: it lets them in, starts the game once
+// they're all ready, and runs the game for them.
+type ServedLobby struct {
+	mu sync.Mutex
+
+	config      ServedLobbyConfig
+	lobby       *Lobby
+	joinLimiter *joinLimiter
+
+	// The game being played, on a manager with no screen, or nil in between
+	game View
+	mgr  *ViewManager
+}
+
+// NewServedLobby opens a lobby as the configuration says, with no players
+// yet.
+func NewServedLobby(config ServedLobbyConfig, mgr *ViewManager) *ServedLobby {
+	lobby := NewLobby(config.Name, config.Private, config.Game, config.Capacity, arcade.Server.ID)
+	lobby.PlayerIDs = []string{}
+	lobby.Dedicated = true
+	lobby.Obstacles = config.Obstacles
+	lobby.TimeLimit = config.TimeLimit
+	lobby.Region = arcade.Server.Region
+
+	return &ServedLobby{
+		config:      config,
+		lobby:       lobby,
+		joinLimiter: newJoinLimiter(),
+		mgr:         mgr,
+	}
+}
+
+func (l *ServedLobby) HostedLobby() *Lobby {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.lobby
+}
+
+func (l *ServedLobby) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	l.mu.Lock()
+	game := l.game
+	l.mu.Unlock()
+
+	if game != nil {
+		return game.GetHeartbeatMetadata()
+	}
+
+	l.lobby.updateRTTs()
+	return l.lobby
+}
+
+// AnnotateHeartbeat makes our heartbeats look like a host's in the lobby,
+// or in the game once it's started, since that's what players expect.
+func (l *ServedLobby) AnnotateHeartbeat(md *HeartbeatMetadata) {
+	l.mu.Lock()
+	game := l.game
+	l.mu.Unlock()
+
+	if game == nil {
+		md.View = "LobbyView"
+
+		l.lobby.mu.RLock()
+		md.Lobby = l.lobby.summary()
+		md.Players = len(l.lobby.PlayerIDs)
+		l.lobby.mu.RUnlock()
+
+		return
+	}
+
+	md.View = reflect.Indirect(reflect.ValueOf(game)).Type().Name()
+
+	if hv, ok := game.(HeartbeatView); ok {
+		hv.AnnotateHeartbeat(md)
+	}
+}
+
+func (l *ServedLobby) ProcessEvent(ev interface{}) {
+	l.mu.Lock()
+	game := l.game
+	l.mu.Unlock()
+
+	if game != nil {
+		game.ProcessEvent(ev)
+		return
+	}
+
+	switch evt := ev.(type) {
+	case *ClientDisconnectedEvent:
+		if l.lobby.HasPlayer(evt.ClientID) {
+			l.removePlayer(evt.ClientID, "disconnected")
+		}
+	case *HeartbeatEvent:
+		var status LobbyPlayerStatus
+
+		if err := json.Unmarshal(evt.Metadata, &status); err != nil || status.LobbyID != l.lobby.ID || !l.lobby.HasPlayer(evt.ClientID) {
+			return
+		}
+
+		l.lobby.SetPlayerStatus(evt.ClientID, status.Ready, status.Idle)
+		l.lobby.SetPlayerAway(evt.ClientID, evt.Info.Away)
+		l.lobby.SetPlayerProfile(evt.ClientID, status.Name, status.Avatar, status.Rank)
+		l.lobby.SetPlayerRegion(evt.ClientID, status.Region)
+
+		if l.readyToStart() {
+			l.start()
+		}
+	}
+}
+
+func (l *ServedLobby) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	l.mu.Lock()
+	game := l.game
+	l.mu.Unlock()
+
+	switch p := p.(type) {
+	case *JoinMessage:
+		return l.join(from, p)
+	case *LeaveMessage:
+		if game == nil && l.lobby.HasPlayer(p.PlayerID) && p.PlayerID == p.SenderID {
+			l.removePlayer(p.PlayerID, "left")
+		}
+
+		return nil
+	case *CoachRequestMessage:
+		// There's no one here to approve coaches
+		return nil
+	}
+
+	if game != nil {
+		return game.ProcessMessage(from, p)
+	}
+
+	return nil
+}
+
+// join lets a player in if there's room and they are who they say, the same
+// as a player hosting would.
+func (l *ServedLobby) join(from *net.Client, p *JoinMessage) *JoinReplyMessage {
+	key := connectionKey(from, p.Origin)
+
+	l.lobby.mu.RLock()
+	full := len(l.lobby.PlayerIDs) >= l.lobby.Capacity || l.lobby.State != LobbyOpen
+	code := l.lobby.Code
+	l.lobby.mu.RUnlock()
+
+	if !l.joinLimiter.Allowed(key) {
+		return NewJoinReplyMessage(&Lobby{}, ErrRateLimited)
+	} else if full || p.CoachFor != "" {
+		return NewJoinReplyMessage(&Lobby{}, ErrCapacity)
+	} else if code != p.Code {
+		l.joinLimiter.Failed(key)
+		return NewJoinReplyMessage(&Lobby{}, ErrWrongCode)
+	} else if !l.lobby.verifyJoin(p) {
+		l.joinLimiter.Failed(key)
+		return NewJoinReplyMessage(&Lobby{}, ErrIdentity)
+	}
+
+	l.joinLimiter.Succeeded(key)
+
+	if !l.lobby.HasPlayer(p.PlayerID) {
+		l.lobby.AddPlayer(p.PlayerID)
+	}
+
+	if p.Status.LobbyID == l.lobby.ID {
+		l.lobby.SetPlayerStatus(p.PlayerID, false, false)
+		l.lobby.SetPlayerProfile(p.PlayerID, p.Status.Name, p.Status.Avatar, p.Status.Rank)
+		l.lobby.SetPlayerRegion(p.PlayerID, p.Status.Region)
+	}
+
+	arcade.Server.BeginLobbyHeartbeats(p.PlayerID, l.lobby.ID)
+	go broadcastServedLobby(l.lobby)
+
+	l.lobby.mu.RLock()
+	log.Printf("%s joined %s (%d/%d)\n", l.lobby.playerName(p.PlayerID), l.lobby.Name, len(l.lobby.PlayerIDs), l.lobby.Capacity)
+	l.lobby.mu.RUnlock()
+
+	return NewJoinReplyMessage(l.lobby, OK)
+}
+
+func (l *ServedLobby) removePlayer(playerID, why string) {
+	l.lobby.mu.RLock()
+	name := l.lobby.playerName(playerID)
+	l.lobby.mu.RUnlock()
+
+	l.lobby.RemovePlayer(playerID)
+	arcade.Server.EndHeartbeats(playerID)
+	go broadcastServedLobby(l.lobby)
+
+	log.Printf("%s %s %s\n", name, why, l.lobby.Name)
+}
+
+// readyToStart returns whether everyone's ready, and either the lobby's full
+// or it's filled up with bots.
+func (l *ServedLobby) readyToStart() bool {
+	l.lobby.mu.RLock()
+	defer l.lobby.mu.RUnlock()
+
+	if len(l.lobby.PlayerIDs) == 0 || l.lobby.State != LobbyOpen {
+		return false
+	}
+
+	if len(l.lobby.PlayerIDs) < l.lobby.Capacity && !l.config.Bots {
+		return false
+	}
+
+	for _, id := range l.lobby.PlayerIDs {
+		if !l.lobby.Ready[id] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// start fills any empty seats with bots and starts the game.
+func (l *ServedLobby) start() {
+	// Heartbeats come in together, and only the first starts the game
+	if !announceLobbyState(l.lobby, LobbyStarting) {
+		return
+	}
+
+	l.lobby.mu.Lock()
+	for i := 1; l.config.Bots && len(l.lobby.PlayerIDs) < l.lobby.Capacity; i++ {
+		botID := fmt.Sprintf("bot-%d", i)
+		l.lobby.PlayerIDs = append(l.lobby.PlayerIDs, botID)
+		l.lobby.Bots = append(l.lobby.Bots, botID)
+		l.lobby.Names[botID] = fmt.Sprintf("Bot %d", i)
+	}
+	playerIDs := append([]string(nil), l.lobby.PlayerIDs...)
+	l.lobby.mu.Unlock()
+
+	rng := NewMatchRNG()
+
+	for _, playerID := range playerIDs {
+		if client, ok := arcade.Server.Network.GetClient(playerID); ok {
+			arcade.Server.Network.Send(client, NewStartGameMessage(l.lobby.ID, rng.Commitment(), ""))
+		}
+	}
+
+	announceLobbyState(l.lobby, LobbyInGame)
+	arcade.Server.Events.Publish(NewGameStartedEvent(l.lobby))
+
+	game := NewPongGameView(l.mgr, l.lobby, rng)
+
+	l.mu.Lock()
+	l.game = game
+	l.mu.Unlock()
+
+	game.Init()
+
+	log.Printf("Started %s with %d players\n", l.lobby.Name, len(playerIDs))
+}
+
+// stop ends the game, if one's running, and its heartbeats.
+func (l *ServedLobby) stop() {
+	l.mu.Lock()
+	game := l.game
+	l.game = nil
+	l.mu.Unlock()
+
+	if game != nil {
+		game.Unload()
+	}
+
+	arcade.Server.EndLobbyHeartbeats(l.lobby.ID)
+}
+
+// broadcastServedLobby tells everyone who isn't in a lobby we serve about its
+// latest state, like a host's lobby view does.
+func broadcastServedLobby(lobby *Lobby) {
+	arcade.Server.Network.ClientsRange(func(client *net.Client) bool {
+		client.RLock()
+		skip := client.State != net.Connected || client.Distributor
+		client.RUnlock()
+
+		if skip || lobby.HasPlayer(client.ID) {
+			return true
+		}
+
+		arcade.Server.Network.Send(client, NewLobbyUpdateMessage(lobby))
+		return true
+	})
+}
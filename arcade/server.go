@@ -4,22 +4,43 @@ import (
 	"arcade/arcade/message"
 	"arcade/arcade/multicast"
 	"arcade/arcade/net"
+	"context"
+	"crypto/ed25519"
+	"encoding"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	gonet "net"
 	"reflect"
+	"strconv"
 	"sync"
 	"time"
-
-	"github.com/google/uuid"
-	"github.com/xtaci/kcp-go/v5"
 )
 
 const timeoutInterval = 2500 * time.Millisecond
 const heartbeatInterval = 250 * time.Millisecond
 const rttAverageNum = 10
 
+// How many ports to try, starting with the one asked for, when it's taken
+const portFallbackAttempts = 10
+
+// How long to wait before accepting again after a failure, doubling each time
+const (
+	minAcceptBackoff = 5 * time.Millisecond
+	maxAcceptBackoff = time.Second
+)
+
 type ConnectedClientInfo struct {
 	LastHeartbeat time.Time
 	RTTs          []time.Duration
+
+	// Whether each of the last few heartbeats we sent was answered
+	Replies []bool
+
+	// Lobby we host that the client's in, whose heartbeats they're sent,
+	// or empty for whatever's on screen
+	LobbyID string
 }
 
 func (c ConnectedClientInfo) GetMeanRTT() time.Duration {
@@ -44,39 +65,180 @@ type Server struct {
 
 	Network *net.Network
 
+	// What happens on the network, for the view manager and anything else
+	// that wants to know
+	Events *EventBus
+
 	Addr string
 	ID   string
 
 	connectedClients sync.Map
+
+	// Inbound limits, so a flooding peer can't drown out everyone else
+	RateLimiter *RateLimiter
+
+	// Signs match results, for the distributor to tell players apart from
+	// anyone claiming to be them. Made fresh every session, and vouched for
+	// by our identity with the token
+	SessionKey   ed25519.PrivateKey
+	SessionToken SessionToken
+
+	// True if we're a distributor, rather than a player
+	distributor bool
+
+	// Set by `arcade serve`, which hosts lobbies with no view
+	Headless bool
+
+	// Only set when running as a distributor
+	directory   *Directory
+	leaderboard *Leaderboard
+	challenges  *ChallengeBoard
+	bans        *BanList
+	shedder     *loadShedder
+	spectators  *SpectateRelay
+	replays     *ReplayStore
+	workshop    *Workshop
+
+	// How busy our distributor last said it was
+	distributorLoad *DistributorLoad
+
+	// Region our distributor serves, if we're near it
+	distributorRegion string
+
+	// Region we serve, told to players. Only set when running as a
+	// distributor
+	Region string
+
+	// Network interface to find players on the LAN on, or empty for every
+	// one
+	LANInterface string
+
+	// Peers found on the LAN, and when they last announced themselves
+	lanPeers *DiscoveryCache
+
+	// Done once the server's stopped, which ends everything it runs in the
+	// background
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Ends the heartbeat loop that's running, if one is
+	stopHeartbeats context.CancelFunc
+
+	// What Start is accepting connections on
+	listener gonet.Listener
+
+	// Lobbies we host, by ID
+	lobbyHostsMu sync.RWMutex
+	lobbyHosts   map[string]LobbyHost
 }
 
-// NewServer creates the server with a given address.
-func NewServer(addr string, port int, distributor bool, mgr *ViewManager) *Server {
-	id := uuid.NewString()
+var errServerStopped = errors.New("server stopped")
+
+// NewServer creates the server with a given address, for the player with the
+// identity.
+func NewServer(addr string, port int, distributor bool, identity *Identity, mgr *ViewManager) *Server {
+	id := identity.PlayerID()
 	net := net.NewNetwork(id, port, distributor)
 
+	_, sessionKey, err := ed25519.GenerateKey(nil)
+
+	if err != nil {
+		panic(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
 	s := &Server{
+		ctx:              ctx,
+		cancel:           cancel,
 		mgr:              mgr,
+		distributor:      distributor,
 		Addr:             addr,
 		Network:          net,
+		Events:           NewEventBus(),
 		ID:               id,
 		connectedClients: sync.Map{},
+		RateLimiter:      NewRateLimiter(),
+		SessionKey:       sessionKey,
+		SessionToken:     identity.NewSessionToken(sessionKey.Public().(ed25519.PublicKey)),
+		lanPeers:         NewDiscoveryCache(),
+		lobbyHosts:       make(map[string]LobbyHost),
 	}
 
+	net.SignSessionKey = s.proveSessionKey
+	net.VerifySessionKey = checkSessionKey
+	net.Admit = s.admitMessage
+
+	if distributor {
+		s.directory = NewDirectory()
+		s.leaderboard = NewLeaderboard()
+		s.shedder = newLoadShedder()
+		s.spectators = NewSpectateRelay(net, s.shedder)
+		s.replays = NewReplayStore()
+		s.workshop = NewWorkshop()
+		s.challenges = NewChallengeBoard()
+
+		if s.bans, err = LoadBanList(); err != nil {
+			fmt.Println("Couldn't load bans:", err)
+		}
+
+		if err := s.loadState(); err != nil {
+			fmt.Println("Couldn't restore state:", err)
+		}
+
+		go s.startSaving()
+		go s.startLoadReports()
+		go s.sweepResults(s.ctx)
+	}
+
+	// Lobbies we host close once their games end, and hear about the
+	// network whether or not they're on screen
+	s.Events.Subscribe(finishHostedLobby, GameEvents)
+	s.Events.Subscribe(s.forwardToLobbyHosts, NetworkEvents)
+
 	message.AddListener(message.Listener{
 		Distributor: true,
 		ServerID:    id,
 		Handle:      s.handleMessage,
 	})
 
-	go s.startHeartbeats()
+	net.Delegate = s
+	s.startHeartbeats()
 
 	return s
 }
 
+// startHeartbeats starts heartbeating the connected clients, in place of the
+// loop that's running, if any. Replies to the old loop's heartbeats are
+// ignored, so it can't bring back clients ended since.
 func (s *Server) startHeartbeats() {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	if s.stopHeartbeats != nil {
+		s.stopHeartbeats()
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.stopHeartbeats = cancel
+
+	go s.heartbeat(ctx)
+}
+
+func (s *Server) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
 	for {
 		s.connectedClients.Range(func(key, value any) bool {
+			if ctx.Err() != nil {
+				return false
+			}
+
 			clientID := key.(string)
 			info := value.(ConnectedClientInfo)
 
@@ -89,7 +251,13 @@ func (s *Server) startHeartbeats() {
 				return true
 			}
 
-			metadata := s.mgr.GetHeartbeatMetadata()
+			var metadata []byte
+
+			if host, ok := s.lobbyHost(info.LobbyID); ok {
+				metadata = s.lobbyHeartbeatMetadata(host)
+			} else if s.mgr != nil {
+				metadata = s.mgr.GetHeartbeatMetadata()
+			}
 
 			go func(clientID string) {
 				start := time.Now()
@@ -97,15 +265,19 @@ func (s *Server) startHeartbeats() {
 				end := time.Now()
 
 				_, ok := res.(*HeartbeatReplyMessage)
+				answered := ok && err == nil
 
-				if !ok || err != nil {
+				if ctx.Err() != nil {
 					return
 				}
 
 				if c, ok := s.connectedClients.Load(clientID); ok {
-					client := c.(ConnectedClientInfo)
-					client.RTTs = append(client.RTTs, end.Sub(start))
-					client.LastHeartbeat = time.Now()
+					client := c.(ConnectedClientInfo).recordReply(answered, end.Sub(start))
+
+					if answered {
+						client.LastHeartbeat = time.Now()
+					}
+
 					s.connectedClients.Store(clientID, client)
 				}
 			}(clientID)
@@ -113,7 +285,11 @@ func (s *Server) startHeartbeats() {
 			return true
 		})
 
-		<-time.After(heartbeatInterval)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
@@ -124,19 +300,78 @@ func (s *Server) BeginHeartbeats(clientID string) {
 	})
 }
 
+// BeginLobbyHeartbeats starts heartbeating a client that's joined a lobby we
+// host, with the lobby's heartbeats.
+func (s *Server) BeginLobbyHeartbeats(clientID, lobbyID string) {
+	s.connectedClients.Store(clientID, ConnectedClientInfo{
+		LastHeartbeat: time.Now(),
+		RTTs:          []time.Duration{},
+		LobbyID:       lobbyID,
+	})
+}
+
 func (s *Server) EndHeartbeats(clientID string) {
 	s.connectedClients.Delete(clientID)
 }
 
+// EndLobbyHeartbeats stops heartbeating the members of a lobby we host, for
+// when it closes while we carry on hosting others.
+func (s *Server) EndLobbyHeartbeats(lobbyID string) {
+	s.connectedClients.Range(func(key, value any) bool {
+		if value.(ConnectedClientInfo).LobbyID == lobbyID {
+			s.connectedClients.Delete(key)
+		}
+
+		return true
+	})
+}
+
+// EndAllHeartbeats stops heartbeating everyone, for when we stop hosting or
+// leave a lobby. Heartbeats start over for whoever's begun next.
 func (s *Server) EndAllHeartbeats() {
+	s.Lock()
+	if s.stopHeartbeats != nil {
+		s.stopHeartbeats()
+	}
+	s.Unlock()
+
 	s.connectedClients.Range(func(key, value any) bool {
 		s.connectedClients.Delete(key)
 		return true
 	})
+
+	s.startHeartbeats()
 }
 
-func (s *Server) GetHeartbeatClients() sync.Map {
-	return s.connectedClients
+func (s *Server) GetHeartbeatClients() *sync.Map {
+	return &s.connectedClients
+}
+
+// admitMessage is checked before a received message is queued for
+// handleMessage, so a flood is turned away before it takes up room.
+func (s *Server) admitMessage(c *net.Client, senderID, messageType, origin string) bool {
+	switch s.RateLimiter.Allow(connectionKey(c, origin), messageType) {
+	case RateDrop:
+		return false
+	case RateDisconnect:
+		c.RLock()
+		neighborID, relayed := c.ID, c.Distributor
+		c.RUnlock()
+
+		// Relayed floods are dropped, since hanging up would cut us off
+		// from the distributor
+		if relayed {
+			return false
+		}
+
+		log.Printf("Disconnecting %s for flooding '%s' messages\n", neighborID, messageType)
+
+		s.EndHeartbeats(neighborID)
+		s.Network.Disconnect(neighborID)
+		return false
+	}
+
+	return true
 }
 
 func (s *Server) handleMessage(client, msg interface{}) interface{} {
@@ -149,12 +384,39 @@ func (s *Server) handleMessage(client, msg interface{}) interface{} {
 		baseMsg.RecipientID = s.ID
 	}
 
+	// Whoever's on the other end picks these, and they're sliced for logs and
+	// used as keys, so ones that can't be real are dropped
+	if !validPlayerID(baseMsg.SenderID) || !validPlayerID(baseMsg.RecipientID) {
+		log.Printf("Dropped '%s' with a malformed sender or recipient\n", baseMsg.Type)
+		return nil
+	}
+
+	trace := arcade.Diagnostics.trace(baseMsg.Type)
+	defer trace.done()
+
+	// Banned players can't register or have anything relayed
+	if s.distributor {
+		c.RLock()
+		addr, neighborID := c.Addr, c.ID
+		c.RUnlock()
+
+		if _, banned := s.bans.Banned(baseMsg.SenderID, addr); banned {
+			fmt.Printf("Refused '%s' from banned %s\n", baseMsg.Type, baseMsg.SenderID)
+
+			if neighborID == baseMsg.SenderID {
+				s.Network.Disconnect(neighborID)
+			}
+
+			return nil
+		}
+	}
+
 	// Signal message received if necessary
 	s.Network.SignalReceived(baseMsg.MessageID, msg)
 
-	if arcade.Distributor {
+	if s.distributor {
 		fmt.Println(msg)
-		fmt.Printf("Received '%s' from %s\n", baseMsg.Type, baseMsg.SenderID[:4])
+		fmt.Printf("Received '%s' from %s\n", baseMsg.Type, shortID(baseMsg.SenderID, 4))
 
 		if baseMsg.Type == "error" {
 			fmt.Println(msg)
@@ -165,15 +427,57 @@ func (s *Server) handleMessage(client, msg interface{}) interface{} {
 	switch msg := msg.(type) {
 	case *DisconnectMessage:
 		s.Network.Disconnect(c.ID)
-	case *net.PingMessage, *net.PongMessage, *net.RoutingMessage:
+	case *net.PingMessage:
+		if s.distributor && s.shedder.full(s.neighbors(c)) {
+			fmt.Printf("Full, turning away %s\n", shortID(msg.SenderID, 4))
+
+			// Give the reply a moment to get out first
+			time.AfterFunc(time.Second, func() {
+				s.Network.Disconnect(msg.SenderID)
+			})
+
+			return NewErrorMessage(errServerFull)
+		}
+	case *net.PongMessage, *net.RoutingMessage:
 		break
 	default:
 		if baseMsg.RecipientID != s.ID {
-			if arcade.Distributor {
-				fmt.Println("Forwarding message to", baseMsg.RecipientID[:4])
+			if s.distributor {
+				fmt.Println("Forwarding message to", shortID(baseMsg.RecipientID, 4))
 				fmt.Println(msg)
 			}
 
+			trace.in("relay")
+
+			if s.distributor {
+				if _, banned := s.bans.Banned(baseMsg.RecipientID, ""); banned {
+					return NewErrorMessage("invalid recipient")
+				}
+
+				// Hosts past their lobby cap don't get theirs shown
+				if info, ok := msg.(*LobbyInfoMessage); ok && info.Lobby != nil && !s.directory.AllowLobby(baseMsg.SenderID, info.Lobby.ID) {
+					return nil
+				}
+
+				priority := net.PriorityControl
+
+				if p, ok := msg.(net.Prioritized); ok {
+					priority = p.SendPriority()
+				}
+
+				data, _ := msg.(encoding.BinaryMarshaler).MarshalBinary()
+
+				if !s.shedder.admitRelay(len(data), priority) {
+					return nil
+				}
+
+				c.RLock()
+				origin := messageOrigin(c.Addr)
+				c.RUnlock()
+
+				reflect.ValueOf(msg).Elem().FieldByName("Message").FieldByName("Origin").SetString(origin)
+			}
+
 			s.RLock()
 			recipient, ok := s.Network.GetClient(baseMsg.RecipientID)
 			s.RUnlock()
@@ -185,29 +489,156 @@ func (s *Server) handleMessage(client, msg interface{}) interface{} {
 				return NewErrorMessage("invalid recipient")
 			}
 		} else {
-			if arcade.Distributor {
-				fmt.Println(msg)
-				panic("Recipient: " + baseMsg.RecipientID + ", self: " + s.ID)
+			if s.distributor {
+				trace.in("directory")
+
+				if reply, ok := s.directory.handleMessage(c, msg); ok {
+					return reply
+				}
+
+				trace.in("spectators")
+
+				if reply, ok := s.spectators.handleMessage(c, msg); ok {
+					return reply
+				}
+
+				trace.in("replays")
+
+				if reply, ok := s.replays.handleMessage(c, msg); ok {
+					return reply
+				}
+
+				trace.in("workshop")
+
+				if reply, ok := s.workshop.handleMessage(c, msg); ok {
+					return reply
+				}
+
+				trace.in("challenges")
+
+				if reply, ok := s.challenges.handleMessage(c, msg); ok {
+					return reply
+				}
+
+				if _, ok := msg.(*SeasonQueryMessage); ok {
+					trace.in("leaderboard")
+					return s.leaderboard.SeasonInfo(baseMsg.SenderID)
+				}
+
+				if report, ok := msg.(*ResultReportMessage); ok {
+					trace.in("leaderboard")
+
+					c.RLock()
+					addr, neighborID := c.Addr, c.ID
+					c.RUnlock()
+
+					// Reports come straight from the player, so the sender
+					// is who's on the other end of the connection
+					if neighborID != report.SenderID {
+						return NewErrorMessage("results must be reported directly")
+					}
+
+					// We record the matches we host as we saw them end
+					if _, hosted := s.lobbyHost(report.Result.GameID); hosted {
+						return nil
+					}
+
+					if report.Entry {
+						if err := s.leaderboard.Enter(report.SenderID, report.Token, report.Result, report.Signature); err != nil {
+							fmt.Printf("Rejected entry from %s: %v\n", shortID(report.SenderID, 4), err)
+							return NewErrorMessage(err.Error())
+						}
+
+						return nil
+					}
+
+					if _, err := s.leaderboard.Report(report.SenderID, addr, report.Token, report.Result, report.Signature); err != nil {
+						fmt.Printf("Rejected result from %s: %v\n", shortID(report.SenderID, 4), err)
+						return NewErrorMessage(err.Error())
+					}
+
+					return nil
+				}
+
+				trace.in("matches")
+
+				if reply, ok := s.handleMatchMessage(c, baseMsg.SenderID, msg); ok {
+					return reply
+				}
+
+				trace.in("unexpected")
+				fmt.Printf("Unexpected '%s' from %s\n", baseMsg.Type, shortID(baseMsg.SenderID, 4))
+				return NewErrorMessage("unexpected message")
+			}
+
+			s.publishMessage(msg)
+
+			trace.in("lobbies")
+
+			if s.Headless {
+				if reply, ok := s.answerForHostedLobbies(c, msg); ok {
+					return reply
+				}
+			} else if _, ok := msg.(*HelloMessage); ok {
+				go s.announceHostedLobbies(c)
 			}
 
+			if reply, ok := s.routeToLobby(c, baseMsg.SenderID, msg); ok {
+				return reply
+			}
+
+			// Servers without views, like in tests, only talk to the network.
+			// Headless ones still keep up with their players' heartbeats
+			if s.mgr == nil && !s.Headless {
+				return nil
+			}
+
+			c.RLock()
+			fromDistributor := c.Distributor && c.ID == baseMsg.SenderID
+			distance := int(c.Distance)
+			c.RUnlock()
+
 			switch msg := msg.(type) {
 			case *HeartbeatMessage:
-				if cli, ok := s.connectedClients.Load(msg.SenderID); ok {
-					client := cli.(ConnectedClientInfo)
-					client.LastHeartbeat = time.Now()
-					s.connectedClients.Store(msg.SenderID, client)
-
-					c.Lock()
-					c.Distance = float64(client.GetMeanRTT().Milliseconds())
-					c.Unlock()
+				trace.in("heartbeat")
+
+				// Distributors send these to say how busy they are, unless
+				// they're hosting our match
+				if fromDistributor {
+					md := parseHeartbeatMetadata(msg.Metadata)
+
+					if md.Load != nil {
+						s.Lock()
+						s.distributorLoad = md.Load
+						s.Unlock()
+
+						s.setDistributorRegion(md.Region, distance)
+
+						return nil
+					}
 				}
 
-				// Send heartbeat metadata to view
-				s.mgr.ProcessEvent(NewHeartbeatEvent(msg.Metadata))
+				return s.receiveHeartbeat(c, msg)
+			case *ErrorMessage:
+				// Can arrive before we know the sender is a distributor,
+				// since it answers the ping
+				if msg.Text == errServerFull {
+					notify("The distributor is full, try again later")
+					return nil
+				}
 
-				// Reply to heartbeat
-				return NewHeartbeatReplyMessage(msg.Seq)
+				if s.mgr == nil {
+					return nil
+				}
+
+				trace.in(s.mgr.viewName())
+				return s.mgr.ProcessMessage(c, msg)
 			default:
+				if s.mgr == nil {
+					return nil
+				}
+
+				trace.in(s.mgr.viewName())
 				return s.mgr.ProcessMessage(c, msg)
 			}
 		}
@@ -216,36 +647,214 @@ func (s *Server) handleMessage(client, msg interface{}) interface{} {
 	return nil
 }
 
-// Start starts listening for connections on a given address.
+// receiveHeartbeat notes that the sender's still there, and passes what
+// they're up to on to whatever's listening, before answering.
+func (s *Server) receiveHeartbeat(c *net.Client, msg *HeartbeatMessage) *HeartbeatReplyMessage {
+	if cli, ok := s.connectedClients.Load(msg.SenderID); ok {
+		client := cli.(ConnectedClientInfo)
+		client.LastHeartbeat = time.Now()
+		s.connectedClients.Store(msg.SenderID, client)
+
+		c.Lock()
+		c.Distance = float64(client.GetMeanRTT().Milliseconds())
+		c.Unlock()
+	}
+
+	s.Events.Publish(NewHeartbeatEvent(msg.SenderID, msg.Metadata))
+
+	return NewHeartbeatReplyMessage(msg.Seq)
+}
+
+// publishMessage publishes the events the message means, if any.
+func (s *Server) publishMessage(msg interface{}) {
+	switch msg := msg.(type) {
+	case *LobbyInfoMessage:
+		if msg.Lobby != nil {
+			s.Events.Publish(NewLobbyUpdatedEvent(msg.Lobby))
+		}
+	case *LobbyUpdateMessage:
+		if msg.Lobby != nil {
+			s.Events.Publish(NewLobbyUpdatedEvent(msg.Lobby))
+		}
+	case *LobbyEndMessage:
+		s.Events.Publish(NewLobbyEndedEvent(msg.LobbyID))
+	case *LobbyStateMessage:
+		s.Events.Publish(NewLobbyStateChangedEvent(msg.LobbyID, msg.State))
+	case *JoinReplyMessage:
+		s.Events.Publish(NewJoinReplyEvent(msg.Lobby, msg.Error))
+	}
+}
+
+// Start starts listening for connections on a given address. Returns an error
+// if it can't, or once it can't take any more connections, and nil once the
+// server's stopped.
 func (s *Server) Start(noLAN bool) error {
-	listener, err := kcp.Listen(s.Addr)
+	listener, err := s.listen()
 
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	defer listener.Close()
+
+	s.Lock()
+	s.listener = listener
+	s.Unlock()
+
+	// In case Stop came before there was a listener for it to close
+	if s.ctx.Err() != nil {
+		return errServerStopped
 	}
 
-	fmt.Printf("Listening at %s...\n", s.Addr)
+	fmt.Printf("Listening at %s...\n", listener.Addr())
 	fmt.Printf("ID: %s\n", s.ID)
 
+	// Game updates go over plain UDP where they can, and reliably otherwise.
+	// Connections that aren't over the internet don't need it
+	if !s.distributor && s.Network.Transport == net.KCP && s.Network.UnreliablePort() == 0 {
+		if err := s.Network.ListenUnreliable(); err != nil {
+			fmt.Printf("Unreliable messages disabled: %v\n", err)
+		}
+	}
+
+	if s.Network.Transport == net.KCP && s.Network.FECPort() == 0 {
+		if err := s.Network.ListenFEC(); err != nil {
+			log.Println("Error correction disabled:", err)
+		}
+	}
+
+	// Players behind proxies can only reach us over TCP, on the same port
+	if s.Network.Transport == net.KCP && s.Network.TCPPort() == 0 {
+		if err := s.Network.ListenTCP(listener.Addr().String()); err != nil {
+			log.Println("Connections through proxies disabled:", err)
+		}
+	}
+
+	// Distributors' addresses are fixed, so only players' connections move
+	if !s.distributor {
+		go s.Network.WatchAddrs(s.ctx.Done())
+	}
+
 	if !noLAN {
 		startCh := make(chan error)
-		go multicast.Listen(s.ID, s, startCh)
+		go multicast.Listen(s.ID, s.Port(), s.LANInterface, s, startCh)
 
+		// Playing over the internet still works without LAN discovery
 		if err := <-startCh; err != nil {
-			panic(err)
+			log.Println("LAN discovery disabled:", err)
+		} else {
+			go s.announceLAN()
 		}
 	}
 
+	backoff := minAcceptBackoff
+
 	for {
 		// Wait for new client connections
 		conn, err := listener.Accept()
 
+		if s.ctx.Err() != nil {
+			if err == nil {
+				conn.Close()
+			}
+
+			return nil
+		} else if errors.Is(err, io.ErrClosedPipe) || errors.Is(err, gonet.ErrClosed) {
+			return err
+		} else if err != nil {
+			log.Printf("Couldn't accept a connection, retrying in %v: %v\n", backoff, err)
+			time.Sleep(backoff)
+
+			if backoff *= 2; backoff > maxAcceptBackoff {
+				backoff = maxAcceptBackoff
+			}
+
+			continue
+		}
+
+		backoff = minAcceptBackoff
+		go s.Network.Accept(conn)
+	}
+}
+
+// Stop ends heartbeats and everything else the server runs in the background,
+// and stops Start accepting connections. Connections already made are left
+// to time out. A stopped server can't be started again.
+func (s *Server) Stop() {
+	s.cancel()
+
+	s.RLock()
+	listener := s.listener
+	s.RUnlock()
+
+	if listener != nil {
+		listener.Close()
+	}
+}
+
+// listen starts listening on our port. Players' ports just need to be free, so
+// if it's taken, say by another instance on the same machine, the next few are
+// tried too; the distributor's address is fixed, so it only tries its own.
+func (s *Server) listen() (gonet.Listener, error) {
+	s.RLock()
+	host, portStr, err := gonet.SplitHostPort(s.Addr)
+	s.RUnlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := strconv.Atoi(portStr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := portFallbackAttempts
+
+	if s.distributor {
+		attempts = 1
+	}
+
+	var listenErr error
+
+	for i := 0; i < attempts && port+i <= 65535; i++ {
+		listener, err := s.Network.Transport.Listen(gonet.JoinHostPort(host, strconv.Itoa(port+i)))
+
 		if err != nil {
-			panic(err)
+			listenErr = err
+			continue
 		}
 
-		s.Network.Connect(conn.RemoteAddr().String(), "", conn)
+		if i > 0 {
+			log.Printf("Port %d is busy, using %d\n", port, port+i)
+
+			s.SetPort(port + i)
+		}
+
+		return listener, nil
+	}
+
+	if attempts == 1 {
+		return nil, fmt.Errorf("couldn't listen on port %d: %w", port, listenErr)
 	}
+
+	return nil, fmt.Errorf("couldn't listen on ports %d to %d: %w", port, port+attempts-1, listenErr)
+}
+
+// Port returns the port we're listening on.
+func (s *Server) Port() int {
+	return s.Network.Port()
+}
+
+// SetPort changes the port to listen on, for the next time the server is
+// started.
+func (s *Server) SetPort(port int) {
+	s.Lock()
+	s.Addr = fmt.Sprintf("0.0.0.0:%d", port)
+	s.Unlock()
+
+	s.Network.SetPort(port)
 }
 
 //
@@ -253,8 +862,55 @@ func (s *Server) Start(noLAN bool) error {
 //
 
 func (s *Server) ClientDiscovered(addr, id string) {
+	news := s.lanPeers.Seen(id, addr, time.Now())
+
+	// Peers announce themselves every so often, which only needs us to
+	// connect if we aren't already
+	if c, ok := s.Network.GetClient(id); ok && !news {
+		c.RLock()
+		connected := c.State == net.Connected
+		c.RUnlock()
+
+		if connected {
+			return
+		}
+	}
+
 	s.RLock()
 	defer s.RUnlock()
 
 	s.Network.Connect(addr, id, nil)
 }
+
+// announceLAN announces us on the LAN every so often, and publishes a
+// LANPeerLostEvent for each peer that's stopped announcing itself, until
+// the server's stopped.
+func (s *Server) announceLAN() {
+	ticker := time.NewTicker(lanAnnounceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case now := <-ticker.C:
+			multicast.Discover(s.Addr, s.ID, s.Port())
+
+			for _, id := range s.lanPeers.Expire(now) {
+				s.Events.Publish(NewLANPeerLostEvent(id))
+			}
+		}
+	}
+}
+
+//
+// NetworkDelegate methods
+//
+
+func (s *Server) ClientConnected(id string) {
+	s.Events.Publish(NewClientConnectedEvent(id))
+}
+
+func (s *Server) ClientDisconnected(id string) {
+	s.Events.Publish(NewClientDisconnectedEvent(id))
+}
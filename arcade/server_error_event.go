@@ -0,0 +1,18 @@
+package arcade
+
+// ServerErrorEvent is sent to the view manager when we stop being able to take
+// connections, so the player can pick another port instead of the game
+// crashing.
+type ServerErrorEvent struct {
+	Err error
+}
+
+func NewServerErrorEvent(err error) *ServerErrorEvent {
+	return &ServerErrorEvent{
+		Err: err,
+	}
+}
+
+func (e *ServerErrorEvent) Topic() EventTopic {
+	return NetworkEvents
+}
@@ -0,0 +1,277 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"arcade/arcade/net"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestDistributor starts a distributor that keeps its files in a temporary
+// directory and never listens, for feeding messages to directly.
+func newTestDistributor(tb testing.TB) *Server {
+	dir := tb.TempDir()
+	tb.Setenv("HOME", dir)
+	tb.Setenv("XDG_CONFIG_HOME", dir)
+
+	arcade.Distributor = true
+	tb.Cleanup(func() {
+		arcade.Distributor = false
+	})
+
+	registerMessages()
+
+	s := NewServer("127.0.0.1:0", 0, true, NewIdentity(), nil)
+	tb.Cleanup(s.Stop)
+
+	return s
+}
+
+// messageSeeds returns every registered message, addressed to the distributor
+// and to another player.
+func messageSeeds(tb testing.TB, distributorID string) [][]byte {
+	senderID := NewIdentity().PlayerID()
+	otherID := NewIdentity().PlayerID()
+	seeds := make([][]byte, 0)
+
+	for _, msg := range message.Registered() {
+		for _, recipientID := range []string{distributorID, otherID} {
+			p := reflect.New(reflect.TypeOf(msg))
+			p.Elem().Set(reflect.ValueOf(msg))
+
+			base := p.Elem().FieldByName("Message")
+			base.FieldByName("SenderID").SetString(senderID)
+			base.FieldByName("RecipientID").SetString(recipientID)
+			base.FieldByName("MessageID").SetString("seed")
+
+			data, err := json.Marshal(p.Interface())
+
+			if err != nil {
+				tb.Fatal(err)
+			}
+
+			seeds = append(seeds, data)
+		}
+	}
+
+	return seeds
+}
+
+// FuzzNotify feeds packets, as they'd arrive from anyone on the internet,
+// through parsing and the distributor's handling of them. Nothing it's sent
+// may panic.
+func FuzzNotify(f *testing.F) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	s := newTestDistributor(f)
+
+	for _, seed := range messageSeeds(f, s.ID) {
+		f.Add(seed)
+	}
+
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"Type":"ping"}`))
+	f.Add([]byte(`{"Type":1}`))
+	f.Add([]byte(`null`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		message.Notify(s.ID, &net.Client{}, data)
+	})
+}
+
+// FuzzHandleMessage gives the distributor well-formed messages of each type
+// with whatever's in their fields, which the fuzzer finds faster than by
+// mutating whole packets.
+func FuzzHandleMessage(f *testing.F) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	s := newTestDistributor(f)
+	msgs := message.Registered()
+
+	for _, seed := range messageSeeds(f, s.ID) {
+		var res struct {
+			Type string
+		}
+
+		if err := json.Unmarshal(seed, &res); err != nil {
+			f.Fatal(err)
+		}
+
+		for i, msg := range msgs {
+			if reflect.ValueOf(msg).FieldByName("Message").FieldByName("Type").String() == res.Type {
+				f.Add(uint8(i), seed)
+			}
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, index uint8, data []byte) {
+		msg := msgs[int(index)%len(msgs)]
+		p := reflect.New(reflect.TypeOf(msg))
+		p.Elem().Set(reflect.ValueOf(msg))
+		messageType := p.Elem().FieldByName("Message").FieldByName("Type").String()
+
+		if json.Unmarshal(data, p.Interface()) != nil {
+			return
+		}
+
+		p.Elem().FieldByName("Message").FieldByName("Type").SetString(messageType)
+		s.handleMessage(&net.Client{}, p.Interface())
+	})
+}
+
+// A player and a distributor in one process, over pipes that take as long as
+// a real connection might.
+func TestMemoryTransportConnects(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	latency := 20 * time.Millisecond
+	transport := net.NewMemoryTransport(latency)
+
+	distributor := newTestDistributor(t)
+	distributor.Network.Transport = transport
+
+	go distributor.Start(true)
+
+	player := NewServer("127.0.0.1:6791", 6791, false, NewIdentity(), nil)
+	player.Network.Transport = transport
+	defer player.Stop()
+
+	var err error
+
+	// Until the distributor is listening
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if _, err = player.Network.Connect(distributor.Addr, "", nil); err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, ok := player.Network.GetDistributor()
+
+	if !ok {
+		t.Fatal("not connected to the distributor")
+	}
+
+	client.RLock()
+	rtt := client.Distance
+	client.RUnlock()
+
+	if rtt < float64(2*latency.Milliseconds()) {
+		t.Errorf("round trip took %vms, expected at least %v", rtt, 2*latency)
+	}
+
+	res, err := player.Network.SendAndReceive(client, NewSpectateQueryMessage())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := res.(*SpectateListMessage); !ok {
+		t.Errorf("got %T, expected the list of games", res)
+	}
+}
+
+func TestStopEndsStart(t *testing.T) {
+	distributor := newTestDistributor(t)
+	distributor.Network.Transport = net.NewMemoryTransport(0)
+
+	startErr := make(chan error, 1)
+
+	go func() {
+		startErr <- distributor.Start(true)
+	}()
+
+	// Until it's listening, or Stop would come first
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		distributor.RLock()
+		listening := distributor.listener != nil
+		distributor.RUnlock()
+
+		if listening {
+			break
+		}
+	}
+
+	distributor.Stop()
+
+	select {
+	case err := <-startErr:
+		if err != nil {
+			t.Errorf("Start returned %v after stopping", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Start kept accepting after stopping")
+	}
+
+	if err := distributor.Start(true); err == nil {
+		t.Error("started again after stopping")
+	}
+}
+
+// Servers without views still publish what happens, for bots and tests.
+func TestEventsWithoutViews(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	registerMessages()
+	transport := net.NewMemoryTransport(0)
+
+	host := NewServer("127.0.0.1:6792", 6792, false, NewIdentity(), nil)
+	host.Network.Transport = transport
+	defer host.Stop()
+
+	go host.Start(true)
+
+	player := NewServer("127.0.0.1:6793", 6793, false, NewIdentity(), nil)
+	player.Network.Transport = transport
+	defer player.Stop()
+
+	events := make(chan Event, 8)
+	player.Events.Subscribe(func(ev Event) {
+		events <- ev
+	}, NetworkEvents, LobbyEvents)
+
+	var err error
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if _, err = player.Network.Connect(host.Addr, "", nil); err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, ok := host.Network.GetClient(player.ID)
+
+	if !ok {
+		t.Fatal("the host doesn't know the player")
+	}
+
+	host.Network.Send(client, NewLobbyUpdateMessage(NewLobby("Events", false, Pong, 2, host.ID)))
+
+	want := []string{"*arcade.ClientConnectedEvent", "*arcade.LobbyUpdatedEvent"}
+
+	for _, name := range want {
+		select {
+		case ev := <-events:
+			if got := reflect.TypeOf(ev).String(); got != name {
+				t.Errorf("got %s, expected %s", got, name)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no %s", name)
+		}
+	}
+}
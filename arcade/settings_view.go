@@ -0,0 +1,357 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"arcade/arcade/widgets"
+	"encoding"
+	"strconv"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+type SettingsView struct {
+	View
+	mgr *ViewManager
+
+	mu sync.RWMutex
+
+	theme       *widgets.Select
+	asciiMode   *widgets.Checkbox
+	sound       *widgets.Checkbox
+	playerName  *widgets.TextInput
+	port        *widgets.TextInput
+	distributor *widgets.TextInput
+	fpsCap      *widgets.Select
+	graphics    *widgets.Select
+	accessible  *widgets.Checkbox
+	awayAfter   *widgets.Select
+	keyHold     *widgets.Select
+	region      *widgets.Select
+	crossRegion *widgets.Checkbox
+
+	focus *widgets.FocusGroup
+
+	errMsg string
+}
+
+var fpsCapOptions = []string{"unlimited", "15", "30", "60", "120"}
+
+// Minutes without input before we're shown as away
+var awayAfterOptions = []string{"never", "2", "5", "10", "30"}
+
+// Milliseconds without a key repeat before a held key counts as let go
+var keyHoldOptions = []string{"off", "80", "150", "250", "400"}
+
+var settingsFooter = "↑/↓ Move    ←/→ Change    Enter Press"
+
+const (
+	settingsLabelX  = 16
+	settingsWidgetX = 38
+	settingsWidth   = 26
+	settingsY       = 5
+)
+
+var settingsLabels = []string{
+	"Theme",
+	"ASCII mode",
+	"Sound",
+	"Default name",
+	"Port",
+	"Distributor",
+	"FPS cap",
+	"Graphics",
+	"Screen reader",
+	"Away after (min)",
+	"Key hold (ms)",
+	"Region",
+	"Match other regions",
+}
+
+func NewSettingsView(mgr *ViewManager) *SettingsView {
+	config := mgr.Config()
+	v := &SettingsView{mgr: mgr}
+
+	v.theme = widgets.NewSelect(settingsWidgetX, settingsY, settingsWidth, themeNames())
+	v.theme.SetValue(config.Theme)
+	v.theme.OnChange = func(string) { v.preview() }
+
+	v.asciiMode = widgets.NewCheckbox(settingsWidgetX, settingsY+1, settingsWidth, "", config.ASCIIMode)
+	v.asciiMode.OnChange = func(bool) { v.preview() }
+
+	v.sound = widgets.NewCheckbox(settingsWidgetX, settingsY+2, settingsWidth, "", config.Sound)
+
+	v.playerName = widgets.NewTextInput(settingsWidgetX, settingsY+3, settingsWidth)
+	v.playerName.MaxLength = 20
+	v.playerName.Placeholder = "(none)"
+	v.playerName.SetValue(config.PlayerName)
+
+	v.port = widgets.NewTextInput(settingsWidgetX, settingsY+4, settingsWidth)
+	v.port.MaxLength = 5
+	v.port.SetValue(strconv.Itoa(config.Port))
+
+	v.distributor = widgets.NewTextInput(settingsWidgetX, settingsY+5, settingsWidth)
+	v.distributor.MaxLength = 64
+	v.distributor.SetValue(config.DistributorAddr)
+
+	v.fpsCap = widgets.NewSelect(settingsWidgetX, settingsY+6, settingsWidth, fpsCapOptions)
+	v.fpsCap.SetValue(strconv.Itoa(config.FPSCap))
+
+	if config.FPSCap <= 0 {
+		v.fpsCap.SetValue("unlimited")
+	}
+
+	v.fpsCap.OnChange = func(string) { v.preview() }
+
+	v.graphics = widgets.NewSelect(settingsWidgetX, settingsY+7, settingsWidth, graphicsModes)
+	v.graphics.SetValue(config.Graphics)
+	v.graphics.OnChange = func(string) { v.preview() }
+
+	v.accessible = widgets.NewCheckbox(settingsWidgetX, settingsY+8, settingsWidth, "", config.Accessible)
+	v.accessible.OnChange = func(bool) { v.preview() }
+
+	v.awayAfter = widgets.NewSelect(settingsWidgetX, settingsY+9, settingsWidth, awayAfterOptions)
+	v.awayAfter.SetValue(strconv.Itoa(config.AwayAfter))
+
+	if config.AwayAfter <= 0 {
+		v.awayAfter.SetValue("never")
+	}
+
+	// Keep a time set in the config file by hand
+	holdOptions := keyHoldOptions
+	release := strconv.Itoa(config.RepeatRelease)
+
+	if config.RepeatRelease > 0 && !containsString(holdOptions, release) {
+		holdOptions = append(append([]string(nil), holdOptions...), release)
+	}
+
+	v.keyHold = widgets.NewSelect(settingsWidgetX, settingsY+10, settingsWidth, holdOptions)
+	v.keyHold.SetValue(release)
+
+	if config.RepeatRelease <= 0 {
+		v.keyHold.SetValue("off")
+	}
+
+	v.region = widgets.NewSelect(settingsWidgetX, settingsY+11, settingsWidth, regionOptions())
+	v.region.SetValue(config.Region)
+
+	if !validRegion(config.Region) {
+		v.region.SetValue(RegionAuto)
+	}
+
+	v.crossRegion = widgets.NewCheckbox(settingsWidgetX, settingsY+12, settingsWidth, "", config.CrossRegion)
+
+	v.focus = widgets.NewFocusGroup(
+		v.theme,
+		v.asciiMode,
+		v.sound,
+		v.playerName,
+		v.port,
+		v.distributor,
+		v.fpsCap,
+		v.graphics,
+		v.accessible,
+		v.awayAfter,
+		v.keyHold,
+		v.region,
+		v.crossRegion,
+		widgets.NewButton(settingsLabelX, settingsY+14, 15, "KEYBINDINGS", func() {
+			v.mgr.PushView(NewKeybindingsView(v.mgr))
+		}),
+		widgets.NewButton(settingsLabelX, settingsY+15, 15, "SOUNDS", func() {
+			v.mgr.PushView(NewSoundsView(v.mgr))
+		}),
+		widgets.NewButton(settingsWidgetX, settingsY+15, 24, "NETWORK", func() {
+			v.mgr.PushView(NewNetworkSettingsView(v.mgr))
+		}),
+		widgets.NewButton(settingsWidgetX, settingsY+14, 10, "SAVE", v.save),
+		widgets.NewButton(settingsWidgetX+14, settingsY+14, 10, "BACK", v.back),
+	)
+
+	return v
+}
+
+func (v *SettingsView) Widgets() *widgets.FocusGroup {
+	return v.focus
+}
+
+// read builds a config from the form, or returns a message about the first
+// field that isn't valid.
+func (v *SettingsView) read() (*Config, string) {
+	config := *v.mgr.Config()
+
+	config.Theme = v.theme.Value()
+	config.ASCIIMode = v.asciiMode.Checked()
+	config.Sound = v.sound.Checked()
+	config.PlayerName = v.playerName.Value()
+	config.DistributorAddr = v.distributor.Value()
+	config.Graphics = v.graphics.Value()
+	config.Accessible = v.accessible.Checked()
+	config.Region = v.region.Value()
+	config.CrossRegion = v.crossRegion.Checked()
+	config.FPSCap = 0
+
+	if fps, err := strconv.Atoi(v.fpsCap.Value()); err == nil {
+		config.FPSCap = fps
+	}
+
+	config.AwayAfter = 0
+
+	if minutes, err := strconv.Atoi(v.awayAfter.Value()); err == nil {
+		config.AwayAfter = minutes
+	}
+
+	config.RepeatRelease = 0
+
+	if ms, err := strconv.Atoi(v.keyHold.Value()); err == nil {
+		config.RepeatRelease = ms
+	}
+
+	port, err := strconv.Atoi(v.port.Value())
+
+	if err != nil || port < 1 || port > 65535 {
+		return nil, "Port must be between 1 and 65535."
+	}
+
+	config.Port = port
+
+	if config.DistributorAddr == "" {
+		return nil, "Distributor address can't be empty."
+	}
+
+	return &config, ""
+}
+
+// preview shows the look of the settings before they're saved.
+func (v *SettingsView) preview() {
+	preview := *v.mgr.Config()
+
+	preview.Theme = v.theme.Value()
+	preview.ASCIIMode = v.asciiMode.Checked()
+	preview.Graphics = v.graphics.Value()
+	preview.Accessible = v.accessible.Checked()
+
+	if fps, err := strconv.Atoi(v.fpsCap.Value()); err == nil {
+		preview.FPSCap = fps
+	} else {
+		preview.FPSCap = 0
+	}
+
+	v.mgr.PreviewConfig(&preview)
+}
+
+func (v *SettingsView) save() {
+	config, errMsg := v.read()
+
+	if errMsg == "" && config.Save() != nil {
+		errMsg = "Couldn't save settings."
+	}
+
+	v.mu.Lock()
+	v.errMsg = errMsg
+	v.mu.Unlock()
+
+	if errMsg != "" {
+		return
+	}
+
+	original := v.mgr.Config()
+	v.mgr.ApplyConfig(config)
+
+	if config.Port != original.Port || config.DistributorAddr != original.DistributorAddr {
+		notify("Settings saved, restart to change network settings")
+	} else {
+		notify("Settings saved")
+	}
+}
+
+func (v *SettingsView) back() {
+	// Undo anything that was only previewed
+	v.mgr.ApplyConfig(v.mgr.Config())
+	v.mgr.PopView()
+}
+
+// Back drops unsaved changes on the way out, like the BACK button.
+func (v *SettingsView) Back() bool {
+	v.back()
+	return true
+}
+
+func (v *SettingsView) Init() {
+}
+
+func (v *SettingsView) OnPause() {
+}
+
+// OnResume shows the unsaved changes again, since saving keybindings applies
+// the saved settings.
+func (v *SettingsView) OnResume() {
+	v.preview()
+}
+
+func (v *SettingsView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		switch evt.Key() {
+		case tcell.KeyUp:
+			v.focus.Prev()
+		case tcell.KeyDown:
+			v.focus.Next()
+		}
+	}
+}
+
+func (v *SettingsView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *SettingsView) Render(s *Screen) {
+	width, height := s.displaySize()
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	errSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorLightGreen)
+
+	s.DrawBlockText(CenterX, 1, sty, "SETTINGS", false)
+
+	for i, label := range settingsLabels {
+		s.DrawText(settingsLabelX, settingsY+i, sty, label)
+	}
+
+	v.focus.Render(s)
+
+	// Say what auto picked
+	s.DrawEmpty(settingsWidgetX+settingsWidth+1, settingsY+7, width-2, settingsY+7, sty)
+
+	if v.graphics.Value() == GraphicsAuto {
+		s.DrawText(settingsWidgetX+settingsWidth+2, settingsY+7, sty, "("+v.mgr.detectedGraphics+")")
+	}
+
+	s.DrawEmpty(settingsWidgetX+settingsWidth+1, settingsY+11, width-2, settingsY+11, sty)
+
+	if v.region.Value() == RegionAuto {
+		detected := "unknown"
+
+		if arcade.Server != nil && arcade.Server.DistributorRegion() != "" {
+			detected = arcade.Server.DistributorRegion()
+		}
+
+		s.DrawText(settingsWidgetX+settingsWidth+2, settingsY+11, sty, "("+detected+")")
+	}
+
+	v.mu.RLock()
+	errMsg := v.errMsg
+	v.mu.RUnlock()
+
+	s.DrawEmpty(1, settingsY+16, width-2, settingsY+16, sty)
+	s.DrawText(layout.Center(width, errMsg), settingsY+16, errSty, errMsg)
+
+	s.DrawText(layout.Center(width, settingsFooter), height-2, sty, settingsFooter)
+}
+
+func (v *SettingsView) Unload() {
+}
+
+func (v *SettingsView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
@@ -0,0 +1,273 @@
+package arcade
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+const (
+	// Every this many snapshots is sent whole, so a player who missed acks
+	// for a while catches up without waiting on one
+	snapshotKeyframeInterval = 30
+
+	// Snapshots kept on each side to diff against
+	snapshotHistory = 32
+)
+
+var errSnapshotBase = errors.New("snapshot base not found")
+
+// Snapshot is a game state as sent to one player: either the whole state, or
+// the fields that changed since a snapshot they've acked, as a JSON merge
+// patch (RFC 7386). Since a null in a patch removes the field, states sent
+// this way shouldn't rely on null values.
+type Snapshot struct {
+	Seq      int
+	BaseSeq  int
+	Keyframe bool
+	Data     json.RawMessage
+}
+
+type snapshotDoc struct {
+	seq int
+	doc interface{}
+}
+
+// SnapshotEncoder is kept by the host to diff each player's snapshots against
+// the last one they acked.
+type SnapshotEncoder struct {
+	mu sync.Mutex
+
+	seq     int
+	history []snapshotDoc
+	acked   map[string]int
+}
+
+func NewSnapshotEncoder() *SnapshotEncoder {
+	return &SnapshotEncoder{acked: make(map[string]int)}
+}
+
+// Add records the next state, and returns its sequence number.
+func (e *SnapshotEncoder) Add(state interface{}) (int, error) {
+	doc, err := toSnapshotDoc(state)
+
+	if err != nil {
+		return 0, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.seq++
+	e.history = append(e.history, snapshotDoc{e.seq, doc})
+
+	if len(e.history) > snapshotHistory {
+		e.history = e.history[1:]
+	}
+
+	return e.seq, nil
+}
+
+// Encode returns the latest state for the player, as a delta from the last
+// snapshot they acked when there is one to diff against.
+func (e *SnapshotEncoder) Encode(playerID string, keyframe bool) (*Snapshot, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.history) == 0 {
+		return nil, errSnapshotBase
+	}
+
+	latest := e.history[len(e.history)-1]
+	base, ok := e.find(e.acked[playerID])
+
+	if keyframe || !ok || latest.seq%snapshotKeyframeInterval == 0 {
+		data, err := json.Marshal(latest.doc)
+		return &Snapshot{Seq: latest.seq, Keyframe: true, Data: data}, err
+	}
+
+	data, err := json.Marshal(mergeDiff(base.doc, latest.doc))
+	return &Snapshot{Seq: latest.seq, BaseSeq: base.seq, Data: data}, err
+}
+
+// Ack records that the player has the snapshot, so later ones can be diffed
+// against it.
+func (e *SnapshotEncoder) Ack(playerID string, seq int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if seq > e.acked[playerID] && seq <= e.seq {
+		e.acked[playerID] = seq
+	}
+}
+
+// Resync makes the player's next snapshot whole, for when they've drifted
+// from the host's state.
+func (e *SnapshotEncoder) Resync(playerID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.acked, playerID)
+}
+
+// find returns the snapshot with the sequence number, if it's still kept.
+// Expects the lock to be held.
+func (e *SnapshotEncoder) find(seq int) (snapshotDoc, bool) {
+	for _, d := range e.history {
+		if d.seq == seq {
+			return d, true
+		}
+	}
+
+	return snapshotDoc{}, false
+}
+
+// SnapshotDecoder is kept by players to rebuild states from the host's
+// snapshots.
+type SnapshotDecoder struct {
+	mu sync.Mutex
+
+	latest  int
+	history []snapshotDoc
+}
+
+func NewSnapshotDecoder() *SnapshotDecoder {
+	return &SnapshotDecoder{}
+}
+
+// Decode rebuilds the snapshot's state into v. Snapshots older than the last
+// one decoded, and deltas from a snapshot that's no longer kept, return an
+// error and should be ignored.
+func (d *SnapshotDecoder) Decode(s *Snapshot, v interface{}) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if s.Seq <= d.latest {
+		return errors.New("snapshot out of date")
+	}
+
+	patch, err := decodeSnapshotJSON(s.Data)
+
+	if err != nil {
+		return err
+	}
+
+	doc := patch
+
+	if !s.Keyframe {
+		var base *snapshotDoc
+
+		for i := range d.history {
+			if d.history[i].seq == s.BaseSeq {
+				base = &d.history[i]
+			}
+		}
+
+		if base == nil {
+			return errSnapshotBase
+		}
+
+		doc = mergePatch(base.doc, patch)
+	}
+
+	data, err := json.Marshal(doc)
+
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	d.latest = s.Seq
+	d.history = append(d.history, snapshotDoc{s.Seq, doc})
+
+	if len(d.history) > snapshotHistory {
+		d.history = d.history[1:]
+	}
+
+	return nil
+}
+
+// toSnapshotDoc turns a state into plain maps, slices and values to diff.
+func toSnapshotDoc(state interface{}) (interface{}, error) {
+	data, err := json.Marshal(state)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeSnapshotJSON(data)
+}
+
+// decodeSnapshotJSON keeps numbers as they were written, so they compare
+// and round trip exactly.
+func decodeSnapshotJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+	err := dec.Decode(&doc)
+
+	return doc, err
+}
+
+// mergeDiff returns the merge patch that turns from into to. Objects are
+// diffed field by field; anything else is replaced whole when it changes.
+func mergeDiff(from, to interface{}) interface{} {
+	fromObj, ok1 := from.(map[string]interface{})
+	toObj, ok2 := to.(map[string]interface{})
+
+	if !ok1 || !ok2 {
+		return to
+	}
+
+	patch := make(map[string]interface{})
+
+	for key, value := range toObj {
+		old, ok := fromObj[key]
+
+		if !ok {
+			patch[key] = value
+		} else if !reflect.DeepEqual(old, value) {
+			patch[key] = mergeDiff(old, value)
+		}
+	}
+
+	for key := range fromObj {
+		if _, ok := toObj[key]; !ok {
+			patch[key] = nil
+		}
+	}
+
+	return patch
+}
+
+// mergePatch applies a merge patch, without changing doc.
+func mergePatch(doc, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+
+	if !ok {
+		return patch
+	}
+
+	docObj, _ := doc.(map[string]interface{})
+	result := make(map[string]interface{}, len(docObj))
+
+	for key, value := range docObj {
+		result[key] = value
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(result, key)
+		} else {
+			result[key] = mergePatch(result[key], value)
+		}
+	}
+
+	return result
+}
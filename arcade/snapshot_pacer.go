@@ -0,0 +1,90 @@
+package arcade
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// Most ticks between snapshots to a player on a struggling link
+	maxSnapshotEvery = 4
+
+	// Ticks between checks of each player's link, and calm checks in a row
+	// before they're sped back up
+	pacerCheckTicks    = 10
+	pacerRecoverChecks = 3
+
+	// Messages waiting to be written to a player before their link counts
+	// as backed up
+	pacerBacklog = 8
+
+	// How far over the best round trip time a player's can go before it's
+	// a spike, on top of twice the best
+	pacerRTTSlack = 50 * time.Millisecond
+)
+
+// SnapshotPacer decides how often the host sends each player its state.
+// Players whose messages back up, or whose round trip time spikes, get one
+// every few ticks instead of every tick until their link settles, so the
+// game stays playable on links that can't keep up.
+type SnapshotPacer struct {
+	mu      sync.Mutex
+	players map[string]*snapshotPace
+}
+
+type snapshotPace struct {
+	// Ticks between snapshots
+	every int
+
+	// Calm checks in a row
+	calm int
+
+	// Best round trip time seen, to tell spikes from a link that's always
+	// slow
+	bestRTT time.Duration
+}
+
+func NewSnapshotPacer() *SnapshotPacer {
+	return &SnapshotPacer{players: make(map[string]*snapshotPace)}
+}
+
+// Due returns whether the player should be sent the state for the tick,
+// given how many messages are waiting to be written to them and their
+// current round trip time, or a negative one if it isn't known.
+func (p *SnapshotPacer) Due(playerID string, tick, backlog int, rtt time.Duration) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pace, ok := p.players[playerID]
+
+	if !ok {
+		pace = &snapshotPace{every: 1}
+		p.players[playerID] = pace
+	}
+
+	if rtt >= 0 && (pace.bestRTT == 0 || rtt < pace.bestRTT) {
+		pace.bestRTT = rtt
+	}
+
+	if tick%pacerCheckTicks == 0 {
+		spike := rtt >= 0 && rtt > 2*pace.bestRTT+pacerRTTSlack
+
+		switch {
+		case backlog >= pacerBacklog || spike:
+			pace.calm = 0
+
+			if pace.every < maxSnapshotEvery {
+				pace.every++
+			}
+		case pace.every > 1:
+			pace.calm++
+
+			if pace.calm >= pacerRecoverChecks {
+				pace.calm = 0
+				pace.every--
+			}
+		}
+	}
+
+	return tick%pace.every == 0
+}
@@ -0,0 +1,61 @@
+package arcade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnapshotPacer(t *testing.T) {
+	p := NewSnapshotPacer()
+	rtt := 30 * time.Millisecond
+
+	sent := func(from, to, backlog int, rtt time.Duration) int {
+		n := 0
+
+		for tick := from; tick < to; tick++ {
+			if p.Due("a", tick, backlog, rtt) {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	if n := sent(0, 20, 0, rtt); n != 20 {
+		t.Errorf("calm link got %d of 20 ticks", n)
+	}
+
+	// A backed up queue slows snapshots down a step every check, as far as
+	// maxSnapshotEvery
+	sent(20, 100, pacerBacklog, rtt)
+
+	if n := sent(100, 120, pacerBacklog, rtt); n != 20/maxSnapshotEvery {
+		t.Errorf("backed up link got %d of 20 ticks", n)
+	}
+
+	// So does a spike in round trip time, but not a link that's always slow
+	q := NewSnapshotPacer()
+
+	for tick := 0; tick < 50; tick++ {
+		q.Due("slow", tick, 0, 300*time.Millisecond)
+		q.Due("spiky", tick, 0, rtt)
+	}
+
+	for tick := 50; tick < 60; tick++ {
+		q.Due("slow", tick, 0, 300*time.Millisecond)
+		q.Due("spiky", tick, 0, 300*time.Millisecond)
+	}
+
+	if q.Due("slow", 61, 0, 300*time.Millisecond) != true || q.Due("spiky", 61, 0, 300*time.Millisecond) {
+		t.Error("spike wasn't told apart from a slow link")
+	}
+
+	// Once the link settles, it's sped back up a step at a time
+	if n := sent(120, 120+pacerCheckTicks*pacerRecoverChecks*maxSnapshotEvery, 0, rtt); n == 0 {
+		t.Error("settled link got nothing")
+	}
+
+	if n := sent(300, 320, 0, rtt); n != 20 {
+		t.Errorf("recovered link got %d of 20 ticks", n)
+	}
+}
@@ -0,0 +1,186 @@
+package arcade
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// SoundEvent is something in the arcade that can make a sound.
+type SoundEvent string
+
+const (
+	SoundScore     SoundEvent = "score"
+	SoundCollision SoundEvent = "collision"
+	SoundCountdown SoundEvent = "countdown"
+	SoundChat      SoundEvent = "chat"
+	SoundNotify    SoundEvent = "notify"
+)
+
+const (
+	// Bells ring from the terminal, and never need anything installed
+	SoundBackendBell = "bell"
+
+	// System sounds are played with the OS's audio player if there is one,
+	// otherwise the bell rings instead
+	SoundBackendSystem = "system"
+)
+
+var soundBackends = []string{SoundBackendSystem, SoundBackendBell}
+
+// soundPattern is how an event sounds, as a number of bells or a system
+// sound.
+type soundPattern struct {
+	Help  string
+	Bells int
+	Gap   time.Duration
+
+	// System sound names, without extension, on macOS, freedesktop systems
+	// and Windows
+	MacSound     string
+	LinuxSound   string
+	WindowsSound string
+}
+
+var soundEvents = []SoundEvent{SoundScore, SoundCollision, SoundCountdown, SoundChat, SoundNotify}
+
+var soundPatterns = map[SoundEvent]soundPattern{
+	SoundScore:     {"Point scored", 2, 120 * time.Millisecond, "Glass", "complete", "Asterisk"},
+	SoundCollision: {"Crash", 3, 60 * time.Millisecond, "Basso", "dialog-warning", "Hand"},
+	SoundCountdown: {"Countdown", 1, 0, "Tink", "bell", "Beep"},
+	SoundChat:      {"Chat message", 1, 0, "Pop", "message-new-instant", "Question"},
+	SoundNotify:    {"Notification", 1, 0, "Ping", "message", "Exclamation"},
+}
+
+const linuxSoundDir = "/usr/share/sounds/freedesktop/stereo"
+
+// SoundManager plays sounds for game events, following the player's
+// settings.
+type SoundManager struct {
+	mu sync.Mutex
+
+	// Rings the terminal bell
+	bell func()
+
+	enabled bool
+	backend string
+	muted   map[SoundEvent]bool
+
+	// Only one sound plays at a time, anything else is dropped
+	playing bool
+}
+
+func NewSoundManager(bell func()) *SoundManager {
+	return &SoundManager{
+		bell:  bell,
+		muted: make(map[SoundEvent]bool),
+	}
+}
+
+// Apply picks up the sound settings.
+func (m *SoundManager) Apply(config *Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.enabled = config.Sound
+	m.backend = config.SoundBackend
+	m.muted = make(map[SoundEvent]bool)
+
+	for _, event := range soundEvents {
+		m.muted[event] = !soundEventOn(config, event)
+	}
+}
+
+// soundEventOn returns false if the player turned the event's sound off.
+// Events are on unless they say otherwise.
+func soundEventOn(config *Config, event SoundEvent) bool {
+	on, ok := config.SoundEvents[string(event)]
+	return !ok || on
+}
+
+// Play makes the event's sound, unless sound is off for it.
+func (m *SoundManager) Play(event SoundEvent) {
+	m.mu.Lock()
+
+	if !m.enabled || m.muted[event] || m.playing {
+		m.mu.Unlock()
+		return
+	}
+
+	m.playing = true
+	backend := m.backend
+	m.mu.Unlock()
+
+	go func() {
+		m.play(event, backend)
+
+		m.mu.Lock()
+		m.playing = false
+		m.mu.Unlock()
+	}()
+}
+
+// Preview makes the event's sound even if it's turned off, so the player can
+// hear what they're choosing.
+func (m *SoundManager) Preview(event SoundEvent, backend string) {
+	go m.play(event, backend)
+}
+
+func (m *SoundManager) play(event SoundEvent, backend string) {
+	pattern, ok := soundPatterns[event]
+
+	if !ok {
+		return
+	}
+
+	if backend == SoundBackendSystem {
+		if cmd := systemSoundCommand(pattern); cmd != nil && cmd.Run() == nil {
+			return
+		}
+	}
+
+	for i := 0; i < pattern.Bells; i++ {
+		if i > 0 {
+			time.Sleep(pattern.Gap)
+		}
+
+		m.bell()
+	}
+}
+
+// playSound plays the event's sound, if the player has it on. Distributors
+// stay quiet.
+func playSound(event SoundEvent) {
+	if arcade.Distributor || arcade.Server == nil || arcade.Server.mgr == nil {
+		return
+	}
+
+	arcade.Server.mgr.Sounds.Play(event)
+}
+
+// systemSoundCommand returns a command that plays the sound with the OS's
+// audio player, or nil if there isn't one.
+func systemSoundCommand(pattern soundPattern) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("afplay"); err == nil {
+			return exec.Command("afplay", "/System/Library/Sounds/"+pattern.MacSound+".aiff")
+		}
+	case "linux":
+		file := linuxSoundDir + "/" + pattern.LinuxSound + ".oga"
+
+		if _, err := os.Stat(file); err != nil {
+			return nil
+		}
+
+		if _, err := exec.LookPath("paplay"); err == nil {
+			return exec.Command("paplay", file)
+		}
+	case "windows":
+		return exec.Command("powershell", "-NoProfile", "-Command", "[System.Media.SystemSounds]::"+pattern.WindowsSound+".Play()")
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"arcade/arcade/widgets"
+	"encoding"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// SoundsView picks how sounds play and which events make them. Changes are
+// saved as they're made, like keybindings.
+type SoundsView struct {
+	View
+	mgr *ViewManager
+
+	backend *widgets.Select
+	events  map[SoundEvent]*widgets.Checkbox
+
+	focus *widgets.FocusGroup
+}
+
+var soundsFooter = "↑/↓ Move    ←/→ Change    Enter Toggle    [B]ack"
+
+func NewSoundsView(mgr *ViewManager) *SoundsView {
+	config := mgr.Config()
+	v := &SoundsView{
+		mgr:    mgr,
+		events: make(map[SoundEvent]*widgets.Checkbox),
+	}
+
+	v.backend = widgets.NewSelect(settingsWidgetX, settingsY, settingsWidth, soundBackends)
+	v.backend.SetValue(config.SoundBackend)
+	v.backend.OnChange = func(backend string) {
+		v.save()
+		v.mgr.Sounds.Preview(SoundNotify, backend)
+	}
+
+	focusable := []widgets.Widget{v.backend}
+
+	for i, event := range soundEvents {
+		event := event
+		cb := widgets.NewCheckbox(settingsWidgetX, settingsY+2+i, settingsWidth, "", soundEventOn(config, event))
+
+		cb.OnChange = func(on bool) {
+			v.save()
+
+			if on {
+				v.mgr.Sounds.Preview(event, v.backend.Value())
+			}
+		}
+
+		v.events[event] = cb
+		focusable = append(focusable, cb)
+	}
+
+	v.focus = widgets.NewFocusGroup(focusable...)
+
+	return v
+}
+
+func (v *SoundsView) Widgets() *widgets.FocusGroup {
+	return v.focus
+}
+
+func (v *SoundsView) save() {
+	config := *v.mgr.Config()
+	config.SoundBackend = v.backend.Value()
+	config.SoundEvents = make(map[string]bool)
+
+	for event, cb := range v.events {
+		// Only what's turned off is written, so new events start on
+		if !cb.Checked() {
+			config.SoundEvents[string(event)] = false
+		}
+	}
+
+	if err := config.Save(); err != nil {
+		notify("Couldn't save sound settings")
+	}
+
+	v.mgr.ApplyConfig(&config)
+}
+
+func (v *SoundsView) Init() {
+}
+
+func (v *SoundsView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		switch evt.Key() {
+		case tcell.KeyUp:
+			v.focus.Prev()
+		case tcell.KeyDown:
+			v.focus.Next()
+		case tcell.KeyRune:
+			if evt.Rune() == 'b' {
+				v.mgr.PopView()
+			}
+		}
+	}
+}
+
+func (v *SoundsView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *SoundsView) Render(s *Screen) {
+	width, height := s.displaySize()
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	noteSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorDarkGreen)
+
+	s.DrawBlockText(CenterX, 1, sty, "SOUNDS", false)
+	s.DrawText(settingsLabelX, settingsY, sty, "Play with")
+
+	for i, event := range soundEvents {
+		s.DrawText(settingsLabelX, settingsY+2+i, sty, soundPatterns[event].Help)
+	}
+
+	v.focus.Render(s)
+
+	if !v.mgr.Config().Sound {
+		note := "Sound is off in settings, so nothing will play."
+		s.DrawText(layout.Center(width, note), settingsY+len(soundEvents)+3, noteSty, note)
+	}
+
+	s.DrawText(layout.Center(width, soundsFooter), height-2, sty, soundsFooter)
+}
+
+func (v *SoundsView) Unload() {
+}
+
+func (v *SoundsView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
@@ -0,0 +1,140 @@
+package arcade
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// How often the host of a spectatable game sends the distributor its state
+	spectateInterval = 100 * time.Millisecond
+
+	// Most a state may decompress to, so one frame can't eat a watcher's memory
+	maxSpectateStateSize = 1 << 20
+)
+
+// SpectateInfo describes a game being streamed through the distributor.
+type SpectateInfo struct {
+	GameID    string
+	LobbyName string
+	GameType  string
+	HostID    string
+	PlayerIDs []string
+	Names     map[string]string `json:",omitempty"`
+
+	// Hash of the custom Tron map the game's played on. Its walls are only
+	// shown to spectators who have it
+	MapHash string `json:",omitempty"`
+
+	// Filled in by the distributor when listing games
+	Spectators int `json:",omitempty"`
+}
+
+// spectateStream sends the state of a game we host to the distributor, which
+// passes it on to everyone watching. Our uplink carries one copy however many
+// are watching.
+type spectateStream struct {
+	mu sync.Mutex
+
+	info     SpectateInfo
+	seq      int
+	lastSent time.Time
+	ended    bool
+}
+
+// newSpectateStream returns the stream for the lobby's game, or nil if the
+// lobby isn't open to spectators or isn't ours. Expects the lobby's lock to be
+// held.
+func newSpectateStream(lobby *Lobby) *spectateStream {
+	if !lobby.Spectatable || lobby.Private || lobby.HostID != arcade.Server.ID {
+		return nil
+	}
+
+	playerIDs := make([]string, len(lobby.PlayerIDs))
+	copy(playerIDs, lobby.PlayerIDs)
+
+	names := make(map[string]string, len(lobby.Names))
+
+	for id, name := range lobby.Names {
+		names[id] = name
+	}
+
+	return &spectateStream{
+		info: SpectateInfo{
+			GameID:    lobby.ID,
+			LobbyName: lobby.Name,
+			GameType:  lobby.GameType,
+			HostID:    lobby.HostID,
+			PlayerIDs: playerIDs,
+			Names:     names,
+		},
+	}
+}
+
+// send streams the state if a frame is due. The last state is always sent,
+// so spectators see how the game ended. It's safe to call on a nil stream.
+func (st *spectateStream) send(state interface{}, ended bool) {
+	if st == nil {
+		return
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.ended || (!ended && time.Since(st.lastSent) < spectateInterval) {
+		return
+	}
+
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		return
+	}
+
+	// Encoded now, since the game goes on changing the state once we return
+	data, err := encodeSpectateState(state)
+
+	if err != nil {
+		return
+	}
+
+	st.seq++
+	st.lastSent = time.Now()
+	st.ended = ended
+
+	go arcade.Server.Network.Send(distributor, NewSpectateFrameMessage(st.info, st.seq, data, ended))
+}
+
+// encodeSpectateState compresses a game state for spectators.
+func encodeSpectateState(state interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+
+	if err := json.NewEncoder(w).Encode(state); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeSpectateState reads a state compressed by encodeSpectateState.
+func decodeSpectateState(data []byte, state interface{}) error {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+
+	if err != nil {
+		return err
+	}
+
+	defer r.Close()
+
+	return json.NewDecoder(io.LimitReader(r, maxSpectateStateSize)).Decode(state)
+}
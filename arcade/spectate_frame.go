@@ -0,0 +1,46 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"arcade/arcade/net"
+	"encoding/json"
+)
+
+// SpectateFrameMessage is a game's compressed state, sent by its host to the
+// distributor and passed on by the distributor to each spectator.
+type SpectateFrameMessage struct {
+	message.Message
+	Info SpectateInfo
+	Seq  int
+	Data []byte
+
+	// Set on the last frame of the game
+	Ended bool `json:",omitempty"`
+}
+
+func NewSpectateFrameMessage(info SpectateInfo, seq int, data []byte, ended bool) *SpectateFrameMessage {
+	return &SpectateFrameMessage{
+		Message: message.Message{Type: "spectate_frame"},
+		Info:    info,
+		Seq:     seq,
+		Data:    data,
+		Ended:   ended,
+	}
+}
+
+func (m SpectateFrameMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// SendPriority lets frames be dropped under load, but not the last one.
+func (m SpectateFrameMessage) SendPriority() net.Priority {
+	if m.Ended {
+		return net.PriorityControl
+	}
+
+	return net.PriorityState
+}
+
+func (m SpectateFrameMessage) CoalesceKey() string {
+	return "spectate_frame:" + m.Info.GameID
+}
@@ -0,0 +1,23 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// SpectateListMessage is the distributor's reply to a SpectateQueryMessage.
+type SpectateListMessage struct {
+	message.Message
+	Games []SpectateInfo
+}
+
+func NewSpectateListMessage(games []SpectateInfo) *SpectateListMessage {
+	return &SpectateListMessage{
+		Message: message.Message{Type: "spectate_list"},
+		Games:   games,
+	}
+}
+
+func (m SpectateListMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,21 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// SpectateQueryMessage asks the distributor which games can be watched.
+type SpectateQueryMessage struct {
+	message.Message
+}
+
+func NewSpectateQueryMessage() *SpectateQueryMessage {
+	return &SpectateQueryMessage{
+		Message: message.Message{Type: "spectate_query"},
+	}
+}
+
+func (m SpectateQueryMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,197 @@
+package arcade
+
+import (
+	"arcade/arcade/net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Games whose host hasn't sent a frame in this long are no longer listed
+const spectateTimeout = 5 * time.Second
+
+type spectatedGame struct {
+	last        *SpectateFrameMessage
+	lastSeen    time.Time
+	subscribers map[string]bool
+}
+
+// SpectateRelay is the distributor's side of spectating. Hosts of spectatable
+// games send it their frames once, and it passes each one on to everyone
+// watching.
+type SpectateRelay struct {
+	mu sync.Mutex
+
+	network *net.Network
+	shedder *loadShedder
+
+	games map[string]*spectatedGame
+}
+
+func NewSpectateRelay(network *net.Network, shedder *loadShedder) *SpectateRelay {
+	return &SpectateRelay{
+		network: network,
+		shedder: shedder,
+		games:   make(map[string]*spectatedGame),
+	}
+}
+
+// handleMessage processes messages addressed to the distributor itself. The
+// second return value is false if the message isn't about spectating.
+func (r *SpectateRelay) handleMessage(c *net.Client, msg interface{}) (interface{}, bool) {
+	switch msg := msg.(type) {
+	case *SpectateFrameMessage:
+		if msg.Info.HostID != msg.SenderID || !r.publish(msg) {
+			return NewErrorMessage("invalid frame"), true
+		}
+
+		return nil, true
+	case *SpectateSubscribeMessage:
+		return r.subscribe(msg.SenderID, msg.GameID, msg.Unsubscribe), true
+	case *SpectateQueryMessage:
+		return NewSpectateListMessage(r.list()), true
+	}
+
+	return nil, false
+}
+
+// publish records the game's latest frame and passes it on. Returns false if
+// the game belongs to someone else.
+func (r *SpectateRelay) publish(frame *SpectateFrameMessage) bool {
+	r.mu.Lock()
+	r.expire()
+
+	game, ok := r.games[frame.Info.GameID]
+
+	if !ok {
+		game = &spectatedGame{subscribers: make(map[string]bool)}
+		r.games[frame.Info.GameID] = game
+	} else if game.last.Info.HostID != frame.SenderID {
+		r.mu.Unlock()
+		return false
+	} else if frame.Seq <= game.last.Seq {
+		// Overtaken by a newer frame on the way here
+		r.mu.Unlock()
+		return true
+	}
+
+	game.last = frame
+	game.lastSeen = time.Now()
+
+	subscribers := make([]string, 0, len(game.subscribers))
+
+	for id := range game.subscribers {
+		subscribers = append(subscribers, id)
+	}
+
+	if frame.Ended {
+		delete(r.games, frame.Info.GameID)
+	}
+	r.mu.Unlock()
+
+	gone := r.fanOut(frame, subscribers)
+
+	if len(gone) > 0 {
+		r.mu.Lock()
+		for _, id := range gone {
+			if game, ok := r.games[frame.Info.GameID]; ok {
+				delete(game.subscribers, id)
+			}
+		}
+		r.mu.Unlock()
+	}
+
+	return true
+}
+
+// fanOut sends the frame to each subscriber, and returns the ones that are no
+// longer connected. Frames count against the relay cap like any other relayed
+// message, so a popular game gives way before lobbies do.
+func (r *SpectateRelay) fanOut(frame *SpectateFrameMessage, subscribers []string) []string {
+	gone := make([]string, 0)
+	priority := frame.SendPriority()
+
+	for _, id := range subscribers {
+		client, ok := r.network.GetClient(id)
+
+		if !ok {
+			gone = append(gone, id)
+			continue
+		}
+
+		if !r.shedder.admitRelay(len(frame.Data), priority) {
+			continue
+		}
+
+		out := *frame
+
+		if !r.network.Send(client, &out) {
+			gone = append(gone, id)
+		}
+	}
+
+	return gone
+}
+
+// subscribe adds or removes the spectator, and replies with the game's latest
+// frame so they have something to show straight away.
+func (r *SpectateRelay) subscribe(spectatorID, gameID string, unsubscribe bool) interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expire()
+
+	game, ok := r.games[gameID]
+
+	if unsubscribe {
+		if ok {
+			delete(game.subscribers, spectatorID)
+		}
+
+		return nil
+	}
+
+	if !ok {
+		return NewErrorMessage("no such game")
+	}
+
+	game.subscribers[spectatorID] = true
+
+	frame := *game.last
+	return &frame
+}
+
+// list returns the games that can be watched, most watched first.
+func (r *SpectateRelay) list() []SpectateInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expire()
+
+	games := make([]SpectateInfo, 0, len(r.games))
+
+	for _, game := range r.games {
+		info := game.last.Info
+		info.Spectators = len(game.subscribers)
+		games = append(games, info)
+	}
+
+	sort.Slice(games, func(i, j int) bool {
+		if games[i].Spectators != games[j].Spectators {
+			return games[i].Spectators > games[j].Spectators
+		}
+
+		return games[i].LobbyName < games[j].LobbyName
+	})
+
+	return games
+}
+
+// expire drops games whose host has gone quiet. Expects the lock to be held.
+func (r *SpectateRelay) expire() {
+	for id, game := range r.games {
+		if time.Since(game.lastSeen) > spectateTimeout {
+			delete(r.games, id)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// SpectateSubscribeMessage asks the distributor to start, or stop, passing on
+// a game's frames.
+type SpectateSubscribeMessage struct {
+	message.Message
+	GameID      string
+	Unsubscribe bool `json:",omitempty"`
+}
+
+func NewSpectateSubscribeMessage(gameID string, unsubscribe bool) *SpectateSubscribeMessage {
+	return &SpectateSubscribeMessage{
+		Message:     message.Message{Type: "spectate_subscribe"},
+		GameID:      gameID,
+		Unsubscribe: unsubscribe,
+	}
+}
+
+func (m SpectateSubscribeMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,380 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// SpectateView shows a game streamed through the distributor, to someone who
+// isn't playing in it, or plays back a replay.
+type SpectateView struct {
+	View
+	mgr *ViewManager
+
+	mu sync.RWMutex
+
+	info    SpectateInfo
+	seq     int
+	started bool
+	ended   bool
+	errMsg  string
+
+	// The latest state of whichever game it is
+	pong PongGameState
+	tron TronGameState
+
+	// Caster overlays, the camera (0 for the whole game, or a player by
+	// their place in the lobby plus one) and what's happened so far
+	casting bool
+	camera  int
+	history []string
+
+	// Whether Tron is split between the camera's player and the next
+	split bool
+
+	// Custom Tron map, if the game's on one we have
+	arena *TronMap
+
+	// Set when playing back a replay instead of watching live
+	replay *Replay
+	stopCh chan bool
+}
+
+func NewSpectateView(mgr *ViewManager, info SpectateInfo) *SpectateView {
+	return &SpectateView{
+		mgr:  mgr,
+		info: info,
+	}
+}
+
+// NewReplayView plays back a replay the same way a live game is watched.
+func NewReplayView(mgr *ViewManager, replay *Replay) *SpectateView {
+	return &SpectateView{
+		mgr: mgr,
+		info: SpectateInfo{
+			GameID:    replay.Info.ID,
+			LobbyName: replay.Info.LobbyName,
+			GameType:  replay.Info.GameType,
+			PlayerIDs: replay.Info.PlayerIDs,
+			Names:     replay.Info.Names,
+		},
+		arena:  replay.Map,
+		replay: replay,
+		stopCh: make(chan bool),
+	}
+}
+
+func (v *SpectateView) Init() {
+	if v.info.MapHash != "" {
+		v.arena, _ = findTronMap(v.info.MapHash)
+	}
+
+	if v.replay != nil {
+		go v.play()
+	} else {
+		go v.subscribe()
+	}
+}
+
+// play shows the replay's frames as they were recorded, until it's over or
+// the view is left.
+func (v *SpectateView) play() {
+	start := time.Now()
+	last := len(v.replay.Frames) - 1
+
+	for i, frame := range v.replay.Frames {
+		select {
+		case <-time.After(time.Until(start.Add(frame.At))):
+		case <-v.stopCh:
+			return
+		}
+
+		state := frame.State
+		v.applyState(i+1, i == last, nil, func(dst interface{}) error {
+			return json.Unmarshal(state, dst)
+		})
+	}
+}
+
+// subscribe asks the distributor for the game's frames. It replies with the
+// latest one.
+func (v *SpectateView) subscribe() {
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		v.fail("Not connected to the distributor.")
+		return
+	}
+
+	res, err := arcade.Server.Network.SendAndReceive(distributor, NewSpectateSubscribeMessage(v.info.GameID, false))
+
+	if err != nil {
+		v.fail("The distributor didn't answer.")
+		return
+	}
+
+	switch res := res.(type) {
+	case *SpectateFrameMessage:
+		v.applyFrame(res)
+	default:
+		v.fail("That game has ended.")
+	}
+}
+
+func (v *SpectateView) fail(msg string) {
+	v.mu.Lock()
+	v.errMsg = msg
+	v.mu.Unlock()
+
+	v.mgr.RequestRender()
+}
+
+// applyFrame shows the frame if it's newer than the one we have.
+func (v *SpectateView) applyFrame(frame *SpectateFrameMessage) {
+	if frame.Info.GameID != v.info.GameID {
+		return
+	}
+
+	// The host's names are newer than the list's
+	v.applyState(frame.Seq, frame.Ended, frame.Info.Names, func(state interface{}) error {
+		return decodeSpectateState(frame.Data, state)
+	})
+}
+
+// applyState shows the state read by decode, if its sequence number is newer
+// than the one we have.
+func (v *SpectateView) applyState(seq int, ended bool, names map[string]string, decode func(state interface{}) error) {
+	v.mu.Lock()
+
+	if seq <= v.seq {
+		v.mu.Unlock()
+		return
+	}
+
+	var err error
+	prevPong, prevTron := v.pong, v.tron
+
+	switch v.info.GameType {
+	case Pong:
+		var state PongGameState
+		if err = decode(&state); err == nil {
+			v.pong = state
+		}
+	case Tron:
+		var state TronGameState
+		if err = decode(&state); err == nil {
+			v.tron = state
+		}
+	}
+
+	if err != nil {
+		v.mu.Unlock()
+		return
+	}
+
+	if names != nil {
+		v.info.Names = names
+	}
+
+	v.seq = seq
+	v.ended = ended
+
+	if v.started {
+		v.recordRounds(prevPong, prevTron)
+	}
+
+	v.started = true
+	v.mu.Unlock()
+
+	v.mgr.RequestRender()
+}
+
+func (v *SpectateView) Keymap() *Keymap {
+	return spectateKeymap
+}
+
+func (v *SpectateView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		switch spectateKeymap.Action(evt) {
+		case ActionCast:
+			v.mu.Lock()
+			v.casting = !v.casting
+			v.mu.Unlock()
+
+			v.mgr.RequestRender()
+		case ActionCameraPrev:
+			v.moveCamera(-1)
+		case ActionCameraNext:
+			v.moveCamera(1)
+		case ActionSplit:
+			v.mu.Lock()
+			v.split = !v.split
+			v.mu.Unlock()
+
+			// Split screen draws outside the display
+			v.mgr.screen.Reset()
+			v.mgr.RequestRender()
+		case ActionInset:
+			v.mgr.PopToInset(v.info.LobbyName)
+		}
+	}
+}
+
+func (v *SpectateView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	switch p := p.(type) {
+	case *SpectateFrameMessage:
+		v.applyFrame(p)
+	}
+
+	return nil
+}
+
+// playerName returns the name the host knows the player by, through the
+// profanity filter.
+func (v *SpectateView) playerName(playerID string) string {
+	if name := v.info.Names[playerID]; name != "" {
+		return filterText(name)
+	}
+
+	return shortID(playerID, 8)
+}
+
+func (v *SpectateView) Render(s *Screen) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	width, height := s.displaySize()
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	boxStyle := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorTeal)
+
+	if !v.started {
+		s.ClearContent()
+
+		msg := v.errMsg
+
+		if msg == "" {
+			msg = fmt.Sprintf("Tuning in to %s...", v.info.LobbyName)
+		}
+
+		s.DrawText(layout.Center(width, msg), height/2, sty, msg)
+		return
+	}
+
+	camera := v.cameraID()
+
+	switch v.info.GameType {
+	case Pong:
+		renderPongCourt(s, v.pong, v.info.PlayerIDs, camera)
+	case Tron:
+		if panes := v.splitPanes(); panes != nil {
+			s.DrawSplit(panes)
+		} else {
+			v.renderTron(s, camera)
+		}
+	}
+
+	if v.casting {
+		v.renderCaster(s)
+	}
+
+	if v.ended {
+		s.DrawBlockText(CenterX, CenterY, boxStyle, "GAME OVER", true)
+
+		if winner := v.winner(); winner != "" {
+			msg := v.playerName(winner) + " won"
+			s.DrawText(layout.Center(width, msg), height-6, boxStyle, msg)
+		}
+	}
+
+	label := fmt.Sprintf(" Watching %s ", v.info.LobbyName)
+
+	if v.replay != nil {
+		label = fmt.Sprintf(" Replay of %s ", v.info.LobbyName)
+	}
+
+	s.DrawText(width-layout.Width(label)-3, height-2, sty.Reverse(true), label)
+
+	cameraLabel := " Camera: whole game "
+
+	if camera != "" {
+		cameraLabel = fmt.Sprintf(" Camera: %s ", v.playerName(camera))
+	}
+
+	if v.casting || camera != "" {
+		s.DrawText(3, height-2, sty, cameraLabel+"[←/→] ")
+	}
+}
+
+// renderTron draws the arena with the camera following the player, or the
+// whole game for "". Expects the lock to be held.
+func (v *SpectateView) renderTron(s *Screen, focus string) {
+	width, height := s.displaySize()
+	boxStyle := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorTeal)
+
+	s.ClearContent()
+	s.DrawBox(1, 1, width-2, height-2, boxStyle, false)
+
+	tg := &TronGameView{
+		mgr:              v.mgr,
+		Game:             Game[TronGameState, TronClientState]{PlayerIDs: v.info.PlayerIDs},
+		WorkingGameState: v.tron,
+		focus:            focus,
+		arena:            v.arena,
+	}
+	tg.renderGame(s)
+}
+
+// splitPanes returns views following two players, starting from the
+// camera's, or nil if the game isn't split. Expects the lock to be held.
+func (v *SpectateView) splitPanes() []splitPane {
+	if !v.split || v.info.GameType != Tron || len(v.info.PlayerIDs) < 2 {
+		return nil
+	}
+
+	first := v.camera - 1
+
+	if first < 0 {
+		first = 0
+	}
+
+	panes := make([]splitPane, 2)
+
+	for i := range panes {
+		playerID := v.info.PlayerIDs[(first+i)%len(v.info.PlayerIDs)]
+		status := "Riding"
+
+		if !v.tron.ClientStates[playerID].Alive {
+			status = "Crashed"
+		}
+
+		panes[i] = splitPane{
+			Render: func(s *Screen) { v.renderTron(s, playerID) },
+			HUD:    fmt.Sprintf(" %s  %s ", v.playerName(playerID), status),
+		}
+	}
+
+	return panes
+}
+
+func (v *SpectateView) Unload() {
+	if v.replay != nil {
+		close(v.stopCh)
+		return
+	}
+
+	if distributor, ok := arcade.Server.Network.GetDistributor(); ok {
+		go arcade.Server.Network.Send(distributor, NewSpectateSubscribeMessage(v.info.GameID, true))
+	}
+}
+
+func (v *SpectateView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
@@ -1,6 +1,7 @@
 package arcade
 
 import (
+	"arcade/arcade/layout"
 	"arcade/arcade/net"
 	"encoding"
 	"sync"
@@ -13,43 +14,83 @@ type SplashView struct {
 	View
 	mgr *ViewManager
 
-	mu            sync.RWMutex
-	displayFooter bool
-	stopTickerCh  chan bool
+	mu           sync.RWMutex
+	frame        int
+	titleY       *Tween
+	subtitleY    *Tween
+	stopTickerCh chan bool
+
+	// Set while drawing's too slow to keep up, when the lights stop chasing
+	// and the footer stops blinking
+	degraded bool
 }
 
 var splashFooter = "Press any key to start"
 
+// Marquee bounds, inclusive. The title is only drawn inside the lights.
+const (
+	splashMarqueeX1 = 12
+	splashMarqueeY1 = 2
+	splashMarqueeX2 = displayWidth - 13
+	splashMarqueeY2 = 17
+
+	splashTitleY    = 4
+	splashSubtitleY = 10
+
+	splashFrameTime = 100 * time.Millisecond
+)
+
 func NewSplashView(mgr *ViewManager) *SplashView {
-	view := &SplashView{
-		mgr:           mgr,
-		displayFooter: true,
-		stopTickerCh:  make(chan bool),
+	return &SplashView{
+		mgr:          mgr,
+		stopTickerCh: make(chan bool),
+	}
+}
+
+func (v *SplashView) Init() {
+	// Without the animation, the title starts where it ends up
+	still := v.mgr.Announcer.Enabled()
+	titleTime, subtitleTime := 900*time.Millisecond, 700*time.Millisecond
+
+	if still {
+		titleTime, subtitleTime = 0, 0
+	}
+
+	v.mu.Lock()
+	v.titleY = NewTween(splashMarqueeY1-6, splashTitleY, titleTime, EaseOutBounce)
+	v.subtitleY = NewTween(splashMarqueeY2+1, splashSubtitleY, subtitleTime, EaseOutBack)
+	v.mu.Unlock()
+
+	if still {
+		announce("ASCII Arcade. %s.", splashFooter)
+		return
 	}
 
-	ticker := time.NewTicker(750 * time.Millisecond)
+	ticker := time.NewTicker(splashFrameTime)
 
 	go func() {
 		for {
 			select {
 			case <-ticker.C:
-				view.mu.Lock()
-				view.displayFooter = !view.displayFooter
-				view.mu.Unlock()
-
-				view.mgr.RequestRender()
-			case <-view.stopTickerCh:
+				v.mu.Lock()
+				degraded := v.degraded
+
+				if !degraded {
+					v.frame++
+				}
+				v.mu.Unlock()
+
+				if !degraded {
+					v.mgr.RequestRender()
+				}
+			case <-v.stopTickerCh:
 				ticker.Stop()
 				return
 			}
 		}
 	}()
 
-	return view
-}
-
-func (v *SplashView) Init() {
-
+	v.mgr.Animate(time.Second)
 }
 
 func (v *SplashView) ProcessEvent(evt interface{}) {
@@ -73,26 +114,90 @@ func (v *SplashView) Render(s *Screen) {
 	// Green text on default background
 	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
 
-	// Draw ASCII ARCADE header
-	s.DrawBlockText(CenterX, 3, sty, "ASCII", true)
-	s.DrawBlockText(CenterX, 10, sty, "ARCADE", true)
-
-	// Draw footer
 	v.mu.RLock()
 	defer v.mu.RUnlock()
 
-	footerX := (width - len(splashFooter)) / 2
+	// The title moves, so whatever it covered last frame has to go
+	s.ClearContent()
+
+	v.renderMarquee(s)
+
+	// Draw ASCII ARCADE header, dropping in from above and rising from below
+	drawClippedBlockText(s, v.titleY.Int(), sty, "ASCII")
+	drawClippedBlockText(s, v.subtitleY.Int(), sty, "ARCADE")
+
+	// Draw footer
+	footerX := layout.Center(width, splashFooter)
 	footerY := 20
 
-	if v.displayFooter {
+	if v.frame/7%2 == 0 || v.degraded {
 		s.DrawText(footerX, footerY, sty, splashFooter)
-	} else {
-		s.DrawEmpty(footerX, footerY, footerX+len(splashFooter), footerY, sty)
+	}
+}
+
+// SetDegraded stops the lights and the footer where they are while drawing's
+// too slow, and starts them again once it isn't.
+func (v *SplashView) SetDegraded(degraded bool) {
+	v.mu.Lock()
+	v.degraded = degraded
+	v.mu.Unlock()
+
+	v.mgr.RequestRender()
+}
+
+// renderMarquee draws the lights around the title, chasing each other
+// clockwise.
+func (v *SplashView) renderMarquee(s *Screen) {
+	litSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorLightGreen)
+	unlitSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorDarkGreen)
+
+	i := 0
+	bulb := func(x, y int) {
+		if (i-v.frame)%3 == 0 {
+			s.DrawText(x, y, litSty, "●")
+		} else {
+			s.DrawText(x, y, unlitSty, "○")
+		}
+
+		i++
+	}
+
+	for x := splashMarqueeX1; x < splashMarqueeX2; x += 2 {
+		bulb(x, splashMarqueeY1)
+	}
+
+	for y := splashMarqueeY1; y < splashMarqueeY2; y++ {
+		bulb(splashMarqueeX2, y)
+	}
+
+	for x := splashMarqueeX2; x > splashMarqueeX1; x -= 2 {
+		bulb(x, splashMarqueeY2)
+	}
+
+	for y := splashMarqueeY2; y > splashMarqueeY1; y-- {
+		bulb(splashMarqueeX1, y)
+	}
+}
+
+// drawClippedBlockText draws big block text centered, leaving out any rows
+// that fall outside the marquee.
+func drawClippedBlockText(s *Screen, y int, style tcell.Style, text string) {
+	width, _ := s.displaySize()
+	rows := generateText(text, true)
+	x := layout.Center(width, rows[0])
+
+	for i, row := range rows {
+		if y+i <= splashMarqueeY1 || y+i >= splashMarqueeY2 {
+			continue
+		}
+
+		s.DrawText(x, y+i, style, row)
 	}
 }
 
 func (v *SplashView) Unload() {
-	v.stopTickerCh <- true
+	// Doesn't block if the ticker is busy drawing a frame
+	close(v.stopTickerCh)
 }
 
 func (v *SplashView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
@@ -0,0 +1,109 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// splitPane is one view of a game in split screen, with a line of its own
+// under it for who it's following.
+type splitPane struct {
+	Render func(s *Screen)
+	HUD    string
+}
+
+// splitRect is where a pane goes, in terminal cells, its line included.
+type splitRect struct {
+	X, Y          int
+	Width, Height int
+}
+
+// perspectiveGame is a local game that can be drawn from each player's point
+// of view, for split screen.
+type perspectiveGame interface {
+	// RenderFor draws the game following the player
+	RenderFor(s *Screen, player int)
+
+	// Status says how the player's doing, for under their view
+	Status(player int) string
+}
+
+// splitLayout places n panes on a terminal of the size, side by side or
+// stacked, whichever keeps more of each. Panes are never bigger than the
+// display, so on a big enough terminal each is drawn at full size.
+func splitLayout(n, termWidth, termHeight int) []splitRect {
+	if n == 0 {
+		return nil
+	}
+
+	paneHeight := displayHeight + 1
+
+	sideWidth := minInt(termWidth/n, displayWidth)
+	sideHeight := minInt(termHeight, paneHeight)
+	stackWidth := minInt(termWidth, displayWidth)
+	stackHeight := minInt(termHeight/n, paneHeight)
+
+	stacked := stackWidth*stackHeight > sideWidth*sideHeight
+	rects := make([]splitRect, n)
+
+	if stacked {
+		x := (termWidth - stackWidth) / 2
+		y := (termHeight - n*stackHeight) / 2
+
+		for i := range rects {
+			rects[i] = splitRect{x, y + i*stackHeight, stackWidth, stackHeight}
+		}
+	} else {
+		x := (termWidth - n*sideWidth) / 2
+		y := (termHeight - sideHeight) / 2
+
+		for i := range rects {
+			rects[i] = splitRect{x + i*sideWidth, y, sideWidth, sideHeight}
+		}
+	}
+
+	return rects
+}
+
+// DrawSplit draws the panes across the whole terminal, each shrunk to fit
+// its place if it has to be. Their lines aren't shrunk, so they stay
+// readable.
+func (s *Screen) DrawSplit(panes []splitPane) {
+	termWidth, termHeight := s.Size()
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorTeal)
+	blank := tcell.StyleDefault.Background(tcell.ColorBlack)
+
+	for y := 0; y < termHeight; y++ {
+		for x := 0; x < termWidth; x++ {
+			s.SetContent(x, y, ' ', nil, blank)
+		}
+	}
+
+	for i, rect := range splitLayout(len(panes), termWidth, termHeight) {
+		canvas, f := s.Canvas()
+		panes[i].Render(canvas)
+
+		for row, cells := range f.scaled(rect.Width, rect.Height-1) {
+			for col, c := range cells {
+				s.SetContent(rect.X+col, rect.Y+row, c.primary, c.combining, c.style)
+			}
+		}
+
+		hud := layout.Truncate(panes[i].HUD, rect.Width)
+		x := rect.X + layout.Center(rect.Width, hud)
+
+		for _, r := range hud {
+			s.SetContent(x, rect.Y+rect.Height-1, r, nil, sty.Reverse(true))
+			x += layout.RuneWidth(r)
+		}
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
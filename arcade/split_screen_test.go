@@ -0,0 +1,31 @@
+package arcade
+
+import "testing"
+
+func TestSplitLayout(t *testing.T) {
+	tests := []struct {
+		name                  string
+		termWidth, termHeight int
+		want                  []splitRect
+	}{
+		{"wide enough for two", 170, 30, []splitRect{{5, 2, 80, 25}, {85, 2, 80, 25}}},
+		{"tall enough for two", 90, 60, []splitRect{{5, 5, 80, 25}, {5, 30, 80, 25}}},
+		{"smallest terminal", 80, 24, []splitRect{{0, 0, 40, 24}, {40, 0, 40, 24}}},
+		{"narrow and tall", 80, 40, []splitRect{{0, 0, 80, 20}, {0, 20, 80, 20}}},
+	}
+
+	for _, test := range tests {
+		got := splitLayout(2, test.termWidth, test.termHeight)
+
+		if len(got) != len(test.want) {
+			t.Fatalf("%s: got %v", test.name, got)
+		}
+
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package arcade
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	gonet "net"
+	"os"
+	"os/exec"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHServer hosts the arcade for players who don't have it, over SSH. Each
+// session runs its own arcade as a child process, with the session as its
+// terminal, so every player gets their own identity and settings as if
+// they'd installed it. Players who sign in with a key get theirs back each
+// time, and anyone else plays as a guest.
+type SSHServer struct {
+	config *ssh.ServerConfig
+
+	// Flags each session's arcade is started with
+	args []string
+
+	profiles *sessionProfiles
+}
+
+// Where the name of the key a player signed in with is kept in their
+// connection's permissions
+const sshKeyExtension = "arcade-key"
+
+type sshPtyRequest struct {
+	Term    string
+	Columns uint32
+	Rows    uint32
+	Width   uint32
+	Height  uint32
+	Modes   string
+}
+
+type sshWindowChange struct {
+	Columns uint32
+	Rows    uint32
+	Width   uint32
+	Height  uint32
+}
+
+type sshExitStatus struct {
+	Status uint32
+}
+
+// NewSSHServer makes a server that identifies itself with the host key, and
+// starts sessions' arcades with the flags in args.
+func NewSSHServer(hostKey ed25519.PrivateKey, args []string) (*SSHServer, error) {
+	signer, err := ssh.NewSignerFromKey(hostKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Like any arcade, anyone who can reach it can play. Any key is let
+	// in, and is who the player is. Clients without one are asked no
+	// questions.
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			return &ssh.Permissions{Extensions: map[string]string{sshKeyExtension: sshKeyName(key)}}, nil
+		},
+		KeyboardInteractiveCallback: func(conn ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			return &ssh.Permissions{}, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	return &SSHServer{
+		config:   config,
+		args:     args,
+		profiles: newSessionProfiles(),
+	}, nil
+}
+
+// ListenAndServe serves sessions on the address until it can't listen.
+func (s *SSHServer) ListenAndServe(addr string) error {
+	listener, err := gonet.Listen("tcp", addr)
+
+	if err != nil {
+		return err
+	}
+
+	defer listener.Close()
+
+	fmt.Printf("Serving SSH at %s...\n", listener.Addr())
+
+	for {
+		conn, err := listener.Accept()
+
+		if err != nil {
+			return err
+		}
+
+		go s.handleConn(conn)
+	}
+}
+
+func (s *SSHServer) handleConn(conn gonet.Conn) {
+	serverConn, channels, requests, err := ssh.NewServerConn(conn, s.config)
+
+	if err != nil {
+		log.Println("SSH handshake failed:", err)
+		return
+	}
+
+	defer serverConn.Close()
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only sessions are served")
+			continue
+		}
+
+		// Named after the key, so players get theirs back. The user name
+		// could be anyone's.
+		var profile string
+		var ok bool
+
+		if key := serverConn.Permissions.Extensions[sshKeyExtension]; key != "" {
+			profile, ok = s.profiles.claim("ssh", key)
+		} else {
+			profile, ok = s.profiles.claimGuest("ssh")
+		}
+
+		if !ok {
+			newChannel.Reject(ssh.ResourceShortage, "the arcade is full, try again later")
+			continue
+		}
+
+		go func(newChannel ssh.NewChannel) {
+			defer s.profiles.release(profile)
+			s.handleSession(newChannel, profile)
+		}(newChannel)
+	}
+}
+
+// sshKeyName names the public key, to keep the profile of whoever has it
+// under.
+func sshKeyName(key ssh.PublicKey) string {
+	sum := sha256.Sum256(key.Marshal())
+	return hex.EncodeToString(sum[:12])
+}
+
+// handleSession runs an arcade in the session once the client asks for a
+// shell, and ends it when either side goes away.
+func (s *SSHServer) handleSession(newChannel ssh.NewChannel, profile string) {
+	channel, requests, err := newChannel.Accept()
+
+	if err != nil {
+		return
+	}
+
+	defer channel.Close()
+
+	// Window sizes go to the session's arcade on a pipe of their own
+	sizesR, sizesW, err := os.Pipe()
+
+	if err != nil {
+		return
+	}
+
+	defer sizesR.Close()
+	defer sizesW.Close()
+
+	var term string
+	var cmd *exec.Cmd
+
+	for req := range requests {
+		ok := false
+
+		switch req.Type {
+		case "pty-req":
+			var pty sshPtyRequest
+
+			if ssh.Unmarshal(req.Payload, &pty) == nil {
+				term = pty.Term
+				fmt.Fprintf(sizesW, "%d %d\n", pty.Columns, pty.Rows)
+				ok = true
+			}
+		case "window-change":
+			var size sshWindowChange
+
+			if ssh.Unmarshal(req.Payload, &size) == nil {
+				fmt.Fprintf(sizesW, "%d %d\n", size.Columns, size.Rows)
+				ok = true
+			}
+		case "shell":
+			// The arcade needs a terminal to draw on
+			if cmd != nil || term == "" {
+				break
+			}
+
+			if cmd, err = s.startArcade(channel, sizesR, term, profile); err != nil {
+				log.Println("Couldn't start an arcade for", profile+":", err)
+				break
+			}
+
+			ok = true
+
+			go func(cmd *exec.Cmd) {
+				status := uint32(0)
+
+				if err := cmd.Wait(); err != nil {
+					status = 1
+				}
+
+				channel.SendRequest("exit-status", false, ssh.Marshal(sshExitStatus{status}))
+				channel.Close()
+			}(cmd)
+		}
+
+		if req.WantReply {
+			req.Reply(ok, nil)
+		}
+	}
+
+	// The client's gone, so its arcade goes too
+	if cmd != nil && cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+}
+
+// startArcade starts an arcade process for the session, drawing on the
+// channel.
+func (s *SSHServer) startArcade(channel io.ReadWriter, sizes *os.File, term, profile string) (*exec.Cmd, error) {
+	stdinR, stdinW, err := os.Pipe()
+
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := startSessionArcade(stdinR, sizes, channel, term, profile, s.args)
+	stdinR.Close()
+
+	if err != nil {
+		stdinW.Close()
+		return nil, err
+	}
+
+	go func() {
+		io.Copy(stdinW, channel)
+		stdinW.Close()
+	}()
+
+	return cmd, nil
+}
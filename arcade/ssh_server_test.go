@@ -0,0 +1,94 @@
+package arcade
+
+import (
+	"crypto/ed25519"
+	gonet "net"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sshSignIn signs in to the server as the user, with the key if there is
+// one, and returns the key name the server saw.
+func sshSignIn(t *testing.T, s *SSHServer, user string, key ed25519.PrivateKey) string {
+	// Both ends write before they read, which a pipe won't buffer
+	listener, err := gonet.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	config := &ssh.ClientConfig{
+		User:            user,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Auth: []ssh.AuthMethod{
+			ssh.KeyboardInteractive(func(string, string, []string, []bool) ([]string, error) {
+				return nil, nil
+			}),
+		},
+	}
+
+	if key != nil {
+		signer, err := ssh.NewSignerFromKey(key)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		config.Auth = append([]ssh.AuthMethod{ssh.PublicKeys(signer)}, config.Auth...)
+	}
+
+	go func() {
+		if client, err := ssh.Dial("tcp", listener.Addr().String(), config); err == nil {
+			client.Close()
+		}
+	}()
+
+	serverSide, err := listener.Accept()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer serverSide.Close()
+
+	conn, _, _, err := ssh.NewServerConn(serverSide, s.config)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return conn.Permissions.Extensions[sshKeyExtension]
+}
+
+func TestSSHKeysDecideProfiles(t *testing.T) {
+	_, hostKey, _ := ed25519.GenerateKey(nil)
+	_, alice, _ := ed25519.GenerateKey(nil)
+	_, mallory, _ := ed25519.GenerateKey(nil)
+
+	s, err := NewSSHServer(hostKey, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := sshSignIn(t, s, "alice", alice)
+
+	if key == "" {
+		t.Fatal("signing in with a key didn't name it")
+	}
+
+	if got := sshSignIn(t, s, "someone-else", alice); got != key {
+		t.Error("the same key got another profile under another name")
+	}
+
+	if got := sshSignIn(t, s, "alice", mallory); got == key {
+		t.Error("another key got alice's profile by using alice's name")
+	}
+
+	if got := sshSignIn(t, s, "alice", nil); got != "" {
+		t.Error("signing in without a key got a profile of its own:", got)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package arcade
+
+import (
+	"errors"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// newSessionTty can't take over an SSH or telnet session's terminal here.
+func newSessionTty() (tcell.Tty, error) {
+	return nil, errors.New("Remote sessions aren't supported on this platform")
+}
@@ -0,0 +1,118 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package arcade
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// sessionTty is the terminal of an SSH or telnet session, when we're the
+// arcade an SSHServer or TelnetServer started for it. The session comes in
+// on stdin and goes out on stdout, and window sizes arrive on a pipe of their
+// own.
+type sessionTty struct {
+	in  *os.File
+	out *os.File
+
+	mu       sync.Mutex
+	cols     int
+	rows     int
+	onResize func()
+}
+
+// newSessionTty reads the session's terminal from where its server left it,
+// waiting for its first window size.
+func newSessionTty() (tcell.Tty, error) {
+	// Only non-blocking files can have reads cut short, which tcell needs to
+	// stop reading when it's done
+	if err := syscall.SetNonblock(syscall.Stdin, true); err != nil {
+		return nil, err
+	}
+
+	t := &sessionTty{
+		in:  os.NewFile(uintptr(syscall.Stdin), "session"),
+		out: os.Stdout,
+	}
+
+	sizes := bufio.NewScanner(os.NewFile(3, "sizes"))
+
+	if !sizes.Scan() || !t.setSize(sizes.Text()) {
+		return nil, errors.New("no window size for the session")
+	}
+
+	go func() {
+		for sizes.Scan() {
+			if t.setSize(sizes.Text()) {
+				t.mu.Lock()
+				onResize := t.onResize
+				t.mu.Unlock()
+
+				if onResize != nil {
+					onResize()
+				}
+			}
+		}
+	}()
+
+	return t, nil
+}
+
+func (t *sessionTty) setSize(line string) bool {
+	var cols, rows int
+
+	if _, err := fmt.Sscan(line, &cols, &rows); err != nil {
+		return false
+	}
+
+	t.mu.Lock()
+	t.cols, t.rows = cols, rows
+	t.mu.Unlock()
+
+	return true
+}
+
+func (t *sessionTty) Start() error {
+	return t.in.SetReadDeadline(time.Time{})
+}
+
+func (t *sessionTty) Stop() error {
+	return nil
+}
+
+func (t *sessionTty) Drain() error {
+	return t.in.SetReadDeadline(time.Now())
+}
+
+func (t *sessionTty) NotifyResize(cb func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.onResize = cb
+}
+
+func (t *sessionTty) WindowSize() (int, int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.cols, t.rows, nil
+}
+
+func (t *sessionTty) Read(p []byte) (int, error) {
+	return t.in.Read(p)
+}
+
+func (t *sessionTty) Write(p []byte) (int, error) {
+	return t.out.Write(p)
+}
+
+func (t *sessionTty) Close() error {
+	return nil
+}
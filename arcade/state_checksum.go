@@ -0,0 +1,35 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"arcade/arcade/net"
+	"encoding/json"
+)
+
+// StateChecksumMessage is sent by players to the host every so often, so it
+// can tell when they've drifted from its state.
+type StateChecksumMessage struct {
+	message.Message
+	GameID   string
+	Checksum StateChecksum
+}
+
+func NewStateChecksumMessage(gameID string, checksum StateChecksum) *StateChecksumMessage {
+	return &StateChecksumMessage{
+		Message:  message.Message{Type: "state_checksum"},
+		GameID:   gameID,
+		Checksum: checksum,
+	}
+}
+
+func (m StateChecksumMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func (m StateChecksumMessage) SendPriority() net.Priority {
+	return net.PriorityState
+}
+
+func (m StateChecksumMessage) CoalesceKey() string {
+	return "state_checksum:" + m.GameID
+}
@@ -0,0 +1,26 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// StateResyncMessage carries the host's whole state to a player who drifted
+// from it, for games that don't already send whole states now and then.
+type StateResyncMessage struct {
+	message.Message
+	GameID string
+	State  json.RawMessage
+}
+
+func NewStateResyncMessage(gameID string, state json.RawMessage) *StateResyncMessage {
+	return &StateResyncMessage{
+		Message: message.Message{Type: "state_resync"},
+		GameID:  gameID,
+		State:   state,
+	}
+}
+
+func (m StateResyncMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
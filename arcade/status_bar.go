@@ -0,0 +1,154 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// StatusInfo is what a view has to say in the status bar.
+type StatusInfo struct {
+	LobbyName  string
+	HostID     string
+	UnreadChat int
+}
+
+// StatusView is implemented by views that are part of a lobby or game.
+type StatusView interface {
+	StatusInfo() StatusInfo
+}
+
+// StatusBar is the line along the bottom of the screen saying who the player
+// is and how they're connected. It's refreshed on heartbeats and connection
+// changes, rather than every frame.
+type StatusBar struct {
+	mu sync.RWMutex
+
+	name       string
+	connection string
+	port       int
+	rtt        time.Duration
+	info       StatusInfo
+}
+
+func NewStatusBar() *StatusBar {
+	return &StatusBar{}
+}
+
+// Update refreshes everything in the status bar, for the given view.
+func (sb *StatusBar) Update(v View) {
+	if arcade.Server == nil {
+		return
+	}
+
+	name := arcade.Server.ID[:8]
+
+	if profile, err := LoadProfile(); err == nil && profile.Name != "" {
+		name = profile.Name
+	}
+
+	var info StatusInfo
+
+	if svw, ok := v.(StatusView); ok {
+		info = svw.StatusInfo()
+	}
+
+	var rtt time.Duration
+
+	if info.HostID != "" && info.HostID != arcade.Server.ID {
+		clients := arcade.Server.GetHeartbeatClients()
+
+		if c, ok := clients.Load(info.HostID); ok {
+			rtt = c.(ConnectedClientInfo).GetMeanRTT()
+		}
+	}
+
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	sb.name = name
+	sb.connection = connectionState()
+	sb.port = arcade.Server.Port()
+	sb.rtt = rtt
+	sb.info = info
+}
+
+// connectionState says whether there's anyone to play with on the LAN or
+// through a distributor.
+func connectionState() string {
+	lan := false
+
+	arcade.Server.Network.ClientsRange(func(c *net.Client) bool {
+		c.RLock()
+		defer c.RUnlock()
+
+		if !c.Distributor && c.Neighbor && c.State == net.Connected {
+			lan = true
+			return false
+		}
+
+		return true
+	})
+
+	_, distributor := arcade.Server.Network.GetDistributor()
+	load, _ := arcade.Server.DistributorLoad()
+
+	switch {
+	case lan && distributor && load.Busy():
+		return "LAN + distributor (busy)"
+	case lan && distributor:
+		return "LAN + distributor"
+	case lan:
+		return "LAN"
+	case distributor && load.Busy():
+		return "Distributor (busy)"
+	case distributor:
+		return "Distributor"
+	}
+
+	return "Offline"
+}
+
+func (sb *StatusBar) Render(s *Screen) {
+	sb.mu.RLock()
+	defer sb.mu.RUnlock()
+
+	if sb.name == "" {
+		return
+	}
+
+	parts := []string{sb.name, sb.connection}
+
+	// Only worth mentioning if we couldn't get the port asked for
+	if sb.port != 0 && sb.port != arcade.Port {
+		parts = append(parts, fmt.Sprintf("port %d", sb.port))
+	}
+
+	if sb.info.LobbyName != "" {
+		parts = append(parts, sb.info.LobbyName)
+	}
+
+	if sb.rtt > 0 {
+		parts = append(parts, fmt.Sprintf("%dms", sb.rtt.Milliseconds()))
+	}
+
+	if sb.info.UnreadChat > 0 {
+		parts = append(parts, fmt.Sprintf("%d unread", sb.info.UnreadChat))
+	}
+
+	width, height := s.displaySize()
+	text := layout.Truncate(" "+strings.Join(parts, " │ ")+" ", width-4)
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	textSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorLightGreen)
+
+	// Sits in the bottom of the border, which is redrawn first in case the
+	// text got shorter
+	s.DrawLine(1, height-1, width-2, height-1, sty, true)
+	s.DrawText(2, height-1, textSty, text)
+}
@@ -0,0 +1,460 @@
+package arcade
+
+import (
+	"fmt"
+	"io"
+	"log"
+	gonet "net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Telnet commands and options, from RFCs 854, 856, 857, 858, 1073 and 1091
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWill = 251
+	telnetWont = 252
+	telnetDo   = 253
+	telnetDont = 254
+	telnetIAC  = 255
+
+	telnetBinary   = 0
+	telnetEcho     = 1
+	telnetSGA      = 3
+	telnetTermType = 24
+	telnetNAWS     = 31
+
+	telnetIs   = 0
+	telnetSend = 1
+)
+
+// How long clients get to say what their terminal is before we guess
+const telnetNegotiationTimeout = 2 * time.Second
+
+// Window size of clients that don't say, the size of every classic terminal
+const (
+	telnetDefaultCols = 80
+	telnetDefaultRows = 24
+)
+
+// TelnetServer hosts the arcade over telnet, for classic terminals and BBS
+// setups. Like over SSH, each connection runs its own arcade as a child
+// process. What the client says its terminal is decides whether it's drawn
+// in color and with more than ASCII.
+type TelnetServer struct {
+	// Flags each connection's arcade is started with
+	args []string
+
+	profiles *sessionProfiles
+}
+
+// terminalCaps is what we can draw on a client's terminal.
+type terminalCaps struct {
+	// TERM the arcade is started with, which tcell has to know
+	Term string
+
+	ASCII   bool
+	NoColor bool
+}
+
+// NewTelnetServer makes a server that starts connections' arcades with the
+// flags in args.
+func NewTelnetServer(args []string) *TelnetServer {
+	return &TelnetServer{args: args, profiles: newSessionProfiles()}
+}
+
+// ListenAndServe serves connections on the address until it can't listen.
+func (s *TelnetServer) ListenAndServe(addr string) error {
+	listener, err := gonet.Listen("tcp", addr)
+
+	if err != nil {
+		return err
+	}
+
+	defer listener.Close()
+
+	fmt.Printf("Serving telnet at %s...\n", listener.Addr())
+
+	for {
+		conn, err := listener.Accept()
+
+		if err != nil {
+			return err
+		}
+
+		go s.handleConn(conn)
+	}
+}
+
+// handleConn negotiates what the client's terminal can do, then runs an
+// arcade for it until either side goes away.
+func (s *TelnetServer) handleConn(conn gonet.Conn) {
+	defer conn.Close()
+
+	// Nothing over telnet proves who anyone is, so everyone's a guest
+	profile, ok := s.profiles.claimGuest("telnet")
+
+	if !ok {
+		io.WriteString(conn, "The arcade is full, try again later.\r\n")
+		return
+	}
+
+	defer s.profiles.release(profile)
+
+	sizesR, sizesW, err := os.Pipe()
+
+	if err != nil {
+		return
+	}
+
+	defer sizesR.Close()
+	defer sizesW.Close()
+
+	stdinR, stdinW, err := os.Pipe()
+
+	if err != nil {
+		return
+	}
+
+	t := newTelnetConn(conn, sizesW)
+	go func() {
+		t.readInto(stdinW)
+		stdinW.Close()
+	}()
+
+	if err := t.negotiate(); err != nil {
+		stdinR.Close()
+		return
+	}
+
+	caps := telnetCaps(t.awaitTermType(telnetNegotiationTimeout))
+	args := s.args
+
+	if caps.ASCII {
+		args = append([]string{"-ascii"}, args...)
+	}
+
+	if caps.NoColor {
+		args = append([]string{"-no-color"}, args...)
+	}
+
+	log.Printf("Telnet client %s is %s, ascii: %v, no color: %v\n", profile, caps.Term, caps.ASCII, caps.NoColor)
+
+	cmd, err := startSessionArcade(stdinR, sizesR, telnetWriter{conn}, caps.Term, profile, args)
+	stdinR.Close()
+
+	if err != nil {
+		log.Println("Couldn't start an arcade for", profile+":", err)
+		return
+	}
+
+	exited := make(chan bool)
+
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	select {
+	case <-exited:
+	case <-t.closed:
+		// The client's gone, so its arcade goes too
+		cmd.Process.Kill()
+		<-exited
+	}
+}
+
+// telnetCaps decides what can be drawn on a terminal of the type, as the
+// client named it. Anything we don't recognize is treated as a VT100, which
+// nearly everything can pretend to be.
+func telnetCaps(termType string) terminalCaps {
+	term := strings.ToLower(termType)
+
+	switch {
+	case strings.HasPrefix(term, "xterm"), strings.HasPrefix(term, "screen"), strings.HasPrefix(term, "tmux"),
+		strings.HasPrefix(term, "rxvt"), strings.HasPrefix(term, "putty"), term == "linux":
+		return terminalCaps{Term: term}
+	case strings.HasPrefix(term, "ansi"), term == "pcansi", term == "scoansi", term == "syncterm", term == "cterm":
+		// BBS terminals draw in color, but in a DOS code page, not UTF-8
+		return terminalCaps{Term: "ansi", ASCII: true}
+	}
+
+	return terminalCaps{Term: "vt100", ASCII: true, NoColor: true}
+}
+
+// telnetConn strips telnet's negotiation out of what a client sends, leaving
+// what the player typed, and answers it.
+type telnetConn struct {
+	conn gonet.Conn
+
+	// Where window sizes are written, as "cols rows" lines
+	sizes io.Writer
+
+	mu       sync.Mutex
+	termType string
+	typed    bool
+
+	// Closed once the client's said what its terminal is, or won't
+	termTypeCh chan bool
+
+	// Closed once the client's gone
+	closed chan bool
+
+	// Whether it's told us a window size
+	sized bool
+}
+
+func newTelnetConn(conn gonet.Conn, sizes io.Writer) *telnetConn {
+	return &telnetConn{
+		conn:       conn,
+		sizes:      sizes,
+		termTypeCh: make(chan bool),
+		closed:     make(chan bool),
+	}
+}
+
+// negotiate asks the client for character at a time input without echo, and
+// for its window size and terminal type.
+func (t *telnetConn) negotiate() error {
+	_, err := t.conn.Write([]byte{
+		telnetIAC, telnetWill, telnetEcho,
+		telnetIAC, telnetWill, telnetSGA,
+		telnetIAC, telnetDo, telnetSGA,
+		telnetIAC, telnetWill, telnetBinary,
+		telnetIAC, telnetDo, telnetBinary,
+		telnetIAC, telnetDo, telnetNAWS,
+		telnetIAC, telnetDo, telnetTermType,
+	})
+
+	return err
+}
+
+// awaitTermType waits until the client says what its terminal is, or the
+// timeout. Clients that don't say are given the default window size.
+func (t *telnetConn) awaitTermType(timeout time.Duration) string {
+	select {
+	case <-t.termTypeCh:
+	case <-t.closed:
+	case <-time.After(timeout):
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.sized {
+		fmt.Fprintf(t.sizes, "%d %d\n", telnetDefaultCols, telnetDefaultRows)
+		t.sized = true
+	}
+
+	return t.termType
+}
+
+func (t *telnetConn) setTermType(termType string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.typed {
+		return
+	}
+
+	t.termType = termType
+	t.typed = true
+	close(t.termTypeCh)
+}
+
+func (t *telnetConn) setSize(cols, rows int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Some clients send 0 for sizes they don't know
+	if cols == 0 || rows == 0 {
+		return
+	}
+
+	fmt.Fprintf(t.sizes, "%d %d\n", cols, rows)
+	t.sized = true
+}
+
+// readInto copies what the player types to w, handling negotiation along the
+// way, until the client goes away.
+func (t *telnetConn) readInto(w io.Writer) {
+	defer close(t.closed)
+
+	p := &telnetParser{conn: t}
+	buf := make([]byte, 1024)
+
+	for {
+		n, err := t.conn.Read(buf)
+
+		if n > 0 {
+			if _, err := w.Write(p.parse(buf[:n])); err != nil {
+				return
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// reply sends a command to the client.
+func (t *telnetConn) reply(b ...byte) {
+	t.conn.Write(b)
+}
+
+// telnetParser picks commands out of a client's stream, which can split
+// them across reads.
+type telnetParser struct {
+	conn *telnetConn
+
+	state int
+
+	// The command being read, and the subnegotiation's bytes
+	verb byte
+	sub  []byte
+
+	// Whether the last data byte was a carriage return
+	cr bool
+}
+
+const (
+	telnetStateData = iota
+	telnetStateIAC
+	telnetStateOption
+	telnetStateSub
+	telnetStateSubIAC
+)
+
+// parse returns the data in b, and acts on the commands.
+func (p *telnetParser) parse(b []byte) []byte {
+	data := make([]byte, 0, len(b))
+
+	for _, c := range b {
+		switch p.state {
+		case telnetStateData:
+			if c == telnetIAC {
+				p.state = telnetStateIAC
+				continue
+			}
+
+			// Outside of binary mode, Enter is CR NUL or CR LF, and terminals
+			// send a lone CR
+			if p.cr && (c == 0 || c == '\n') {
+				p.cr = false
+				continue
+			}
+
+			p.cr = c == '\r'
+			data = append(data, c)
+		case telnetStateIAC:
+			switch c {
+			case telnetIAC:
+				// An escaped 255
+				data = append(data, c)
+				p.state = telnetStateData
+			case telnetWill, telnetWont, telnetDo, telnetDont:
+				p.verb = c
+				p.state = telnetStateOption
+			case telnetSB:
+				p.sub = p.sub[:0]
+				p.state = telnetStateSub
+			default:
+				// NOP, go ahead and the rest mean nothing to us
+				p.state = telnetStateData
+			}
+		case telnetStateOption:
+			p.option(p.verb, c)
+			p.state = telnetStateData
+		case telnetStateSub:
+			if c == telnetIAC {
+				p.state = telnetStateSubIAC
+			} else {
+				p.sub = append(p.sub, c)
+			}
+		case telnetStateSubIAC:
+			switch c {
+			case telnetSE:
+				p.subnegotiation(p.sub)
+				p.state = telnetStateData
+			case telnetIAC:
+				p.sub = append(p.sub, c)
+				p.state = telnetStateSub
+			default:
+				// Malformed, so it's dropped
+				p.state = telnetStateData
+			}
+		}
+	}
+
+	return data
+}
+
+// option answers the client's side of an option's negotiation. Options we
+// asked about are taken as answers, and we refuse any others.
+func (p *telnetParser) option(verb, option byte) {
+	switch verb {
+	case telnetWill:
+		switch option {
+		case telnetTermType:
+			p.conn.reply(telnetIAC, telnetSB, telnetTermType, telnetSend, telnetIAC, telnetSE)
+		case telnetNAWS, telnetSGA, telnetBinary:
+		default:
+			p.conn.reply(telnetIAC, telnetDont, option)
+		}
+	case telnetWont:
+		if option == telnetTermType {
+			p.conn.setTermType("")
+		}
+	case telnetDo:
+		switch option {
+		case telnetEcho, telnetSGA, telnetBinary:
+		default:
+			p.conn.reply(telnetIAC, telnetWont, option)
+		}
+	}
+}
+
+func (p *telnetParser) subnegotiation(sub []byte) {
+	if len(sub) == 0 {
+		return
+	}
+
+	switch sub[0] {
+	case telnetTermType:
+		if len(sub) > 1 && sub[1] == telnetIs {
+			p.conn.setTermType(string(sub[2:]))
+		}
+	case telnetNAWS:
+		if len(sub) == 5 {
+			p.conn.setSize(int(sub[1])<<8|int(sub[2]), int(sub[3])<<8|int(sub[4]))
+		}
+	}
+}
+
+// telnetWriter sends the arcade's output to a client, escaping the byte that
+// would be read as a command.
+type telnetWriter struct {
+	conn gonet.Conn
+}
+
+func (w telnetWriter) Write(b []byte) (int, error) {
+	escaped := make([]byte, 0, len(b))
+
+	for _, c := range b {
+		escaped = append(escaped, c)
+
+		if c == telnetIAC {
+			escaped = append(escaped, telnetIAC)
+		}
+	}
+
+	if _, err := w.conn.Write(escaped); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
@@ -0,0 +1,83 @@
+package arcade
+
+import (
+	"bytes"
+	"io"
+	gonet "net"
+	"testing"
+	"time"
+)
+
+func newTestTelnetConn(t *testing.T) (*telnetConn, *bytes.Buffer) {
+	server, client := gonet.Pipe()
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+
+	// Our replies aren't what's tested
+	go io.Copy(io.Discard, client)
+
+	sizes := new(bytes.Buffer)
+	return newTelnetConn(server, sizes), sizes
+}
+
+func TestTelnetParserStripsNegotiation(t *testing.T) {
+	conn, sizes := newTestTelnetConn(t)
+	p := &telnetParser{conn: conn}
+
+	var data []byte
+
+	// Split mid-command, like reads can be
+	for _, b := range [][]byte{
+		{'a', telnetIAC, telnetSB, telnetNAWS, 0, 100, 0},
+		{40, telnetIAC, telnetSE, 'b', telnetIAC, telnetIAC, '\r', 0},
+		{telnetIAC, telnetSB, telnetTermType, telnetIs, 'V', 'T', '1', '0', '0', telnetIAC, telnetSE, '\r', '\n', 'c'},
+	} {
+		data = append(data, p.parse(b)...)
+	}
+
+	if want := []byte{'a', 'b', telnetIAC, '\r', '\r', 'c'}; !bytes.Equal(data, want) {
+		t.Errorf("got data %v, want %v", data, want)
+	}
+
+	if got := sizes.String(); got != "100 40\n" {
+		t.Errorf("got sizes %q, want \"100 40\\n\"", got)
+	}
+
+	if got := conn.awaitTermType(time.Second); got != "VT100" {
+		t.Errorf("got terminal type %q, want VT100", got)
+	}
+}
+
+func TestTelnetDefaultsWithoutNegotiation(t *testing.T) {
+	conn, sizes := newTestTelnetConn(t)
+	p := &telnetParser{conn: conn}
+
+	p.parse([]byte{telnetIAC, telnetWont, telnetTermType})
+
+	if got := conn.awaitTermType(time.Second); got != "" {
+		t.Errorf("got terminal type %q, want none", got)
+	}
+
+	if got := sizes.String(); got != "80 24\n" {
+		t.Errorf("got sizes %q, want the default", got)
+	}
+
+	if caps := telnetCaps(""); !caps.ASCII || !caps.NoColor || caps.Term != "vt100" {
+		t.Errorf("unknown terminals got %+v", caps)
+	}
+}
+
+func TestTelnetCaps(t *testing.T) {
+	for term, want := range map[string]terminalCaps{
+		"XTERM-256COLOR": {Term: "xterm-256color"},
+		"ANSI":           {Term: "ansi", ASCII: true},
+		"SyncTERM":       {Term: "ansi", ASCII: true},
+		"VT220":          {Term: "vt100", ASCII: true, NoColor: true},
+	} {
+		if got := telnetCaps(term); got != want {
+			t.Errorf("%s got %+v, want %+v", term, got, want)
+		}
+	}
+}
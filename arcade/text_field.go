@@ -1,6 +1,10 @@
 package arcade
 
 import (
+	"arcade/arcade/layout"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/gdamore/tcell/v2"
 )
 
@@ -9,10 +13,15 @@ type TextField struct {
 
 	sty         tcell.Style
 	x, y, width int
-	cursorPos   int
-	value       string
-	label       string
-	active      bool
+
+	// In runes, not bytes
+	cursorPos int
+
+	value     string
+	label     string
+	active    bool
+	masked    bool
+	maxLength int
 }
 
 func NewTextField(x, y, width int, label string) *TextField {
@@ -25,6 +34,29 @@ func NewTextField(x, y, width int, label string) *TextField {
 	}
 }
 
+// SetMasked hides the value behind asterisks, for passwords.
+func (tf *TextField) SetMasked(masked bool) {
+	tf.Lock()
+	defer tf.Unlock()
+
+	tf.masked = masked
+}
+
+// SetMaxLength limits how many characters can be typed. Zero means no limit.
+func (tf *TextField) SetMaxLength(maxLength int) {
+	tf.Lock()
+	defer tf.Unlock()
+
+	tf.maxLength = maxLength
+}
+
+func (tf *TextField) Value() string {
+	tf.RLock()
+	defer tf.RUnlock()
+
+	return tf.value
+}
+
 func (tf *TextField) Focus() {
 	tf.Lock()
 	defer tf.Unlock()
@@ -43,7 +75,7 @@ func (tf *TextField) ProcessEvent(evt interface{}) {
 			if tf.delegate.NavigateForward() {
 				tf.active = false
 			}
-		case tcell.KeyUp:
+		case tcell.KeyUp, tcell.KeyBacktab:
 			if tf.delegate.NavigateBackward() {
 				tf.active = false
 			}
@@ -54,7 +86,7 @@ func (tf *TextField) ProcessEvent(evt interface{}) {
 
 			tf.cursorPos -= 1
 		case tcell.KeyRight:
-			if tf.cursorPos >= len(tf.value) {
+			if tf.cursorPos >= utf8.RuneCountInString(tf.value) {
 				break
 			}
 
@@ -64,9 +96,14 @@ func (tf *TextField) ProcessEvent(evt interface{}) {
 				break
 			}
 
-			tf.value = tf.value[:tf.cursorPos-1] + tf.value[tf.cursorPos:]
+			runes := []rune(tf.value)
+			tf.value = string(runes[:tf.cursorPos-1]) + string(runes[tf.cursorPos:])
 			tf.cursorPos -= 1
 		default:
+			if tf.maxLength > 0 && utf8.RuneCountInString(tf.value) >= tf.maxLength {
+				break
+			}
+
 			tf.value += string(evt.Rune())
 			tf.cursorPos += 1
 		}
@@ -92,22 +129,40 @@ func (tf *TextField) Render(s *Screen) {
 		y = (screenH - 2) / 2
 	}
 
-	s.DrawText(x+(tf.width-len(tf.label))/2, y-1, tf.sty, tf.label)
+	value := tf.value
+
+	if tf.masked {
+		value = strings.Repeat("*", utf8.RuneCountInString(tf.value))
+	}
+
+	// Whatever doesn't fit inside the box is cut off
+	inner := tf.width - 2
+	runes := []rune(layout.Clip(value, inner))
+	value = string(runes)
+
+	label := layout.Truncate(tf.label, tf.width)
+	s.DrawText(x+layout.Center(tf.width, label), y-1, tf.sty, label)
 	s.DrawBox(x, y, x+tf.width-1, y+2, tf.sty, false)
-	s.DrawText(x+(tf.width-len(tf.value))/2, y+1, tf.sty, tf.value)
+	s.DrawEmpty(x+1, y+1, x+tf.width-2, y+1, tf.sty)
+	s.DrawText(x+layout.Center(tf.width, value), y+1, tf.sty, value)
 
 	if tf.active {
 		// Draw selected character with gray background
 		ch := " "
+		cursor := tf.cursorPos
+
+		if cursor > len(runes) {
+			cursor = len(runes)
+		}
 
-		if len(tf.value) > 0 && tf.cursorPos < len(tf.value) {
-			ch = string(tf.value[tf.cursorPos])
+		if cursor < len(runes) {
+			ch = string(runes[cursor])
 		}
 
 		selectedSty := tf.sty.Background(tcell.ColorGray)
-		cursorX := x + (tf.width-len(tf.value))/2 + tf.cursorPos
+		cursorX := x + layout.Center(tf.width, value) + layout.Width(string(runes[:cursor]))
 
-		if len(tf.value) == 0 {
+		if len(runes) == 0 {
 			cursorX -= 1
 		}
 
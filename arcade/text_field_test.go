@@ -0,0 +1,40 @@
+package arcade
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestTextFieldWideText(t *testing.T) {
+	canvas, f := (&Screen{}).Canvas()
+
+	tf := NewTextField(10, 5, 12, "名前")
+	tf.value = "ñandú"
+	tf.cursorPos = 5
+	tf.active = true
+	tf.Render(canvas)
+
+	// The label's four cells wide, and the value five, however many bytes
+	if f[4][14].primary != '名' || f[4][16].primary != '前' {
+		t.Error("label not centered by its width")
+	}
+
+	if f[6][13].primary != 'ñ' || f[6][17].primary != 'ú' {
+		t.Error("value not centered by its width")
+	}
+
+	if _, bg, _ := f[6][18].style.Decompose(); bg != tcell.ColorGray {
+		t.Error("cursor not just after the value")
+	}
+}
+
+func TestButtonTruncatesTitle(t *testing.T) {
+	canvas, f := (&Screen{}).Canvas()
+
+	NewButton(0, 0, 6, "Play online", nil).Render(canvas)
+
+	if got := frameRow(f[1][:6]); got != "Play …" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,108 @@
+package arcade
+
+import (
+	"github.com/gdamore/tcell/v2"
+)
+
+const defaultTheme = "green"
+
+// Theme swaps the arcade's greens for other colors. Player colors are left
+// alone.
+type Theme struct {
+	Name   string
+	Colors map[tcell.Color]tcell.Color
+}
+
+var themes = []*Theme{
+	{Name: "green"},
+	{Name: "amber", Colors: map[tcell.Color]tcell.Color{
+		tcell.ColorGreen:      tcell.ColorOrange,
+		tcell.ColorLightGreen: tcell.ColorGold,
+		tcell.ColorDarkGreen:  tcell.ColorDarkOrange,
+	}},
+	{Name: "blue", Colors: map[tcell.Color]tcell.Color{
+		tcell.ColorGreen:      tcell.ColorDarkCyan,
+		tcell.ColorLightGreen: tcell.ColorLightCyan,
+		tcell.ColorDarkGreen:  tcell.ColorDarkBlue,
+	}},
+	{Name: "mono", Colors: map[tcell.Color]tcell.Color{
+		tcell.ColorGreen:      tcell.ColorSilver,
+		tcell.ColorLightGreen: tcell.ColorWhite,
+		tcell.ColorDarkGreen:  tcell.ColorGray,
+	}},
+}
+
+func themeNames() []string {
+	names := make([]string, len(themes))
+
+	for i, theme := range themes {
+		names[i] = theme.Name
+	}
+
+	return names
+}
+
+// getTheme returns the theme with the given name, or the default one.
+func getTheme(name string) *Theme {
+	for _, theme := range themes {
+		if theme.Name == name {
+			return theme
+		}
+	}
+
+	return themes[0]
+}
+
+func (t *Theme) apply(style tcell.Style) tcell.Style {
+	if len(t.Colors) == 0 {
+		return style
+	}
+
+	fg, bg, _ := style.Decompose()
+
+	if c, ok := t.Colors[fg]; ok {
+		style = style.Foreground(c)
+	}
+
+	if c, ok := t.Colors[bg]; ok {
+		style = style.Background(c)
+	}
+
+	return style
+}
+
+// asciiRunes replaces drawing characters for terminals that can't show them.
+var asciiRunes = map[rune]rune{
+	'▲': '^', '▼': 'v', '↑': '^', '↓': 'v', '←': '<', '→': '>',
+	'◀': '<', '▶': '>', '…': '.', '█': '#', '░': ' ', '▒': ':', '▓': '%', '•': '*', '●': 'o', '○': '.', '♛': '*', '⚠': '!',
+}
+
+// asciiRune returns a plain ASCII stand-in for the rune.
+func asciiRune(r rune) rune {
+	if r < 0x80 {
+		return r
+	}
+
+	if a, ok := asciiRunes[r]; ok {
+		return a
+	}
+
+	switch r {
+	case '━', '═', '─', '╌', '┅':
+		return '-'
+	case '┃', '║', '│', '╎', '┇':
+		return '|'
+	}
+
+	// Box drawing corners and joints
+	if r >= 0x2500 && r <= 0x257f {
+		return '+'
+	}
+
+	// Other block elements
+	if r >= 0x2580 && r <= 0x259f {
+		return '#'
+	}
+
+	return r
+}
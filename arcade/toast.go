@@ -0,0 +1,218 @@
+package arcade
+
+import (
+	"arcade/arcade/widgets"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	// How long a toast stays on screen
+	toastDuration = 5 * time.Second
+
+	// Most toasts shown at once; older ones are still in the history
+	maxToasts = 3
+
+	maxToastHistory = 50
+
+	toastWidth = 36
+)
+
+// History panel, drawn over the right side of the view
+const (
+	toastHistoryX1 = displayWidth - toastWidth - 4
+	toastHistoryY1 = 2
+	toastHistoryX2 = displayWidth - 2
+	toastHistoryY2 = displayHeight - 3
+)
+
+type Toast struct {
+	Text string
+	Time time.Time
+
+	dismissed bool
+}
+
+func (t *Toast) expired() bool {
+	return t.dismissed || time.Since(t.Time) >= toastDuration
+}
+
+// ToastManager keeps short notifications that show in the corner of any view
+// and expire on their own, along with a history of past ones.
+type ToastManager struct {
+	mu sync.RWMutex
+
+	toasts []*Toast
+
+	showHistory bool
+	historyList *widgets.ScrollList
+
+	// Called when toasts appear or disappear, so the screen can be redrawn
+	onChange func()
+
+	// Called with the text of each new toast
+	OnPush func(text string)
+}
+
+func NewToastManager(onChange func()) *ToastManager {
+	t := &ToastManager{
+		historyList: widgets.NewScrollList(toastHistoryX1+1, toastHistoryY1+1, toastHistoryX2-toastHistoryX1-1, toastHistoryY2-toastHistoryY1-1),
+		onChange:    onChange,
+	}
+
+	t.historyList.Placeholder = "No notifications yet."
+	t.historyList.SetFocused(true)
+
+	return t
+}
+
+// Push shows a new toast.
+func (t *ToastManager) Push(text string) {
+	t.add(&Toast{Text: text, Time: time.Now()})
+	time.AfterFunc(toastDuration, t.onChange)
+
+	if t.OnPush != nil {
+		t.OnPush(text)
+	}
+}
+
+// Log adds a notification to the history without showing it, for things
+// that already have their own prompt on screen.
+func (t *ToastManager) Log(text string) {
+	t.add(&Toast{Text: text, Time: time.Now(), dismissed: true})
+}
+
+func (t *ToastManager) add(toast *Toast) {
+	t.mu.Lock()
+	t.toasts = append(t.toasts, toast)
+
+	if len(t.toasts) > maxToastHistory {
+		t.toasts = t.toasts[len(t.toasts)-maxToastHistory:]
+	}
+
+	t.updateHistory()
+	t.mu.Unlock()
+
+	// Toasts can be pushed while views hold their locks, so don't render here
+	go t.onChange()
+}
+
+// Active returns the toasts that should be on screen, oldest first.
+func (t *ToastManager) Active() []*Toast {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	active := make([]*Toast, 0, maxToasts)
+
+	for i := len(t.toasts) - 1; i >= 0 && len(active) < maxToasts; i-- {
+		if !t.toasts[i].expired() {
+			active = append([]*Toast{t.toasts[i]}, active...)
+		}
+	}
+
+	return active
+}
+
+// DismissAll hides every toast on screen. They stay in the history.
+func (t *ToastManager) DismissAll() {
+	t.mu.Lock()
+	for _, toast := range t.toasts {
+		toast.dismissed = true
+	}
+	t.mu.Unlock()
+
+	t.onChange()
+}
+
+func (t *ToastManager) ShowingHistory() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.showHistory
+}
+
+func (t *ToastManager) ToggleHistory() {
+	t.mu.Lock()
+	t.showHistory = !t.showHistory
+	t.mu.Unlock()
+
+	// Show the newest notification first
+	t.historyList.Select(0)
+	t.onChange()
+}
+
+// updateHistory refreshes the history panel rows, newest first. Expects the
+// lock to be held.
+func (t *ToastManager) updateHistory() {
+	rows := make([]string, len(t.toasts))
+
+	for i, toast := range t.toasts {
+		rows[len(t.toasts)-1-i] = fmt.Sprintf(" %s %s", toast.Time.Format("15:04"), toast.Text)
+	}
+
+	t.historyList.SetItems(rows)
+}
+
+// ProcessEvent scrolls the history panel while it's open. Returns true if the
+// event was used.
+func (t *ToastManager) ProcessEvent(evt *tcell.EventKey) bool {
+	t.mu.RLock()
+	showHistory := t.showHistory
+	t.mu.RUnlock()
+
+	if !showHistory {
+		return false
+	}
+
+	return t.historyList.ProcessEvent(evt)
+}
+
+func (t *ToastManager) Render(s *Screen, y int) {
+	width, _ := s.displaySize()
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorYellow)
+
+	t.mu.RLock()
+	showHistory := t.showHistory
+	t.mu.RUnlock()
+
+	if showHistory {
+		s.DrawEmpty(toastHistoryX1, toastHistoryY1, toastHistoryX2, toastHistoryY2, sty)
+		s.DrawBox(toastHistoryX1, toastHistoryY1, toastHistoryX2, toastHistoryY2, sty, false)
+
+		header := " Notifications - Ctrl-N to close "
+		s.DrawText(toastHistoryX1+(toastHistoryX2-toastHistoryX1+1-len(header))/2, toastHistoryY1, sty, header)
+
+		t.historyList.Render(s)
+		return
+	}
+
+	x1 := width - toastWidth - 3
+	x2 := width - 3
+
+	for _, toast := range t.Active() {
+		text := []rune(toast.Text)
+
+		if len(text) > toastWidth-4 {
+			text = append(text[:toastWidth-5], '…')
+		}
+
+		s.DrawEmpty(x1, y, x2, y+2, sty)
+		s.DrawBox(x1, y, x2, y+2, sty, false)
+		s.DrawText(x1+2, y+1, sty, string(text))
+
+		y += 3
+	}
+}
+
+// notify shows a toast on the player's screen. It does nothing on the
+// distributor, which has no screen.
+func notify(format string, args ...interface{}) {
+	if arcade.Distributor || arcade.Server == nil || arcade.Server.mgr == nil {
+		return
+	}
+
+	arcade.Server.mgr.Toasts.Push(fmt.Sprintf(format, args...))
+}
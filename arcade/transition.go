@@ -0,0 +1,132 @@
+package arcade
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Transition is how one view gives way to the next.
+type Transition int
+
+const (
+	TransitionNone Transition = iota
+
+	// Cells of the old view give way to the new one a few at a time
+	TransitionFade
+
+	// The new view pushes the old one out to the left, or to the right
+	TransitionSlideLeft
+	TransitionSlideRight
+)
+
+const (
+	transitionDuration = 250 * time.Millisecond
+
+	// Time between frames while something is animating
+	animationFrame = time.Second / 30
+)
+
+type frameCell struct {
+	primary   rune
+	combining []rune
+	style     tcell.Style
+}
+
+// frame is a copy of what's in the display area, by row.
+type frame [][]frameCell
+
+// captureFrame copies what's on the screen in the display area.
+func captureFrame(s *Screen) frame {
+	startX, startY := s.offset()
+	width, height := s.displaySize()
+	f := make(frame, height)
+
+	for y := range f {
+		f[y] = make([]frameCell, width)
+
+		for x := range f[y] {
+			primary, combining, style, _ := s.Screen.GetContent(startX+x, startY+y)
+			f[y][x] = frameCell{primary, combining, style}
+		}
+	}
+
+	return f
+}
+
+// viewTransition draws the last frame of the old view over the new one until
+// it's done.
+type viewTransition struct {
+	kind  Transition
+	from  frame
+	tween *Tween
+}
+
+func newViewTransition(kind Transition, from frame) *viewTransition {
+	return &viewTransition{
+		kind:  kind,
+		from:  from,
+		tween: NewTween(0, 1, transitionDuration, EaseInOutQuad),
+	}
+}
+
+// render mixes the old frame with what the new view just drew. Returns true
+// once the transition is over.
+func (t *viewTransition) render(s *Screen) bool {
+	if t.tween.Done() {
+		return true
+	}
+
+	to := captureFrame(s)
+	startX, startY := s.offset()
+	p := t.tween.Value()
+
+	for y := range to {
+		if y >= len(t.from) {
+			break
+		}
+
+		width := len(to[y])
+		shift := int(p * float64(width))
+
+		for x := range to[y] {
+			var c frameCell
+
+			switch t.kind {
+			case TransitionSlideLeft:
+				if x+shift < width {
+					c = t.from[y][x+shift]
+				} else {
+					c = to[y][x+shift-width]
+				}
+			case TransitionSlideRight:
+				if x < shift {
+					c = to[y][width-shift+x]
+				} else {
+					c = t.from[y][x-shift]
+				}
+			default:
+				c = to[y][x]
+
+				if dissolveOrder(x, y) >= p {
+					c = t.from[y][x]
+				}
+			}
+
+			// Cells were captured with the theme already applied
+			s.Screen.SetContent(startX+x, startY+y, c.primary, c.combining, c.style)
+		}
+	}
+
+	return false
+}
+
+// dissolveOrder gives each cell a fixed spot between 0 and 1 in the order
+// cells change during a fade, scattered so it doesn't look like a wipe.
+func dissolveOrder(x, y int) float64 {
+	h := uint32(x)*374761393 + uint32(y)*668265263
+	h = (h ^ (h >> 13)) * 1274126177
+	h ^= h >> 16
+
+	return float64(h%1024) / 1024
+}
@@ -1,15 +1,16 @@
 package arcade
 
 import (
+	"arcade/arcade/layout"
 	"encoding"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math"
 	"strconv"
 	"sync"
 	"time"
-	"unicode/utf8"
 
 	"arcade/arcade/net"
 	"arcade/raft"
@@ -49,6 +50,12 @@ type TronClientState struct {
 	Y         int
 	Direction TronDirection
 	PlayerNum int
+
+	// Timestep of the last move taken, so there's at most one a timestep
+	LastMove int
+
+	// Timesteps left passing through trails, from a ghost power-up
+	Ghost int `json:",omitempty"`
 }
 
 type TronGameState struct {
@@ -59,6 +66,16 @@ type TronGameState struct {
 	Collisions       []byte
 	ClientStates     map[string]TronClientState
 	CommitedTimeStep int
+
+	// The time limit and when the arena closes in, and the timesteps played,
+	// which are only counted when there's either
+	Tick   int             `json:",omitempty"`
+	Timer  *MatchTimer     `json:",omitempty"`
+	Shrink *ShrinkSchedule `json:",omitempty"`
+
+	// Power-ups the lobby's mod put in that haven't been picked up yet,
+	// including the ones that haven't turned up
+	PowerUps []TronPowerUp `json:",omitempty"`
 }
 
 type TronCommandType int64
@@ -168,12 +185,69 @@ type TronGameView struct {
 	lastApplyMsgInd   int
 	gameRenderState   TronGameRenderState
 	lobby             *Lobby
+	chat              *ChatOverlay
+	inputs            *InputValidator
+
+	// The host compares players' committed states with its own; players send
+	// a checksum of theirs every so often
+	desync       *DesyncDetector
+	lastChecksum int
+
+	// Set if we're hosting and the lobby can be watched
+	spectate *spectateStream
+
+	// Set unless replays are turned off
+	replay *replayRecorder
+
+	// When watching, the player the camera follows. Everyone else is dimmed
+	focus string
+
+	// Custom arena, or nil for the classic empty one
+	arena *TronMap
+
+	// What the lobby's mod set the game up with, if it has one
+	rules *TronRules
+
+	// The lobby's time limit, and whether its arena closes in before then
+	timer     *MatchTimer
+	shrinking bool
 }
 
 const CLIENT_LAG_TIMESTEP = 0
 const FRAGMENTS = 2
+const TronTimestepPeriod = 80 * time.Millisecond
+
+func NewTronGameView(mgr *ViewManager, lobby *Lobby, rng *MatchRNG) *TronGameView {
+	lobby.mu.RLock()
+	spectate := newSpectateStream(lobby)
+	replay := newReplayRecorder(mgr, lobby)
+	mapHash := lobby.MapHash
+	rules := lobby.modRules
+	timeLimit := time.Duration(lobby.TimeLimit) * time.Second
+	shrinking := lobby.ShrinkArena
+	lobby.mu.RUnlock()
+
+	var arena *TronMap
+
+	if mapHash != "" {
+		if m, ok := findTronMap(mapHash); ok {
+			arena = m
+		} else {
+			log.Println("Missing the lobby's map", mapHash)
+		}
+	}
+
+	// Replays keep the map and the mod's rules, so they can be simulated
+	// without either
+	if spectate != nil {
+		spectate.info.MapHash = mapHash
+	}
+
+	if replay != nil {
+		replay.replay.Map = arena
+		replay.replay.Rules = rules
+	}
 
-func NewTronGameView(mgr *ViewManager, lobby *Lobby) *TronGameView {
 	return &TronGameView{
 		mgr: mgr,
 		Game: Game[TronGameState, TronClientState]{
@@ -184,11 +258,62 @@ func NewTronGameView(mgr *ViewManager, lobby *Lobby) *TronGameView {
 			Me:             arcade.Server.ID,
 			HostID:         lobby.HostID,
 			HostSyncPeriod: 2000,
-			TimestepPeriod: 80,
+			TimestepPeriod: int(TronTimestepPeriod.Milliseconds()),
 			Timestep:       0,
+			RNG:            rng,
+			NetworkStart:   arcade.Server.Network.Usage(),
 		},
-		lobby: lobby,
+		lobby:     lobby,
+		chat:      NewChatOverlay(lobby.ID, lobby.PlayerIDs),
+		inputs:    NewInputValidator(Tron, mgr.Config().KickCheaters),
+		desync:    NewDesyncDetector(lobby.ID),
+		spectate:  spectate,
+		replay:    replay,
+		arena:     arena,
+		rules:     rules,
+		timer:     NewMatchTimer(timeLimit, tronSuddenDeathEvery, TronTimestepPeriod),
+		shrinking: shrinking,
+	}
+}
+
+// settleTronMod runs the lobby's mod, if it has one, keeping the rules it
+// sets the match up with. Everyone runs the same script with the same seed
+// and the same limits, so gets the same rules. It returns an error if the mod
+// is missing or didn't work.
+func settleTronMod(lobby *Lobby) error {
+	lobby.mu.Lock()
+	lobby.modRules = nil
+	gameType, lobbyID, mapHash, modHash := lobby.GameType, lobby.ID, lobby.MapHash, lobby.ModHash
+	players := len(lobby.PlayerIDs)
+	lobby.mu.Unlock()
+
+	if gameType != Tron || modHash == "" {
+		return nil
+	}
+
+	m, ok := findTronMod(modHash)
+
+	if !ok {
+		return fmt.Errorf("missing mod %s", modHash)
 	}
+
+	var arena *TronMap
+
+	if mapHash != "" {
+		arena, _ = findTronMap(mapHash)
+	}
+
+	rules, err := m.Rules(modSeed(lobbyID, m), players, arena)
+
+	if err != nil {
+		return err
+	}
+
+	lobby.mu.Lock()
+	lobby.modRules = rules
+	lobby.mu.Unlock()
+
+	return nil
 }
 
 var lastReceivedInp = make(map[string]int)
@@ -251,33 +376,29 @@ func (tg *TronGameView) Init() {
 
 	log.Println("RAFT SERVER:", &tg.RaftServer)
 
-	width, height := tg.mgr.screen.displaySize()
-
-	clientStates := make(map[string]TronClientState)
-	startingPos, startingDir := tg.getStartingPosAndDir()
+	tg.CommitedGameState = tg.startingState(tg.getTimestep())
+	tg.WorkingGameState = tg.CommitedGameState
+	tg.WorkingGameState.Collisions = tg.initCollisions()
 
-	for i, playerID := range tg.PlayerIDs {
-		x := startingPos[i][0]
-		y := startingPos[i][1]
-		clientStates[playerID] = TronClientState{tg.getTimestep(), true, TRON_COLORS[i], x, y, startingDir[i], i}
+	for playerID, clientState := range tg.CommitedGameState.ClientStates {
 		lastReceivedInp[playerID] = 0
 
 		if playerID == tg.Me {
-			tg.LatestInputDir = startingDir[i]
+			tg.LatestInputDir = clientState.Direction
 		}
 	}
 
 	tg.NextDir = -1
-
-	tg.CommitedGameState = TronGameState{width, height, false, "", tg.initCollisions(), clientStates, -1}
-	tg.WorkingGameState = TronGameState{width, height, false, "", tg.initCollisions(), clientStates, -1}
 	mu.Unlock()
 	tg.startApplyChanHandler()
 
 	go func() {
+		defer tg.mgr.recoverCrash()
 
 		for i := 3; i > 0; i-- {
 			countdownNum = i
+			playSound(SoundCountdown)
+			announce("Game starting in %d", i)
 			mu.RLock()
 			tg.mgr.RequestRender()
 			mu.RUnlock()
@@ -289,6 +410,9 @@ func (tg *TronGameView) Init() {
 
 		tg.gameRenderState = TronGameScreen
 		lastTimestep := -1
+		alive := tronAliveCount(tg.CommitedGameState)
+		meAlive := true
+		shrinking := false
 		for !tg.CommitedGameState.Ended {
 			c.Wait()
 
@@ -301,6 +425,32 @@ func (tg *TronGameView) Init() {
 
 			// update gamestate and render for previous timestep
 			tg.updateWorkingGameState(timestep - 1)
+			tg.spectate.send(tg.WorkingGameState, false)
+			tg.replay.record(tg.WorkingGameState, "", false)
+
+			if stillAlive := tronAliveCount(tg.CommitedGameState); stillAlive < alive {
+				playSound(SoundCollision)
+
+				if me, ok := tg.CommitedGameState.ClientStates[tg.Me]; ok && !me.Alive && meAlive {
+					announce("You crashed, %d still riding", stillAlive)
+					meAlive = false
+				} else {
+					announce("Someone crashed, %d still riding", stillAlive)
+				}
+
+				alive = stillAlive
+			}
+
+			if state := tg.CommitedGameState; !shrinking && state.Shrink.Inset(state.Tick) > 0 {
+				shrinking = true
+				playSound(SoundCountdown)
+
+				if state.Timer.SuddenDeath(state.Tick) {
+					announce("Time's up, sudden death. The arena's closing in")
+				} else {
+					announce("The arena's closing in")
+				}
+			}
 
 			tg.mgr.RequestRender()
 
@@ -314,6 +464,11 @@ func (tg *TronGameView) Init() {
 			}
 			tg.mgr.RUnlock()
 
+			if tg.Me != tg.HostID && timestep-tg.lastChecksum >= stateChecksumInterval {
+				tg.sendChecksum()
+				tg.lastChecksum = timestep
+			}
+
 			// send command for current timestep
 			tg.updateSelf()
 			tg.WorkingGameState = tg.clientPredict(tg.WorkingGameState, 1, []string{tg.Me})
@@ -322,8 +477,30 @@ func (tg *TronGameView) Init() {
 		}
 
 		tg.gameRenderState = TronWinScreen
+		tg.spectate.send(tg.WorkingGameState, true)
+
+		if tg.Me == tg.HostID {
+			tg.replay.conclude("", newStateChecksum(tg.CommitedGameState.CommitedTimeStep, tg.CommitedGameState))
+		}
+
+		tg.replay.record(tg.WorkingGameState, tg.WorkingGameState.Winner, true)
+		won := tg.WorkingGameState.Winner == tg.Me
+		result := MatchResult{
+			GameID:   tg.ID,
+			GameType: Tron,
+			Players:  tg.PlayerIDs,
+			Winner:   tg.WorkingGameState.Winner,
+		}
 		mu.Unlock()
 
+		go reportMatchResult(result)
+
+		if won {
+			announce("Game over, you won")
+		} else {
+			announce("Game over, you lost")
+		}
+
 		tg.mgr.RequestRender()
 	}()
 
@@ -334,20 +511,36 @@ func (tg *TronGameView) ProcessEvent(ev interface{}) {
 	case *tcell.EventKey:
 		if ev.Key() == tcell.KeyEnter {
 			mu.RLock()
-			if tg.CommitedGameState.Ended {
+			ended := tg.CommitedGameState.Ended
+			mu.RUnlock()
+
+			if ended {
 				// tg.RaftServer.Kill()
 				// arcade.Server.EndAllHeartbeats()
 				// lobby := tg.lobby
-				mu.RUnlock()
 				tg.mgr.SetView(NewGamesListView(tg.mgr)) //TODO: change this to the lobby view
+				return
 			}
+		}
 
+		if tg.chat.ProcessEvent(ev) {
+			tg.mgr.RequestRender()
 			return
 		}
+
 		tg.ProcessEventKey(ev)
 	}
 }
 
+// Keymap returns the game keys, or nil while typing a chat message.
+func (tg *TronGameView) Keymap() *Keymap {
+	if tg.chat.Typing() {
+		return nil
+	}
+
+	return gameKeymap
+}
+
 func (tg *TronGameView) ProcessEventKey(ev *tcell.EventKey) {
 
 	key := ev.Key()
@@ -356,17 +549,19 @@ func (tg *TronGameView) ProcessEventKey(ev *tcell.EventKey) {
 	mu.RUnlock()
 	var newDir TronDirection
 
-	switch key {
-	case tcell.KeyCtrlG:
+	if key == tcell.KeyCtrlG {
 		showCommits = !showCommits
 		return
-	case tcell.KeyUp:
+	}
+
+	switch gameKeymap.Action(ev) {
+	case ActionUp:
 		newDir = TronUp
-	case tcell.KeyRight:
+	case ActionRight:
 		newDir = TronRight
-	case tcell.KeyDown:
+	case ActionDown:
 		newDir = TronDown
-	case tcell.KeyLeft:
+	case ActionLeft:
 		newDir = TronLeft
 	}
 
@@ -391,6 +586,34 @@ func (tg *TronGameView) ProcessEventKey(ev *tcell.EventKey) {
 }
 
 func (tg *TronGameView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	if tg.chat.ProcessMessage(p) {
+		return nil
+	}
+
+	switch p := p.(type) {
+	case *StateChecksumMessage:
+		if p.GameID == tg.ID && tg.Me == tg.HostID && tg.desync.Check(p.SenderID, p.Checksum) {
+			tg.sendResync(p.SenderID)
+		}
+
+		return nil
+	case *StateResyncMessage:
+		if p.GameID == tg.ID && p.SenderID == tg.HostID {
+			tg.resync(p.State)
+		}
+
+		return nil
+	case *KickMessage:
+		if p.LobbyID == tg.ID && p.SenderID == tg.HostID {
+			arcade.Server.EndAllHeartbeats()
+			tg.mgr.SetView(NewGamesListView(tg.mgr))
+
+			notify("You were removed from the game")
+		}
+
+		return nil
+	}
+
 	return tg.RaftServer.ProcessMessage(from, p)
 }
 
@@ -409,6 +632,7 @@ func (tg *TronGameView) Render(s *Screen) {
 		myState := tg.getMyState()
 		style := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorNames[myState.Color])
 		chr := getDirChr(myState.Direction)
+		tg.renderWalls(s)
 		s.DrawText(myState.X, myState.Y, style, chr)
 
 		// draw countdown
@@ -424,21 +648,40 @@ func (tg *TronGameView) Render(s *Screen) {
 			s.DrawBlockText(CenterX, CenterY, boxStyle, "GAME OVER", true)
 		}
 
-		s.DrawText((displayWidth-utf8.RuneCountInString(returnToLobbyText))/2, displayHeight-6, boxStyle, returnToLobbyText)
+		s.DrawText(layout.Center(displayWidth, returnToLobbyText), displayHeight-6, boxStyle, returnToLobbyText)
 
+		if report := networkReport(tg.NetworkStart); report != "" {
+			s.DrawText(layout.Center(displayWidth, report), displayHeight-5, boxStyle, report)
+		}
 	}
 
+	tg.chat.Render(s)
 }
 
 func (tg *TronGameView) renderGame(s *Screen) {
 	tg.mgr.RLock()
 	showDebug := tg.mgr.showDebug
 	tg.mgr.RUnlock()
+
+	focusNum := -1
+
+	if cs, ok := tg.WorkingGameState.ClientStates[tg.focus]; ok {
+		focusNum = cs.PlayerNum
+	}
+
+	tg.renderWalls(s)
+	renderTronShrink(s, tg.WorkingGameState)
+	renderTronPowerUps(s, tg.WorkingGameState)
+
 	for row := 0; row < tg.WorkingGameState.Width; row++ {
 		for col := 0; col < tg.WorkingGameState.Height; col++ {
 			if ok, playerNum := tg.getCollision(tg.WorkingGameState.Collisions, row, col); ok && playerNum >= 0 {
 				style := tcell.StyleDefault.Background(tcell.ColorNames[TRON_COLORS[playerNum]])
 
+				if focusNum >= 0 && playerNum != focusNum {
+					style = tcell.StyleDefault.Background(tcell.ColorDarkGray)
+				}
+
 				if showDebug {
 					s.DrawText(row, col, style, "*")
 				} else {
@@ -459,6 +702,15 @@ func (tg *TronGameView) renderGame(s *Screen) {
 	for _, client := range tg.WorkingGameState.ClientStates {
 		if client.Alive {
 			style := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorNames[client.Color])
+
+			if focusNum >= 0 && client.PlayerNum != focusNum {
+				style = style.Foreground(tcell.ColorDarkGray)
+			}
+
+			if client.Ghost > 0 {
+				style = style.Dim(true)
+			}
+
 			chr := getDirChr(client.Direction)
 			s.DrawText(client.X, client.Y, style, chr)
 			if client.Direction == TronLeft {
@@ -471,6 +723,66 @@ func (tg *TronGameView) renderGame(s *Screen) {
 			s.DrawText(client.X, client.Y, style, "😵")
 		}
 	}
+
+	me := tg.Me
+
+	if tg.focus != "" {
+		me = tg.focus
+	}
+
+	hud := tronHUD(tg.WorkingGameState, tg.PlayerIDs, me)
+
+	if warning, ok := connectionWarning(tg.HostID); ok {
+		hud.Right = append([]HUDItem{warning}, hud.Right...)
+	}
+
+	s.DrawHUD(hud)
+}
+
+// renderWalls draws the custom arena's walls, if there is one.
+func (tg *TronGameView) renderWalls(s *Screen) {
+	if tg.arena == nil {
+		return
+	}
+
+	style := tcell.StyleDefault.Background(tcell.ColorTeal)
+
+	for y, row := range tg.arena.Rows {
+		for x := 0; x < len(row); x++ {
+			if row[x] == mapWall {
+				s.DrawText(x, y, style, " ")
+			}
+		}
+	}
+}
+
+// renderTronShrink draws the walls the arena's closed in with, and flashes
+// the cells they're about to cover.
+func renderTronShrink(s *Screen, gameState TronGameState) {
+	schedule := gameState.Shrink
+
+	if schedule == nil {
+		return
+	}
+
+	inset := schedule.Inset(gameState.Tick)
+	x1, y1 := 2, 2
+	x2, y2 := gameState.Width-3, gameState.Height-3
+
+	ring := func(from, to int, style tcell.Style) {
+		for i := from; i < to; i++ {
+			s.DrawEmpty(x1+i, y1+i, x2-i, y1+i, style)
+			s.DrawEmpty(x1+i, y2-i, x2-i, y2-i, style)
+			s.DrawEmpty(x1+i, y1+i, x1+i, y2-i, style)
+			s.DrawEmpty(x2-i, y1+i, x2-i, y2-i, style)
+		}
+	}
+
+	ring(0, inset, tcell.StyleDefault.Background(tcell.ColorGray))
+
+	if schedule.Warning(gameState.Tick) && gameState.Tick/2%2 == 0 {
+		ring(inset, inset+1, tcell.StyleDefault.Background(tcell.ColorDarkRed))
+	}
 }
 
 // JANK: This applies entries in order without processing out of order timesteps. This could cause jumps in game state
@@ -479,6 +791,8 @@ func (tg *TronGameView) renderGame(s *Screen) {
 // ^ maybe not applicable anymore
 func (tg *TronGameView) startApplyChanHandler() {
 	go func() {
+		defer tg.mgr.recoverCrash()
+
 		for {
 			applyMsg := <-tg.ApplyChan
 			// log.Println("[RAFT]", "APPLY")
@@ -492,16 +806,23 @@ func (tg *TronGameView) startApplyChanHandler() {
 				} else if cmd, ok := readLogEntryAsTronCmd(applyMsg.Command); ok {
 					log.Println("Applying: ", cmd, applyMsg.CommandTimestep)
 
-					jumpAhead := math.Max(float64(applyMsg.CommandTimestep-tg.CommitedGameState.CommitedTimeStep-1), 0)
-					log.Println("Jump ahead: ", jumpAhead)
-					newCommitedGameState := tg.clientPredictAll(tg.CommitedGameState, int(jumpAhead))
+					newCommitedGameState, reason := tg.commitCommand(tg.CommitedGameState, cmd, applyMsg.CommandTimestep)
 
-					newCommitedGameState.CommitedTimeStep = applyMsg.CommandTimestep
-					newCommitedGameState = tg.applyCommandToGameState(newCommitedGameState, cmd)
-					newCommitedGameState = tg.clientPredictAll(newCommitedGameState, 1) // current timestep forward
+					// Every player skips impossible moves the same way, but
+					// only the host keeps count of them
+					if reason != "" && tg.Me == tg.HostID {
+						if tg.inputs.Violation(cmd.PlayerID, reason) {
+							go tg.kickPlayer(cmd.PlayerID)
+						}
+					}
 
 					tg.CommitedGameState = newCommitedGameState
 
+					if tg.Me == tg.HostID {
+						tg.desync.Record(newStateChecksum(newCommitedGameState.CommitedTimeStep, newCommitedGameState))
+						tg.replay.input(applyMsg.CommandTimestep, cmd.PlayerID, cmd)
+					}
+
 					tg.truncateMoveQueueIfNecessary(cmd)
 				}
 
@@ -662,12 +983,118 @@ func (tg *TronGameView) updateWorkingGameState(currentTimestep int) {
 
 }
 
+// startingState returns the state every player starts the game from.
+func (tg *TronGameView) startingState(timestep int) TronGameState {
+	width, height := tg.mgr.screen.displaySize()
+
+	clientStates := make(map[string]TronClientState)
+	startingPos, startingDir := tg.getStartingPosAndDir()
+
+	for i, playerID := range tg.PlayerIDs {
+		x := startingPos[i][0]
+		y := startingPos[i][1]
+		clientStates[playerID] = TronClientState{timestep, true, TRON_COLORS[i], x, y, startingDir[i], i, -1, 0}
+	}
+
+	var powerUps []TronPowerUp
+
+	if tg.rules != nil {
+		powerUps = append(powerUps, tg.rules.PowerUps...)
+	}
+
+	return TronGameState{width, height, false, "", tg.initCollisions(), clientStates, -1, 0, tg.timer, tg.shrinkSchedule(width, height), powerUps}
+}
+
+// shrinkSchedule returns when the arena closes in: early on if the lobby
+// asked for it, and when time's up if there's a time limit, whichever's first.
+func (tg *TronGameView) shrinkSchedule(width, height int) *ShrinkSchedule {
+	var schedule *ShrinkSchedule
+
+	// The inside of the arena is from 2 to Width-3 and Height-3
+	if tg.shrinking {
+		schedule = NewShrinkSchedule(arenaShrinkAfter, arenaShrinkEvery, TronTimestepPeriod, width-4, height-4)
+	}
+
+	if tg.timer != nil && (schedule == nil || tg.timer.Limit < schedule.Start) {
+		limit := time.Duration(tg.timer.Limit) * TronTimestepPeriod
+		schedule = NewShrinkSchedule(limit, tronSuddenDeathEvery, TronTimestepPeriod, width-4, height-4)
+	}
+
+	return schedule
+}
+
+// commitCommand moves the committed state up to the command's timestep and
+// applies it. Also returns why the command was skipped, if it was.
+func (tg *TronGameView) commitCommand(gameState TronGameState, cmd TronCommand, timestep int) (TronGameState, string) {
+	jumpAhead := math.Max(float64(timestep-gameState.CommitedTimeStep-1), 0)
+	log.Println("Jump ahead: ", jumpAhead)
+	gameState = tg.clientPredictAll(gameState, int(jumpAhead))
+
+	gameState.CommitedTimeStep = timestep
+	reason := tronMoveViolation(gameState, cmd)
+
+	gameState = tg.applyCommandToGameState(gameState, cmd)
+	gameState = tg.clientPredictAll(gameState, 1) // current timestep forward
+
+	return gameState, reason
+}
+
+// simulateTronReplay plays a game from the commands its host committed, in
+// the order they were committed.
+func simulateTronReplay(replay *Replay) (TronGameState, error) {
+	if !replay.Simulated() {
+		return TronGameState{}, errors.New("replay can't be simulated")
+	}
+
+	arena := replay.Map
+
+	if arena != nil && (arena.Validate() != nil || len(arena.Spawns()) < len(replay.Info.PlayerIDs)) {
+		return TronGameState{}, errors.New("replay's map can't be played on")
+	}
+
+	if replay.Rules != nil && replay.Rules.validate(len(replay.Info.PlayerIDs), arena) != nil {
+		return TronGameState{}, errors.New("replay's mod can't be played")
+	}
+
+	// Only the display's size is needed, which doesn't take a screen
+	tg := &TronGameView{
+		mgr:       &ViewManager{},
+		Game:      Game[TronGameState, TronClientState]{PlayerIDs: replay.Info.PlayerIDs},
+		arena:     arena,
+		rules:     replay.Rules,
+		timer:     NewMatchTimer(time.Duration(replay.Info.TimeLimit)*time.Second, tronSuddenDeathEvery, TronTimestepPeriod),
+		shrinking: replay.Info.ShrinkArena,
+	}
+
+	state := tg.startingState(0)
+
+	// Bounds are checked against the working state
+	tg.WorkingGameState = state
+
+	for _, input := range replay.Inputs {
+		var cmd TronCommand
+
+		if err := json.Unmarshal(input.Input, &cmd); err != nil {
+			return state, err
+		}
+
+		state, _ = tg.commitCommand(state, cmd, input.Tick)
+	}
+
+	return state, nil
+}
+
 // applies game state without increasing timestep
 func (tg *TronGameView) applyCommandToGameState(gameState TronGameState, cmd TronCommand) TronGameState {
 	clientState := gameState.ClientStates[cmd.PlayerID]
 	switch cmd.Type {
 	case TronMoveCmd:
+		if tronMoveViolation(gameState, cmd) != "" {
+			return gameState
+		}
+
 		clientState.Direction = cmd.Direction
+		clientState.LastMove = cmd.Timestep
 	case TronEndGameCmd:
 		gameState.Ended = true
 		gameState.Winner = cmd.Winner
@@ -702,6 +1129,10 @@ func (tg *TronGameView) clientPredict(gameState TronGameState, numTimesteps int,
 	}
 
 	for i := 0; i < numTimesteps; i++ {
+		if gameState.Timer != nil || gameState.Shrink != nil || len(gameState.PowerUps) > 0 {
+			gameState.Tick++
+		}
+
 		for _, playerId := range playerIds {
 			clientState := gameState.ClientStates[playerId]
 			if !clientState.Alive {
@@ -729,9 +1160,15 @@ func (tg *TronGameView) clientPredict(gameState TronGameState, numTimesteps int,
 			clientState.X = newX
 			clientState.Y = newY
 
+			if clientState.Ghost > 0 {
+				clientState.Ghost--
+			}
+
 			gameState.ClientStates[playerId] = clientState
 		}
 
+		gameState = tg.pickUpPowerUps(gameState)
+
 		// can def optimize out this 2nd loop
 		for playerId, clientState := range gameState.ClientStates {
 			if tg.shouldDie(clientState, gameState) {
@@ -744,6 +1181,14 @@ func (tg *TronGameView) clientPredict(gameState TronGameState, numTimesteps int,
 
 // GAME FUNCTIONS
 func (tg *TronGameView) getStartingPosAndDir() ([][2]int, []TronDirection) {
+	if tg.rules != nil && tg.rules.Spawns != nil {
+		return tg.rules.Spawns, tg.rules.Dirs
+	}
+
+	if tg.arena != nil {
+		return tg.arena.StartingPosAndDir()
+	}
+
 	width, height := tg.mgr.screen.displaySize()
 	width -= 1 // account for tron border
 	height -= 1
@@ -752,8 +1197,32 @@ func (tg *TronGameView) getStartingPosAndDir() ([][2]int, []TronDirection) {
 }
 
 func (tg *TronGameView) shouldDie(player TronClientState, gameState TronGameState) bool {
-	collides, _ := tg.getCollision(gameState.Collisions, player.X, player.Y)
-	return tg.isOutOfBounds(player.X, player.Y) || collides
+	collides, playerNum := tg.getCollision(gameState.Collisions, player.X, player.Y)
+
+	// Ghosts go through trails, but not walls
+	if player.Ghost > 0 && playerNum >= 0 {
+		collides = false
+	}
+
+	return tg.isOutOfBounds(player.X, player.Y) || tronInShrunkWall(gameState, player.X, player.Y) || collides
+}
+
+// tronInShrunkWall returns true if the cell's been walled off by the arena
+// closing in.
+func tronInShrunkWall(gameState TronGameState, x, y int) bool {
+	return gameState.Shrink.Closed(gameState.Tick, x, y, 2, 2, gameState.Width-3, gameState.Height-3)
+}
+
+func tronAliveCount(gameState TronGameState) int {
+	alive := 0
+
+	for _, client := range gameState.ClientStates {
+		if client.Alive {
+			alive++
+		}
+	}
+
+	return alive
 }
 
 func (tg *TronGameView) die(player TronClientState) TronClientState {
@@ -786,7 +1255,11 @@ func (tg *TronGameView) setCollision(collisions []byte, x int, y int, playerNum
 	width, _ := tg.mgr.screen.displaySize()
 	if !tg.isOutOfBounds(x, y) && playerNum < 8 {
 		ind := y*width + x
-		collisions[ind/2] |= byte(playerNum<<1+1) << ((ind % 2) * 4)
+
+		// Ghosts leave the trails they cross as they were
+		if collisions[ind/2]>>((ind%2)*4)&1 == 0 {
+			collisions[ind/2] |= byte(playerNum<<1+1) << ((ind % 2) * 4)
+		}
 	}
 	return collisions
 }
@@ -798,6 +1271,10 @@ func (tg *TronGameView) getTimestep() int {
 // returns bool of collision and player num
 func (tg *TronGameView) getCollision(collisions []byte, x int, y int) (bool, int) {
 	width, _ := tg.mgr.screen.displaySize()
+	if tg.arena != nil && tg.arena.Wall(x, y) {
+		return true, -1
+	}
+
 	if !tg.isOutOfBounds(x, y) {
 		ind := y*width + x
 		offset := ((ind % 2) * 4)
@@ -812,6 +1289,93 @@ func (tg *TronGameView) getCollision(collisions []byte, x int, y int) (bool, int
 	return true, -1
 }
 
+// tronMoveViolation returns why a move command can't be taken, or "" if it
+// can. Players can't turn back into their own trail, or turn twice in one
+// timestep.
+func tronMoveViolation(gameState TronGameState, cmd TronCommand) string {
+	if cmd.Type != TronMoveCmd {
+		return ""
+	}
+
+	clientState, ok := gameState.ClientStates[cmd.PlayerID]
+
+	if !ok {
+		return "not a player"
+	}
+
+	if cmd.Direction < TronUp || cmd.Direction > TronLeft {
+		return fmt.Sprintf("unknown direction %d", cmd.Direction)
+	}
+
+	if cmd.Direction != clientState.Direction && !canMoveInDir(clientState.Direction, cmd.Direction) {
+		return "reversed direction"
+	}
+
+	if cmd.Timestep <= clientState.LastMove {
+		return fmt.Sprintf("second move in timestep %d", cmd.Timestep)
+	}
+
+	return ""
+}
+
+// kickPlayer sends a cheating player back to the games list. Their light
+// cycle keeps going until it crashes.
+func (tg *TronGameView) kickPlayer(playerID string) {
+	arcade.Server.EndHeartbeats(playerID)
+
+	if client, ok := arcade.Server.Network.GetClient(playerID); ok {
+		arcade.Server.Network.Send(client, NewKickMessage(tg.ID))
+	}
+
+	notify("%s was removed for impossible inputs", shortID(playerID, 8))
+}
+
+// sendChecksum tells the host what our committed state is. Expects mu to be
+// held.
+func (tg *TronGameView) sendChecksum() {
+	if host, ok := arcade.Server.Network.GetClient(tg.HostID); ok {
+		checksum := newStateChecksum(tg.CommitedGameState.CommitedTimeStep, tg.CommitedGameState)
+		arcade.Server.Network.Send(host, NewStateChecksumMessage(tg.ID, checksum))
+	}
+}
+
+// sendResync sends our committed state to a player who drifted from it.
+func (tg *TronGameView) sendResync(playerID string) {
+	mu.RLock()
+	data, err := json.Marshal(tg.CommitedGameState)
+	mu.RUnlock()
+
+	if err != nil {
+		return
+	}
+
+	if client, ok := arcade.Server.Network.GetClient(playerID); ok {
+		arcade.Server.Network.Send(client, NewStateResyncMessage(tg.ID, data))
+	}
+}
+
+// resync replaces our committed state with the host's. It's only taken if
+// we're at the same point in the log, since entries already applied on the
+// host would otherwise be applied twice.
+func (tg *TronGameView) resync(data json.RawMessage) {
+	var state TronGameState
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if state.CommitedTimeStep != tg.CommitedGameState.CommitedTimeStep {
+		log.Println("Skipped resync at", state.CommitedTimeStep, "while at", tg.CommitedGameState.CommitedTimeStep)
+		return
+	}
+
+	log.Println("Resynced with the host at", state.CommitedTimeStep)
+	tg.CommitedGameState = state
+}
+
 func canMoveInDir(currentDir TronDirection, proposedDir TronDirection) bool {
 	if currentDir == TronDown || currentDir == TronUp {
 		return proposedDir == TronLeft || proposedDir == TronRight
@@ -892,6 +1456,17 @@ func (v *TronGameView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
 	return nil
 }
 
+// AnnotateHeartbeat leaves out the state hash, since the committed state is
+// changed by raft without a lock to read it under.
+func (tg *TronGameView) AnnotateHeartbeat(md *HeartbeatMetadata) {
+	md.Lobby = &LobbySummary{ID: tg.ID, Name: tg.Name, HostID: tg.HostID, GameType: Tron, Capacity: len(tg.PlayerIDs), Players: len(tg.PlayerIDs), State: LobbyInGame}
+	md.Players = len(tg.PlayerIDs)
+}
+
+func (tg *TronGameView) StatusInfo() StatusInfo {
+	return StatusInfo{LobbyName: tg.Name, HostID: tg.HostID, UnreadChat: tg.chat.Unread()}
+}
+
 func (tg *TronGameView) Unload() {
 	tg.RaftServer.Kill()
 }
@@ -0,0 +1,508 @@
+package arcade
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	MAPS_DIRNAME  = "maps"
+	MAP_EXTENSION = ".json"
+
+	// What the rows of a map are made of
+	mapFloor = '.'
+	mapWall  = '#'
+
+	// Longest map name, which is also its file name
+	maxMapNameLength = 24
+
+	// What the map selector calls the empty arena
+	classicMapName = "classic"
+
+	// Size of a map file we'll read
+	maxMapSize = 2 * 1024
+)
+
+var (
+	errMapSize        = fmt.Errorf("maps are %dx%d", displayWidth, displayHeight)
+	errMapCell        = errors.New("maps can only have floor, walls and spawns 1 to 8")
+	errMapSpawns      = errors.New("maps need at least 2 spawns")
+	errMapSpawnOrder  = errors.New("spawns need numbering from 1, each used once")
+	errMapUnreachable = errors.New("every spawn has to be reachable from the others")
+	errMapName        = errors.New("maps need a name of letters, numbers, spaces, - and _")
+)
+
+// TronMap is a custom Tron arena, the size of the display. Players start on
+// its spawns in the order they joined, and crash into its walls the same as
+// trails.
+type TronMap struct {
+	Name string
+
+	// '#' for a wall, '1' to '8' for a spawn and '.' for the floor. Cells
+	// outside the arena's border are never played on
+	Rows []string
+}
+
+// tronMapJSON is how maps are saved and sent: a bit for each cell that's a
+// wall, and the spawns in order. It fits in a packet, whatever's drawn.
+type tronMapJSON struct {
+	Name   string
+	Walls  []byte
+	Spawns [][2]int
+}
+
+func (m TronMap) MarshalJSON() ([]byte, error) {
+	walls := make([]byte, (displayWidth*displayHeight+7)/8)
+
+	for y, row := range m.Rows {
+		for x := 0; x < len(row) && x < displayWidth; x++ {
+			if row[x] == mapWall {
+				i := y*displayWidth + x
+				walls[i/8] |= 1 << (i % 8)
+			}
+		}
+	}
+
+	return json.Marshal(tronMapJSON{Name: m.Name, Walls: walls, Spawns: m.Spawns()})
+}
+
+func (m *TronMap) UnmarshalJSON(data []byte) error {
+	var file tronMapJSON
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+
+	if len(file.Walls) != (displayWidth*displayHeight+7)/8 {
+		return errMapSize
+	}
+
+	if len(file.Spawns) > len(TRON_COLORS) {
+		return errMapSpawnOrder
+	}
+
+	rows := make([][]byte, displayHeight)
+
+	for y := range rows {
+		rows[y] = make([]byte, displayWidth)
+
+		for x := range rows[y] {
+			i := y*displayWidth + x
+			rows[y][x] = mapFloor
+
+			if file.Walls[i/8]&(1<<(i%8)) != 0 {
+				rows[y][x] = mapWall
+			}
+		}
+	}
+
+	for n, pos := range file.Spawns {
+		if pos[0] < 0 || pos[0] >= displayWidth || pos[1] < 0 || pos[1] >= displayHeight {
+			return errMapCell
+		}
+
+		rows[pos[1]][pos[0]] = byte('1' + n)
+	}
+
+	m.Name = file.Name
+	m.Rows = make([]string, displayHeight)
+
+	for y, row := range rows {
+		m.Rows[y] = string(row)
+	}
+
+	return nil
+}
+
+// NewTronMap returns an empty arena with the first two spawns where the
+// classic arena has them.
+func NewTronMap(name string) *TronMap {
+	rows := make([][]byte, displayHeight)
+
+	for y := range rows {
+		rows[y] = []byte(strings.Repeat(string(mapFloor), displayWidth))
+	}
+
+	tg := &TronGameView{mgr: &ViewManager{}}
+	spawns, _ := tg.getStartingPosAndDir()
+
+	for i, pos := range spawns[:2] {
+		rows[pos[1]][pos[0]] = byte('1' + i)
+	}
+
+	m := &TronMap{Name: name, Rows: make([]string, displayHeight)}
+
+	for y, row := range rows {
+		m.Rows[y] = string(row)
+	}
+
+	return m
+}
+
+// inArena returns whether the cell can be played on, inside the border.
+func (m *TronMap) inArena(x, y int) bool {
+	return x > 1 && x < displayWidth-2 && y > 1 && y < displayHeight-2
+}
+
+// At returns what's in the cell.
+func (m *TronMap) At(x, y int) byte {
+	if y < 0 || y >= len(m.Rows) || x < 0 || x >= len(m.Rows[y]) {
+		return mapWall
+	}
+
+	return m.Rows[y][x]
+}
+
+// Set puts a wall, spawn or floor in the cell, if it's in the arena.
+func (m *TronMap) Set(x, y int, cell byte) {
+	if !m.inArena(x, y) {
+		return
+	}
+
+	row := []byte(m.Rows[y])
+	row[x] = cell
+	m.Rows[y] = string(row)
+}
+
+// Wall returns whether the cell is a wall.
+func (m *TronMap) Wall(x, y int) bool {
+	return m.At(x, y) == mapWall
+}
+
+// Spawns returns where each player starts, in order.
+func (m *TronMap) Spawns() [][2]int {
+	found := make(map[int][2]int)
+
+	for y, row := range m.Rows {
+		for x := 0; x < len(row); x++ {
+			if n, ok := mapSpawnNum(row[x]); ok {
+				found[n] = [2]int{x, y}
+			}
+		}
+	}
+
+	spawns := make([][2]int, 0, len(found))
+
+	for n := 0; n < len(found); n++ {
+		pos, ok := found[n]
+
+		if !ok {
+			break
+		}
+
+		spawns = append(spawns, pos)
+	}
+
+	return spawns
+}
+
+// StartingPosAndDir returns where each player starts and which way they
+// face, which is toward whichever side has the most room.
+func (m *TronMap) StartingPosAndDir() ([][2]int, []TronDirection) {
+	spawns := m.Spawns()
+	dirs := make([]TronDirection, len(spawns))
+
+	for i, pos := range spawns {
+		best := 0
+
+		for _, dir := range []TronDirection{TronUp, TronRight, TronDown, TronLeft} {
+			if room := m.room(pos[0], pos[1], dir); room > best {
+				dirs[i], best = dir, room
+			}
+		}
+	}
+
+	return spawns, dirs
+}
+
+// room returns how many open cells there are going from the cell in the
+// direction.
+func (m *TronMap) room(x, y int, dir TronDirection) int {
+	for i := 0; ; i++ {
+		x, y = tronStep(x, y, dir)
+
+		if !m.inArena(x, y) || m.Wall(x, y) {
+			return i
+		}
+	}
+}
+
+// Validate returns why the map can't be saved or played on, or nil if it can.
+func (m *TronMap) Validate() error {
+	if !validMapName(m.Name) {
+		return errMapName
+	}
+
+	return m.validateLayout()
+}
+
+// validateLayout returns why the map can't be played on, whatever it's
+// called.
+func (m *TronMap) validateLayout() error {
+	if len(m.Rows) != displayHeight {
+		return errMapSize
+	}
+
+	counts := make(map[int]int)
+
+	for y, row := range m.Rows {
+		if len(row) != displayWidth {
+			return errMapSize
+		}
+
+		for x := 0; x < len(row); x++ {
+			n, spawn := mapSpawnNum(row[x])
+
+			switch {
+			case spawn && m.inArena(x, y):
+				counts[n]++
+			case row[x] != mapFloor && row[x] != mapWall:
+				return errMapCell
+			}
+		}
+	}
+
+	if len(counts) < 2 {
+		return errMapSpawns
+	}
+
+	for n := 0; n < len(counts); n++ {
+		if counts[n] != 1 {
+			return errMapSpawnOrder
+		}
+	}
+
+	spawns := m.Spawns()
+	reached := m.reachable(spawns[0])
+
+	for _, pos := range spawns[1:] {
+		if !reached[pos] {
+			return errMapUnreachable
+		}
+	}
+
+	return nil
+}
+
+// reachable returns the cells that can be ridden to from the cell.
+func (m *TronMap) reachable(from [2]int) map[[2]int]bool {
+	reached := map[[2]int]bool{from: true}
+	queue := [][2]int{from}
+
+	for len(queue) > 0 {
+		pos := queue[0]
+		queue = queue[1:]
+
+		for _, dir := range []TronDirection{TronUp, TronRight, TronDown, TronLeft} {
+			x, y := tronStep(pos[0], pos[1], dir)
+			next := [2]int{x, y}
+
+			if reached[next] || !m.inArena(x, y) || m.Wall(x, y) {
+				continue
+			}
+
+			reached[next] = true
+			queue = append(queue, next)
+		}
+	}
+
+	return reached
+}
+
+// Hash identifies the map by its layout, whatever it's called.
+func (m *TronMap) Hash() string {
+	sum := sha256.Sum256([]byte(strings.Join(m.Rows, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// mapSpawnNum returns the player a spawn cell is for, counting from 0.
+func mapSpawnNum(cell byte) (int, bool) {
+	if cell < '1' || cell > '8' {
+		return 0, false
+	}
+
+	return int(cell - '1'), true
+}
+
+// tronStep returns the cell next to the cell in the direction.
+func tronStep(x, y int, dir TronDirection) (int, int) {
+	switch dir {
+	case TronUp:
+		y--
+	case TronRight:
+		x++
+	case TronDown:
+		y++
+	case TronLeft:
+		x--
+	}
+
+	return x, y
+}
+
+// validMapName returns true if the name is safe to use as a file name.
+func validMapName(name string) bool {
+	if strings.TrimSpace(name) == "" || len(name) > maxMapNameLength {
+		return false
+	}
+
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == ' ', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func mapsDir() (string, error) {
+	dir, err := configDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, MAPS_DIRNAME), nil
+}
+
+// saveTronMap writes the map to the maps directory, replacing any with the
+// same name.
+func saveTronMap(m *TronMap) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	dir, err := mapsDir()
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(m)
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path.Join(dir, m.Name+MAP_EXTENSION), data, 0644)
+}
+
+// loadTronMaps returns the playable maps in the maps directory, by name.
+func loadTronMaps() []*TronMap {
+	dir, err := mapsDir()
+
+	if err != nil {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+
+	// Including when no maps have been made yet
+	if err != nil {
+		return nil
+	}
+
+	maps := make([]*TronMap, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), MAP_EXTENSION) {
+			continue
+		}
+
+		data, err := os.ReadFile(path.Join(dir, entry.Name()))
+
+		if err != nil || len(data) > maxMapSize {
+			continue
+		}
+
+		var m TronMap
+
+		if json.Unmarshal(data, &m) != nil || m.Validate() != nil {
+			continue
+		}
+
+		maps = append(maps, &m)
+	}
+
+	sort.Slice(maps, func(i, j int) bool {
+		return maps[i].Name < maps[j].Name
+	})
+
+	return maps
+}
+
+// loadTronMap returns the map with the name, if we have it.
+func loadTronMap(name string) (*TronMap, bool) {
+	for _, m := range loadTronMaps() {
+		if m.Name == name {
+			return m, true
+		}
+	}
+
+	return nil, false
+}
+
+// findTronMap returns a map with the layout, if we have one.
+func findTronMap(hash string) (*TronMap, bool) {
+	for _, m := range loadTronMaps() {
+		if m.Hash() == hash {
+			return m, true
+		}
+	}
+
+	return nil, false
+}
+
+// deleteTronMap removes the map from the maps directory.
+func deleteTronMap(name string) error {
+	if !validMapName(name) {
+		return errMapName
+	}
+
+	dir, err := mapsDir()
+
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path.Join(dir, name+MAP_EXTENSION))
+}
+
+// keepSharedMap saves a map another player sent us, unless we have it already.
+// It's renamed if we have a different map by the same name.
+func keepSharedMap(m *TronMap) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := findTronMap(m.Hash()); ok {
+		return nil
+	}
+
+	if _, ok := loadTronMap(m.Name); ok {
+		suffix := "-" + m.Hash()[:6]
+		name := m.Name
+
+		if len(name)+len(suffix) > maxMapNameLength {
+			name = name[:maxMapNameLength-len(suffix)]
+		}
+
+		m.Name = name + suffix
+	}
+
+	return saveTronMap(m)
+}
@@ -0,0 +1,105 @@
+package arcade
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewTronMapIsPlayable(t *testing.T) {
+	m := NewTronMap("Open")
+
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if spawns := m.Spawns(); len(spawns) != 2 {
+		t.Errorf("got %d spawns", len(spawns))
+	}
+
+	if err := (&TronMap{Name: "../escape", Rows: m.Rows}).Validate(); err != errMapName {
+		t.Errorf("unsafe name got %v", err)
+	}
+}
+
+func TestTronMapSpawnsMustBeReachable(t *testing.T) {
+	m := NewTronMap("Boxed")
+	spawn := m.Spawns()[0]
+
+	// Wall in the first spawn
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			if dx != 0 || dy != 0 {
+				m.Set(spawn[0]+dx, spawn[1]+dy, mapWall)
+			}
+		}
+	}
+
+	if err := m.Validate(); err != errMapUnreachable {
+		t.Errorf("walled in spawn got %v", err)
+	}
+
+	// Opening one side lets it out
+	m.Set(spawn[0]+1, spawn[1], mapFloor)
+
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, dirs := m.StartingPosAndDir()
+
+	if dirs[0] != TronRight {
+		t.Errorf("spawn faces %d instead of the opening", dirs[0])
+	}
+}
+
+func TestTronMapSpawnsAreNumberedInOrder(t *testing.T) {
+	m := NewTronMap("Gaps")
+	m.Set(10, 10, '4')
+
+	if err := m.Validate(); err != errMapSpawnOrder {
+		t.Errorf("skipping spawn 3 got %v", err)
+	}
+
+	m.Set(12, 10, '3')
+
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if spawns := m.Spawns(); len(spawns) != 4 || spawns[3] != [2]int{10, 10} {
+		t.Errorf("got spawns %v", spawns)
+	}
+}
+
+func TestTronMapFitsInAPacket(t *testing.T) {
+	m := NewTronMap("Checkerboard of walls")
+
+	for y := 0; y < displayHeight; y++ {
+		for x := (y % 2); x < displayWidth; x += 2 {
+			m.Set(x, y, mapWall)
+		}
+	}
+
+	m.Set(2, 2, '1')
+	m.Set(3, 2, '2')
+
+	data, err := NewMapShareMessage("lobby", m).MarshalBinary()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data) > 1000 {
+		t.Errorf("map share is %d bytes", len(data))
+	}
+
+	var msg MapShareMessage
+
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.Map.Hash() != m.Hash() {
+		t.Error("map changed on the way")
+	}
+}
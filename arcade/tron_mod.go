@@ -0,0 +1,168 @@
+package arcade
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	MODS_DIRNAME  = "mods"
+	MOD_EXTENSION = ".lua"
+
+	// Size of a mod script we'll read or run
+	maxModSize = 8 * 1024
+)
+
+var (
+	errModName = errors.New("mods need a name of letters, numbers, spaces, - and _")
+	errModSize = errors.New("mod scripts can't be bigger than 8KB")
+)
+
+// TronMod is a Lua script that changes how a Tron game's set up: where
+// players start, and which power-ups turn up where and when. Mods are kept in
+// the mods directory, named for their file, and sent to everyone in a lobby
+// that uses one, so all the players run the same script.
+type TronMod struct {
+	Name   string
+	Source string
+}
+
+// Hash identifies the mod by its script, whatever it's called.
+func (m *TronMod) Hash() string {
+	sum := sha256.Sum256([]byte(m.Source))
+	return hex.EncodeToString(sum[:])
+}
+
+// Validate returns why the mod can't be used, or nil if it can. The script
+// isn't run, only checked that it parses.
+func (m *TronMod) Validate() error {
+	if !validMapName(m.Name) {
+		return errModName
+	}
+
+	if len(m.Source) > maxModSize {
+		return errModSize
+	}
+
+	return checkModSyntax(m)
+}
+
+func modsDir() (string, error) {
+	dir, err := configDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, MODS_DIRNAME), nil
+}
+
+// saveTronMod writes the mod to the mods directory, replacing any with the
+// same name.
+func saveTronMod(m *TronMod) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	dir, err := modsDir()
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path.Join(dir, m.Name+MOD_EXTENSION), []byte(m.Source), 0644)
+}
+
+// loadTronMods returns the usable mods in the mods directory, by name.
+func loadTronMods() []*TronMod {
+	dir, err := modsDir()
+
+	if err != nil {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+
+	// Including when there's no mods directory yet
+	if err != nil {
+		return nil
+	}
+
+	mods := make([]*TronMod, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), MOD_EXTENSION) {
+			continue
+		}
+
+		data, err := os.ReadFile(path.Join(dir, entry.Name()))
+
+		if err != nil || len(data) > maxModSize {
+			continue
+		}
+
+		m := &TronMod{Name: strings.TrimSuffix(entry.Name(), MOD_EXTENSION), Source: string(data)}
+
+		if m.Validate() != nil {
+			continue
+		}
+
+		mods = append(mods, m)
+	}
+
+	sort.Slice(mods, func(i, j int) bool {
+		return mods[i].Name < mods[j].Name
+	})
+
+	return mods
+}
+
+// findTronMod returns a mod with the script, if we have one.
+func findTronMod(hash string) (*TronMod, bool) {
+	for _, m := range loadTronMods() {
+		if m.Hash() == hash {
+			return m, true
+		}
+	}
+
+	return nil, false
+}
+
+// keepSharedMod saves a mod another player sent us, unless we have it
+// already. It's renamed if we have a different mod by the same name.
+func keepSharedMod(m *TronMod) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	if _, ok := findTronMod(m.Hash()); ok {
+		return nil
+	}
+
+	for _, other := range loadTronMods() {
+		if other.Name != m.Name {
+			continue
+		}
+
+		suffix := "-" + m.Hash()[:6]
+		name := m.Name
+
+		if len(name)+len(suffix) > maxMapNameLength {
+			name = name[:maxMapNameLength-len(suffix)]
+		}
+
+		m.Name = name + suffix
+		break
+	}
+
+	return saveTronMod(m)
+}
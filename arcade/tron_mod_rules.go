@@ -0,0 +1,469 @@
+package arcade
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	// How many instructions a mod's script gets to set up a game, and how
+	// many bytes of strings it can make while it does. Counted rather than
+	// timed, so a script stops at the same point on every player's machine.
+	modInstructionLimit = 2000000
+	modMemoryLimit      = 16 << 20
+
+	// Most power-ups a mod can put in one game
+	maxModPowerUps = 64
+
+	// Latest timestep a power-up can turn up at
+	maxModPowerUpTick = 100000
+)
+
+// What power-ups do when they're picked up
+const (
+	tronPowerUpGhost = "ghost" // pass through trails for a while
+	tronPowerUpClear = "clear" // wipe your own trail
+)
+
+var (
+	errModLimits   = errors.New("mod took too long or used too much memory")
+	errModConcat   = errors.New("mod joins strings with '..', which can't be measured, use table.concat instead")
+	errModSpawns   = errors.New("mod needs a spawn for every player, in the arena and each on its own")
+	errModPowerUps = fmt.Errorf("mod's power-ups need to be in the arena, %d at most", maxModPowerUps)
+)
+
+// Globals that could reach outside the sandbox, or let a script get around
+// its limits
+var modBlockedGlobals = []string{"collectgarbage", "dofile", "getfenv", "load", "loadfile", "loadstring", "module", "newproxy", "print", "require", "setfenv", "_printregs"}
+
+// TronPowerUp turns up in the arena at a timestep, and stays until someone
+// drives over it.
+type TronPowerUp struct {
+	Tick int
+	X    int
+	Y    int
+	Kind string
+}
+
+// TronRules is what a mod sets a game up with. Spawns are nil to use the
+// arena's own.
+type TronRules struct {
+	Spawns   [][2]int
+	Dirs     []TronDirection
+	PowerUps []TronPowerUp
+}
+
+// modSeed is what a mod's random numbers are drawn from in a lobby. Players
+// only learn the match's seed once it's over, so the lobby and mod are used
+// instead, which everyone knows when the game starts.
+func modSeed(lobbyID string, m *TronMod) int64 {
+	sum := sha256.Sum256([]byte(lobbyID + m.Hash()))
+	return int64(binary.BigEndian.Uint64(sum[:8]))
+}
+
+// modBudget counts what a mod's script does, and stops it once it's done too
+// much. The interpreter asks its context whether it's done before every
+// instruction, which is where they're counted.
+type modBudget struct {
+	instructions int
+	bytes        int
+	done         chan struct{}
+}
+
+func newModBudget() *modBudget {
+	return &modBudget{done: make(chan struct{})}
+}
+
+func (b *modBudget) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (b *modBudget) Done() <-chan struct{} {
+	if b.instructions++; b.instructions > modInstructionLimit {
+		b.stop()
+	}
+
+	return b.done
+}
+
+func (b *modBudget) Err() error {
+	select {
+	case <-b.done:
+		return errModLimits
+	default:
+		return nil
+	}
+}
+
+func (b *modBudget) Value(key interface{}) interface{} {
+	return nil
+}
+
+// charge counts a string the script's about to make. It returns false, and
+// stops the script, if that's more than it has left.
+func (b *modBudget) charge(bytes int) bool {
+	if b.bytes += bytes; b.bytes > modMemoryLimit {
+		b.stop()
+		return false
+	}
+
+	return true
+}
+
+func (b *modBudget) stop() {
+	select {
+	case <-b.done:
+	default:
+		close(b.done)
+	}
+}
+
+// newModState returns a Lua interpreter that can only do arithmetic, strings
+// and tables. There's no way to read files, reach the network or tell the
+// time, so the script does the same thing on every player's machine. It
+// stops when it's spent the budget.
+func newModState(seed int64, budget *modBudget) *lua.LState {
+	L := lua.NewState(lua.Options{
+		SkipOpenLibs:    true,
+		CallStackSize:   64,
+		RegistrySize:    1024,
+		RegistryMaxSize: 64 * 1024,
+	})
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	for _, name := range modBlockedGlobals {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	// Tables only grow an entry an instruction, so the instruction limit
+	// keeps them small. Strings can grow much faster, so anything that
+	// makes one is charged for it first, by the most it could be. Ones that
+	// can't be told in advance are taken out.
+	if strs, ok := L.GetGlobal(lua.StringLibName).(*lua.LTable); ok {
+		for _, name := range []string{"dump", "format", "gsub", "rep"} {
+			strs.RawSetString(name, lua.LNil)
+		}
+
+		for _, name := range []string{"lower", "reverse", "sub", "upper"} {
+			meterModFunc(L, strs, name, budget, func(L *lua.LState) int {
+				return len(L.CheckString(1))
+			})
+		}
+
+		meterModFunc(L, strs, "char", budget, func(L *lua.LState) int {
+			return L.GetTop()
+		})
+	}
+
+	if tables, ok := L.GetGlobal(lua.TabLibName).(*lua.LTable); ok {
+		meterModFunc(L, tables, "concat", budget, func(L *lua.LState) int {
+			t := L.CheckTable(1)
+			size := len(L.OptString(2, "")) * t.Len()
+
+			t.ForEach(func(_, v lua.LValue) {
+				if s, ok := v.(lua.LString); ok {
+					size += len(s)
+				} else if _, ok := v.(lua.LNumber); ok {
+					size += 32
+				}
+			})
+
+			return size
+		})
+	}
+
+	// Random numbers come from the lobby's seed instead
+	if maths, ok := L.GetGlobal(lua.MathLibName).(*lua.LTable); ok {
+		maths.RawSetString("random", lua.LNil)
+		maths.RawSetString("randomseed", lua.LNil)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+
+	// random() is in [0, 1), and random(n) is a whole number from 1 to n
+	L.SetGlobal("random", L.NewFunction(func(L *lua.LState) int {
+		if L.GetTop() == 0 {
+			L.Push(lua.LNumber(rng.Float64()))
+			return 1
+		}
+
+		n := L.CheckInt(1)
+
+		if n < 1 {
+			L.ArgError(1, "needs to be at least 1")
+		}
+
+		L.Push(lua.LNumber(rng.Intn(n) + 1))
+		return 1
+	}))
+
+	return L
+}
+
+// meterModFunc replaces the library function with one that charges the
+// budget for what size says it could make, before making it.
+func meterModFunc(L *lua.LState, lib *lua.LTable, name string, budget *modBudget, size func(*lua.LState) int) {
+	fn, ok := lib.RawGetString(name).(*lua.LFunction)
+
+	if !ok || !fn.IsG {
+		return
+	}
+
+	lib.RawSetString(name, L.NewFunction(func(L *lua.LState) int {
+		if !budget.charge(size(L)) {
+			L.RaiseError(errModLimits.Error())
+		}
+
+		return fn.GFunction(L)
+	}))
+}
+
+// loadMod compiles the mod's script, returning an error if it doesn't parse
+// or does something its budget can't measure.
+func loadMod(L *lua.LState, m *TronMod) (*lua.LFunction, error) {
+	fn, err := L.LoadString(m.Source)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if modConcatenates(fn.Proto) {
+		return nil, errModConcat
+	}
+
+	return fn, nil
+}
+
+// modConcatenates returns whether the compiled function, or any defined in
+// it, joins strings with '..'.
+func modConcatenates(proto *lua.FunctionProto) bool {
+	for _, inst := range proto.Code {
+		if int(inst>>26) == lua.OP_CONCAT {
+			return true
+		}
+	}
+
+	for _, inner := range proto.FunctionPrototypes {
+		if modConcatenates(inner) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkModSyntax returns an error if the mod's script doesn't parse, or
+// couldn't be run.
+func checkModSyntax(m *TronMod) error {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	defer L.Close()
+
+	_, err := loadMod(L, m)
+	return err
+}
+
+// Rules runs the mod to set up a game for the players, on the arena, or the
+// classic one if it's nil. The script can define either of:
+//
+//	spawns(players, width, height) -> {{x=, y=, dir="up"|"right"|"down"|"left"}, ...}
+//	powerups(width, height) -> {{tick=, x=, y=, kind="ghost"|"clear"}, ...}
+//
+// Cells are counted from 0, and the arena inside its border is from 2 to
+// width-3 and height-3.
+func (m *TronMod) Rules(seed int64, players int, arena *TronMap) (*TronRules, error) {
+	if len(m.Source) > maxModSize {
+		return nil, errModSize
+	}
+
+	budget := newModBudget()
+
+	L := newModState(seed, budget)
+	defer L.Close()
+
+	fn, err := loadMod(L, m)
+
+	if err != nil {
+		return nil, err
+	}
+
+	L.SetContext(budget)
+	rules, err := runMod(L, fn, players)
+
+	if budget.Err() != nil {
+		return nil, errModLimits
+	} else if err != nil {
+		return nil, err
+	}
+
+	return rules, rules.validate(players, arena)
+}
+
+func runMod(L *lua.LState, fn *lua.LFunction, players int) (*TronRules, error) {
+	if err := L.CallByParam(lua.P{Fn: fn, Protect: true}); err != nil {
+		return nil, err
+	}
+
+	rules := &TronRules{}
+
+	if fn, ok := L.GetGlobal("spawns").(*lua.LFunction); ok {
+		spawns, err := callMod(L, fn, lua.LNumber(players), lua.LNumber(displayWidth), lua.LNumber(displayHeight))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if spawns.Len() > len(TRON_COLORS) {
+			return nil, errModSpawns
+		}
+
+		for i := 1; i <= spawns.Len(); i++ {
+			spawn, ok := spawns.RawGetInt(i).(*lua.LTable)
+
+			if !ok {
+				return nil, errModSpawns
+			}
+
+			x, okX := luaInt(spawn, "x")
+			y, okY := luaInt(spawn, "y")
+			dir, okDir := tronDirectionNamed(lua.LVAsString(spawn.RawGetString("dir")))
+
+			if !okX || !okY || !okDir {
+				return nil, errModSpawns
+			}
+
+			rules.Spawns = append(rules.Spawns, [2]int{x, y})
+			rules.Dirs = append(rules.Dirs, dir)
+		}
+	}
+
+	if fn, ok := L.GetGlobal("powerups").(*lua.LFunction); ok {
+		powerUps, err := callMod(L, fn, lua.LNumber(displayWidth), lua.LNumber(displayHeight))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if powerUps.Len() > maxModPowerUps {
+			return nil, errModPowerUps
+		}
+
+		for i := 1; i <= powerUps.Len(); i++ {
+			powerUp, ok := powerUps.RawGetInt(i).(*lua.LTable)
+
+			if !ok {
+				return nil, errModPowerUps
+			}
+
+			tick, okTick := luaInt(powerUp, "tick")
+			x, okX := luaInt(powerUp, "x")
+			y, okY := luaInt(powerUp, "y")
+
+			if !okTick || !okX || !okY {
+				return nil, errModPowerUps
+			}
+
+			rules.PowerUps = append(rules.PowerUps, TronPowerUp{tick, x, y, lua.LVAsString(powerUp.RawGetString("kind"))})
+		}
+	}
+
+	return rules, nil
+}
+
+// callMod calls a function the mod defined, which should return a table.
+func callMod(L *lua.LState, fn *lua.LFunction, args ...lua.LValue) (*lua.LTable, error) {
+	if err := L.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}, args...); err != nil {
+		return nil, err
+	}
+
+	ret := L.Get(-1)
+	L.Pop(1)
+
+	table, ok := ret.(*lua.LTable)
+
+	if !ok {
+		return nil, fmt.Errorf("mod returned a %s, not a table", ret.Type())
+	}
+
+	return table, nil
+}
+
+// luaInt returns the table's field, if it's a whole number that could be a
+// cell or a timestep.
+func luaInt(t *lua.LTable, key string) (int, bool) {
+	n, ok := t.RawGetString(key).(lua.LNumber)
+
+	if !ok || n < 0 || n > maxModPowerUpTick || float64(n) != math.Trunc(float64(n)) {
+		return 0, false
+	}
+
+	return int(n), true
+}
+
+func tronDirectionNamed(name string) (TronDirection, bool) {
+	switch name {
+	case "up":
+		return TronUp, true
+	case "right":
+		return TronRight, true
+	case "down":
+		return TronDown, true
+	case "left":
+		return TronLeft, true
+	}
+
+	return 0, false
+}
+
+// validate returns an error unless everything the mod set up can be played.
+func (r *TronRules) validate(players int, arena *TronMap) error {
+	playable := func(x, y int) bool {
+		inside := x >= 2 && x <= displayWidth-3 && y >= 2 && y <= displayHeight-3
+		return inside && (arena == nil || !arena.Wall(x, y))
+	}
+
+	if r.Spawns != nil {
+		if len(r.Spawns) < players {
+			return errModSpawns
+		}
+
+		taken := make(map[[2]int]bool)
+
+		for _, spawn := range r.Spawns {
+			if !playable(spawn[0], spawn[1]) || taken[spawn] {
+				return errModSpawns
+			}
+
+			taken[spawn] = true
+		}
+	}
+
+	for _, p := range r.PowerUps {
+		known := p.Kind == tronPowerUpGhost || p.Kind == tronPowerUpClear
+
+		if !known || p.Tick < 0 || !playable(p.X, p.Y) {
+			return errModPowerUps
+		}
+	}
+
+	return nil
+}
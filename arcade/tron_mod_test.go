@@ -0,0 +1,221 @@
+package arcade
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testModSource = `
+function spawns(players, width, height)
+	local s = {}
+	for i = 1, players do
+		s[i] = {x = 2 + i * 4, y = height - 3, dir = "up"}
+	end
+	return s
+end
+
+function powerups(width, height)
+	return {
+		{tick = 10, x = random(width - 4) + 1, y = random(height - 4) + 1, kind = "ghost"},
+		{tick = 20, x = 40, y = 12, kind = "clear"},
+	}
+end
+`
+
+func TestTronModRules(t *testing.T) {
+	m := &TronMod{Name: "test", Source: testModSource}
+
+	rules, err := m.Rules(1, 3, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantSpawns := [][2]int{{6, displayHeight - 3}, {10, displayHeight - 3}, {14, displayHeight - 3}}
+
+	if !reflect.DeepEqual(rules.Spawns, wantSpawns) {
+		t.Errorf("spawns = %v, want %v", rules.Spawns, wantSpawns)
+	}
+
+	for _, dir := range rules.Dirs {
+		if dir != TronUp {
+			t.Errorf("direction = %v, want up", dir)
+		}
+	}
+
+	if len(rules.PowerUps) != 2 || rules.PowerUps[1] != (TronPowerUp{20, 40, 12, tronPowerUpClear}) {
+		t.Errorf("power-ups = %v", rules.PowerUps)
+	}
+
+	// Everyone in the lobby has to get the same rules
+	again, _ := m.Rules(1, 3, nil)
+
+	if !reflect.DeepEqual(rules, again) {
+		t.Errorf("rules from the same seed differ: %v and %v", rules, again)
+	}
+}
+
+func TestTronModSandbox(t *testing.T) {
+	for _, source := range []string{
+		`os.exit(1)`,
+		`io.open("/etc/passwd")`,
+		`dofile("/etc/passwd")`,
+		`require("os")`,
+		`load("return 1")()`,
+		`math.random(10)`,
+		`string.rep("x", 1000)`,
+		`return ("x"):rep(1000)`,
+	} {
+		m := &TronMod{Name: "test", Source: source}
+
+		if _, err := m.Rules(1, 2, nil); err == nil {
+			t.Errorf("%q ran in the sandbox", source)
+		}
+	}
+}
+
+func TestTronModLimits(t *testing.T) {
+	for _, source := range []string{
+		`while true do end`,
+		`local s = "xxxxxxxx" while true do s = s .. s end`,
+		`local s = "xxxxxxxx" while true do s = table.concat({s, s}) end`,
+		`local s = "xxxxxxxx" while true do s = table.concat({s, s, s}, s) end`,
+		`local function f() return f() + 1 end f()`,
+	} {
+		m := &TronMod{Name: "test", Source: source}
+
+		if _, err := m.Rules(1, 2, nil); err == nil {
+			t.Errorf("%q wasn't stopped", source)
+		}
+	}
+}
+
+func TestTronModBudget(t *testing.T) {
+	b := newModBudget()
+
+	for i := 0; i < modInstructionLimit; i++ {
+		b.Done()
+	}
+
+	if b.Err() != nil {
+		t.Fatal("stopped before the last instruction it had")
+	}
+
+	b.Done()
+
+	if b.Err() != errModLimits {
+		t.Fatal("ran past its instructions")
+	}
+
+	b = newModBudget()
+
+	if !b.charge(modMemoryLimit) || b.charge(1) || b.Err() != errModLimits {
+		t.Error("made more strings than it had room for")
+	}
+}
+
+func TestTronModConcatRefused(t *testing.T) {
+	m := &TronMod{Name: "test", Source: `function spawns() local f = function(s) return s .. "!" end return {} end`}
+
+	if err := m.Validate(); err == nil {
+		t.Error("a mod joining strings with '..' was accepted")
+	}
+
+	if _, err := m.Rules(1, 2, nil); err != errModConcat {
+		t.Errorf("ran a mod joining strings with '..': %v", err)
+	}
+}
+
+func TestTronModValidation(t *testing.T) {
+	rows := make([]string, displayHeight)
+
+	for y := range rows {
+		rows[y] = strings.Repeat(string(mapFloor), displayWidth)
+	}
+
+	rows[10] = rows[10][:10] + string(mapWall) + rows[10][11:]
+	arena := &TronMap{Name: "walls", Rows: rows}
+
+	for _, c := range []struct {
+		name   string
+		source string
+	}{
+		{"too few spawns", `function spawns() return {{x = 5, y = 5, dir = "up"}} end`},
+		{"shared spawn", `function spawns() return {{x = 5, y = 5, dir = "up"}, {x = 5, y = 5, dir = "down"}} end`},
+		{"spawn outside", `function spawns() return {{x = 0, y = 5, dir = "up"}, {x = 6, y = 5, dir = "down"}} end`},
+		{"spawn in a wall", `function spawns() return {{x = 10, y = 10, dir = "up"}, {x = 6, y = 5, dir = "down"}} end`},
+		{"bad direction", `function spawns() return {{x = 5, y = 5, dir = "north"}, {x = 6, y = 5, dir = "down"}} end`},
+		{"fractional cell", `function spawns() return {{x = 5.5, y = 5, dir = "up"}, {x = 6, y = 5, dir = "down"}} end`},
+		{"unknown power-up", `function powerups() return {{tick = 1, x = 5, y = 5, kind = "laser"}} end`},
+		{"power-up outside", `function powerups() return {{tick = 1, x = 5, y = 50, kind = "ghost"}} end`},
+		{"not a table", `function powerups() return 5 end`},
+	} {
+		m := &TronMod{Name: "test", Source: c.source}
+
+		if _, err := m.Rules(1, 2, arena); err == nil {
+			t.Errorf("%s: rules were accepted", c.name)
+		}
+	}
+}
+
+func TestTronModValidate(t *testing.T) {
+	if err := (&TronMod{Name: "ok", Source: testModSource}).Validate(); err != nil {
+		t.Errorf("good mod: %v", err)
+	}
+
+	if (&TronMod{Name: "../escape", Source: testModSource}).Validate() == nil {
+		t.Error("accepted a name that's a path")
+	}
+
+	if (&TronMod{Name: "broken", Source: "function ("}).Validate() == nil {
+		t.Error("accepted a script that doesn't parse")
+	}
+
+	if (&TronMod{Name: "big", Source: strings.Repeat("-", maxModSize+1)}).Validate() == nil {
+		t.Error("accepted a script that's too big")
+	}
+}
+
+func TestModShareAssembly(t *testing.T) {
+	m := &TronMod{Name: "test", Source: strings.Repeat("-- padding\n", 200)}
+	msgs := modShareMessages("lobby", m)
+
+	if len(msgs) < 2 {
+		t.Fatalf("split into %d messages, want several", len(msgs))
+	}
+
+	a := newModAssembler(m.Hash())
+
+	// Parts can arrive in any order, and more than once
+	for i := len(msgs) - 1; i > 0; i-- {
+		if _, done := a.add(msgs[i]); done {
+			t.Fatal("done before every part arrived")
+		}
+	}
+
+	a.add(msgs[1])
+	got, done := a.add(msgs[0])
+
+	if !done || got.Source != m.Source || got.Name != m.Name {
+		t.Fatalf("assembled %v, %v", got, done)
+	}
+
+	// Parts of some other mod are ignored
+	other := modShareMessages("lobby", &TronMod{Name: "other", Source: "-- other"})
+
+	if _, done := newModAssembler(m.Hash()).add(other[0]); done {
+		t.Error("assembled a different mod")
+	}
+}
+
+func TestTronClearTrail(t *testing.T) {
+	// A nibble a cell: set, then the player. Players 0 and 2, then 2 and no one
+	collisions := []byte{0x51, 0x05}
+
+	collisions = tronClearTrail(collisions, 2)
+
+	if want := []byte{1, 0}; !reflect.DeepEqual(collisions, want) {
+		t.Errorf("collisions = %v, want %v", collisions, want)
+	}
+}
@@ -0,0 +1,98 @@
+package arcade
+
+import (
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Timesteps a ghost power-up lets a player pass through trails for
+const tronGhostTicks = 25
+
+// pickUpPowerUps gives each power-up that's turned up to the player driving
+// over it. If two players get there at once, the one who joined first has
+// it, so everyone works out the same thing.
+func (tg *TronGameView) pickUpPowerUps(gameState TronGameState) TronGameState {
+	if len(gameState.PowerUps) == 0 {
+		return gameState
+	}
+
+	playerIDs := make([]string, 0, len(gameState.ClientStates))
+
+	for playerID := range gameState.ClientStates {
+		playerIDs = append(playerIDs, playerID)
+	}
+
+	sort.Slice(playerIDs, func(i, j int) bool {
+		return gameState.ClientStates[playerIDs[i]].PlayerNum < gameState.ClientStates[playerIDs[j]].PlayerNum
+	})
+
+	left := make([]TronPowerUp, 0, len(gameState.PowerUps))
+
+	for _, powerUp := range gameState.PowerUps {
+		picked := false
+
+		for _, playerID := range playerIDs {
+			client := gameState.ClientStates[playerID]
+
+			if powerUp.Tick > gameState.Tick || !client.Alive || client.X != powerUp.X || client.Y != powerUp.Y {
+				continue
+			}
+
+			switch powerUp.Kind {
+			case tronPowerUpGhost:
+				client.Ghost = tronGhostTicks
+			case tronPowerUpClear:
+				gameState.Collisions = tronClearTrail(gameState.Collisions, client.PlayerNum)
+			}
+
+			gameState.ClientStates[playerID] = client
+			picked = true
+			break
+		}
+
+		if !picked {
+			left = append(left, powerUp)
+		}
+	}
+
+	gameState.PowerUps = left
+	return gameState
+}
+
+// tronClearTrail removes the player's trail from the arena.
+func tronClearTrail(collisions []byte, playerNum int) []byte {
+	for i := range collisions {
+		for _, offset := range []int{0, 4} {
+			coll := collisions[i] >> offset
+
+			if coll&1 == 1 && int((coll>>1)&7) == playerNum {
+				collisions[i] &^= 0xf << offset
+			}
+		}
+	}
+
+	return collisions
+}
+
+// renderTronPowerUps draws the power-ups that have turned up.
+func renderTronPowerUps(s *Screen, gameState TronGameState) {
+	for _, powerUp := range gameState.PowerUps {
+		if powerUp.Tick > gameState.Tick {
+			continue
+		}
+
+		style := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorFuchsia)
+		chr := "?"
+
+		switch powerUp.Kind {
+		case tronPowerUpGhost:
+			chr = "◌"
+		case tronPowerUpClear:
+			chr = "✦"
+			style = style.Foreground(tcell.ColorYellow)
+		}
+
+		s.DrawText(powerUp.X, powerUp.Y, style, chr)
+	}
+}
@@ -0,0 +1,87 @@
+package arcade
+
+import "testing"
+
+// playTutorialStep plays the step with a bot standing in for the player, and
+// returns whether it was done before it had to be tried again.
+func playTutorialStep(t *testing.T, gameType string, step tutorialStep, skill BotSkill) bool {
+	t.Helper()
+
+	g := localGameTypes[gameType].New(&ViewManager{}, 2, NewMatchRNG())
+	used := make(map[Action]bool)
+
+	if step.Setup != nil {
+		step.Setup(g)
+	}
+
+	for ticks := 1; ticks < 2000; ticks++ {
+		if action := g.BotMove(0, skill); action != "" {
+			used[action] = true
+			g.Input(0, action)
+		}
+
+		if step.Bot != nil {
+			if action := g.BotMove(1, *step.Bot); action != "" {
+				g.Input(1, action)
+			}
+		}
+
+		g.Step()
+
+		if step.Done(g, used, ticks) {
+			return true
+		}
+
+		if step.Failed != nil && step.Failed(g) {
+			return false
+		}
+
+		if ended, _ := g.Ended(); ended {
+			return false
+		}
+	}
+
+	t.Fatal("step never ended")
+	return false
+}
+
+func TestTutorialStepsCanBeDone(t *testing.T) {
+	hard := botSkills[len(botSkills)-1]
+
+	// Against the bot that never moves, a good player outlasts it
+	if !playTutorialStep(t, Tron, tronTutorial[2], hard) {
+		t.Error("couldn't outlast a bot going straight")
+	}
+
+	// Starting by the wall, turning away from it is enough
+	if !playTutorialStep(t, Tron, tronTutorial[1], hard) {
+		t.Error("couldn't turn away from the wall")
+	}
+
+	// The ball's served at the player, who sends it back
+	if !playTutorialStep(t, Pong, pongTutorial[1], hard) {
+		t.Error("couldn't return the ball")
+	}
+}
+
+func TestTutorialStepCanBeFailed(t *testing.T) {
+	idle := BotSkill{MoveEvery: 1 << 30}
+
+	// Riding straight into the wall
+	if playTutorialStep(t, Tron, tronTutorial[1], idle) {
+		t.Error("crashing into the wall counted")
+	}
+}
+
+func TestPongReturned(t *testing.T) {
+	toward := PongBall{X: pongCourtX1 + 2, Y: 10, VX: -1, VY: 1}
+	away := PongBall{X: pongCourtX1 + 2, Y: 10, VX: 1, VY: 1}
+
+	if !pongReturned(toward, away, PongLeft) {
+		t.Error("bounce off the left paddle didn't count")
+	}
+
+	if pongReturned(toward, away, PongRight) || pongReturned(away, toward, PongLeft) {
+		t.Error("ball moving toward a side counted as its return")
+	}
+}
@@ -0,0 +1,354 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"arcade/arcade/widgets"
+	"encoding"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// How long "Nice!" or "Try again" shows between steps
+const tutorialPauseTime = 1500 * time.Millisecond
+
+var tutorialFooter = "[Esc] Leave the tutorial"
+
+// tutorialStep is one thing a tutorial has the player do, in a fresh game
+// against a bot.
+type tutorialStep struct {
+	Text string
+
+	// What the step's keys do, highlighted until they've been used
+	Actions []Action
+
+	// How good the bot is, or nil for one that never moves
+	Bot *BotSkill
+
+	// Setup arranges the fresh game for the step, if it needs to
+	Setup func(g localGame)
+
+	// Done returns true once the step's been done, given the actions the
+	// player's used and the ticks since it started
+	Done func(g localGame, used map[Action]bool, ticks int) bool
+
+	// Failed returns true if the step has to be tried again
+	Failed func(g localGame) bool
+}
+
+// TutorialView walks a new player through a game's controls, a step at a
+// time, each in its own scripted game against a bot.
+type TutorialView struct {
+	View
+	mgr *ViewManager
+
+	mu       sync.RWMutex
+	gameType string
+	steps    []tutorialStep
+	step     int
+	game     localGame
+	used     map[Action]bool
+	ticks    int
+	finished bool
+
+	// Shown over the game while it's paused between steps
+	banner string
+
+	// Set in games where keys are held down
+	hold *KeyHold
+
+	stopTickerCh chan bool
+}
+
+func NewTutorialView(mgr *ViewManager, gameType string) *TutorialView {
+	v := &TutorialView{
+		mgr:          mgr,
+		gameType:     gameType,
+		steps:        localGameTypes[gameType].Tutorial,
+		stopTickerCh: make(chan bool),
+	}
+
+	if localGameTypes[gameType].HoldKeys {
+		v.hold = NewKeyHold(mgr.Config())
+	}
+
+	v.startStep()
+	return v
+}
+
+// startStep sets up a fresh game for the current step. Expects the lock to be
+// held, or the view not to be shown yet.
+func (v *TutorialView) startStep() {
+	step := v.steps[v.step]
+
+	v.game = localGameTypes[v.gameType].New(v.mgr, 2, NewMatchRNG())
+	v.used = make(map[Action]bool)
+	v.ticks = 0
+
+	if step.Setup != nil {
+		step.Setup(v.game)
+	}
+
+	announce("Step %d of %d. %s", v.step+1, len(v.steps), step.Text)
+}
+
+func (v *TutorialView) Init() {
+	go func() {
+		defer v.mgr.recoverCrash()
+		v.run()
+	}()
+}
+
+// run steps the game, moving on to the next step once the player's done this
+// one, and starting it over if they fail it.
+func (v *TutorialView) run() {
+	ticker := time.NewTicker(v.game.TickPeriod())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			v.mu.Lock()
+
+			if v.finished {
+				v.mu.Unlock()
+				return
+			}
+
+			step := v.steps[v.step]
+
+			if v.hold != nil {
+				if action := v.hold.Tick(now); action != "" {
+					v.game.Input(0, action)
+				}
+			}
+
+			if step.Bot != nil {
+				if action := v.game.BotMove(1, *step.Bot); action != "" {
+					v.game.Input(1, action)
+				}
+			}
+
+			v.game.Step()
+			v.ticks++
+
+			banner := ""
+			ended, _ := v.game.Ended()
+
+			switch {
+			case step.Done(v.game, v.used, v.ticks):
+				banner = "Nice!"
+				v.step++
+
+				if v.step == len(v.steps) {
+					v.finished = true
+					announce("Tutorial done. You're ready to play online")
+				}
+			case step.Failed != nil && step.Failed(v.game):
+				banner = "Try again"
+			case ended:
+				// The bot went out before the step was done
+			default:
+				v.mu.Unlock()
+				v.mgr.RequestRender()
+				continue
+			}
+
+			v.banner = banner
+			finished := v.finished
+			v.mu.Unlock()
+
+			if finished {
+				v.mgr.markTutorialSeen(v.gameType)
+			}
+
+			v.mgr.RequestRender()
+
+			if banner != "" {
+				playSound(SoundCountdown)
+
+				select {
+				case <-time.After(tutorialPauseTime):
+				case <-v.stopTickerCh:
+					return
+				}
+			}
+
+			v.mu.Lock()
+			v.banner = ""
+
+			if v.finished {
+				v.mu.Unlock()
+				v.mgr.RequestRender()
+				return
+			}
+
+			v.startStep()
+			v.mu.Unlock()
+		case <-v.stopTickerCh:
+			return
+		}
+	}
+}
+
+func (v *TutorialView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		v.mu.Lock()
+		finished := v.finished && v.banner == ""
+
+		if !v.finished && v.banner == "" {
+			if action := gameKeymap.Action(evt); action != "" {
+				v.used[action] = true
+
+				if v.hold == nil || !isMoveAction(action) || v.hold.Press(action, time.Now()) {
+					v.game.Input(0, action)
+				}
+			}
+		}
+		v.mu.Unlock()
+
+		if finished && evt.Key() == tcell.KeyEnter {
+			v.mgr.PopView()
+			return
+		}
+
+		v.mgr.RequestRender()
+	}
+}
+
+func (v *TutorialView) Keymap() *Keymap {
+	return gameKeymap
+}
+
+func (v *TutorialView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *TutorialView) Render(s *Screen) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	v.game.Render(s)
+
+	width, height := s.displaySize()
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorTeal)
+	keySty := tcell.StyleDefault.Background(tcell.ColorYellow).Foreground(tcell.ColorBlack)
+	usedSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorDarkGreen)
+
+	if v.finished && v.banner == "" {
+		s.DrawBlockText(CenterX, CenterY, sty, "READY", true)
+
+		msg := fmt.Sprintf("You're ready for online %s. Press [Enter] to go back", v.gameType)
+		s.DrawText(layout.Center(width, msg), height-6, sty, msg)
+		return
+	}
+
+	// What to do along the top, and its keys along the bottom
+	index := v.step
+
+	if index == len(v.steps) {
+		index--
+	}
+
+	step := v.steps[index]
+	text := fmt.Sprintf(" %d/%d  %s ", index+1, len(v.steps), step.Text)
+	s.DrawText(layout.Center(width, text), 0, sty.Reverse(true), text)
+
+	keys := make([]string, 0, len(step.Actions))
+
+	for _, action := range step.Actions {
+		keys = append(keys, " "+tutorialKeyLabel(action)+" ")
+	}
+
+	x := layout.Center(width, strings.Join(keys, " "))
+
+	for i, action := range step.Actions {
+		keyStyle := keySty
+
+		if v.used[action] {
+			keyStyle = usedSty
+		}
+
+		s.DrawText(x, height-1, keyStyle, keys[i])
+		x += layout.Width(keys[i]) + 1
+	}
+
+	s.DrawText(width-1-layout.Width(tutorialFooter), height-1, sty, tutorialFooter)
+
+	if v.banner != "" {
+		s.DrawBlockText(CenterX, CenterY, sty, strings.ToUpper(v.banner), true)
+	}
+}
+
+func (v *TutorialView) Unload() {
+	close(v.stopTickerCh)
+}
+
+func (v *TutorialView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}
+
+// tutorialKeyLabel names the first game key bound to the action.
+func tutorialKeyLabel(action Action) string {
+	if key, ok := gameKeymap.Key(action); ok {
+		return key.String()
+	}
+
+	return string(action)
+}
+
+// offerTutorial suggests a game's tutorial the first time the player goes to
+// play it online, then carries on with then unless they take it.
+func (mgr *ViewManager) offerTutorial(gameType string, then func()) {
+	if !mgr.tutorialUnseen(gameType) {
+		then()
+		return
+	}
+
+	mgr.ShowModal(widgets.NewModal("First "+gameType+" game?", []string{
+		fmt.Sprintf("Learn to play %s against a bot before", gameType),
+		"your first online match.",
+	}, []string{"Tutorial", "Skip"}, func(choice int) {
+		mgr.markTutorialSeen(gameType)
+
+		if choice == 0 {
+			mgr.PushView(NewTutorialView(mgr, gameType))
+		} else {
+			then()
+		}
+	}))
+}
+
+// tutorialUnseen returns true if the game has a tutorial the player hasn't
+// played or skipped.
+func (mgr *ViewManager) tutorialUnseen(gameType string) bool {
+	return len(localGameTypes[gameType].Tutorial) > 0 && !mgr.Config().TutorialsSeen[gameType]
+}
+
+// markTutorialSeen remembers the player's done or skipped the game's
+// tutorial, so it isn't offered again.
+func (mgr *ViewManager) markTutorialSeen(gameType string) {
+	config := *mgr.Config()
+	config.TutorialsSeen = make(map[string]bool)
+
+	for game, seen := range mgr.Config().TutorialsSeen {
+		config.TutorialsSeen[game] = seen
+	}
+
+	config.TutorialsSeen[gameType] = true
+
+	if err := config.Save(); err != nil {
+		log.Println("Couldn't save the tutorials seen:", err)
+	}
+
+	mgr.Lock()
+	mgr.config = &config
+	mgr.Unlock()
+}
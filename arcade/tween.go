@@ -0,0 +1,128 @@
+package arcade
+
+import (
+	"math"
+	"time"
+)
+
+// Easing maps how far through an animation we are, from 0 to 1, to how far
+// the animated value has moved. Most start at 0 and end at 1, but some
+// overshoot on the way.
+type Easing func(t float64) float64
+
+func Linear(t float64) float64 {
+	return t
+}
+
+func EaseInQuad(t float64) float64 {
+	return t * t
+}
+
+func EaseOutQuad(t float64) float64 {
+	return t * (2 - t)
+}
+
+func EaseInOutQuad(t float64) float64 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+
+	return -1 + (4-2*t)*t
+}
+
+func EaseOutCubic(t float64) float64 {
+	t--
+	return t*t*t + 1
+}
+
+// EaseOutBack overshoots the end a little before settling, which makes
+// things look like they pop into place.
+func EaseOutBack(t float64) float64 {
+	const c1 = 1.70158
+	const c3 = c1 + 1
+
+	return 1 + c3*math.Pow(t-1, 3) + c1*math.Pow(t-1, 2)
+}
+
+func EaseOutBounce(t float64) float64 {
+	const n1 = 7.5625
+	const d1 = 2.75
+
+	switch {
+	case t < 1/d1:
+		return n1 * t * t
+	case t < 2/d1:
+		t -= 1.5 / d1
+		return n1*t*t + 0.75
+	case t < 2.5/d1:
+		t -= 2.25 / d1
+		return n1*t*t + 0.9375
+	default:
+		t -= 2.625 / d1
+		return n1*t*t + 0.984375
+	}
+}
+
+// Lerp returns the value t of the way from a to b.
+func Lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+// Tween moves a value from one number to another over time. It starts when
+// it's made, and is read whenever a frame is drawn.
+type Tween struct {
+	From     float64
+	To       float64
+	Duration time.Duration
+	Ease     Easing
+
+	start time.Time
+}
+
+func NewTween(from, to float64, duration time.Duration, ease Easing) *Tween {
+	if ease == nil {
+		ease = Linear
+	}
+
+	return &Tween{
+		From:     from,
+		To:       to,
+		Duration: duration,
+		Ease:     ease,
+		start:    time.Now(),
+	}
+}
+
+// Restart plays the tween again from the start.
+func (t *Tween) Restart() {
+	t.start = time.Now()
+}
+
+// Progress returns how far through the tween we are, from 0 to 1, before
+// easing.
+func (t *Tween) Progress() float64 {
+	if t.Duration <= 0 {
+		return 1
+	}
+
+	p := float64(time.Since(t.start)) / float64(t.Duration)
+
+	if p > 1 {
+		return 1
+	}
+
+	return p
+}
+
+func (t *Tween) Value() float64 {
+	return Lerp(t.From, t.To, t.Ease(t.Progress()))
+}
+
+// Int returns the value rounded to the nearest cell.
+func (t *Tween) Int() int {
+	return int(math.Round(t.Value()))
+}
+
+func (t *Tween) Done() bool {
+	return t.Progress() >= 1
+}
@@ -2,6 +2,7 @@ package arcade
 
 import (
 	"arcade/arcade/net"
+	"arcade/arcade/widgets"
 	"encoding"
 )
 
@@ -13,3 +14,23 @@ type View interface {
 	Render(s *Screen)
 	Unload()
 }
+
+// PausableView is implemented by views that hold on to resources, like
+// tickers, that should stop while another view is pushed on top of them.
+type PausableView interface {
+	OnPause()
+	OnResume()
+}
+
+// BackView is implemented by views that do something of their own when the
+// player goes back. Back returns true if it took care of it.
+type BackView interface {
+	Back() bool
+}
+
+// WidgetView is implemented by views built from widgets. The ViewManager gives
+// key events to the view's focused widget before the view sees them. Widgets
+// may return nil when no widget should get events.
+type WidgetView interface {
+	Widgets() *widgets.FocusGroup
+}
@@ -1,10 +1,14 @@
 package arcade
 
 import (
+	"arcade/arcade/layout"
 	"arcade/arcade/net"
+	"arcade/arcade/widgets"
 	"fmt"
+	"log"
 	"math"
 	"os"
+	"reflect"
 	"sync"
 	"time"
 
@@ -18,22 +22,353 @@ type ViewManager struct {
 
 	view      View
 	showDebug bool
+
+	// The terminal to draw on, if it isn't ours, like an SSH session's
+	tty tcell.Tty
+
+	// What the terminal can't draw, whatever the settings say
+	forceASCII bool
+	noColor    bool
+
+	// Views underneath the current one, paused until it's popped
+	stack []View
+
+	// Views still running in windows over the current one, and the z-order
+	// of the next
+	insets     []*Inset
+	nextInsetZ int
+
+	// Invite waiting for the player to accept or decline, and the invite that
+	// was accepted and is waiting for the host's reply
+	invite         *InviteMessage
+	acceptedInvite *InviteMessage
+
+	// When the player last pressed a key
+	lastInput time.Time
+
+	// Sends our games to our coach, if we have one
+	coach coachRelay
+
+	// Dialog shown on top of the view, which gets every key while open
+	modal *widgets.Modal
+
+	// Whether the keybinding help is showing, and the view's bindings at the
+	// time it was opened
+	showHelp   bool
+	helpKeymap *Keymap
+
+	Toasts *ToastManager
+	Sounds *SoundManager
+	Status *StatusBar
+
+	Achievements *Achievements
+
+	// Our ranked standing, for lobby badges
+	season seasonCache
+
+	// Says what changed in accessibility mode
+	Announcer *Announcer
+
+	config *Config
+
+	// Settings being tried out before they're saved
+	preview *Config
+
+	// Change from the last view to the current one, while it's playing
+	transition *viewTransition
+
+	// Graphics protocol the terminal seems to support, the renderer in use
+	// (nil for text only), and whether there are pixels on the screen
+	detectedGraphics string
+	graphics         graphicsRenderer
+	pixelsShown      bool
+
+	// Keeps frames, and the pixels drawn after them, from interleaving
+	renderMu sync.Mutex
+
+	// Frames keep being drawn until then, for things that are animating
+	animateUntil time.Time
+	animating    bool
+
+	// When the last frame was drawn, and whether one is waiting on the FPS cap
+	lastFrame    time.Time
+	framePending bool
+
+	// How the view on screen is keeping up with the frame rate
+	frames FrameBudget
+}
+
+func NewViewManager(config *Config) *ViewManager {
+	mgr := &ViewManager{showDebug: false, lastInput: time.Now(), config: config}
+	mgr.Toasts = NewToastManager(mgr.toastsChanged)
+	mgr.Sounds = NewSoundManager(mgr.bell)
+	mgr.Status = NewStatusBar()
+	mgr.Announcer = NewAnnouncer()
+	mgr.Achievements = &Achievements{}
+	mgr.Toasts.OnPush = func(text string) {
+		mgr.Sounds.Play(SoundNotify)
+		mgr.Announcer.Announce(text)
+	}
+
+	applyKeybindings(config.Keybindings)
+	mgr.Sounds.Apply(config)
+	mgr.Announcer.SetEnabled(config.Accessible)
+
+	mgr.detectedGraphics = detectGraphics()
+	mgr.graphics = newGraphicsRenderer(config.Graphics, mgr.detectedGraphics)
+
+	return mgr
+}
+
+// Config returns the settings in use.
+func (mgr *ViewManager) Config() *Config {
+	mgr.RLock()
+	defer mgr.RUnlock()
+
+	return mgr.config
+}
+
+// ApplyConfig switches to new settings. The port and distributor address only
+// change on the next start.
+func (mgr *ViewManager) ApplyConfig(config *Config) {
+	mgr.Lock()
+	mgr.config = config
+	mgr.preview = nil
+	mgr.Unlock()
+
+	applyKeybindings(config.Keybindings)
+	mgr.Sounds.Apply(config)
+	mgr.Announcer.SetEnabled(config.Accessible)
+
+	if mgr.screen == nil {
+		return
+	}
+
+	mgr.setGraphics(config.Graphics)
+	mgr.screen.SetTheme(getTheme(config.Theme), config.ASCIIMode || mgr.forceASCII)
+	mgr.screen.Reset()
+	mgr.RequestRender()
+}
+
+// PreviewConfig shows how settings look without making them the ones in use.
+// They're dropped by the next ApplyConfig.
+func (mgr *ViewManager) PreviewConfig(config *Config) {
+	mgr.Lock()
+	mgr.preview = config
+	mgr.Unlock()
+
+	mgr.Announcer.SetEnabled(config.Accessible)
+
+	if mgr.screen == nil {
+		return
+	}
+
+	mgr.setGraphics(config.Graphics)
+	mgr.screen.SetTheme(getTheme(config.Theme), config.ASCIIMode || mgr.forceASCII)
+	mgr.screen.Reset()
+	mgr.RequestRender()
 }
 
-func NewViewManager() *ViewManager {
-	return &ViewManager{showDebug: false}
+// setGraphics switches graphics protocols, taking down pixels drawn with the
+// old one.
+func (mgr *ViewManager) setGraphics(mode string) {
+	mgr.renderMu.Lock()
+	defer mgr.renderMu.Unlock()
+
+	mgr.clearPixels()
+
+	mgr.Lock()
+	mgr.graphics = newGraphicsRenderer(mode, mgr.detectedGraphics)
+	mgr.Unlock()
+}
+
+// renderPixels draws the view's pixels over its text, if the view and the
+// terminal both can. Expects renderMu to be held.
+func (mgr *ViewManager) renderPixels() {
+	mgr.RLock()
+	renderer := mgr.graphics
+	pv, ok := mgr.view.(PixelView)
+
+	// Anything on top of the view would end up under the pixels
+	covered := mgr.modal != nil || mgr.showHelp || mgr.transition != nil || mgr.invite != nil
+	config := mgr.config
+
+	if mgr.preview != nil {
+		config = mgr.preview
+	}
+	mgr.RUnlock()
+
+	covered = covered || len(mgr.Toasts.Active()) > 0 || mgr.Toasts.ShowingHistory()
+
+	// Screen readers can only read text
+	covered = covered || mgr.Announcer.Enabled()
+
+	if renderer == nil || !ok || covered {
+		mgr.clearPixels()
+		return
+	}
+
+	x1, y1, x2, y2 := pv.PixelArea()
+	cols, rows := x2-x1+1, y2-y1+1
+	cellWidth, cellHeight := cellPixelSize()
+
+	canvas := newPixelCanvas(cols, rows, cellWidth, cellHeight, getTheme(config.Theme))
+
+	if !pv.RenderPixels(canvas) {
+		mgr.clearPixels()
+		return
+	}
+
+	x, y := mgr.screen.offset()
+
+	if renderer.draw(os.Stdout, canvas, x+x1, y+y1, cols, rows) == nil {
+		mgr.pixelsShown = true
+	}
+}
+
+// clearPixels takes down whatever pixels are showing. Expects renderMu to be
+// held.
+func (mgr *ViewManager) clearPixels() {
+	if !mgr.pixelsShown {
+		return
+	}
+
+	mgr.RLock()
+	renderer := mgr.graphics
+	mgr.RUnlock()
+
+	if renderer != nil {
+		renderer.clear(os.Stdout, mgr.screen)
+	}
+
+	mgr.pixelsShown = false
+}
+
+// bell rings the terminal bell.
+func (mgr *ViewManager) bell() {
+	if mgr.screen != nil {
+		mgr.screen.Beep()
+	}
+}
+
+// allowFrame returns false if drawing now would go over the FPS cap, and
+// makes sure a frame is drawn once the wait is over.
+func (mgr *ViewManager) allowFrame() bool {
+	mgr.Lock()
+	defer mgr.Unlock()
+
+	config := mgr.config
+
+	if mgr.preview != nil {
+		config = mgr.preview
+	}
+
+	if config.FPSCap <= 0 {
+		return true
+	}
+
+	interval := time.Second / time.Duration(config.FPSCap)
+	since := time.Since(mgr.lastFrame)
+
+	if since >= interval {
+		mgr.lastFrame = time.Now()
+		return true
+	}
+
+	if !mgr.framePending {
+		mgr.framePending = true
+
+		time.AfterFunc(interval-since, func() {
+			mgr.Lock()
+			mgr.framePending = false
+			mgr.Unlock()
+
+			mgr.RequestRender()
+		})
+	}
+
+	return false
+}
+
+// toastsChanged redraws everything, since a toast that went away leaves
+// nothing behind to draw over it.
+func (mgr *ViewManager) toastsChanged() {
+	if mgr.screen == nil {
+		return
+	}
+
+	mgr.screen.Reset()
+	mgr.RequestRender()
+}
+
+// IdleFor returns how long it has been since the player last pressed a key.
+func (mgr *ViewManager) IdleFor() time.Duration {
+	mgr.RLock()
+	defer mgr.RUnlock()
+
+	return time.Since(mgr.lastInput)
+}
+
+// Away returns whether the player has been gone long enough for other
+// players to be told, and for them to be left out of matches.
+func (mgr *ViewManager) Away() bool {
+	minutes := mgr.Config().AwayAfter
+
+	return minutes > 0 && mgr.IdleFor() >= time.Duration(minutes)*time.Minute
 }
 
 func (mgr *ViewManager) ProcessMessage(from interface{}, p interface{}) interface{} {
+	switch p := p.(type) {
+	case *InviteMessage:
+		mgr.receiveInvite(p)
+		return nil
+	case *JoinReplyMessage:
+		if mgr.processInviteReply(p) {
+			return nil
+		}
+	}
+
 	mgr.RLock()
 	v := mgr.view
 	mgr.RUnlock()
 
 	defer mgr.RequestRender()
+
+	// Views in windows keep up too, though only the current one replies
+	for _, in := range mgr.insetViews() {
+		in.View.ProcessMessage(from.(*net.Client), p)
+	}
+
 	return v.ProcessMessage(from.(*net.Client), p)
 }
 
+// showing returns whether v is the current view.
+func (mgr *ViewManager) showing(v interface{}) bool {
+	mgr.RLock()
+	defer mgr.RUnlock()
+
+	return mgr.view != nil && v == interface{}(mgr.view)
+}
+
+// viewName returns the current view's type, to tell handlers apart in traces.
+func (mgr *ViewManager) viewName() string {
+	mgr.RLock()
+	defer mgr.RUnlock()
+
+	if mgr.view == nil {
+		return ""
+	}
+
+	return reflect.TypeOf(mgr.view).Elem().Name()
+}
+
 func (mgr *ViewManager) ProcessEvent(ev interface{}) {
+	// Shown whatever's on screen, even before the first view
+	if evt, ok := ev.(*ServerErrorEvent); ok {
+		mgr.showServerError(evt.Err)
+		return
+	}
+
 	mgr.RLock()
 	v := mgr.view
 	mgr.RUnlock()
@@ -42,28 +377,375 @@ func (mgr *ViewManager) ProcessEvent(ev interface{}) {
 		return
 	}
 
+	switch ev.(type) {
+	case *HeartbeatEvent, *ClientConnectedEvent, *ClientDisconnectedEvent:
+		defer mgr.Status.Update(v)
+	}
+
+	if evt, ok := ev.(*tcell.EventKey); ok {
+		if mgr.processModalEvent(evt) || mgr.Toasts.ProcessEvent(evt) || mgr.processHelpEvent(evt) {
+			return
+		}
+
+		if wv, ok := v.(WidgetView); ok {
+			if group := wv.Widgets(); group != nil && group.ProcessEvent(evt) {
+				return
+			}
+		}
+
+		if globalKeymap.Action(evt) == ActionHelp && mgr.openHelp(v) {
+			return
+		}
+	}
+
 	v.ProcessEvent(ev)
 }
 
+// showServerError tells the player we can't take connections, and offers to
+// try the next port.
+func (mgr *ViewManager) showServerError(err error) {
+	next := arcade.Port + portFallbackAttempts
+
+	mgr.ShowModal(widgets.NewModal("Can't accept players", []string{
+		fmt.Sprintf("Ports %d to %d are in use or unavailable.", arcade.Port, next-1),
+		"Others can't join your games until you choose others.",
+	}, []string{fmt.Sprintf("Try from port %d", next), "Quit"}, func(choice int) {
+		if choice != 0 {
+			mgr.Quit()
+			return
+		}
+
+		arcade.Port = next
+		arcade.Server.SetPort(next)
+
+		arcade.Engine.Listen()
+	}))
+}
+
+// ShowModal opens a dialog on top of the current view. It closes once the
+// player makes a choice.
+func (mgr *ViewManager) ShowModal(m *widgets.Modal) {
+	mgr.Lock()
+	mgr.modal = m
+	mgr.Unlock()
+
+	mgr.RequestRender()
+}
+
+func (mgr *ViewManager) modalOpen() bool {
+	mgr.RLock()
+	defer mgr.RUnlock()
+
+	return mgr.modal != nil
+}
+
+func (mgr *ViewManager) processModalEvent(evt *tcell.EventKey) bool {
+	mgr.RLock()
+	modal := mgr.modal
+	mgr.RUnlock()
+
+	if modal == nil {
+		return false
+	}
+
+	modal.ProcessEvent(evt)
+
+	if modal.Closed() {
+		mgr.Lock()
+		if mgr.modal == modal {
+			mgr.modal = nil
+		}
+		mgr.Unlock()
+
+		mgr.screen.Reset()
+	}
+
+	return true
+}
+
+// openHelp shows the keybindings for the view. Returns false if the view
+// doesn't have any right now, so the key can go to the view instead.
+func (mgr *ViewManager) openHelp(v View) bool {
+	kv, ok := v.(KeymapView)
+
+	if !ok {
+		return false
+	}
+
+	keymap := kv.Keymap()
+
+	if keymap == nil {
+		return false
+	}
+
+	mgr.Lock()
+	mgr.showHelp = true
+	mgr.helpKeymap = keymap
+	mgr.Unlock()
+
+	mgr.RequestRender()
+	return true
+}
+
+// processHelpEvent closes the help on any key. Returns true if the help was
+// open.
+func (mgr *ViewManager) processHelpEvent(evt *tcell.EventKey) bool {
+	mgr.Lock()
+	showHelp := mgr.showHelp
+	mgr.showHelp = false
+	mgr.Unlock()
+
+	if showHelp {
+		mgr.screen.Reset()
+	}
+
+	return showHelp
+}
+
+func (mgr *ViewManager) renderHelp() {
+	mgr.RLock()
+	showHelp := mgr.showHelp
+	keymap := mgr.helpKeymap
+	mgr.RUnlock()
+
+	if !showHelp {
+		return
+	}
+
+	const (
+		x1 = 2
+		y1 = 2
+		x2 = displayWidth - 3
+		y2 = displayHeight - 3
+		mx = (x1 + x2) / 2
+	)
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	headerSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorLightGreen)
+
+	mgr.screen.DrawEmpty(x1, y1, x2, y2, sty)
+	mgr.screen.DrawBox(x1, y1, x2, y2, headerSty, true)
+
+	title := " Keys - press any key to close "
+	mgr.screen.DrawText(layout.Center(displayWidth, title), y1, headerSty, title)
+
+	// The view's keys on the left, the ones that work everywhere on the right
+	drawColumn := func(x int, keymap *Keymap) {
+		mgr.screen.DrawText(x, y1+2, headerSty, keymap.Name)
+
+		labelWidth := 0
+
+		bindings := keymap.List()
+
+		for _, binding := range bindings {
+			if w := layout.Width(binding.Label()) + 2; w > labelWidth {
+				labelWidth = w
+			}
+		}
+
+		for i, binding := range bindings {
+			y := y1 + 4 + i
+
+			if y >= y2 {
+				break
+			}
+
+			mgr.screen.DrawText(x, y, headerSty, binding.Label())
+			mgr.screen.DrawText(x+labelWidth, y, sty, binding.Help)
+		}
+	}
+
+	drawColumn(x1+3, keymap)
+	drawColumn(mx+3, globalKeymap)
+}
+
+// SetView unloads every view, including the ones underneath, and shows the
+// new one.
 func (mgr *ViewManager) SetView(v View) {
 	mgr.Lock()
 
+	for _, paused := range mgr.stack {
+		paused.Unload()
+	}
+
+	mgr.stack = nil
+	mgr.Unlock()
+
+	mgr.ReplaceView(v)
+}
+
+// ReplaceView swaps the current view for a new one, keeping the views
+// underneath.
+func (mgr *ViewManager) ReplaceView(v View) {
+	mgr.Lock()
+
 	// Unload existing view
 	if mgr.view != nil {
 		mgr.view.Unload()
 	}
 
+	mgr.show(v, TransitionFade)
+	mgr.view.Init()
+
+	mgr.Unlock()
+
+	// Render
+	mgr.Status.Update(v)
+	mgr.Animate(transitionDuration)
+	mgr.RequestRender()
+}
+
+// PushView pauses the current view and shows the new one on top of it.
+func (mgr *ViewManager) PushView(v View) {
+	mgr.RLock()
+	current := mgr.view
+	mgr.RUnlock()
+
+	// Pausing may need the lock, so it happens first
+	if pv, ok := current.(PausableView); ok {
+		pv.OnPause()
+	}
+
+	mgr.Lock()
+
+	if mgr.view != nil {
+		mgr.stack = append(mgr.stack, mgr.view)
+	}
+
+	mgr.show(v, TransitionSlideLeft)
+	mgr.view.Init()
+
+	mgr.Unlock()
+
+	mgr.Status.Update(v)
+
+	mgr.Animate(transitionDuration)
+	mgr.RequestRender()
+}
+
+// PopView unloads the current view and resumes the one underneath. Returns
+// false if there's nothing to go back to.
+func (mgr *ViewManager) PopView() bool {
+	mgr.Lock()
+
+	if len(mgr.stack) == 0 {
+		mgr.Unlock()
+		return false
+	}
+
+	mgr.view.Unload()
+
+	v := mgr.stack[len(mgr.stack)-1]
+	mgr.stack = mgr.stack[:len(mgr.stack)-1]
+	mgr.show(v, TransitionSlideRight)
+	mgr.Unlock()
+
+	if pv, ok := v.(PausableView); ok {
+		pv.OnResume()
+	}
+
+	mgr.Status.Update(v)
+
+	mgr.Animate(transitionDuration)
+	mgr.RequestRender()
+	return true
+}
+
+// show makes the view current, playing the transition from the last one.
+// Expects the lock to be held.
+func (mgr *ViewManager) show(v View, kind Transition) {
+	mgr.transition = nil
+
+	if mgr.view != nil && kind != TransitionNone && !mgr.Announcer.Enabled() && !mgr.frames.Degraded() {
+		mgr.transition = newViewTransition(kind, captureFrame(mgr.screen))
+	}
+
 	// Reset screen state
 	mgr.screen.Reset()
 
-	// Save view
+	// Dialogs belong to the view they were opened over
+	mgr.modal = nil
+	mgr.showHelp = false
+
 	mgr.view = v
-	mgr.view.Init()
+}
 
+// Animate keeps drawing frames for a while, for views that are otherwise only
+// drawn when something changes.
+func (mgr *ViewManager) Animate(d time.Duration) {
+	// Redrawing for the sake of it gets in the way of screen readers, and
+	// of views that can't keep up already
+	if mgr.Announcer.Enabled() || mgr.frames.Degraded() {
+		return
+	}
+
+	mgr.Lock()
+	until := time.Now().Add(d)
+
+	if until.After(mgr.animateUntil) {
+		mgr.animateUntil = until
+	}
+
+	running := mgr.animating
+	mgr.animating = true
 	mgr.Unlock()
 
-	// Render
-	mgr.RequestRender()
+	if running {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(animationFrame)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			mgr.RequestRender()
+
+			mgr.Lock()
+			if time.Now().After(mgr.animateUntil) {
+				mgr.animating = false
+				mgr.Unlock()
+				return
+			}
+			mgr.Unlock()
+		}
+	}()
+}
+
+// Back closes whatever is open on top of the view, or lets the view handle
+// it, or goes back to the previous view. With nowhere to go back to, it quits.
+func (mgr *ViewManager) Back() {
+	mgr.Lock()
+	v := mgr.view
+
+	if mgr.modal != nil || mgr.showHelp {
+		mgr.modal = nil
+		mgr.showHelp = false
+		mgr.Unlock()
+
+		mgr.screen.Reset()
+		mgr.RequestRender()
+		return
+	}
+	mgr.Unlock()
+
+	if mgr.Toasts.ShowingHistory() {
+		mgr.Toasts.ToggleHistory()
+		return
+	}
+
+	if inGame(v) {
+		mgr.confirmQuit()
+		return
+	}
+
+	if bv, ok := v.(BackView); ok && bv.Back() {
+		return
+	}
+
+	if !mgr.PopView() {
+		mgr.Quit()
+	}
 }
 
 func (mgr *ViewManager) ToggleDebugPanel() {
@@ -74,26 +756,43 @@ func (mgr *ViewManager) ToggleDebugPanel() {
 }
 
 func (mgr *ViewManager) Start(v View) {
-	s, err := tcell.NewScreen()
+	var s tcell.Screen
+	var err error
+
+	if mgr.tty != nil {
+		s, err = tcell.NewTerminfoScreenFromTty(mgr.tty)
+	} else {
+		s, err = tcell.NewScreen()
+	}
 
 	if err != nil {
 		panic(err)
 	}
 
-	mgr.screen = &Screen{Screen: s}
+	config := mgr.Config()
+	mgr.screen = &Screen{
+		Screen:    s,
+		theme:     getTheme(config.Theme),
+		asciiMode: config.ASCIIMode || mgr.forceASCII,
+		noColor:   mgr.noColor,
+	}
 
 	if err := mgr.screen.Init(); err != nil {
 		panic(err)
 	}
 
+	// Sessions over SSH are on someone else's machine, so only a local
+	// terminal reads the gamepads plugged in
+	if mgr.tty == nil {
+		go startGamepad(mgr, mgr.screen)
+	}
+
+	// Put the terminal back if anything on the main loop panics
+	defer mgr.recoverCrash()
+
 	// Set first view
 	mgr.SetView(v)
 
-	quit := func() {
-		mgr.screen.Fini()
-		os.Exit(0)
-	}
-
 	for {
 		// Update screen
 		mgr.RequestRender()
@@ -107,24 +806,34 @@ func (mgr *ViewManager) Start(v View) {
 			mgr.screen.Reset()
 			mgr.RequestRender()
 		case *tcell.EventKey:
-			switch ev.Key() {
-			case tcell.KeyEscape, tcell.KeyCtrlC:
-				// Quit even if we hit deadlock on a dead client
-				time.AfterFunc(250*time.Millisecond, quit)
-
-				mgr.RLock()
-				mgr.view.Unload()
-				mgr.RUnlock()
+			mgr.Lock()
+			mgr.lastInput = time.Now()
+			mgr.Unlock()
 
-				arcade.Server.Network.SendNeighbors(NewDisconnectMessage())
-
-				quit()
-			case tcell.KeyCtrlD:
+			switch globalKeymap.Action(ev) {
+			case ActionQuit:
+				mgr.Back()
+				continue
+			case ActionForceQuit:
+				mgr.Quit()
+			case ActionNotifications:
+				mgr.Toasts.ToggleHistory()
+				continue
+			case ActionDismiss:
+				mgr.Toasts.DismissAll()
+				continue
+			case ActionCloseInsets:
+				mgr.CloseInsets()
+				continue
+			case ActionDebug:
 				mgr.ToggleDebugPanel()
 
 				mgr.screen.Reset()
 				mgr.RequestRender()
 				continue
+			}
+
+			switch ev.Key() {
 			case tcell.KeyCtrlQ:
 				arcade.Server.Network.SetDropRate(1)
 				continue
@@ -137,6 +846,14 @@ func (mgr *ViewManager) Start(v View) {
 			case tcell.KeyCtrlR:
 				arcade.Server.Network.SetDropRate(0)
 				continue
+			case tcell.KeyCtrlT:
+				if mgr.showDiagnostics() {
+					continue
+				}
+			case tcell.KeyRune:
+				if mgr.processInviteKey(ev.Rune()) {
+					continue
+				}
 			}
 		}
 
@@ -145,7 +862,77 @@ func (mgr *ViewManager) Start(v View) {
 	}
 }
 
+// Quit tells our neighbors we're leaving and exits.
+func (mgr *ViewManager) Quit() {
+	quit := func() {
+		mgr.screen.Fini()
+		os.Exit(0)
+	}
+
+	// Quit even if we hit deadlock on a dead client
+	time.AfterFunc(250*time.Millisecond, quit)
+
+	mgr.RLock()
+	mgr.view.Unload()
+	mgr.RUnlock()
+
+	arcade.Server.Network.SendNeighbors(NewDisconnectMessage())
+
+	quit()
+}
+
+// confirmQuit asks before quitting in the middle of a game.
+func (mgr *ViewManager) confirmQuit() {
+	mgr.ShowModal(widgets.NewModal("Quit", []string{
+		"The game is still going.",
+		"Quit anyway?",
+	}, []string{"Keep playing", "Quit"}, func(choice int) {
+		if choice == 1 {
+			mgr.Quit()
+		}
+	}))
+}
+
+// showDiagnostics pushes the diagnostics view, unless it's already showing. It returns false if the key should go to the view.
+func (mgr *ViewManager) showDiagnostics() bool {
+	if arcade.Diagnostics == nil {
+		return false
+	}
+
+	mgr.RLock()
+	v := mgr.view
+	mgr.RUnlock()
+
+	// Pushing a view over a game would pause it for everyone
+	if inGame(v) {
+		return false
+	}
+
+	if _, showing := v.(*DiagnosticsView); !showing {
+		mgr.PushView(NewDiagnosticsView(mgr))
+	}
+
+	return true
+}
+
+// inGame returns true if the view is a running game.
+func inGame(v View) bool {
+	switch v.(type) {
+	case *TronGameView, *PongGameView:
+		return true
+	}
+
+	return false
+}
+
 func (mgr *ViewManager) RequestRender() {
+	// Managers running games for `arcade serve` have nothing to draw on
+	if mgr.screen == nil || !mgr.allowFrame() {
+		return
+	}
+
+	defer arcade.Diagnostics.observeFrame(time.Now())
+
 	displayWidth, displayHeight := mgr.screen.displaySize()
 	width, height := mgr.screen.Size()
 
@@ -159,11 +946,53 @@ func (mgr *ViewManager) RequestRender() {
 
 	if width < displayWidth || height < displayHeight {
 		warning := "Please make your terminal window larger!"
-		mgr.screen.DrawText((displayWidth-len(warning))/2, displayHeight/2-1, tcell.StyleDefault, warning)
+		mgr.screen.DrawText(layout.Center(displayWidth, warning), displayHeight/2-1, tcell.StyleDefault, warning)
 	} else {
 		mgr.RLock()
-		mgr.view.Render(mgr.screen)
+		view := mgr.view
+		start := time.Now()
+		view.Render(mgr.screen)
+		took := time.Since(start)
+		transition := mgr.transition
 		mgr.RUnlock()
+
+		mgr.observeRender(view, took)
+
+		if transition != nil && transition.render(mgr.screen) {
+			mgr.Lock()
+			if mgr.transition == transition {
+				mgr.transition = nil
+			}
+			mgr.Unlock()
+
+			// The old view may have been left behind in cells the new
+			// one doesn't draw every frame
+			mgr.screen.Reset()
+			mgr.RLock()
+			mgr.view.Render(mgr.screen)
+			mgr.RUnlock()
+		}
+
+		// Before anything is drawn over the game
+		mgr.RLock()
+		mgr.coach.relay(mgr.screen, mgr.view)
+		mgr.RUnlock()
+
+		mgr.renderInsets()
+
+		mgr.Status.Render(mgr.screen)
+		mgr.Announcer.Render(mgr.screen)
+
+		mgr.Toasts.Render(mgr.screen, mgr.renderInvite())
+		mgr.renderHelp()
+
+		mgr.RLock()
+		modal := mgr.modal
+		mgr.RUnlock()
+
+		if modal != nil {
+			modal.Render(mgr.screen)
+		}
 	}
 
 	if showDebug {
@@ -173,7 +1002,7 @@ func (mgr *ViewManager) RequestRender() {
 		// clear debug sections
 		emptySty := tcell.StyleDefault.Background(tcell.ColorBlack)
 		mgr.screen.DrawEmpty(-x, -y, -x+22, -y+6, emptySty)
-		mgr.screen.DrawEmpty(-x, h+y-1, -x+40+22, h+y-2, emptySty)
+		mgr.screen.DrawEmpty(-x, h+y-1, -x+40+22, h+y-4, emptySty)
 
 		debugSty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorRed)
 
@@ -191,6 +1020,10 @@ func (mgr *ViewManager) RequestRender() {
 		text0 := "Ctrl-R to drop 0%"
 		mgr.screen.DrawText(-x, -y+4, debugSty, text0)
 
+		if arcade.Diagnostics != nil {
+			mgr.screen.DrawText(-x, -y+5, debugSty, "Ctrl-T for diagnostics")
+		}
+
 		switch arcade.Server.Network.GetDropRate() {
 		case 0:
 			mgr.screen.DrawText(-x+len(text0)+1, -y+4, debugSty, "<--")
@@ -203,26 +1036,104 @@ func (mgr *ViewManager) RequestRender() {
 		}
 
 		connectedClients := arcade.Server.GetHeartbeatClients()
+		usage := arcade.Server.Network.Usage()
 
 		i := 0
 		connectedClients.Range(func(key, value any) bool {
 			clientID := key.(string)
 			info := value.(ConnectedClientInfo)
 
-			s := fmt.Sprintf("%s: %dms", clientID[:4], info.GetMeanRTT().Milliseconds())
+			traffic := usage.Peers[clientID]
+			link := fmt.Sprintf("%dms %d%% loss", info.GetMeanRTT().Milliseconds(), int(info.Quality(time.Now()).Loss*100))
+
+			if c, ok := arcade.Server.Network.GetClient(clientID); ok {
+				c.RLock()
+				if c.FEC {
+					link += " FEC"
+				}
+				c.RUnlock()
+			}
+
+			s := fmt.Sprintf("%s: %s, %s out, %s in", shortID(clientID, 4), link, formatBytes(uint64(traffic.Sent)), formatBytes(uint64(traffic.Received)))
 			mgr.screen.DrawText(w+x-len(s), -y+i, debugSty, s)
 			i++
 
 			return true
 		})
 
+		total := fmt.Sprintf("Total: %s out, %s in", formatBytes(uint64(usage.Total.Sent)), formatBytes(uint64(usage.Total.Received)))
+
+		if usage.Dropped > 0 {
+			total += fmt.Sprintf(", %d capped", usage.Dropped)
+		}
+
+		mgr.screen.DrawText(w+x-len(total), -y+i, debugSty, total)
+
+		resent, recovered := net.KCPStats()
+		kcpStats := fmt.Sprintf("KCP: %.1f%% resent, %d recovered by FEC", resent*100, recovered)
+		mgr.screen.DrawText(w+x-len(kcpStats), -y+i+1, debugSty, kcpStats)
+
+		if name, took, budget, ok := mgr.frames.Slow(); ok {
+			mgr.screen.DrawText(-x, h+y-4, debugSty, fmt.Sprintf("Slow view: %s took %s of %s", name, formatMillis(took), formatMillis(budget)))
+		}
+
+		if name, took, ok := arcade.Diagnostics.Slowest(); ok {
+			mgr.screen.DrawText(-x, h+y-3, debugSty, fmt.Sprintf("Slow handler: %s took %s", name, formatMillis(took)))
+		}
+
 		if ip, err := net.GetLocalIP(); err == nil {
-			mgr.screen.DrawText(-x, h+y-1, debugSty, fmt.Sprintf("Local IP: %s:%d", ip, arcade.Port))
+			mgr.screen.DrawText(-x, h+y-1, debugSty, fmt.Sprintf("Local IP: %s:%d", ip, arcade.Server.Port()))
 			mgr.screen.DrawText(-x, h+y-2, debugSty, fmt.Sprintf("ID: %s", arcade.Server.ID))
 		}
 	}
 
+	mgr.renderMu.Lock()
 	mgr.screen.Show()
+	mgr.renderPixels()
+	mgr.renderMu.Unlock()
+}
+
+// frameBudget returns how long a frame has at the FPS cap, or how long one
+// can take before it's a hitch if there isn't one.
+func (mgr *ViewManager) frameBudget() time.Duration {
+	mgr.RLock()
+	defer mgr.RUnlock()
+
+	config := mgr.config
+
+	if mgr.preview != nil {
+		config = mgr.preview
+	}
+
+	if config.FPSCap <= 0 {
+		return hitchThreshold
+	}
+
+	return time.Second / time.Duration(config.FPSCap)
+}
+
+// observeRender notes how long the view took to draw. Once it keeps going
+// over budget it's logged, and it and the manager leave out decorative
+// animation until it catches up.
+func (mgr *ViewManager) observeRender(view View, took time.Duration) {
+	name := reflect.TypeOf(view).Elem().Name()
+	budget := mgr.frameBudget()
+
+	if !mgr.frames.Observe(name, took, budget) {
+		return
+	}
+
+	_, worst, _, slow := mgr.frames.Slow()
+
+	if slow {
+		log.Printf("Drawing %s took up to %v, over its %v budget, so decorative animation is off\n", name, worst, budget)
+	} else {
+		log.Printf("Drawing %s is back under its %v budget\n", name, budget)
+	}
+
+	if dv, ok := view.(DegradableView); ok {
+		dv.SetDegraded(slow)
+	}
 }
 
 func (mgr *ViewManager) RequestDebugRender() {
@@ -238,14 +1149,18 @@ func (mgr *ViewManager) RequestDebugRender() {
 }
 
 func (mgr *ViewManager) GetHeartbeatMetadata() []byte {
+	away := mgr.Away()
+
 	mgr.RLock()
-	metadata := mgr.view.GetHeartbeatMetadata()
+	metadata, err := newHeartbeatMetadata(mgr.view)
 	mgr.RUnlock()
 
-	if metadata == nil {
-		return nil
+	if err != nil {
+		panic(err)
 	}
 
+	metadata.Away = away
+
 	data, err := metadata.MarshalBinary()
 
 	if err != nil {
@@ -256,13 +1171,174 @@ func (mgr *ViewManager) GetHeartbeatMetadata() []byte {
 }
 
 //
-// NetworkDelegate functions
+// Server events
+//
+
+// subscribe hands the server's network events to the current view.
+func (mgr *ViewManager) subscribe(events *EventBus) {
+	events.Subscribe(func(ev Event) {
+		mgr.ProcessEvent(ev)
+	}, NetworkEvents)
+
+	events.Subscribe(func(ev Event) {
+		if evt, ok := ev.(*GameEndedEvent); ok {
+			mgr.recordAchievements(evt.Result)
+
+			time.AfterFunc(seasonRefreshDelay, func() {
+				mgr.refreshSeason()
+			})
+		}
+	}, GameEvents)
+}
+
+// recordAchievements counts a game we played towards our achievements, and
+// tells us about any it unlocked.
+func (mgr *ViewManager) recordAchievements(result MatchResult) {
+	unlocked, err := mgr.Achievements.Record(result, arcade.Server.ID)
+
+	if err != nil {
+		log.Println("Couldn't save achievements:", err)
+	}
+
+	for _, a := range unlocked {
+		mgr.Toasts.Push(fmt.Sprintf("Achievement unlocked: %s", a.Name))
+	}
+}
+
+//
+// Invites
 //
 
-func (mgr *ViewManager) ClientConnected(id string) {
-	mgr.ProcessEvent(&ClientConnectedEvent{id})
+func (mgr *ViewManager) receiveInvite(msg *InviteMessage) {
+	if msg.SenderID != msg.HostID || IsBlocked(msg.HostID) {
+		return
+	}
+
+	msg.HostName = filterText(msg.HostName)
+	msg.PlayerName = filterText(msg.PlayerName)
+
+	hostName := msg.HostName
+
+	if hostName == "" {
+		hostName = shortID(msg.HostID, 8)
+	}
+
+	// The invite has its own prompt, so only keep it in the history
+	mgr.Toasts.Log(fmt.Sprintf("Invite from %s to '%s'", hostName, msg.LobbyName))
+
+	mgr.Lock()
+	mgr.invite = msg
+	mgr.Unlock()
+
+	mgr.RequestRender()
+}
+
+// processInviteKey accepts or declines a pending invite. Returns true if the
+// key was used.
+func (mgr *ViewManager) processInviteKey(r rune) bool {
+	mgr.Lock()
+	invite := mgr.invite
+
+	if invite == nil || (r != 'y' && r != 'n') {
+		mgr.Unlock()
+		return false
+	}
+
+	mgr.invite = nil
+
+	if r == 'y' {
+		mgr.acceptedInvite = invite
+	}
+	mgr.Unlock()
+
+	if r == 'y' {
+		if host, ok := arcade.Server.Network.GetClient(invite.HostID); ok {
+			join := NewJoinMessage("", arcade.Server.ID, invite.LobbyID)
+			join.CoachFor = invite.CoachFor
+
+			go arcade.Server.Network.Send(host, join)
+		}
+	}
+
+	mgr.screen.Reset()
+	mgr.RequestRender()
+	return true
 }
 
-func (mgr *ViewManager) ClientDisconnected(id string) {
-	mgr.ProcessEvent(&ClientDisconnectedEvent{id})
+// processInviteReply moves to the lobby once the host of an accepted invite
+// lets us in. Returns true if the reply was for an accepted invite.
+func (mgr *ViewManager) processInviteReply(msg *JoinReplyMessage) bool {
+	mgr.Lock()
+	invite := mgr.acceptedInvite
+
+	if invite == nil || msg.SenderID != invite.HostID {
+		mgr.Unlock()
+		return false
+	}
+
+	mgr.acceptedInvite = nil
+	mgr.Unlock()
+
+	if msg.Error == OK {
+		mgr.SetView(NewLobbyView(mgr, msg.Lobby))
+		arcade.Server.BeginHeartbeats(msg.Lobby.HostID)
+	}
+
+	return true
+}
+
+// renderInvite draws the pending invite, if any, and returns the row below it.
+func (mgr *ViewManager) renderInvite() int {
+	mgr.RLock()
+	invite := mgr.invite
+	mgr.RUnlock()
+
+	if invite == nil {
+		return 1
+	}
+
+	width, _ := mgr.screen.displaySize()
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorYellow)
+
+	hostName := invite.HostName
+
+	if hostName == "" {
+		hostName = shortID(invite.HostID, 4)
+	}
+
+	lines := []string{
+		fmt.Sprintf("%s invited you to play %s", hostName, invite.GameType),
+		fmt.Sprintf("in lobby '%s'", invite.LobbyName),
+		"[Y] Accept   [N] Decline",
+	}
+
+	if invite.CoachFor != "" {
+		playerName := invite.PlayerName
+
+		if playerName == "" {
+			playerName = invite.CoachFor[:4]
+		}
+
+		lines[0] = fmt.Sprintf("%s asked you to coach them at %s", playerName, invite.GameType)
+	}
+
+	boxWidth := 0
+
+	for _, line := range lines {
+		if len(line) > boxWidth {
+			boxWidth = len(line)
+		}
+	}
+
+	x1 := width - boxWidth - 6
+	x2 := width - 3
+
+	mgr.screen.DrawEmpty(x1, 1, x2, len(lines)+2, sty)
+	mgr.screen.DrawBox(x1, 1, x2, len(lines)+2, sty, false)
+
+	for i, line := range lines {
+		mgr.screen.DrawText(x1+2, 2+i, sty, line)
+	}
+
+	return len(lines) + 3
 }
@@ -0,0 +1,54 @@
+package widgets
+
+import (
+	"arcade/arcade/layout"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Button runs OnPress when Enter or space is pressed while it's focused.
+type Button struct {
+	Base
+
+	Label   string
+	OnPress func()
+}
+
+func NewButton(x, y, width int, label string, onPress func()) *Button {
+	return &Button{
+		Base:    Base{X: x, Y: y, Width: width},
+		Label:   label,
+		OnPress: onPress,
+	}
+}
+
+func (b *Button) ProcessEvent(evt *tcell.EventKey) bool {
+	if evt.Key() != tcell.KeyEnter && !(evt.Key() == tcell.KeyRune && evt.Rune() == ' ') {
+		return false
+	}
+
+	b.RLock()
+	onPress := b.OnPress
+	b.RUnlock()
+
+	if onPress != nil {
+		onPress()
+	}
+
+	return true
+}
+
+func (b *Button) Render(c Canvas) {
+	b.RLock()
+	defer b.RUnlock()
+
+	sty := BoldStyle
+
+	if b.focused {
+		sty = FocusedStyle
+	}
+
+	label := "[ " + b.Label + " ]"
+	c.DrawEmpty(b.X, b.Y, b.X+b.Width-1, b.Y, sty)
+	c.DrawText(b.X+layout.Center(b.Width, label), b.Y, sty, label)
+}
@@ -0,0 +1,76 @@
+package widgets
+
+import (
+	"arcade/arcade/layout"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Checkbox toggles when Enter or space is pressed while it's focused.
+type Checkbox struct {
+	Base
+
+	Label    string
+	checked  bool
+	OnChange func(checked bool)
+}
+
+func NewCheckbox(x, y, width int, label string, checked bool) *Checkbox {
+	return &Checkbox{
+		Base:    Base{X: x, Y: y, Width: width},
+		Label:   label,
+		checked: checked,
+	}
+}
+
+func (cb *Checkbox) Checked() bool {
+	cb.RLock()
+	defer cb.RUnlock()
+
+	return cb.checked
+}
+
+func (cb *Checkbox) SetChecked(checked bool) {
+	cb.Lock()
+	defer cb.Unlock()
+
+	cb.checked = checked
+}
+
+func (cb *Checkbox) ProcessEvent(evt *tcell.EventKey) bool {
+	if evt.Key() != tcell.KeyEnter && !(evt.Key() == tcell.KeyRune && evt.Rune() == ' ') {
+		return false
+	}
+
+	cb.Lock()
+	cb.checked = !cb.checked
+	checked := cb.checked
+	onChange := cb.OnChange
+	cb.Unlock()
+
+	if onChange != nil {
+		onChange(checked)
+	}
+
+	return true
+}
+
+func (cb *Checkbox) Render(c Canvas) {
+	cb.RLock()
+	defer cb.RUnlock()
+
+	sty := Style
+
+	if cb.focused {
+		sty = FocusedStyle
+	}
+
+	box := "[ ] "
+
+	if cb.checked {
+		box = "[x] "
+	}
+
+	c.DrawEmpty(cb.X, cb.Y, cb.X+cb.Width-1, cb.Y, sty)
+	c.DrawText(cb.X, cb.Y, sty, layout.Truncate(box+cb.Label, cb.Width))
+}
@@ -0,0 +1,112 @@
+package widgets
+
+import (
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// FocusGroup is an ordered set of widgets with one of them focused. Tab and
+// Backtab move focus, and other keys go to the focused widget.
+type FocusGroup struct {
+	mu sync.RWMutex
+
+	widgets []Widget
+	index   int
+}
+
+func NewFocusGroup(widgets ...Widget) *FocusGroup {
+	g := &FocusGroup{widgets: widgets}
+
+	if len(widgets) > 0 {
+		widgets[0].SetFocused(true)
+	}
+
+	return g
+}
+
+// Focused returns the focused widget, or nil if the group is empty.
+func (g *FocusGroup) Focused() Widget {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if len(g.widgets) == 0 {
+		return nil
+	}
+
+	return g.widgets[g.index]
+}
+
+// Focus moves focus to the given widget if it's in the group.
+func (g *FocusGroup) Focus(w Widget) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for i, widget := range g.widgets {
+		if widget == w {
+			g.moveFocus(i)
+			return
+		}
+	}
+}
+
+func (g *FocusGroup) Next() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.widgets) > 0 {
+		g.moveFocus((g.index + 1) % len(g.widgets))
+	}
+}
+
+func (g *FocusGroup) Prev() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.widgets) > 0 {
+		g.moveFocus((g.index - 1 + len(g.widgets)) % len(g.widgets))
+	}
+}
+
+// moveFocus expects the lock to be held.
+func (g *FocusGroup) moveFocus(i int) {
+	g.widgets[g.index].SetFocused(false)
+	g.index = i
+	g.widgets[g.index].SetFocused(true)
+}
+
+// ProcessEvent gives the event to the focused widget, and moves focus on Tab
+// and Backtab if the widget didn't use them. Returns true if the event was
+// used.
+func (g *FocusGroup) ProcessEvent(evt *tcell.EventKey) bool {
+	focused := g.Focused()
+
+	if focused == nil {
+		return false
+	}
+
+	if focused.ProcessEvent(evt) {
+		return true
+	}
+
+	switch evt.Key() {
+	case tcell.KeyTab:
+		g.Next()
+		return true
+	case tcell.KeyBacktab:
+		g.Prev()
+		return true
+	}
+
+	return false
+}
+
+func (g *FocusGroup) Render(c Canvas) {
+	g.mu.RLock()
+	widgets := g.widgets
+	g.mu.RUnlock()
+
+	for _, w := range widgets {
+		w.Render(c)
+	}
+}
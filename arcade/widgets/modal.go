@@ -0,0 +1,124 @@
+package widgets
+
+import (
+	"arcade/arcade/layout"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Modal is a centered dialog with a message and a row of choices. While it's
+// open it takes every key event.
+type Modal struct {
+	Base
+
+	Title   string
+	Lines   []string
+	Choices []string
+
+	selected int
+	closed   bool
+
+	// OnChoose is called with the index of the chosen option
+	OnChoose func(choice int)
+}
+
+func NewModal(title string, lines []string, choices []string, onChoose func(choice int)) *Modal {
+	return &Modal{
+		Title:    title,
+		Lines:    lines,
+		Choices:  choices,
+		OnChoose: onChoose,
+	}
+}
+
+// Closed returns true once a choice has been made.
+func (m *Modal) Closed() bool {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.closed
+}
+
+func (m *Modal) ProcessEvent(evt *tcell.EventKey) bool {
+	m.Lock()
+
+	switch evt.Key() {
+	case tcell.KeyLeft, tcell.KeyBacktab:
+		if m.selected > 0 {
+			m.selected--
+		}
+	case tcell.KeyRight, tcell.KeyTab:
+		if m.selected < len(m.Choices)-1 {
+			m.selected++
+		}
+	case tcell.KeyEnter:
+		choice := m.selected
+		onChoose := m.OnChoose
+		m.closed = true
+		m.Unlock()
+
+		if onChoose != nil {
+			onChoose(choice)
+		}
+
+		return true
+	}
+
+	m.Unlock()
+	return true
+}
+
+func (m *Modal) Render(c Canvas) {
+	m.RLock()
+	defer m.RUnlock()
+
+	width, height := c.DisplaySize()
+
+	choices := ""
+
+	for i, choice := range m.Choices {
+		if i > 0 {
+			choices += "   "
+		}
+
+		choices += "[ " + choice + " ]"
+	}
+
+	boxWidth := layout.Width(m.Title) + 4
+
+	for _, line := range append([]string{choices}, m.Lines...) {
+		if w := layout.Width(line) + 4; w > boxWidth {
+			boxWidth = w
+		}
+	}
+
+	boxHeight := len(m.Lines) + 5
+
+	x1 := (width - boxWidth) / 2
+	y1 := (height - boxHeight) / 2
+	x2 := x1 + boxWidth - 1
+	y2 := y1 + boxHeight - 1
+
+	c.DrawEmpty(x1, y1, x2, y2, Style)
+	c.DrawBox(x1, y1, x2, y2, BoldStyle, true)
+	c.DrawText(x1+2, y1, BoldStyle, " "+m.Title+" ")
+
+	for i, line := range m.Lines {
+		c.DrawText(x1+2, y1+2+i, Style, line)
+	}
+
+	// Draw the choices, highlighting the selected one
+	x := layout.Center(width, choices)
+
+	for i, choice := range m.Choices {
+		label := "[ " + choice + " ]"
+		sty := Style
+
+		if i == m.selected {
+			sty = FocusedStyle
+		}
+
+		c.DrawText(x, y2-1, sty, label)
+		x += layout.Width(label) + 3
+	}
+}
@@ -0,0 +1,65 @@
+package widgets
+
+import (
+	"arcade/arcade/layout"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ProgressBar shows how far along something is. It can't be focused.
+type ProgressBar struct {
+	Base
+
+	Label    string
+	progress float64
+}
+
+func NewProgressBar(x, y, width int, label string) *ProgressBar {
+	return &ProgressBar{
+		Base:  Base{X: x, Y: y, Width: width},
+		Label: label,
+	}
+}
+
+// SetProgress sets how far along the bar is, from 0 to 1.
+func (pb *ProgressBar) SetProgress(progress float64) {
+	pb.Lock()
+	defer pb.Unlock()
+
+	if progress < 0 {
+		progress = 0
+	} else if progress > 1 {
+		progress = 1
+	}
+
+	pb.progress = progress
+}
+
+func (pb *ProgressBar) ProcessEvent(evt *tcell.EventKey) bool {
+	return false
+}
+
+func (pb *ProgressBar) Render(c Canvas) {
+	pb.RLock()
+	defer pb.RUnlock()
+
+	percent := fmt.Sprintf(" %3d%%", int(pb.progress*100))
+	label := ""
+
+	if pb.Label != "" {
+		label = pb.Label + " "
+	}
+
+	barWidth := pb.Width - layout.Width(label) - len(percent)
+
+	if barWidth < 1 {
+		barWidth = 1
+	}
+
+	filled := int(pb.progress * float64(barWidth))
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+
+	c.DrawText(pb.X, pb.Y, Style, label+bar+percent)
+}
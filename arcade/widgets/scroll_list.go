@@ -0,0 +1,180 @@
+package widgets
+
+import (
+	"arcade/arcade/layout"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ScrollList shows a list of rows, scrolling to keep the selected one visible.
+type ScrollList struct {
+	Base
+
+	Height int
+
+	items    []string
+	selected int
+	offset   int
+
+	// Placeholder is shown when there are no items
+	Placeholder string
+
+	OnSelect func(index int)
+}
+
+func NewScrollList(x, y, width, height int) *ScrollList {
+	return &ScrollList{
+		Base:   Base{X: x, Y: y, Width: width},
+		Height: height,
+	}
+}
+
+// SetItems replaces the rows, keeping the selection in range.
+func (l *ScrollList) SetItems(items []string) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.items = items
+	l.clamp()
+}
+
+func (l *ScrollList) Len() int {
+	l.RLock()
+	defer l.RUnlock()
+
+	return len(l.items)
+}
+
+// Selected returns the index of the selected row, or -1 if the list is empty.
+func (l *ScrollList) Selected() int {
+	l.RLock()
+	defer l.RUnlock()
+
+	if len(l.items) == 0 {
+		return -1
+	}
+
+	return l.selected
+}
+
+func (l *ScrollList) Select(index int) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.selected = index
+	l.clamp()
+}
+
+// Offset returns the index of the first visible row.
+func (l *ScrollList) Offset() int {
+	l.RLock()
+	defer l.RUnlock()
+
+	return l.offset
+}
+
+// clamp keeps the selection in range and on screen. Expects the lock to be
+// held.
+func (l *ScrollList) clamp() {
+	if l.selected >= len(l.items) {
+		l.selected = len(l.items) - 1
+	}
+
+	if l.selected < 0 {
+		l.selected = 0
+	}
+
+	if l.selected < l.offset {
+		l.offset = l.selected
+	} else if l.selected >= l.offset+l.Height {
+		l.offset = l.selected - l.Height + 1
+	}
+
+	if maxOffset := len(l.items) - l.Height; l.offset > maxOffset && maxOffset >= 0 {
+		l.offset = maxOffset
+	}
+
+	if l.offset < 0 {
+		l.offset = 0
+	}
+}
+
+func (l *ScrollList) ProcessEvent(evt *tcell.EventKey) bool {
+	l.Lock()
+
+	var selected func(int)
+
+	switch evt.Key() {
+	case tcell.KeyUp:
+		l.selected--
+	case tcell.KeyDown:
+		l.selected++
+	case tcell.KeyPgUp:
+		l.selected -= l.Height
+	case tcell.KeyPgDn:
+		l.selected += l.Height
+	case tcell.KeyHome:
+		l.selected = 0
+	case tcell.KeyEnd:
+		l.selected = len(l.items) - 1
+	case tcell.KeyEnter:
+		if l.OnSelect == nil || len(l.items) == 0 {
+			l.Unlock()
+			return false
+		}
+
+		selected = l.OnSelect
+	default:
+		l.Unlock()
+		return false
+	}
+
+	l.clamp()
+	index := l.selected
+	l.Unlock()
+
+	if selected != nil {
+		selected(index)
+	}
+
+	return true
+}
+
+func (l *ScrollList) Render(c Canvas) {
+	l.RLock()
+	defer l.RUnlock()
+
+	for row := 0; row < l.Height; row++ {
+		c.DrawEmpty(l.X, l.Y+row, l.X+l.Width-1, l.Y+row, Style)
+	}
+
+	if len(l.items) == 0 {
+		c.DrawText(l.X+1, l.Y, MutedStyle, layout.Truncate(l.Placeholder, l.Width-2))
+		return
+	}
+
+	for row := 0; row < l.Height && l.offset+row < len(l.items); row++ {
+		i := l.offset + row
+		sty := Style
+
+		if i == l.selected {
+			sty = FocusedStyle
+
+			if !l.focused {
+				sty = BoldStyle
+			}
+		}
+
+		c.DrawEmpty(l.X, l.Y+row, l.X+l.Width-1, l.Y+row, sty)
+		c.DrawText(l.X, l.Y+row, sty, layout.Truncate(l.items[i], l.Width-1))
+	}
+
+	// Show that there's more above or below
+	if l.offset > 0 {
+		c.DrawText(l.X+l.Width-1, l.Y, Style, "▲")
+	}
+
+	if l.offset+l.Height < len(l.items) {
+		c.DrawText(l.X+l.Width-1, l.Y+l.Height-1, Style, "▼")
+	}
+}
@@ -0,0 +1,97 @@
+package widgets
+
+import (
+	"arcade/arcade/layout"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Select picks one of a few options with the left and right arrows.
+type Select struct {
+	Base
+
+	Options  []string
+	selected int
+	OnChange func(value string)
+}
+
+func NewSelect(x, y, width int, options []string) *Select {
+	return &Select{
+		Base:    Base{X: x, Y: y, Width: width},
+		Options: options,
+	}
+}
+
+func (s *Select) Value() string {
+	s.RLock()
+	defer s.RUnlock()
+
+	if len(s.Options) == 0 {
+		return ""
+	}
+
+	return s.Options[s.selected]
+}
+
+// SetValue selects the option with the given value, if there is one.
+func (s *Select) SetValue(value string) {
+	s.Lock()
+	defer s.Unlock()
+
+	for i, option := range s.Options {
+		if option == value {
+			s.selected = i
+			return
+		}
+	}
+}
+
+func (s *Select) ProcessEvent(evt *tcell.EventKey) bool {
+	s.Lock()
+
+	if len(s.Options) == 0 {
+		s.Unlock()
+		return false
+	}
+
+	switch evt.Key() {
+	case tcell.KeyLeft:
+		s.selected = (s.selected - 1 + len(s.Options)) % len(s.Options)
+	case tcell.KeyRight:
+		s.selected = (s.selected + 1) % len(s.Options)
+	default:
+		s.Unlock()
+		return false
+	}
+
+	value := s.Options[s.selected]
+	onChange := s.OnChange
+	s.Unlock()
+
+	if onChange != nil {
+		onChange(value)
+	}
+
+	return true
+}
+
+func (s *Select) Render(c Canvas) {
+	s.RLock()
+	defer s.RUnlock()
+
+	sty := Style
+
+	if s.focused {
+		sty = FocusedStyle
+	}
+
+	value := ""
+
+	if len(s.Options) > 0 {
+		value = s.Options[s.selected]
+	}
+
+	c.DrawEmpty(s.X, s.Y, s.X+s.Width-1, s.Y, sty)
+	c.DrawText(s.X, s.Y, sty, layout.Truncate("◀ "+value, s.Width-2))
+	c.DrawText(s.X+s.Width-1, s.Y, sty, "▶")
+}
@@ -0,0 +1,210 @@
+package widgets
+
+import (
+	"arcade/arcade/layout"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// TextInput is a single line text box with a cursor and shift-arrow selection.
+type TextInput struct {
+	Base
+
+	value       []rune
+	cursor      int
+	anchor      int // other end of the selection, or -1
+	MaxLength   int
+	Masked      bool
+	Placeholder string
+
+	OnSubmit func(value string)
+	OnChange func(value string)
+}
+
+func NewTextInput(x, y, width int) *TextInput {
+	return &TextInput{
+		Base:   Base{X: x, Y: y, Width: width},
+		anchor: -1,
+	}
+}
+
+func (ti *TextInput) Value() string {
+	ti.RLock()
+	defer ti.RUnlock()
+
+	return string(ti.value)
+}
+
+func (ti *TextInput) SetValue(value string) {
+	ti.Lock()
+	defer ti.Unlock()
+
+	ti.value = []rune(value)
+	ti.cursor = len(ti.value)
+	ti.anchor = -1
+}
+
+// selection returns the selected range. Expects the lock to be held.
+func (ti *TextInput) selection() (int, int, bool) {
+	if ti.anchor < 0 || ti.anchor == ti.cursor {
+		return 0, 0, false
+	}
+
+	if ti.anchor < ti.cursor {
+		return ti.anchor, ti.cursor, true
+	}
+
+	return ti.cursor, ti.anchor, true
+}
+
+// deleteSelection removes the selected text. Expects the lock to be held.
+func (ti *TextInput) deleteSelection() bool {
+	start, end, ok := ti.selection()
+
+	if !ok {
+		return false
+	}
+
+	ti.value = append(ti.value[:start:start], ti.value[end:]...)
+	ti.cursor = start
+	ti.anchor = -1
+
+	return true
+}
+
+// moveCursor moves the cursor, extending the selection if shift is held.
+// Expects the lock to be held.
+func (ti *TextInput) moveCursor(pos int, selecting bool) {
+	if pos < 0 {
+		pos = 0
+	}
+
+	if pos > len(ti.value) {
+		pos = len(ti.value)
+	}
+
+	if selecting && ti.anchor < 0 {
+		ti.anchor = ti.cursor
+	} else if !selecting {
+		ti.anchor = -1
+	}
+
+	ti.cursor = pos
+}
+
+func (ti *TextInput) ProcessEvent(evt *tcell.EventKey) bool {
+	ti.Lock()
+
+	selecting := evt.Modifiers()&tcell.ModShift != 0
+	before := string(ti.value)
+	handled := true
+	var submit func(string)
+
+	switch evt.Key() {
+	case tcell.KeyLeft:
+		ti.moveCursor(ti.cursor-1, selecting)
+	case tcell.KeyRight:
+		ti.moveCursor(ti.cursor+1, selecting)
+	case tcell.KeyHome:
+		ti.moveCursor(0, selecting)
+	case tcell.KeyEnd:
+		ti.moveCursor(len(ti.value), selecting)
+	case tcell.KeyCtrlA:
+		ti.anchor = 0
+		ti.cursor = len(ti.value)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if !ti.deleteSelection() && ti.cursor > 0 {
+			ti.value = append(ti.value[:ti.cursor-1:ti.cursor-1], ti.value[ti.cursor:]...)
+			ti.cursor--
+		}
+	case tcell.KeyDelete:
+		if !ti.deleteSelection() && ti.cursor < len(ti.value) {
+			ti.value = append(ti.value[:ti.cursor:ti.cursor], ti.value[ti.cursor+1:]...)
+		}
+	case tcell.KeyEnter:
+		submit = ti.OnSubmit
+		handled = submit != nil
+	case tcell.KeyRune:
+		ti.deleteSelection()
+
+		if ti.MaxLength > 0 && len(ti.value) >= ti.MaxLength {
+			break
+		}
+
+		ti.value = append(ti.value[:ti.cursor:ti.cursor], append([]rune{evt.Rune()}, ti.value[ti.cursor:]...)...)
+		ti.cursor++
+	default:
+		handled = false
+	}
+
+	value := string(ti.value)
+	changed := ti.OnChange
+	ti.Unlock()
+
+	// Callbacks are called without the lock so they can use the input
+	if changed != nil && value != before {
+		changed(value)
+	}
+
+	if submit != nil {
+		submit(value)
+	}
+
+	return handled
+}
+
+func (ti *TextInput) Render(c Canvas) {
+	ti.RLock()
+	defer ti.RUnlock()
+
+	text := ti.value
+
+	if ti.Masked {
+		text = []rune(strings.Repeat("*", len(ti.value)))
+	}
+
+	c.DrawEmpty(ti.X, ti.Y, ti.X+ti.Width-1, ti.Y, Style)
+
+	if len(text) == 0 && !ti.focused {
+		c.DrawText(ti.X, ti.Y, MutedStyle, layout.Truncate(ti.Placeholder, ti.Width))
+		return
+	}
+
+	// Scroll so the cursor stays visible
+	offset := 0
+
+	if ti.cursor >= ti.Width {
+		offset = ti.cursor - ti.Width + 1
+	}
+
+	start, end, selected := ti.selection()
+
+	x := ti.X
+
+	for i := offset; i <= len(text); i++ {
+		ch := " "
+
+		if i < len(text) {
+			ch = string(text[i])
+		}
+
+		// Wide runes take two cells, and can't be split at the edge
+		if x+layout.Width(ch) > ti.X+ti.Width {
+			break
+		}
+
+		sty := Style
+
+		if selected && i >= start && i < end {
+			sty = FocusedStyle
+		}
+
+		if ti.focused && i == ti.cursor {
+			sty = CursorStyle
+		}
+
+		c.DrawText(x, ti.Y, sty, ch)
+		x += layout.Width(ch)
+	}
+}
@@ -0,0 +1,57 @@
+package widgets
+
+import (
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Canvas is what widgets draw on. The arcade Screen implements it.
+type Canvas interface {
+	DisplaySize() (int, int)
+	DrawText(x, y int, style tcell.Style, text string)
+	DrawEmpty(x1, y1, x2, y2 int, style tcell.Style)
+	DrawBox(x1, y1, x2, y2 int, style tcell.Style, thicker bool)
+}
+
+// Widget is a piece of UI that can be drawn, focused and given key events.
+type Widget interface {
+	Render(c Canvas)
+
+	// ProcessEvent returns true if the widget used the event.
+	ProcessEvent(evt *tcell.EventKey) bool
+
+	SetFocused(focused bool)
+}
+
+// Styles shared by all widgets
+var (
+	Style        = tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	BoldStyle    = tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorLightGreen)
+	FocusedStyle = tcell.StyleDefault.Background(tcell.ColorDarkGreen).Foreground(tcell.ColorWhite)
+	CursorStyle  = tcell.StyleDefault.Background(tcell.ColorGray).Foreground(tcell.ColorWhite)
+	MutedStyle   = tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGray)
+)
+
+// Base holds the state every widget has. Embed it to get SetFocused.
+type Base struct {
+	sync.RWMutex
+
+	X, Y    int
+	Width   int
+	focused bool
+}
+
+func (b *Base) SetFocused(focused bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.focused = focused
+}
+
+func (b *Base) Focused() bool {
+	b.RLock()
+	defer b.RUnlock()
+
+	return b.focused
+}
@@ -0,0 +1,305 @@
+package arcade
+
+import (
+	"arcade/arcade/net"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// What's published to the workshop. Only maps, for now
+	WorkshopMap = "map"
+
+	// Items the workshop keeps, and how many one player can have published
+	maxWorkshopItems        = 500
+	maxPublishedPerPlayer   = 20
+	maxWorkshopAuthorLength = 24
+
+	// Items in each page of the workshop, so a page fits in one packet
+	workshopPageSize = 3
+)
+
+var (
+	errWorkshopPublished = errors.New("that map's already in the workshop")
+	errWorkshopLimit     = fmt.Errorf("you can publish up to %d maps", maxPublishedPerPlayer)
+	errWorkshopFull      = errors.New("the workshop is full")
+	errWorkshopMissing   = errors.New("no such map in the workshop")
+)
+
+// WorkshopItem describes something published to the workshop.
+type WorkshopItem struct {
+	// The hash of the content, which the distributor works out itself
+	Hash string
+	Kind string
+	Name string
+
+	PublishedBy string
+	Author      string
+	PublishedAt time.Time
+
+	// Bytes of the content as it's sent
+	Size      int
+	Downloads int
+}
+
+// workshopEntry is a published item with its content, which is also how the
+// distributor saves it.
+type workshopEntry struct {
+	Item WorkshopItem
+	Map  *TronMap
+}
+
+// Workshop keeps the maps players have published to the distributor, for
+// anyone to browse and download.
+type Workshop struct {
+	mu    sync.Mutex
+	items map[string]*workshopEntry
+}
+
+func NewWorkshop() *Workshop {
+	return &Workshop{
+		items: make(map[string]*workshopEntry),
+	}
+}
+
+// handleMessage processes messages addressed to the distributor itself. The
+// second return value is false if the message isn't for the workshop.
+func (w *Workshop) handleMessage(c *net.Client, msg interface{}) (interface{}, bool) {
+	switch msg := msg.(type) {
+	case *WorkshopPublishMessage:
+		item, err := w.Publish(msg.SenderID, msg.Author, msg.Map)
+
+		if err != nil {
+			return NewErrorMessage(err.Error()), true
+		}
+
+		return NewWorkshopItemMessage(item, nil), true
+	case *WorkshopQueryMessage:
+		items, pages := w.Page(msg.Page, msg.Newest)
+		return NewWorkshopListMessage(items, msg.Page, pages), true
+	case *WorkshopDownloadMessage:
+		item, m, err := w.Download(msg.Hash)
+
+		if err != nil {
+			return NewErrorMessage(err.Error()), true
+		}
+
+		return NewWorkshopItemMessage(item, m), true
+	}
+
+	return nil, false
+}
+
+// Publish adds the player's map to the workshop, once it's checked it can be
+// played on and isn't there already.
+func (w *Workshop) Publish(playerID, author string, m *TronMap) (WorkshopItem, error) {
+	if m == nil {
+		return WorkshopItem{}, errMapSize
+	}
+
+	if err := m.Validate(); err != nil {
+		return WorkshopItem{}, err
+	}
+
+	data, err := json.Marshal(m)
+
+	if err != nil {
+		return WorkshopItem{}, err
+	}
+
+	if len(data) > maxMapSize {
+		return WorkshopItem{}, errMapSize
+	}
+
+	if len(author) > maxWorkshopAuthorLength {
+		author = author[:maxWorkshopAuthorLength]
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	hash := m.Hash()
+
+	if _, ok := w.items[hash]; ok {
+		return WorkshopItem{}, errWorkshopPublished
+	}
+
+	if len(w.items) >= maxWorkshopItems {
+		return WorkshopItem{}, errWorkshopFull
+	}
+
+	published := 0
+
+	for _, entry := range w.items {
+		if entry.Item.PublishedBy == playerID {
+			published++
+		}
+	}
+
+	if published >= maxPublishedPerPlayer {
+		return WorkshopItem{}, errWorkshopLimit
+	}
+
+	item := WorkshopItem{
+		Hash:        hash,
+		Kind:        WorkshopMap,
+		Name:        m.Name,
+		PublishedBy: playerID,
+		Author:      author,
+		PublishedAt: time.Now(),
+		Size:        len(data),
+	}
+
+	w.items[hash] = &workshopEntry{Item: item, Map: m}
+
+	fmt.Printf("%s published the map %s\n", shortID(playerID, 4), m.Name)
+	return item, nil
+}
+
+// Page returns a page of the workshop, most downloaded first unless newest,
+// and how many pages there are.
+func (w *Workshop) Page(page int, newest bool) ([]WorkshopItem, int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	items := make([]WorkshopItem, 0, len(w.items))
+
+	for _, entry := range w.items {
+		items = append(items, entry.Item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if !newest && items[i].Downloads != items[j].Downloads {
+			return items[i].Downloads > items[j].Downloads
+		}
+
+		return items[i].PublishedAt.After(items[j].PublishedAt)
+	})
+
+	pages := (len(items) + workshopPageSize - 1) / workshopPageSize
+
+	if page < 0 || page >= pages {
+		return []WorkshopItem{}, pages
+	}
+
+	end := (page + 1) * workshopPageSize
+
+	if end > len(items) {
+		end = len(items)
+	}
+
+	return items[page*workshopPageSize : end], pages
+}
+
+// Download returns the published map with the hash, counting the download.
+func (w *Workshop) Download(hash string) (WorkshopItem, *TronMap, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entry, ok := w.items[hash]
+
+	if !ok {
+		return WorkshopItem{}, nil, errWorkshopMissing
+	}
+
+	entry.Item.Downloads++
+
+	return entry.Item, entry.Map, nil
+}
+
+// snapshot returns everything published, for saving.
+func (w *Workshop) snapshot() []workshopEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entries := make([]workshopEntry, 0, len(w.items))
+
+	for _, entry := range w.items {
+		entries = append(entries, *entry)
+	}
+
+	return entries
+}
+
+// restore brings back saved items, skipping any that no longer check out.
+func (w *Workshop) restore(entries []workshopEntry) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i := range entries {
+		entry := entries[i]
+
+		if entry.Map == nil || entry.Map.Validate() != nil || entry.Map.Hash() != entry.Item.Hash {
+			continue
+		}
+
+		w.items[entry.Item.Hash] = &entry
+	}
+}
+
+// publishMap sends one of our maps to the distributor's workshop.
+func publishMap(m *TronMap) (*WorkshopItem, error) {
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		return nil, errors.New("not connected to the distributor")
+	}
+
+	author := ""
+
+	if profile, err := LoadProfile(); err == nil {
+		author = profile.Name
+	}
+
+	reply, err := arcade.Server.Network.SendAndReceive(distributor, NewWorkshopPublishMessage(author, m))
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch reply := reply.(type) {
+	case *WorkshopItemMessage:
+		return &reply.Item, nil
+	case *ErrorMessage:
+		return nil, errors.New(reply.Text)
+	}
+
+	return nil, errors.New("unexpected reply")
+}
+
+// downloadMap fetches a map from the distributor's workshop and keeps it,
+// checking it's the layout that was asked for.
+func downloadMap(hash string) (*TronMap, error) {
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		return nil, errors.New("not connected to the distributor")
+	}
+
+	reply, err := arcade.Server.Network.SendAndReceive(distributor, NewWorkshopDownloadMessage(hash))
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch reply := reply.(type) {
+	case *WorkshopItemMessage:
+		if reply.Map == nil || reply.Map.Hash() != hash {
+			return nil, errors.New("got a different map")
+		}
+
+		if err := keepSharedMap(reply.Map); err != nil {
+			return nil, err
+		}
+
+		return reply.Map, nil
+	case *ErrorMessage:
+		return nil, errors.New(reply.Text)
+	}
+
+	return nil, errors.New("unexpected reply")
+}
@@ -0,0 +1,24 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// WorkshopDownloadMessage asks the distributor for a published map by its
+// hash. It replies with a WorkshopItemMessage carrying the map.
+type WorkshopDownloadMessage struct {
+	message.Message
+	Hash string
+}
+
+func NewWorkshopDownloadMessage(hash string) *WorkshopDownloadMessage {
+	return &WorkshopDownloadMessage{
+		Message: message.Message{Type: "workshop_download"},
+		Hash:    hash,
+	}
+}
+
+func (m WorkshopDownloadMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
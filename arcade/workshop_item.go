@@ -0,0 +1,26 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// WorkshopItemMessage describes an item in the workshop, with the map itself
+// when it's been downloaded.
+type WorkshopItemMessage struct {
+	message.Message
+	Item WorkshopItem
+	Map  *TronMap `json:",omitempty"`
+}
+
+func NewWorkshopItemMessage(item WorkshopItem, m *TronMap) *WorkshopItemMessage {
+	return &WorkshopItemMessage{
+		Message: message.Message{Type: "workshop_item"},
+		Item:    item,
+		Map:     m,
+	}
+}
+
+func (m WorkshopItemMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
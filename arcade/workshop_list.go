@@ -0,0 +1,27 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// WorkshopListMessage is the distributor's reply to a WorkshopQueryMessage.
+type WorkshopListMessage struct {
+	message.Message
+	Items []WorkshopItem
+	Page  int
+	Pages int
+}
+
+func NewWorkshopListMessage(items []WorkshopItem, page, pages int) *WorkshopListMessage {
+	return &WorkshopListMessage{
+		Message: message.Message{Type: "workshop_list"},
+		Items:   items,
+		Page:    page,
+		Pages:   pages,
+	}
+}
+
+func (m WorkshopListMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,27 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// WorkshopPublishMessage publishes a map to the workshop, under the name of
+// the player publishing it. The distributor replies with a WorkshopItemMessage
+// describing it, or an ErrorMessage.
+type WorkshopPublishMessage struct {
+	message.Message
+	Author string
+	Map    *TronMap
+}
+
+func NewWorkshopPublishMessage(author string, m *TronMap) *WorkshopPublishMessage {
+	return &WorkshopPublishMessage{
+		Message: message.Message{Type: "workshop_publish"},
+		Author:  author,
+		Map:     m,
+	}
+}
+
+func (m WorkshopPublishMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,26 @@
+package arcade
+
+import (
+	"arcade/arcade/message"
+	"encoding/json"
+)
+
+// WorkshopQueryMessage asks the distributor for a page of what's been
+// published to the workshop, the most downloaded first or the newest first.
+type WorkshopQueryMessage struct {
+	message.Message
+	Page   int
+	Newest bool `json:",omitempty"`
+}
+
+func NewWorkshopQueryMessage(page int, newest bool) *WorkshopQueryMessage {
+	return &WorkshopQueryMessage{
+		Message: message.Message{Type: "workshop_query"},
+		Page:    page,
+		Newest:  newest,
+	}
+}
+
+func (m WorkshopQueryMessage) MarshalBinary() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,124 @@
+package arcade
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// workshopTestMap returns a playable map with a wall that makes its layout
+// different for each n.
+func workshopTestMap(name string, n int) *TronMap {
+	m := NewTronMap(name)
+	m.Set(2+n%70, 2+n/70, mapWall)
+
+	return m
+}
+
+func TestWorkshopPublishAndDownload(t *testing.T) {
+	w := NewWorkshop()
+	playerID := NewIdentity().PlayerID()
+	m := workshopTestMap("Pillar", 0)
+
+	item, err := w.Publish(playerID, "Flynn", m)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if item.Hash != m.Hash() || item.Kind != WorkshopMap || item.Size == 0 {
+		t.Errorf("got item %+v", item)
+	}
+
+	// The same layout under another name is still the same map
+	renamed := &TronMap{Name: "Copy", Rows: m.Rows}
+
+	if _, err := w.Publish(playerID, "Flynn", renamed); err != errWorkshopPublished {
+		t.Errorf("republishing got %v", err)
+	}
+
+	if _, err := w.Publish(playerID, "Flynn", &TronMap{Name: "Broken", Rows: m.Rows[1:]}); err != errMapSize {
+		t.Errorf("publishing a broken map got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := w.Download(item.Hash); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, downloaded, err := w.Download(item.Hash)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Downloads != 3 || downloaded.Hash() != item.Hash {
+		t.Errorf("got %d downloads of %s", got.Downloads, downloaded.Name)
+	}
+
+	if _, _, err := w.Download("nope"); err != errWorkshopMissing {
+		t.Errorf("missing map got %v", err)
+	}
+}
+
+func TestWorkshopLimitsEachPlayer(t *testing.T) {
+	w := NewWorkshop()
+	playerID := NewIdentity().PlayerID()
+
+	for i := 0; i < maxPublishedPerPlayer; i++ {
+		if _, err := w.Publish(playerID, "", workshopTestMap(fmt.Sprint("Map ", i), i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := w.Publish(playerID, "", workshopTestMap("One more", maxPublishedPerPlayer)); err != errWorkshopLimit {
+		t.Errorf("publishing past the limit got %v", err)
+	}
+
+	if _, err := w.Publish(NewIdentity().PlayerID(), "", workshopTestMap("Someone else", maxPublishedPerPlayer)); err != nil {
+		t.Errorf("another player got %v", err)
+	}
+}
+
+func TestWorkshopPagesFitInAPacket(t *testing.T) {
+	w := NewWorkshop()
+	long := strings.Repeat("x", maxMapNameLength)
+
+	for i := 0; i < 2*workshopPageSize; i++ {
+		item, err := w.Publish(NewIdentity().PlayerID(), strings.Repeat("y", 100), workshopTestMap(long[:maxMapNameLength-3]+fmt.Sprintf("%03d", i), i))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The first is the most downloaded
+		for j := 0; j < 2*workshopPageSize-i; j++ {
+			w.Download(item.Hash)
+		}
+	}
+
+	items, pages := w.Page(0, false)
+
+	if pages != 2 || len(items) != workshopPageSize || !strings.HasSuffix(items[0].Name, "000") {
+		t.Fatalf("got %d pages, first is %v", pages, items)
+	}
+
+	if newest, _ := w.Page(0, true); !strings.HasSuffix(newest[0].Name, fmt.Sprintf("%03d", 2*workshopPageSize-1)) {
+		t.Errorf("newest first got %s", newest[0].Name)
+	}
+
+	msg := NewWorkshopListMessage(items, 0, pages)
+	msg.SenderID = NewIdentity().PlayerID()
+	msg.RecipientID = NewIdentity().PlayerID()
+
+	data, err := msg.MarshalBinary()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data) > 1200 {
+		t.Errorf("a page is %d bytes", len(data))
+	}
+}
@@ -0,0 +1,238 @@
+package arcade
+
+import (
+	"arcade/arcade/layout"
+	"arcade/arcade/net"
+	"arcade/arcade/widgets"
+	"encoding"
+	"fmt"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var workshopFooter = "↑/↓ Move   ←/→ Page   Enter Download   [S]ort   [R]efresh   [B]ack"
+
+// WorkshopView browses the maps players have published to the distributor,
+// a page at a time, and downloads them to play on.
+type WorkshopView struct {
+	View
+	mgr *ViewManager
+
+	mu     sync.RWMutex
+	items  []WorkshopItem
+	page   int
+	pages  int
+	newest bool
+
+	// Set once the distributor's answered, or why it couldn't
+	loaded bool
+	errMsg string
+
+	list  *widgets.ScrollList
+	focus *widgets.FocusGroup
+}
+
+func NewWorkshopView(mgr *ViewManager) *WorkshopView {
+	v := &WorkshopView{
+		mgr:  mgr,
+		list: widgets.NewScrollList(mapsListX-12, mapsListY+2, mapsListWidth+24, workshopPageSize),
+	}
+
+	v.list.OnSelect = v.download
+	v.focus = widgets.NewFocusGroup(v.list)
+
+	return v
+}
+
+func (v *WorkshopView) Widgets() *widgets.FocusGroup {
+	return v.focus
+}
+
+func (v *WorkshopView) Init() {
+	go v.refresh()
+}
+
+// refresh asks the distributor for the page we're on.
+func (v *WorkshopView) refresh() {
+	v.mu.RLock()
+	page, newest := v.page, v.newest
+	v.mu.RUnlock()
+
+	distributor, ok := arcade.Server.Network.GetDistributor()
+
+	if !ok {
+		v.setError("Not connected to the distributor.")
+		return
+	}
+
+	res, err := arcade.Server.Network.SendAndReceive(distributor, NewWorkshopQueryMessage(page, newest))
+	reply, ok := res.(*WorkshopListMessage)
+
+	if !ok || err != nil {
+		v.setError("Couldn't reach the workshop.")
+		return
+	}
+
+	rows := make([]string, len(reply.Items))
+
+	for i, item := range reply.Items {
+		author := filterText(item.Author)
+
+		if author == "" && len(item.PublishedBy) >= 8 {
+			author = item.PublishedBy[:8]
+		}
+
+		rows[i] = fmt.Sprintf(" %-24s by %-24s %5d ↓", filterText(item.Name), layout.Truncate(author, 24), item.Downloads)
+	}
+
+	v.mu.Lock()
+	v.items = reply.Items
+	v.page, v.pages = reply.Page, reply.Pages
+	v.loaded = true
+	v.errMsg = ""
+	v.mu.Unlock()
+
+	v.list.SetItems(rows)
+	v.mgr.RequestRender()
+}
+
+func (v *WorkshopView) setError(msg string) {
+	v.mu.Lock()
+	v.errMsg = msg
+	v.mu.Unlock()
+
+	v.mgr.RequestRender()
+}
+
+// download fetches the map at the row and saves it with ours.
+func (v *WorkshopView) download(index int) {
+	v.mu.RLock()
+
+	if index < 0 || index >= len(v.items) {
+		v.mu.RUnlock()
+		return
+	}
+
+	item := v.items[index]
+	v.mu.RUnlock()
+
+	go func() {
+		if _, ok := findTronMap(item.Hash); ok {
+			notify("You already have %s", item.Name)
+			return
+		}
+
+		m, err := downloadMap(item.Hash)
+
+		if err != nil {
+			notify("Couldn't download %s: %s", item.Name, err)
+			return
+		}
+
+		notify("Downloaded %s", m.Name)
+		v.refresh()
+	}()
+}
+
+// turn moves to another page, if there is one.
+func (v *WorkshopView) turn(delta int) {
+	v.mu.Lock()
+	page := v.page + delta
+
+	if page < 0 || page >= v.pages {
+		v.mu.Unlock()
+		return
+	}
+
+	v.page = page
+	v.mu.Unlock()
+
+	go v.refresh()
+}
+
+func (v *WorkshopView) ProcessEvent(evt interface{}) {
+	switch evt := evt.(type) {
+	case *tcell.EventKey:
+		switch evt.Key() {
+		case tcell.KeyLeft:
+			v.turn(-1)
+		case tcell.KeyRight:
+			v.turn(1)
+		case tcell.KeyRune:
+			switch evt.Rune() {
+			case 's':
+				v.mu.Lock()
+				v.newest = !v.newest
+				v.page = 0
+				v.mu.Unlock()
+
+				go v.refresh()
+			case 'r':
+				go v.refresh()
+			case 'b':
+				v.mgr.PopView()
+			}
+		}
+	}
+}
+
+func (v *WorkshopView) ProcessMessage(from *net.Client, p interface{}) interface{} {
+	return nil
+}
+
+func (v *WorkshopView) Render(s *Screen) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	s.ClearContent()
+
+	width, height := s.displaySize()
+
+	sty := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	dimSty := sty.Foreground(tcell.ColorDarkGreen)
+
+	s.DrawBlockText(CenterX, 1, sty, "WORKSHOP", false)
+
+	x1, y1 := mapsListX-13, mapsListY-1
+	x2, y2 := mapsListX+mapsListWidth+12, mapsListY+2+workshopPageSize
+
+	s.DrawBox(x1, y1, x2, y2, sty, false)
+
+	sort := "Most downloaded"
+
+	if v.newest {
+		sort = "Newest"
+	}
+
+	s.DrawText(x1+2, y1+1, sty, sort)
+
+	if v.pages > 0 {
+		page := fmt.Sprintf("Page %d of %d", v.page+1, v.pages)
+		s.DrawText(x2-1-layout.Width(page), y1+1, sty, page)
+	}
+
+	switch {
+	case v.errMsg != "":
+		s.DrawText(layout.Center(width, v.errMsg), y1+3, sty, v.errMsg)
+	case !v.loaded:
+		s.DrawText(layout.Center(width, "Looking for maps..."), y1+3, sty, "Looking for maps...")
+	case len(v.items) == 0:
+		msg := "Nobody has published a map yet."
+		s.DrawText(layout.Center(width, msg), y1+3, sty, msg)
+	default:
+		v.focus.Render(s)
+	}
+
+	hint := "Publish your own maps with [P] on the maps screen"
+	s.DrawText(layout.Center(width, hint), y2+2, dimSty, hint)
+
+	s.DrawText(layout.Center(width, workshopFooter), height-2, sty, workshopFooter)
+}
+
+func (v *WorkshopView) Unload() {
+}
+
+func (v *WorkshopView) GetHeartbeatMetadata() encoding.BinaryMarshaler {
+	return nil
+}